@@ -227,6 +227,22 @@ func RemoveFile(id string) error {
 	return nil
 }
 
+// CheckWritable verifies that the cache directory can still be written to. If
+// caching is disabled, no check is performed.
+func CheckWritable() error {
+	if !enabled {
+		return nil
+	}
+
+	f, err := os.CreateTemp(path, ".health-*")
+	if err != nil {
+		return err
+	}
+
+	_ = f.Close()
+	return os.Remove(f.Name())
+}
+
 func init() {
 	if os.Getenv("YEETFILE_STORAGE") == "local" {
 		enabled = false
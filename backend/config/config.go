@@ -29,6 +29,7 @@ var (
 	defaultUserMaxPasswords = utils.GetEnvVarInt("YEETFILE_DEFAULT_MAX_PASSWORDS", -1)
 	defaultUserStorage      = utils.GetEnvVarInt64("YEETFILE_DEFAULT_USER_STORAGE", -1)
 	defaultUserSend         = utils.GetEnvVarInt64("YEETFILE_DEFAULT_USER_SEND", -1)
+	maxTextSendSize         = utils.GetEnvVarInt64("YEETFILE_MAX_TEXT_SEND_SIZE", constants.DefaultMaxTextSendSize)
 	maxNumUsers             = utils.GetEnvVarInt("YEETFILE_MAX_NUM_USERS", -1)
 	password                = []byte(utils.GetEnvVar("YEETFILE_SERVER_PASSWORD", ""))
 	allowInsecureLinks      = utils.GetEnvVarBool("YEETFILE_ALLOW_INSECURE_LINKS", false)
@@ -37,8 +38,15 @@ var (
 	limiterSeconds  = utils.GetEnvVarInt("YEETFILE_LIMITER_SECONDS", 30)
 	limiterAttempts = utils.GetEnvVarInt("YEETFILE_LIMITER_ATTEMPTS", 6)
 
-	defaultSecret     = []byte("yeetfile-debug-secret-key-123456")
+	// Account lockout config
+	lockoutAttempts   = utils.GetEnvVarInt("YEETFILE_LOCKOUT_ATTEMPTS", 5)
+	lockoutSeconds    = utils.GetEnvVarInt("YEETFILE_LOCKOUT_SECONDS", 60)
+	lockoutMaxSeconds = utils.GetEnvVarInt("YEETFILE_LOCKOUT_MAX_SECONDS", 86400)
+
+	defaultSecret     = []byte(utils.DebugServerSecret)
 	secret            = utils.GetEnvVarBytesB64("YEETFILE_SERVER_SECRET", defaultSecret)
+	previousSecrets   = utils.GetEnvVarBytesB64List("YEETFILE_PREVIOUS_SERVER_SECRETS")
+	legacyDecryption  = !utils.GetEnvVarBool("YEETFILE_DISABLE_LEGACY_DECRYPTION", false)
 	fallbackWebSecret = utils.GetEnvVarBytesB64(
 		"YEETFILE_FALLBACK_WEB_SECRET",
 		securecookie.GenerateRandomKey(32))
@@ -46,6 +54,14 @@ var (
 	TLSCert = utils.GetEnvVar("YEETFILE_TLS_CERT", "")
 	TLSKey  = utils.GetEnvVar("YEETFILE_TLS_KEY", "")
 
+	// TLS cert/key file paths (reloaded on SIGHUP or file change), and
+	// optional TLS policy settings
+	TLSCertPath   = utils.GetEnvVar("YEETFILE_TLS_CERT_PATH", "")
+	TLSKeyPath    = utils.GetEnvVar("YEETFILE_TLS_KEY_PATH", "")
+	TLSClientCA   = utils.GetEnvVar("YEETFILE_TLS_CLIENT_CA", "")
+	TLSMinVersion = utils.GetEnvVar("YEETFILE_TLS_MIN_VERSION", "")
+	TLSCiphers    = utils.GetEnvVar("YEETFILE_TLS_CIPHERS", "")
+
 	IsDebugMode   = utils.GetEnvVarBool("YEETFILE_DEBUG", false)
 	IsLockedDown  = utils.GetEnvVarBool("YEETFILE_LOCKDOWN", false)
 	InstanceAdmin = utils.GetEnvVar("YEETFILE_INSTANCE_ADMIN", "")
@@ -75,6 +91,35 @@ var email = EmailConfig{
 	NoReplyAddress: os.Getenv("YEETFILE_EMAIL_NO_REPLY"),
 }
 
+// =============================================================================
+// Single sign-on configuration (OpenID Connect)
+// =============================================================================
+
+type OIDCConfig struct {
+	Configured     bool
+	Issuer         string
+	ClientID       string
+	ClientSecret   string
+	GroupsClaim    string
+	AllowedDomains []string
+	AllowedGroups  []string
+	AdminGroups    []string
+
+	AllowMissingVerified bool
+}
+
+var oidc = OIDCConfig{
+	Issuer:         os.Getenv("YEETFILE_OIDC_ISSUER"),
+	ClientID:       os.Getenv("YEETFILE_OIDC_CLIENT_ID"),
+	ClientSecret:   os.Getenv("YEETFILE_OIDC_CLIENT_SECRET"),
+	GroupsClaim:    utils.GetEnvVar("YEETFILE_OIDC_GROUPS_CLAIM", "groups"),
+	AllowedDomains: utils.GetEnvVarList("YEETFILE_OIDC_ALLOWED_DOMAINS"),
+	AllowedGroups:  utils.GetEnvVarList("YEETFILE_OIDC_ALLOWED_GROUPS"),
+	AdminGroups:    utils.GetEnvVarList("YEETFILE_OIDC_ADMIN_GROUPS"),
+
+	AllowMissingVerified: utils.GetEnvVarBool("YEETFILE_OIDC_ALLOW_MISSING_EMAIL_VERIFIED", false),
+}
+
 // =============================================================================
 // Billing configuration (Stripe)
 // =============================================================================
@@ -108,24 +153,31 @@ var btcPayBilling = BTCPayBillingConfig{
 // =============================================================================
 
 type ServerConfig struct {
-	StorageType         string
-	Domain              string
-	DefaultMaxPasswords int
-	DefaultUserStorage  int64
-	DefaultUserSend     int64
-	MaxUserCount        int
-	CurrentUserCount    int
-	Email               EmailConfig
-	StripeBilling       StripeBillingConfig
-	BTCPayBilling       BTCPayBillingConfig
-	BillingEnabled      bool
-	Version             string
-	PasswordHash        []byte
-	ServerSecret        []byte
-	FallbackWebSecret   []byte
-	AllowInsecureLinks  bool
-	LimiterSeconds      int
-	LimiterAttempts     int
+	StorageType           string
+	Domain                string
+	DefaultMaxPasswords   int
+	DefaultUserStorage    int64
+	DefaultUserSend       int64
+	MaxTextSendSize       int64
+	MaxUserCount          int
+	CurrentUserCount      int
+	Email                 EmailConfig
+	OIDC                  OIDCConfig
+	StripeBilling         StripeBillingConfig
+	BTCPayBilling         BTCPayBillingConfig
+	BillingEnabled        bool
+	Version               string
+	PasswordHash          []byte
+	ServerSecret          []byte
+	PreviousServerSecrets [][]byte
+	LegacyDecryption      bool
+	FallbackWebSecret     []byte
+	AllowInsecureLinks    bool
+	LimiterSeconds        int
+	LimiterAttempts       int
+	LockoutAttempts       int
+	LockoutSeconds        int
+	LockoutMaxSeconds     int
 }
 
 type TemplateConfig struct {
@@ -136,6 +188,7 @@ type TemplateConfig struct {
 	BillingEnabled   bool
 	StripeEnabled    bool
 	BTCPayEnabled    bool
+	SSOEnabled       bool
 }
 
 var YeetFileConfig ServerConfig
@@ -145,6 +198,7 @@ func init() {
 	email.Configured = !utils.IsStructMissingAnyField(email)
 	stripeBilling.Configured = !utils.IsStructMissingAnyField(stripeBilling)
 	btcPayBilling.Configured = !utils.IsStructMissingAnyField(btcPayBilling)
+	oidc.Configured = !utils.IsAnyStringMissing(oidc.Issuer, oidc.ClientID, oidc.ClientSecret)
 
 	var passwordHash []byte
 	var err error
@@ -165,24 +219,55 @@ func init() {
 			"bytes are required.", len(secret), constants.KeySize)
 	}
 
+	for _, previousSecret := range previousSecrets {
+		if len(previousSecret) != constants.KeySize {
+			log.Fatalf("ERROR: YEETFILE_PREVIOUS_SERVER_SECRETS contains a "+
+				"%d byte secret, but %d bytes are required.",
+				len(previousSecret), constants.KeySize)
+		}
+	}
+
+	if lockoutAttempts > 0 && (lockoutSeconds < 1 || lockoutMaxSeconds < lockoutSeconds) {
+		log.Fatalf("ERROR: YEETFILE_LOCKOUT_SECONDS must be at least 1, and " +
+			"YEETFILE_LOCKOUT_MAX_SECONDS can't be less than " +
+			"YEETFILE_LOCKOUT_SECONDS.")
+	}
+
+	if oidc.Configured && len(domain) == 0 {
+		log.Fatalf("ERROR: YEETFILE_DOMAIN is required for the identity " +
+			"provider to redirect users back to YeetFile after SSO login.")
+	}
+
+	if maxTextSendSize < constants.MaxPlaintextLen {
+		log.Fatalf("ERROR: YEETFILE_MAX_TEXT_SEND_SIZE must be at least %d "+
+			"bytes.", constants.MaxPlaintextLen)
+	}
+
 	YeetFileConfig = ServerConfig{
-		StorageType:         storageType,
-		Domain:              domain,
-		DefaultMaxPasswords: defaultUserMaxPasswords,
-		DefaultUserStorage:  defaultUserStorage,
-		DefaultUserSend:     defaultUserSend,
-		MaxUserCount:        maxNumUsers,
-		Email:               email,
-		StripeBilling:       stripeBilling,
-		BTCPayBilling:       btcPayBilling,
-		BillingEnabled:      stripeBilling.Configured || btcPayBilling.Configured,
-		Version:             constants.VERSION,
-		PasswordHash:        passwordHash,
-		ServerSecret:        secret,
-		FallbackWebSecret:   fallbackWebSecret,
-		AllowInsecureLinks:  allowInsecureLinks,
-		LimiterSeconds:      limiterSeconds,
-		LimiterAttempts:     limiterAttempts,
+		StorageType:           storageType,
+		Domain:                domain,
+		DefaultMaxPasswords:   defaultUserMaxPasswords,
+		DefaultUserStorage:    defaultUserStorage,
+		DefaultUserSend:       defaultUserSend,
+		MaxTextSendSize:       maxTextSendSize,
+		MaxUserCount:          maxNumUsers,
+		Email:                 email,
+		OIDC:                  oidc,
+		StripeBilling:         stripeBilling,
+		BTCPayBilling:         btcPayBilling,
+		BillingEnabled:        stripeBilling.Configured || btcPayBilling.Configured,
+		Version:               constants.VERSION,
+		PasswordHash:          passwordHash,
+		ServerSecret:          secret,
+		PreviousServerSecrets: previousSecrets,
+		LegacyDecryption:      legacyDecryption,
+		FallbackWebSecret:     fallbackWebSecret,
+		AllowInsecureLinks:    allowInsecureLinks,
+		LimiterSeconds:        limiterSeconds,
+		LimiterAttempts:       limiterAttempts,
+		LockoutAttempts:       lockoutAttempts,
+		LockoutSeconds:        lockoutSeconds,
+		LockoutMaxSeconds:     lockoutMaxSeconds,
 	}
 
 	// Subset of main server config to use in HTML templating
@@ -193,15 +278,18 @@ func init() {
 		BillingEnabled: YeetFileConfig.BillingEnabled,
 		StripeEnabled:  YeetFileConfig.StripeBilling.Configured,
 		BTCPayEnabled:  YeetFileConfig.BTCPayBilling.Configured,
+		SSOEnabled:     YeetFileConfig.OIDC.Configured,
 	}
 
 	log.Printf("Configuration:\n"+
 		"  Email:            %v\n"+
 		"  Billing (Stripe): %v\n"+
-		"  Billing (BTCPay): %v\n",
+		"  Billing (BTCPay): %v\n"+
+		"  SSO (OIDC):       %v\n",
 		email.Configured,
 		stripeBilling.Configured,
 		btcPayBilling.Configured,
+		oidc.Configured,
 	)
 
 	if IsDebugMode {
@@ -239,6 +327,8 @@ func GetServerInfoStruct() shared.ServerInfo {
 		BTCPayEnabled:      YeetFileConfig.StripeBilling.Configured,
 		DefaultStorage:     YeetFileConfig.DefaultUserStorage,
 		DefaultSend:        YeetFileConfig.DefaultUserSend,
+		MaxTextSize:        YeetFileConfig.MaxTextSendSize,
+		SSOEnabled:         YeetFileConfig.OIDC.Configured,
 
 		Upgrades:      *allUpgrades,
 		MonthUpgrades: upgrades.GetVaultUpgrades(false, allUpgrades.VaultUpgrades),
@@ -20,6 +20,8 @@ const (
 	UpgradeTask    = "upgrade"
 	UpgradeExpTask = "upgrade-expiration"
 	B2AuthTask     = "b2-auth-task"
+	ShareExpTask   = "share-expiration"
+	SecurityTask   = "security-events"
 )
 
 type CronTask struct {
@@ -36,6 +38,9 @@ type CronTask struct {
 // - a bandwidth task for resetting user bandwidth every N days
 // - an upgrade monitoring task for instances with billing enabled
 // - a downloads cleanup task that removes abandoned in-progress downloads
+// - a share expiration task that removes expired vault shares and notifies
+// owners of shares that are about to expire
+// - a security log cleanup task that removes old security events
 var tasks = []CronTask{
 	{
 		Name:           ExpiryTask,
@@ -80,6 +85,20 @@ var tasks = []CronTask{
 		Enabled:        true,
 		TaskFn:         db.CleanUpDownloads,
 	},
+	{
+		Name:           ShareExpTask,
+		Interval:       time.Minute,
+		IntervalAmount: 10,
+		Enabled:        true,
+		TaskFn:         db.CheckShareExpiry,
+	},
+	{
+		Name:           SecurityTask,
+		Interval:       time.Hour,
+		IntervalAmount: 24,
+		Enabled:        true,
+		TaskFn:         db.CleanUpSecurityEvents,
+	},
 	{
 		Name:           B2AuthTask,
 		Interval:       time.Hour,
@@ -0,0 +1,162 @@
+package crypto
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// CBOR major types (RFC 8949)
+const (
+	cborUint       = 0
+	cborNegInt     = 1
+	cborByteString = 2
+	cborTextString = 3
+	cborArray      = 4
+	cborMap        = 5
+	cborSimple     = 7
+)
+
+const maxCBORDepth = 16
+
+var cborTruncatedErr = errors.New("cbor: unexpected end of data")
+
+// decodeCBOR decodes the first CBOR item in data, returning the decoded value
+// and the remaining bytes. Only the subset of CBOR used by WebAuthn
+// authenticators is supported (definite lengths, no tags or floats). Integers
+// are decoded as int64, byte strings as []byte, text strings as string, arrays
+// as []any, and maps as map[any]any.
+func decodeCBOR(data []byte) (any, []byte, error) {
+	return decodeCBORItem(data, 0)
+}
+
+func decodeCBORItem(data []byte, depth int) (any, []byte, error) {
+	if depth > maxCBORDepth {
+		return nil, nil, errors.New("cbor: maximum depth exceeded")
+	} else if len(data) == 0 {
+		return nil, nil, cborTruncatedErr
+	}
+
+	major := data[0] >> 5
+	info := data[0] & 0x1f
+	data = data[1:]
+
+	if major == cborSimple {
+		switch info {
+		case 20:
+			return false, data, nil
+		case 21:
+			return true, data, nil
+		case 22:
+			return nil, data, nil
+		default:
+			return nil, nil, errors.New("cbor: unsupported simple value")
+		}
+	}
+
+	arg, data, err := decodeCBORArgument(info, data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch major {
+	case cborUint:
+		if arg > 1<<63-1 {
+			return nil, nil, errors.New("cbor: integer overflow")
+		}
+		return int64(arg), data, nil
+	case cborNegInt:
+		if arg > 1<<63-1 {
+			return nil, nil, errors.New("cbor: integer overflow")
+		}
+		return -1 - int64(arg), data, nil
+	case cborByteString, cborTextString:
+		if arg > uint64(len(data)) {
+			return nil, nil, cborTruncatedErr
+		}
+
+		value := make([]byte, arg)
+		copy(value, data[:arg])
+		if major == cborTextString {
+			return string(value), data[arg:], nil
+		}
+
+		return value, data[arg:], nil
+	case cborArray:
+		// Every item is at least one byte long
+		if arg > uint64(len(data)) {
+			return nil, nil, cborTruncatedErr
+		}
+
+		items := make([]any, 0, arg)
+		for i := uint64(0); i < arg; i++ {
+			var item any
+			item, data, err = decodeCBORItem(data, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			items = append(items, item)
+		}
+
+		return items, data, nil
+	case cborMap:
+		if arg > uint64(len(data)) {
+			return nil, nil, cborTruncatedErr
+		}
+
+		items := make(map[any]any, arg)
+		for i := uint64(0); i < arg; i++ {
+			var key, value any
+			key, data, err = decodeCBORItem(data, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, nil, errors.New("cbor: unsupported map key type")
+			}
+
+			value, data, err = decodeCBORItem(data, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			items[key] = value
+		}
+
+		return items, data, nil
+	default:
+		return nil, nil, errors.New("cbor: unsupported major type")
+	}
+}
+
+func decodeCBORArgument(info byte, data []byte) (uint64, []byte, error) {
+	switch {
+	case info < 24:
+		return uint64(info), data, nil
+	case info == 24:
+		if len(data) < 1 {
+			return 0, nil, cborTruncatedErr
+		}
+		return uint64(data[0]), data[1:], nil
+	case info == 25:
+		if len(data) < 2 {
+			return 0, nil, cborTruncatedErr
+		}
+		return uint64(binary.BigEndian.Uint16(data)), data[2:], nil
+	case info == 26:
+		if len(data) < 4 {
+			return 0, nil, cborTruncatedErr
+		}
+		return uint64(binary.BigEndian.Uint32(data)), data[4:], nil
+	case info == 27:
+		if len(data) < 8 {
+			return 0, nil, cborTruncatedErr
+		}
+		return binary.BigEndian.Uint64(data), data[8:], nil
+	default:
+		return 0, nil, errors.New("cbor: indefinite lengths aren't supported")
+	}
+}
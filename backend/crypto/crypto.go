@@ -1,50 +1,190 @@
 package crypto
 
 import (
+	"bytes"
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
 	"errors"
 	"io"
 	"yeetfile/backend/config"
 )
 
+const (
+	// aeadVersion is the first byte of values encrypted with AES-GCM. It's
+	// followed by the ID of the server secret used to encrypt the value, so
+	// that values can still be decrypted after the secret is rotated.
+	aeadVersion = 1
+	keyIDSize   = 4
+	headerSize  = 1 + keyIDSize
+)
+
+var UnknownKeyError = errors.New("value was encrypted with an unknown server secret")
+var LegacyDisabledError = errors.New("decrypting values encrypted with AES-CFB is disabled")
+
+// KeyID returns the ID of a server secret, which is prefixed to every value
+// encrypted with the secret
+func KeyID(key []byte) []byte {
+	hash := sha256.Sum256(key)
+	return hash[:keyIDSize]
+}
+
+// HMAC returns an HMAC-SHA256 of the data using the current server secret
+func HMAC(data []byte) []byte {
+	mac := hmac.New(sha256.New, config.YeetFileConfig.ServerSecret)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// Encrypt encrypts a value with AES-GCM using the current server secret
 func Encrypt(text string) ([]byte, error) {
-	block, err := aes.NewCipher(config.YeetFileConfig.ServerSecret)
+	return encryptWithKey(config.YeetFileConfig.ServerSecret, []byte(text))
+}
+
+// Decrypt decrypts a value encrypted by Encrypt, using either the current or
+// one of the previous server secrets. Values encrypted with AES-CFB before
+// server secrets could be rotated are also supported, unless legacy decryption
+// has been disabled.
+func Decrypt(data []byte) (string, error) {
+	keys := serverSecrets()
+	if hasAEADHeader(data, keys) {
+		plaintext, err := decryptAEAD(data, keys)
+		if err != nil {
+			return "", err
+		}
+
+		return string(plaintext), nil
+	} else if !config.YeetFileConfig.LegacyDecryption {
+		return "", LegacyDisabledError
+	}
+
+	// Legacy values start with a random IV, which could begin with the AES-GCM
+	// version byte. The value is only treated as legacy if that isn't
+	// followed by the ID of a known server secret.
+	plaintext, err := decryptLegacy(data, keys)
+	if err != nil && len(data) > 0 && data[0] == aeadVersion {
+		return "", UnknownKeyError
+	}
+
+	return plaintext, err
+}
+
+// IsCurrentKey returns true if the value was encrypted with AES-GCM using the
+// current server secret, and doesn't need to be re-encrypted
+func IsCurrentKey(data []byte) bool {
+	if len(data) < headerSize || data[0] != aeadVersion {
+		return false
+	}
+
+	currentID := KeyID(config.YeetFileConfig.ServerSecret)
+	return bytes.Equal(data[1:headerSize], currentID)
+}
+
+// hasAEADHeader returns true if the value starts with the AES-GCM version byte
+// followed by the ID of one of the server secrets
+func hasAEADHeader(data []byte, keys [][]byte) bool {
+	if len(data) < headerSize || data[0] != aeadVersion {
+		return false
+	}
+
+	for _, key := range keys {
+		if bytes.Equal(data[1:headerSize], KeyID(key)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func serverSecrets() [][]byte {
+	keys := [][]byte{config.YeetFileConfig.ServerSecret}
+	return append(keys, config.YeetFileConfig.PreviousServerSecrets...)
+}
+
+func encryptWithKey(key, plaintext []byte) ([]byte, error) {
+	aesgcm, err := newGCM(key)
 	if err != nil {
 		return nil, err
 	}
 
-	b64Text := base64.StdEncoding.EncodeToString([]byte(text))
-	ciphertext := make([]byte, aes.BlockSize+len(b64Text))
-	iv := ciphertext[:aes.BlockSize]
-	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+	header := append([]byte{aeadVersion}, KeyID(key)...)
+	nonce := make([]byte, aesgcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
 		return nil, err
 	}
 
-	cfb := cipher.NewCFBEncrypter(block, iv)
-	cfb.XORKeyStream(ciphertext[aes.BlockSize:], []byte(b64Text))
-	return ciphertext, nil
+	ciphertext := aesgcm.Seal(nil, nonce, plaintext, header)
+
+	result := make([]byte, 0, len(header)+len(nonce)+len(ciphertext))
+	result = append(result, header...)
+	result = append(result, nonce...)
+	return append(result, ciphertext...), nil
 }
 
-func Decrypt(data []byte) (string, error) {
-	block, err := aes.NewCipher(config.YeetFileConfig.ServerSecret)
-	if err != nil {
-		return "", err
+func decryptAEAD(data []byte, keys [][]byte) ([]byte, error) {
+	if len(data) < headerSize || data[0] != aeadVersion {
+		return nil, errors.New("value isn't encrypted with AES-GCM")
 	}
 
+	header := data[:headerSize]
+	for _, key := range keys {
+		if !bytes.Equal(header[1:], KeyID(key)) {
+			continue
+		}
+
+		aesgcm, err := newGCM(key)
+		if err != nil {
+			return nil, err
+		}
+
+		data = data[headerSize:]
+		if len(data) < aesgcm.NonceSize() {
+			return nil, errors.New("ciphertext too short")
+		}
+
+		nonce := data[:aesgcm.NonceSize()]
+		return aesgcm.Open(nil, nonce, data[aesgcm.NonceSize():], header)
+	}
+
+	return nil, UnknownKeyError
+}
+
+// decryptLegacy decrypts a value that was encrypted with AES-CFB. Since there's
+// no authentication, each server secret is tried until the result is valid
+// base64 (which is how values were encoded before encryption).
+func decryptLegacy(data []byte, keys [][]byte) (string, error) {
 	if len(data) < aes.BlockSize {
 		return "", errors.New("ciphertext too short")
 	}
 
 	iv := data[:aes.BlockSize]
-	data = data[aes.BlockSize:]
-	cfb := cipher.NewCFBDecrypter(block, iv)
-	cfb.XORKeyStream(data, data)
-	value, err := base64.StdEncoding.DecodeString(string(data))
+	for _, key := range keys {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return "", err
+		}
+
+		decrypted := make([]byte, len(data)-aes.BlockSize)
+		cfb := cipher.NewCFBDecrypter(block, iv)
+		cfb.XORKeyStream(decrypted, data[aes.BlockSize:])
+
+		value, err := base64.StdEncoding.DecodeString(string(decrypted))
+		if err == nil {
+			return string(value), nil
+		}
+	}
+
+	return "", errors.New("unable to decrypt value")
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	return string(value), nil
+
+	return cipher.NewGCM(block)
 }
@@ -1,8 +1,13 @@
 package crypto
 
 import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"yeetfile/backend/config"
 )
 
 func TestEncryptDecrypt(t *testing.T) {
@@ -17,3 +22,90 @@ func TestEncryptDecrypt(t *testing.T) {
 
 	assert.Equal(t, text, decryptedVal)
 }
+
+// encryptLegacy encrypts a value the way it was encrypted before server secrets
+// could be rotated
+func encryptLegacy(key []byte, text string) []byte {
+	block, _ := aes.NewCipher(key)
+	b64Text := base64.StdEncoding.EncodeToString([]byte(text))
+	ciphertext := make([]byte, aes.BlockSize+len(b64Text))
+	iv := ciphertext[:aes.BlockSize]
+	_, _ = rand.Read(iv)
+
+	cfb := cipher.NewCFBEncrypter(block, iv)
+	cfb.XORKeyStream(ciphertext[aes.BlockSize:], []byte(b64Text))
+	return ciphertext
+}
+
+func TestDecryptTampered(t *testing.T) {
+	encryptedVal, err := Encrypt("yeetfile")
+	assert.Nil(t, err)
+
+	encryptedVal[len(encryptedVal)-1] ^= 1
+	_, err = Decrypt(encryptedVal)
+	assert.NotNil(t, err)
+}
+
+func TestDecryptLegacy(t *testing.T) {
+	text := "my password hint"
+	encryptedVal := encryptLegacy(config.YeetFileConfig.ServerSecret, text)
+	assert.False(t, IsCurrentKey(encryptedVal))
+
+	decryptedVal, err := Decrypt(encryptedVal)
+	assert.Nil(t, err)
+	assert.Equal(t, text, decryptedVal)
+
+	config.YeetFileConfig.LegacyDecryption = false
+	defer func() {
+		config.YeetFileConfig.LegacyDecryption = true
+	}()
+
+	_, err = Decrypt(encryptedVal)
+	assert.Equal(t, LegacyDisabledError, err)
+
+	// Values encrypted with AES-GCM are unaffected
+	encryptedVal, err = Encrypt(text)
+	assert.Nil(t, err)
+
+	decryptedVal, err = Decrypt(encryptedVal)
+	assert.Nil(t, err)
+	assert.Equal(t, text, decryptedVal)
+}
+
+func TestRotateServerSecret(t *testing.T) {
+	previousSecret := config.YeetFileConfig.ServerSecret
+	defer func() {
+		config.YeetFileConfig.ServerSecret = previousSecret
+		config.YeetFileConfig.PreviousServerSecrets = nil
+	}()
+
+	text := "yeetfile"
+	encryptedVal, err := Encrypt(text)
+	assert.Nil(t, err)
+	assert.True(t, IsCurrentKey(encryptedVal))
+
+	legacyVal := encryptLegacy(previousSecret, text)
+
+	newSecret := make([]byte, len(previousSecret))
+	_, _ = rand.Read(newSecret)
+	config.YeetFileConfig.ServerSecret = newSecret
+
+	// Values encrypted with a secret that isn't configured can't be decrypted
+	_, err = Decrypt(encryptedVal)
+	assert.NotNil(t, err)
+
+	config.YeetFileConfig.PreviousServerSecrets = [][]byte{previousSecret}
+	assert.False(t, IsCurrentKey(encryptedVal))
+
+	decryptedVal, err := Decrypt(encryptedVal)
+	assert.Nil(t, err)
+	assert.Equal(t, text, decryptedVal)
+
+	decryptedVal, err = Decrypt(legacyVal)
+	assert.Nil(t, err)
+	assert.Equal(t, text, decryptedVal)
+
+	reencryptedVal, err := Encrypt(decryptedVal)
+	assert.Nil(t, err)
+	assert.True(t, IsCurrentKey(reencryptedVal))
+}
@@ -0,0 +1,338 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/url"
+)
+
+// COSE algorithm identifiers supported for WebAuthn credentials
+const (
+	COSEAlgES256 = -7
+	COSEAlgEdDSA = -8
+	COSEAlgRS256 = -257
+)
+
+// WebAuthnAlgorithms are the COSE algorithms accepted during registration, in
+// order of preference
+var WebAuthnAlgorithms = []int{COSEAlgES256, COSEAlgEdDSA, COSEAlgRS256}
+
+const (
+	webAuthnCreate = "webauthn.create"
+	webAuthnGet    = "webauthn.get"
+
+	authDataMinLen = 37
+
+	flagUserPresent            = 0x01
+	flagAttestedCredentialData = 0x40
+)
+
+var (
+	WebAuthnChallengeErr = errors.New("webauthn challenge mismatch")
+	WebAuthnOriginErr    = errors.New("webauthn origin mismatch")
+	WebAuthnRPIDErr      = errors.New("webauthn relying party ID mismatch")
+	WebAuthnSignatureErr = errors.New("webauthn signature is invalid")
+	WebAuthnCounterErr   = errors.New("webauthn signature counter didn't increase")
+)
+
+// RelyingParty identifies the server to WebAuthn authenticators. If Origin is
+// empty, any origin with a hostname matching ID is accepted, which is only
+// intended for development servers that don't have a domain configured.
+type RelyingParty struct {
+	ID     string
+	Origin string
+}
+
+// WebAuthnCredential is a public key credential created by an authenticator.
+// PublicKey is the COSE-encoded key from the authenticator data.
+type WebAuthnCredential struct {
+	ID        []byte
+	PublicKey []byte
+	SignCount uint32
+}
+
+type clientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+type authenticatorData struct {
+	rpIDHash     []byte
+	flags        byte
+	signCount    uint32
+	credentialID []byte
+	publicKey    []byte
+}
+
+// VerifyRegistration validates the response from navigator.credentials.create
+// and returns the new credential. Attestation statements aren't verified,
+// since any authenticator is allowed to be registered.
+func (rp RelyingParty) VerifyRegistration(
+	challenge,
+	clientDataJSON,
+	attestationObject []byte,
+) (WebAuthnCredential, error) {
+	err := rp.verifyClientData(clientDataJSON, webAuthnCreate, challenge)
+	if err != nil {
+		return WebAuthnCredential{}, err
+	}
+
+	decoded, _, err := decodeCBOR(attestationObject)
+	if err != nil {
+		return WebAuthnCredential{}, err
+	}
+
+	attestation, ok := decoded.(map[any]any)
+	if !ok {
+		return WebAuthnCredential{}, errors.New("invalid attestation object")
+	}
+
+	rawAuthData, ok := attestation["authData"].([]byte)
+	if !ok {
+		return WebAuthnCredential{}, errors.New("missing authenticator data")
+	}
+
+	authData, err := rp.verifyAuthenticatorData(rawAuthData)
+	if err != nil {
+		return WebAuthnCredential{}, err
+	} else if authData.flags&flagAttestedCredentialData == 0 {
+		return WebAuthnCredential{}, errors.New("missing attested credential data")
+	}
+
+	// Ensure the key can be used before storing it
+	_, err = parseCOSEKey(authData.publicKey)
+	if err != nil {
+		return WebAuthnCredential{}, err
+	}
+
+	return WebAuthnCredential{
+		ID:        authData.credentialID,
+		PublicKey: authData.publicKey,
+		SignCount: authData.signCount,
+	}, nil
+}
+
+// VerifyAssertion validates the response from navigator.credentials.get using
+// a previously registered credential, and returns the authenticator's updated
+// signature counter.
+func (rp RelyingParty) VerifyAssertion(
+	credential WebAuthnCredential,
+	challenge,
+	clientDataJSON,
+	rawAuthData,
+	signature []byte,
+) (uint32, error) {
+	err := rp.verifyClientData(clientDataJSON, webAuthnGet, challenge)
+	if err != nil {
+		return 0, err
+	}
+
+	authData, err := rp.verifyAuthenticatorData(rawAuthData)
+	if err != nil {
+		return 0, err
+	}
+
+	publicKey, err := parseCOSEKey(credential.PublicKey)
+	if err != nil {
+		return 0, err
+	}
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signedData := append(bytes.Clone(rawAuthData), clientDataHash[:]...)
+	if !verifyCOSESignature(publicKey, signedData, signature) {
+		return 0, WebAuthnSignatureErr
+	}
+
+	// Authenticators that don't support counters always return 0, otherwise
+	// the counter has to increase to detect cloned authenticators
+	if (authData.signCount != 0 || credential.SignCount != 0) &&
+		authData.signCount <= credential.SignCount {
+		return 0, WebAuthnCounterErr
+	}
+
+	return authData.signCount, nil
+}
+
+func (rp RelyingParty) verifyClientData(
+	clientDataJSON []byte,
+	ceremony string,
+	challenge []byte,
+) error {
+	var data clientData
+	err := json.Unmarshal(clientDataJSON, &data)
+	if err != nil {
+		return err
+	} else if data.Type != ceremony {
+		return errors.New("unexpected webauthn ceremony type")
+	}
+
+	received, err := base64.RawURLEncoding.DecodeString(data.Challenge)
+	if err != nil || len(challenge) == 0 || !bytes.Equal(received, challenge) {
+		return WebAuthnChallengeErr
+	} else if !rp.validOrigin(data.Origin) {
+		return WebAuthnOriginErr
+	}
+
+	return nil
+}
+
+func (rp RelyingParty) validOrigin(origin string) bool {
+	if len(rp.Origin) > 0 {
+		return origin == rp.Origin
+	}
+
+	originURL, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+
+	return originURL.Hostname() == rp.ID
+}
+
+func (rp RelyingParty) verifyAuthenticatorData(data []byte) (authenticatorData, error) {
+	authData, err := parseAuthenticatorData(data)
+	if err != nil {
+		return authenticatorData{}, err
+	}
+
+	rpIDHash := sha256.Sum256([]byte(rp.ID))
+	if !bytes.Equal(authData.rpIDHash, rpIDHash[:]) {
+		return authenticatorData{}, WebAuthnRPIDErr
+	} else if authData.flags&flagUserPresent == 0 {
+		return authenticatorData{}, errors.New("user presence wasn't verified")
+	}
+
+	return authData, nil
+}
+
+// parseAuthenticatorData parses the authenticator data structure described in
+// https://www.w3.org/TR/webauthn-2/#sctn-authenticator-data
+func parseAuthenticatorData(data []byte) (authenticatorData, error) {
+	if len(data) < authDataMinLen {
+		return authenticatorData{}, errors.New("authenticator data is too short")
+	}
+
+	authData := authenticatorData{
+		rpIDHash:  data[:32],
+		flags:     data[32],
+		signCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+
+	if authData.flags&flagAttestedCredentialData == 0 {
+		return authData, nil
+	}
+
+	// AAGUID (16 bytes) followed by the credential ID length (2 bytes)
+	data = data[authDataMinLen:]
+	if len(data) < 18 {
+		return authenticatorData{}, errors.New("attested credential data is too short")
+	}
+
+	idLen := int(binary.BigEndian.Uint16(data[16:18]))
+	data = data[18:]
+	if idLen == 0 || len(data) < idLen {
+		return authenticatorData{}, errors.New("invalid credential ID length")
+	}
+
+	authData.credentialID = bytes.Clone(data[:idLen])
+	data = data[idLen:]
+
+	// The public key is followed by optional extension data, so it needs to
+	// be decoded to find out how long it is
+	_, rest, err := decodeCBOR(data)
+	if err != nil {
+		return authenticatorData{}, err
+	}
+
+	authData.publicKey = bytes.Clone(data[:len(data)-len(rest)])
+	return authData, nil
+}
+
+// parseCOSEKey converts a COSE-encoded public key (RFC 8152) into an
+// *ecdsa.PublicKey, ed25519.PublicKey, or *rsa.PublicKey
+func parseCOSEKey(data []byte) (crypto.PublicKey, error) {
+	decoded, rest, err := decodeCBOR(data)
+	if err != nil {
+		return nil, err
+	} else if len(rest) > 0 {
+		return nil, errors.New("unexpected data after public key")
+	}
+
+	key, ok := decoded.(map[any]any)
+	if !ok {
+		return nil, errors.New("invalid public key")
+	}
+
+	kty, _ := key[int64(1)].(int64)
+	alg, _ := key[int64(3)].(int64)
+	crv, _ := key[int64(-1)].(int64)
+
+	switch {
+	case kty == 2 && alg == COSEAlgES256 && crv == 1:
+		x, _ := key[int64(-2)].([]byte)
+		y, _ := key[int64(-3)].([]byte)
+		if len(x) != 32 || len(y) != 32 {
+			return nil, errors.New("invalid P-256 public key")
+		}
+
+		// Ensures the point is on the curve
+		point := append([]byte{0x04}, append(x, y...)...)
+		if _, err := ecdh.P256().NewPublicKey(point); err != nil {
+			return nil, err
+		}
+
+		return &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}, nil
+	case kty == 1 && alg == COSEAlgEdDSA && crv == 6:
+		x, _ := key[int64(-2)].([]byte)
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 public key")
+		}
+
+		return ed25519.PublicKey(x), nil
+	case kty == 3 && alg == COSEAlgRS256:
+		n, _ := key[int64(-1)].([]byte)
+		e, _ := key[int64(-2)].([]byte)
+		if len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return nil, errors.New("invalid RSA public key")
+		}
+
+		exponent := new(big.Int).SetBytes(e)
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(exponent.Int64()),
+		}, nil
+	default:
+		return nil, errors.New("unsupported public key algorithm")
+	}
+}
+
+func verifyCOSESignature(publicKey crypto.PublicKey, data, signature []byte) bool {
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		hash := sha256.Sum256(data)
+		return ecdsa.VerifyASN1(key, hash[:], signature)
+	case ed25519.PublicKey:
+		return ed25519.Verify(key, data, signature)
+	case *rsa.PublicKey:
+		hash := sha256.Sum256(data)
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, hash[:], signature) == nil
+	default:
+		return false
+	}
+}
@@ -0,0 +1,122 @@
+package crypto
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"yeetfile/backend/crypto/webauthntest"
+)
+
+var testRP = RelyingParty{ID: "localhost", Origin: "http://localhost:8090"}
+
+func newChallenge() []byte {
+	challenge := make([]byte, 32)
+	_, _ = rand.Read(challenge)
+	return challenge
+}
+
+func registerAuthenticator(
+	t *testing.T,
+	key crypto.Signer,
+) (*webauthntest.Authenticator, WebAuthnCredential) {
+	authenticator := webauthntest.NewAuthenticator(key)
+	challenge := newChallenge()
+	clientData, attestation := authenticator.Create(testRP.ID, testRP.Origin, challenge)
+
+	credential, err := testRP.VerifyRegistration(challenge, clientData, attestation)
+	assert.Nil(t, err)
+	assert.Equal(t, authenticator.CredentialID, credential.ID)
+	return authenticator, credential
+}
+
+func TestWebAuthnES256(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	authenticator, credential := registerAuthenticator(t, key)
+
+	challenge := newChallenge()
+	clientData, authData, signature := authenticator.Get(testRP.ID, testRP.Origin, challenge)
+	signCount, err := testRP.VerifyAssertion(
+		credential, challenge, clientData, authData, signature)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(1), signCount)
+
+	// Replaying the assertion should fail
+	credential.SignCount = signCount
+	_, err = testRP.VerifyAssertion(
+		credential, challenge, clientData, authData, signature)
+	assert.Equal(t, WebAuthnCounterErr, err)
+
+	// Tampered signatures should fail
+	challenge = newChallenge()
+	clientData, authData, signature = authenticator.Get(testRP.ID, testRP.Origin, challenge)
+	signature[len(signature)-1] ^= 1
+	_, err = testRP.VerifyAssertion(
+		credential, challenge, clientData, authData, signature)
+	assert.Equal(t, WebAuthnSignatureErr, err)
+}
+
+func TestWebAuthnEdDSA(t *testing.T) {
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	authenticator, credential := registerAuthenticator(t, key)
+
+	challenge := newChallenge()
+	clientData, authData, signature := authenticator.Get(testRP.ID, testRP.Origin, challenge)
+	_, err := testRP.VerifyAssertion(
+		credential, challenge, clientData, authData, signature)
+	assert.Nil(t, err)
+}
+
+func TestWebAuthnInvalidAssertion(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	authenticator, credential := registerAuthenticator(t, key)
+
+	// Wrong challenge
+	clientData, authData, signature := authenticator.Get(testRP.ID, testRP.Origin, newChallenge())
+	_, err := testRP.VerifyAssertion(
+		credential, newChallenge(), clientData, authData, signature)
+	assert.Equal(t, WebAuthnChallengeErr, err)
+
+	// Wrong origin
+	challenge := newChallenge()
+	clientData, authData, signature = authenticator.Get(testRP.ID, "https://example.com", challenge)
+	_, err = testRP.VerifyAssertion(
+		credential, challenge, clientData, authData, signature)
+	assert.Equal(t, WebAuthnOriginErr, err)
+
+	// Wrong relying party
+	otherRP := RelyingParty{ID: "example.com", Origin: testRP.Origin}
+	challenge = newChallenge()
+	clientData, authData, signature = authenticator.Get(testRP.ID, testRP.Origin, challenge)
+	_, err = otherRP.VerifyAssertion(
+		credential, challenge, clientData, authData, signature)
+	assert.Equal(t, WebAuthnRPIDErr, err)
+
+	// Registration responses can't be used as assertions
+	challenge = newChallenge()
+	clientData, _ = authenticator.Create(testRP.ID, testRP.Origin, challenge)
+	_, err = testRP.VerifyAssertion(
+		credential, challenge, clientData, authData, signature)
+	assert.NotNil(t, err)
+}
+
+func TestWebAuthnDevOrigin(t *testing.T) {
+	devRP := RelyingParty{ID: "localhost"}
+	assert.True(t, devRP.validOrigin("http://localhost:8090"))
+	assert.False(t, devRP.validOrigin("http://localhost.example.com"))
+}
+
+func TestDecodeCBORTruncated(t *testing.T) {
+	data := webauthntest.EncodeCBOR([][2]any{{"authData", make([]byte, 64)}})
+	for i := 0; i < len(data); i++ {
+		_, _, err := decodeCBOR(data[:i])
+		assert.NotNil(t, err)
+	}
+
+	_, rest, err := decodeCBOR(data)
+	assert.Nil(t, err)
+	assert.Empty(t, rest)
+}
@@ -0,0 +1,175 @@
+// Package webauthntest provides a software WebAuthn authenticator, which
+// registers credentials with "none" attestation and signs assertions with an
+// ES256 or EdDSA key. It's intended for tests only.
+package webauthntest
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+)
+
+const (
+	coseAlgES256 = -7
+	coseAlgEdDSA = -8
+
+	flagUserPresent            = 0x01
+	flagAttestedCredentialData = 0x40
+)
+
+// CBOR major types (RFC 8949)
+const (
+	cborUint       = 0
+	cborNegInt     = 1
+	cborByteString = 2
+	cborTextString = 3
+	cborMap        = 5
+)
+
+// Authenticator is a software authenticator with a single credential
+type Authenticator struct {
+	CredentialID []byte
+	SignCount    uint32
+
+	key crypto.Signer
+}
+
+// NewAuthenticator returns an authenticator for the provided key, which must
+// be either a P-256 *ecdsa.PrivateKey or an ed25519.PrivateKey
+func NewAuthenticator(key crypto.Signer) *Authenticator {
+	credentialID := make([]byte, 16)
+	_, _ = rand.Read(credentialID)
+	return &Authenticator{CredentialID: credentialID, key: key}
+}
+
+// NewES256Authenticator returns an authenticator with a new P-256 key
+func NewES256Authenticator() *Authenticator {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	return NewAuthenticator(key)
+}
+
+// Create returns the client data JSON and attestation object for registering
+// the authenticator's credential with the relying party
+func (a *Authenticator) Create(rpID, origin string, challenge []byte) ([]byte, []byte) {
+	attestationObject := EncodeCBOR([][2]any{
+		{"fmt", "none"},
+		{"attStmt", [][2]any{}},
+		{"authData", a.authData(rpID, true)},
+	})
+
+	return ClientDataJSON("webauthn.create", challenge, origin), attestationObject
+}
+
+// Get increments the sign count and returns the client data JSON,
+// authenticator data, and signature for an assertion of the challenge
+func (a *Authenticator) Get(rpID, origin string, challenge []byte) ([]byte, []byte, []byte) {
+	a.SignCount += 1
+	clientData := ClientDataJSON("webauthn.get", challenge, origin)
+	authData := a.authData(rpID, false)
+
+	clientDataHash := sha256.Sum256(clientData)
+	signedData := append(authData, clientDataHash[:]...)
+
+	var signature []byte
+	switch key := a.key.(type) {
+	case *ecdsa.PrivateKey:
+		hash := sha256.Sum256(signedData)
+		signature, _ = ecdsa.SignASN1(rand.Reader, key, hash[:])
+	case ed25519.PrivateKey:
+		signature = ed25519.Sign(key, signedData)
+	}
+
+	return clientData, authData, signature
+}
+
+// ClientDataJSON returns the client data that a browser would pass to the
+// authenticator for the ceremony ("webauthn.create" or "webauthn.get")
+func ClientDataJSON(ceremony string, challenge []byte, origin string) []byte {
+	data, _ := json.Marshal(map[string]string{
+		"type":      ceremony,
+		"challenge": base64.RawURLEncoding.EncodeToString(challenge),
+		"origin":    origin,
+	})
+	return data
+}
+
+func (a *Authenticator) authData(rpID string, attested bool) []byte {
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	flags := byte(flagUserPresent)
+	if attested {
+		flags |= flagAttestedCredentialData
+	}
+
+	data := append(rpIDHash[:], flags)
+	data = binary.BigEndian.AppendUint32(data, a.SignCount)
+	if attested {
+		data = append(data, make([]byte, 16)...) // AAGUID
+		data = binary.BigEndian.AppendUint16(data, uint16(len(a.CredentialID)))
+		data = append(data, a.CredentialID...)
+		data = append(data, a.coseKey()...)
+	}
+
+	return data
+}
+
+func (a *Authenticator) coseKey() []byte {
+	switch key := a.key.(type) {
+	case *ecdsa.PrivateKey:
+		x := key.X.FillBytes(make([]byte, 32))
+		y := key.Y.FillBytes(make([]byte, 32))
+		return EncodeCBOR([][2]any{
+			{1, 2}, {3, coseAlgES256}, {-1, 1}, {-2, x}, {-3, y},
+		})
+	case ed25519.PrivateKey:
+		return EncodeCBOR([][2]any{
+			{1, 1}, {3, coseAlgEdDSA}, {-1, 6}, {-2, []byte(key.Public().(ed25519.PublicKey))},
+		})
+	default:
+		panic("unsupported key")
+	}
+}
+
+// EncodeCBOR encodes ints, byte strings, text strings, and maps (as ordered
+// key/value pairs)
+func EncodeCBOR(value any) []byte {
+	switch v := value.(type) {
+	case int:
+		if v < 0 {
+			return cborHead(cborNegInt, uint64(-1-v))
+		}
+		return cborHead(cborUint, uint64(v))
+	case []byte:
+		return append(cborHead(cborByteString, uint64(len(v))), v...)
+	case string:
+		return append(cborHead(cborTextString, uint64(len(v))), v...)
+	case [][2]any:
+		result := cborHead(cborMap, uint64(len(v)))
+		for _, pair := range v {
+			result = append(result, EncodeCBOR(pair[0])...)
+			result = append(result, EncodeCBOR(pair[1])...)
+		}
+		return result
+	default:
+		panic("unsupported cbor value")
+	}
+}
+
+// cborHead encodes the initial bytes of a CBOR item
+func cborHead(major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return []byte{major<<5 | byte(n)}
+	case n < 1<<8:
+		return []byte{major<<5 | 24, byte(n)}
+	case n < 1<<16:
+		return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32([]byte{major<<5 | 26}, uint32(n))
+	}
+}
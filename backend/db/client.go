@@ -1,6 +1,7 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"embed"
 	_ "embed"
@@ -17,6 +18,14 @@ import (
 
 var db *sql.DB
 
+// dbConn is implemented by both *sql.DB and *sql.Tx, so that queries can be
+// run either on their own or as part of a transaction
+type dbConn interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
 //go:embed scripts/migrations/*.sql
 var migrationScripts embed.FS
 
@@ -73,7 +82,7 @@ func init() {
 			"Error: %v\nPing: %v\n", err, ping)
 	}
 
-	version, err := getMigrationVersion()
+	version, err := getMigrationVersion(context.Background())
 	if err != nil {
 		version = -1
 	}
@@ -147,6 +156,12 @@ func ClearDatabase(id string) {
 		log.Printf("Failed to delete expiry fields for %s\n", id)
 	}
 
+	if DeleteSendRecipients(id) {
+		log.Printf("%s send recipients deleted\n", id)
+	} else {
+		log.Printf("Failed to delete send recipients for %s\n", id)
+	}
+
 	if AdminDeleteFile(id) == nil {
 		log.Printf("%s deleted from vault\n", id)
 	} else {
@@ -172,6 +187,11 @@ func TableIDExists(tableName, id string) bool {
 	return false
 }
 
+// Ping verifies that the database connection is still alive
+func Ping(ctx context.Context) error {
+	return db.PingContext(ctx)
+}
+
 func Close() {
 	log.Println("Closing DB connection")
 	err := db.Close()
@@ -0,0 +1,247 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+	"yeetfile/backend/config"
+	"yeetfile/shared"
+	"yeetfile/shared/constants"
+)
+
+const emergencyAccessIDLength = 16
+
+var (
+	EmergencyAccessNotFoundError   = errors.New("emergency access not found")
+	EmergencyAccessNotGrantedError = errors.New("emergency access hasn't been granted")
+	EmergencyAccessExistsError     = errors.New("user is already an emergency contact")
+	EmergencyAccessKeyError        = errors.New("emergency access key needs to be re-confirmed by the owner")
+)
+
+// emergencyAccessStatus returns the status of an emergency access grant. The
+// status isn't stored, since a request is granted as soon as the waiting period
+// ends without the owner denying it. In debug mode, the waiting period is
+// counted in seconds instead of days so that granted access can be tested.
+func emergencyAccessStatus(requested sql.NullTime, waitDays int) string {
+	if !requested.Valid {
+		return constants.EmergencyStatusIdle
+	}
+
+	waitUnit := 24 * time.Hour
+	if config.IsDebugMode {
+		waitUnit = time.Second
+	}
+
+	waitPeriod := time.Duration(waitDays) * waitUnit
+	if time.Now().UTC().Before(requested.Time.Add(waitPeriod)) {
+		return constants.EmergencyStatusRequested
+	}
+
+	return constants.EmergencyStatusGranted
+}
+
+// NewEmergencyContact designates a user as an emergency contact for the owner.
+// The protected key is the owner's private key encrypted with the contact's
+// public key.
+func NewEmergencyContact(
+	ownerID,
+	contactID,
+	accessType string,
+	waitDays int,
+	protectedKey []byte,
+) (string, error) {
+	if ownerID == contactID {
+		return "", errors.New("cannot add yourself as an emergency contact")
+	} else if len(protectedKey) == 0 {
+		return "", errors.New("missing protected key")
+	}
+
+	var exists bool
+	s := `SELECT EXISTS(SELECT 1 FROM emergency_access
+	                   WHERE owner_id=$1 AND contact_id=$2)`
+	err := db.QueryRow(s, ownerID, contactID).Scan(&exists)
+	if err != nil {
+		return "", err
+	} else if exists {
+		return "", EmergencyAccessExistsError
+	}
+
+	id := shared.GenRandomString(emergencyAccessIDLength)
+	for TableIDExists("emergency_access", id) {
+		id = shared.GenRandomString(emergencyAccessIDLength)
+	}
+
+	s = `INSERT INTO emergency_access
+	     (id, owner_id, contact_id, access_type, wait_days, protected_key, created)
+	     VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	_, err = db.Exec(s, id, ownerID, contactID, accessType, waitDays,
+		protectedKey, time.Now().UTC())
+	return id, err
+}
+
+// GetEmergencyAccess returns the emergency contacts that a user has designated,
+// as well as the users who have designated them as an emergency contact
+func GetEmergencyAccess(userID string) (shared.EmergencyAccessResponse, error) {
+	response := shared.EmergencyAccessResponse{
+		Contacts: []shared.EmergencyAccess{},
+		Grantors: []shared.EmergencyAccess{},
+	}
+
+	s := `SELECT id, owner_id, contact_id, access_type, wait_days,
+	             protected_key IS NOT NULL, requested
+	      FROM emergency_access
+	      WHERE owner_id=$1 OR contact_id=$1
+	      ORDER BY created DESC`
+	rows, err := db.Query(s, userID)
+	if err != nil {
+		return response, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var access shared.EmergencyAccess
+		var ownerID, contactID string
+		var requested sql.NullTime
+
+		err = rows.Scan(
+			&access.ID,
+			&ownerID,
+			&contactID,
+			&access.AccessType,
+			&access.WaitDays,
+			&access.HasKey,
+			&requested)
+		if err != nil {
+			return response, err
+		}
+
+		access.Requested = requested.Time
+		access.Status = emergencyAccessStatus(requested, access.WaitDays)
+
+		if ownerID != userID {
+			access.UserName, err = GetUserPublicName(ownerID)
+			if err != nil {
+				access.UserName = "???"
+			}
+
+			response.Grantors = append(response.Grantors, access)
+			continue
+		}
+
+		access.UserName, err = GetUserPublicName(contactID)
+		if err != nil {
+			access.UserName = "???"
+		}
+
+		// The owner needs the contact's public key to re-confirm the
+		// contact after rotating their keys
+		access.PublicKey, err = GetUserPubKey(contactID)
+		if err != nil {
+			return response, err
+		}
+
+		response.Contacts = append(response.Contacts, access)
+	}
+
+	return response, nil
+}
+
+// UpdateEmergencyAccessKey replaces the owner's private key that was encrypted
+// with the contact's public key, which is required after the owner rotates
+// their keys
+func UpdateEmergencyAccessKey(ownerID, id string, protectedKey []byte) error {
+	s := `UPDATE emergency_access SET protected_key=$3
+	      WHERE id=$1 AND owner_id=$2`
+	return execEmergencyAccess(s, id, ownerID, protectedKey)
+}
+
+// DeleteEmergencyAccess removes an emergency contact. Either the owner or the
+// contact can remove it.
+func DeleteEmergencyAccess(userID, id string) error {
+	s := `DELETE FROM emergency_access
+	      WHERE id=$1 AND (owner_id=$2 OR contact_id=$2)`
+	return execEmergencyAccess(s, id, userID)
+}
+
+// RequestEmergencyAccess starts the waiting period for a contact's access to
+// the owner's vault. Returns the owner's ID and the number of days the owner
+// has to deny the request.
+func RequestEmergencyAccess(contactID, id string) (string, int, error) {
+	var ownerID string
+	var waitDays int
+	s := `UPDATE emergency_access SET requested=$3
+	      WHERE id=$1 AND contact_id=$2 AND requested IS NULL
+	      RETURNING owner_id, wait_days`
+	err := db.QueryRow(s, id, contactID, time.Now().UTC()).Scan(&ownerID, &waitDays)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", 0, EmergencyAccessNotFoundError
+	}
+
+	return ownerID, waitDays, err
+}
+
+// DenyEmergencyAccess resets a request for emergency access, which can be done
+// by the owner to deny it (including access that was already granted), or by
+// the contact to cancel it
+func DenyEmergencyAccess(userID, id string) error {
+	s := `UPDATE emergency_access SET requested=NULL
+	      WHERE id=$1 AND (owner_id=$2 OR contact_id=$2)
+	      AND requested IS NOT NULL`
+	return execEmergencyAccess(s, id, userID)
+}
+
+// GetGrantedEmergencyAccess returns the owner's ID, the type of access, and the
+// owner's protected private key for a contact whose access has been granted
+func GetGrantedEmergencyAccess(contactID, id string) (string, string, []byte, error) {
+	var (
+		ownerID      string
+		accessType   string
+		waitDays     int
+		protectedKey []byte
+		requested    sql.NullTime
+	)
+
+	s := `SELECT owner_id, access_type, wait_days, protected_key, requested
+	      FROM emergency_access
+	      WHERE id=$1 AND contact_id=$2`
+	err := db.QueryRow(s, id, contactID).Scan(
+		&ownerID, &accessType, &waitDays, &protectedKey, &requested)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", nil, EmergencyAccessNotFoundError
+	} else if err != nil {
+		return "", "", nil, err
+	}
+
+	status := emergencyAccessStatus(requested, waitDays)
+	if status != constants.EmergencyStatusGranted {
+		return "", "", nil, EmergencyAccessNotGrantedError
+	} else if len(protectedKey) == 0 {
+		return "", "", nil, EmergencyAccessKeyError
+	}
+
+	return ownerID, accessType, protectedKey, nil
+}
+
+// DeleteUserEmergencyAccess removes every emergency contact the user has
+// designated, as well as their access to other users' vaults
+func DeleteUserEmergencyAccess(userID string) error {
+	s := `DELETE FROM emergency_access WHERE owner_id=$1 OR contact_id=$1`
+	_, err := db.Exec(s, userID)
+	return err
+}
+
+func execEmergencyAccess(query string, args ...any) error {
+	result, err := db.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	} else if count == 0 {
+		return EmergencyAccessNotFoundError
+	}
+
+	return nil
+}
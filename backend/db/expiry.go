@@ -1,10 +1,16 @@
 package db
 
 import (
+	"database/sql"
 	"log"
 	"time"
+	"yeetfile/backend/mail"
 )
 
+// UnknownTotalDownloads is returned as the total number of downloads for sends
+// created before the total was recorded
+const UnknownTotalDownloads = -1
+
 type FileExpiry struct {
 	ID        string
 	Downloads int
@@ -13,8 +19,8 @@ type FileExpiry struct {
 
 func SetFileExpiry(id string, downloads int, date time.Time) error {
 	s := `INSERT INTO expiry
-	      (id, downloads, date)
-	      VALUES ($1, $2, $3)`
+	      (id, downloads, date, total_downloads)
+	      VALUES ($1, $2, $3, $2)`
 	_, err := db.Exec(s, id, downloads, date)
 	return err
 }
@@ -51,7 +57,7 @@ func DecrementDownloads(id string) int {
 }
 
 func GetFileExpiry(metadataID string) FileExpiry {
-	s := `SELECT * FROM expiry WHERE id=$1`
+	s := `SELECT id, downloads, date FROM expiry WHERE id=$1`
 	rows, err := db.Query(s, metadataID)
 
 	if err != nil {
@@ -81,7 +87,9 @@ func GetFileExpiry(metadataID string) FileExpiry {
 // expiration date has been surpassed. If it has, the file is deleted.
 func CheckExpiry(deleteFn func(metadata FileMetadata)) func() {
 	return func() {
-		s := `SELECT id FROM expiry WHERE date < CURRENT_TIMESTAMP at time zone 'UTC'`
+		s := `SELECT id, downloads, total_downloads
+		      FROM expiry
+		      WHERE date < CURRENT_TIMESTAMP at time zone 'UTC'`
 		rows, err := db.Query(s)
 
 		if err != nil {
@@ -92,8 +100,10 @@ func CheckExpiry(deleteFn func(metadata FileMetadata)) func() {
 		defer rows.Close()
 		for rows.Next() {
 			var id string
+			var downloads int
+			var totalDownloads sql.NullInt32
 
-			err = rows.Scan(&id)
+			err = rows.Scan(&id, &downloads, &totalDownloads)
 
 			if err != nil {
 				log.Printf("Error scanning rows: %v\n", err)
@@ -105,14 +115,43 @@ func CheckExpiry(deleteFn func(metadata FileMetadata)) func() {
 			metadata, err := RetrieveMetadata(id)
 			if err != nil {
 				log.Printf("Metadata not found for id: " + id)
-			} else {
-				deleteFn(metadata)
+				continue
 			}
+
+			if totalDownloads.Valid && downloads == int(totalDownloads.Int32) {
+				notifyExpiredUnused(id, downloads)
+			}
+
+			deleteFn(metadata)
 		}
 	}
 
 }
 
+// notifyExpiredUnused emails the owner of a send that expired without being
+// downloaded, if they opted in to notifications for that send.
+func notifyExpiredUnused(id string, downloads int) {
+	email, err := GetSendNotifyEmail(id)
+	if err != nil || len(email) == 0 {
+		return
+	}
+
+	err = mail.SendExpiredNotification(email, id, downloads)
+	if err != nil {
+		log.Printf("Error sending expiration notification: %v\n", err)
+	}
+}
+
+// totalDownloadsOrUnknown returns the total number of downloads for a send, or
+// UnknownTotalDownloads if the send predates tracking the total
+func totalDownloadsOrUnknown(totalDownloads sql.NullInt32) int {
+	if !totalDownloads.Valid {
+		return UnknownTotalDownloads
+	}
+
+	return int(totalDownloads.Int32)
+}
+
 func DeleteExpiry(id string) bool {
 	s := `DELETE FROM expiry
 	      WHERE id = $1`
@@ -123,3 +162,16 @@ func DeleteExpiry(id string) bool {
 
 	return true
 }
+
+// UpdateFileExpiry sets a new expiration date and number of remaining
+// downloads for a send. The total number of downloads is adjusted by the same
+// amount so that previous downloads are still accounted for.
+func UpdateFileExpiry(id string, downloads int, date time.Time) error {
+	s := `UPDATE expiry
+	      SET total_downloads = total_downloads - downloads + $2,
+	          downloads = $2,
+	          date = $3
+	      WHERE id=$1`
+	_, err := db.Exec(s, id, downloads, date)
+	return err
+}
@@ -0,0 +1,74 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+)
+
+// failedAttemptWindow is how long a failed attempt counts towards locking an
+// account. Failures older than this are forgotten on the next failure.
+const failedAttemptWindow = 24 * time.Hour
+
+// AddFailedAttempt increments the number of failed attempts of the provided
+// kind (i.e. password or 2FA) for the user, and returns the new total
+func AddFailedAttempt(userID, kind string) (int, error) {
+	var failures int
+	now := time.Now().UTC()
+	s := `INSERT INTO failed_attempts (user_id, kind, failures, last_failure)
+	      VALUES ($1, $2, 1, $3)
+	      ON CONFLICT (user_id, kind) DO UPDATE SET
+	          failures = CASE WHEN failed_attempts.last_failure < $4
+	                     THEN 1 ELSE failed_attempts.failures + 1 END,
+	          last_failure = $3
+	      RETURNING failures`
+	err := db.QueryRow(s, userID, kind, now, now.Add(-failedAttemptWindow)).
+		Scan(&failures)
+	return failures, err
+}
+
+// SetLockedUntil prevents the user from attempting to log in (or use a 2FA
+// code, depending on the kind) until the provided time
+func SetLockedUntil(userID, kind string, lockedUntil time.Time) error {
+	s := `UPDATE failed_attempts SET locked_until=$3
+	      WHERE user_id=$1 AND kind=$2`
+	_, err := db.Exec(s, userID, kind, lockedUntil)
+	return err
+}
+
+// GetLockedUntil returns the time that the user's lockout ends for the
+// provided kind of attempt. A zero time is returned if the user isn't locked.
+func GetLockedUntil(userID, kind string) (time.Time, error) {
+	var lockedUntil sql.NullTime
+	s := `SELECT locked_until FROM failed_attempts WHERE user_id=$1 AND kind=$2`
+	err := db.QueryRow(s, userID, kind).Scan(&lockedUntil)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+
+	return lockedUntil.Time, err
+}
+
+// IsUserLocked returns true if any kind of attempt is currently locked for the
+// user
+func IsUserLocked(userID string) (bool, error) {
+	var locked bool
+	s := `SELECT EXISTS(SELECT 1 FROM failed_attempts
+	                    WHERE user_id=$1 AND locked_until > $2)`
+	err := db.QueryRow(s, userID, time.Now().UTC()).Scan(&locked)
+	return locked, err
+}
+
+// ResetFailedAttempts clears the user's failed attempts of the provided kind,
+// along with any lockout
+func ResetFailedAttempts(userID, kind string) error {
+	s := `DELETE FROM failed_attempts WHERE user_id=$1 AND kind=$2`
+	_, err := db.Exec(s, userID, kind)
+	return err
+}
+
+// UnlockUser clears every kind of failed attempt and lockout for the user
+func UnlockUser(userID string) error {
+	s := `DELETE FROM failed_attempts WHERE user_id=$1`
+	_, err := db.Exec(s, userID)
+	return err
+}
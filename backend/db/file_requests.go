@@ -0,0 +1,228 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+	"yeetfile/shared"
+)
+
+const fileRequestIDLength = 16
+
+var FileRequestNotFoundError = errors.New("file request not found")
+var FileRequestLimitError = errors.New("file request is expired or at its upload limit")
+
+// CreateFileRequest creates a new file request for the user, bound to the
+// specified vault folder. A zero expiration means the request never expires.
+func CreateFileRequest(
+	ownerID string,
+	request shared.NewFileRequest,
+	expiration time.Time,
+) (string, error) {
+	id := shared.GenRandomString(fileRequestIDLength)
+	for FileRequestIDExists(id) {
+		id = shared.GenRandomString(fileRequestIDLength)
+	}
+
+	var exp sql.NullTime
+	if !expiration.IsZero() {
+		exp = sql.NullTime{Time: expiration.UTC(), Valid: true}
+	}
+
+	s := `INSERT INTO file_requests
+	      (id, owner_id, folder_id, max_uploads, uploads, max_size, expiration, created)
+	      VALUES ($1, $2, $3, $4, 0, $5, $6, $7)`
+	_, err := db.Exec(
+		s,
+		id,
+		ownerID,
+		request.FolderID,
+		request.MaxUploads,
+		request.MaxSize,
+		exp,
+		time.Now().UTC())
+	if err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+func FileRequestIDExists(id string) bool {
+	var exists bool
+	s := `SELECT EXISTS(SELECT 1 FROM file_requests WHERE id=$1)`
+	err := db.QueryRow(s, id).Scan(&exists)
+	return err != nil || exists
+}
+
+// GetFileRequests returns all file requests created by the user. Requests
+// bound to the user's root folder are returned with an empty folder ID.
+func GetFileRequests(ownerID string) ([]shared.FileRequest, error) {
+	result := []shared.FileRequest{}
+
+	s := `SELECT id, folder_id, max_uploads, uploads, max_size, expiration, created
+	      FROM file_requests
+	      WHERE owner_id=$1
+	      ORDER BY created DESC`
+	rows, err := db.Query(s, ownerID)
+	if err != nil {
+		return result, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		request, err := scanFileRequest(rows)
+		if err != nil {
+			return result, err
+		}
+
+		if request.FolderID == ownerID {
+			request.FolderID = ""
+		}
+
+		result = append(result, request)
+	}
+
+	return result, nil
+}
+
+// GetFileRequest returns a file request and the ID of the user who created it
+func GetFileRequest(id string) (shared.FileRequest, string, error) {
+	s := `SELECT id, folder_id, max_uploads, uploads, max_size, expiration, created, owner_id
+	      FROM file_requests
+	      WHERE id=$1`
+	rows, err := db.Query(s, id)
+	if err != nil {
+		return shared.FileRequest{}, "", err
+	}
+
+	defer rows.Close()
+	if !rows.Next() {
+		return shared.FileRequest{}, "", FileRequestNotFoundError
+	}
+
+	var ownerID string
+	request, err := scanFileRequest(rows, &ownerID)
+	return request, ownerID, err
+}
+
+// scanFileRequest scans a file_requests row into a FileRequest, with any
+// additional selected columns scanned into the extra destinations.
+func scanFileRequest(rows *sql.Rows, extra ...any) (shared.FileRequest, error) {
+	var request shared.FileRequest
+	var expiration sql.NullTime
+
+	dest := []any{
+		&request.ID,
+		&request.FolderID,
+		&request.MaxUploads,
+		&request.Uploads,
+		&request.MaxSize,
+		&expiration,
+		&request.Created,
+	}
+
+	err := rows.Scan(append(dest, extra...)...)
+	if err != nil {
+		return shared.FileRequest{}, err
+	}
+
+	if expiration.Valid {
+		request.Expiration = expiration.Time
+	}
+
+	return request, nil
+}
+
+// IncrementFileRequestUploads increments the upload counter for a request,
+// returning FileRequestLimitError if the request has expired or has already
+// reached its max number of uploads.
+func IncrementFileRequestUploads(id string) error {
+	s := `UPDATE file_requests
+	      SET uploads = uploads + 1
+	      WHERE id=$1
+	        AND (max_uploads = 0 OR uploads < max_uploads)
+	        AND (expiration IS NULL OR expiration > CURRENT_TIMESTAMP at time zone 'UTC')`
+	result, err := db.Exec(s, id)
+	if err != nil {
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		return FileRequestLimitError
+	}
+
+	return nil
+}
+
+// DecrementFileRequestUploads releases an upload counted by
+// IncrementFileRequestUploads, i.e. if the upload couldn't be initialized
+func DecrementFileRequestUploads(id string) error {
+	s := `UPDATE file_requests SET uploads = uploads - 1
+	      WHERE id=$1 AND uploads > 0`
+	_, err := db.Exec(s, id)
+	return err
+}
+
+// DeleteFileRequest removes a file request. Files that were already uploaded
+// using the request are kept in the owner's vault.
+func DeleteFileRequest(id, ownerID string) error {
+	s := `DELETE FROM file_requests WHERE id=$1 AND owner_id=$2`
+	result, err := db.Exec(s, id, ownerID)
+	if err != nil {
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		return FileRequestNotFoundError
+	}
+
+	return nil
+}
+
+// DeleteUserFileRequests removes all file requests created by a user
+func DeleteUserFileRequests(ownerID string) error {
+	s := `DELETE FROM file_requests WHERE owner_id=$1`
+	_, err := db.Exec(s, ownerID)
+	return err
+}
+
+// SetVaultItemRequest marks a vault item as being uploaded via a file request.
+// If pendingKey is true, the item's protected key is wrapped with the owner's
+// public key (instead of the parent folder key) until the owner re-wraps it.
+func SetVaultItemRequest(itemID, requestID string, pendingKey bool) error {
+	s := `UPDATE vault SET request_id=$2, pending_key=$3, request_uploading=true
+	      WHERE id=$1`
+	_, err := db.Exec(s, itemID, requestID, pendingKey)
+	return err
+}
+
+// FinishFileRequestUpload marks a file request item as fully uploaded, after
+// which no more chunks can be uploaded for it using the request.
+func FinishFileRequestUpload(itemID string) error {
+	s := `UPDATE vault SET request_uploading=false WHERE id=$1`
+	_, err := db.Exec(s, itemID)
+	return err
+}
+
+// GetFileRequestItemOwner returns the owner of a vault item that is still
+// being uploaded using the specified file request.
+func GetFileRequestItemOwner(requestID, itemID string) (string, error) {
+	if len(requestID) == 0 {
+		return "", FileRequestNotFoundError
+	}
+
+	var ownerID string
+	s := `SELECT owner_id FROM vault
+	      WHERE id=$1 AND request_id=$2 AND request_uploading=true`
+	err := db.QueryRow(s, itemID, requestID).Scan(&ownerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", FileRequestNotFoundError
+	}
+
+	return ownerID, err
+}
@@ -21,7 +21,7 @@ func NewRootFolder(id string, protectedKey []byte) error {
 		return errors.New("error fetching user by ID when creating root folder")
 	}
 
-	return insertFolder(id, id, shared.NewVaultFolder{
+	return insertFolder(db, id, id, shared.NewVaultFolder{
 		Name:         "",
 		ParentID:     "",
 		ProtectedKey: protectedKey,
@@ -53,16 +53,21 @@ func NewFolder(folder shared.NewVaultFolder, ownerID string, pwFolder bool) (str
 		ownerID = parentOwnerID
 	}
 
-	return folderID, insertFolder(folderID, ownerID, folder, pwFolder)
+	return folderID, insertFolder(db, folderID, ownerID, folder, pwFolder)
 }
 
-func insertFolder(id, ownerID string, folder shared.NewVaultFolder, pwFolder bool) error {
+func insertFolder(
+	conn dbConn,
+	id, ownerID string,
+	folder shared.NewVaultFolder,
+	pwFolder bool,
+) error {
 	s := `INSERT INTO folders 
 	      (id, name, owner_id, protected_key, modified, parent_id, pw_folder, ref_id)
 	      VALUES 
 	      ($1, $2, $3, $4, $5, $6, $7, $1)`
 
-	_, err := db.Exec(
+	_, err := conn.Exec(
 		s,
 		id,
 		folder.Name,
@@ -162,7 +167,7 @@ func GetSubfolders(
 	          (SELECT COUNT(*) FROM sharing s WHERE s.item_id = f.id) AS share_count
 	          FROM folders f
 	          WHERE f.parent_id = $1
-	          AND f.pw_folder = $2
+	          AND f.pw_folder = $2` + excludeExpiredShares("f") + `
 	          ORDER BY f.modified DESC`
 
 	rows, err := db.Query(query, folderID, pwFolder)
@@ -287,34 +292,38 @@ func ChangeFolderPermission(folderID, ownerID string, canModify bool) error {
 	return nil
 }
 
+// GetFolderOwnerStorage returns the used and available storage of the owner of
+// a folder, which is either a user or an organization
 func GetFolderOwnerStorage(folderID string) (int64, int64, error) {
-	var (
-		storageUsed      int64
-		storageAvailable int64
-	)
-
-	s := `
-	    WITH folder_owner AS (
-	        SELECT owner_id FROM folders WHERE id = $1
-	    )
-	    SELECT storage_used, storage_available
-		FROM users
-		WHERE id = (SELECT owner_id FROM folder_owner);`
+	orgID, err := GetFolderOrgID(folderID)
+	if err != nil {
+		return 0, 0, err
+	} else if len(orgID) > 0 {
+		return GetOrgStorage(orgID)
+	}
 
-	err := db.QueryRow(s, folderID).Scan(&storageUsed, &storageAvailable)
+	ownerID, err := GetFolderOwner(folderID)
 	if err != nil {
 		return 0, 0, err
 	}
 
-	return storageUsed, storageAvailable, nil
+	return GetUserStorageLimits(ownerID)
 }
 
 func UpdateFolderOwnerStorage(folderID string, amount int64) error {
 	var (
+		ownerID          string
 		storageUsed      int64
 		storageAvailable int64
 	)
 
+	orgID, err := GetFolderOrgID(folderID)
+	if err != nil {
+		return err
+	} else if len(orgID) > 0 {
+		return UpdateOrgStorage(orgID, amount)
+	}
+
 	s := `
 	    WITH folder_owner AS (
 	        SELECT owner_id FROM folders WHERE id = $1
@@ -325,12 +334,19 @@ func UpdateFolderOwnerStorage(folderID string, amount int64) error {
 	                           ELSE storage_used + $2
 	                         END
 	      WHERE id = (SELECT owner_id FROM folder_owner) 
-	      RETURNING storage_used, storage_available`
+	      RETURNING id, storage_used, storage_available`
+
+	err = db.QueryRow(s, folderID, amount).Scan(&ownerID, &storageUsed, &storageAvailable)
+	if err != nil {
+		return err
+	} else if amount <= 0 {
+		return nil
+	}
 
-	err := db.QueryRow(s, folderID, amount).Scan(&storageUsed, &storageAvailable)
+	exceeded, err := isOverStorageLimit(db, ownerID, storageUsed, storageAvailable)
 	if err != nil {
 		return err
-	} else if storageUsed > storageAvailable && amount > 0 {
+	} else if exceeded {
 		return UserStorageExceeded
 	}
 
@@ -341,7 +357,8 @@ func GetFolderOwnership(
 	folderID,
 	ownerID string,
 ) (shared.FolderOwnershipInfo, error) {
-	query := `SELECT id, ref_id, can_modify FROM folders WHERE ref_id=$1 and owner_id=$2`
+	query := `SELECT f.id, f.ref_id, f.can_modify FROM folders f
+	          WHERE f.ref_id=$1 and f.owner_id=$2` + excludeExpiredShares("f")
 	rows, err := db.Query(query, folderID, ownerID)
 	if err == nil && rows.Next() {
 		defer rows.Close()
@@ -512,8 +529,9 @@ func GetKeySequence(folderID, ownerID string) ([][]byte, error) {
 	return keySequence[1:], nil
 }
 
-// ShareFolder shares a user's folder with another user via the recipient's user
-// ID (determined before calling ShareFolder).
+// ShareFolder invites another user to a folder via the recipient's user ID
+// (determined before calling ShareFolder). The folder isn't added to the
+// recipient's vault until they accept the invitation (see LinkSharedFolder).
 func ShareFolder(share shared.NewSharedItem, userID string) (string, error) {
 	if share.ItemID == share.UserID {
 		return "", errors.New("cannot share user's root folder")
@@ -530,44 +548,52 @@ func ShareFolder(share shared.NewSharedItem, userID string) (string, error) {
 		return "", errors.New("cannot share within a shared folder")
 	}
 
-	isAlreadyShared, err := IsSharedWithRecipient(share.UserID, share.ItemID, share.RecipientID)
+	err = checkCanShareWithRecipient(share)
 	if err != nil {
 		return "", err
-	} else if isAlreadyShared {
-		return "", AlreadySharedError
 	}
 
-	folder, err := GetFolderInfo(share.ItemID, share.UserID, ownership, true)
+	shareID, shareErr := AddSharingEntry(share, true)
+	if shareErr != nil {
+		return "", shareErr
+	}
+
+	return shareID, nil
+}
+
+// LinkSharedFolder adds a new folder entry to the recipient's vault that
+// references a folder shared with them, once they've accepted the invitation
+// to the folder.
+func LinkSharedFolder(
+	tx *sql.Tx,
+	ownerID,
+	recipientID,
+	folderID string,
+	protectedKey []byte,
+	canModify bool,
+) error {
+	folder, err := GetFolderInfo(folderID, ownerID, shared.FolderOwnershipInfo{}, true)
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	folderID := shared.GenRandomString(VaultIDLength)
-	for FolderIDExists(folderID) {
-		folderID = shared.GenRandomString(VaultIDLength)
+	linkID := shared.GenRandomString(VaultIDLength)
+	for FolderIDExists(linkID) {
+		linkID = shared.GenRandomString(VaultIDLength)
 	}
 
-	sharedByName, _ := GetUserPublicName(userID)
+	sharedByName, _ := GetUserPublicName(ownerID)
 
 	// Add new folder entry for recipient
-	s1 := `INSERT INTO folders (id, name, parent_id, owner_id, 
+	s := `INSERT INTO folders (id, name, parent_id, owner_id, 
                      protected_key, shared_by, modified, ref_id, can_modify,
                      pw_folder)
 	       VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
-	_, err = db.Exec(s1, folderID, folder.Name,
-		share.RecipientID, share.RecipientID, share.ProtectedKey,
-		sharedByName, time.Now().UTC(), folder.ID, share.CanModify,
+	_, err = tx.Exec(s, linkID, folder.Name,
+		recipientID, recipientID, protectedKey,
+		sharedByName, time.Now().UTC(), folder.ID, canModify,
 		folder.PasswordFolder)
-	if err != nil {
-		return "", err
-	}
-
-	shareID, shareErr := AddSharingEntry(share.UserID, share.RecipientID, share.ItemID, true, share.CanModify)
-	if shareErr != nil {
-		return "", shareErr
-	}
-
-	return shareID, nil
+	return err
 }
 
 // UpdateVaultFolderName updates the name of a folder in the vault. Note that the
@@ -594,6 +620,13 @@ func UpdateVaultFolderName(id, ownerID, newName string) error {
 
 // DeleteSharedFolder removes a folder that has been shared with the current user
 func DeleteSharedFolder(id, ownerID string) error {
+	orgID, err := GetFolderOrgID(id)
+	if err != nil {
+		return err
+	} else if len(orgID) > 0 {
+		return OrgFolderLinkError
+	}
+
 	s := `DELETE FROM folders WHERE id=$1 AND owner_id=$2 RETURNING ref_id`
 	rows, err := db.Query(s, id, ownerID)
 	if err != nil {
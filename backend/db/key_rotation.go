@@ -0,0 +1,229 @@
+package db
+
+import (
+	"errors"
+	"yeetfile/shared"
+)
+
+var KeyVersionMismatchError = errors.New("key version doesn't match the user's current key version")
+var KeyRotationMismatchError = errors.New("keys don't match the keys encrypted with the user's public key")
+
+// wrappedKeyTable describes where keys that are encrypted with a user's public
+// key are stored. Keys are selected as (id, protected_key) pairs and updated
+// using the key ID and the user ID.
+type wrappedKeyTable struct {
+	selectQuery string
+	updateQuery string
+}
+
+var (
+	// Root folder, folders in the root folder, and folders shared with
+	// the user (including org folders)
+	rootFolderKeys = wrappedKeyTable{
+		selectQuery: `SELECT id, protected_key FROM folders
+		              WHERE owner_id=$1 AND (id=$1 OR parent_id=$1)`,
+		updateQuery: `UPDATE folders SET protected_key=$3
+		              WHERE id=$1 AND owner_id=$2`,
+	}
+
+	// Files in the root folder, files shared with the user, and files
+	// uploaded to one of the user's file requests
+	rootItemKeys = wrappedKeyTable{
+		selectQuery: `SELECT id, protected_key FROM vault
+		              WHERE owner_id=$1 AND (folder_id=$1 OR pending_key=true)`,
+		updateQuery: `UPDATE vault SET protected_key=$3
+		              WHERE id=$1 AND owner_id=$2`,
+	}
+
+	shareInvitationKeys = wrappedKeyTable{
+		selectQuery: `SELECT id, protected_key FROM sharing
+		              WHERE recipient_id=$1 AND accepted=false
+		              AND protected_key IS NOT NULL`,
+		updateQuery: `UPDATE sharing SET protected_key=$3
+		              WHERE id=$1 AND recipient_id=$2`,
+	}
+
+	ownershipTransferKeys = wrappedKeyTable{
+		selectQuery: `SELECT id, protected_key FROM ownership_transfers
+		              WHERE recipient_id=$1`,
+		updateQuery: `UPDATE ownership_transfers SET protected_key=$3
+		              WHERE id=$1 AND recipient_id=$2`,
+	}
+
+	inboxSendKeys = wrappedKeyTable{
+		selectQuery: `SELECT send_id, protected_key FROM send_recipients
+		              WHERE user_id=$1`,
+		updateQuery: `UPDATE send_recipients SET protected_key=$3
+		              WHERE send_id=$1 AND user_id=$2`,
+	}
+
+	// Private keys of users who designated the user as an emergency contact
+	emergencyAccessKeys = wrappedKeyTable{
+		selectQuery: `SELECT id, protected_key FROM emergency_access
+		              WHERE contact_id=$1 AND protected_key IS NOT NULL`,
+		updateQuery: `UPDATE emergency_access SET protected_key=$3
+		              WHERE id=$1 AND contact_id=$2`,
+	}
+)
+
+// GetUserKeyVersion returns the version of the user's current key pair, which
+// is incremented each time the user rotates their keys
+func GetUserKeyVersion(userID string) (int, error) {
+	var keyVersion int
+	s := `SELECT key_version FROM users WHERE id=$1`
+	err := db.QueryRow(s, userID).Scan(&keyVersion)
+	return keyVersion, err
+}
+
+// GetWrappedKeys returns every key that is encrypted with the user's public
+// key, which all need to be re-encrypted when the user rotates their keys
+func GetWrappedKeys(userID string) (shared.KeyRotationResponse, error) {
+	return getWrappedKeys(db, userID, false)
+}
+
+// getWrappedKeys returns the user's key version and wrapped keys. If lock is
+// true, the user and each of the selected rows are locked until the end of the
+// transaction.
+func getWrappedKeys(conn dbConn, userID string, lock bool) (shared.KeyRotationResponse, error) {
+	lockClause := ""
+	if lock {
+		lockClause = " FOR UPDATE"
+	}
+
+	var keyVersion int
+	s := `SELECT key_version FROM users WHERE id=$1` + lockClause
+	err := conn.QueryRow(s, userID).Scan(&keyVersion)
+	if err != nil {
+		return shared.KeyRotationResponse{}, err
+	}
+
+	keys := shared.WrappedKeys{}
+	for _, group := range []struct {
+		table wrappedKeyTable
+		keys  *[]shared.WrappedKey
+	}{
+		{rootFolderKeys, &keys.Folders},
+		{rootItemKeys, &keys.Items},
+		{shareInvitationKeys, &keys.Shares},
+		{ownershipTransferKeys, &keys.Transfers},
+		{inboxSendKeys, &keys.Sends},
+		{emergencyAccessKeys, &keys.Emergency},
+	} {
+		query := group.table.selectQuery + lockClause
+		*group.keys, err = queryWrappedKeys(conn, query, userID)
+		if err != nil {
+			return shared.KeyRotationResponse{}, err
+		}
+	}
+
+	return shared.KeyRotationResponse{
+		KeyVersion: keyVersion,
+		Keys:       keys,
+	}, nil
+}
+
+// RotateUserKeys replaces the user's key pair and every key encrypted with
+// their previous public key. The rotation needs to contain exactly the keys
+// returned by GetWrappedKeys, otherwise nothing is updated. The user's recovery
+// key and the copies of their private key shared with their emergency contacts
+// are removed, since they only protect their previous private key.
+func RotateUserKeys(userID string, rotation shared.KeyRotation) error {
+	// Unlike most updates, a partial rotation would leave some keys
+	// encrypted with a public key that doesn't match the user's private key,
+	// so the rotation is applied in a single transaction. The current keys
+	// are locked while they're compared against the rotation and replaced.
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	current, err := getWrappedKeys(tx, userID, true)
+	if err != nil {
+		return err
+	} else if current.KeyVersion != rotation.KeyVersion {
+		return KeyVersionMismatchError
+	}
+
+	updates := []struct {
+		table   wrappedKeyTable
+		current []shared.WrappedKey
+		rotated []shared.WrappedKey
+	}{
+		{rootFolderKeys, current.Keys.Folders, rotation.Keys.Folders},
+		{rootItemKeys, current.Keys.Items, rotation.Keys.Items},
+		{shareInvitationKeys, current.Keys.Shares, rotation.Keys.Shares},
+		{ownershipTransferKeys, current.Keys.Transfers, rotation.Keys.Transfers},
+		{inboxSendKeys, current.Keys.Sends, rotation.Keys.Sends},
+		{emergencyAccessKeys, current.Keys.Emergency, rotation.Keys.Emergency},
+	}
+
+	for _, update := range updates {
+		if !idsMatch(wrappedKeyIDs(update.rotated), wrappedKeyIDs(update.current)) {
+			return KeyRotationMismatchError
+		}
+	}
+
+	s := `UPDATE users
+	      SET public_key=$2, protected_key=$3, key_version=key_version+1,
+	          recovery_hash=NULL, recovery_key=NULL
+	      WHERE id=$1 AND key_version=$4`
+	result, err := tx.Exec(s, userID, rotation.PublicKey,
+		rotation.ProtectedKey, rotation.KeyVersion)
+	if err != nil {
+		return err
+	} else if rows, err := result.RowsAffected(); err != nil || rows != 1 {
+		return KeyVersionMismatchError
+	}
+
+	s = `UPDATE emergency_access SET protected_key=NULL WHERE owner_id=$1`
+	_, err = tx.Exec(s, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, update := range updates {
+		for _, key := range update.rotated {
+			_, err = tx.Exec(update.table.updateQuery,
+				key.ID, userID, key.ProtectedKey)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+func queryWrappedKeys(conn dbConn, query, userID string) ([]shared.WrappedKey, error) {
+	keys := []shared.WrappedKey{}
+	rows, err := conn.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var key shared.WrappedKey
+		err = rows.Scan(&key.ID, &key.ProtectedKey)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+func wrappedKeyIDs(keys []shared.WrappedKey) []string {
+	var ids []string
+	for _, key := range keys {
+		ids = append(ids, key.ID)
+	}
+
+	return ids
+}
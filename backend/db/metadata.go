@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"errors"
 	"log"
+	"strings"
 	"time"
 	"yeetfile/shared"
 	"yeetfile/shared/constants"
@@ -11,6 +12,8 @@ import (
 
 const uploadIDLength = 12
 
+var SendNotFoundError = errors.New("send not found")
+
 type FileMetadata struct {
 	ID                string
 	RefID             string
@@ -53,6 +56,64 @@ func InsertMetadata(chunks int, ownerID, name string, textOnly bool) (string, er
 	return id, nil
 }
 
+// SetSendNotify sets whether the owner of a send should be emailed when the
+// send is downloaded or expires.
+func SetSendNotify(id string, notify bool) error {
+	s := `UPDATE metadata SET notify=$2 WHERE id=$1`
+	_, err := db.Exec(s, id, notify)
+	return err
+}
+
+// GetSendNotifyEmail returns the email of the owner of a send, if the owner
+// has opted in to notifications for that send. If they haven't opted in, or if
+// they don't have an email associated with their account, an empty string is
+// returned.
+func GetSendNotifyEmail(id string) (string, error) {
+	var email string
+	s := `SELECT u.email
+	      FROM metadata m
+	      JOIN users u ON m.owner_id = u.id
+	      WHERE m.id=$1 AND m.notify=true`
+	err := db.QueryRow(s, id).Scan(&email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+
+	return email, err
+}
+
+// SetSendManifest stores the encrypted manifest for a multi-file send
+func SetSendManifest(id string, manifest []byte) error {
+	s := `UPDATE metadata SET manifest=$2 WHERE id=$1`
+	_, err := db.Exec(s, id, manifest)
+	return err
+}
+
+// GetSendManifest returns the encrypted manifest for a send, or nil if the
+// send only contains a single file or text.
+func GetSendManifest(id string) ([]byte, error) {
+	var manifest []byte
+	s := `SELECT manifest FROM metadata WHERE id=$1`
+	err := db.QueryRow(s, id).Scan(&manifest)
+	return manifest, err
+}
+
+// SetSendFormat stores the encrypted format hint for a text send
+func SetSendFormat(id string, format []byte) error {
+	s := `UPDATE metadata SET format=$2 WHERE id=$1`
+	_, err := db.Exec(s, id, format)
+	return err
+}
+
+// GetSendFormat returns the encrypted format hint for a text send, or nil if
+// a format wasn't provided.
+func GetSendFormat(id string) ([]byte, error) {
+	var format []byte
+	s := `SELECT format FROM metadata WHERE id=$1`
+	err := db.QueryRow(s, id).Scan(&format)
+	return format, err
+}
+
 func MetadataIDExists(id string) bool {
 	rows, err := db.Query(`SELECT * FROM metadata WHERE id = $1`, id)
 	if err != nil {
@@ -208,3 +269,76 @@ func AdminFetchSentFiles(userID string) ([]shared.AdminFileInfoResponse, error)
 
 	return result, nil
 }
+
+// GetUserSends returns all active sends created by a user, including their
+// size, remaining downloads, and expiration.
+func GetUserSends(ownerID string) ([]shared.SendItem, error) {
+	result := []shared.SendItem{}
+
+	s := `SELECT m.id, m.length, m.modified, m.notify, m.manifest IS NOT NULL,
+	             e.downloads, e.total_downloads, e.date
+	      FROM metadata m
+	      JOIN expiry e ON m.id = e.id
+	      WHERE m.owner_id=$1 AND e.date > CURRENT_TIMESTAMP at time zone 'UTC'
+	      ORDER BY m.modified DESC`
+
+	rows, err := db.Query(s, ownerID)
+	if err != nil {
+		return result, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var item shared.SendItem
+		var totalDownloads sql.NullInt32
+		err = rows.Scan(
+			&item.ID,
+			&item.Size,
+			&item.Created,
+			&item.Notify,
+			&item.Bundle,
+			&item.Downloads,
+			&totalDownloads,
+			&item.Expiration)
+		if err != nil {
+			return result, err
+		}
+
+		item.TotalDownloads = totalDownloadsOrUnknown(totalDownloads)
+
+		item.TextOnly = strings.HasPrefix(item.ID, constants.PlaintextIDPrefix)
+		result = append(result, item)
+	}
+
+	return result, nil
+}
+
+// RetrieveUserSend returns the metadata and total number of downloads for a
+// send, if the send belongs to the specified user and hasn't expired. The total is
+// UnknownTotalDownloads for sends created before it was tracked.
+func RetrieveUserSend(id, ownerID string) (FileMetadata, int, error) {
+	var totalDownloads sql.NullInt32
+	var metadata FileMetadata
+
+	s := `SELECT m.id, m.chunks, m.filename, m.b2_id, m.length,
+	             e.downloads, e.total_downloads, e.date
+	      FROM metadata m
+	      JOIN expiry e ON m.id = e.id
+	      WHERE m.id=$1 AND m.owner_id=$2
+	        AND e.date > CURRENT_TIMESTAMP at time zone 'UTC'`
+
+	err := db.QueryRow(s, id, ownerID).Scan(
+		&metadata.ID,
+		&metadata.Chunks,
+		&metadata.Name,
+		&metadata.B2ID,
+		&metadata.Length,
+		&metadata.Downloads,
+		&totalDownloads,
+		&metadata.Expiration)
+	if errors.Is(err, sql.ErrNoRows) {
+		return FileMetadata{}, 0, SendNotFoundError
+	}
+
+	return metadata, totalDownloadsOrUnknown(totalDownloads), err
+}
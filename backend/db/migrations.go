@@ -1,11 +1,15 @@
 package db
 
-import "time"
+import (
+	"context"
+	"fmt"
+	"time"
+)
 
-func getMigrationVersion() (int, error) {
+func getMigrationVersion(ctx context.Context) (int, error) {
 	var version int
 	s := `SELECT version FROM migrations ORDER BY date DESC LIMIT 1`
-	err := db.QueryRow(s).Scan(&version)
+	err := db.QueryRowContext(ctx, s).Scan(&version)
 
 	return version, err
 }
@@ -15,3 +19,39 @@ func setMigrationVersion(version int) error {
 	_, err := db.Exec(s, version, time.Now().UTC())
 	return err
 }
+
+// getLatestScriptVersion returns the highest migration script version embedded
+// in the server binary.
+func getLatestScriptVersion() (int, error) {
+	dir, err := migrationScripts.ReadDir(migrationDir)
+	if err != nil {
+		return -1, err
+	}
+
+	latest := -1
+	for _, file := range dir {
+		latest = max(latest, getScriptVersion(file.Name()))
+	}
+
+	return latest, nil
+}
+
+// CheckMigrations returns an error if the database has not been migrated to
+// the latest script version embedded in the server binary.
+func CheckMigrations(ctx context.Context) error {
+	current, err := getMigrationVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	latest, err := getLatestScriptVersion()
+	if err != nil {
+		return err
+	}
+
+	if current < latest {
+		return fmt.Errorf("database at version %d, expected %d", current, latest)
+	}
+
+	return nil
+}
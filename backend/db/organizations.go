@@ -0,0 +1,664 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+	"yeetfile/backend/config"
+	"yeetfile/shared"
+	"yeetfile/shared/constants"
+)
+
+const orgIDLength = 16
+
+var OrgNotFoundError = errors.New("organization not found")
+var AlreadyInOrgError = errors.New("user is already a member of an organization")
+var OrgKeyMismatchError = errors.New("keys don't match the organization's members or folders")
+var OrgRotationPendingError = errors.New("org folder keys need to be rotated first")
+var OrgFolderLinkError = errors.New("organization folders can only be removed by an org admin")
+
+// orgFolderQuery selects the IDs of the top level folders owned by an
+// organization. These folders are stored with the org ID as both the owner ID
+// and parent ID, and every member has a linked folder in their root folder.
+const orgFolderQuery = `SELECT id FROM folders WHERE owner_id=$1 AND parent_id=$1`
+
+// CreateOrganization creates a new organization with the user as its owner
+func CreateOrganization(name, ownerID string) (string, error) {
+	orgID, _, err := GetUserOrg(ownerID)
+	if err != nil {
+		return "", err
+	} else if len(orgID) > 0 {
+		return "", AlreadyInOrgError
+	}
+
+	orgID = shared.GenRandomString(orgIDLength)
+	for TableIDExists("organizations", orgID) {
+		orgID = shared.GenRandomString(orgIDLength)
+	}
+
+	s := `INSERT INTO organizations (id, name, owner_id, created)
+	      VALUES ($1, $2, $3, $4)`
+	_, err = db.Exec(s, orgID, name, ownerID, time.Now().UTC())
+	if err != nil {
+		return "", err
+	}
+
+	s = `INSERT INTO org_members (org_id, user_id, role, created)
+	     VALUES ($1, $2, $3, $4)`
+	_, err = db.Exec(s, orgID, ownerID, constants.OrgRoleOwner, time.Now().UTC())
+	return orgID, err
+}
+
+// GetUserOrg returns the ID of the organization the user belongs to, and their
+// role in the organization. The ID is empty if the user isn't in an org.
+func GetUserOrg(userID string) (string, string, error) {
+	var orgID string
+	var role string
+	s := `SELECT org_id, role FROM org_members WHERE user_id=$1`
+	err := db.QueryRow(s, userID).Scan(&orgID, &role)
+	if err == sql.ErrNoRows {
+		return "", "", nil
+	}
+
+	return orgID, role, err
+}
+
+// GetOrganization returns the organization that the user belongs to, including
+// its members and the org folders with keys wrapped for the user
+func GetOrganization(userID string) (shared.Organization, error) {
+	orgID, role, err := GetUserOrg(userID)
+	if err != nil {
+		return shared.Organization{}, err
+	} else if len(orgID) == 0 {
+		return shared.Organization{}, OrgNotFoundError
+	}
+
+	org := shared.Organization{ID: orgID, UserID: userID, Role: role}
+	s := `SELECT name, rotate_keys FROM organizations WHERE id=$1`
+	err = db.QueryRow(s, orgID).Scan(&org.Name, &org.RotateKeys)
+	if err != nil {
+		return shared.Organization{}, err
+	}
+
+	org.Members, err = GetOrgMembers(orgID)
+	if err != nil {
+		return shared.Organization{}, err
+	}
+
+	org.Folders, err = getOrgFolders(orgID, userID)
+	if err != nil {
+		return shared.Organization{}, err
+	}
+
+	org.StorageUsed, org.StorageAvailable, err = GetOrgStorage(orgID)
+	if err != nil {
+		return shared.Organization{}, err
+	}
+
+	return org, nil
+}
+
+// GetOrgMembers returns all members of an organization
+func GetOrgMembers(orgID string) ([]shared.OrgMember, error) {
+	members, err := queryOrgMembers(db, orgID)
+	if err != nil {
+		return members, err
+	}
+
+	for i, member := range members {
+		members[i].Name, err = GetUserPublicName(member.ID)
+		if err != nil {
+			return members, err
+		}
+	}
+
+	return members, nil
+}
+
+// queryOrgMembers returns the ID and role of each member of an organization
+func queryOrgMembers(conn dbConn, orgID string) ([]shared.OrgMember, error) {
+	members := []shared.OrgMember{}
+	s := `SELECT user_id, role FROM org_members WHERE org_id=$1 ORDER BY created`
+	rows, err := conn.Query(s, orgID)
+	if err != nil {
+		return members, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var member shared.OrgMember
+		err = rows.Scan(&member.ID, &member.Role)
+		if err != nil {
+			return members, err
+		}
+
+		members = append(members, member)
+	}
+
+	return members, nil
+}
+
+// getOrgFolders returns the org's folders using the user's linked folders,
+// which contain the folder key encrypted with the user's public key
+func getOrgFolders(orgID, userID string) ([]shared.OrgFolder, error) {
+	folders := []shared.OrgFolder{}
+	s := `SELECT ref_id, name, protected_key FROM folders
+	      WHERE owner_id=$2 AND ref_id IN (` + orgFolderQuery + `)
+	      ORDER BY modified DESC`
+	rows, err := db.Query(s, orgID, userID)
+	if err != nil {
+		return folders, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var folder shared.OrgFolder
+		err = rows.Scan(&folder.ID, &folder.Name, &folder.ProtectedKey)
+		if err != nil {
+			return folders, err
+		}
+
+		folders = append(folders, folder)
+	}
+
+	return folders, nil
+}
+
+// GetOrgFolderIDs returns the IDs of the org's top level folders
+func GetOrgFolderIDs(orgID string) ([]string, error) {
+	return queryIDs(db, orgFolderQuery, orgID)
+}
+
+// GetFolderOrgID returns the ID of the organization that owns a folder, or an
+// empty string if the folder isn't owned by an organization. This works for
+// org folders, their subfolders, and the linked folders of org members.
+func GetFolderOrgID(folderID string) (string, error) {
+	var orgID string
+	s := `SELECT o.id FROM folders f
+	      JOIN folders r ON r.id = f.ref_id
+	      JOIN organizations o ON o.id = r.owner_id
+	      WHERE f.id=$1`
+	err := db.QueryRow(s, folderID).Scan(&orgID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+
+	return orgID, err
+}
+
+// AddOrgMember adds a user to an organization, creating a linked folder in the
+// user's root folder for each org folder
+func AddOrgMember(orgID, userID, role string, folderKeys []shared.OrgKey) error {
+	memberOrgID, _, err := GetUserOrg(userID)
+	if err != nil {
+		return err
+	} else if len(memberOrgID) > 0 {
+		return AlreadyInOrgError
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	orgName, rotateKeys, err := getOrgState(tx, orgID)
+	if err != nil {
+		return err
+	} else if rotateKeys {
+		return OrgRotationPendingError
+	}
+
+	folderIDs, err := queryIDs(tx, orgFolderQuery, orgID)
+	if err != nil {
+		return err
+	} else if !keysMatchIDs(folderKeys, folderIDs) {
+		return OrgKeyMismatchError
+	}
+
+	s := `INSERT INTO org_members (org_id, user_id, role, created)
+	      VALUES ($1, $2, $3, $4)`
+	_, err = tx.Exec(s, orgID, userID, role, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	for _, key := range folderKeys {
+		err = linkOrgFolder(tx, key.ID, userID, orgName, key.ProtectedKey, role)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// SetOrgMemberRole changes a member's role, updating their permissions for
+// each of the org's folders
+func SetOrgMemberRole(orgID, userID, role string) error {
+	s := `UPDATE org_members SET role=$3 WHERE org_id=$1 AND user_id=$2`
+	result, err := db.Exec(s, orgID, userID, role)
+	if err != nil {
+		return err
+	} else if affected, _ := result.RowsAffected(); affected == 0 {
+		return OrgNotFoundError
+	}
+
+	s = `UPDATE folders SET can_modify=$3
+	     WHERE owner_id=$2 AND ref_id IN (` + orgFolderQuery + `)`
+	_, err = db.Exec(s, orgID, userID, orgRoleCanModify(role))
+	return err
+}
+
+// RemoveOrgMember removes a user from an organization. The user's linked org
+// folders are deleted, and any files they uploaded to the org's folders are
+// transferred to the org. Since the removed member may still have copies of
+// the org folder keys, the org is flagged as needing its keys rotated.
+func RemoveOrgMember(orgID, userID string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	_, _, err = getOrgState(tx, orgID)
+	if err != nil {
+		return err
+	}
+
+	s := `DELETE FROM folders
+	      WHERE owner_id=$2 AND ref_id IN (` + orgFolderQuery + `)`
+	_, err = tx.Exec(s, orgID, userID)
+	if err != nil {
+		return err
+	}
+
+	s = `WITH RECURSIVE org_tree AS (
+	         ` + orgFolderQuery + `
+	         UNION ALL
+	         SELECT f.id FROM folders f
+	         INNER JOIN org_tree t ON f.parent_id = t.id
+	     )
+	     UPDATE vault SET owner_id=$1
+	     WHERE owner_id=$2 AND folder_id IN (SELECT id FROM org_tree)`
+	_, err = tx.Exec(s, orgID, userID)
+	if err != nil {
+		return err
+	}
+
+	s = `DELETE FROM org_members WHERE org_id=$1 AND user_id=$2`
+	_, err = tx.Exec(s, orgID, userID)
+	if err != nil {
+		return err
+	}
+
+	s = `UPDATE organizations
+	     SET rotate_keys = rotate_keys OR EXISTS(` + orgFolderQuery + `)
+	     WHERE id=$1`
+	_, err = tx.Exec(s, orgID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// NewOrgFolder creates a top level folder owned by the organization. The folder
+// key is only stored in the members' linked folders, since the org itself
+// doesn't have a key pair.
+func NewOrgFolder(orgID string, folder shared.NewOrgFolder) (string, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return "", err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	orgName, rotateKeys, err := getOrgState(tx, orgID)
+	if err != nil {
+		return "", err
+	} else if rotateKeys {
+		return "", OrgRotationPendingError
+	}
+
+	members, err := queryOrgMembers(tx, orgID)
+	if err != nil {
+		return "", err
+	}
+
+	var memberIDs []string
+	for _, member := range members {
+		memberIDs = append(memberIDs, member.ID)
+	}
+
+	if !keysMatchIDs(folder.MemberKeys, memberIDs) {
+		return "", OrgKeyMismatchError
+	}
+
+	folderID := shared.GenRandomString(VaultIDLength)
+	for FolderIDExists(folderID) {
+		folderID = shared.GenRandomString(VaultIDLength)
+	}
+
+	err = insertFolder(tx, folderID, orgID, shared.NewVaultFolder{
+		Name:         folder.Name,
+		ParentID:     orgID,
+		ProtectedKey: []byte{},
+	}, false)
+	if err != nil {
+		return "", err
+	}
+
+	for _, member := range members {
+		for _, key := range folder.MemberKeys {
+			if key.ID != member.ID {
+				continue
+			}
+
+			err = linkOrgFolder(tx, folderID, member.ID, orgName, key.ProtectedKey, member.Role)
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+
+	return folderID, tx.Commit()
+}
+
+// RotateOrgKeys replaces the keys of all org folders. The rotation needs to
+// contain every org folder, and every item, subfolder, and member of each
+// folder, otherwise nothing is updated.
+//
+// Only the top level folder keys are replaced. The keys of their subfolders
+// are re-encrypted with the new folder key but stay the same, so a removed
+// member who kept a subfolder key can still decrypt files added to that
+// subfolder later. Items uploaded using a file request that are still
+// encrypted with a user's public key aren't included, since they don't use
+// the folder key.
+func RotateOrgKeys(orgID string, rotation shared.OrgKeyRotation) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	_, _, err = getOrgState(tx, orgID)
+	if err != nil {
+		return err
+	}
+
+	folderIDs, err := queryIDs(tx, orgFolderQuery, orgID)
+	if err != nil {
+		return err
+	}
+
+	members, err := queryOrgMembers(tx, orgID)
+	if err != nil {
+		return err
+	}
+
+	var memberIDs []string
+	for _, member := range members {
+		memberIDs = append(memberIDs, member.ID)
+	}
+
+	var rotatedIDs []shared.OrgKey
+	for _, folder := range rotation.Folders {
+		rotatedIDs = append(rotatedIDs, shared.OrgKey{ID: folder.FolderID})
+
+		itemIDs, err := queryIDs(tx, `SELECT id FROM vault
+		                          WHERE folder_id=$1 AND id=ref_id
+		                          AND pending_key=false`, folder.FolderID)
+		if err != nil {
+			return err
+		}
+
+		subfolderIDs, err := queryIDs(tx, `SELECT id FROM folders
+		                               WHERE parent_id=$1 AND id=ref_id`,
+			folder.FolderID)
+		if err != nil {
+			return err
+		}
+
+		if len(folder.Name) == 0 ||
+			!keysMatchIDs(folder.Items, itemIDs) ||
+			!keysMatchIDs(folder.Subfolders, subfolderIDs) ||
+			!keysMatchIDs(folder.MemberKeys, memberIDs) {
+			return OrgKeyMismatchError
+		}
+	}
+
+	if !keysMatchIDs(rotatedIDs, folderIDs) {
+		return OrgKeyMismatchError
+	}
+
+	for _, folder := range rotation.Folders {
+		err = rotateOrgFolder(tx, folder)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(`UPDATE organizations SET rotate_keys=false WHERE id=$1`, orgID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func rotateOrgFolder(tx *sql.Tx, folder shared.OrgFolderRotation) error {
+	for _, item := range folder.Items {
+		s := `UPDATE vault SET protected_key=$3 WHERE id=$1 AND folder_id=$2`
+		_, err := tx.Exec(s, item.ID, folder.FolderID, item.ProtectedKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, subfolder := range folder.Subfolders {
+		s := `UPDATE folders SET protected_key=$3 WHERE id=$1 AND parent_id=$2`
+		_, err := tx.Exec(s, subfolder.ID, folder.FolderID, subfolder.ProtectedKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, member := range folder.MemberKeys {
+		s := `UPDATE folders SET protected_key=$3 WHERE ref_id=$1 AND owner_id=$2`
+		_, err := tx.Exec(s, folder.FolderID, member.ID, member.ProtectedKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	s := `UPDATE folders SET name=$2, modified=$3 WHERE ref_id=$1`
+	_, err := tx.Exec(s, folder.FolderID, folder.Name, time.Now().UTC())
+	return err
+}
+
+// DeleteOrganization removes an organization and its members. The org's
+// folders need to be deleted beforehand.
+func DeleteOrganization(orgID string) error {
+	_, err := db.Exec(`DELETE FROM org_members WHERE org_id=$1`, orgID)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`DELETE FROM organizations WHERE id=$1`, orgID)
+	return err
+}
+
+// GetOrgStorage returns the used and available storage for an organization.
+// Storage is pooled across all members, so the available storage is the sum of
+// each member's storage, and the used storage includes both the members' own
+// files and the files in org folders.
+func GetOrgStorage(orgID string) (int64, int64, error) {
+	return queryOrgStorage(db, orgID)
+}
+
+func queryOrgStorage(conn dbConn, orgID string) (int64, int64, error) {
+	var storageUsed int64
+	var storageAvailable int64
+	s := `SELECT o.storage_used + COALESCE(SUM(u.storage_used), 0),
+	             COALESCE(SUM(u.storage_available), 0)
+	      FROM organizations o
+	      LEFT JOIN org_members m ON m.org_id = o.id
+	      LEFT JOIN users u ON u.id = m.user_id
+	      WHERE o.id=$1
+	      GROUP BY o.id`
+	err := conn.QueryRow(s, orgID).Scan(&storageUsed, &storageAvailable)
+	if err == sql.ErrNoRows {
+		return 0, 0, OrgNotFoundError
+	}
+
+	return storageUsed, storageAvailable, err
+}
+
+// UpdateOrgStorage updates the amount of storage used by files in org folders
+func UpdateOrgStorage(orgID string, amount int64) error {
+	s := `UPDATE organizations
+	      SET storage_used = CASE
+	                           WHEN storage_used + $2 < 0 THEN 0
+	                           ELSE storage_used + $2
+	                         END
+	      WHERE id=$1`
+	_, err := db.Exec(s, orgID, amount)
+	if err != nil || amount <= 0 || config.YeetFileConfig.DefaultUserStorage <= 0 {
+		return err
+	}
+
+	storageUsed, storageAvailable, err := GetOrgStorage(orgID)
+	if err != nil {
+		return err
+	} else if storageUsed > storageAvailable {
+		return UserStorageExceeded
+	}
+
+	return nil
+}
+
+// isOverStorageLimit checks if a user has used more than their available
+// storage. Members of an organization share the org's pooled storage instead.
+func isOverStorageLimit(
+	conn dbConn,
+	userID string,
+	storageUsed, storageAvailable int64,
+) (bool, error) {
+	orgID, _, err := GetUserOrg(userID)
+	if err != nil {
+		return false, err
+	} else if len(orgID) > 0 {
+		storageUsed, storageAvailable, err = queryOrgStorage(conn, orgID)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return storageUsed > storageAvailable, nil
+}
+
+// getOrgState returns the org's name and whether its keys need to be rotated.
+// The org is locked until the end of the transaction, so that changes to its
+// members and folders are made one at a time.
+func getOrgState(tx *sql.Tx, orgID string) (string, bool, error) {
+	var name string
+	var rotateKeys bool
+	s := `SELECT name, rotate_keys FROM organizations WHERE id=$1 FOR UPDATE`
+	err := tx.QueryRow(s, orgID).Scan(&name, &rotateKeys)
+	if err == sql.ErrNoRows {
+		return "", false, OrgNotFoundError
+	}
+
+	return name, rotateKeys, err
+}
+
+// linkOrgFolder adds an org folder to a member's root folder, using the folder
+// key encrypted with the member's public key
+func linkOrgFolder(
+	tx *sql.Tx,
+	folderID, userID, orgName string,
+	protectedKey []byte,
+	role string,
+) error {
+	linkID := shared.GenRandomString(VaultIDLength)
+	for FolderIDExists(linkID) {
+		linkID = shared.GenRandomString(VaultIDLength)
+	}
+
+	s := `INSERT INTO folders (id, name, parent_id, owner_id, protected_key,
+	                           shared_by, modified, ref_id, can_modify, pw_folder)
+	      SELECT $1, name, $2, $2, $3, $4, $5, id, $6, false
+	      FROM folders WHERE id=$7`
+	_, err := tx.Exec(s, linkID, userID, protectedKey, orgName,
+		time.Now().UTC(), orgRoleCanModify(role), folderID)
+	return err
+}
+
+func orgRoleCanModify(role string) bool {
+	return role != constants.OrgRoleReadOnly
+}
+
+// keysMatchIDs checks that there's exactly one key for each of the IDs
+func keysMatchIDs(keys []shared.OrgKey, ids []string) bool {
+	var keyIDs []string
+	for _, key := range keys {
+		keyIDs = append(keyIDs, key.ID)
+	}
+
+	return idsMatch(keyIDs, ids)
+}
+
+// idsMatch checks that both lists contain the same IDs, with no duplicates
+func idsMatch(keyIDs []string, ids []string) bool {
+	if len(keyIDs) != len(ids) {
+		return false
+	}
+
+	remaining := make(map[string]bool)
+	for _, id := range ids {
+		remaining[id] = true
+	}
+
+	for _, keyID := range keyIDs {
+		if !remaining[keyID] {
+			return false
+		}
+
+		delete(remaining, keyID)
+	}
+
+	return true
+}
+
+func queryIDs(conn dbConn, query string, args ...any) ([]string, error) {
+	var ids []string
+	rows, err := conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var id string
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
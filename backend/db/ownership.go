@@ -0,0 +1,486 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/lib/pq"
+	"time"
+	"yeetfile/shared"
+	"yeetfile/shared/constants"
+)
+
+const transferIDLength = 16
+
+// folderTreeQuery returns the IDs of a folder and all of its subfolders. Links
+// to shared folders always live in the recipient's root folder, so the tree
+// only contains folders owned by the folder's owner.
+const folderTreeQuery = `WITH RECURSIVE folder_tree AS (
+	    SELECT id FROM folders WHERE id = $1
+	    UNION ALL
+	    SELECT f.id FROM folders f
+	    INNER JOIN folder_tree ft ON f.parent_id = ft.id
+	)
+	SELECT id FROM folder_tree`
+
+var (
+	TransferNotFoundError = errors.New("ownership transfer not found")
+	TransferPendingError  = errors.New("item already has a pending ownership transfer")
+	TransferItemError     = errors.New("only items owned by the user can be transferred")
+	PendingUploadsError   = errors.New("folder contains file request uploads that need to be opened first")
+)
+
+// transferItems contains the IDs of every folder and file that are moved to
+// a new owner as part of an ownership transfer
+type transferItems struct {
+	folderIDs []string
+	fileIDs   []string
+}
+
+func (items transferItems) all() []string {
+	return append(append([]string{}, items.folderIDs...), items.fileIDs...)
+}
+
+// NewOwnershipTransfer creates a pending transfer of a file or folder to another
+// user. The protected key is the item's key encrypted with the recipient's
+// public key, which is how the keys of items in a user's root folder are
+// stored, so the recipient doesn't need to re-wrap it when accepting.
+func NewOwnershipTransfer(
+	ownerID,
+	recipientID,
+	itemID string,
+	protectedKey []byte,
+	isFolder bool,
+) (string, error) {
+	if ownerID == recipientID {
+		return "", errors.New("cannot transfer an item to its owner")
+	} else if len(protectedKey) == 0 {
+		return "", errors.New("missing protected key")
+	}
+
+	err := checkTransferableItem(ownerID, itemID, isFolder)
+	if err != nil {
+		return "", err
+	}
+
+	isBlocked, err := IsUserBlocked(recipientID, ownerID)
+	if err != nil {
+		return "", err
+	} else if isBlocked {
+		return "", BlockedSenderError
+	}
+
+	isPending, err := isTransferPending(itemID)
+	if err != nil {
+		return "", err
+	} else if isPending {
+		return "", TransferPendingError
+	}
+
+	transferID := shared.GenRandomString(transferIDLength)
+	for TableIDExists("ownership_transfers", transferID) {
+		transferID = shared.GenRandomString(transferIDLength)
+	}
+
+	s := `INSERT INTO ownership_transfers
+	      (id, owner_id, recipient_id, item_id, is_folder, protected_key, created)
+	      VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	_, err = db.Exec(s, transferID, ownerID, recipientID, itemID, isFolder,
+		protectedKey, time.Now().UTC())
+	return transferID, err
+}
+
+// GetOwnershipTransfers returns the pending transfers that a user has been
+// offered, as well as the transfers they've started for their own items
+func GetOwnershipTransfers(userID string) (shared.OwnershipTransfersResponse, error) {
+	response := shared.OwnershipTransfersResponse{
+		Incoming: []shared.OwnershipTransfer{},
+		Outgoing: []shared.OwnershipTransfer{},
+	}
+
+	s := `SELECT id, owner_id, recipient_id, item_id, is_folder, protected_key, created
+	      FROM ownership_transfers
+	      WHERE owner_id=$1 OR recipient_id=$1
+	      ORDER BY created DESC`
+	rows, err := db.Query(s, userID)
+	if err != nil {
+		return response, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var transfer shared.OwnershipTransfer
+		var ownerID, recipientID, itemID string
+		var created sql.NullTime
+
+		err = rows.Scan(
+			&transfer.ID,
+			&ownerID,
+			&recipientID,
+			&itemID,
+			&transfer.IsFolder,
+			&transfer.ProtectedKey,
+			&created)
+		if err != nil {
+			return response, err
+		}
+
+		transfer.Created = created.Time
+		items, err := getTransferItems(db, itemID, transfer.IsFolder)
+		if err != nil {
+			return response, err
+		}
+
+		transfer.Size, err = getTransferSize(db, ownerID, items)
+		if err != nil {
+			return response, err
+		}
+
+		if ownerID != userID {
+			transfer.UserName, err = GetUserPublicName(ownerID)
+			if err != nil {
+				transfer.UserName = "???"
+			}
+
+			transfer.PublicKey, err = GetUserPubKey(ownerID)
+			if err != nil {
+				return response, err
+			}
+
+			transfer.Name, err = getTransferItemName(itemID, transfer.IsFolder)
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			} else if err != nil {
+				return response, err
+			}
+
+			response.Incoming = append(response.Incoming, transfer)
+			continue
+		}
+
+		transfer.UserName, err = GetUserPublicName(recipientID)
+		if err != nil {
+			transfer.UserName = "???"
+		}
+
+		// The item key is encrypted for the recipient, so it isn't useful
+		// to the owner
+		transfer.ProtectedKey = nil
+		response.Outgoing = append(response.Outgoing, transfer)
+	}
+
+	return response, nil
+}
+
+// AcceptOwnershipTransfer moves a file or folder (including all subfolders and
+// their contents) into the recipient's root folder and makes them the owner.
+// The storage used by the item is moved from the previous owner to the
+// recipient, and existing shares of the item are updated to be shared by the
+// recipient instead. If the item was shared with the recipient, their link to
+// the item is removed. Everything is done in a single transaction, so that a
+// failure doesn't leave the item partially transferred.
+func AcceptOwnershipTransfer(recipientID, transferID string) error {
+	var (
+		ownerID      string
+		itemID       string
+		isFolder     bool
+		protectedKey []byte
+	)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	// The transfer is removed before anything else, so that it can't be
+	// accepted more than once by concurrent requests
+	s := `DELETE FROM ownership_transfers
+	      WHERE id=$1 AND recipient_id=$2
+	      RETURNING owner_id, item_id, is_folder, protected_key`
+	err = tx.QueryRow(s, transferID, recipientID).Scan(
+		&ownerID, &itemID, &isFolder, &protectedKey)
+	if errors.Is(err, sql.ErrNoRows) {
+		return TransferNotFoundError
+	} else if err != nil {
+		return err
+	}
+
+	// The owner may have moved the item into a shared folder, etc, since
+	// starting the transfer
+	err = checkTransferableItem(ownerID, itemID, isFolder)
+	if err != nil {
+		return err
+	}
+
+	items, err := getTransferItems(tx, itemID, isFolder)
+	if err != nil {
+		return err
+	}
+
+	hasPendingKeys, err := hasPendingItemKeys(tx, items.fileIDs)
+	if err != nil {
+		return err
+	} else if hasPendingKeys {
+		return PendingUploadsError
+	}
+
+	size, err := getTransferSize(tx, ownerID, items)
+	if err != nil {
+		return err
+	}
+
+	err = updateStorageUsed(tx, recipientID, size)
+	if err != nil {
+		return err
+	}
+
+	err = updateStorageUsed(tx, ownerID, -size)
+	if err != nil {
+		return err
+	}
+
+	err = moveTransferItems(tx, ownerID, recipientID, itemID, isFolder, protectedKey, items)
+	if err != nil {
+		return err
+	}
+
+	err = transferShares(tx, ownerID, recipientID, items)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeclineOwnershipTransfer removes a transfer that was offered to the user
+func DeclineOwnershipTransfer(recipientID, transferID string) error {
+	s := `DELETE FROM ownership_transfers WHERE id=$1 AND recipient_id=$2`
+	return deleteOwnershipTransfer(s, transferID, recipientID)
+}
+
+// CancelOwnershipTransfer removes a transfer that the user started
+func CancelOwnershipTransfer(ownerID, transferID string) error {
+	s := `DELETE FROM ownership_transfers WHERE id=$1 AND owner_id=$2`
+	return deleteOwnershipTransfer(s, transferID, ownerID)
+}
+
+// DeleteUserOwnershipTransfers removes all pending transfers involving a user,
+// used when deleting their account
+func DeleteUserOwnershipTransfers(userID string) error {
+	s := `DELETE FROM ownership_transfers WHERE owner_id=$1 OR recipient_id=$1`
+	_, err := db.Exec(s, userID)
+	return err
+}
+
+// RemoveOwnershipTransferByItemID removes the pending transfer for an item, if
+// there is one, used when the item is deleted
+func RemoveOwnershipTransferByItemID(itemID string) error {
+	s := `DELETE FROM ownership_transfers WHERE item_id=$1`
+	_, err := db.Exec(s, itemID)
+	return err
+}
+
+func deleteOwnershipTransfer(query, transferID, userID string) error {
+	result, err := db.Exec(query, transferID, userID)
+	if err != nil {
+		return err
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	} else if count == 0 {
+		return TransferNotFoundError
+	}
+
+	return nil
+}
+
+// checkTransferableItem returns an error if the file or folder isn't owned by
+// the user. Items that the user has added to another user's shared folder or
+// an organization folder can't be transferred, since the user doesn't own the
+// parent folder.
+func checkTransferableItem(ownerID, itemID string, isFolder bool) error {
+	folderID := itemID
+	if isFolder {
+		if itemID == ownerID {
+			return errors.New("cannot transfer user's root folder")
+		}
+	} else {
+		s := `SELECT folder_id FROM vault
+		      WHERE id=$1 AND ref_id=$1 AND owner_id=$2`
+		err := db.QueryRow(s, itemID, ownerID).Scan(&folderID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return TransferItemError
+		} else if err != nil {
+			return err
+		}
+	}
+
+	ownership, err := GetFolderOwnership(folderID, ownerID)
+	if err == FolderNotFoundError || (err == nil && !ownership.IsOwner) {
+		return TransferItemError
+	}
+
+	return err
+}
+
+// isTransferPending checks to see if there's already a pending transfer for an
+// item
+func isTransferPending(itemID string) (bool, error) {
+	var count int
+	s := `SELECT COUNT(*) FROM ownership_transfers WHERE item_id=$1`
+	err := db.QueryRow(s, itemID).Scan(&count)
+	return count > 0, err
+}
+
+// getTransferItems returns the IDs of all folders and files included in the
+// transfer of an item
+func getTransferItems(conn dbConn, itemID string, isFolder bool) (transferItems, error) {
+	if !isFolder {
+		return transferItems{fileIDs: []string{itemID}}, nil
+	}
+
+	folderIDs, err := queryIDs(conn, folderTreeQuery, itemID)
+	if err != nil {
+		return transferItems{}, err
+	}
+
+	s := `SELECT id FROM vault WHERE folder_id=ANY($1)`
+	fileIDs, err := queryIDs(conn, s, pq.Array(folderIDs))
+	if err != nil {
+		return transferItems{}, err
+	}
+
+	return transferItems{folderIDs: folderIDs, fileIDs: fileIDs}, nil
+}
+
+// getTransferSize returns the amount of storage used by the owner's files
+// included in a transfer, which matches what the owner was charged when
+// uploading them. Files that other users added to a shared folder stay owned
+// by them, so they aren't included.
+func getTransferSize(conn dbConn, ownerID string, items transferItems) (int64, error) {
+	var size int64
+	s := `SELECT COALESCE(SUM(GREATEST(length - $3 * chunks, 0)), 0) FROM vault
+	      WHERE id=ANY($1) AND owner_id=$2
+	        AND (pw_data IS NULL OR LENGTH(pw_data) = 0)`
+	err := conn.QueryRow(s, pq.Array(items.fileIDs), ownerID,
+		constants.TotalOverhead).Scan(&size)
+	return size, err
+}
+
+func getTransferItemName(itemID string, isFolder bool) (string, error) {
+	var name string
+	s := `SELECT name FROM vault WHERE id=$1`
+	if isFolder {
+		s = `SELECT name FROM folders WHERE id=$1`
+	}
+
+	err := db.QueryRow(s, itemID).Scan(&name)
+	return name, err
+}
+
+// hasPendingItemKeys checks to see if any of the files were uploaded via a file
+// request and still have a key that can only be decrypted by the owner
+func hasPendingItemKeys(conn dbConn, fileIDs []string) (bool, error) {
+	var count int
+	s := `SELECT COUNT(*) FROM vault WHERE id=ANY($1) AND pending_key=true`
+	err := conn.QueryRow(s, pq.Array(fileIDs)).Scan(&count)
+	return count > 0, err
+}
+
+// moveTransferItems updates the owner of all items in a transfer and moves the
+// transferred item into the recipient's root folder
+func moveTransferItems(
+	tx *sql.Tx,
+	ownerID,
+	recipientID,
+	itemID string,
+	isFolder bool,
+	protectedKey []byte,
+	items transferItems,
+) error {
+	now := time.Now().UTC()
+	if isFolder {
+		s := `UPDATE folders SET owner_id=$1 WHERE id=ANY($2) AND owner_id=$3`
+		_, err := tx.Exec(s, recipientID, pq.Array(items.folderIDs), ownerID)
+		if err != nil {
+			return err
+		}
+
+		s = `UPDATE folders SET parent_id=$1, protected_key=$2, modified=$3
+		     WHERE id=$4`
+		_, err = tx.Exec(s, recipientID, protectedKey, now, itemID)
+		if err != nil {
+			return err
+		}
+
+		// File requests for the folders would otherwise keep uploading
+		// files encrypted with the previous owner's public key
+		s = `DELETE FROM file_requests WHERE folder_id=ANY($1)`
+		_, err = tx.Exec(s, pq.Array(items.folderIDs))
+		if err != nil {
+			return err
+		}
+	} else {
+		s := `UPDATE vault SET folder_id=$1, protected_key=$2, modified=$3
+		      WHERE id=$4`
+		_, err := tx.Exec(s, recipientID, protectedKey, now, itemID)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Files added to a shared folder by other users keep their uploader as
+	// the owner, same as before the transfer
+	s := `UPDATE vault SET owner_id=$1 WHERE id=ANY($2) AND owner_id=$3`
+	_, err := tx.Exec(s, recipientID, pq.Array(items.fileIDs), ownerID)
+	return err
+}
+
+// transferShares updates the sharing entries for all items in a transfer to
+// be owned by the recipient, and updates the name shown to users that the
+// items are shared with. Shares with the recipient are removed, since they now
+// own the items.
+func transferShares(tx *sql.Tx, ownerID, recipientID string, items transferItems) error {
+	itemIDs := pq.Array(items.all())
+
+	s := `DELETE FROM folders WHERE ref_id=ANY($1) AND owner_id=$2 AND id != ref_id`
+	_, err := tx.Exec(s, itemIDs, recipientID)
+	if err != nil {
+		return err
+	}
+
+	s = `DELETE FROM vault WHERE ref_id=ANY($1) AND owner_id=$2 AND id != ref_id`
+	_, err = tx.Exec(s, itemIDs, recipientID)
+	if err != nil {
+		return err
+	}
+
+	s = `DELETE FROM sharing WHERE item_id=ANY($1) AND recipient_id=$2`
+	_, err = tx.Exec(s, itemIDs, recipientID)
+	if err != nil {
+		return err
+	}
+
+	s = `UPDATE sharing SET owner_id=$1 WHERE item_id=ANY($2) AND owner_id=$3`
+	_, err = tx.Exec(s, recipientID, itemIDs, ownerID)
+	if err != nil {
+		return err
+	}
+
+	sharedByName, _ := GetUserPublicName(recipientID)
+
+	s = `UPDATE folders SET shared_by=$1 WHERE ref_id=ANY($2) AND id != ref_id`
+	_, err = tx.Exec(s, sharedByName, itemIDs)
+	if err != nil {
+		return err
+	}
+
+	s = `UPDATE vault SET shared_by=$1 WHERE ref_id=ANY($2) AND id != ref_id`
+	_, err = tx.Exec(s, sharedByName, itemIDs)
+	return err
+}
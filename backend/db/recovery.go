@@ -0,0 +1,27 @@
+package db
+
+// SetUserRecoveryKey stores the bcrypt hash of the user's recovery key hash,
+// along with a copy of their private key encrypted with their recovery key.
+func SetUserRecoveryKey(userID string, recoveryHash, protectedKey []byte) error {
+	s := `UPDATE users SET recovery_hash=$2, recovery_key=$3 WHERE id=$1`
+	_, err := db.Exec(s, userID, recoveryHash, protectedKey)
+	return err
+}
+
+// RemoveUserRecoveryKey removes the user's recovery key, if they have one
+func RemoveUserRecoveryKey(userID string) error {
+	s := `UPDATE users SET recovery_hash=NULL, recovery_key=NULL WHERE id=$1`
+	_, err := db.Exec(s, userID)
+	return err
+}
+
+// GetUserRecoveryKey returns the bcrypt hash of the user's recovery key hash
+// and their private key encrypted with their recovery key. Both are empty if
+// the user hasn't set a recovery key.
+func GetUserRecoveryKey(userID string) ([]byte, []byte, error) {
+	var recoveryHash []byte
+	var protectedKey []byte
+	s := `SELECT recovery_hash, recovery_key FROM users WHERE id=$1`
+	err := db.QueryRow(s, userID).Scan(&recoveryHash, &protectedKey)
+	return recoveryHash, protectedKey, err
+}
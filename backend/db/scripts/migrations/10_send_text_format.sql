@@ -0,0 +1 @@
+ALTER TABLE metadata ADD COLUMN format bytea DEFAULT NULL;
@@ -0,0 +1,9 @@
+create table if not exists send_recipients
+(
+    send_id       text  not null,
+    user_id       text  not null,
+    protected_key bytea not null,
+    created       timestamp,
+    constraint send_recipients_pk
+        primary key (send_id, user_id)
+);
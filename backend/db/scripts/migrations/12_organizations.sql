@@ -0,0 +1,24 @@
+create table if not exists organizations
+(
+    id           text   not null
+        constraint organizations_pk
+            primary key,
+    name         text   not null,
+    owner_id     text   not null,
+    storage_used bigint  default 0,
+    rotate_keys  boolean default false,
+    created      timestamp
+);
+
+create table if not exists org_members
+(
+    org_id  text not null,
+    user_id text not null,
+    role    text not null,
+    created timestamp,
+    constraint org_members_pk
+        primary key (org_id, user_id)
+);
+
+create unique index if not exists org_members_user_id_index
+    on org_members (user_id);
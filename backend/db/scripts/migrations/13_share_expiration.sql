@@ -0,0 +1,3 @@
+ALTER TABLE sharing ADD COLUMN expiration timestamp DEFAULT NULL;
+ALTER TABLE sharing ADD COLUMN notified boolean DEFAULT false;
+UPDATE sharing SET notified = false;
@@ -0,0 +1,17 @@
+ALTER TABLE sharing ADD COLUMN accepted boolean DEFAULT false;
+ALTER TABLE sharing ADD COLUMN protected_key bytea DEFAULT NULL;
+ALTER TABLE sharing ADD COLUMN created timestamp DEFAULT NULL;
+UPDATE sharing SET accepted = true;
+
+create table if not exists blocked_users
+(
+    id         text not null
+        constraint blocked_users_pk
+            primary key,
+    user_id    text not null,
+    blocked_id text not null,
+    created    timestamp
+);
+
+create unique index if not exists blocked_users_user_id_blocked_id_index
+    on blocked_users (user_id, blocked_id);
@@ -0,0 +1,15 @@
+create table if not exists ownership_transfers
+(
+    id            text  not null
+        constraint ownership_transfers_pk
+            primary key,
+    owner_id      text  not null,
+    recipient_id  text  not null,
+    item_id       text  not null,
+    is_folder     boolean default false,
+    protected_key bytea not null,
+    created       timestamp
+);
+
+create unique index if not exists ownership_transfers_item_id_index
+    on ownership_transfers (item_id);
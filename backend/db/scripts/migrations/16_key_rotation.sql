@@ -0,0 +1,2 @@
+ALTER TABLE users ADD COLUMN key_version integer DEFAULT 1;
+UPDATE users SET key_version = 1;
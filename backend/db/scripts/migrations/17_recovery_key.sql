@@ -0,0 +1,4 @@
+ALTER TABLE users ADD COLUMN recovery_hash bytea DEFAULT NULL;
+ALTER TABLE users ADD COLUMN recovery_key bytea DEFAULT NULL;
+ALTER TABLE verify ADD COLUMN recovery_hash bytea DEFAULT NULL;
+ALTER TABLE verify ADD COLUMN recovery_key bytea DEFAULT NULL;
@@ -0,0 +1,16 @@
+create table if not exists emergency_access
+(
+    id            text    not null
+        constraint emergency_access_pk
+            primary key,
+    owner_id      text    not null,
+    contact_id    text    not null,
+    access_type   text    not null,
+    wait_days     integer not null,
+    protected_key bytea,
+    requested     timestamp,
+    created       timestamp
+);
+
+create unique index if not exists emergency_access_owner_contact_index
+    on emergency_access (owner_id, contact_id);
@@ -0,0 +1,6 @@
+ALTER TABLE users ADD COLUMN kdf_algorithm text DEFAULT 'argon2id';
+ALTER TABLE users ADD COLUMN kdf_iterations integer DEFAULT 2;
+ALTER TABLE users ADD COLUMN kdf_memory integer DEFAULT 64;
+ALTER TABLE verify ADD COLUMN kdf_algorithm text DEFAULT 'argon2id';
+ALTER TABLE verify ADD COLUMN kdf_iterations integer DEFAULT 2;
+ALTER TABLE verify ADD COLUMN kdf_memory integer DEFAULT 64;
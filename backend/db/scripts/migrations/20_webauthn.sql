@@ -0,0 +1,29 @@
+create table if not exists webauthn_credentials
+(
+    id            text    not null
+        constraint webauthn_credentials_pk
+            primary key,
+    user_id       text    not null,
+    name          text    not null,
+    credential_id bytea   not null,
+    public_key    bytea   not null,
+    sign_count    bigint  default 0,
+    last_used     timestamp,
+    created       timestamp
+);
+
+create unique index if not exists webauthn_credentials_credential_id_index
+    on webauthn_credentials (credential_id);
+
+create index if not exists webauthn_credentials_user_id_index
+    on webauthn_credentials (user_id);
+
+create table if not exists webauthn_challenges
+(
+    user_id   text      not null,
+    ceremony  text      not null,
+    challenge bytea     not null,
+    expires   timestamp not null,
+    constraint webauthn_challenges_pk
+        primary key (user_id, ceremony)
+);
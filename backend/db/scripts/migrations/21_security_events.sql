@@ -0,0 +1,14 @@
+create table if not exists security_events
+(
+    user_id    text not null,
+    event_type text not null,
+    ip_address text,
+    user_agent text,
+    details    text,
+    created    timestamp
+);
+
+create index if not exists security_events_user_id_created_index
+    on security_events (user_id, created);
+
+ALTER TABLE users ADD COLUMN login_alerts boolean DEFAULT true;
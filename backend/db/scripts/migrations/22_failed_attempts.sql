@@ -0,0 +1,9 @@
+create table if not exists failed_attempts
+(
+    user_id      text not null,
+    kind         text not null,
+    failures     integer default 0 not null,
+    last_failure timestamp,
+    locked_until timestamp,
+    primary key (user_id, kind)
+);
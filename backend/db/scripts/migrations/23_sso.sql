@@ -0,0 +1,18 @@
+create table if not exists oidc_states
+(
+    state    text primary key,
+    nonce    text not null,
+    verifier text not null,
+    is_cli   boolean default false,
+    expires  timestamp
+);
+
+create table if not exists sso_tickets
+(
+    id       text primary key,
+    email    text not null,
+    is_admin boolean default false,
+    expires  timestamp
+);
+
+ALTER TABLE users ADD COLUMN sso_admin boolean DEFAULT false;
@@ -0,0 +1,6 @@
+ALTER TABLE metadata ADD COLUMN notify boolean DEFAULT false;
+UPDATE metadata SET notify = false;
+
+-- Sends created before this migration are left with a null total, since it's
+-- unknown how many times they've already been downloaded
+ALTER TABLE expiry ADD COLUMN total_downloads smallint;
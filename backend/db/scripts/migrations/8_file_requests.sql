@@ -0,0 +1,24 @@
+create table if not exists file_requests
+(
+    id          text not null
+        constraint file_requests_pk
+            primary key,
+    owner_id    text not null,
+    folder_id   text not null,
+    max_uploads integer default 0,
+    uploads     integer default 0,
+    max_size    bigint  default 0,
+    expiration  timestamp,
+    created     timestamp
+);
+
+ALTER TABLE vault ADD COLUMN request_id text DEFAULT '';
+UPDATE vault SET request_id = '';
+
+ALTER TABLE vault ADD COLUMN pending_key boolean DEFAULT false;
+UPDATE vault SET pending_key = false;
+
+-- Tracks whether a file uploaded using a file request is still receiving
+-- chunks, so that chunks can't be added once the upload has finished
+ALTER TABLE vault ADD COLUMN request_uploading boolean DEFAULT false;
+UPDATE vault SET request_uploading = false;
@@ -0,0 +1 @@
+ALTER TABLE metadata ADD COLUMN manifest bytea DEFAULT NULL;
@@ -0,0 +1,105 @@
+package db
+
+import (
+	"database/sql"
+	"log"
+	"time"
+	"yeetfile/shared"
+	"yeetfile/shared/constants"
+)
+
+// NewSecurityEvent records an event in the user's security log
+func NewSecurityEvent(userID string, event shared.SecurityEvent) error {
+	s := `INSERT INTO security_events
+	      (user_id, event_type, ip_address, user_agent, details, created)
+	      VALUES ($1, $2, $3, $4, $5, $6)`
+	_, err := db.Exec(s, userID, event.Type, event.IPAddress, event.UserAgent,
+		event.Details, time.Now().UTC())
+	return err
+}
+
+// GetSecurityEvents returns the user's most recent security events, newest
+// first
+func GetSecurityEvents(userID string) ([]shared.SecurityEvent, error) {
+	events := []shared.SecurityEvent{}
+	s := `SELECT event_type, ip_address, user_agent, details, created
+	      FROM security_events
+	      WHERE user_id=$1
+	      ORDER BY created DESC
+	      LIMIT $2`
+	rows, err := db.Query(s, userID, constants.MaxSecurityEvents)
+	if err != nil {
+		return events, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var event shared.SecurityEvent
+		var ip, userAgent, details sql.NullString
+		err = rows.Scan(&event.Type, &ip, &userAgent, &details, &event.Created)
+		if err != nil {
+			return events, err
+		}
+
+		event.IPAddress = ip.String
+		event.UserAgent = userAgent.String
+		event.Details = details.String
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+// IsNewLoginSource returns true if the user has logged in before, but never
+// from the provided IP address or user agent
+func IsNewLoginSource(userID, ip, userAgent string) (bool, error) {
+	var hasLogins, knownIP, knownAgent bool
+	s := `SELECT COUNT(*) > 0,
+	             COALESCE(bool_or(ip_address=$3), false),
+	             COALESCE(bool_or(user_agent=$4), false)
+	      FROM security_events
+	      WHERE user_id=$1 AND event_type=$2`
+	err := db.QueryRow(s, userID, constants.EventLogin, ip, userAgent).Scan(
+		&hasLogins,
+		&knownIP,
+		&knownAgent)
+	if err != nil {
+		return false, err
+	}
+
+	return hasLogins && (!knownIP || !knownAgent), nil
+}
+
+// GetUserLoginAlerts returns true if the user should be emailed when logging
+// in from a new device or IP address
+func GetUserLoginAlerts(userID string) (bool, error) {
+	var loginAlerts sql.NullBool
+	s := `SELECT login_alerts FROM users WHERE id=$1`
+	err := db.QueryRow(s, userID).Scan(&loginAlerts)
+	return !loginAlerts.Valid || loginAlerts.Bool, err
+}
+
+// SetUserLoginAlerts enables or disables new login alerts for the user
+func SetUserLoginAlerts(userID string, loginAlerts bool) error {
+	s := `UPDATE users SET login_alerts=$2 WHERE id=$1`
+	_, err := db.Exec(s, userID, loginAlerts)
+	return err
+}
+
+// DeleteUserSecurityEvents removes every event from the user's security log
+func DeleteUserSecurityEvents(userID string) error {
+	s := `DELETE FROM security_events WHERE user_id=$1`
+	_, err := db.Exec(s, userID)
+	return err
+}
+
+// CleanUpSecurityEvents removes security events older than the retention
+// period defined by constants.SecurityLogRetentionDays
+func CleanUpSecurityEvents() {
+	retention := constants.SecurityLogRetentionDays * 24 * time.Hour
+	s := `DELETE FROM security_events WHERE created < $1`
+	_, err := db.Exec(s, time.Now().UTC().Add(-retention))
+	if err != nil {
+		log.Printf("Error cleaning up security events: %v\n", err)
+	}
+}
@@ -0,0 +1,104 @@
+package db
+
+import (
+	"log"
+	"time"
+	"yeetfile/shared"
+)
+
+// AddSendRecipient addresses a send to a user, storing the send key encrypted
+// with the user's public key.
+func AddSendRecipient(sendID, userID string, protectedKey []byte) error {
+	s := `INSERT INTO send_recipients (send_id, user_id, protected_key, created)
+	      VALUES ($1, $2, $3, $4)
+	      ON CONFLICT (send_id, user_id) DO UPDATE SET protected_key=$3`
+	_, err := db.Exec(s, sendID, userID, protectedKey, time.Now().UTC())
+	return err
+}
+
+// CanAccessSend checks if a user is allowed to download a send. Sends that
+// aren't addressed to any recipients can be downloaded by anyone with the
+// link, otherwise the user needs to be either a recipient or the owner of the
+// send. An empty user ID is used for requests without a session.
+func CanAccessSend(sendID, userID string) (bool, error) {
+	var canAccess bool
+	s := `SELECT NOT EXISTS(SELECT 1 FROM send_recipients WHERE send_id=$1)
+	          OR EXISTS(SELECT 1 FROM send_recipients
+	                    WHERE send_id=$1 AND user_id=$2)
+	          OR EXISTS(SELECT 1 FROM metadata
+	                    WHERE id=$1 AND owner_id=$2 AND owner_id != '')`
+	err := db.QueryRow(s, sendID, userID).Scan(&canAccess)
+	return canAccess, err
+}
+
+// IsSendRecipient checks if a send has been addressed to the user
+func IsSendRecipient(sendID, userID string) (bool, error) {
+	var exists bool
+	s := `SELECT EXISTS(SELECT 1 FROM send_recipients
+	                 WHERE send_id=$1 AND user_id=$2)`
+	err := db.QueryRow(s, sendID, userID).Scan(&exists)
+	return exists, err
+}
+
+// GetInboxSends returns the active sends that have been addressed to the user,
+// along with the send key encrypted with the user's public key.
+func GetInboxSends(userID string) ([]shared.InboxItem, error) {
+	result := []shared.InboxItem{}
+	var senderIDs []string
+
+	s := `SELECT m.id, m.filename, m.owner_id, m.length, m.manifest IS NOT NULL,
+	             e.downloads, e.date, r.protected_key, r.created
+	      FROM send_recipients r
+	      JOIN metadata m ON r.send_id = m.id
+	      JOIN expiry e ON m.id = e.id
+	      WHERE r.user_id=$1 AND e.date > CURRENT_TIMESTAMP at time zone 'UTC'
+	      ORDER BY r.created DESC`
+
+	rows, err := db.Query(s, userID)
+	if err != nil {
+		return result, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var item shared.InboxItem
+		var senderID string
+		err = rows.Scan(
+			&item.ID,
+			&item.Name,
+			&senderID,
+			&item.Size,
+			&item.Bundle,
+			&item.Downloads,
+			&item.Expiration,
+			&item.ProtectedKey,
+			&item.Created)
+		if err != nil {
+			return result, err
+		}
+
+		result = append(result, item)
+		senderIDs = append(senderIDs, senderID)
+	}
+
+	for i, senderID := range senderIDs {
+		result[i].SenderName, err = GetUserPublicName(senderID)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// DeleteSendRecipients removes all recipients of a send
+func DeleteSendRecipients(sendID string) bool {
+	s := `DELETE FROM send_recipients WHERE send_id=$1`
+	_, err := db.Exec(s, sendID)
+	if err != nil {
+		log.Printf("Error deleting send recipients: %v\n", err)
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,98 @@
+package db
+
+import (
+	"fmt"
+	"yeetfile/backend/crypto"
+)
+
+type encryptedUserValues struct {
+	id     string
+	secret []byte
+	hint   []byte
+}
+
+// ReencryptUserSecrets re-encrypts every user's TOTP secret and password hint
+// with the current server secret. Values that are already encrypted with the
+// current server secret are skipped. Each value is only replaced if it hasn't
+// changed since it was read, so that a user changing their 2FA or hint at the
+// same time isn't overwritten. Returns the number of updated users.
+func ReencryptUserSecrets() (int, error) {
+	s := `SELECT id, secret, pw_hint FROM users
+	      WHERE length(secret) > 0 OR length(pw_hint) > 0`
+	rows, err := db.Query(s)
+	if err != nil {
+		return 0, err
+	}
+
+	var users []encryptedUserValues
+	for rows.Next() {
+		var user encryptedUserValues
+		err = rows.Scan(&user.id, &user.secret, &user.hint)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+
+		users = append(users, user)
+	}
+
+	rows.Close()
+
+	updated := 0
+	for _, user := range users {
+		secretChanged, err := reencryptColumn(user.id, "secret", user.secret)
+		if err != nil {
+			return updated, fmt.Errorf("user %s secret: %w", user.id, err)
+		}
+
+		hintChanged, err := reencryptColumn(user.id, "pw_hint", user.hint)
+		if err != nil {
+			return updated, fmt.Errorf("user %s password hint: %w", user.id, err)
+		}
+
+		if secretChanged || hintChanged {
+			updated += 1
+		}
+	}
+
+	return updated, nil
+}
+
+// reencryptColumn re-encrypts a user's value stored in the provided column,
+// as long as the stored value still matches the value that was read. Returns
+// true if the value was updated.
+func reencryptColumn(userID, column string, value []byte) (bool, error) {
+	encrypted, changed, err := reencryptValue(value)
+	if err != nil || !changed {
+		return false, err
+	}
+
+	s := `UPDATE users SET ` + column + `=$2 WHERE id=$1 AND ` + column + `=$3`
+	result, err := db.Exec(s, userID, encrypted, value)
+	if err != nil {
+		return false, err
+	}
+
+	count, err := result.RowsAffected()
+	return count > 0, err
+}
+
+// reencryptValue decrypts and re-encrypts a value with the current server
+// secret, returning the new value and whether it was changed
+func reencryptValue(value []byte) ([]byte, bool, error) {
+	if len(value) == 0 || crypto.IsCurrentKey(value) {
+		return value, false, nil
+	}
+
+	decrypted, err := crypto.Decrypt(value)
+	if err != nil {
+		return nil, false, err
+	}
+
+	encrypted, err := crypto.Encrypt(decrypted)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return encrypted, true, nil
+}
@@ -0,0 +1,230 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+	"yeetfile/shared"
+)
+
+const blockedIDLength = 16
+
+// GetShareInvitations returns all pending invitations to shared files and
+// folders for a user, along with the list of users they've blocked
+func GetShareInvitations(recipientID string) (shared.ShareInvitationsResponse, error) {
+	response := shared.ShareInvitationsResponse{
+		Invitations: []shared.ShareInvitation{},
+		Blocked:     []shared.BlockedUser{},
+	}
+
+	s := `SELECT s.id, s.owner_id, u.public_key, s.is_folder, s.can_modify,
+	             s.expiration, s.created
+	      FROM sharing s
+	      JOIN users u ON u.id = s.owner_id
+	      WHERE s.recipient_id=$1 AND s.accepted=false
+	        AND (s.expiration IS NULL OR s.expiration >= CURRENT_TIMESTAMP at time zone 'UTC')
+	      ORDER BY s.created DESC`
+	rows, err := db.Query(s, recipientID)
+	if err != nil {
+		return response, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var invitation shared.ShareInvitation
+		var ownerID string
+		var expiration, created sql.NullTime
+
+		err = rows.Scan(
+			&invitation.ID,
+			&ownerID,
+			&invitation.PublicKey,
+			&invitation.IsFolder,
+			&invitation.CanModify,
+			&expiration,
+			&created)
+		if err != nil {
+			return response, err
+		}
+
+		invitation.SharerName, err = GetUserPublicName(ownerID)
+		if err != nil {
+			invitation.SharerName = "???"
+		}
+
+		invitation.Expiration = expiration.Time
+		invitation.Created = created.Time
+		response.Invitations = append(response.Invitations, invitation)
+	}
+
+	response.Blocked, err = GetBlockedUsers(recipientID)
+	return response, err
+}
+
+// AcceptShareInvitation adds a shared file or folder to the recipient's vault
+// and marks the share as accepted. The item key stored with the invitation is
+// moved to the recipient's new vault entry. Both happen in one transaction, so
+// that an invitation can't be linked more than once.
+func AcceptShareInvitation(recipientID, shareID string) error {
+	var (
+		ownerID      string
+		itemID       string
+		isFolder     bool
+		canModify    bool
+		protectedKey []byte
+	)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	s := `SELECT owner_id, item_id, is_folder, can_modify, protected_key
+	      FROM sharing
+	      WHERE id=$1 AND recipient_id=$2 AND accepted=false
+	        AND (expiration IS NULL OR expiration >= CURRENT_TIMESTAMP at time zone 'UTC')
+	      FOR UPDATE`
+	err = tx.QueryRow(s, shareID, recipientID).Scan(
+		&ownerID, &itemID, &isFolder, &canModify, &protectedKey)
+	if errors.Is(err, sql.ErrNoRows) {
+		return InvitationNotFoundError
+	} else if err != nil {
+		return err
+	}
+
+	if isFolder {
+		err = LinkSharedFolder(tx, ownerID, recipientID, itemID, protectedKey, canModify)
+	} else {
+		err = LinkSharedFile(tx, ownerID, recipientID, itemID, protectedKey, canModify)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	s = `UPDATE sharing SET accepted=true, protected_key=NULL WHERE id=$1`
+	_, err = tx.Exec(s, shareID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeclineShareInvitation removes a pending invitation to a shared file or
+// folder
+func DeclineShareInvitation(recipientID, shareID string) error {
+	s := `DELETE FROM sharing WHERE id=$1 AND recipient_id=$2 AND accepted=false`
+	result, err := db.Exec(s, shareID, recipientID)
+	if err != nil {
+		return err
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	} else if count == 0 {
+		return InvitationNotFoundError
+	}
+
+	return nil
+}
+
+// BlockShareSender blocks the user who sent a share invitation from sharing
+// any more files or folders with the recipient. All pending invitations from
+// that user are declined.
+func BlockShareSender(recipientID, shareID string) error {
+	var ownerID string
+	s := `SELECT owner_id FROM sharing
+	      WHERE id=$1 AND recipient_id=$2 AND accepted=false`
+	err := db.QueryRow(s, shareID, recipientID).Scan(&ownerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return InvitationNotFoundError
+	} else if err != nil {
+		return err
+	}
+
+	blockedID := shared.GenRandomString(blockedIDLength)
+	for TableIDExists("blocked_users", blockedID) {
+		blockedID = shared.GenRandomString(blockedIDLength)
+	}
+
+	s = `INSERT INTO blocked_users (id, user_id, blocked_id, created)
+	     VALUES ($1, $2, $3, $4)
+	     ON CONFLICT (user_id, blocked_id) DO NOTHING`
+	_, err = db.Exec(s, blockedID, recipientID, ownerID, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	s = `DELETE FROM sharing
+	     WHERE owner_id=$1 AND recipient_id=$2 AND accepted=false`
+	_, err = db.Exec(s, ownerID, recipientID)
+	return err
+}
+
+// UnblockUser allows a previously blocked user to share content with the
+// current user again
+func UnblockUser(userID, id string) error {
+	s := `DELETE FROM blocked_users WHERE id=$1 AND user_id=$2`
+	_, err := db.Exec(s, id, userID)
+	return err
+}
+
+// GetBlockedUsers returns the list of users that a user has blocked from
+// sharing content with them
+func GetBlockedUsers(userID string) ([]shared.BlockedUser, error) {
+	blocked := []shared.BlockedUser{}
+	s := `SELECT id, blocked_id FROM blocked_users
+	      WHERE user_id=$1 ORDER BY created DESC`
+	rows, err := db.Query(s, userID)
+	if err != nil {
+		return blocked, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var id, blockedID string
+		err = rows.Scan(&id, &blockedID)
+		if err != nil {
+			return blocked, err
+		}
+
+		name, err := GetUserPublicName(blockedID)
+		if err != nil {
+			name = "???"
+		}
+
+		blocked = append(blocked, shared.BlockedUser{ID: id, Name: name})
+	}
+
+	return blocked, nil
+}
+
+// IsUserBlocked checks to see if a user has blocked another user from sharing
+// content with them
+func IsUserBlocked(userID, blockedID string) (bool, error) {
+	var count int
+	s := `SELECT COUNT(*) FROM blocked_users WHERE user_id=$1 AND blocked_id=$2`
+	err := db.QueryRow(s, userID, blockedID).Scan(&count)
+	return count > 0, err
+}
+
+// DeleteUserInvitations removes a user's pending share invitations and any
+// blocks involving the user, used when deleting their account
+func DeleteUserInvitations(userID string) error {
+	s := `DELETE FROM sharing
+	      WHERE (owner_id=$1 OR recipient_id=$1) AND accepted=false`
+	_, err := db.Exec(s, userID)
+	if err != nil {
+		return err
+	}
+
+	s = `DELETE FROM blocked_users WHERE user_id=$1 OR blocked_id=$1`
+	_, err = db.Exec(s, userID)
+	return err
+}
@@ -1,35 +1,89 @@
 package db
 
 import (
+	"database/sql"
 	"errors"
+	"fmt"
+	"log"
+	"time"
+	"yeetfile/backend/mail"
 	"yeetfile/shared"
 )
 
 const sharingIDLength = 16
 
-var AlreadySharedError = errors.New("item is already shared with this user")
+// expiredShareQuery matches the sharing entry for a recipient's reference to a
+// shared file or folder if the share has expired. The expired shares task
+// removes these entries, but until then the query is used to hide them from
+// the recipient. The argument is the alias of the vault or folders table.
+const expiredShareQuery = `SELECT 1 FROM sharing s
+	WHERE s.item_id = %[1]s.ref_id
+	AND s.recipient_id = %[1]s.owner_id
+	AND s.expiration < CURRENT_TIMESTAMP at time zone 'UTC'`
+
+// shareExpiryNotice is how long before a share expires that the owner is
+// notified about the upcoming expiration
+const shareExpiryNotice = time.Hour * 24
+
+var (
+	AlreadySharedError      = errors.New("item is already shared with this user")
+	BlockedSenderError      = errors.New("recipient is not accepting shares from this user")
+	InvitationNotFoundError = errors.New("share invitation not found")
+)
 
 // AddSharingEntry adds a new entry to the sharing table containing relevant
-// info regarding a shared folder or file.
-func AddSharingEntry(
-	ownerID,
-	recipientID,
-	itemID string,
-	isFolder,
-	canModify bool,
-) (string, error) {
+// info regarding a shared folder or file. New entries start out as pending
+// invitations, which hold the item key (encrypted with the recipient's public
+// key) until the recipient accepts the invitation. A zero expiration creates a
+// share that never expires.
+func AddSharingEntry(share shared.NewSharedItem, isFolder bool) (string, error) {
 	sharingID := shared.GenRandomString(sharingIDLength)
 	for TableIDExists("sharing", sharingID) {
 		sharingID = shared.GenRandomString(sharingIDLength)
 	}
 
-	s := `INSERT INTO sharing (id, owner_id, recipient_id, item_id, is_folder, can_modify) 
-	      VALUES ($1, $2, $3, $4, $5, $6)`
+	var exp sql.NullTime
+	if !share.Expiration.IsZero() {
+		exp = sql.NullTime{Time: share.Expiration.UTC(), Valid: true}
+	}
+
+	s := `INSERT INTO sharing
+	      (id, owner_id, recipient_id, item_id, is_folder, can_modify,
+	       expiration, accepted, protected_key, created)
+	      VALUES ($1, $2, $3, $4, $5, $6, $7, false, $8, $9)`
 
-	_, err := db.Exec(s, sharingID, ownerID, recipientID, itemID, isFolder, canModify)
+	_, err := db.Exec(s, sharingID, share.UserID, share.RecipientID,
+		share.ItemID, isFolder, share.CanModify, exp, share.ProtectedKey,
+		time.Now().UTC())
 	return sharingID, err
 }
 
+// checkCanShareWithRecipient returns an error if the item has already been
+// shared with the recipient, or if the recipient has blocked the sharer
+func checkCanShareWithRecipient(share shared.NewSharedItem) error {
+	isAlreadyShared, err := IsSharedWithRecipient(share.UserID, share.ItemID, share.RecipientID)
+	if err != nil {
+		return err
+	} else if isAlreadyShared {
+		return AlreadySharedError
+	}
+
+	isBlocked, err := IsUserBlocked(share.RecipientID, share.UserID)
+	if err != nil {
+		return err
+	} else if isBlocked {
+		return BlockedSenderError
+	}
+
+	return nil
+}
+
+// excludeExpiredShares returns a query condition that excludes a recipient's
+// references to shares that have expired
+func excludeExpiredShares(alias string) string {
+	return ` AND NOT EXISTS (` + fmt.Sprintf(expiredShareQuery, alias) + `)`
+}
+
 // IsSharedWithRecipient checks to see if a file or folder has already been
 // shared with a particular user
 func IsSharedWithRecipient(ownerID, itemID, recipientID string) (bool, error) {
@@ -153,7 +207,7 @@ func GetShareInfo(ownerID, itemID string, isFolder bool) ([]shared.ShareInfo, er
 		return nil, err
 	}
 
-	s := `SELECT id, recipient_id, can_modify 
+	s := `SELECT id, recipient_id, can_modify, expiration, accepted
 	      FROM sharing 
 	      WHERE owner_id=$1 AND item_id=$2 ORDER BY id`
 	rows, err := db.Query(s, ownerID, itemID)
@@ -167,8 +221,10 @@ func GetShareInfo(ownerID, itemID string, isFolder bool) ([]shared.ShareInfo, er
 		var id string
 		var recipientID string
 		var canModify bool
+		var expiration sql.NullTime
+		var accepted bool
 
-		err = rows.Scan(&id, &recipientID, &canModify)
+		err = rows.Scan(&id, &recipientID, &canModify, &expiration, &accepted)
 		if err != nil {
 			return nil, err
 		}
@@ -179,9 +235,11 @@ func GetShareInfo(ownerID, itemID string, isFolder bool) ([]shared.ShareInfo, er
 		}
 
 		shareList = append(shareList, shared.ShareInfo{
-			ID:        id,
-			Recipient: name,
-			CanModify: canModify,
+			ID:         id,
+			Recipient:  name,
+			CanModify:  canModify,
+			Expiration: expiration.Time,
+			Pending:    !accepted,
 		})
 	}
 
@@ -223,3 +281,103 @@ func UserCanEditItem(itemID, ownerID string, isFolder bool) error {
 
 	return nil
 }
+
+// HasActiveShare checks to see if a file or folder is shared with a particular
+// user, that the user has accepted the share, and that it hasn't expired yet
+func HasActiveShare(ownerID, itemID, recipientID string) (bool, error) {
+	var count int
+	s := `SELECT COUNT(*) FROM sharing
+	      WHERE owner_id=$1 AND item_id=$2 AND recipient_id=$3 AND accepted=true
+	      AND (expiration IS NULL OR expiration >= CURRENT_TIMESTAMP at time zone 'UTC')`
+	err := db.QueryRow(s, ownerID, itemID, recipientID).Scan(&count)
+	return count > 0, err
+}
+
+// CheckShareExpiry removes all shares that have passed their expiration date,
+// including the recipient's reference to the shared file or folder. Owners of
+// shares that are expiring soon are notified by email (if they have one).
+func CheckShareExpiry() {
+	notifyExpiringShares()
+
+	s := `SELECT id, recipient_id, item_id, is_folder
+	      FROM sharing
+	      WHERE expiration < CURRENT_TIMESTAMP at time zone 'UTC'`
+	rows, err := db.Query(s)
+	if err != nil {
+		log.Printf("Error retrieving expired shares: %v\n", err)
+		return
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var id, recipientID, itemID string
+		var isFolder bool
+
+		err = rows.Scan(&id, &recipientID, &itemID, &isFolder)
+		if err != nil {
+			log.Printf("Error scanning expired share: %v\n", err)
+			continue
+		}
+
+		if isFolder {
+			err = DeleteSharedFolderByRefID(itemID, recipientID)
+		} else {
+			err = DeleteSharedFileByRefID(itemID, recipientID)
+		}
+
+		if err != nil {
+			log.Printf("Error removing expired shared item: %v\n", err)
+			continue
+		}
+
+		err = RemoveShareEntry(id)
+		if err != nil {
+			log.Printf("Error removing expired share: %v\n", err)
+		}
+	}
+}
+
+// notifyExpiringShares emails the owners of shares that will expire within
+// shareExpiryNotice. Each share is only included in one notification.
+func notifyExpiringShares() {
+	s := `UPDATE sharing
+	      SET notified=true
+	      WHERE notified=false
+	        AND accepted=true
+	        AND expiration >= CURRENT_TIMESTAMP at time zone 'UTC'
+	        AND expiration < $1
+	      RETURNING owner_id, recipient_id, is_folder, expiration`
+	rows, err := db.Query(s, time.Now().UTC().Add(shareExpiryNotice))
+	if err != nil {
+		log.Printf("Error retrieving expiring shares: %v\n", err)
+		return
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var ownerID, recipientID string
+		var isFolder bool
+		var expiration time.Time
+
+		err = rows.Scan(&ownerID, &recipientID, &isFolder, &expiration)
+		if err != nil {
+			log.Printf("Error scanning expiring share: %v\n", err)
+			continue
+		}
+
+		email, err := GetUserEmailByID(ownerID)
+		if err != nil || len(email) == 0 {
+			continue
+		}
+
+		recipient, err := GetUserPublicName(recipientID)
+		if err != nil {
+			recipient = "???"
+		}
+
+		err = mail.SendShareExpiringEmail(email, recipient, isFolder, expiration)
+		if err != nil {
+			log.Printf("Error sending share expiration email: %v\n", err)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+	"yeetfile/shared"
+	"yeetfile/shared/constants"
+)
+
+const ssoTicketLength = 32
+
+var (
+	OIDCStateExpiredError  = errors.New("sso login is missing or expired")
+	SSOTicketNotFoundError = errors.New("sso ticket is missing or expired")
+)
+
+// NewOIDCState stores the values needed to complete a login with the identity
+// provider, and removes any logins that were abandoned
+func NewOIDCState(state, nonce, verifier string, isCLI bool) error {
+	now := time.Now().UTC()
+	s := `DELETE FROM oidc_states WHERE expires < $1`
+	_, err := db.Exec(s, now)
+	if err != nil {
+		return err
+	}
+
+	s = `INSERT INTO oidc_states (state, nonce, verifier, is_cli, expires)
+	     VALUES ($1, $2, $3, $4, $5)`
+	_, err = db.Exec(s, state, nonce, verifier, isCLI,
+		now.Add(constants.SSOTicketTimeout*time.Second))
+	return err
+}
+
+// ConsumeOIDCState removes and returns the nonce and PKCE verifier for a login
+// with the identity provider, so that each state can only be used once
+func ConsumeOIDCState(state string) (string, string, bool, error) {
+	var nonce, verifier string
+	var isCLI bool
+	var expires time.Time
+	s := `DELETE FROM oidc_states WHERE state=$1
+	      RETURNING nonce, verifier, is_cli, expires`
+	err := db.QueryRow(s, state).Scan(&nonce, &verifier, &isCLI, &expires)
+	if err == sql.ErrNoRows || (err == nil && time.Now().UTC().After(expires)) {
+		return "", "", false, OIDCStateExpiredError
+	}
+
+	return nonce, verifier, isCLI, err
+}
+
+// NewSSOTicket creates a short-lived ticket for a user who was authorized by
+// the identity provider, which is required alongside their password to log in
+// or sign up
+func NewSSOTicket(email string, isAdmin bool) (string, error) {
+	now := time.Now().UTC()
+	s := `DELETE FROM sso_tickets WHERE expires < $1`
+	_, err := db.Exec(s, now)
+	if err != nil {
+		return "", err
+	}
+
+	id := shared.GenRandomString(ssoTicketLength)
+	s = `INSERT INTO sso_tickets (id, email, is_admin, expires)
+	     VALUES ($1, $2, $3, $4)`
+	_, err = db.Exec(s, id, email, isAdmin,
+		now.Add(constants.SSOTicketTimeout*time.Second))
+	return id, err
+}
+
+// GetSSOTicket returns the email and admin status of the user that the ticket
+// was created for
+func GetSSOTicket(id string) (string, bool, error) {
+	var email string
+	var isAdmin bool
+	s := `SELECT email, is_admin FROM sso_tickets WHERE id=$1 AND expires > $2`
+	err := db.QueryRow(s, id, time.Now().UTC()).Scan(&email, &isAdmin)
+	if err == sql.ErrNoRows {
+		return "", false, SSOTicketNotFoundError
+	}
+
+	return email, isAdmin, err
+}
+
+// DeleteSSOTicket removes a ticket after it has been used to log in
+func DeleteSSOTicket(id string) error {
+	s := `DELETE FROM sso_tickets WHERE id=$1`
+	_, err := db.Exec(s, id)
+	return err
+}
+
+// SetUserSSOAdmin updates whether the user is an admin, based on their groups
+// in the identity provider
+func SetUserSSOAdmin(userID string, isAdmin bool) error {
+	s := `UPDATE users SET sso_admin=$2 WHERE id=$1`
+	_, err := db.Exec(s, userID, isAdmin)
+	return err
+}
+
+// IsUserSSOAdmin returns true if the user was made an admin by their groups in
+// the identity provider
+func IsUserSSOAdmin(userID string) bool {
+	var isAdmin sql.NullBool
+	s := `SELECT sso_admin FROM users WHERE id=$1`
+	err := db.QueryRow(s, userID).Scan(&isAdmin)
+	return err == nil && isAdmin.Bool
+}
@@ -29,6 +29,8 @@ type User struct {
 	StorageUsed         int64
 	SendAvailable       int64
 	SendUsed            int64
+	HasRecoveryKey      bool
+	KDF                 shared.KDFParams
 }
 
 type UserStorage struct {
@@ -95,8 +97,11 @@ func NewUser(user User) (string, error) {
                    last_upgraded_month,
                    protected_key,
                    public_key,
-                   bandwidth)
-	      VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
+                   bandwidth,
+                   kdf_algorithm,
+                   kdf_iterations,
+                   kdf_memory)
+	      VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)`
 
 	_, err := db.Exec(
 		s,
@@ -113,7 +118,10 @@ func NewUser(user User) (string, error) {
 		user.PublicKey,
 		config.YeetFileConfig.DefaultUserStorage*
 			constants.TotalBandwidthMultiplier*
-			constants.BandwidthMonitorDuration)
+			constants.BandwidthMonitorDuration,
+		user.KDF.Algorithm,
+		user.KDF.Iterations,
+		user.KDF.Memory)
 	if err != nil {
 		return "", err
 	}
@@ -129,13 +137,17 @@ func NewUser(user User) (string, error) {
 // user changing their email or password.
 func UpdateUser(user User, accountID string) error {
 	s := `UPDATE users 
-	      SET email=$1, pw_hash=$2, protected_key=$3
-	      WHERE id=$4`
+	      SET email=$1, pw_hash=$2, protected_key=$3,
+	          kdf_algorithm=$4, kdf_iterations=$5, kdf_memory=$6
+	      WHERE id=$7`
 	_, err := db.Exec(
 		s,
 		user.Email,
 		user.PasswordHash,
 		user.ProtectedPrivateKey,
+		user.KDF.Algorithm,
+		user.KDF.Iterations,
+		user.KDF.Memory,
 		accountID)
 	return err
 }
@@ -251,19 +263,34 @@ func GetUserStorage(id string) (UserStorage, UserSend, error) {
 // UpdateStorageUsed updates the amount of storage used by the user. Can be a
 // negative number to remove storage space.
 func UpdateStorageUsed(userID string, amount int64) error {
-	var storageUsed int
-	var storageAvailable int
+	return updateStorageUsed(db, userID, amount)
+}
+
+// updateStorageUsed is the same as UpdateStorageUsed, but can be run as part of
+// a transaction
+func updateStorageUsed(conn dbConn, userID string, amount int64) error {
+	var storageUsed int64
+	var storageAvailable int64
 	s := `UPDATE users 
 	      SET storage_used = CASE 
 	                           WHEN storage_used + $1 < 0 THEN 0
 	                           ELSE storage_used + $1
 	                         END
-	      WHERE id=$2 AND storage_available > 0
+	      WHERE id=$2 AND (
+	          storage_available > 0 OR
+	          id IN (SELECT user_id FROM org_members))
 	      RETURNING storage_used, storage_available`
-	err := db.QueryRow(s, amount, userID).Scan(&storageUsed, &storageAvailable)
+	err := conn.QueryRow(s, amount, userID).Scan(&storageUsed, &storageAvailable)
 	if err != nil && err != sql.ErrNoRows {
 		return err
-	} else if storageUsed > storageAvailable && amount > 0 && config.YeetFileConfig.DefaultUserStorage > 0 {
+	} else if err == sql.ErrNoRows || amount <= 0 || config.YeetFileConfig.DefaultUserStorage <= 0 {
+		return nil
+	}
+
+	exceeded, err := isOverStorageLimit(conn, userID, storageUsed, storageAvailable)
+	if err != nil {
+		return err
+	} else if exceeded {
 		return UserStorageExceeded
 	}
 
@@ -396,6 +423,21 @@ func GetUserPasswordHashByID(id string) ([]byte, []byte, error) {
 	return pwHash, secret, nil
 }
 
+// GetUserKDFParams retrieves the key derivation params for a user with the
+// provided email or ID, which the user needs in order to derive their keys
+// before logging in.
+func GetUserKDFParams(identifier string) (shared.KDFParams, error) {
+	var params shared.KDFParams
+	s := `SELECT kdf_algorithm, kdf_iterations, kdf_memory
+	      FROM users
+	      WHERE email = $1 OR id = $1`
+	err := db.QueryRow(s, identifier).Scan(
+		&params.Algorithm,
+		&params.Iterations,
+		&params.Memory)
+	return params, err
+}
+
 // GetUserKeys retrieves the user's public key and their private key, the latter
 // is encrypted with their user key (which is generated client side and never stored)
 func GetUserKeys(id string) ([]byte, []byte, error) {
@@ -435,18 +477,19 @@ func GetUserByID(id string) (User, error) {
 		storageUsed      int64
 		pwHint           []byte
 		secret           []byte
+		hasRecoveryKey   bool
 	)
 	s := `SELECT email, payment_id, upgrade_exp,
 	             send_available, send_used, 
 		     storage_available, storage_used,
-		     pw_hint, secret
+		     pw_hint, secret, recovery_hash IS NOT NULL
 	      FROM users
 	      WHERE id = $1`
 	err := db.QueryRow(s, id).Scan(
 		&email, &paymentID, &expiration,
 		&sendAvailable, &sendUsed,
 		&storageAvailable, &storageUsed,
-		&pwHint, &secret,
+		&pwHint, &secret, &hasRecoveryKey,
 	)
 
 	if err != nil {
@@ -465,6 +508,7 @@ func GetUserByID(id string) (User, error) {
 		StorageUsed:      storageUsed,
 		PasswordHint:     pwHint,
 		Secret:           secret,
+		HasRecoveryKey:   hasRecoveryKey,
 	}, nil
 }
 
@@ -640,11 +684,18 @@ func GetUserSendLimits(id string) (int64, int64, error) {
 }
 
 // GetUserStorageLimits returns the amount of used and available bytes for
-// storing files
+// storing files. Members of an organization use the org's pooled storage.
 func GetUserStorageLimits(id string) (int64, int64, error) {
+	orgID, _, err := GetUserOrg(id)
+	if err != nil {
+		return 0, 0, err
+	} else if len(orgID) > 0 {
+		return GetOrgStorage(orgID)
+	}
+
 	var storageUsed int64
 	var storageAvailable int64
-	err := db.QueryRow(`
+	err = db.QueryRow(`
 		SELECT storage_used, storage_available
 		FROM users
 		WHERE id = $1`, id).Scan(&storageUsed, &storageAvailable)
@@ -999,12 +1050,21 @@ func DeleteUser(id string) error {
 	return nil
 }
 
-func UpdateUserLogin(id string, loginKeyHash, protectedKey []byte) error {
+// UpdateUserLogin replaces the user's login hash and protected key, as well as
+// the key derivation params used to generate them
+func UpdateUserLogin(
+	id string,
+	loginKeyHash,
+	protectedKey []byte,
+	kdf shared.KDFParams,
+) error {
 	s := `UPDATE users
-          SET pw_hash=$2, protected_key=$3
+          SET pw_hash=$2, protected_key=$3,
+              kdf_algorithm=$4, kdf_iterations=$5, kdf_memory=$6
           WHERE id=$1`
 
-	_, err := db.Exec(s, id, loginKeyHash, protectedKey)
+	_, err := db.Exec(s, id, loginKeyHash, protectedKey,
+		kdf.Algorithm, kdf.Iterations, kdf.Memory)
 	if err != nil {
 		return err
 	}
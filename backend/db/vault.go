@@ -17,7 +17,8 @@ var AccessError = errors.New("unauthorized access")
 
 // GetFileOwnership retrieves ownership details for a particular file
 func GetFileOwnership(fileID, userID string) (shared.FileOwnershipInfo, error) {
-	s := `SELECT can_modify FROM vault WHERE owner_id=$1 AND ref_id=$2`
+	s := `SELECT v.can_modify FROM vault v
+	      WHERE v.owner_id=$1 AND v.ref_id=$2` + excludeExpiredShares("v")
 	rows, err := db.Query(s, userID, fileID)
 	if err != nil {
 		return shared.FileOwnershipInfo{}, err
@@ -85,8 +86,10 @@ func GetVaultItems(
 	if len(folderID) == 0 || folderID == userID {
 		query := `SELECT v.id, v.name, v.length, v.modified, v.protected_key,
        		                 v.shared_by, v.link_tag, v.can_modify, v.ref_id, v.pw_data,
+       		                 v.pending_key,
        		                 (SELECT COUNT(*) FROM sharing s WHERE s.item_id = v.id) AS share_count
-       		                 FROM vault v WHERE owner_id=$1 AND folder_id=$1`
+       		                 FROM vault v WHERE owner_id=$1 AND folder_id=$1` +
+			excludeExpiredShares("v")
 
 		query += qFilter
 		rows, err = db.Query(query, userID)
@@ -97,12 +100,16 @@ func GetVaultItems(
 			return nil, shared.FolderOwnershipInfo{}, AccessError
 		}
 
+		// Files uploaded via a file request are only visible to the
+		// folder owner until they've re-wrapped the file key
 		query := `SELECT v.id, v.name, v.length, v.modified, v.protected_key,
        		                 v.shared_by, v.link_tag, v.can_modify, v.ref_id, v.pw_data,
+       		                 v.pending_key,
        		                 (SELECT COUNT(*) FROM sharing s WHERE s.item_id = v.id) AS share_count
-		          FROM vault v WHERE folder_id=$1`
+		          FROM vault v WHERE folder_id=$1
+		          AND (v.pending_key = false OR v.owner_id = $2)`
 		query += qFilter
-		rows, err = db.Query(query, folderID)
+		rows, err = db.Query(query, folderID, userID)
 	}
 
 	if err != nil {
@@ -123,11 +130,12 @@ func GetVaultItems(
 		var canModify bool
 		var refID string
 		var pwData []byte
+		var pendingKey bool
 		var shareCount int
 
 		err = rows.Scan(&id, &name, &length, &modified, &protectedKey,
 			&sharedBy, &linkTag, &canModify, &refID, &pwData,
-			&shareCount)
+			&pendingKey, &shareCount)
 		if err != nil {
 			return nil, shared.FolderOwnershipInfo{}, err
 		}
@@ -156,6 +164,7 @@ func GetVaultItems(
 			RefID:        refID,
 			IsOwner:      isOwner,
 			PasswordData: pwData,
+			PendingKey:   pendingKey,
 		})
 	}
 
@@ -245,8 +254,9 @@ func ChangeFilePermission(fileID, ownerID string, canModify bool) error {
 	return nil
 }
 
-// ShareFile shares a user's file with another user via the recipient's user
-// ID (determined before calling ShareFile).
+// ShareFile invites another user to a file via the recipient's user ID
+// (determined before calling ShareFile). The file isn't added to the
+// recipient's vault until they accept the invitation (see LinkSharedFile).
 // Returns the ID created in the `sharing` table.
 func ShareFile(share shared.NewSharedItem, userID string) (string, error) {
 	if len(share.RecipientID) == 0 {
@@ -265,16 +275,28 @@ func ShareFile(share shared.NewSharedItem, userID string) (string, error) {
 		return "", errors.New("cannot share within shared folder")
 	}
 
-	isAlreadyShared, err := IsSharedWithRecipient(share.UserID, share.ItemID, share.RecipientID)
+	err = checkCanShareWithRecipient(share)
 	if err != nil {
 		return "", err
-	} else if isAlreadyShared {
-		return "", AlreadySharedError
 	}
 
-	file, err := RetrieveVaultMetadata(share.ItemID, share.UserID)
+	return AddSharingEntry(share, false)
+}
+
+// LinkSharedFile adds a new vault entry to the recipient's root folder that
+// references a file shared with them, once they've accepted the invitation to
+// the file.
+func LinkSharedFile(
+	tx *sql.Tx,
+	ownerID,
+	recipientID,
+	fileID string,
+	protectedKey []byte,
+	canModify bool,
+) error {
+	file, err := RetrieveVaultMetadata(fileID, ownerID)
 	if err != nil {
-		return "", err
+		return err
 	}
 
 	itemID := shared.GenRandomString(VaultIDLength)
@@ -282,30 +304,19 @@ func ShareFile(share shared.NewSharedItem, userID string) (string, error) {
 		itemID = shared.GenRandomString(VaultIDLength)
 	}
 
-	sharedByName, _ := GetUserPublicName(userID)
+	sharedByName, _ := GetUserPublicName(ownerID)
 
-	s1 := `INSERT INTO vault
+	s := `INSERT INTO vault
     	           (id, name, folder_id, owner_id, b2_id, length, chunks,
                     protected_key, shared_by, modified, can_modify, ref_id, pw_data)
 	       VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`
-	_, err = db.Exec(s1,
+	_, err = tx.Exec(s,
 		itemID, file.Name,
-		share.RecipientID, share.RecipientID,
+		recipientID, recipientID,
 		file.B2ID, file.Length, file.Chunks,
-		share.ProtectedKey, sharedByName,
-		time.Now().UTC(), share.CanModify, file.ID, file.PasswordData)
-	if err != nil {
-		return "", err
-	}
-
-	shareID, shareErr := AddSharingEntry(
-		share.UserID,
-		share.RecipientID,
-		share.ItemID,
-		false,
-		share.CanModify)
-
-	return shareID, shareErr
+		protectedKey, sharedByName,
+		time.Now().UTC(), canModify, file.ID, file.PasswordData)
+	return err
 }
 
 func VaultItemIDExists(id string) bool {
@@ -344,6 +355,27 @@ func UpdateVaultFile(id, ownerID string, mod shared.ModifyVaultItem) error {
 	return nil
 }
 
+// SetPendingItemKey replaces the protected key of a file uploaded via a file
+// request (wrapped with the owner's public key) with one wrapped with the
+// parent folder's key.
+func SetPendingItemKey(id, ownerID string, protectedKey []byte) error {
+	s := `UPDATE vault
+	      SET protected_key=$3, pending_key=false
+	      WHERE id=$1 AND owner_id=$2 AND pending_key=true`
+	result, err := db.Exec(s, id, ownerID, protectedKey)
+	if err != nil {
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		return AccessError
+	}
+
+	return nil
+}
+
 // DeleteVaultFile deletes an entry in the file vault
 func DeleteVaultFile(id, ownerID string) error {
 	err := UserCanEditItem(id, ownerID, false)
@@ -527,7 +559,7 @@ func RetrieveVaultMetadata(id, ownerID string) (FileMetadata, error) {
 	ownership, err := CheckFolderOwnership(ownerID, folderID)
 	if err != nil {
 		// Check if this is a file shared from another user's home dir
-		isShared, err := IsSharedWithRecipient(folderID, id, ownerID)
+		isShared, err := HasActiveShare(folderID, id, ownerID)
 		if !isShared || err != nil {
 			return FileMetadata{}, AccessError
 		}
@@ -541,7 +573,7 @@ func RetrieveVaultMetadata(id, ownerID string) (FileMetadata, error) {
 	if folderID == ownerID {
 		// This file is in the user's root folder, which requires filtering
 		// by owner_id as well.
-		s += " and owner_id = $2"
+		s += " and owner_id = $2" + excludeExpiredShares("vault")
 		rows, err = db.Query(s, id, ownerID)
 	} else {
 		rows, err = db.Query(s, id)
@@ -18,14 +18,19 @@ type VerifiedAccountValues struct {
 	PublicKey               []byte
 	ProtectedVaultFolderKey []byte
 	PasswordHint            []byte
+	RecoveryHash            []byte
+	RecoveryProtectedKey    []byte
+	KDF                     shared.KDFParams
 }
 
 // NewVerification creates a new verification entry for a user. Account ID can
 // be left empty for new user verification, otherwise should be provided if
-// an existing user is verifying their new email.
+// an existing user is verifying their new email. The recovery hash is only
+// provided if the user created a recovery key during signup.
 func NewVerification(
 	signupData shared.Signup,
 	pwHash []byte,
+	recoveryHash []byte,
 	accountID string,
 ) (string, error) {
 	if config.YeetFileConfig.MaxUserCount > 0 {
@@ -84,8 +89,13 @@ func NewVerification(
 			          protected_private_key=$3, 
 			          protected_vault_folder_key=$4, 
 			          pw_hint=$5,
-			          account_id=$6
-			      WHERE identity=$7`
+			          account_id=$6,
+			          recovery_hash=$7,
+			          recovery_key=$8,
+			          kdf_algorithm=$9,
+			          kdf_iterations=$10,
+			          kdf_memory=$11
+			      WHERE identity=$12`
 			_, err = db.Exec(s,
 				pwHash,
 				signupData.PublicKey,
@@ -93,6 +103,11 @@ func NewVerification(
 				signupData.ProtectedVaultFolderKey,
 				pwHintEncrypted,
 				accountID,
+				recoveryHash,
+				signupData.RecoveryProtectedKey,
+				signupData.KDF.Algorithm,
+				signupData.KDF.Iterations,
+				signupData.KDF.Memory,
 				signupData.Identifier)
 			if err != nil {
 				return "", err
@@ -111,8 +126,13 @@ func NewVerification(
 			          protected_private_key=$5,
 			          protected_vault_folder_key=$6,
 			          pw_hint=$7,
-			          account_id=$8
-			      WHERE identity=$9`
+			          account_id=$8,
+			          recovery_hash=$9,
+			          recovery_key=$10,
+			          kdf_algorithm=$11,
+			          kdf_iterations=$12,
+			          kdf_memory=$13
+			      WHERE identity=$14`
 			_, err = db.Exec(s,
 				code,
 				pwHash,
@@ -122,6 +142,11 @@ func NewVerification(
 				signupData.ProtectedVaultFolderKey,
 				pwHintEncrypted,
 				accountID,
+				recoveryHash,
+				signupData.RecoveryProtectedKey,
+				signupData.KDF.Algorithm,
+				signupData.KDF.Iterations,
+				signupData.KDF.Memory,
 				signupData.Identifier)
 			if err != nil {
 				return "", err
@@ -139,8 +164,13 @@ func NewVerification(
                     protected_private_key,
                     protected_vault_folder_key,
                     account_id,
-                    pw_hint) 
-		      VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+                    pw_hint,
+                    recovery_hash,
+                    recovery_key,
+                    kdf_algorithm,
+                    kdf_iterations,
+                    kdf_memory) 
+		      VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`
 		_, err = db.Exec(
 			s,
 			signupData.Identifier,
@@ -151,7 +181,12 @@ func NewVerification(
 			signupData.ProtectedPrivateKey,
 			signupData.ProtectedVaultFolderKey,
 			accountID,
-			pwHintEncrypted)
+			pwHintEncrypted,
+			recoveryHash,
+			signupData.RecoveryProtectedKey,
+			signupData.KDF.Algorithm,
+			signupData.KDF.Iterations,
+			signupData.KDF.Memory)
 		if err != nil {
 			return "", err
 		}
@@ -171,6 +206,9 @@ func VerifyUser(identity string, code string) (VerifiedAccountValues, error) {
 		protectedPrivateKey     []byte
 		protectedVaultFolderKey []byte
 		encPwHint               []byte
+		recoveryHash            []byte
+		recoveryProtectedKey    []byte
+		kdf                     shared.KDFParams
 	)
 
 	s := `SELECT 
@@ -179,7 +217,12 @@ func VerifyUser(identity string, code string) (VerifiedAccountValues, error) {
 	          public_key, 
 	          protected_private_key, 
 	          protected_vault_folder_key, 
-	          pw_hint 
+	          pw_hint,
+	          recovery_hash,
+	          recovery_key,
+	          kdf_algorithm,
+	          kdf_iterations,
+	          kdf_memory
 	      FROM verify WHERE identity=$1 AND code=$2`
 
 	row := db.QueryRow(s, identity, code)
@@ -189,7 +232,12 @@ func VerifyUser(identity string, code string) (VerifiedAccountValues, error) {
 		&publicKey,
 		&protectedPrivateKey,
 		&protectedVaultFolderKey,
-		&encPwHint)
+		&encPwHint,
+		&recoveryHash,
+		&recoveryProtectedKey,
+		&kdf.Algorithm,
+		&kdf.Iterations,
+		&kdf.Memory)
 
 	if err != nil {
 		return VerifiedAccountValues{}, err
@@ -203,6 +251,9 @@ func VerifyUser(identity string, code string) (VerifiedAccountValues, error) {
 		ProtectedPrivateKey:     protectedPrivateKey,
 		ProtectedVaultFolderKey: protectedVaultFolderKey,
 		PasswordHint:            encPwHint,
+		RecoveryHash:            recoveryHash,
+		RecoveryProtectedKey:    recoveryProtectedKey,
+		KDF:                     kdf,
 	}, nil
 }
 
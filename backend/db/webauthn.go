@@ -0,0 +1,200 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+	"yeetfile/backend/crypto"
+	"yeetfile/shared"
+	"yeetfile/shared/constants"
+)
+
+const webAuthnIDLength = 16
+
+var (
+	WebAuthnCredentialNotFoundError = errors.New("webauthn credential not found")
+	WebAuthnCredentialExistsError   = errors.New("webauthn credential is already registered")
+	WebAuthnChallengeExpiredError   = errors.New("webauthn challenge is missing or expired")
+)
+
+// NewWebAuthnCredential stores a WebAuthn credential for the user under the
+// provided name, and returns the credential's ID
+func NewWebAuthnCredential(
+	userID,
+	name string,
+	credential crypto.WebAuthnCredential,
+) (string, error) {
+	var exists bool
+	s := `SELECT EXISTS(SELECT 1 FROM webauthn_credentials WHERE credential_id=$1)`
+	err := db.QueryRow(s, credential.ID).Scan(&exists)
+	if err != nil {
+		return "", err
+	} else if exists {
+		return "", WebAuthnCredentialExistsError
+	}
+
+	id := shared.GenRandomString(webAuthnIDLength)
+	for TableIDExists("webauthn_credentials", id) {
+		id = shared.GenRandomString(webAuthnIDLength)
+	}
+
+	s = `INSERT INTO webauthn_credentials
+	     (id, user_id, name, credential_id, public_key, sign_count, created)
+	     VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	_, err = db.Exec(s, id, userID, name, credential.ID, credential.PublicKey,
+		credential.SignCount, time.Now().UTC())
+	return id, err
+}
+
+// GetWebAuthnCredentials returns the names and usage of the user's WebAuthn
+// credentials
+func GetWebAuthnCredentials(userID string) ([]shared.WebAuthnCredential, error) {
+	credentials := []shared.WebAuthnCredential{}
+	s := `SELECT id, name, created, last_used
+	      FROM webauthn_credentials
+	      WHERE user_id=$1
+	      ORDER BY created`
+	rows, err := db.Query(s, userID)
+	if err != nil {
+		return credentials, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var credential shared.WebAuthnCredential
+		var lastUsed sql.NullTime
+		err = rows.Scan(
+			&credential.ID,
+			&credential.Name,
+			&credential.Created,
+			&lastUsed)
+		if err != nil {
+			return credentials, err
+		}
+
+		credential.LastUsed = lastUsed.Time
+		credentials = append(credentials, credential)
+	}
+
+	return credentials, nil
+}
+
+// GetWebAuthnCredentialIDs returns the authenticator-assigned IDs of each of
+// the user's WebAuthn credentials
+func GetWebAuthnCredentialIDs(userID string) ([][]byte, error) {
+	var ids [][]byte
+	s := `SELECT credential_id FROM webauthn_credentials WHERE user_id=$1`
+	rows, err := db.Query(s, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var id []byte
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// GetWebAuthnCredential returns the user's WebAuthn credential matching the
+// authenticator-assigned credential ID
+func GetWebAuthnCredential(
+	userID string,
+	credentialID []byte,
+) (crypto.WebAuthnCredential, error) {
+	credential := crypto.WebAuthnCredential{ID: credentialID}
+	s := `SELECT public_key, sign_count FROM webauthn_credentials
+	      WHERE user_id=$1 AND credential_id=$2`
+	err := db.QueryRow(s, userID, credentialID).Scan(
+		&credential.PublicKey,
+		&credential.SignCount)
+	if err == sql.ErrNoRows {
+		return credential, WebAuthnCredentialNotFoundError
+	}
+
+	return credential, err
+}
+
+// CountWebAuthnCredentials returns the number of WebAuthn credentials that the
+// user has registered
+func CountWebAuthnCredentials(userID string) (int, error) {
+	var count int
+	s := `SELECT COUNT(*) FROM webauthn_credentials WHERE user_id=$1`
+	err := db.QueryRow(s, userID).Scan(&count)
+	return count, err
+}
+
+// UpdateWebAuthnSignCount stores the authenticator's signature counter after a
+// successful assertion
+func UpdateWebAuthnSignCount(userID string, credentialID []byte, signCount uint32) error {
+	s := `UPDATE webauthn_credentials SET sign_count=$3, last_used=$4
+	      WHERE user_id=$1 AND credential_id=$2`
+	_, err := db.Exec(s, userID, credentialID, signCount, time.Now().UTC())
+	return err
+}
+
+// DeleteWebAuthnCredential removes one of the user's WebAuthn credentials
+func DeleteWebAuthnCredential(userID, id string) error {
+	s := `DELETE FROM webauthn_credentials WHERE id=$1 AND user_id=$2`
+	result, err := db.Exec(s, id, userID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	} else if rows == 0 {
+		return WebAuthnCredentialNotFoundError
+	}
+
+	return nil
+}
+
+// DeleteUserWebAuthn removes all of the user's WebAuthn credentials and any
+// pending challenges
+func DeleteUserWebAuthn(userID string) error {
+	s := `DELETE FROM webauthn_credentials WHERE user_id=$1`
+	_, err := db.Exec(s, userID)
+	if err != nil {
+		return err
+	}
+
+	s = `DELETE FROM webauthn_challenges WHERE user_id=$1`
+	_, err = db.Exec(s, userID)
+	return err
+}
+
+// SetWebAuthnChallenge stores a challenge for a WebAuthn ceremony, replacing
+// any challenge for the same ceremony that the user hasn't completed yet
+func SetWebAuthnChallenge(userID, ceremony string, challenge []byte) error {
+	expires := time.Now().UTC().Add(constants.WebAuthnTimeout * time.Second)
+	s := `INSERT INTO webauthn_challenges (user_id, ceremony, challenge, expires)
+	      VALUES ($1, $2, $3, $4)
+	      ON CONFLICT (user_id, ceremony)
+	      DO UPDATE SET challenge=$3, expires=$4`
+	_, err := db.Exec(s, userID, ceremony, challenge, expires)
+	return err
+}
+
+// ConsumeWebAuthnChallenge removes and returns the user's challenge for a
+// WebAuthn ceremony, so that each challenge can only be used once
+func ConsumeWebAuthnChallenge(userID, ceremony string) ([]byte, error) {
+	var challenge []byte
+	var expires time.Time
+	s := `DELETE FROM webauthn_challenges WHERE user_id=$1 AND ceremony=$2
+	      RETURNING challenge, expires`
+	err := db.QueryRow(s, userID, ceremony).Scan(&challenge, &expires)
+	if err == sql.ErrNoRows || (err == nil && time.Now().UTC().After(expires)) {
+		return nil, WebAuthnChallengeExpiredError
+	}
+
+	return challenge, err
+}
@@ -0,0 +1,48 @@
+package mail
+
+import (
+	"bytes"
+	"text/template"
+	"time"
+)
+
+type EmergencyAccessRequestEmail struct {
+	Contact  string
+	WaitDays int
+	Granted  string
+}
+
+var emergencyAccessRequestSubject = "YeetFile: Emergency access requested"
+var emergencyAccessRequestTemplate = template.Must(template.New("").Parse(
+	"Hello,\n\n{{.Contact}} has requested emergency access to your YeetFile " +
+		"account.\n\nIf you don't deny the request within " +
+		"{{.WaitDays}} day(s), they will be granted access on " +
+		"{{.Granted}}.\n\nIf you didn't expect this request, you can " +
+		"deny it from the Emergency Access section of your account " +
+		"in the YeetFile CLI.\n\n- YeetFile"))
+
+// SendEmergencyAccessRequestEmail notifies a user that one of their emergency
+// contacts has requested access to their account, and when access will be
+// granted if the request isn't denied.
+func SendEmergencyAccessRequestEmail(
+	to, contact string,
+	waitDays int,
+	granted time.Time,
+) error {
+	var buf bytes.Buffer
+	err := emergencyAccessRequestTemplate.Execute(&buf, EmergencyAccessRequestEmail{
+		Contact:  contact,
+		WaitDays: waitDays,
+		Granted:  granted.UTC().Format(time.RFC1123),
+	})
+	if err != nil {
+		return err
+	}
+
+	body := buf.String()
+
+	// sendEmail can take a while to return, so we're calling it in the
+	// background here.
+	go sendEmail(to, emergencyAccessRequestSubject, body)
+	return nil
+}
@@ -0,0 +1,44 @@
+package mail
+
+import (
+	"bytes"
+	"text/template"
+	"time"
+)
+
+type AccountLockedEmail struct {
+	Attempt     string
+	LockedUntil string
+}
+
+var accountLockedSubject = "YeetFile: Account temporarily locked"
+var accountLockedTemplate = template.Must(template.New("").Parse(
+	"Hello,\n\nThere have been too many failed {{.Attempt}} attempts on " +
+		"your YeetFile account, so it has been temporarily locked " +
+		"until {{.LockedUntil}}. Each additional failed attempt after " +
+		"that will lock the account for longer.\n\nIf these attempts " +
+		"weren't made by you, someone may be trying to guess your " +
+		"login. You can review recent activity in the Security Log " +
+		"section of your account in the YeetFile CLI, and contact the " +
+		"instance admin if you need your account unlocked sooner." +
+		"\n\n- YeetFile"))
+
+// SendAccountLockedEmail notifies a user that their account has been
+// temporarily locked after too many failed login or 2FA attempts.
+func SendAccountLockedEmail(to, attempt string, lockedUntil time.Time) error {
+	var buf bytes.Buffer
+	err := accountLockedTemplate.Execute(&buf, AccountLockedEmail{
+		Attempt:     attempt,
+		LockedUntil: lockedUntil.UTC().Format(time.RFC1123),
+	})
+	if err != nil {
+		return err
+	}
+
+	body := buf.String()
+
+	// sendEmail can take a while to return, so we're calling it in the
+	// background here.
+	go sendEmail(to, accountLockedSubject, body)
+	return nil
+}
@@ -0,0 +1,49 @@
+package mail
+
+import (
+	"bytes"
+	"text/template"
+	"time"
+)
+
+type NewLoginEmail struct {
+	IPAddress string
+	UserAgent string
+	Time      string
+}
+
+var newLoginSubject = "YeetFile: New login to your account"
+var newLoginTemplate = template.Must(template.New("").Parse(
+	"Hello,\n\nYour YeetFile account was just logged into from a device or " +
+		"location that hasn't been used before.\n\n" +
+		"Time: {{.Time}}\nIP address: {{.IPAddress}}\n" +
+		"Device: {{.UserAgent}}\n\nIf this was you, you can ignore this " +
+		"email. If not, you should change your password and log out " +
+		"of all other sessions as soon as possible. You can review " +
+		"recent activity in the Security Log section of your account " +
+		"in the YeetFile CLI.\n\n- YeetFile"))
+
+// SendNewLoginEmail notifies a user that their account was logged into from a
+// previously unseen device or IP address.
+func SendNewLoginEmail(to, ip, userAgent string, loginTime time.Time) error {
+	if len(userAgent) == 0 {
+		userAgent = "Unknown"
+	}
+
+	var buf bytes.Buffer
+	err := newLoginTemplate.Execute(&buf, NewLoginEmail{
+		IPAddress: ip,
+		UserAgent: userAgent,
+		Time:      loginTime.UTC().Format(time.RFC1123),
+	})
+	if err != nil {
+		return err
+	}
+
+	body := buf.String()
+
+	// sendEmail can take a while to return, so we're calling it in the
+	// background here.
+	go sendEmail(to, newLoginSubject, body)
+	return nil
+}
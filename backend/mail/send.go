@@ -0,0 +1,80 @@
+package mail
+
+import (
+	"bytes"
+	"text/template"
+)
+
+type SendNotificationEmail struct {
+	ID        string
+	Remaining int
+}
+
+// Note: Send notifications intentionally only include the ID of the send,
+// since names are encrypted and the server has no way of knowing them.
+var sendDownloadedSubject = "YeetFile Send: Link downloaded"
+var sendDownloadedTemplate = template.Must(template.New("").Parse(
+	"Hello,\n\nA link you created with YeetFile Send (ID: {{.ID}}) " +
+		"was just downloaded.\n\n" +
+		"Remaining downloads: {{.Remaining}}\n\n" +
+		"The link will be deleted once it runs out of downloads or " +
+		"reaches its expiration date.\n\n- YeetFile"))
+
+var sendExhaustedSubject = "YeetFile Send: Link deleted"
+var sendExhaustedTemplate = template.Must(template.New("").Parse(
+	"Hello,\n\nA link you created with YeetFile Send (ID: {{.ID}}) " +
+		"was just downloaded.\n\n" +
+		"Remaining downloads: 0\n\n" +
+		"The link has used all of its downloads and has been " +
+		"deleted.\n\n- YeetFile"))
+
+var sendExpiredSubject = "YeetFile Send: Link expired"
+var sendExpiredTemplate = template.Must(template.New("").Parse(
+	"Hello,\n\nA link you created with YeetFile Send (ID: {{.ID}}) " +
+		"expired without being downloaded.\n\n" +
+		"Remaining downloads: {{.Remaining}}\n\n" +
+		"The link has been deleted and can no longer be used.\n\n" +
+		"- YeetFile"))
+
+// SendDownloadedNotification notifies the owner of a send that their link was
+// downloaded, along with the number of downloads remaining. If no downloads
+// are remaining, the owner is instead notified that the link was deleted.
+func SendDownloadedNotification(to, id string, remaining int) error {
+	if remaining == 0 {
+		return sendNotification(to, sendExhaustedSubject, sendExhaustedTemplate, id, remaining)
+	}
+
+	return sendNotification(to, sendDownloadedSubject, sendDownloadedTemplate, id, remaining)
+}
+
+// SendExpiredNotification notifies the owner of a send that their link expired
+// before it was downloaded.
+func SendExpiredNotification(to, id string, remaining int) error {
+	return sendNotification(to, sendExpiredSubject, sendExpiredTemplate, id, remaining)
+}
+
+func sendNotification(
+	to, subject string,
+	tmpl *template.Template,
+	id string,
+	remaining int,
+) error {
+	var buf bytes.Buffer
+
+	notification := SendNotificationEmail{
+		ID:        id,
+		Remaining: remaining,
+	}
+
+	err := tmpl.Execute(&buf, notification)
+	if err != nil {
+		return err
+	}
+
+	body := buf.String()
+
+	// sendEmail can take a while to return, so we're calling it in the
+	// background here.
+	go sendEmail(to, subject, body)
+	return nil
+}
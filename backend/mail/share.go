@@ -0,0 +1,54 @@
+package mail
+
+import (
+	"bytes"
+	"text/template"
+	"time"
+)
+
+type ShareExpiringEmail struct {
+	ItemType   string
+	Recipient  string
+	Expiration string
+}
+
+// Note: Like send notifications, these emails don't include the name of the
+// shared item, since names are encrypted and unknown to the server.
+var shareExpiringSubject = "YeetFile Vault: Shared access expiring"
+var shareExpiringTemplate = template.Must(template.New("").Parse(
+	"Hello,\n\nA {{.ItemType}} you shared with {{.Recipient}} from your " +
+		"YeetFile vault is set to expire on {{.Expiration}}.\n\n" +
+		"Once the share expires, {{.Recipient}} will no longer have " +
+		"access to the {{.ItemType}}. If they still need access, you " +
+		"can share the {{.ItemType}} with them again after it " +
+		"expires.\n\n- YeetFile"))
+
+// SendShareExpiringEmail notifies the owner of a shared file or folder that
+// the recipient's access is about to expire.
+func SendShareExpiringEmail(
+	to, recipient string,
+	isFolder bool,
+	expiration time.Time,
+) error {
+	itemType := "file"
+	if isFolder {
+		itemType = "folder"
+	}
+
+	var buf bytes.Buffer
+	err := shareExpiringTemplate.Execute(&buf, ShareExpiringEmail{
+		ItemType:   itemType,
+		Recipient:  recipient,
+		Expiration: expiration.UTC().Format(time.RFC1123),
+	})
+	if err != nil {
+		return err
+	}
+
+	body := buf.String()
+
+	// sendEmail can take a while to return, so we're calling it in the
+	// background here.
+	go sendEmail(to, shareExpiringSubject, body)
+	return nil
+}
@@ -1,15 +1,24 @@
 package main
 
 import (
+	"fmt"
 	_ "github.com/joho/godotenv/autoload"
+	"os"
 	"yeetfile/backend/cron"
 	"yeetfile/backend/db"
 	"yeetfile/backend/server"
 	"yeetfile/backend/utils"
 )
 
+// main starts the YeetFile server. Note that the "config check" command is
+// handled earlier, while loading the config file (see utils/config_file.go).
 func main() {
 	defer db.Close()
+
+	if isReencryptSecretsCmd() {
+		os.Exit(reencryptSecrets())
+	}
+
 	cron.InitCronTasks(server.ManageLimiters)
 
 	host := utils.GetEnvVar("YEETFILE_HOST", "0.0.0.0")
@@ -17,3 +26,23 @@ func main() {
 
 	server.Run(host, port)
 }
+
+// isReencryptSecretsCmd returns true if the server was started with the
+// "secrets reencrypt" command
+func isReencryptSecretsCmd() bool {
+	return len(os.Args) > 2 && os.Args[1] == "secrets" && os.Args[2] == "reencrypt"
+}
+
+// reencryptSecrets re-encrypts every value encrypted with a previous server
+// secret (or with the legacy encryption) using the current server secret.
+// Returns the exit code for the "secrets reencrypt" command.
+func reencryptSecrets() int {
+	updated, err := db.ReencryptUserSecrets()
+	if err != nil {
+		fmt.Printf("ERROR: Re-encrypted %d users before failing: %v\n", updated, err)
+		return 1
+	}
+
+	fmt.Printf("Re-encrypted secrets for %d users\n", updated)
+	return 0
+}
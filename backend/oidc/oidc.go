@@ -0,0 +1,175 @@
+package oidc
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	discoveryPath = "/.well-known/openid-configuration"
+	scopes        = "openid email profile"
+	maxBodySize   = 1 << 20
+)
+
+var (
+	IssuerMismatchErr = errors.New("oidc issuer doesn't match the configured issuer")
+	MissingIDTokenErr = errors.New("token response is missing an id_token")
+)
+
+// Provider is an OpenID Connect identity provider that users are sent to for
+// authorization, using the authorization code flow with PKCE
+type Provider struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	GroupsClaim  string
+
+	// AllowMissingVerified accepts ID tokens without an email_verified
+	// claim, for providers that only issue emails that they manage
+	AllowMissingVerified bool
+
+	authURL  string
+	tokenURL string
+	jwksURL  string
+
+	client *http.Client
+	keys   map[string]any
+	keysMu sync.Mutex
+}
+
+type discovery struct {
+	Issuer   string `json:"issuer"`
+	AuthURL  string `json:"authorization_endpoint"`
+	TokenURL string `json:"token_endpoint"`
+	JWKSURL  string `json:"jwks_uri"`
+}
+
+type tokenResponse struct {
+	IDToken string `json:"id_token"`
+	Error   string `json:"error"`
+}
+
+// Discover fetches the provider's configuration from its discovery document
+func Discover(
+	issuer,
+	clientID,
+	clientSecret,
+	redirectURL,
+	groupsClaim string,
+) (*Provider, error) {
+	provider := &Provider{
+		Issuer:       strings.TrimSuffix(issuer, "/"),
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectURL,
+		GroupsClaim:  groupsClaim,
+		client:       &http.Client{Timeout: 10 * time.Second},
+	}
+
+	var config discovery
+	err := provider.getJSON(provider.Issuer+discoveryPath, &config)
+	if err != nil {
+		return nil, err
+	} else if strings.TrimSuffix(config.Issuer, "/") != provider.Issuer {
+		return nil, IssuerMismatchErr
+	} else if len(config.AuthURL) == 0 ||
+		len(config.TokenURL) == 0 ||
+		len(config.JWKSURL) == 0 {
+		return nil, errors.New("oidc discovery document is missing endpoints")
+	}
+
+	provider.authURL = config.AuthURL
+	provider.tokenURL = config.TokenURL
+	provider.jwksURL = config.JWKSURL
+	return provider, nil
+}
+
+// AuthCodeURL returns the URL that the user is redirected to in order to log
+// in with the provider
+func (p *Provider) AuthCodeURL(state, nonce, codeVerifier string) string {
+	challenge := sha256.Sum256([]byte(codeVerifier))
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.ClientID},
+		"redirect_uri":          {p.RedirectURL},
+		"scope":                 {scopes},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+
+	separator := "?"
+	if strings.Contains(p.authURL, "?") {
+		separator = "&"
+	}
+
+	return p.authURL + separator + params.Encode()
+}
+
+// Exchange trades an authorization code for the user's ID token, and returns
+// the token's claims once it has been verified
+func (p *Provider) Exchange(code, codeVerifier, nonce string) (Claims, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.RedirectURL},
+		"code_verifier": {codeVerifier},
+	}
+
+	req, err := http.NewRequest(
+		http.MethodPost,
+		p.tokenURL,
+		strings.NewReader(form.Encode()))
+	if err != nil {
+		return Claims{}, err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(p.ClientID), url.QueryEscape(p.ClientSecret))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	defer resp.Body.Close()
+
+	var token tokenResponse
+	err = json.NewDecoder(io.LimitReader(resp.Body, maxBodySize)).Decode(&token)
+	if err != nil {
+		return Claims{}, err
+	} else if resp.StatusCode != http.StatusOK {
+		return Claims{}, fmt.Errorf("token request failed (%d): %s",
+			resp.StatusCode, token.Error)
+	} else if len(token.IDToken) == 0 {
+		return Claims{}, MissingIDTokenErr
+	}
+
+	return p.VerifyIDToken(token.IDToken, nonce)
+}
+
+func (p *Provider) getJSON(url string, value any) error {
+	resp, err := p.client.Get(url)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request to %s failed with status %d", url, resp.StatusCode)
+	}
+
+	return json.NewDecoder(io.LimitReader(resp.Body, maxBodySize)).Decode(value)
+}
@@ -0,0 +1,180 @@
+package oidc
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+	"yeetfile/backend/oidc/oidctest"
+)
+
+const (
+	testClientID     = "yeetfile"
+	testClientSecret = "secret"
+	testRedirectURL  = "http://localhost:8090/api/oidc/callback"
+)
+
+func newTestProvider(t *testing.T) (*oidctest.Provider, *Provider) {
+	mock := oidctest.NewProvider(
+		testClientID,
+		testClientSecret,
+		"user@example.com",
+		[]string{"staff", "admins"})
+	server := httptest.NewServer(mock)
+	t.Cleanup(server.Close)
+	mock.Issuer = server.URL
+
+	provider, err := Discover(
+		server.URL,
+		testClientID,
+		testClientSecret,
+		testRedirectURL,
+		"groups")
+	assert.Nil(t, err)
+	return mock, provider
+}
+
+// authorize follows the provider's authorization redirect, and returns the
+// code and state sent back to the redirect URL
+func authorize(t *testing.T, authURL string) (string, string) {
+	client := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	resp, err := client.Get(authURL)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	assert.Nil(t, err)
+	return location.Query().Get("code"), location.Query().Get("state")
+}
+
+func TestAuthCodeFlow(t *testing.T) {
+	_, provider := newTestProvider(t)
+
+	authURL := provider.AuthCodeURL("state", "nonce", "verifier")
+	code, state := authorize(t, authURL)
+	assert.Equal(t, "state", state)
+
+	claims, err := provider.Exchange(code, "verifier", "nonce")
+	assert.Nil(t, err)
+	assert.Equal(t, "user@example.com", claims.Email)
+	assert.Equal(t, []string{"staff", "admins"}, claims.Groups)
+	assert.NotEmpty(t, claims.Subject)
+
+	// Codes can only be exchanged once
+	_, err = provider.Exchange(code, "verifier", "nonce")
+	assert.NotNil(t, err)
+}
+
+func TestAuthCodeFlowInvalid(t *testing.T) {
+	mock, provider := newTestProvider(t)
+
+	// Wrong PKCE verifier
+	code, _ := authorize(t, provider.AuthCodeURL("state", "nonce", "verifier"))
+	_, err := provider.Exchange(code, "wrong", "nonce")
+	assert.NotNil(t, err)
+
+	// Wrong nonce
+	code, _ = authorize(t, provider.AuthCodeURL("state", "nonce", "verifier"))
+	_, err = provider.Exchange(code, "verifier", "other")
+	assert.Equal(t, TokenNonceErr, err)
+
+	// Unverified email
+	mock.EmailVerified = false
+	code, _ = authorize(t, provider.AuthCodeURL("state", "nonce", "verifier"))
+	_, err = provider.Exchange(code, "verifier", "nonce")
+	assert.Equal(t, UnverifiedEmailErr, err)
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	mock, provider := newTestProvider(t)
+	claims := func() map[string]any {
+		return map[string]any{
+			"iss":   mock.Issuer,
+			"aud":   []string{testClientID},
+			"sub":   "subject",
+			"email": "user@example.com",
+			"nonce": "nonce",
+			"exp":   time.Now().Add(time.Minute).Unix(),
+		}
+	}
+
+	// email_verified is required unless the provider is trusted without it
+	_, err := provider.VerifyIDToken(mock.SignToken(claims()), "nonce")
+	assert.Equal(t, UnverifiedEmailErr, err)
+
+	provider.AllowMissingVerified = true
+	_, err = provider.VerifyIDToken(mock.SignToken(claims()), "nonce")
+	assert.Nil(t, err)
+
+	unverified := claims()
+	unverified["email_verified"] = false
+	_, err = provider.VerifyIDToken(mock.SignToken(unverified), "nonce")
+	assert.Equal(t, UnverifiedEmailErr, err)
+	provider.AllowMissingVerified = false
+
+	verified := claims()
+	verified["email_verified"] = "true"
+	_, err = provider.VerifyIDToken(mock.SignToken(verified), "nonce")
+	assert.Nil(t, err)
+
+	expired := claims()
+	expired["exp"] = time.Now().Add(-time.Hour).Unix()
+	_, err = provider.VerifyIDToken(mock.SignToken(expired), "nonce")
+	assert.Equal(t, TokenExpiredErr, err)
+
+	wrongAudience := claims()
+	wrongAudience["aud"] = "other-client"
+	_, err = provider.VerifyIDToken(mock.SignToken(wrongAudience), "nonce")
+	assert.Equal(t, TokenAudienceErr, err)
+
+	wrongIssuer := claims()
+	wrongIssuer["iss"] = "https://example.com"
+	_, err = provider.VerifyIDToken(mock.SignToken(wrongIssuer), "nonce")
+	assert.Equal(t, TokenIssuerErr, err)
+
+	missingEmail := claims()
+	delete(missingEmail, "email")
+	_, err = provider.VerifyIDToken(mock.SignToken(missingEmail), "nonce")
+	assert.Equal(t, MissingEmailErr, err)
+
+	// Tampered payloads fail signature verification
+	token := strings.Split(mock.SignToken(claims()), ".")
+	otherToken := strings.Split(mock.SignToken(expired), ".")
+	tampered := token[0] + "." + otherToken[1] + "." + token[2]
+	_, err = provider.VerifyIDToken(tampered, "nonce")
+	assert.Equal(t, TokenSignatureErr, err)
+
+	_, err = provider.VerifyIDToken("not.a-token", "nonce")
+	assert.Equal(t, InvalidTokenErr, err)
+}
+
+func TestDiscoverIssuerMismatch(t *testing.T) {
+	mock := oidctest.NewProvider(testClientID, testClientSecret, "user@example.com", nil)
+	server := httptest.NewServer(mock)
+	defer server.Close()
+	mock.Issuer = "https://example.com"
+
+	_, err := Discover(server.URL, testClientID, testClientSecret, testRedirectURL, "groups")
+	assert.Equal(t, IssuerMismatchErr, err)
+}
+
+func TestClaimsAccess(t *testing.T) {
+	claims := Claims{Email: "user@Example.com", Groups: []string{"staff", "it"}}
+
+	assert.True(t, claims.InDomain([]string{"example.org", "example.com"}))
+	assert.True(t, claims.InDomain([]string{"@example.com"}))
+	assert.False(t, claims.InDomain([]string{"sub.example.com"}))
+	assert.False(t, claims.InDomain(nil))
+
+	assert.True(t, claims.InAnyGroup([]string{"admins", "it"}))
+	assert.False(t, claims.InAnyGroup([]string{"admins"}))
+	assert.False(t, claims.InAnyGroup(nil))
+}
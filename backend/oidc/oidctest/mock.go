@@ -0,0 +1,190 @@
+// Package oidctest provides a mock OpenID Connect identity provider, which
+// automatically approves every authorization request as a single configured
+// user. It's intended for tests and local development only.
+package oidctest
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const mockKeyID = "mock-key"
+
+type authRequest struct {
+	redirectURI string
+	nonce       string
+	challenge   string
+}
+
+// Provider is a mock identity provider. Issuer must be set to the URL that the
+// provider is served from before it's used.
+type Provider struct {
+	Issuer        string
+	ClientID      string
+	ClientSecret  string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Groups        []string
+
+	key   *rsa.PrivateKey
+	codes map[string]authRequest
+	mu    sync.Mutex
+}
+
+// NewProvider returns a mock identity provider that logs in every user as the
+// provided email
+func NewProvider(clientID, clientSecret, email string, groups []string) *Provider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+
+	return &Provider{
+		ClientID:      clientID,
+		ClientSecret:  clientSecret,
+		Subject:       randomString(),
+		Email:         email,
+		EmailVerified: true,
+		Groups:        groups,
+		key:           key,
+		codes:         map[string]authRequest{},
+	}
+}
+
+func (p *Provider) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	switch req.URL.Path {
+	case "/.well-known/openid-configuration":
+		writeJSON(w, map[string]string{
+			"issuer":                 p.Issuer,
+			"authorization_endpoint": p.Issuer + "/authorize",
+			"token_endpoint":         p.Issuer + "/token",
+			"jwks_uri":               p.Issuer + "/jwks",
+		})
+	case "/jwks":
+		writeJSON(w, map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": mockKeyID,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+		}}})
+	case "/authorize":
+		p.authorize(w, req)
+	case "/token":
+		p.token(w, req)
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+// authorize immediately redirects back to the client with a new code
+func (p *Provider) authorize(w http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	redirectURI := query.Get("redirect_uri")
+	if query.Get("client_id") != p.ClientID || len(redirectURI) == 0 {
+		http.Error(w, "invalid client", http.StatusBadRequest)
+		return
+	}
+
+	code := randomString()
+	p.mu.Lock()
+	p.codes[code] = authRequest{
+		redirectURI: redirectURI,
+		nonce:       query.Get("nonce"),
+		challenge:   query.Get("code_challenge"),
+	}
+	p.mu.Unlock()
+
+	params := url.Values{"code": {code}, "state": {query.Get("state")}}
+	http.Redirect(w, req, redirectURI+"?"+params.Encode(), http.StatusFound)
+}
+
+// token exchanges a code for a signed ID token
+func (p *Provider) token(w http.ResponseWriter, req *http.Request) {
+	clientID, clientSecret, _ := req.BasicAuth()
+	clientID, _ = url.QueryUnescape(clientID)
+	clientSecret, _ = url.QueryUnescape(clientSecret)
+	if clientID != p.ClientID || clientSecret != p.ClientSecret {
+		writeError(w, http.StatusUnauthorized, "invalid_client")
+		return
+	}
+
+	code := req.PostFormValue("code")
+	p.mu.Lock()
+	request, ok := p.codes[code]
+	delete(p.codes, code)
+	p.mu.Unlock()
+
+	verifier := sha256.Sum256([]byte(req.PostFormValue("code_verifier")))
+	challenge := base64.RawURLEncoding.EncodeToString(verifier[:])
+	if !ok || request.redirectURI != req.PostFormValue("redirect_uri") ||
+		request.challenge != challenge {
+		writeError(w, http.StatusBadRequest, "invalid_grant")
+		return
+	}
+
+	now := time.Now()
+	writeJSON(w, map[string]string{
+		"token_type": "Bearer",
+		"id_token": p.SignToken(map[string]any{
+			"iss":            p.Issuer,
+			"aud":            p.ClientID,
+			"sub":            p.Subject,
+			"email":          p.Email,
+			"email_verified": p.EmailVerified,
+			"groups":         p.Groups,
+			"nonce":          request.nonce,
+			"iat":            now.Unix(),
+			"exp":            now.Add(5 * time.Minute).Unix(),
+		}),
+	})
+}
+
+// SignToken returns a JWT containing the provided claims, signed with the
+// provider's key
+func (p *Provider) SignToken(claims map[string]any) string {
+	header, _ := json.Marshal(map[string]string{
+		"alg": "RS256",
+		"kid": mockKeyID,
+		"typ": "JWT",
+	})
+	payload, _ := json.Marshal(claims)
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." +
+		base64.RawURLEncoding.EncodeToString(payload)
+	hash := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, p.key, crypto.SHA256, hash[:])
+	if err != nil {
+		panic(err)
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func writeJSON(w http.ResponseWriter, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": code})
+}
+
+func randomString() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,360 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	algRS256 = "RS256"
+	algES256 = "ES256"
+
+	clockSkew = time.Minute
+)
+
+var (
+	InvalidTokenErr    = errors.New("malformed id token")
+	UnsupportedAlgErr  = errors.New("unsupported id token signing algorithm")
+	UnknownKeyErr      = errors.New("id token signed with an unknown key")
+	TokenSignatureErr  = errors.New("id token signature is invalid")
+	TokenIssuerErr     = errors.New("id token issuer is invalid")
+	TokenAudienceErr   = errors.New("id token audience is invalid")
+	TokenExpiredErr    = errors.New("id token has expired")
+	TokenNonceErr      = errors.New("id token nonce is invalid")
+	MissingEmailErr    = errors.New("id token is missing an email")
+	UnverifiedEmailErr = errors.New("id token email hasn't been verified")
+)
+
+// Claims are the verified claims from a user's ID token that are used to
+// authorize them
+type Claims struct {
+	Subject string
+	Email   string
+	Groups  []string
+}
+
+type tokenHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// VerifyIDToken verifies the signature and standard claims of an ID token,
+// and returns the user's claims. Tokens without a verified email are
+// rejected, since the email is used as the user's YeetFile identifier.
+func (p *Provider) VerifyIDToken(rawToken, nonce string) (Claims, error) {
+	parts := strings.Split(rawToken, ".")
+	if len(parts) != 3 {
+		return Claims{}, InvalidTokenErr
+	}
+
+	var header tokenHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Claims{}, InvalidTokenErr
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Claims{}, InvalidTokenErr
+	}
+
+	key, err := p.getKey(header.Kid)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	err = verifySignature(header.Alg, key, []byte(parts[0]+"."+parts[1]), signature)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	var payload map[string]any
+	if err = decodeSegment(parts[1], &payload); err != nil {
+		return Claims{}, InvalidTokenErr
+	}
+
+	return p.validateClaims(payload, nonce)
+}
+
+func (p *Provider) validateClaims(payload map[string]any, nonce string) (Claims, error) {
+	issuer, _ := payload["iss"].(string)
+	if strings.TrimSuffix(issuer, "/") != p.Issuer {
+		return Claims{}, TokenIssuerErr
+	}
+
+	if !hasAudience(payload["aud"], p.ClientID) {
+		return Claims{}, TokenAudienceErr
+	} else if azp, ok := payload["azp"].(string); ok && azp != p.ClientID {
+		return Claims{}, TokenAudienceErr
+	}
+
+	exp, ok := payload["exp"].(float64)
+	if !ok || time.Now().After(time.Unix(int64(exp), 0).Add(clockSkew)) {
+		return Claims{}, TokenExpiredErr
+	}
+
+	if tokenNonce, _ := payload["nonce"].(string); tokenNonce != nonce {
+		return Claims{}, TokenNonceErr
+	}
+
+	claims := Claims{Groups: stringList(payload[p.GroupsClaim])}
+	claims.Subject, _ = payload["sub"].(string)
+	claims.Email, _ = payload["email"].(string)
+	if len(claims.Email) == 0 {
+		return Claims{}, MissingEmailErr
+	}
+
+	// The email is used to restrict logins and to create accounts, so it
+	// has to be verified by the provider. Providers that omit the claim
+	// for emails that they manage can be trusted with AllowMissingVerified.
+	switch verified := payload["email_verified"].(type) {
+	case bool:
+		ok = verified
+	case string:
+		ok = verified == "true"
+	case nil:
+		ok = p.AllowMissingVerified
+	default:
+		ok = false
+	}
+
+	if !ok {
+		return Claims{}, UnverifiedEmailErr
+	}
+
+	return claims, nil
+}
+
+// InDomain returns true if the user's email belongs to one of the domains
+func (c Claims) InDomain(domains []string) bool {
+	_, domain, found := strings.Cut(c.Email, "@")
+	if !found {
+		return false
+	}
+
+	for _, allowed := range domains {
+		if strings.EqualFold(domain, strings.TrimPrefix(allowed, "@")) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// InAnyGroup returns true if the user is a member of at least one of the groups
+func (c Claims) InAnyGroup(groups []string) bool {
+	for _, group := range groups {
+		if slices.Contains(c.Groups, group) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getKey returns the provider's signing key with the provided ID, refreshing
+// the provider's keys if it isn't found (i.e. after the provider rotates its
+// keys)
+func (p *Provider) getKey(kid string) (any, error) {
+	p.keysMu.Lock()
+	defer p.keysMu.Unlock()
+
+	if key, ok := p.findKey(kid); ok {
+		return key, nil
+	}
+
+	var jwks struct {
+		Keys []jwk `json:"keys"`
+	}
+
+	err := p.getJSON(p.jwksURL, &jwks)
+	if err != nil {
+		return nil, err
+	}
+
+	p.keys = map[string]any{}
+	for _, key := range jwks.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+
+		publicKey, err := parseJWK(key)
+		if err != nil {
+			continue
+		}
+
+		p.keys[key.Kid] = publicKey
+	}
+
+	if key, ok := p.findKey(kid); ok {
+		return key, nil
+	}
+
+	return nil, UnknownKeyErr
+}
+
+// findKey returns the key matching the key ID. Tokens without a key ID can
+// only be used with providers that have a single signing key.
+func (p *Provider) findKey(kid string) (any, bool) {
+	if len(kid) == 0 && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+func parseJWK(key jwk) (any, error) {
+	switch key.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(key.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := base64.RawURLEncoding.DecodeString(key.E)
+		if err != nil {
+			return nil, err
+		}
+
+		publicKey := &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+
+		if publicKey.N.BitLen() < 2048 || publicKey.E < 3 {
+			return nil, errors.New("invalid rsa key")
+		}
+
+		return publicKey, nil
+	case "EC":
+		if key.Crv != "P-256" {
+			return nil, errors.New("unsupported curve")
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(key.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := base64.RawURLEncoding.DecodeString(key.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		// Ensures the point is on the curve
+		point := append([]byte{0x04}, append(leftPad(x, 32), leftPad(y, 32)...)...)
+		if _, err = ecdh.P256().NewPublicKey(point); err != nil {
+			return nil, err
+		}
+
+		return &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}, nil
+	default:
+		return nil, errors.New("unsupported key type")
+	}
+}
+
+func verifySignature(alg string, key any, signed, signature []byte) error {
+	hash := sha256.Sum256(signed)
+	switch alg {
+	case algRS256:
+		publicKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return TokenSignatureErr
+		}
+
+		err := rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, hash[:], signature)
+		if err != nil {
+			return TokenSignatureErr
+		}
+	case algES256:
+		publicKey, ok := key.(*ecdsa.PublicKey)
+		if !ok || len(signature) != 64 {
+			return TokenSignatureErr
+		}
+
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(publicKey, hash[:], r, s) {
+			return TokenSignatureErr
+		}
+	default:
+		return UnsupportedAlgErr
+	}
+
+	return nil
+}
+
+func decodeSegment(segment string, value any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, value)
+}
+
+func hasAudience(aud any, clientID string) bool {
+	for _, audience := range stringList(aud) {
+		if audience == clientID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// stringList returns a claim that can be either a single string or an array
+// of strings as a slice
+func stringList(claim any) []string {
+	switch value := claim.(type) {
+	case string:
+		return []string{value}
+	case []any:
+		var values []string
+		for _, item := range value {
+			if str, ok := item.(string); ok {
+				values = append(values, str)
+			}
+		}
+
+		return values
+	default:
+		return nil
+	}
+}
+
+func leftPad(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+
+	return append(make([]byte, size-len(b)), b...)
+}
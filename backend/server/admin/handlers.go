@@ -6,6 +6,7 @@ import (
 	"log"
 	"net/http"
 	"strings"
+	"yeetfile/backend/db"
 	"yeetfile/shared"
 )
 
@@ -39,12 +40,18 @@ func UserActionHandler(w http.ResponseWriter, req *http.Request, id string) {
 			return
 		}
 
+		locked, err := db.IsUserLocked(user.ID)
+		if err != nil {
+			log.Printf("Error checking user lockout: %v\n", err)
+		}
+
 		files := fetchAllFiles(userID)
 		userResponse := shared.AdminUserInfoResponse{
 			ID:          user.ID,
 			Email:       user.Email,
 			StorageUsed: shared.ReadableFileSize(user.StorageUsed),
 			SendUsed:    shared.ReadableFileSize(user.SendUsed),
+			Locked:      locked,
 
 			Files: files,
 		}
@@ -53,6 +60,30 @@ func UserActionHandler(w http.ResponseWriter, req *http.Request, id string) {
 	}
 }
 
+// UnlockUserHandler clears a user's failed login and 2FA attempts, which
+// removes any lockout on their account
+func UnlockUserHandler(w http.ResponseWriter, req *http.Request, _ string) {
+	segments := strings.Split(req.URL.Path, "/")
+	userID := segments[len(segments)-1]
+
+	user, err := getUserInfo(userID)
+	if err == sql.ErrNoRows {
+		http.Error(w, "No match found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.Printf("Error fetching user: %v\n", err)
+		http.Error(w, "Failed to fetch user info", http.StatusInternalServerError)
+		return
+	}
+
+	err = db.UnlockUser(user.ID)
+	if err != nil {
+		log.Printf("Error unlocking user: %v\n", err)
+		http.Error(w, "Failed to unlock user", http.StatusInternalServerError)
+		return
+	}
+}
+
 func FileActionHandler(w http.ResponseWriter, req *http.Request, _ string) {
 	segments := strings.Split(req.URL.Path, "/")
 	fileID := segments[len(segments)-1]
@@ -0,0 +1,85 @@
+package audit
+
+import (
+	"log"
+	"net/http"
+	"time"
+	"yeetfile/backend/config"
+	"yeetfile/backend/db"
+	"yeetfile/backend/mail"
+	"yeetfile/backend/utils"
+	"yeetfile/shared"
+	"yeetfile/shared/constants"
+)
+
+const maxUserAgentLen = 256
+
+// newEvent creates a security event with the source of the request
+func newEvent(req *http.Request, eventType, details string) shared.SecurityEvent {
+	ip, err := utils.GetReqSource(req)
+	if err != nil {
+		log.Printf("Error reading request source: %v\n", err)
+	}
+
+	userAgent := req.UserAgent()
+	if len(userAgent) > maxUserAgentLen {
+		userAgent = userAgent[:maxUserAgentLen]
+	}
+
+	return shared.SecurityEvent{
+		Type:      eventType,
+		IPAddress: ip,
+		UserAgent: userAgent,
+		Details:   details,
+	}
+}
+
+// Record adds an event to the user's security log. Errors are logged rather
+// than returned, since failing to record an event shouldn't interrupt the
+// request that triggered it.
+func Record(req *http.Request, userID, eventType, details string) {
+	err := db.NewSecurityEvent(userID, newEvent(req, eventType, details))
+	if err != nil {
+		log.Printf("Error recording security event: %v\n", err)
+	}
+}
+
+// RecordLogin adds a successful login to the user's security log, and emails
+// the user if the login came from a device or IP address that they haven't
+// logged in from before.
+func RecordLogin(req *http.Request, userID string) {
+	event := newEvent(req, constants.EventLogin, "")
+	isNew, err := db.IsNewLoginSource(userID, event.IPAddress, event.UserAgent)
+	if err != nil {
+		log.Printf("Error checking login source: %v\n", err)
+	}
+
+	err = db.NewSecurityEvent(userID, event)
+	if err != nil {
+		log.Printf("Error recording security event: %v\n", err)
+	}
+
+	if isNew && config.YeetFileConfig.Email.Configured {
+		sendLoginAlert(userID, event)
+	}
+}
+
+func sendLoginAlert(userID string, event shared.SecurityEvent) {
+	loginAlerts, err := db.GetUserLoginAlerts(userID)
+	if err != nil {
+		log.Printf("Error checking user login alerts: %v\n", err)
+		return
+	} else if !loginAlerts {
+		return
+	}
+
+	email, err := db.GetUserEmailByID(userID)
+	if err != nil || len(email) == 0 {
+		return
+	}
+
+	err = mail.SendNewLoginEmail(email, event.IPAddress, event.UserAgent, time.Now())
+	if err != nil {
+		log.Printf("Error sending new login email: %v\n", err)
+	}
+}
@@ -9,19 +9,24 @@ import (
 	"yeetfile/backend/db"
 	"yeetfile/backend/server/transfer/vault"
 	"yeetfile/shared"
+	"yeetfile/shared/constants"
 )
 
 var (
-	Missing2FAErr = errors.New("TOTP code missing")
-	Failed2FAErr  = errors.New("TOTP code failed")
+	Missing2FAErr = errors.New("2FA code or security key missing")
+	Failed2FAErr  = errors.New("2FA code or security key failed")
 )
 
 // ValidateCredentials checks the provided key hash against the one stored in
 // the database, and if there's a match, returns the user's true account ID.
+// If validate2FA is true, either a TOTP/recovery code or a WebAuthn assertion
+// is required for users with 2FA enabled. Accounts with too many recent failed
+// attempts are temporarily locked, and return AccountLockedErr.
 func ValidateCredentials(
 	identifier string,
 	keyHash []byte,
 	code string,
+	assertion *shared.WebAuthnAssertion,
 	validate2FA bool,
 ) (string, error) {
 	var userID string
@@ -47,13 +52,21 @@ func ValidateCredentials(
 		userID = identifier
 	}
 
+	err = checkLockout(userID, passwordAttempt)
+	if err != nil {
+		return "", err
+	}
+
 	err = bcrypt.CompareHashAndPassword(pwHash, keyHash)
 	if err != nil {
+		addFailedAttempt(userID, passwordAttempt)
 		return "", err
 	}
 
-	if secret != nil && len(secret) > 0 && validate2FA {
-		err = validateTOTP(secret, code, userID)
+	resetFailedAttempts(userID, passwordAttempt)
+
+	if validate2FA {
+		err = validateSecondFactor(userID, secret, code, assertion)
 		if err != nil {
 			return "", err
 		}
@@ -62,6 +75,29 @@ func ValidateCredentials(
 	return userID, nil
 }
 
+// validateSecondFactor checks the user's TOTP or recovery code, or a WebAuthn
+// assertion from one of their security keys. Users without 2FA enabled don't
+// need to provide either.
+func validateSecondFactor(
+	userID string,
+	secret []byte,
+	code string,
+	assertion *shared.WebAuthnAssertion,
+) error {
+	webAuthnCount, err := db.CountWebAuthnCredentials(userID)
+	if err != nil {
+		return err
+	} else if len(secret) == 0 && webAuthnCount == 0 {
+		return nil
+	}
+
+	if assertion != nil && webAuthnCount > 0 {
+		return validateWebAuthn(userID, *assertion)
+	}
+
+	return validateTOTP(secret, code, userID)
+}
+
 func createNewUser(values db.VerifiedAccountValues) (string, error) {
 	var id string
 	var err error
@@ -74,6 +110,7 @@ func createNewUser(values db.VerifiedAccountValues) (string, error) {
 			PublicKey:           values.PublicKey,
 			ProtectedPrivateKey: values.ProtectedPrivateKey,
 			PasswordHash:        values.PasswordHash,
+			KDF:                 values.KDF,
 		})
 	} else {
 		id, err = db.NewUser(db.User{
@@ -82,6 +119,7 @@ func createNewUser(values db.VerifiedAccountValues) (string, error) {
 			PublicKey:           values.PublicKey,
 			ProtectedPrivateKey: values.ProtectedPrivateKey,
 			PasswordHint:        values.PasswordHint,
+			KDF:                 values.KDF,
 		})
 	}
 
@@ -90,6 +128,18 @@ func createNewUser(values db.VerifiedAccountValues) (string, error) {
 		return "", err
 	}
 
+	// Set the recovery key, if the user created one during signup
+	if len(values.RecoveryHash) > 0 {
+		err = db.SetUserRecoveryKey(
+			id,
+			values.RecoveryHash,
+			values.RecoveryProtectedKey)
+		if err != nil {
+			log.Printf("Error setting user recovery key: %v\n", err)
+			return "", err
+		}
+	}
+
 	// Initialize user's root vault folder
 	err = db.NewRootFolder(id, values.ProtectedVaultFolderKey)
 	if err != nil {
@@ -112,6 +162,7 @@ func updateUser(values db.VerifiedAccountValues) error {
 		Email:               values.Email,
 		PasswordHash:        values.PasswordHash,
 		ProtectedPrivateKey: values.ProtectedPrivateKey,
+		KDF:                 values.KDF,
 	}, values.AccountID)
 
 	if err != nil {
@@ -121,7 +172,15 @@ func updateUser(values db.VerifiedAccountValues) error {
 	return nil
 }
 
+// IsInstanceAdmin returns true if the user is the configured instance admin, or
+// if the identity provider placed them in one of the admin groups
 func IsInstanceAdmin(currentUserID string) bool {
+	if config.YeetFileConfig.OIDC.Configured &&
+		len(config.YeetFileConfig.OIDC.AdminGroups) > 0 &&
+		db.IsUserSSOAdmin(currentUserID) {
+		return true
+	}
+
 	adminID := config.InstanceAdmin
 	if len(adminID) > 0 {
 		if strings.Contains(adminID, "@") {
@@ -146,12 +205,68 @@ func DeleteUser(id string, deleteAccount shared.DeleteAccount) error {
 		return errors.New("error validating account")
 	}
 
+	orgID, role, err := db.GetUserOrg(id)
+	if err != nil {
+		log.Printf("Error fetching user organization: %v\n", err)
+		return err
+	} else if role == constants.OrgRoleOwner {
+		return errors.New("the user's organization must be deleted first")
+	} else if len(orgID) > 0 {
+		err = db.RemoveOrgMember(orgID, id)
+		if err != nil {
+			log.Printf("Error removing user from organization: %v\n", err)
+			return err
+		}
+	}
+
 	_, err = vault.DeleteVaultFolder(id, id, false, false)
 	if err != nil {
 		log.Printf("Error deleting user root folder: %v\n", err)
 		return err
 	}
 
+	err = db.DeleteUserFileRequests(id)
+	if err != nil {
+		log.Printf("Error deleting user file requests: %v\n", err)
+		return err
+	}
+
+	err = db.DeleteUserInvitations(id)
+	if err != nil {
+		log.Printf("Error deleting user share invitations: %v\n", err)
+		return err
+	}
+
+	err = db.DeleteUserOwnershipTransfers(id)
+	if err != nil {
+		log.Printf("Error deleting user ownership transfers: %v\n", err)
+		return err
+	}
+
+	err = db.DeleteUserEmergencyAccess(id)
+	if err != nil {
+		log.Printf("Error deleting user emergency access: %v\n", err)
+		return err
+	}
+
+	err = db.DeleteUserWebAuthn(id)
+	if err != nil {
+		log.Printf("Error deleting user security keys: %v\n", err)
+		return err
+	}
+
+	err = db.DeleteUserSecurityEvents(id)
+	if err != nil {
+		log.Printf("Error deleting user security events: %v\n", err)
+		return err
+	}
+
+	err = db.UnlockUser(id)
+	if err != nil {
+		log.Printf("Error deleting user failed attempts: %v\n", err)
+		return err
+	}
+
 	err = db.DeleteUser(id)
 	if err != nil {
 		log.Printf("Error deleting user: %v\n", err)
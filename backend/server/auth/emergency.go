@@ -0,0 +1,280 @@
+package auth
+
+import (
+	"encoding/json"
+	"golang.org/x/crypto/bcrypt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"yeetfile/backend/db"
+	"yeetfile/backend/mail"
+	"yeetfile/backend/server/audit"
+	"yeetfile/backend/utils"
+	"yeetfile/shared"
+	"yeetfile/shared/constants"
+)
+
+// EmergencyAccessHandler returns the user's emergency contacts and the users
+// who designated them as a contact (GET), or adds a new emergency contact
+// (POST)
+func EmergencyAccessHandler(w http.ResponseWriter, req *http.Request, userID string) {
+	if req.Method == http.MethodGet {
+		response, err := db.GetEmergencyAccess(userID)
+		if err != nil {
+			log.Printf("Error fetching emergency access: %v\n", err)
+			http.Error(w, "Error fetching emergency access", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	var contact shared.NewEmergencyContact
+	err := utils.LimitedJSONReader(w, req.Body).Decode(&contact)
+	if err != nil || len(contact.User) == 0 || len(contact.ProtectedKey) == 0 {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	} else if contact.AccessType != constants.EmergencyAccessView &&
+		contact.AccessType != constants.EmergencyAccessTakeover {
+		http.Error(w, "Invalid access type", http.StatusBadRequest)
+		return
+	} else if contact.WaitDays < 1 || contact.WaitDays > constants.MaxEmergencyWaitDays {
+		http.Error(w, "Invalid waiting period", http.StatusBadRequest)
+		return
+	}
+
+	contactID, err := getEmergencyContactID(contact.User)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	id, err := db.NewEmergencyContact(
+		userID,
+		contactID,
+		contact.AccessType,
+		contact.WaitDays,
+		contact.ProtectedKey)
+	if err == db.EmergencyAccessExistsError {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	} else if err != nil {
+		log.Printf("Error adding emergency contact: %v\n", err)
+		http.Error(w, "Error adding emergency contact", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(shared.EmergencyAccess{
+		ID:         id,
+		AccessType: contact.AccessType,
+		WaitDays:   contact.WaitDays,
+		Status:     constants.EmergencyStatusIdle,
+		HasKey:     true,
+	})
+}
+
+// EmergencyContactHandler allows the owner to replace the private key shared
+// with an emergency contact (PUT), or either the owner or the contact to
+// remove the contact (DELETE)
+func EmergencyContactHandler(w http.ResponseWriter, req *http.Request, userID string) {
+	segments := strings.Split(req.URL.Path, "/")
+	id := segments[len(segments)-1]
+
+	var err error
+	switch req.Method {
+	case http.MethodPut:
+		var update shared.UpdateEmergencyAccess
+		err = utils.LimitedJSONReader(w, req.Body).Decode(&update)
+		if err != nil || len(update.ProtectedKey) == 0 {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		err = db.UpdateEmergencyAccessKey(userID, id, update.ProtectedKey)
+	case http.MethodDelete:
+		err = db.DeleteEmergencyAccess(userID, id)
+	}
+
+	handleEmergencyAccessError(w, err)
+}
+
+// EmergencyRequestHandler allows a contact to request access to the owner's
+// vault (POST), which notifies the owner by email, or the owner to deny a
+// request and the contact to cancel it (DELETE)
+func EmergencyRequestHandler(w http.ResponseWriter, req *http.Request, userID string) {
+	segments := strings.Split(req.URL.Path, "/")
+	id := segments[len(segments)-1]
+
+	if req.Method == http.MethodDelete {
+		handleEmergencyAccessError(w, db.DenyEmergencyAccess(userID, id))
+		return
+	}
+
+	ownerID, waitDays, err := db.RequestEmergencyAccess(userID, id)
+	if err != nil {
+		handleEmergencyAccessError(w, err)
+		return
+	}
+
+	email, err := db.GetUserEmailByID(ownerID)
+	if err != nil || len(email) == 0 {
+		log.Printf("Unable to notify user of emergency access request: %v\n", err)
+		return
+	}
+
+	contactName, err := db.GetUserPublicName(userID)
+	if err != nil {
+		contactName = "An emergency contact"
+	}
+
+	granted := time.Now().Add(time.Duration(waitDays) * 24 * time.Hour)
+	err = mail.SendEmergencyAccessRequestEmail(email, contactName, waitDays, granted)
+	if err != nil {
+		log.Printf("Error sending emergency access request email: %v\n", err)
+	}
+}
+
+// EmergencyKeyHandler returns the owner's keys to a contact whose emergency
+// access has been granted
+func EmergencyKeyHandler(w http.ResponseWriter, req *http.Request, userID string) {
+	segments := strings.Split(req.URL.Path, "/")
+	id := segments[len(segments)-1]
+
+	ownerID, accessType, protectedKey, err := db.GetGrantedEmergencyAccess(userID, id)
+	if err != nil {
+		handleEmergencyAccessError(w, err)
+		return
+	}
+
+	pubKey, err := db.GetUserPubKey(ownerID)
+	if err != nil {
+		log.Printf("Error fetching pub key: %v\n", err)
+		http.Error(w, "Error fetching keys", http.StatusInternalServerError)
+		return
+	}
+
+	response := shared.EmergencyAccessKeyResponse{
+		AccessType:   accessType,
+		PublicKey:    pubKey,
+		ProtectedKey: protectedKey,
+	}
+
+	if accessType == constants.EmergencyAccessTakeover {
+		email, err := db.GetUserEmailByID(ownerID)
+		if err != nil {
+			log.Printf("Error fetching user email: %v\n", err)
+			http.Error(w, "Error fetching keys", http.StatusInternalServerError)
+			return
+		}
+
+		// The owner's user key is derived from their email, or their
+		// account ID if they don't have an email
+		response.Identifier = email
+		if len(email) == 0 {
+			response.Identifier = ownerID
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// EmergencyTakeoverHandler replaces the owner's login with one set by a
+// contact with takeover access. The owner's 2FA (including security keys),
+// password hint, and existing sessions are removed, since they can't be used
+// by the contact.
+func EmergencyTakeoverHandler(w http.ResponseWriter, req *http.Request, userID string) {
+	segments := strings.Split(req.URL.Path, "/")
+	id := segments[len(segments)-1]
+
+	var takeover shared.EmergencyTakeover
+	err := utils.LimitedJSONReader(w, req.Body).Decode(&takeover)
+	if err != nil || utils.IsStructMissingAnyField(takeover) {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	kdf, err := shared.ValidateKDFParams(takeover.KDF)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ownerID, accessType, _, err := db.GetGrantedEmergencyAccess(userID, id)
+	if err != nil {
+		handleEmergencyAccessError(w, err)
+		return
+	} else if accessType != constants.EmergencyAccessTakeover {
+		http.Error(w, "Emergency contact doesn't have takeover access",
+			http.StatusForbidden)
+		return
+	}
+
+	bcryptHash, err := bcrypt.GenerateFromPassword(takeover.LoginKeyHash, 8)
+	if err != nil {
+		log.Printf("Error generating bcrypt hash: %v\n", err)
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	err = db.UpdateUserLogin(ownerID, bcryptHash, takeover.ProtectedKey, kdf)
+	if err != nil {
+		log.Printf("Error updating user login credentials: %v\n", err)
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	// The password hint was for the previous password
+	err = db.UpdatePasswordHint(ownerID, nil)
+	if err != nil {
+		log.Printf("Error removing pw hint: %v\n", err)
+	}
+
+	audit.Record(req, ownerID, constants.EventPasswordChanged, "emergency takeover")
+
+	err = db.RemoveUser2FA(ownerID)
+	if err == nil {
+		err = db.DeleteUserWebAuthn(ownerID)
+	}
+
+	if err != nil {
+		log.Printf("Error removing 2FA: %v\n", err)
+	} else {
+		audit.Record(req, ownerID, constants.Event2FADisabled, "emergency takeover")
+	}
+
+	// Log out every existing session
+	err = db.SetUserSessionKey(ownerID, shared.GenRandomString(16))
+	if err != nil {
+		log.Printf("Error resetting user session key: %v\n", err)
+	} else {
+		audit.Record(req, ownerID, constants.EventSessionsRevoked, "emergency takeover")
+	}
+}
+
+func getEmergencyContactID(user string) (string, error) {
+	if strings.Contains(user, "@") {
+		return db.GetUserIDByEmail(user)
+	}
+
+	_, err := db.GetUserByID(user)
+	return user, err
+}
+
+func handleEmergencyAccessError(w http.ResponseWriter, err error) {
+	switch err {
+	case nil:
+		return
+	case db.EmergencyAccessNotFoundError:
+		http.Error(w, "Emergency access not found", http.StatusNotFound)
+	case db.EmergencyAccessNotGrantedError, db.EmergencyAccessKeyError:
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		log.Printf("Error updating emergency access: %v\n", err)
+		http.Error(w, "Error updating emergency access", http.StatusInternalServerError)
+	}
+}
@@ -10,12 +10,46 @@ import (
 	"yeetfile/backend/crypto"
 	"yeetfile/backend/db"
 	"yeetfile/backend/mail"
+	"yeetfile/backend/server/audit"
 	"yeetfile/backend/server/session"
 	"yeetfile/backend/utils"
 	"yeetfile/shared"
 	"yeetfile/shared/constants"
 )
 
+// KDFHandler returns the key derivation params that a user needs in order to
+// derive their login key hash. Unknown users are given params chosen by
+// decoyKDFParams, so that the response doesn't reveal whether an account
+// exists.
+func KDFHandler(w http.ResponseWriter, req *http.Request) {
+	identifier := req.URL.Query().Get("identifier")
+	if len(identifier) == 0 {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	params, err := db.GetUserKDFParams(identifier)
+	if err != nil {
+		params = decoyKDFParams(identifier)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(params)
+}
+
+// decoyKDFParams returns the params for an identifier that doesn't belong to
+// an account. Accounts use either the legacy or the default params, depending
+// on whether they've been upgraded yet, so one of the two is picked using an
+// HMAC of the identifier. The same identifier always gets the same params, so
+// repeated requests can't be used to tell it apart from a real account.
+func decoyKDFParams(identifier string) shared.KDFParams {
+	if crypto.HMAC([]byte(identifier))[0]&1 == 0 {
+		return shared.LegacyKDFParams()
+	}
+
+	return shared.DefaultKDFParams()
+}
+
 // LoginHandler handles a POST request to /login to log the user in.
 func LoginHandler(w http.ResponseWriter, req *http.Request) {
 	var login shared.Login
@@ -25,8 +59,24 @@ func LoginHandler(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	userID, err := ValidateCredentials(login.Identifier, login.LoginKeyHash, login.Code, true)
+	var ssoAdmin bool
+	if config.YeetFileConfig.OIDC.Configured {
+		var err error
+		ssoAdmin, err = checkSSOTicket(login.SSOTicket, login.Identifier)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+	}
+
+	userID, err := ValidateCredentials(
+		login.Identifier,
+		login.LoginKeyHash,
+		login.Code,
+		login.WebAuthn,
+		true)
 	if err != nil {
+		recordFailedLogin(req, login.Identifier, err)
 		if err == Missing2FAErr {
 			log.Printf("Error: Missing TOTP")
 			http.Error(w, "TOTP required", http.StatusForbidden)
@@ -37,6 +87,8 @@ func LoginHandler(w http.ResponseWriter, req *http.Request) {
 			return
 		}
 
+		// Locked accounts get the same response as unknown users, so
+		// that the lockout doesn't reveal which accounts exist
 		http.Error(w, "User not found, or incorrect password", http.StatusNotFound)
 		return
 	}
@@ -47,6 +99,15 @@ func LoginHandler(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	if config.YeetFileConfig.OIDC.Configured {
+		_ = db.DeleteSSOTicket(login.SSOTicket)
+		err = db.SetUserSSOAdmin(userID, ssoAdmin)
+		if err != nil {
+			log.Printf("Error updating user SSO admin status: %v\n", err)
+		}
+	}
+
+	audit.RecordLogin(req, userID)
 	_ = session.SetSession(userID, w, req)
 	_ = json.NewEncoder(w).Encode(shared.LoginResponse{
 		PublicKey:    publicKey,
@@ -81,7 +142,31 @@ func SignupHandler(w http.ResponseWriter, req *http.Request) {
 	var response shared.SignupResponse
 	status := http.StatusOK
 
-	if len(signupData.Identifier) == 0 {
+	if config.YeetFileConfig.OIDC.Configured {
+		// Accounts are created with the email from the identity provider,
+		// without needing to be verified again
+		ticket, err := SignupWithSSO(signupData)
+		if err == SSORequiredErr || err == SSOEmailMismatchErr ||
+			err == db.SSOTicketNotFoundError {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		} else if err != nil {
+			log.Printf("Error creating (SSO) account: %v\n", err)
+			errMsg := "Error creating account"
+			if err == db.UserAlreadyExists {
+				errMsg = "User already exists"
+			}
+			status = http.StatusBadRequest
+			response = shared.SignupResponse{
+				Error: errMsg,
+			}
+		} else {
+			response = shared.SignupResponse{
+				Identifier: signupData.Identifier,
+				SSOTicket:  ticket,
+			}
+		}
+	} else if len(signupData.Identifier) == 0 {
 		// No email, so this is an account ID only signup
 		isCLI := req.UserAgent() == constants.CLIUserAgent
 		id, captcha, err := SignupAccountIDOnly(isCLI)
@@ -175,6 +260,7 @@ func AccountHandler(w http.ResponseWriter, req *http.Request, id string) {
 			UpgradeExp:       user.UpgradeExp,
 			HasPasswordHint:  len(user.PasswordHint) > 0,
 			Has2FA:           len(user.Secret) > 0,
+			HasRecoveryKey:   user.HasRecoveryKey,
 		})
 	}
 }
@@ -241,9 +327,12 @@ func VerifyEmailHandler(w http.ResponseWriter, req *http.Request) {
 			return
 		}
 
+		audit.Record(req, userID, constants.EventEmailChanged, "")
 		err = session.InvalidateOtherSessions(w, req)
 		if err != nil {
 			log.Printf("Error invalidating user's other sessions")
+		} else {
+			audit.Record(req, userID, constants.EventSessionsRevoked, "email changed")
 		}
 	}
 
@@ -261,12 +350,23 @@ func VerifyAccountHandler(w http.ResponseWriter, req *http.Request) {
 		log.Printf("Unable to parse VerifyAccount request: %v\n", err)
 		http.Error(w, "Unable to parse request", http.StatusBadRequest)
 		return
-	} else if utils.IsStructMissingAnyField(verify) {
+	} else if utils.IsAnyStringMissing(verify.ID, verify.Code) ||
+		utils.IsAnyByteSliceMissing(
+			verify.LoginKeyHash,
+			verify.PublicKey,
+			verify.ProtectedPrivateKey,
+			verify.ProtectedVaultFolderKey) {
 		log.Println("Missing required fields for verification")
 		http.Error(w, "Unable to parse request", http.StatusBadRequest)
 		return
 	}
 
+	kdf, err := shared.ValidateKDFParams(verify.KDF)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// Verify user verification code
 	_, err = db.VerifyUser(verify.ID, verify.Code)
 	if err != nil {
@@ -282,6 +382,15 @@ func VerifyAccountHandler(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	recoveryHash, err := generateRecoveryHash(
+		verify.RecoveryKeyHash,
+		verify.RecoveryProtectedKey)
+	if err != nil {
+		log.Printf("Error generating bcrypt recovery hash: %v\n", err)
+		http.Error(w, "Invalid recovery key", http.StatusBadRequest)
+		return
+	}
+
 	_, err = createNewUser(db.VerifiedAccountValues{
 		AccountID:               verify.ID,
 		Email:                   "",
@@ -289,6 +398,9 @@ func VerifyAccountHandler(w http.ResponseWriter, req *http.Request) {
 		ProtectedPrivateKey:     verify.ProtectedPrivateKey,
 		PublicKey:               verify.PublicKey,
 		ProtectedVaultFolderKey: verify.ProtectedVaultFolderKey,
+		RecoveryHash:            recoveryHash,
+		RecoveryProtectedKey:    verify.RecoveryProtectedKey,
+		KDF:                     kdf,
 	})
 
 	if err != nil {
@@ -399,7 +511,17 @@ func PubKeyHandler(w http.ResponseWriter, req *http.Request, _ string) {
 		return
 	}
 
-	jsonData, _ := json.Marshal(shared.PubKeyResponse{PublicKey: pubKey})
+	keyVersion, err := db.GetUserKeyVersion(userID)
+	if err != nil {
+		log.Printf("Error fetching key version: %v\n", err)
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	jsonData, _ := json.Marshal(shared.PubKeyResponse{
+		PublicKey:  pubKey,
+		KeyVersion: keyVersion,
+	})
 	w.Header().Set("Content-Type", "application/json")
 	_, _ = w.Write(jsonData)
 }
@@ -422,10 +544,68 @@ func ProtectedKeyHandler(w http.ResponseWriter, _ *http.Request, id string) {
 	_, _ = w.Write(jsonData)
 }
 
+// KeyRotationHandler returns every key encrypted with the user's public key
+// (GET), or replaces the user's key pair and all of those keys (PUT). The user's
+// other sessions are signed out after rotating, since they would still be using
+// the previous key pair.
+func KeyRotationHandler(w http.ResponseWriter, req *http.Request, id string) {
+	if req.Method == http.MethodGet {
+		response, err := db.GetWrappedKeys(id)
+		if err != nil {
+			log.Printf("Error fetching wrapped keys: %v\n", err)
+			http.Error(w, "Error fetching keys", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	var rotation shared.KeyRotation
+	err := utils.LimitedOrgKeysJSONReader(w, req.Body).Decode(&rotation)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	} else if len(rotation.PublicKey) == 0 || len(rotation.ProtectedKey) == 0 {
+		http.Error(w, "Missing new key pair", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := ValidateCredentials(id, rotation.LoginKeyHash, "", nil, false)
+	if err != nil || id != userID {
+		http.Error(w, "Incorrect password", http.StatusUnauthorized)
+		return
+	}
+
+	err = db.RotateUserKeys(id, rotation)
+	if err == db.KeyVersionMismatchError || err == db.KeyRotationMismatchError {
+		http.Error(w, "Keys have changed since the rotation started, "+
+			"please try again", http.StatusConflict)
+		return
+	} else if err != nil {
+		log.Printf("Error rotating user keys: %v\n", err)
+		http.Error(w, "Error rotating keys", http.StatusInternalServerError)
+		return
+	}
+
+	err = session.InvalidateOtherSessions(w, req)
+	if err != nil {
+		log.Printf("Error invalidating user's other sessions: %v\n", err)
+	} else {
+		audit.Record(req, id, constants.EventSessionsRevoked, "keys rotated")
+	}
+}
+
 // ChangeEmailHandler validates the user's old login information, and uses the
 // ChangeEmail request struct to send a verification email to their new email
 // in preparation for updating their login key hash, encrypted protected key, etc
 func ChangeEmailHandler(w http.ResponseWriter, req *http.Request, id string) {
+	if config.YeetFileConfig.OIDC.Configured {
+		http.Error(w, "Email is managed by your identity provider", http.StatusForbidden)
+		return
+	}
+
 	var fn session.HandlerFunc
 	switch req.Method {
 	case http.MethodPost:
@@ -506,12 +686,18 @@ func finishEmailChangeHandler(w http.ResponseWriter, req *http.Request, id strin
 		return
 	}
 
-	userID, err := ValidateCredentials(id, changeEmail.OldLoginKeyHash, "", false)
+	userID, err := ValidateCredentials(id, changeEmail.OldLoginKeyHash, "", nil, false)
 	if err != nil || id != userID {
 		http.Error(w, "Incorrect password", http.StatusUnauthorized)
 		return
 	}
 
+	kdf, err := shared.ValidateKDFParams(changeEmail.KDF)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	bcryptHash, err := bcrypt.GenerateFromPassword(changeEmail.NewLoginKeyHash, 8)
 	if err != nil {
 		log.Printf("Error generating bcrypt hash: %v\n", err)
@@ -522,7 +708,8 @@ func finishEmailChangeHandler(w http.ResponseWriter, req *http.Request, id strin
 	code, err := db.NewVerification(shared.Signup{
 		Identifier:          changeEmail.NewEmail,
 		ProtectedPrivateKey: changeEmail.ProtectedKey,
-	}, bcryptHash, userID)
+		KDF:                 kdf,
+	}, bcryptHash, nil, userID)
 	if err != nil {
 		log.Printf("Error creating email verification entry: %v\n", err)
 		http.Error(w, "Server error", http.StatusInternalServerError)
@@ -549,12 +736,33 @@ func ChangePasswordHandler(w http.ResponseWriter, req *http.Request, id string)
 		return
 	}
 
-	userID, err := ValidateCredentials(id, changePassword.OldLoginKeyHash, "", false)
+	userID, err := ValidateCredentials(id, changePassword.OldLoginKeyHash, "", nil, false)
 	if err != nil || id != userID {
 		http.Error(w, "Incorrect password", http.StatusUnauthorized)
 		return
 	}
 
+	kdf, err := shared.ValidateKDFParams(changePassword.KDF)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	event := constants.EventPasswordChanged
+	if changePassword.KDFUpgrade {
+		currentKDF, err := db.GetUserKDFParams(id)
+		if err != nil {
+			log.Printf("Error fetching user kdf params: %v\n", err)
+			http.Error(w, "Server error", http.StatusInternalServerError)
+			return
+		} else if !isKDFUpgrade(currentKDF, kdf) {
+			http.Error(w, "Key derivation params must be stronger than the current params", http.StatusBadRequest)
+			return
+		}
+
+		event = constants.EventKDFUpgraded
+	}
+
 	bcryptHash, err := bcrypt.GenerateFromPassword(
 		changePassword.NewLoginKeyHash, 8)
 	if err != nil {
@@ -563,12 +771,24 @@ func ChangePasswordHandler(w http.ResponseWriter, req *http.Request, id string)
 		return
 	}
 
-	err = db.UpdateUserLogin(id, bcryptHash, changePassword.ProtectedKey)
+	err = db.UpdateUserLogin(id, bcryptHash, changePassword.ProtectedKey, kdf)
 	if err != nil {
 		log.Printf("Error updating user login credentials: %v\n", err)
 		http.Error(w, "Server error", http.StatusInternalServerError)
 		return
 	}
+
+	audit.Record(req, id, event, "")
+}
+
+// isKDFUpgrade checks that the new key derivation params are stronger than the
+// current params. Since the server can't tell whether the password itself was
+// changed, this limits the upgrade event to cases where the params actually
+// changed, rather than any password change.
+func isKDFUpgrade(current, upgraded shared.KDFParams) bool {
+	return upgraded != current &&
+		upgraded.Iterations >= current.Iterations &&
+		upgraded.Memory >= current.Memory
 }
 
 // ChangeHintHandler handles a plaintext hint sent to the server, which is
@@ -605,6 +825,7 @@ func ChangeHintHandler(w http.ResponseWriter, req *http.Request, id string) {
 		return
 	}
 
+	audit.Record(req, id, constants.EventHintChanged, "")
 	w.WriteHeader(http.StatusOK)
 }
 
@@ -636,6 +857,8 @@ func TwoFactorHandler(w http.ResponseWriter, req *http.Request, userID string) {
 			return
 		}
 
+		audit.Record(req, userID, constants.Event2FAEnabled, "authenticator app")
+
 		err = json.NewEncoder(w).Encode(response)
 		if err != nil {
 			http.Error(w, "Error sending response", http.StatusInternalServerError)
@@ -652,6 +875,8 @@ func TwoFactorHandler(w http.ResponseWriter, req *http.Request, userID string) {
 			http.Error(w, "Invalid TOTP code", http.StatusUnauthorized)
 			return
 		}
+
+		audit.Record(req, userID, constants.Event2FADisabled, "authenticator app")
 	}
 }
 
@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"errors"
+	"log"
+	"time"
+	"yeetfile/backend/config"
+	"yeetfile/backend/db"
+	"yeetfile/backend/mail"
+)
+
+const (
+	passwordAttempt  = "password"
+	twoFactorAttempt = "2fa"
+)
+
+var AccountLockedErr = errors.New("too many failed attempts, please try again later")
+
+// lockoutDuration returns how long an account should be locked after the
+// provided number of consecutive failures. The first lockout happens after
+// config.LockoutAttempts failures, and the duration doubles with each failure
+// after that, up to config.LockoutMaxSeconds.
+func lockoutDuration(failures int) time.Duration {
+	attempts := config.YeetFileConfig.LockoutAttempts
+	if attempts <= 0 || failures < attempts {
+		return 0
+	}
+
+	maxDuration := time.Duration(config.YeetFileConfig.LockoutMaxSeconds) * time.Second
+	duration := time.Duration(config.YeetFileConfig.LockoutSeconds) * time.Second
+	for i := attempts; i < failures && duration < maxDuration; i++ {
+		duration *= 2
+	}
+
+	return min(duration, maxDuration)
+}
+
+// checkLockout returns AccountLockedErr if the user is locked out of the
+// provided kind of attempt
+func checkLockout(userID, kind string) error {
+	if config.YeetFileConfig.LockoutAttempts <= 0 {
+		return nil
+	}
+
+	lockedUntil, err := db.GetLockedUntil(userID, kind)
+	if err != nil {
+		return err
+	} else if time.Now().UTC().Before(lockedUntil) {
+		return AccountLockedErr
+	}
+
+	return nil
+}
+
+// addFailedAttempt records a failed attempt for the user, and locks the user
+// out of that kind of attempt once they've failed too many times. The user is
+// emailed the first time they're locked out.
+func addFailedAttempt(userID, kind string) {
+	if config.YeetFileConfig.LockoutAttempts <= 0 {
+		return
+	}
+
+	failures, err := db.AddFailedAttempt(userID, kind)
+	if err != nil {
+		log.Printf("Error recording failed attempt: %v\n", err)
+		return
+	}
+
+	duration := lockoutDuration(failures)
+	if duration == 0 {
+		return
+	}
+
+	lockedUntil := time.Now().UTC().Add(duration)
+	err = db.SetLockedUntil(userID, kind, lockedUntil)
+	if err != nil {
+		log.Printf("Error locking user: %v\n", err)
+		return
+	}
+
+	if failures == config.YeetFileConfig.LockoutAttempts &&
+		config.YeetFileConfig.Email.Configured {
+		sendLockoutEmail(userID, kind, lockedUntil)
+	}
+}
+
+// resetFailedAttempts clears the user's failed attempts after a successful
+// attempt
+func resetFailedAttempts(userID, kind string) {
+	if config.YeetFileConfig.LockoutAttempts <= 0 {
+		return
+	}
+
+	err := db.ResetFailedAttempts(userID, kind)
+	if err != nil {
+		log.Printf("Error resetting failed attempts: %v\n", err)
+	}
+}
+
+func sendLockoutEmail(userID, kind string, lockedUntil time.Time) {
+	email, err := db.GetUserEmailByID(userID)
+	if err != nil || len(email) == 0 {
+		return
+	}
+
+	attempt := "login"
+	if kind == twoFactorAttempt {
+		attempt = "2FA"
+	}
+
+	err = mail.SendAccountLockedEmail(email, attempt, lockedUntil)
+	if err != nil {
+		log.Printf("Error sending account locked email: %v\n", err)
+	}
+}
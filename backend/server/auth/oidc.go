@@ -0,0 +1,200 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"yeetfile/backend/config"
+	"yeetfile/backend/db"
+	"yeetfile/backend/oidc"
+	"yeetfile/shared"
+	"yeetfile/shared/endpoints"
+)
+
+const (
+	oidcStateLength    = 32
+	oidcNonceLength    = 32
+	oidcVerifierLength = 64
+)
+
+var (
+	SSORequiredErr      = errors.New("this instance requires logging in with SSO")
+	SSOEmailMismatchErr = errors.New("email doesn't match the SSO login")
+)
+
+var (
+	oidcProvider   *oidc.Provider
+	oidcProviderMu sync.Mutex
+)
+
+// getOIDCProvider returns the configured identity provider, fetching its
+// discovery document the first time it's needed
+func getOIDCProvider() (*oidc.Provider, error) {
+	oidcProviderMu.Lock()
+	defer oidcProviderMu.Unlock()
+
+	if oidcProvider != nil {
+		return oidcProvider, nil
+	}
+
+	provider, err := oidc.Discover(
+		config.YeetFileConfig.OIDC.Issuer,
+		config.YeetFileConfig.OIDC.ClientID,
+		config.YeetFileConfig.OIDC.ClientSecret,
+		oidcRedirectURL(),
+		config.YeetFileConfig.OIDC.GroupsClaim)
+	if err != nil {
+		return nil, err
+	}
+
+	provider.AllowMissingVerified = config.YeetFileConfig.OIDC.AllowMissingVerified
+	oidcProvider = provider
+	return oidcProvider, nil
+}
+
+// oidcRedirectURL returns the URL that the identity provider sends users back
+// to after they log in
+func oidcRedirectURL() string {
+	domain := strings.TrimSuffix(config.YeetFileConfig.Domain, "/")
+	if !strings.Contains(domain, "://") {
+		domain = "https://" + domain
+	}
+
+	return domain + string(endpoints.OIDCCallback)
+}
+
+// OIDCLoginHandler redirects the user to the identity provider to log in. The
+// "cli" query param can be set to show the user a code to paste into the CLI
+// once they've logged in, instead of redirecting them back to the web app.
+func OIDCLoginHandler(w http.ResponseWriter, req *http.Request) {
+	if !config.YeetFileConfig.OIDC.Configured {
+		http.Error(w, "SSO is not configured for this instance", http.StatusNotFound)
+		return
+	}
+
+	provider, err := getOIDCProvider()
+	if err != nil {
+		log.Printf("Error fetching OIDC provider configuration: %v\n", err)
+		http.Error(w, "Unable to reach identity provider", http.StatusBadGateway)
+		return
+	}
+
+	state := shared.GenRandomString(oidcStateLength)
+	nonce := shared.GenRandomString(oidcNonceLength)
+	verifier := shared.GenRandomString(oidcVerifierLength)
+	isCLI := len(req.URL.Query().Get("cli")) > 0
+
+	err = db.NewOIDCState(state, nonce, verifier, isCLI)
+	if err != nil {
+		log.Printf("Error storing OIDC state: %v\n", err)
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(
+		w,
+		req,
+		provider.AuthCodeURL(state, nonce, verifier),
+		http.StatusTemporaryRedirect)
+}
+
+// OIDCCallbackHandler handles the user being redirected back from the identity
+// provider. The user's ID token is verified and checked against the allowed
+// domains and groups, and they're given a ticket that authorizes them to log
+// in or sign up. The ticket doesn't replace the user's password, which is
+// still needed to decrypt their keys.
+func OIDCCallbackHandler(w http.ResponseWriter, req *http.Request) {
+	if !config.YeetFileConfig.OIDC.Configured {
+		http.Error(w, "SSO is not configured for this instance", http.StatusNotFound)
+		return
+	}
+
+	query := req.URL.Query()
+	if idpErr := query.Get("error"); len(idpErr) > 0 {
+		log.Printf("Identity provider returned an error: %s\n", idpErr)
+		http.Error(w, "SSO login was cancelled or denied", http.StatusUnauthorized)
+		return
+	}
+
+	nonce, verifier, isCLI, err := db.ConsumeOIDCState(query.Get("state"))
+	if err != nil {
+		http.Error(w, "SSO login expired, please try again", http.StatusBadRequest)
+		return
+	}
+
+	provider, err := getOIDCProvider()
+	if err != nil {
+		log.Printf("Error fetching OIDC provider configuration: %v\n", err)
+		http.Error(w, "Unable to reach identity provider", http.StatusBadGateway)
+		return
+	}
+
+	claims, err := provider.Exchange(query.Get("code"), verifier, nonce)
+	if err != nil {
+		log.Printf("Error completing OIDC login: %v\n", err)
+		http.Error(w, "Unable to verify SSO login", http.StatusUnauthorized)
+		return
+	}
+
+	oidcConfig := config.YeetFileConfig.OIDC
+	if len(oidcConfig.AllowedDomains) > 0 && !claims.InDomain(oidcConfig.AllowedDomains) {
+		http.Error(w, "Your email domain isn't allowed on this instance", http.StatusForbidden)
+		return
+	} else if len(oidcConfig.AllowedGroups) > 0 && !claims.InAnyGroup(oidcConfig.AllowedGroups) {
+		http.Error(w, "You aren't a member of a group allowed on this instance", http.StatusForbidden)
+		return
+	}
+
+	email := claims.Email
+	ticket, err := db.NewSSOTicket(email, claims.InAnyGroup(oidcConfig.AdminGroups))
+	if err != nil {
+		log.Printf("Error creating SSO ticket: %v\n", err)
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	if isCLI {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = fmt.Fprintf(w, "Logged in as %s\n\n"+
+			"Paste this SSO code into the YeetFile CLI to continue:\n\n"+
+			"%s\n", email, ticket)
+		return
+	}
+
+	// Existing users are sent to log in, otherwise they're sent to create
+	// an account with the email from the identity provider
+	page := endpoints.HTMLSignup
+	if _, err = db.GetUserIDByEmail(email); err == nil {
+		page = endpoints.HTMLLogin
+	}
+
+	params := url.Values{"sso": {ticket}, "email": {email}}
+	http.Redirect(
+		w,
+		req,
+		string(page)+"?"+params.Encode(),
+		http.StatusTemporaryRedirect)
+}
+
+// checkSSOTicket ensures that the user logging in or signing up was authorized
+// by the identity provider, and returns whether they should be an admin. The
+// ticket isn't removed, so that it can be reused if the user needs to retry
+// (i.e. after entering an incorrect password or 2FA code).
+func checkSSOTicket(ticket, identifier string) (bool, error) {
+	if len(ticket) == 0 {
+		return false, SSORequiredErr
+	}
+
+	email, isAdmin, err := db.GetSSOTicket(ticket)
+	if err != nil {
+		return false, err
+	} else if !strings.EqualFold(email, identifier) {
+		return false, SSOEmailMismatchErr
+	}
+
+	return isAdmin, nil
+}
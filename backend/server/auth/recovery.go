@@ -0,0 +1,232 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"golang.org/x/crypto/bcrypt"
+	"log"
+	"net/http"
+	"strings"
+	"yeetfile/backend/db"
+	"yeetfile/backend/server/audit"
+	"yeetfile/backend/utils"
+	"yeetfile/shared"
+	"yeetfile/shared/constants"
+)
+
+var (
+	MissingRecoveryKeyErr = errors.New("user doesn't have a recovery key")
+	EmailIdentifierErr    = errors.New("accounts with an email must use their email")
+)
+
+// RecoveryKeyHandler handles setting (PUT) or removing (DELETE) the recovery
+// key for the current user. Both require the user's login key hash, and
+// setting a recovery key replaces any existing recovery key.
+func RecoveryKeyHandler(w http.ResponseWriter, req *http.Request, id string) {
+	if req.Method == http.MethodDelete {
+		var removeRecoveryKey shared.RemoveRecoveryKey
+		err := utils.LimitedJSONReader(w, req.Body).Decode(&removeRecoveryKey)
+		if err != nil || utils.IsStructMissingAnyField(removeRecoveryKey) {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		userID, err := ValidateCredentials(id, removeRecoveryKey.LoginKeyHash, "", nil, false)
+		if err != nil || id != userID {
+			http.Error(w, "Incorrect password", http.StatusUnauthorized)
+			return
+		}
+
+		err = db.RemoveUserRecoveryKey(id)
+		if err != nil {
+			log.Printf("Error removing recovery key: %v\n", err)
+			http.Error(w, "Error removing recovery key", http.StatusInternalServerError)
+			return
+		}
+
+		audit.Record(req, id, constants.EventRecoveryChanged, "removed")
+		return
+	}
+
+	var setRecoveryKey shared.SetRecoveryKey
+	err := utils.LimitedJSONReader(w, req.Body).Decode(&setRecoveryKey)
+	if err != nil || utils.IsStructMissingAnyField(setRecoveryKey) {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := ValidateCredentials(id, setRecoveryKey.LoginKeyHash, "", nil, false)
+	if err != nil || id != userID {
+		http.Error(w, "Incorrect password", http.StatusUnauthorized)
+		return
+	}
+
+	recoveryHash, err := bcrypt.GenerateFromPassword(
+		setRecoveryKey.RecoveryKeyHash, 8)
+	if err != nil {
+		log.Printf("Error generating bcrypt recovery hash: %v\n", err)
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	err = db.SetUserRecoveryKey(id, recoveryHash, setRecoveryKey.ProtectedKey)
+	if err != nil {
+		log.Printf("Error setting recovery key: %v\n", err)
+		http.Error(w, "Error setting recovery key", http.StatusInternalServerError)
+		return
+	}
+
+	audit.Record(req, id, constants.EventRecoveryChanged, "created")
+}
+
+// RecoverAccountHandler handles account recovery using a recovery key. A POST
+// request returns the user's private key encrypted with their recovery key, and
+// a PUT request replaces the user's login key hash and protected key, which
+// requires 2FA if the user has it enabled.
+func RecoverAccountHandler(w http.ResponseWriter, req *http.Request) {
+	if req.Method == http.MethodPost {
+		var recoveryLogin shared.RecoveryKeyLogin
+		err := utils.LimitedJSONReader(w, req.Body).Decode(&recoveryLogin)
+		if err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		_, _, protectedKey, err := validateRecoveryKey(
+			recoveryLogin.Identifier,
+			recoveryLogin.RecoveryKeyHash)
+		if err == EmailIdentifierErr {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		} else if err != nil {
+			http.Error(w, "User not found, or incorrect recovery key", http.StatusNotFound)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(shared.ProtectedKeyResponse{
+			ProtectedKey: protectedKey,
+		})
+		return
+	}
+
+	var recoverAccount shared.RecoverAccount
+	err := utils.LimitedJSONReader(w, req.Body).Decode(&recoverAccount)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	} else if len(recoverAccount.LoginKeyHash) == 0 || len(recoverAccount.ProtectedKey) == 0 {
+		http.Error(w, "Missing new login", http.StatusBadRequest)
+		return
+	}
+
+	kdf, err := shared.ValidateKDFParams(recoverAccount.KDF)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	userID, secret, _, err := validateRecoveryKey(
+		recoverAccount.Identifier,
+		recoverAccount.RecoveryKeyHash)
+	if err == EmailIdentifierErr {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	} else if err != nil {
+		http.Error(w, "User not found, or incorrect recovery key", http.StatusNotFound)
+		return
+	}
+
+	err = validateSecondFactor(userID, secret, recoverAccount.Code, nil)
+	if err == Missing2FAErr {
+		http.Error(w, "TOTP required", http.StatusForbidden)
+		return
+	} else if err == AccountLockedErr {
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+		return
+	} else if err != nil {
+		http.Error(w, "TOTP incorrect", http.StatusForbidden)
+		return
+	}
+
+	bcryptHash, err := bcrypt.GenerateFromPassword(recoverAccount.LoginKeyHash, 8)
+	if err != nil {
+		log.Printf("Error generating bcrypt hash: %v\n", err)
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	err = db.UpdateUserLogin(userID, bcryptHash, recoverAccount.ProtectedKey, kdf)
+	if err != nil {
+		log.Printf("Error updating user login credentials: %v\n", err)
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	// The password hint was for the previous password
+	err = db.UpdatePasswordHint(userID, nil)
+	if err != nil {
+		log.Printf("Error removing pw hint: %v\n", err)
+	}
+
+	audit.Record(req, userID, constants.EventPasswordChanged, "recovery key used")
+
+	// Log out every existing session
+	err = db.SetUserSessionKey(userID, shared.GenRandomString(16))
+	if err != nil {
+		log.Printf("Error resetting user session key: %v\n", err)
+	} else {
+		audit.Record(req, userID, constants.EventSessionsRevoked, "account recovered")
+	}
+}
+
+// validateRecoveryKey checks the recovery key hash against the one stored for
+// the user, and returns the user's ID, encrypted 2FA secret, and private key
+// encrypted with their recovery key. Users with an email need to use it as
+// their identifier, since their user key is derived from it.
+func validateRecoveryKey(
+	identifier string,
+	recoveryKeyHash []byte,
+) (string, []byte, []byte, error) {
+	var userID string
+	var secret []byte
+	var err error
+	if strings.Contains(identifier, "@") {
+		_, secret, err = db.GetUserPasswordHashByEmail(identifier)
+		if err != nil {
+			return "", nil, nil, err
+		}
+
+		userID, err = db.GetUserIDByEmail(identifier)
+		if err != nil {
+			return "", nil, nil, err
+		}
+	} else {
+		_, secret, err = db.GetUserPasswordHashByID(identifier)
+		if err != nil {
+			return "", nil, nil, err
+		}
+
+		email, err := db.GetUserEmailByID(identifier)
+		if err != nil {
+			return "", nil, nil, err
+		} else if len(email) > 0 {
+			return "", nil, nil, EmailIdentifierErr
+		}
+
+		userID = identifier
+	}
+
+	recoveryHash, protectedKey, err := db.GetUserRecoveryKey(userID)
+	if err != nil {
+		return "", nil, nil, err
+	} else if len(recoveryHash) == 0 || len(protectedKey) == 0 {
+		return "", nil, nil, MissingRecoveryKeyErr
+	}
+
+	err = bcrypt.CompareHashAndPassword(recoveryHash, recoveryKeyHash)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	return userID, secret, protectedKey, nil
+}
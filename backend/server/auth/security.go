@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"encoding/json"
+	"golang.org/x/crypto/bcrypt"
+	"log"
+	"net/http"
+	"strings"
+	"yeetfile/backend/db"
+	"yeetfile/backend/server/audit"
+	"yeetfile/backend/utils"
+	"yeetfile/shared"
+	"yeetfile/shared/constants"
+)
+
+// recordFailedLogin adds a failed login attempt to the security log of the
+// account matching the identifier, if the account exists. Attempts that are
+// only missing a 2FA code aren't recorded, since clients send those before
+// prompting the user for their code.
+func recordFailedLogin(req *http.Request, identifier string, err error) {
+	var details string
+	switch err {
+	case bcrypt.ErrMismatchedHashAndPassword:
+		details = "incorrect password"
+	case Failed2FAErr:
+		details = "incorrect 2FA code or security key"
+	case AccountLockedErr:
+		details = "account locked"
+	default:
+		return
+	}
+
+	userID := identifier
+	if strings.Contains(identifier, "@") {
+		userID, err = db.GetUserIDByEmail(identifier)
+		if err != nil {
+			return
+		}
+	} else if !db.UserIDExists(identifier) {
+		return
+	}
+
+	audit.Record(req, userID, constants.EventLoginFailed, details)
+}
+
+// SecurityLogHandler returns the user's recent security events (GET), or
+// updates the user's security settings (PUT)
+func SecurityLogHandler(w http.ResponseWriter, req *http.Request, userID string) {
+	switch req.Method {
+	case http.MethodGet:
+		events, err := db.GetSecurityEvents(userID)
+		if err != nil {
+			log.Printf("Error fetching security events: %v\n", err)
+			http.Error(w, "Error fetching security log", http.StatusInternalServerError)
+			return
+		}
+
+		loginAlerts, err := db.GetUserLoginAlerts(userID)
+		if err != nil {
+			log.Printf("Error fetching login alert setting: %v\n", err)
+			http.Error(w, "Error fetching security log", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(shared.SecurityLog{
+			Events:      events,
+			LoginAlerts: loginAlerts,
+		})
+	case http.MethodPut:
+		var settings shared.SecuritySettings
+		err := utils.LimitedJSONReader(w, req.Body).Decode(&settings)
+		if err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		err = db.SetUserLoginAlerts(userID, settings.LoginAlerts)
+		if err != nil {
+			log.Printf("Error updating login alert setting: %v\n", err)
+			http.Error(w, "Error updating security settings", http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
@@ -3,6 +3,7 @@ package auth
 import (
 	"errors"
 	"golang.org/x/crypto/bcrypt"
+	"yeetfile/backend/crypto"
 	"yeetfile/backend/db"
 	"yeetfile/backend/mail"
 	"yeetfile/backend/utils"
@@ -15,6 +16,72 @@ var MissingField = errors.New("missing required signup fields")
 // of a new user. A hash is generated from the provided password and entered
 // into the "users" db table.
 func SignupWithEmail(signup shared.Signup) error {
+	hash, recoveryHash, err := hashSignupCredentials(&signup)
+	if err != nil {
+		return err
+	}
+
+	code, err := db.NewVerification(signup, hash, recoveryHash, "")
+	if err != nil {
+		return err
+	}
+
+	err = mail.SendVerificationEmail(code, signup.Identifier)
+	return err
+}
+
+// SignupWithSSO creates a new user with the email that was verified by the
+// identity provider, so no verification email is needed. The user's keys are
+// still generated and protected client-side with their password. The ticket
+// used to sign up is removed, and a new ticket is returned for the user's
+// first login.
+func SignupWithSSO(signup shared.Signup) (string, error) {
+	isAdmin, err := checkSSOTicket(signup.SSOTicket, signup.Identifier)
+	if err != nil {
+		return "", err
+	}
+
+	hash, recoveryHash, err := hashSignupCredentials(&signup)
+	if err != nil {
+		return "", err
+	}
+
+	var pwHint []byte
+	if len(signup.PasswordHint) > 0 {
+		pwHint, err = crypto.Encrypt(signup.PasswordHint)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	_, err = createNewUser(db.VerifiedAccountValues{
+		Email:                   signup.Identifier,
+		PasswordHash:            hash,
+		ProtectedPrivateKey:     signup.ProtectedPrivateKey,
+		PublicKey:               signup.PublicKey,
+		ProtectedVaultFolderKey: signup.ProtectedVaultFolderKey,
+		PasswordHint:            pwHint,
+		RecoveryHash:            recoveryHash,
+		RecoveryProtectedKey:    signup.RecoveryProtectedKey,
+		KDF:                     signup.KDF,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	err = db.DeleteSSOTicket(signup.SSOTicket)
+	if err != nil {
+		return "", err
+	}
+
+	return db.NewSSOTicket(signup.Identifier, isAdmin)
+}
+
+// hashSignupCredentials ensures that an email signup contains all required
+// fields, and returns the bcrypt hashes of the user's login key hash and
+// recovery key hash. The signup's KDF params are replaced with the validated
+// params.
+func hashSignupCredentials(signup *shared.Signup) ([]byte, []byte, error) {
 	// When signing up with email, no part of the signup struct can be empty
 	isMissingByteSlices := utils.IsAnyByteSliceMissing(
 		signup.ProtectedPrivateKey,
@@ -23,21 +90,29 @@ func SignupWithEmail(signup shared.Signup) error {
 		signup.ProtectedVaultFolderKey)
 	isMissingStrings := utils.IsAnyStringMissing(signup.Identifier)
 	if isMissingStrings || isMissingByteSlices {
-		return MissingField
+		return nil, nil, MissingField
+	}
+
+	kdf, err := shared.ValidateKDFParams(signup.KDF)
+	if err != nil {
+		return nil, nil, err
 	}
 
+	signup.KDF = kdf
+
 	hash, err := bcrypt.GenerateFromPassword(signup.LoginKeyHash, 8)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
-	code, err := db.NewVerification(signup, hash, "")
+	recoveryHash, err := generateRecoveryHash(
+		signup.RecoveryKeyHash,
+		signup.RecoveryProtectedKey)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
-	err = mail.SendVerificationEmail(code, signup.Identifier)
-	return err
+	return hash, recoveryHash, nil
 }
 
 // SignupAccountIDOnly creates a new user with only an account ID as the user's
@@ -46,7 +121,7 @@ func SignupWithEmail(signup shared.Signup) error {
 func SignupAccountIDOnly(isCLI bool) (string, string, error) {
 	id := db.CreateUniqueUserID()
 
-	code, err := db.NewVerification(shared.Signup{Identifier: id}, nil, "")
+	code, err := db.NewVerification(shared.Signup{Identifier: id}, nil, nil, "")
 	if err != nil {
 		return "", "", err
 	}
@@ -54,3 +129,15 @@ func SignupAccountIDOnly(isCLI bool) (string, string, error) {
 	captchaBase64, err := GenerateCaptchaImage(code, isCLI)
 	return id, captchaBase64, err
 }
+
+// generateRecoveryHash returns the bcrypt hash of a recovery key hash provided
+// at signup, or nil if the user didn't create a recovery key
+func generateRecoveryHash(recoveryKeyHash, protectedKey []byte) ([]byte, error) {
+	if len(recoveryKeyHash) == 0 && len(protectedKey) == 0 {
+		return nil, nil
+	} else if len(recoveryKeyHash) == 0 || len(protectedKey) == 0 {
+		return nil, MissingField
+	}
+
+	return bcrypt.GenerateFromPassword(recoveryKeyHash, 8)
+}
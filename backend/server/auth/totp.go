@@ -65,11 +65,37 @@ func generateUserTotp(userID string) (shared.NewTOTP, error) {
 
 // validateTOTP checks the provided code against the stored user secret, or
 // against one of the recovery code hashes if the provided code length matches
-// constants.RecoveryCodeLen.
+// constants.RecoveryCodeLen. Users with too many recent incorrect codes are
+// temporarily locked out of 2FA, and return AccountLockedErr.
 func validateTOTP(encSecret []byte, code, userID string) error {
 	if len(code) == 0 {
 		return Missing2FAErr
-	} else if len(code) == 6 {
+	}
+
+	err := checkLockout(userID, twoFactorAttempt)
+	if err != nil {
+		return err
+	}
+
+	err = checkTOTPCode(encSecret, code, userID)
+	if err == Failed2FAErr {
+		addFailedAttempt(userID, twoFactorAttempt)
+	} else if err == nil {
+		resetFailedAttempts(userID, twoFactorAttempt)
+	}
+
+	return err
+}
+
+// checkTOTPCode validates a 2FA or recovery code, removing the recovery code
+// if one was used
+func checkTOTPCode(encSecret []byte, code, userID string) error {
+	if len(code) == 6 {
+		if len(encSecret) == 0 {
+			// The user only has security keys and recovery codes
+			return Failed2FAErr
+		}
+
 		decSecret, err := crypto.Decrypt(encSecret)
 		if err != nil {
 			return err
@@ -127,6 +153,14 @@ func removeTOTP(userID, code string) error {
 		return err
 	}
 
+	// Recovery codes are kept for users who still have security keys
+	count, err := db.CountWebAuthnCredentials(userID)
+	if err != nil {
+		return err
+	} else if count > 0 {
+		return db.SetUserSecret(userID, []byte{})
+	}
+
 	err = db.RemoveUser2FA(userID)
 	return err
 }
@@ -137,23 +171,6 @@ func setTOTP(userID string, set shared.SetTOTP) (shared.SetTOTPResponse, error)
 		return shared.SetTOTPResponse{}, IncorrectCodeErr
 	}
 
-	var recoveryCodes [6]string
-	for i := range recoveryCodes {
-		code := shared.GenRandomString(constants.RecoveryCodeLen)
-		recoveryCodes[i] = code
-	}
-
-	var hashedCodes [6]string
-	for i := range hashedCodes {
-		byteCode := []byte(recoveryCodes[i])
-		hash, err := bcrypt.GenerateFromPassword(byteCode, 8)
-		if err != nil {
-			return shared.SetTOTPResponse{}, err
-		}
-
-		hashedCodes[i] = base64.StdEncoding.EncodeToString(hash)
-	}
-
 	encSecret, err := crypto.Encrypt(set.Secret)
 	if err != nil {
 		return shared.SetTOTPResponse{}, err
@@ -164,7 +181,7 @@ func setTOTP(userID string, set shared.SetTOTP) (shared.SetTOTPResponse, error)
 		return shared.SetTOTPResponse{}, err
 	}
 
-	err = db.SetUserRecoveryCodeHashes(userID, hashedCodes[:])
+	recoveryCodes, err := setRecoveryCodes(userID)
 	if err != nil {
 		recoveryErr := db.RemoveUser2FA(userID)
 		if recoveryErr != nil {
@@ -177,3 +194,28 @@ func setTOTP(userID string, set shared.SetTOTP) (shared.SetTOTPResponse, error)
 
 	return shared.SetTOTPResponse{RecoveryCodes: recoveryCodes}, nil
 }
+
+// setRecoveryCodes generates a new set of one-time recovery codes for the
+// user, replacing any existing codes. Only the bcrypt hashes of the codes are
+// stored.
+func setRecoveryCodes(userID string) ([6]string, error) {
+	var recoveryCodes [6]string
+	for i := range recoveryCodes {
+		code := shared.GenRandomString(constants.RecoveryCodeLen)
+		recoveryCodes[i] = code
+	}
+
+	var hashedCodes [6]string
+	for i := range hashedCodes {
+		byteCode := []byte(recoveryCodes[i])
+		hash, err := bcrypt.GenerateFromPassword(byteCode, 8)
+		if err != nil {
+			return recoveryCodes, err
+		}
+
+		hashedCodes[i] = base64.StdEncoding.EncodeToString(hash)
+	}
+
+	err := db.SetUserRecoveryCodeHashes(userID, hashedCodes[:])
+	return recoveryCodes, err
+}
@@ -0,0 +1,369 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"yeetfile/backend/config"
+	"yeetfile/backend/crypto"
+	"yeetfile/backend/db"
+	"yeetfile/backend/server/audit"
+	"yeetfile/backend/utils"
+	"yeetfile/shared"
+	"yeetfile/shared/constants"
+)
+
+const (
+	webAuthnRegister = "register"
+	webAuthnLogin    = "login"
+
+	webAuthnChallengeSize = 32
+	webAuthnRPName        = "YeetFile"
+)
+
+var TooManyWebAuthnKeysErr = errors.New("maximum number of security keys reached")
+
+// relyingParty returns the WebAuthn relying party for the server's domain. If
+// a domain isn't configured, credentials are scoped to localhost.
+func relyingParty() crypto.RelyingParty {
+	domain := config.YeetFileConfig.Domain
+	if len(domain) == 0 {
+		return crypto.RelyingParty{ID: "localhost"}
+	} else if !strings.Contains(domain, "://") {
+		domain = "https://" + domain
+	}
+
+	domainURL, err := url.Parse(domain)
+	if err != nil {
+		log.Printf("Error parsing domain for webauthn: %v\n", err)
+		return crypto.RelyingParty{ID: "localhost"}
+	}
+
+	return crypto.RelyingParty{
+		ID:     domainURL.Hostname(),
+		Origin: domainURL.Scheme + "://" + domainURL.Host,
+	}
+}
+
+func newWebAuthnChallenge(userID, ceremony string) ([]byte, error) {
+	challenge := make([]byte, webAuthnChallengeSize)
+	if _, err := rand.Read(challenge); err != nil {
+		return nil, err
+	}
+
+	err := db.SetWebAuthnChallenge(userID, ceremony, challenge)
+	return challenge, err
+}
+
+// webAuthnRegistrationOptions generates the options passed to
+// navigator.credentials.create when the user registers a new security key
+func webAuthnRegistrationOptions(userID string) (shared.WebAuthnRegistrationOptions, error) {
+	credentialIDs, err := db.GetWebAuthnCredentialIDs(userID)
+	if err != nil {
+		return shared.WebAuthnRegistrationOptions{}, err
+	} else if len(credentialIDs) >= constants.MaxWebAuthnCredentials {
+		return shared.WebAuthnRegistrationOptions{}, TooManyWebAuthnKeysErr
+	}
+
+	userName, err := db.GetUserPublicName(userID)
+	if err != nil {
+		return shared.WebAuthnRegistrationOptions{}, err
+	}
+
+	challenge, err := newWebAuthnChallenge(userID, webAuthnRegister)
+	if err != nil {
+		return shared.WebAuthnRegistrationOptions{}, err
+	}
+
+	return shared.WebAuthnRegistrationOptions{
+		Challenge:          challenge,
+		RPID:               relyingParty().ID,
+		RPName:             webAuthnRPName,
+		UserID:             []byte(userID),
+		UserName:           userName,
+		Algorithms:         crypto.WebAuthnAlgorithms,
+		ExcludeCredentials: credentialIDs,
+		Timeout:            constants.WebAuthnTimeout * 1000,
+	}, nil
+}
+
+// registerWebAuthn verifies and stores a new security key for the user. If the
+// user doesn't have any other second factor yet, recovery codes are generated
+// and returned in the response.
+func registerWebAuthn(
+	userID string,
+	registration shared.WebAuthnRegistration,
+) (shared.WebAuthnRegistrationResponse, error) {
+	challenge, err := db.ConsumeWebAuthnChallenge(userID, webAuthnRegister)
+	if err != nil {
+		return shared.WebAuthnRegistrationResponse{}, err
+	}
+
+	credential, err := relyingParty().VerifyRegistration(
+		challenge,
+		registration.ClientDataJSON,
+		registration.AttestationObject)
+	if err != nil {
+		return shared.WebAuthnRegistrationResponse{}, err
+	}
+
+	count, err := db.CountWebAuthnCredentials(userID)
+	if err != nil {
+		return shared.WebAuthnRegistrationResponse{}, err
+	} else if count >= constants.MaxWebAuthnCredentials {
+		return shared.WebAuthnRegistrationResponse{}, TooManyWebAuthnKeysErr
+	}
+
+	secret, err := db.GetUserSecret(userID)
+	if err != nil {
+		return shared.WebAuthnRegistrationResponse{}, err
+	}
+
+	id, err := db.NewWebAuthnCredential(userID, registration.Name, credential)
+	if err != nil {
+		return shared.WebAuthnRegistrationResponse{}, err
+	}
+
+	response := shared.WebAuthnRegistrationResponse{ID: id}
+	if count > 0 || len(secret) > 0 {
+		return response, nil
+	}
+
+	recoveryCodes, err := setRecoveryCodes(userID)
+	if err != nil {
+		return shared.WebAuthnRegistrationResponse{}, err
+	}
+
+	response.RecoveryCodes = recoveryCodes[:]
+	return response, nil
+}
+
+// removeWebAuthn removes one of the user's security keys after checking their
+// TOTP or recovery code. If it was the user's only second factor, their
+// recovery codes are removed as well.
+func removeWebAuthn(userID, id, code string) error {
+	secret, err := db.GetUserSecret(userID)
+	if err != nil {
+		return err
+	}
+
+	err = validateTOTP(secret, code, userID)
+	if err == AccountLockedErr {
+		return err
+	} else if err != nil {
+		return Failed2FAErr
+	}
+
+	err = db.DeleteWebAuthnCredential(userID, id)
+	if err != nil {
+		return err
+	}
+
+	count, err := db.CountWebAuthnCredentials(userID)
+	if err != nil || count > 0 {
+		return err
+	}
+
+	if len(secret) > 0 {
+		return nil
+	}
+
+	return db.RemoveUser2FA(userID)
+}
+
+// validateWebAuthn verifies an assertion from one of the user's security keys
+// against the challenge returned by WebAuthnAssertionHandler. Failed assertions
+// count towards the same 2FA lockout as TOTP codes, and return AccountLockedErr
+// once the user is locked out.
+func validateWebAuthn(userID string, assertion shared.WebAuthnAssertion) error {
+	err := checkLockout(userID, twoFactorAttempt)
+	if err != nil {
+		return err
+	}
+
+	err = checkAssertion(userID, assertion)
+	if err == Failed2FAErr {
+		addFailedAttempt(userID, twoFactorAttempt)
+	} else if err == nil {
+		resetFailedAttempts(userID, twoFactorAttempt)
+	}
+
+	return err
+}
+
+// checkAssertion verifies the assertion's signature with the stored credential,
+// and updates the credential's sign count
+func checkAssertion(userID string, assertion shared.WebAuthnAssertion) error {
+	challenge, err := db.ConsumeWebAuthnChallenge(userID, webAuthnLogin)
+	if err == db.WebAuthnChallengeExpiredError {
+		return Failed2FAErr
+	} else if err != nil {
+		return err
+	}
+
+	credential, err := db.GetWebAuthnCredential(userID, assertion.CredentialID)
+	if err == db.WebAuthnCredentialNotFoundError {
+		return Failed2FAErr
+	} else if err != nil {
+		return err
+	}
+
+	signCount, err := relyingParty().VerifyAssertion(
+		credential,
+		challenge,
+		assertion.ClientDataJSON,
+		assertion.AuthenticatorData,
+		assertion.Signature)
+	if err != nil {
+		log.Printf("Error verifying webauthn assertion: %v\n", err)
+		return Failed2FAErr
+	}
+
+	return db.UpdateWebAuthnSignCount(userID, credential.ID, signCount)
+}
+
+// WebAuthnHandler returns the names of the user's registered security keys
+func WebAuthnHandler(w http.ResponseWriter, _ *http.Request, userID string) {
+	credentials, err := db.GetWebAuthnCredentials(userID)
+	if err != nil {
+		log.Printf("Error fetching webauthn credentials: %v\n", err)
+		http.Error(w, "Error fetching security keys", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(credentials)
+}
+
+// WebAuthnRegisterHandler returns the options for registering a new security
+// key (GET), or verifies and stores the new key (POST)
+func WebAuthnRegisterHandler(w http.ResponseWriter, req *http.Request, userID string) {
+	var response any
+	switch req.Method {
+	case http.MethodGet:
+		options, err := webAuthnRegistrationOptions(userID)
+		if err == TooManyWebAuthnKeysErr {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		} else if err != nil {
+			log.Printf("Error generating webauthn options: %v\n", err)
+			http.Error(w, "Error generating security key options", http.StatusInternalServerError)
+			return
+		}
+
+		response = options
+	case http.MethodPost:
+		var registration shared.WebAuthnRegistration
+		err := utils.LimitedJSONReader(w, req.Body).Decode(&registration)
+		if err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		registration.Name = strings.TrimSpace(registration.Name)
+		if len(registration.Name) == 0 ||
+			len(registration.Name) > constants.MaxWebAuthnNameLen {
+			http.Error(w, "Invalid security key name", http.StatusBadRequest)
+			return
+		}
+
+		response, err = registerWebAuthn(userID, registration)
+		if err == db.WebAuthnCredentialExistsError || err == TooManyWebAuthnKeysErr {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		} else if err != nil {
+			log.Printf("Error registering webauthn credential: %v\n", err)
+			http.Error(w, "Failed to register security key", http.StatusBadRequest)
+			return
+		}
+
+		audit.Record(req, userID, constants.Event2FAEnabled,
+			"security key \""+registration.Name+"\"")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// WebAuthnKeyHandler removes one of the user's security keys, which requires
+// the user's TOTP or recovery code
+func WebAuthnKeyHandler(w http.ResponseWriter, req *http.Request, userID string) {
+	segments := strings.Split(req.URL.Path, "/")
+	id := segments[len(segments)-1]
+
+	code := req.URL.Query().Get("code")
+	if len(code) == 0 {
+		http.Error(w, "Missing 2FA or recovery code", http.StatusBadRequest)
+		return
+	}
+
+	err := removeWebAuthn(userID, id, code)
+	if err == AccountLockedErr || err == Failed2FAErr {
+		http.Error(w, "Invalid 2FA or recovery code", http.StatusUnauthorized)
+		return
+	} else if err == db.WebAuthnCredentialNotFoundError {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.Printf("Error removing webauthn credential: %v\n", err)
+		http.Error(w, "Error removing security key", http.StatusInternalServerError)
+		return
+	}
+
+	audit.Record(req, userID, constants.Event2FADisabled, "security key")
+	w.WriteHeader(http.StatusOK)
+}
+
+// WebAuthnAssertionHandler returns the options passed to
+// navigator.credentials.get when logging in with a security key. The user's
+// login key hash is required, so that registered keys aren't revealed to
+// anyone who knows the user's email or account ID.
+func WebAuthnAssertionHandler(w http.ResponseWriter, req *http.Request) {
+	var assertionReq shared.WebAuthnAssertionRequest
+	err := utils.LimitedJSONReader(w, req.Body).Decode(&assertionReq)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := ValidateCredentials(
+		assertionReq.Identifier,
+		assertionReq.LoginKeyHash,
+		"",
+		nil,
+		false)
+	if err != nil {
+		recordFailedLogin(req, assertionReq.Identifier, err)
+		http.Error(w, "User not found, or incorrect password", http.StatusNotFound)
+		return
+	}
+
+	credentialIDs, err := db.GetWebAuthnCredentialIDs(userID)
+	if err != nil {
+		log.Printf("Error fetching webauthn credentials: %v\n", err)
+		http.Error(w, "Error fetching security keys", http.StatusInternalServerError)
+		return
+	} else if len(credentialIDs) == 0 {
+		http.Error(w, "No security keys registered", http.StatusNotFound)
+		return
+	}
+
+	challenge, err := newWebAuthnChallenge(userID, webAuthnLogin)
+	if err != nil {
+		log.Printf("Error generating webauthn challenge: %v\n", err)
+		http.Error(w, "Error generating challenge", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(shared.WebAuthnAssertionOptions{
+		Challenge:        challenge,
+		RPID:             relyingParty().ID,
+		AllowCredentials: credentialIDs,
+		Timeout:          constants.WebAuthnTimeout * 1000,
+	})
+}
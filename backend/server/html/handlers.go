@@ -13,6 +13,7 @@ import (
 	"yeetfile/backend/server/session"
 	"yeetfile/backend/server/upgrades"
 	"yeetfile/shared"
+	"yeetfile/shared/constants"
 	"yeetfile/shared/endpoints"
 )
 
@@ -93,11 +94,15 @@ func SendPageHandler(w http.ResponseWriter, req *http.Request, _ string) {
 		sendAvailable int64
 	)
 
+	// Text over constants.MaxPlaintextLen is uploaded in chunks, which
+	// requires an account
+	maxTextSize := int64(constants.MaxPlaintextLen)
 	showUpgradeLink := false
 	isValidSession := false
 	userID, err := session.GetSessionAndUserID(req)
 	if err == nil && len(userID) > 0 {
 		isValidSession = true
+		maxTextSize = config.YeetFileConfig.MaxTextSendSize
 
 		sendUsed, sendAvailable, err = db.GetUserSendLimits(userID)
 		if err != nil {
@@ -128,6 +133,7 @@ func SendPageHandler(w http.ResponseWriter, req *http.Request, _ string) {
 			SendAvailable:      sendAvailable,
 			ShowUpgradeLink:    showUpgradeLink,
 			AllowInsecureLinks: config.YeetFileConfig.AllowInsecureLinks,
+			MaxTextSize:        maxTextSize,
 		},
 	)
 }
@@ -151,6 +157,23 @@ func DownloadPageHandler(w http.ResponseWriter, req *http.Request) {
 	)
 }
 
+// FileRequestPageHandler returns the HTML page for uploading files using a
+// file request link, which doesn't require an account
+func FileRequestPageHandler(w http.ResponseWriter, req *http.Request) {
+	_ = templates.ServeTemplate(
+		w,
+		templates.FileRequestHTML,
+		templates.Template{Base: templates.BaseTemplate{
+			LoggedIn:   session.IsValidSession(w, req),
+			Title:      "Upload Files",
+			Javascript: []string{"file_request.js"},
+			CSS:        []string{"download.css"},
+			Config:     config.HTMLConfig,
+			Endpoints:  endpoints.HTMLPageEndpoints,
+		}},
+	)
+}
+
 // SignupPageHandler returns the HTML page for signing up for an account
 func SignupPageHandler(w http.ResponseWriter, _ *http.Request) {
 	_ = templates.ServeTemplate(
@@ -167,6 +190,7 @@ func SignupPageHandler(w http.ResponseWriter, _ *http.Request) {
 			},
 			ServerPasswordRequired: config.YeetFileConfig.PasswordHash != nil,
 			EmailConfigured:        config.YeetFileConfig.Email.Configured,
+			SSOEnabled:             config.YeetFileConfig.OIDC.Configured,
 		},
 	)
 }
@@ -204,6 +228,11 @@ func AccountPageHandler(w http.ResponseWriter, req *http.Request, userID string)
 
 	isAdmin := auth.IsInstanceAdmin(userID)
 
+	securityKeys, err := db.GetWebAuthnCredentials(userID)
+	if err != nil {
+		log.Printf("Error fetching user security keys: %v\n", err)
+	}
+
 	_ = templates.ServeTemplate(
 		w,
 		templates.AccountHTML,
@@ -229,6 +258,7 @@ func AccountPageHandler(w http.ResponseWriter, req *http.Request, userID string)
 			StorageUsed:      shared.ReadableFileSize(user.StorageUsed),
 			HasPasswordHint:  hasHint,
 			Has2FA:           user.Secret != nil && len(user.Secret) > 0,
+			SecurityKeys:     securityKeys,
 			ErrorMessage:     errorMsg,
 			SuccessMessage:   successMsg,
 			IsAdmin:          isAdmin,
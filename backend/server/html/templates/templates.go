@@ -15,6 +15,7 @@ const (
 	SendHTML             = "send.html"
 	VaultHTML            = "vault.html"
 	DownloadHTML         = "download.html"
+	FileRequestHTML      = "file_request.html"
 	VerificationHTML     = "verify.html"
 	SignupHTML           = "signup.html"
 	LoginHTML            = "login.html"
@@ -55,6 +56,7 @@ type SignupTemplate struct {
 	Base                   BaseTemplate
 	ServerPasswordRequired bool
 	EmailConfigured        bool
+	SSOEnabled             bool
 }
 
 type LoginTemplate struct {
@@ -68,6 +70,7 @@ type SendTemplate struct {
 	SendAvailable      int64
 	ShowUpgradeLink    bool
 	AllowInsecureLinks bool
+	MaxTextSize        int64
 }
 
 type VaultTemplate struct {
@@ -125,6 +128,7 @@ type AccountTemplate struct {
 	BillingConfigured bool
 	HasPasswordHint   bool
 	Has2FA            bool
+	SecurityKeys      []shared.WebAuthnCredential
 	ErrorMessage      string
 	SuccessMessage    string
 	IsAdmin           bool
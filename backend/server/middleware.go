@@ -5,11 +5,14 @@ import (
 	"golang.org/x/crypto/blake2b"
 	"golang.org/x/time/rate"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 	"yeetfile/backend/config"
+	"yeetfile/backend/db"
 	"yeetfile/backend/server/auth"
 	"yeetfile/backend/server/session"
+	"yeetfile/backend/server/transfer/vault"
 	"yeetfile/backend/utils"
 	"yeetfile/shared/endpoints"
 )
@@ -124,9 +127,15 @@ func AuthMiddleware(next session.HandlerFunc) http.HandlerFunc {
 }
 
 // AdminMiddleware enforces that particular requests are only performed by those
-// marked as "admin" in the database.
+// marked as "admin" in the database. If a client CA is configured, admin
+// requests also require a verified client certificate.
 func AdminMiddleware(next session.HandlerFunc) http.HandlerFunc {
 	handler := func(w http.ResponseWriter, req *http.Request) {
+		if len(config.TLSClientCA) > 0 && !hasVerifiedClientCert(req) {
+			http.Error(w, "Client certificate required", http.StatusUnauthorized)
+			return
+		}
+
 		if session.IsValidSession(w, req) {
 			id, err := session.GetSessionAndUserID(req)
 			if err != nil {
@@ -223,6 +232,62 @@ func AuthLimiterMiddleware(next session.HandlerFunc) http.HandlerFunc {
 	return handler
 }
 
+// FileRequestMiddleware is like AuthMiddleware, but also allows uploading file
+// chunks without a session if the file was created using a file request and
+// the request ID is included in the "request" query param. The request must
+// still be open and the file must still be uploading. The handler is called
+// with the request owner's ID in that case.
+func FileRequestMiddleware(next session.HandlerFunc) http.HandlerFunc {
+	authHandler := AuthMiddleware(next)
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		requestID := req.URL.Query().Get("request")
+		if len(requestID) == 0 {
+			authHandler(w, req)
+			return
+		}
+
+		segments := strings.Split(req.URL.Path, "/")
+		itemID := segments[len(segments)-2]
+		ownerID, err := vault.GetFileRequestUploadOwner(requestID, itemID)
+		if err != nil {
+			http.Error(w, "Invalid file request", http.StatusUnauthorized)
+			return
+		}
+
+		next(w, req, ownerID)
+	}
+
+	return handler
+}
+
+// EmergencyAccessMiddleware allows an emergency contact to view the vault of
+// a user who granted them access, if the grant ID is included in the
+// "emergency" query param. Only GET requests are allowed in that case, and the
+// handler is called with the owner's ID. Requests without the param are passed
+// through unchanged.
+func EmergencyAccessMiddleware(next session.HandlerFunc) session.HandlerFunc {
+	handler := func(w http.ResponseWriter, req *http.Request, userID string) {
+		grantID := req.URL.Query().Get("emergency")
+		if len(grantID) == 0 {
+			next(w, req, userID)
+			return
+		} else if req.Method != http.MethodGet {
+			http.Error(w, "Emergency access is read-only", http.StatusForbidden)
+			return
+		}
+
+		ownerID, _, _, err := db.GetGrantedEmergencyAccess(userID, grantID)
+		if err != nil {
+			http.Error(w, "Emergency access not granted", http.StatusForbidden)
+			return
+		}
+
+		next(w, req, ownerID)
+	}
+
+	return handler
+}
+
 // StripeMiddleware ensures that requests made to Stripe related endpoints are
 // only processed if Stripe has been set up already.
 func StripeMiddleware(next http.HandlerFunc) http.HandlerFunc {
@@ -1,28 +1,129 @@
 package misc
 
 import (
+	"context"
 	"embed"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"golang.org/x/crypto/blake2b"
+	"log"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
+	"yeetfile/backend/cache"
 	"yeetfile/backend/config"
+	"yeetfile/backend/db"
 	"yeetfile/backend/server/session"
 	"yeetfile/backend/static"
+	"yeetfile/backend/storage"
 	"yeetfile/backend/utils"
 	"yeetfile/shared"
 	"yeetfile/shared/constants"
 )
 
+const (
+	healthOK          = "ok"
+	healthUnavailable = "unavailable"
+
+	healthCheckTimeout   = 5 * time.Second
+	storageProbeInterval = 30 * time.Second
+)
+
+var (
+	storageProbeMu   sync.Mutex
+	storageProbeTime time.Time
+	storageProbeErr  error
+)
+
 // UpHandler is used as the health check endpoint for load balancing, docker, etc.
 func UpHandler(w http.ResponseWriter, _ *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	_, _ = w.Write([]byte("OK"))
 }
 
+// LiveHandler reports whether the server process is able to handle requests.
+// Unlike ReadyHandler, this doesn't check any external dependencies, so it
+// should only be used to determine if the server needs to be restarted.
+func LiveHandler(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(shared.HealthResponse{Status: healthOK})
+}
+
+// ReadyHandler checks each dependency required for serving traffic (database,
+// migrations, storage backend, and cache dir) and returns whether each one is
+// available. If any check fails, the response has a 503 status so that the
+// instance can be removed from rotation until it recovers. Failure details are
+// only logged, since this endpoint doesn't require authentication.
+func ReadyHandler(w http.ResponseWriter, req *http.Request) {
+	checks := map[string]func(context.Context) error{
+		"database":   db.Ping,
+		"migrations": db.CheckMigrations,
+		"storage":    probeStorage,
+		"cache":      func(context.Context) error { return cache.CheckWritable() },
+	}
+
+	response := shared.HealthResponse{
+		Status: healthOK,
+		Checks: make(map[string]shared.HealthCheck),
+	}
+
+	for name, check := range checks {
+		result := shared.HealthCheck{OK: true}
+		if err := runHealthCheck(req.Context(), check); err != nil {
+			log.Printf("Health check '%s' failed: %v\n", name, err)
+			result = shared.HealthCheck{OK: false}
+			response.Status = healthUnavailable
+		}
+
+		response.Checks[name] = result
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if response.Status != healthOK {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// runHealthCheck runs a single check, and fails it if it doesn't finish within
+// healthCheckTimeout (i.e. for storage clients that don't accept a context)
+func runHealthCheck(ctx context.Context, check func(context.Context) error) error {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	result := make(chan error, 1)
+	go func() {
+		result <- check(ctx)
+	}()
+
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// probeStorage checks the storage backend, reusing the last result for
+// storageProbeInterval so that polling the endpoint doesn't send a request to
+// the backend every time
+func probeStorage(ctx context.Context) error {
+	storageProbeMu.Lock()
+	defer storageProbeMu.Unlock()
+
+	if time.Since(storageProbeTime) < storageProbeInterval {
+		return storageProbeErr
+	}
+
+	storageProbeErr = storage.Interface.Probe(ctx)
+	storageProbeTime = time.Now()
+	return storageProbeErr
+}
+
 // InfoHandler returns information about the current instance
 func InfoHandler(w http.ResponseWriter, _ *http.Request) {
 	info := config.GetServerInfoStruct()
@@ -0,0 +1,258 @@
+package org
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"yeetfile/backend/db"
+	"yeetfile/backend/utils"
+	"yeetfile/shared"
+	"yeetfile/shared/constants"
+)
+
+// OrgHandler handles fetching (GET), creating (POST), and deleting (DELETE)
+// the organization that the current user belongs to
+func OrgHandler(w http.ResponseWriter, req *http.Request, userID string) {
+	switch req.Method {
+	case http.MethodGet:
+		org, err := db.GetOrganization(userID)
+		if err == db.OrgNotFoundError {
+			http.Error(w, "Not a member of an organization", http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Printf("Error fetching organization: %v\n", err)
+			http.Error(w, "Error fetching organization", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(w).Encode(org)
+		if err != nil {
+			http.Error(w, "Error sending response", http.StatusInternalServerError)
+		}
+	case http.MethodPost:
+		var newOrg shared.NewOrganization
+		err := utils.LimitedJSONReader(w, req.Body).Decode(&newOrg)
+		if err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		newOrg.Name = strings.TrimSpace(newOrg.Name)
+		if len(newOrg.Name) == 0 || len(newOrg.Name) > constants.MaxOrgNameLen {
+			http.Error(w, "Invalid organization name", http.StatusBadRequest)
+			return
+		}
+
+		id, err := db.CreateOrganization(newOrg.Name, userID)
+		if err == db.AlreadyInOrgError {
+			http.Error(w, "Already a member of an organization", http.StatusConflict)
+			return
+		} else if err != nil {
+			log.Printf("Error creating organization: %v\n", err)
+			http.Error(w, "Error creating organization", http.StatusInternalServerError)
+			return
+		}
+
+		err = json.NewEncoder(w).Encode(shared.MetadataUploadResponse{ID: id})
+		if err != nil {
+			http.Error(w, "Error sending response", http.StatusInternalServerError)
+		}
+	case http.MethodDelete:
+		orgID, role, ok := getOrgRole(w, userID)
+		if !ok {
+			return
+		} else if role != constants.OrgRoleOwner {
+			http.Error(w, "Only the owner can delete the organization", http.StatusForbidden)
+			return
+		}
+
+		err := deleteOrganization(orgID)
+		if err != nil {
+			log.Printf("Error deleting organization: %v\n", err)
+			http.Error(w, "Error deleting organization", http.StatusInternalServerError)
+		}
+	}
+}
+
+// OrgMembersHandler adds a new member to the current user's organization. The
+// request needs to include the key for each org folder, encrypted with the new
+// member's public key.
+func OrgMembersHandler(w http.ResponseWriter, req *http.Request, userID string) {
+	orgID, role, ok := getOrgRole(w, userID)
+	if !ok {
+		return
+	}
+
+	var newMember shared.NewOrgMember
+	err := utils.LimitedOrgKeysJSONReader(w, req.Body).Decode(&newMember)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	member, err := addMember(orgID, role, newMember)
+	if err != nil {
+		handleOrgError(w, "Error adding member", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(member)
+	if err != nil {
+		http.Error(w, "Error sending response", http.StatusInternalServerError)
+	}
+}
+
+// OrgMemberHandler changes a member's role (PUT) or removes a member from the
+// organization (DELETE). Removing a member requires the org folder keys to be
+// rotated before any other changes can be made to the organization.
+func OrgMemberHandler(w http.ResponseWriter, req *http.Request, userID string) {
+	segments := strings.Split(req.URL.Path, "/")
+	memberID := segments[len(segments)-1]
+
+	orgID, role, ok := getOrgRole(w, userID)
+	if !ok {
+		return
+	}
+
+	var err error
+	switch req.Method {
+	case http.MethodPut:
+		var mod shared.ModifyOrgMember
+		err = utils.LimitedJSONReader(w, req.Body).Decode(&mod)
+		if err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		var memberRole string
+		memberRole, err = getMemberRole(orgID, memberID)
+		if err == nil {
+			err = canAssignRole(role, memberRole, mod.Role)
+		}
+
+		if err == nil {
+			err = db.SetOrgMemberRole(orgID, memberID, mod.Role)
+		}
+	case http.MethodDelete:
+		err = removeMember(orgID, userID, role, memberID)
+	}
+
+	if err != nil {
+		handleOrgError(w, "Error modifying member", err)
+	}
+}
+
+// OrgFoldersHandler creates a new folder owned by the current user's org. The
+// folder key needs to be encrypted with the public key of each member.
+func OrgFoldersHandler(w http.ResponseWriter, req *http.Request, userID string) {
+	orgID, role, ok := getOrgRole(w, userID)
+	if !ok {
+		return
+	} else if !canManage(role) {
+		handleOrgError(w, "Error creating folder", PermissionError)
+		return
+	}
+
+	var folder shared.NewOrgFolder
+	err := utils.LimitedOrgKeysJSONReader(w, req.Body).Decode(&folder)
+	if err != nil || len(folder.Name) == 0 {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	id, err := db.NewOrgFolder(orgID, folder)
+	if err != nil {
+		handleOrgError(w, "Error creating folder", err)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(shared.MetadataUploadResponse{ID: id})
+	if err != nil {
+		http.Error(w, "Error sending response", http.StatusInternalServerError)
+	}
+}
+
+// OrgFolderHandler deletes an org folder and all of its contents
+func OrgFolderHandler(w http.ResponseWriter, req *http.Request, userID string) {
+	segments := strings.Split(req.URL.Path, "/")
+	folderID := segments[len(segments)-1]
+
+	orgID, role, ok := getOrgRole(w, userID)
+	if !ok {
+		return
+	} else if !canManage(role) {
+		handleOrgError(w, "Error deleting folder", PermissionError)
+		return
+	}
+
+	err := deleteOrgFolder(orgID, folderID)
+	if err != nil {
+		handleOrgError(w, "Error deleting folder", err)
+	}
+}
+
+// OrgKeysHandler replaces the keys of all org folders, which is required after
+// a member has been removed from the organization
+func OrgKeysHandler(w http.ResponseWriter, req *http.Request, userID string) {
+	orgID, role, ok := getOrgRole(w, userID)
+	if !ok {
+		return
+	} else if !canManage(role) {
+		handleOrgError(w, "Error rotating keys", PermissionError)
+		return
+	}
+
+	var rotation shared.OrgKeyRotation
+	err := utils.LimitedOrgKeysJSONReader(w, req.Body).Decode(&rotation)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	err = db.RotateOrgKeys(orgID, rotation)
+	if err != nil {
+		handleOrgError(w, "Error rotating keys", err)
+	}
+}
+
+// getOrgRole returns the ID of the user's organization and their role in it,
+// writing an error response if the user isn't a member of an organization
+func getOrgRole(w http.ResponseWriter, userID string) (string, string, bool) {
+	orgID, role, err := db.GetUserOrg(userID)
+	if err != nil {
+		log.Printf("Error fetching user's organization: %v\n", err)
+		http.Error(w, "Error fetching organization", http.StatusInternalServerError)
+		return "", "", false
+	} else if len(orgID) == 0 {
+		http.Error(w, "Not a member of an organization", http.StatusNotFound)
+		return "", "", false
+	}
+
+	return orgID, role, true
+}
+
+func handleOrgError(w http.ResponseWriter, msg string, err error) {
+	switch err {
+	case PermissionError:
+		http.Error(w, "You don't have permission to do this", http.StatusForbidden)
+	case InvalidRoleError:
+		http.Error(w, "Invalid role", http.StatusBadRequest)
+	case MemberNotFoundError:
+		http.Error(w, "User not found", http.StatusNotFound)
+	case db.FolderNotFoundError:
+		http.Error(w, "Folder not found", http.StatusNotFound)
+	case db.AlreadyInOrgError:
+		http.Error(w, "User is already a member of an organization", http.StatusConflict)
+	case db.OrgRotationPendingError:
+		http.Error(w, "Org folder keys need to be rotated first", http.StatusConflict)
+	case db.OrgKeyMismatchError:
+		http.Error(w, "Keys don't match the organization's members or folders",
+			http.StatusConflict)
+	default:
+		log.Printf("%s: %v\n", msg, err)
+		http.Error(w, msg, http.StatusInternalServerError)
+	}
+}
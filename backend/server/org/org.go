@@ -0,0 +1,133 @@
+package org
+
+import (
+	"errors"
+	"strings"
+	"yeetfile/backend/db"
+	"yeetfile/backend/server/transfer/vault"
+	"yeetfile/shared"
+	"yeetfile/shared/constants"
+)
+
+var PermissionError = errors.New("user doesn't have permission to manage the organization")
+var InvalidRoleError = errors.New("invalid organization role")
+var MemberNotFoundError = errors.New("organization member not found")
+
+// canManage checks if a role is allowed to manage members and org folders
+func canManage(role string) bool {
+	return role == constants.OrgRoleOwner || role == constants.OrgRoleAdmin
+}
+
+// canAssignRole checks if a member with the specified role can give another
+// member a new role. Only the owner can promote members to (or demote from)
+// the admin role, and the owner role can't be assigned.
+func canAssignRole(role, currentRole, newRole string) error {
+	switch newRole {
+	case constants.OrgRoleAdmin, constants.OrgRoleMember, constants.OrgRoleReadOnly:
+	default:
+		return InvalidRoleError
+	}
+
+	if !canManage(role) || currentRole == constants.OrgRoleOwner {
+		return PermissionError
+	} else if role != constants.OrgRoleOwner &&
+		(newRole == constants.OrgRoleAdmin || currentRole == constants.OrgRoleAdmin) {
+		return PermissionError
+	}
+
+	return nil
+}
+
+// getMemberRole returns the role of a member of the organization
+func getMemberRole(orgID, userID string) (string, error) {
+	memberOrgID, role, err := db.GetUserOrg(userID)
+	if err != nil {
+		return "", err
+	} else if memberOrgID != orgID {
+		return "", MemberNotFoundError
+	}
+
+	return role, nil
+}
+
+// addMember adds a user (by email or account ID) to the organization
+func addMember(orgID, role string, member shared.NewOrgMember) (shared.OrgMember, error) {
+	err := canAssignRole(role, "", member.Role)
+	if err != nil {
+		return shared.OrgMember{}, err
+	}
+
+	var userID string
+	if strings.Contains(member.User, "@") {
+		userID, err = db.GetUserIDByEmail(member.User)
+	} else {
+		userID = member.User
+		_, err = db.GetUserByID(member.User)
+	}
+
+	if err != nil || len(userID) == 0 {
+		return shared.OrgMember{}, MemberNotFoundError
+	}
+
+	err = db.AddOrgMember(orgID, userID, member.Role, member.FolderKeys)
+	if err != nil {
+		return shared.OrgMember{}, err
+	}
+
+	name, err := db.GetUserPublicName(userID)
+	return shared.OrgMember{ID: userID, Name: name, Role: member.Role}, err
+}
+
+// removeMember removes a member from the organization. Members can remove
+// themselves, otherwise the same rules apply as when changing a member's role.
+func removeMember(orgID, userID, role, memberID string) error {
+	memberRole, err := getMemberRole(orgID, memberID)
+	if err != nil {
+		return err
+	} else if memberRole == constants.OrgRoleOwner {
+		return PermissionError
+	}
+
+	if memberID != userID {
+		err = canAssignRole(role, memberRole, constants.OrgRoleMember)
+		if err != nil {
+			return err
+		}
+	}
+
+	return db.RemoveOrgMember(orgID, memberID)
+}
+
+// deleteOrgFolder deletes a top level org folder and all of its contents
+func deleteOrgFolder(orgID, folderID string) error {
+	folderIDs, err := db.GetOrgFolderIDs(orgID)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range folderIDs {
+		if id == folderID {
+			_, err = vault.DeleteVaultFolder(folderID, orgID, false, false)
+			return err
+		}
+	}
+
+	return db.FolderNotFoundError
+}
+
+// deleteOrganization deletes all org folders, and then the org itself
+func deleteOrganization(orgID string) error {
+	folderIDs, err := db.GetOrgFolderIDs(orgID)
+	if err != nil {
+		return err
+	}
+
+	for _, folderID := range folderIDs {
+		_, err = vault.DeleteVaultFolder(folderID, orgID, false, false)
+		if err != nil {
+			return err
+		}
+	}
+
+	return db.DeleteOrganization(orgID)
+}
@@ -62,7 +62,7 @@ func (r *router) AddRoutes(routes []RouteDef) {
 func (r *router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	for el, handler := range r.routes {
 		if r.matchPath(el.Path, req.URL.Path) && el.Method == req.Method {
-			if req.URL.Path != string(endpoints.Up) {
+			if !isHealthCheck(req.URL.Path) {
 				log.Printf("%s %s\n", req.Method, req.URL)
 			}
 
@@ -101,3 +101,14 @@ func (r *router) matchPath(pattern, path string) bool {
 
 	return true
 }
+
+// isHealthCheck returns true if the path is one of the health check endpoints,
+// which are polled frequently and shouldn't clutter the request logs.
+func isHealthCheck(path string) bool {
+	switch endpoints.Endpoint(path) {
+	case endpoints.Up, endpoints.HealthLive, endpoints.HealthReady:
+		return true
+	default:
+		return false
+	}
+}
@@ -2,19 +2,17 @@ package server
 
 import (
 	"context"
-	"crypto/tls"
 	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os/signal"
-	"strings"
 	"syscall"
-	"yeetfile/backend/config"
 	"yeetfile/backend/server/admin"
 	"yeetfile/backend/server/auth"
 	"yeetfile/backend/server/html"
 	"yeetfile/backend/server/misc"
+	"yeetfile/backend/server/org"
 	"yeetfile/backend/server/payments"
 	"yeetfile/backend/server/session"
 	"yeetfile/backend/server/transfer/send"
@@ -30,6 +28,7 @@ const (
 	PUT
 	POST
 	DELETE
+	PATCH
 	ALL = GET | PUT | POST | DELETE
 )
 
@@ -38,6 +37,7 @@ var MethodMap = map[HttpMethod]string{
 	PUT:    http.MethodPut,
 	POST:   http.MethodPost,
 	DELETE: http.MethodDelete,
+	PATCH:  http.MethodPatch,
 }
 
 // Run maps URL paths to handlers for the server and begins listening on the
@@ -54,19 +54,47 @@ func Run(host, port string) {
 		{POST, endpoints.UploadSendText, LimiterMiddleware(LockdownAuthMiddleware(send.UploadPlaintextHandler))},
 		{GET, endpoints.DownloadSendFileMetadata, send.DownloadHandler},
 		{GET, endpoints.DownloadSendFileData, send.DownloadChunkHandler},
+		{GET, endpoints.SendRoot, AuthMiddleware(send.SendHandler)},
+		{PATCH | DELETE, endpoints.SendItem, AuthMiddleware(send.ModifySendHandler)},
+		{GET, endpoints.SendInbox, AuthMiddleware(send.InboxHandler)},
+		{POST, endpoints.SendInboxItem, AuthMiddleware(send.SaveInboxSendHandler)},
 
 		// YeetFile Vault
-		{ALL, endpoints.VaultFolder, AuthMiddleware(vault.FolderHandler(vault.FileVault))},
-		{GET | PUT | DELETE, endpoints.VaultFile, AuthMiddleware(vault.FileHandler)},
+		{ALL, endpoints.VaultFolder, AuthMiddleware(EmergencyAccessMiddleware(vault.FolderHandler(vault.FileVault)))},
+		{GET | PUT | DELETE, endpoints.VaultFile, AuthMiddleware(EmergencyAccessMiddleware(vault.FileHandler))},
 		{POST, endpoints.UploadVaultFileMetadata, AuthMiddleware(vault.UploadMetadataHandler)},
-		{POST, endpoints.UploadVaultFileData, AuthMiddleware(vault.UploadDataHandler)},
-		{GET, endpoints.DownloadVaultFileMetadata, AuthLimiterMiddleware(vault.DownloadHandler)},
-		{GET, endpoints.DownloadVaultFileData, AuthMiddleware(vault.DownloadChunkHandler)},
+		{POST, endpoints.UploadVaultFileData, FileRequestMiddleware(vault.UploadDataHandler)},
+		{GET, endpoints.DownloadVaultFileMetadata, AuthLimiterMiddleware(EmergencyAccessMiddleware(vault.DownloadHandler))},
+		{GET, endpoints.DownloadVaultFileData, AuthMiddleware(EmergencyAccessMiddleware(vault.DownloadChunkHandler))},
+		{GET | POST, endpoints.FileRequests, AuthMiddleware(vault.FileRequestsHandler)},
+		{GET, endpoints.FileRequest, LimiterMiddleware(vault.FileRequestInfoHandler)},
+		{DELETE, endpoints.FileRequest, AuthMiddleware(vault.DeleteFileRequestHandler)},
+		{POST, endpoints.UploadFileRequest, LimiterMiddleware(vault.FileRequestUploadHandler)},
 		{ALL, endpoints.ShareFile, AuthMiddleware(vault.ShareHandler(false))},
 		{ALL, endpoints.ShareFolder, AuthMiddleware(vault.ShareHandler(true))},
+		{GET, endpoints.ShareInvitations, AuthMiddleware(vault.ShareInvitationsHandler)},
+		{PUT, endpoints.ShareInvitation, AuthMiddleware(vault.ShareInvitationHandler)},
+		{DELETE, endpoints.ShareBlocked, AuthMiddleware(vault.UnblockUserHandler)},
+		{GET, endpoints.OwnershipTransfers, AuthMiddleware(vault.OwnershipTransfersHandler)},
+		{PUT | DELETE, endpoints.OwnershipTransfer, AuthMiddleware(vault.OwnershipTransferHandler)},
+		{POST, endpoints.TransferFileOwnership, AuthMiddleware(vault.TransferOwnershipHandler(false))},
+		{POST, endpoints.TransferFolderOwnership, AuthMiddleware(vault.TransferOwnershipHandler(true))},
+		{GET | POST, endpoints.EmergencyAccess, AuthMiddleware(auth.EmergencyAccessHandler)},
+		{PUT | DELETE, endpoints.EmergencyContact, AuthMiddleware(auth.EmergencyContactHandler)},
+		{POST | DELETE, endpoints.EmergencyRequest, AuthMiddleware(auth.EmergencyRequestHandler)},
+		{GET, endpoints.EmergencyKey, AuthMiddleware(auth.EmergencyKeyHandler)},
+		{PUT, endpoints.EmergencyTakeover, AuthMiddleware(auth.EmergencyTakeoverHandler)},
+
+		// Organizations
+		{GET | POST | DELETE, endpoints.Org, AuthMiddleware(org.OrgHandler)},
+		{POST, endpoints.OrgMembers, AuthMiddleware(org.OrgMembersHandler)},
+		{PUT | DELETE, endpoints.OrgMember, AuthMiddleware(org.OrgMemberHandler)},
+		{POST, endpoints.OrgFolders, AuthMiddleware(org.OrgFoldersHandler)},
+		{DELETE, endpoints.OrgFolder, AuthMiddleware(org.OrgFolderHandler)},
+		{PUT, endpoints.OrgKeys, AuthMiddleware(org.OrgKeysHandler)},
 
 		// YeetFile Pass (YeetPass)
-		{ALL, endpoints.PassFolder, AuthMiddleware(vault.FolderHandler(vault.PassVault))},
+		{ALL, endpoints.PassFolder, AuthMiddleware(EmergencyAccessMiddleware(vault.FolderHandler(vault.PassVault)))},
 		{POST, endpoints.PassEntry, AuthMiddleware(vault.UploadMetadataHandler)},
 		{DELETE, endpoints.PassEntry, AuthMiddleware(vault.FileHandler)},
 
@@ -76,13 +104,24 @@ func Run(host, port string) {
 		{GET, endpoints.Session, session.SessionHandler},
 		{GET, endpoints.Logout, auth.LogoutHandler},
 		{GET | POST | DELETE, endpoints.TwoFactor, AuthMiddleware(auth.TwoFactorHandler)},
+		{GET, endpoints.WebAuthn, AuthMiddleware(auth.WebAuthnHandler)},
+		{GET | POST, endpoints.WebAuthnRegister, AuthMiddleware(auth.WebAuthnRegisterHandler)},
+		{DELETE, endpoints.WebAuthnKey, AuthMiddleware(auth.WebAuthnKeyHandler)},
+		{POST, endpoints.WebAuthnAssert, LimiterMiddleware(auth.WebAuthnAssertionHandler)},
 		{POST, endpoints.Login, LimiterMiddleware(auth.LoginHandler)},
+		{GET, endpoints.KDF, LimiterMiddleware(auth.KDFHandler)},
+		{GET, endpoints.OIDCLogin, LimiterMiddleware(auth.OIDCLoginHandler)},
+		{GET, endpoints.OIDCCallback, LimiterMiddleware(auth.OIDCCallbackHandler)},
 		{POST, endpoints.Signup, LimiterMiddleware(auth.SignupHandler)},
 		{GET | PUT | DELETE, endpoints.Account, AuthMiddleware(auth.AccountHandler)},
+		{GET | PUT, endpoints.SecurityLog, AuthMiddleware(auth.SecurityLogHandler)},
 		{GET, endpoints.AccountUsage, AuthMiddleware(auth.AccountUsageHandler)},
 		{POST, endpoints.Forgot, LimiterMiddleware(auth.ForgotPasswordHandler)},
+		{POST | PUT, endpoints.Recover, LimiterMiddleware(auth.RecoverAccountHandler)},
+		{PUT | DELETE, endpoints.RecoveryKey, AuthMiddleware(auth.RecoveryKeyHandler)},
 		{GET, endpoints.PubKey, AuthLimiterMiddleware(auth.PubKeyHandler)},
 		{GET, endpoints.ProtectedKey, AuthMiddleware(auth.ProtectedKeyHandler)},
+		{GET | PUT, endpoints.KeyRotation, AuthMiddleware(auth.KeyRotationHandler)},
 		{POST | PUT, endpoints.ChangeEmail, AuthMiddleware(auth.ChangeEmailHandler)},
 		{PUT, endpoints.ChangePassword, AuthMiddleware(auth.ChangePasswordHandler)},
 		{POST, endpoints.ChangeHint, AuthMiddleware(auth.ChangeHintHandler)},
@@ -91,6 +130,7 @@ func Run(host, port string) {
 		// Admin
 		{GET | DELETE, endpoints.AdminUserActions, AdminMiddleware(admin.UserActionHandler)},
 		{GET | DELETE, endpoints.AdminFileActions, AdminMiddleware(admin.FileActionHandler)},
+		{POST, endpoints.AdminUserUnlock, AdminMiddleware(admin.UnlockUserHandler)},
 
 		// Payments (Stripe, BTCPay)
 		{POST, endpoints.StripeWebhook, payments.StripeWebhook},
@@ -108,6 +148,7 @@ func Run(host, port string) {
 		{GET, endpoints.HTMLVaultFolder, AuthMiddleware(html.FileVaultPageHandler)},
 		{GET, endpoints.HTMLVaultFile, AuthMiddleware(html.FileVaultPageHandler)},
 		{GET, endpoints.HTMLSendDownload, html.DownloadPageHandler},
+		{GET, endpoints.HTMLFileRequest, html.FileRequestPageHandler},
 		{GET, endpoints.HTMLSignup, NoAuthMiddleware(html.SignupPageHandler)},
 		{GET, endpoints.HTMLLogin, NoAuthMiddleware(html.LoginPageHandler)},
 		{GET, endpoints.HTMLForgot, NoAuthMiddleware(html.ForgotPageHandler)},
@@ -134,6 +175,8 @@ func Run(host, port string) {
 			misc.FileHandler("/static/", "", static.StaticFiles),
 		},
 		{GET, endpoints.Up, misc.UpHandler},
+		{GET, endpoints.HealthLive, misc.LiveHandler},
+		{GET, endpoints.HealthReady, misc.ReadyHandler},
 		{GET, endpoints.ServerInfo, misc.InfoHandler},
 
 		// StreamSaver.js
@@ -166,33 +209,21 @@ func Run(host, port string) {
 		syscall.SIGTERM)
 	defer stop()
 
-	go serve(r, host, port)
+	go serve(ctx, r, host, port)
 	<-ctx.Done()
 
 	log.Println("Shutting down...")
 }
 
-func serve(r *router, host, port string) {
-	var err error
-	var cert tls.Certificate
-
+func serve(ctx context.Context, r *router, host, port string) {
 	addr := fmt.Sprintf("%s:%s", host, port)
 
-	if len(config.TLSCert) > 0 && len(config.TLSKey) > 0 {
-		config.TLSKey = strings.ReplaceAll(config.TLSKey, "\\n", "\n")
-		config.TLSCert = strings.ReplaceAll(config.TLSCert, "\\n", "\n")
-
-		cert, err = tls.X509KeyPair(
-			[]byte(config.TLSCert),
-			[]byte(config.TLSKey))
-		if err != nil {
-			log.Fatalf("Failed to load key pair: %v", err)
-		}
-
-		tlsConfig := &tls.Config{
-			Certificates: []tls.Certificate{cert},
-		}
+	tlsConfig, useTLS, err := getTLSConfig(ctx)
+	if err != nil {
+		log.Fatalf("Failed to configure TLS: %v", err)
+	}
 
+	if useTLS {
 		server := &http.Server{
 			Addr:      addr,
 			TLSConfig: tlsConfig,
@@ -0,0 +1,243 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"yeetfile/backend/config"
+)
+
+// certPollInterval is how often the cert and key files are checked for changes
+const certPollInterval = 30 * time.Second
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// certReloader holds the current TLS certificate loaded from the configured
+// cert and key file paths, and swaps it out whenever the files change or the
+// server receives a SIGHUP.
+type certReloader struct {
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	certPath string
+	keyPath  string
+	modTime  time.Time
+}
+
+func newCertReloader(certPath, keyPath string) (*certReloader, error) {
+	reloader := &certReloader{certPath: certPath, keyPath: keyPath}
+	if err := reloader.reload(); err != nil {
+		return nil, err
+	}
+
+	return reloader, nil
+}
+
+// reload reads the cert and key files and replaces the current certificate.
+// If the new key pair is invalid, the current certificate is kept.
+func (c *certReloader) reload() error {
+	modTime, err := c.latestModTime()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(c.certPath, c.keyPath)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cert = &cert
+	c.modTime = modTime
+
+	return nil
+}
+
+// latestModTime returns the most recent modification time of the cert and key
+func (c *certReloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, path := range []string{c.certPath, c.keyPath} {
+		info, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+
+	return latest, nil
+}
+
+// hasChanged checks if either of the cert or key files have been modified
+// since the certificate was last loaded.
+func (c *certReloader) hasChanged() bool {
+	modTime, err := c.latestModTime()
+	if err != nil {
+		return false
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return modTime.After(c.modTime)
+}
+
+// GetCertificate is used as the tls.Config callback for fetching the current
+// certificate on each new connection.
+func (c *certReloader) GetCertificate(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cert, nil
+}
+
+// watch reloads the certificate on SIGHUP or when the cert/key files change,
+// until the context is canceled.
+func (c *certReloader) watch(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	ticker := time.NewTicker(certPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			log.Println("Received SIGHUP, reloading TLS certificate...")
+		case <-ticker.C:
+			if !c.hasChanged() {
+				continue
+			}
+
+			log.Println("TLS certificate changed, reloading...")
+		}
+
+		if err := c.reload(); err != nil {
+			log.Printf("Error reloading TLS certificate "+
+				"(keeping current cert): %v\n", err)
+		} else {
+			log.Println("TLS certificate reloaded")
+		}
+	}
+}
+
+// getTLSConfig creates the server TLS config from the cert/key settings, as
+// well as the (optional) min version, cipher, and client CA settings. The
+// returned bool is false if TLS hasn't been configured.
+func getTLSConfig(ctx context.Context) (*tls.Config, bool, error) {
+	tlsConfig := &tls.Config{}
+
+	if len(config.TLSCertPath) > 0 && len(config.TLSKeyPath) > 0 {
+		reloader, err := newCertReloader(config.TLSCertPath, config.TLSKeyPath)
+		if err != nil {
+			return nil, true, fmt.Errorf("failed to load key pair: %v", err)
+		}
+
+		go reloader.watch(ctx)
+		tlsConfig.GetCertificate = reloader.GetCertificate
+	} else if len(config.TLSCert) > 0 && len(config.TLSKey) > 0 {
+		config.TLSKey = strings.ReplaceAll(config.TLSKey, "\\n", "\n")
+		config.TLSCert = strings.ReplaceAll(config.TLSCert, "\\n", "\n")
+
+		cert, err := tls.X509KeyPair(
+			[]byte(config.TLSCert),
+			[]byte(config.TLSKey))
+		if err != nil {
+			return nil, true, fmt.Errorf("failed to load key pair: %v", err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	} else {
+		return nil, false, nil
+	}
+
+	if len(config.TLSMinVersion) > 0 {
+		version, ok := tlsVersions[config.TLSMinVersion]
+		if !ok {
+			return nil, true, fmt.Errorf(
+				"invalid TLS min version '%s'", config.TLSMinVersion)
+		}
+
+		tlsConfig.MinVersion = version
+	}
+
+	if len(config.TLSCiphers) > 0 {
+		ciphers, err := parseCipherSuites(config.TLSCiphers)
+		if err != nil {
+			return nil, true, err
+		}
+
+		tlsConfig.CipherSuites = ciphers
+	}
+
+	if len(config.TLSClientCA) > 0 {
+		caPEM, err := os.ReadFile(config.TLSClientCA)
+		if err != nil {
+			return nil, true, fmt.Errorf("failed to read client CA: %v", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, true, errors.New("no valid certs found in client CA")
+		}
+
+		// Client certs are only required for admin endpoints (see
+		// AdminMiddleware), so they're optional at the TLS level
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	return tlsConfig, true, nil
+}
+
+// parseCipherSuites converts a comma separated list of cipher suite names
+// (i.e. "TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384") into their IDs. Insecure
+// cipher suites are not allowed. Note that this has no effect on TLS 1.3
+// connections, which always use Go's default TLS 1.3 cipher suites.
+func parseCipherSuites(cipherList string) ([]uint16, error) {
+	available := map[string]uint16{}
+	for _, suite := range tls.CipherSuites() {
+		available[suite.Name] = suite.ID
+	}
+
+	var ids []uint16
+	for _, name := range strings.Split(cipherList, ",") {
+		name = strings.TrimSpace(name)
+		if len(name) == 0 {
+			continue
+		}
+
+		id, ok := available[name]
+		if !ok {
+			return nil, fmt.Errorf("unsupported TLS cipher suite '%s'", name)
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// hasVerifiedClientCert returns true if the request was made over TLS with a
+// client certificate signed by the configured client CA.
+func hasVerifiedClientCert(req *http.Request) bool {
+	return req.TLS != nil && len(req.TLS.VerifiedChains) > 0
+}
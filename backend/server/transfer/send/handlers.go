@@ -12,7 +12,9 @@ import (
 	"yeetfile/backend/cache"
 	"yeetfile/backend/config"
 	"yeetfile/backend/db"
+	"yeetfile/backend/server/session"
 	"yeetfile/backend/server/transfer"
+	"yeetfile/backend/server/transfer/vault"
 	"yeetfile/backend/storage"
 	"yeetfile/backend/utils"
 	"yeetfile/shared"
@@ -23,7 +25,7 @@ import (
 // up a file for uploading. This is defined in the UploadMetadata struct.
 func UploadMetadataHandler(w http.ResponseWriter, req *http.Request, userID string) {
 	var meta shared.UploadMetadata
-	data, _ := utils.LimitedReader(w, req.Body)
+	data, _ := utils.LimitedSendMetadataReader(w, req.Body)
 	err := json.Unmarshal(data, &meta)
 	if err != nil {
 		log.Printf("%v\n", req.Body)
@@ -38,6 +40,22 @@ func UploadMetadataHandler(w http.ResponseWriter, req *http.Request, userID stri
 	} else if meta.Downloads == 0 {
 		http.Error(w, "# of downloads cannot be 0", http.StatusBadRequest)
 		return
+	} else if len(meta.Manifest) > constants.MaxSendManifestSize {
+		http.Error(w, "Send manifest is too large", http.StatusBadRequest)
+		return
+	} else if len(meta.Format) > constants.MaxTextFormatLen {
+		http.Error(w, "Invalid text format", http.StatusBadRequest)
+		return
+	}
+
+	if meta.Text && !isValidTextUpload(meta) {
+		http.Error(w, "Invalid text upload size", http.StatusBadRequest)
+		return
+	}
+
+	recipientIDs, ok := getRecipientIDs(w, meta.Recipients)
+	if !ok {
+		return
 	}
 
 	_, err = UserCanSend(meta.Size, req)
@@ -49,7 +67,7 @@ func UploadMetadataHandler(w http.ResponseWriter, req *http.Request, userID stri
 		return
 	}
 
-	id, _ := db.InsertMetadata(meta.Chunks, userID, meta.Name, false)
+	id, _ := db.InsertMetadata(meta.Chunks, userID, meta.Name, meta.Text)
 	err = db.CreateNewUpload(id, meta.Name)
 	if err != nil {
 		log.Printf("Error initializing new upload: %v\n", err)
@@ -65,6 +83,40 @@ func UploadMetadataHandler(w http.ResponseWriter, req *http.Request, userID stri
 		return
 	}
 
+	if meta.Notify {
+		err = db.SetSendNotify(id, true)
+		if err != nil {
+			log.Printf("Error enabling send notifications: %v\n", err)
+			http.Error(w, "Server error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if len(meta.Manifest) > 0 {
+		err = db.SetSendManifest(id, meta.Manifest)
+		if err != nil {
+			log.Printf("Error setting send manifest: %v\n", err)
+			http.Error(w, "Server error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if len(meta.Format) > 0 {
+		err = db.SetSendFormat(id, meta.Format)
+		if err != nil {
+			log.Printf("Error setting text format: %v\n", err)
+			http.Error(w, "Server error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	err = addSendRecipients(id, recipientIDs, meta.Recipients)
+	if err != nil {
+		log.Printf("Error adding send recipients: %v\n", err)
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
 	if meta.Chunks == 1 {
 		err = storage.Interface.InitUpload(id)
 	} else {
@@ -147,8 +199,9 @@ func UploadDataHandler(w http.ResponseWriter, req *http.Request, userID string)
 }
 
 // UploadPlaintextHandler handles uploading plaintext with a max size of
-// shared.MaxPlaintextLen characters (constants.go).
-func UploadPlaintextHandler(w http.ResponseWriter, req *http.Request, _ string) {
+// shared.MaxPlaintextLen characters (constants.go). Larger text is uploaded in
+// chunks using UploadMetadataHandler and UploadDataHandler instead.
+func UploadPlaintextHandler(w http.ResponseWriter, req *http.Request, userID string) {
 	var plaintextUpload shared.PlaintextUpload
 	err := utils.LimitedJSONReader(w, req.Body).Decode(&plaintextUpload)
 	if err != nil {
@@ -160,9 +213,28 @@ func UploadPlaintextHandler(w http.ResponseWriter, req *http.Request, _ string)
 	if len(plaintextUpload.Text) > constants.MaxPlaintextLen+constants.TotalOverhead {
 		http.Error(w, "Invalid upload size", http.StatusBadRequest)
 		return
+	} else if len(plaintextUpload.Format) > constants.MaxTextFormatLen {
+		http.Error(w, "Invalid text format", http.StatusBadRequest)
+		return
+	}
+
+	// Text can be sent without an account, but should still be listed in
+	// the user's sends if they're logged in
+	if len(userID) == 0 && session.IsValidSession(w, req) {
+		userID, _ = session.GetSessionAndUserID(req)
+	}
+
+	if len(plaintextUpload.Recipients) > 0 && len(userID) == 0 {
+		http.Error(w, "An account is required to send to recipients", http.StatusUnauthorized)
+		return
+	}
+
+	recipientIDs, ok := getRecipientIDs(w, plaintextUpload.Recipients)
+	if !ok {
+		return
 	}
 
-	id, err := db.InsertMetadata(1, "", plaintextUpload.Name, true)
+	id, err := db.InsertMetadata(1, userID, plaintextUpload.Name, true)
 	if err != nil {
 		log.Printf("Error inserting new text-only upload metadata: %v\n", err)
 		http.Error(w, "Unable to init metadata", http.StatusInternalServerError)
@@ -184,6 +256,22 @@ func UploadPlaintextHandler(w http.ResponseWriter, req *http.Request, _ string)
 		return
 	}
 
+	if len(plaintextUpload.Format) > 0 {
+		err = db.SetSendFormat(id, plaintextUpload.Format)
+		if err != nil {
+			log.Printf("Error setting text format: %v\n", err)
+			http.Error(w, "Server error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	err = addSendRecipients(id, recipientIDs, plaintextUpload.Recipients)
+	if err != nil {
+		log.Printf("Error adding send recipients: %v\n", err)
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
 	err = storage.Interface.InitUpload(id)
 	if err != nil {
 		http.Error(w, "Unable to init file", http.StatusBadRequest)
@@ -223,7 +311,25 @@ func DownloadHandler(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	if !canAccessSend(w, req, id) {
+		http.Error(w, "Send not found", http.StatusNotFound)
+		return
+	}
+
 	expiry := db.GetFileExpiry(id)
+	manifest, err := db.GetSendManifest(id)
+	if err != nil {
+		log.Printf("Error fetching send manifest: %v\n", err)
+		http.Error(w, "Error fetching send", http.StatusInternalServerError)
+		return
+	}
+
+	format, err := db.GetSendFormat(id)
+	if err != nil {
+		log.Printf("Error fetching text format: %v\n", err)
+		http.Error(w, "Error fetching send", http.StatusInternalServerError)
+		return
+	}
 
 	response := shared.DownloadResponse{
 		Name:       metadata.Name,
@@ -232,6 +338,8 @@ func DownloadHandler(w http.ResponseWriter, req *http.Request) {
 		Size:       metadata.Length,
 		Downloads:  expiry.Downloads,
 		Expiration: expiry.Date,
+		Manifest:   manifest,
+		Format:     format,
 	}
 
 	jsonData, _ := json.Marshal(response)
@@ -263,6 +371,11 @@ func DownloadChunkHandler(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	if !canAccessSend(w, req, id) {
+		http.Error(w, "Send not found", http.StatusNotFound)
+		return
+	}
+
 	var (
 		eof   bool
 		bytes []byte
@@ -282,15 +395,9 @@ func DownloadChunkHandler(w http.ResponseWriter, req *http.Request) {
 
 	// If the file is finished downloading, decrease the download counter
 	// for that file, and delete if 0 are remaining
-	rem := -1
 	if eof {
 		exp := db.GetFileExpiry(metadata.ID)
-		rem = db.DecrementDownloads(metadata.ID)
-
-		if rem == 0 {
-			storage.DeleteFileByMetadata(metadata)
-		}
-
+		rem := countDownload(metadata)
 		if rem >= 0 {
 			w.Header().Set("Downloads", strconv.Itoa(rem))
 		}
@@ -299,3 +406,123 @@ func DownloadChunkHandler(w http.ResponseWriter, req *http.Request) {
 
 	_, _ = w.Write(bytes)
 }
+
+// SendHandler returns the list of active sends created by the current user.
+func SendHandler(w http.ResponseWriter, _ *http.Request, userID string) {
+	sends, err := db.GetUserSends(userID)
+	if err != nil {
+		log.Printf("Error fetching user sends: %v\n", err)
+		http.Error(w, "Error fetching sends", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(shared.SendListResponse{Sends: sends})
+	if err != nil {
+		http.Error(w, "Error sending response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// InboxHandler returns the list of active sends that have been addressed to
+// the current user.
+func InboxHandler(w http.ResponseWriter, _ *http.Request, userID string) {
+	sends, err := db.GetInboxSends(userID)
+	if err != nil {
+		log.Printf("Error fetching inbox sends: %v\n", err)
+		http.Error(w, "Error fetching inbox", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(shared.InboxResponse{Sends: sends})
+	if err != nil {
+		http.Error(w, "Error sending response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// SaveInboxSendHandler copies a send from the user's inbox into their vault.
+// The send data is copied on the server, so the user only needs to provide the
+// send key wrapped with the key of the destination folder. Saving a send
+// counts as a download.
+func SaveInboxSendHandler(w http.ResponseWriter, req *http.Request, userID string) {
+	segments := strings.Split(req.URL.Path, "/")
+	id := segments[len(segments)-1]
+
+	var save shared.SaveInboxSend
+	err := utils.LimitedJSONReader(w, req.Body).Decode(&save)
+	if err != nil || len(save.Name) == 0 || len(save.ProtectedKey) == 0 {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	isRecipient, err := db.IsSendRecipient(id, userID)
+	if err != nil {
+		log.Printf("Error checking send recipient: %v\n", err)
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	} else if !isRecipient {
+		http.Error(w, "Send not found", http.StatusNotFound)
+		return
+	}
+
+	metadata, err := db.RetrieveMetadata(id)
+	if err != nil || metadata.Expiration.Before(time.Now().UTC()) {
+		http.Error(w, "Send not found", http.StatusNotFound)
+		return
+	}
+
+	itemID, err := saveToVault(metadata, userID, save)
+	if err == InvalidVaultSendError || err == vault.OutOfSpaceError {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	} else if err != nil {
+		log.Printf("Error saving send to vault: %v\n", err)
+		http.Error(w, "Error saving send to vault", http.StatusInternalServerError)
+		return
+	}
+
+	countDownload(metadata)
+
+	err = json.NewEncoder(w).Encode(shared.MetadataUploadResponse{ID: itemID})
+	if err != nil {
+		http.Error(w, "Error sending response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// ModifySendHandler handles requests to either revoke (DELETE) one of the
+// user's sends, or change its expiration and/or remaining downloads (PATCH).
+func ModifySendHandler(w http.ResponseWriter, req *http.Request, userID string) {
+	segments := strings.Split(req.URL.Path, "/")
+	id := segments[len(segments)-1]
+
+	metadata, totalDownloads, err := db.RetrieveUserSend(id, userID)
+	if err == db.SendNotFoundError {
+		http.Error(w, "Send not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.Printf("Error fetching send: %v\n", err)
+		http.Error(w, "Error fetching send", http.StatusInternalServerError)
+		return
+	}
+
+	switch req.Method {
+	case http.MethodPatch:
+		var sendMod shared.ModifySend
+		err = utils.LimitedJSONReader(w, req.Body).Decode(&sendMod)
+		if err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		err = updateSend(metadata, sendMod)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	case http.MethodDelete:
+		revokeSend(metadata, totalDownloads, userID)
+	}
+}
@@ -2,14 +2,35 @@ package send
 
 import (
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"strings"
+	"time"
+	"yeetfile/backend/cache"
 	"yeetfile/backend/config"
 	"yeetfile/backend/db"
+	"yeetfile/backend/mail"
 	"yeetfile/backend/server/session"
+	"yeetfile/backend/server/transfer"
+	"yeetfile/backend/server/transfer/vault"
+	"yeetfile/backend/storage"
+	"yeetfile/backend/utils"
+	"yeetfile/shared"
+	"yeetfile/shared/constants"
 )
 
 var OutOfSpaceError = errors.New("not enough space to upload")
+var InvalidExpirationError = errors.New("invalid expiration")
+var ExceedsMaxDownloadsError = fmt.Errorf(
+	"max downloads must be <= %d", constants.MaxSendDownloads)
+var ExceedsMaxAgeError = fmt.Errorf(
+	"expiration must be <= %d days in the future", constants.MaxSendAgeDays)
+var TooManyRecipientsError = fmt.Errorf(
+	"sends can't have more than %d recipients", constants.MaxSendRecipients)
+var RecipientNotFoundError = errors.New("recipient not found")
+var InvalidRecipientError = errors.New("invalid recipient")
+var InvalidVaultSendError = errors.New("only single file sends can be saved to the vault")
 
 // UserCanSend fetches the user ID associated with the request and checks to
 // see if they have enough remaining send space to send a file
@@ -49,3 +70,237 @@ func UpdateUserMeter(size int, id string) error {
 
 	return nil
 }
+
+// notifySender emails the owner of a send after it has been fully downloaded,
+// if they opted in to notifications when creating the send. This needs to be
+// called before the send is deleted.
+func notifySender(id string, remaining int) {
+	email, err := db.GetSendNotifyEmail(id)
+	if err != nil {
+		log.Printf("Error checking send notification setting: %v\n", err)
+		return
+	} else if len(email) == 0 {
+		return
+	}
+
+	err = mail.SendDownloadedNotification(email, id, remaining)
+	if err != nil {
+		log.Printf("Error sending download notification: %v\n", err)
+	}
+}
+
+// countDownload decreases the download counter for a send after it has been
+// fully downloaded, and deletes the send if there are no downloads remaining.
+// Returns the number of remaining downloads, or -1 if the counter couldn't be
+// updated.
+func countDownload(metadata db.FileMetadata) int {
+	rem := db.DecrementDownloads(metadata.ID)
+	if rem >= 0 {
+		notifySender(metadata.ID, rem)
+	}
+
+	if rem == 0 {
+		storage.DeleteFileByMetadata(metadata)
+	}
+
+	return rem
+}
+
+// saveToVault copies the data for a file send into a new file in the user's
+// vault. Text and multi-file sends can't be saved to the vault.
+func saveToVault(
+	metadata db.FileMetadata,
+	userID string,
+	save shared.SaveInboxSend,
+) (string, error) {
+	manifest, err := db.GetSendManifest(metadata.ID)
+	if err != nil {
+		return "", err
+	} else if len(manifest) > 0 ||
+		!strings.HasPrefix(metadata.ID, constants.FileIDPrefix) {
+		return "", InvalidVaultSendError
+	}
+
+	upload := shared.VaultUpload{
+		Name:         save.Name,
+		Length:       metadata.Length - int64(metadata.Chunks*constants.TotalOverhead),
+		Chunks:       metadata.Chunks,
+		FolderID:     save.FolderID,
+		ProtectedKey: save.ProtectedKey,
+	}
+
+	return vault.ImportFile(userID, upload, func(chunk int) ([]byte, error) {
+		var data []byte
+		if cache.HasFile(metadata.ID, metadata.Length) {
+			_, data = transfer.DownloadFileFromCache(metadata.ID, metadata.Length, chunk)
+		} else {
+			_, data = transfer.DownloadFile(
+				metadata.B2ID,
+				metadata.Name,
+				metadata.Length,
+				chunk)
+		}
+
+		if len(data) <= constants.TotalOverhead {
+			return nil, errors.New("unable to read send data")
+		}
+
+		return data, nil
+	})
+}
+
+// updateSend applies changes to the expiration and/or the number of remaining
+// downloads for a send. Fields that are left empty in the request are kept
+// as-is.
+func updateSend(metadata db.FileMetadata, sendMod shared.ModifySend) error {
+	downloads := metadata.Downloads
+	if sendMod.Downloads > constants.MaxSendDownloads {
+		return ExceedsMaxDownloadsError
+	} else if sendMod.Downloads > 0 {
+		downloads = sendMod.Downloads
+	}
+
+	date := metadata.Expiration
+	if len(sendMod.Expiration) > 0 {
+		exp := utils.StrToDuration(sendMod.Expiration, config.IsDebugMode)
+		if exp <= 0 {
+			return InvalidExpirationError
+		} else if exp > constants.MaxSendAgeDays*time.Hour*24 {
+			return ExceedsMaxAgeError
+		}
+
+		date = time.Now().Add(exp).UTC()
+	}
+
+	return db.UpdateFileExpiry(metadata.ID, downloads, date)
+}
+
+// isValidTextUpload checks that a chunked text upload is larger than the max
+// size for a single text upload and doesn't exceed the max text send size.
+// Since chunks can't exceed constants.ChunkSize, limiting the
+// number of chunks also limits the amount that can actually be uploaded.
+func isValidTextUpload(meta shared.UploadMetadata) bool {
+	maxSize := config.YeetFileConfig.MaxTextSendSize
+	maxChunks := shared.CalculateNumChunks(maxSize)
+	return len(meta.Manifest) == 0 &&
+		meta.Size > constants.MaxPlaintextLen &&
+		meta.Size <= maxSize &&
+		meta.Chunks <= maxChunks
+}
+
+// isMeteredSend checks if a send counted against the user's send limit. This
+// includes all files, as well as text sends that were too large to upload
+// in a single request.
+func isMeteredSend(metadata db.FileMetadata) bool {
+	if strings.HasPrefix(metadata.ID, constants.FileIDPrefix) {
+		return true
+	}
+
+	return metadata.Length > constants.MaxPlaintextLen+constants.TotalOverhead
+}
+
+// revokeSend deletes a send before it has expired. If the send was never
+// downloaded, the amount it counted against the user's send limit is refunded
+// (small text sends don't count against the limit). Sends that predate
+// tracking the total number of downloads are never refunded.
+func revokeSend(metadata db.FileMetadata, totalDownloads int, userID string) {
+	if isMeteredSend(metadata) &&
+		totalDownloads != db.UnknownTotalDownloads &&
+		metadata.Downloads == totalDownloads &&
+		metadata.Length > 0 {
+		overhead := int64(metadata.Chunks * constants.TotalOverhead)
+		err := UpdateUserMeter(-int(metadata.Length-overhead), userID)
+		if err != nil {
+			log.Printf("Error refunding send usage: %v\n", err)
+		}
+	}
+
+	storage.DeleteFileByMetadata(metadata)
+}
+
+// resolveRecipients returns the user ID of each recipient of a send, which can
+// be provided as either an email address or an account ID. An error is
+// returned if any of the recipients don't exist.
+func resolveRecipients(recipients []shared.SendRecipient) ([]string, error) {
+	if len(recipients) > constants.MaxSendRecipients {
+		return nil, TooManyRecipientsError
+	}
+
+	var ids []string
+	for _, recipient := range recipients {
+		if len(recipient.User) == 0 || len(recipient.ProtectedKey) == 0 {
+			return nil, InvalidRecipientError
+		}
+
+		recipientID := recipient.User
+		if strings.Contains(recipient.User, "@") {
+			var err error
+			recipientID, err = db.GetUserIDByEmail(recipient.User)
+			if err != nil {
+				return nil, err
+			}
+		} else if _, err := db.GetUserByID(recipient.User); err != nil {
+			recipientID = ""
+		}
+
+		if len(recipientID) == 0 {
+			return nil, RecipientNotFoundError
+		}
+
+		ids = append(ids, recipientID)
+	}
+
+	return ids, nil
+}
+
+// getRecipientIDs resolves the recipients of a new send, writing an error to
+// the response if any of the recipients are invalid.
+func getRecipientIDs(
+	w http.ResponseWriter,
+	recipients []shared.SendRecipient,
+) ([]string, bool) {
+	ids, err := resolveRecipients(recipients)
+	if errors.Is(err, TooManyRecipientsError) ||
+		errors.Is(err, RecipientNotFoundError) ||
+		errors.Is(err, InvalidRecipientError) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	} else if err != nil {
+		log.Printf("Error resolving send recipients: %v\n", err)
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return nil, false
+	}
+
+	return ids, true
+}
+
+// addSendRecipients stores the protected key for each of the resolved
+// recipients of a send
+func addSendRecipients(id string, ids []string, recipients []shared.SendRecipient) error {
+	for i, recipientID := range ids {
+		err := db.AddSendRecipient(id, recipientID, recipients[i].ProtectedKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// canAccessSend checks if the current session is allowed to download a send.
+// Sends addressed to specific users can only be downloaded by those users or
+// the user who created the send.
+func canAccessSend(w http.ResponseWriter, req *http.Request, id string) bool {
+	var userID string
+	if session.IsValidSession(w, req) {
+		userID, _ = session.GetSessionAndUserID(req)
+	}
+
+	canAccess, err := db.CanAccessSend(id, userID)
+	if err != nil {
+		log.Printf("Error checking send access: %v\n", err)
+		return false
+	}
+
+	return canAccess
+}
@@ -10,6 +10,7 @@ import (
 	"yeetfile/backend/cache"
 	"yeetfile/backend/config"
 	"yeetfile/backend/db"
+	"yeetfile/backend/server/audit"
 	"yeetfile/backend/server/session"
 	"yeetfile/backend/server/transfer"
 	"yeetfile/backend/storage"
@@ -278,22 +279,10 @@ func UploadMetadataHandler(w http.ResponseWriter, req *http.Request, userID stri
 		return
 	}
 
-	err = db.CreateNewUpload(itemID, upload.Name)
+	err = initVaultUpload(itemID, userID, upload)
 	if err != nil {
 		log.Printf("Error initializing new upload: %v\n", err)
-		http.Error(w, "Server error", http.StatusInternalServerError)
-		return
-	}
-
-	if upload.Chunks == 1 {
-		err = storage.Interface.InitUpload(itemID)
-	} else {
-		err = storage.Interface.InitLargeUpload(upload.Name, itemID)
-	}
-
-	if err != nil {
 		http.Error(w, "Error initializing storage", http.StatusInternalServerError)
-		_ = db.DeleteVaultFile(itemID, userID)
 		return
 	}
 
@@ -381,6 +370,13 @@ func UploadDataHandler(w http.ResponseWriter, req *http.Request, userID string)
 	}
 
 	if finishedUploading {
+		if len(req.URL.Query().Get("request")) > 0 {
+			err = db.FinishFileRequestUpload(id)
+			if err != nil {
+				log.Printf("[YF Vault] Error finishing file request upload: %v\n", err)
+			}
+		}
+
 		_, _ = io.WriteString(w, id)
 	}
 }
@@ -521,6 +517,8 @@ func ShareHandler(isFolder bool) session.HandlerFunc {
 			var shareInfo shared.ShareInfo
 			shareInfo, shareErr = shareVaultItem(share, itemID, userID, isFolder)
 			if shareErr == nil {
+				audit.Record(req, userID, constants.EventShareGranted,
+					"shared with "+shareInfo.Recipient)
 				jsonData, _ := json.Marshal(shareInfo)
 				w.Header().Set("Content-Type", "application/json")
 				_, _ = w.Write(jsonData)
@@ -558,7 +556,10 @@ func ShareHandler(isFolder bool) session.HandlerFunc {
 			shareErr = db.RemoveShare(userID, itemID, shareID, isFolder)
 		}
 
-		if shareErr != nil {
+		if shareErr == db.BlockedSenderError {
+			http.Error(w, "This user isn't accepting shares from you", http.StatusForbidden)
+			return
+		} else if shareErr != nil {
 			log.Printf("Error with shared content: %v\n", shareErr)
 			http.Error(w, "Error with shared content", http.StatusBadRequest)
 			return
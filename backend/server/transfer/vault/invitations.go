@@ -0,0 +1,75 @@
+package vault
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"yeetfile/backend/db"
+	"yeetfile/backend/utils"
+	"yeetfile/shared"
+	"yeetfile/shared/constants"
+)
+
+// ShareInvitationsHandler returns the current user's pending invitations to
+// shared files and folders, as well as the users they've blocked from sharing
+func ShareInvitationsHandler(w http.ResponseWriter, _ *http.Request, userID string) {
+	invitations, err := db.GetShareInvitations(userID)
+	if err != nil {
+		log.Printf("Error fetching share invitations: %v\n", err)
+		http.Error(w, "Error fetching invitations", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(invitations)
+	if err != nil {
+		http.Error(w, "Error sending response", http.StatusInternalServerError)
+	}
+}
+
+// ShareInvitationHandler accepts, declines, or blocks the sender of a pending
+// invitation to a shared file or folder
+func ShareInvitationHandler(w http.ResponseWriter, req *http.Request, userID string) {
+	segments := strings.Split(req.URL.Path, "/")
+	id := segments[len(segments)-1]
+
+	var action shared.ShareInvitationAction
+	err := utils.LimitedJSONReader(w, req.Body).Decode(&action)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	switch action.Action {
+	case constants.InvitationAccept:
+		err = db.AcceptShareInvitation(userID, id)
+	case constants.InvitationDecline:
+		err = db.DeclineShareInvitation(userID, id)
+	case constants.InvitationBlock:
+		err = db.BlockShareSender(userID, id)
+	default:
+		http.Error(w, "Invalid action", http.StatusBadRequest)
+		return
+	}
+
+	if err == db.InvitationNotFoundError {
+		http.Error(w, "Invitation not found", http.StatusNotFound)
+	} else if err != nil {
+		log.Printf("Error updating share invitation: %v\n", err)
+		http.Error(w, "Error updating invitation", http.StatusInternalServerError)
+	}
+}
+
+// UnblockUserHandler allows a blocked user to share content with the current
+// user again
+func UnblockUserHandler(w http.ResponseWriter, req *http.Request, userID string) {
+	segments := strings.Split(req.URL.Path, "/")
+	id := segments[len(segments)-1]
+
+	err := db.UnblockUser(userID, id)
+	if err != nil {
+		log.Printf("Error unblocking user: %v\n", err)
+		http.Error(w, "Error unblocking user", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,128 @@
+package vault
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"yeetfile/backend/db"
+	"yeetfile/backend/server/session"
+	"yeetfile/backend/utils"
+	"yeetfile/shared"
+	"yeetfile/shared/constants"
+)
+
+// TransferOwnershipHandler starts transferring ownership of a file or folder in
+// the user's vault to another user. The transfer isn't completed until the
+// recipient accepts it.
+func TransferOwnershipHandler(isFolder bool) session.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request, userID string) {
+		segments := strings.Split(req.URL.Path, "/")
+		itemID := segments[len(segments)-1]
+
+		if len(itemID) != db.VaultIDLength {
+			http.Error(w, "Invalid item ID", http.StatusBadRequest)
+			return
+		}
+
+		var transferReq shared.OwnershipTransferRequest
+		err := utils.LimitedJSONReader(w, req.Body).Decode(&transferReq)
+		if err != nil {
+			http.Error(w, "Error decoding request", http.StatusBadRequest)
+			return
+		}
+
+		recipientID, userName, err := getRecipient(transferReq.User)
+		if err != nil {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		transferID, err := db.NewOwnershipTransfer(
+			userID,
+			recipientID,
+			itemID,
+			transferReq.ProtectedKey,
+			isFolder)
+		if err == db.BlockedSenderError {
+			http.Error(w, "This user isn't accepting shares from you", http.StatusForbidden)
+			return
+		} else if err == db.TransferPendingError || err == db.TransferItemError {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		} else if err != nil {
+			log.Printf("Error starting ownership transfer: %v\n", err)
+			http.Error(w, "Error starting ownership transfer", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(shared.OwnershipTransfer{
+			ID:       transferID,
+			UserName: userName,
+			IsFolder: isFolder,
+		})
+	}
+}
+
+// OwnershipTransfersHandler returns the pending ownership transfers that have
+// been offered to the user, as well as the ones they've started
+func OwnershipTransfersHandler(w http.ResponseWriter, _ *http.Request, userID string) {
+	transfers, err := db.GetOwnershipTransfers(userID)
+	if err != nil {
+		log.Printf("Error fetching ownership transfers: %v\n", err)
+		http.Error(w, "Error fetching ownership transfers", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(transfers)
+	if err != nil {
+		http.Error(w, "Error sending response", http.StatusInternalServerError)
+	}
+}
+
+// OwnershipTransferHandler allows the recipient of an ownership transfer to
+// accept or decline it (PUT), or the current owner to cancel it (DELETE)
+func OwnershipTransferHandler(w http.ResponseWriter, req *http.Request, userID string) {
+	segments := strings.Split(req.URL.Path, "/")
+	id := segments[len(segments)-1]
+
+	var err error
+	switch req.Method {
+	case http.MethodPut:
+		var action shared.ShareInvitationAction
+		err = utils.LimitedJSONReader(w, req.Body).Decode(&action)
+		if err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		switch action.Action {
+		case constants.InvitationAccept:
+			err = db.AcceptOwnershipTransfer(userID, id)
+		case constants.InvitationDecline:
+			err = db.DeclineOwnershipTransfer(userID, id)
+		default:
+			http.Error(w, "Invalid action", http.StatusBadRequest)
+			return
+		}
+	case http.MethodDelete:
+		err = db.CancelOwnershipTransfer(userID, id)
+	}
+
+	switch err {
+	case nil:
+		return
+	case db.TransferNotFoundError:
+		http.Error(w, "Ownership transfer not found", http.StatusNotFound)
+	case db.UserStorageExceeded:
+		http.Error(w, "Not enough storage available for the transferred item",
+			http.StatusForbidden)
+	case db.TransferItemError, db.PendingUploadsError:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		log.Printf("Error updating ownership transfer: %v\n", err)
+		http.Error(w, "Error updating ownership transfer", http.StatusInternalServerError)
+	}
+}
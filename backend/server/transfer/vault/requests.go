@@ -0,0 +1,288 @@
+package vault
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"yeetfile/backend/config"
+	"yeetfile/backend/db"
+	"yeetfile/backend/utils"
+	"yeetfile/shared"
+	"yeetfile/shared/constants"
+)
+
+var InvalidFileRequestFolderError = errors.New("invalid folder for file request")
+var FileRequestTooLargeError = errors.New("file exceeds the max size for this request")
+
+// FileRequestsHandler handles listing (GET) and creating (POST) file requests
+// for the current user
+func FileRequestsHandler(w http.ResponseWriter, req *http.Request, userID string) {
+	switch req.Method {
+	case http.MethodGet:
+		requests, err := db.GetFileRequests(userID)
+		if err != nil {
+			log.Printf("Error fetching file requests: %v\n", err)
+			http.Error(w, "Error fetching file requests", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		resp := shared.FileRequestListResponse{Requests: requests}
+		err = json.NewEncoder(w).Encode(resp)
+		if err != nil {
+			http.Error(w, "Error sending response", http.StatusInternalServerError)
+		}
+	case http.MethodPost:
+		var newRequest shared.NewFileRequest
+		err := utils.LimitedJSONReader(w, req.Body).Decode(&newRequest)
+		if err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		id, err := createFileRequest(userID, newRequest)
+		if err != nil {
+			log.Printf("Error creating file request: %v\n", err)
+			http.Error(w, "Error creating file request", http.StatusBadRequest)
+			return
+		}
+
+		err = json.NewEncoder(w).Encode(shared.MetadataUploadResponse{ID: id})
+		if err != nil {
+			http.Error(w, "Error sending response", http.StatusInternalServerError)
+		}
+	}
+}
+
+// DeleteFileRequestHandler deletes one of the user's file requests. Files
+// already uploaded with the request remain in the user's vault.
+func DeleteFileRequestHandler(w http.ResponseWriter, req *http.Request, userID string) {
+	segments := strings.Split(req.URL.Path, "/")
+	id := segments[len(segments)-1]
+
+	err := db.DeleteFileRequest(id, userID)
+	if err == db.FileRequestNotFoundError {
+		http.Error(w, "File request not found", http.StatusNotFound)
+	} else if err != nil {
+		log.Printf("Error deleting file request: %v\n", err)
+		http.Error(w, "Error deleting file request", http.StatusInternalServerError)
+	}
+}
+
+// FileRequestInfoHandler returns the public info for a file request, which
+// includes the public key of the request owner that files are encrypted with.
+// This doesn't require an account.
+func FileRequestInfoHandler(w http.ResponseWriter, req *http.Request) {
+	segments := strings.Split(req.URL.Path, "/")
+	id := segments[len(segments)-1]
+
+	request, ownerID, err := getOpenFileRequest(id)
+	if err != nil {
+		http.Error(w, "File request not found or expired", http.StatusNotFound)
+		return
+	}
+
+	pubKey, err := db.GetUserPubKey(ownerID)
+	if err != nil || len(pubKey) == 0 {
+		log.Printf("Error fetching file request public key: %v\n", err)
+		http.Error(w, "Error fetching file request", http.StatusInternalServerError)
+		return
+	}
+
+	remaining := -1
+	if request.MaxUploads > 0 {
+		remaining = request.MaxUploads - request.Uploads
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(shared.FileRequestInfo{
+		ID:         request.ID,
+		PublicKey:  pubKey,
+		MaxSize:    request.MaxSize,
+		Remaining:  remaining,
+		Expiration: request.Expiration,
+	})
+	if err != nil {
+		http.Error(w, "Error sending response", http.StatusInternalServerError)
+	}
+}
+
+// FileRequestUploadHandler initializes a new file upload into the vault folder
+// of a file request's owner. The file key must be encrypted with the owner's
+// public key. File chunks are then uploaded to the usual vault upload endpoint
+// with the request ID included as a query param.
+func FileRequestUploadHandler(w http.ResponseWriter, req *http.Request) {
+	segments := strings.Split(req.URL.Path, "/")
+	id := segments[len(segments)-2]
+
+	var upload shared.VaultUpload
+	err := utils.LimitedJSONReader(w, req.Body).Decode(&upload)
+	if err != nil || len(upload.PasswordData) > 0 {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	request, ownerID, err := getOpenFileRequest(id)
+	if err != nil {
+		http.Error(w, "File request not found or expired", http.StatusNotFound)
+		return
+	}
+
+	err = validateRequestUpload(request, upload)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = CanUserUpload(upload.Length, ownerID, request.FolderID)
+	if err != nil {
+		log.Printf("Error checking file request storage: %v\n", err)
+		http.Error(w, "Not enough storage available", http.StatusBadRequest)
+		return
+	}
+
+	err = db.IncrementFileRequestUploads(id)
+	if err == db.FileRequestLimitError {
+		http.Error(w, "File request upload limit reached", http.StatusBadRequest)
+		return
+	} else if err != nil {
+		log.Printf("Error updating file request uploads: %v\n", err)
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	upload.FolderID = request.FolderID
+	itemID, err := db.AddVaultItem(ownerID, upload)
+	if err != nil {
+		log.Printf("Error initializing file request upload: %v\n", err)
+		http.Error(w, "Error initializing upload", http.StatusBadRequest)
+		releaseRequestUpload(id)
+		return
+	}
+
+	// Keys for files in the root folder are always wrapped with the owner's
+	// public key, so only files in subfolders need to be re-wrapped later
+	isRootFolder := request.FolderID == ownerID
+	err = db.SetVaultItemRequest(itemID, id, !isRootFolder)
+	if err != nil {
+		log.Printf("Error setting file request for item: %v\n", err)
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		_ = db.DeleteVaultFile(itemID, ownerID)
+		releaseRequestUpload(id)
+		return
+	}
+
+	// initVaultUpload removes the vault item if it fails
+	err = initVaultUpload(itemID, ownerID, upload)
+	if err != nil {
+		log.Printf("Error initializing file request upload: %v\n", err)
+		http.Error(w, "Error initializing storage", http.StatusInternalServerError)
+		releaseRequestUpload(id)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(shared.MetadataUploadResponse{ID: itemID})
+	if err != nil {
+		http.Error(w, "Error sending response", http.StatusInternalServerError)
+	}
+}
+
+// releaseRequestUpload gives back an upload to a file request's limit after the
+// upload failed to initialize
+func releaseRequestUpload(id string) {
+	err := db.DecrementFileRequestUploads(id)
+	if err != nil {
+		log.Printf("Error releasing file request upload: %v\n", err)
+	}
+}
+
+// createFileRequest validates and creates a new file request for the user.
+// Requests can only be created for the user's root folder or folders that
+// they own.
+func createFileRequest(userID string, newRequest shared.NewFileRequest) (string, error) {
+	if newRequest.MaxUploads < 0 || newRequest.MaxSize < 0 {
+		return "", errors.New("invalid upload limits")
+	}
+
+	if len(newRequest.FolderID) == 0 || newRequest.FolderID == userID {
+		newRequest.FolderID = userID
+	} else {
+		folder, err := db.GetFolderInfo(
+			newRequest.FolderID,
+			userID,
+			shared.FolderOwnershipInfo{},
+			true)
+		if err != nil {
+			return "", err
+		} else if !folder.IsOwner || !folder.CanModify || folder.PasswordFolder {
+			return "", InvalidFileRequestFolderError
+		}
+	}
+
+	var expiration time.Time
+	if len(newRequest.Expiration) > 0 {
+		exp := utils.StrToDuration(newRequest.Expiration, config.IsDebugMode)
+		if exp <= 0 {
+			return "", errors.New("invalid expiration")
+		}
+
+		expiration = time.Now().Add(exp)
+	}
+
+	return db.CreateFileRequest(userID, newRequest, expiration)
+}
+
+// GetFileRequestUploadOwner returns the ID of the user who owns a file that is
+// being uploaded using a file request. The request must still be open, and the
+// file must not have finished uploading yet.
+func GetFileRequestUploadOwner(requestID, itemID string) (string, error) {
+	_, ownerID, err := getOpenFileRequest(requestID)
+	if err != nil {
+		return "", err
+	}
+
+	itemOwnerID, err := db.GetFileRequestItemOwner(requestID, itemID)
+	if err != nil {
+		return "", err
+	} else if itemOwnerID != ownerID {
+		return "", db.FileRequestNotFoundError
+	}
+
+	return ownerID, nil
+}
+
+// getOpenFileRequest returns a file request if it hasn't expired yet
+func getOpenFileRequest(id string) (shared.FileRequest, string, error) {
+	request, ownerID, err := db.GetFileRequest(id)
+	if err != nil {
+		return shared.FileRequest{}, "", err
+	}
+
+	if !request.Expiration.IsZero() && request.Expiration.Before(time.Now()) {
+		return shared.FileRequest{}, "", db.FileRequestLimitError
+	}
+
+	return request, ownerID, nil
+}
+
+// validateRequestUpload checks that a new file upload fits within the limits
+// of the file request. Since chunks can't exceed constants.ChunkSize, limiting
+// the number of chunks also limits the amount that can actually be uploaded.
+func validateRequestUpload(request shared.FileRequest, upload shared.VaultUpload) error {
+	if len(upload.Name) == 0 || len(upload.ProtectedKey) == 0 {
+		return errors.New("missing file name or key")
+	} else if upload.Length <= 0 || upload.Chunks <= 0 {
+		return errors.New("invalid file length")
+	} else if int64(upload.Chunks-1)*constants.ChunkSize >= upload.Length {
+		return errors.New("too many chunks for file length")
+	}
+
+	if request.MaxSize > 0 && upload.Length > request.MaxSize {
+		return FileRequestTooLargeError
+	}
+
+	return nil
+}
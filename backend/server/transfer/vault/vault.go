@@ -4,13 +4,24 @@ import (
 	"errors"
 	"log"
 	"strings"
+	"time"
+	"yeetfile/backend/config"
 	"yeetfile/backend/db"
+	"yeetfile/backend/server/transfer"
 	"yeetfile/backend/storage"
+	"yeetfile/backend/utils"
 	"yeetfile/shared"
 	"yeetfile/shared/constants"
 )
 
 func updateVaultFile(id, userID string, mod shared.ModifyVaultItem) error {
+	if len(mod.ProtectedKey) > 0 {
+		err := db.SetPendingItemKey(id, userID, mod.ProtectedKey)
+		if err != nil {
+			return err
+		}
+	}
+
 	if len(mod.Name) > 0 {
 		err := db.UpdateVaultFile(id, userID, mod)
 		if err != nil {
@@ -38,21 +49,19 @@ func shareVaultItem(
 	userID string,
 	isFolder bool,
 ) (shared.ShareInfo, error) {
-	var err error
-	var userName string
-	recipientID := share.User
-	if strings.Contains(share.User, "@") {
-		recipientID, err = db.GetUserIDByEmail(share.User)
-		if err != nil {
-			return shared.ShareInfo{}, err
-		}
-		userName = share.User
-	} else {
-		_, err = db.GetUserByID(share.User)
-		if err != nil {
-			return shared.ShareInfo{}, err
+	recipientID, userName, err := getRecipient(share.User)
+	if err != nil {
+		return shared.ShareInfo{}, err
+	}
+
+	var expiration time.Time
+	if len(share.Expiration) > 0 {
+		exp := utils.StrToDuration(share.Expiration, config.IsDebugMode)
+		if exp <= 0 {
+			return shared.ShareInfo{}, errors.New("invalid expiration")
 		}
-		userName = shared.FormatIDTail(share.User)
+
+		expiration = time.Now().UTC().Add(exp)
 	}
 
 	newShare := shared.NewSharedItem{
@@ -62,6 +71,7 @@ func shareVaultItem(
 		RecipientID:  recipientID,
 		ProtectedKey: share.ProtectedKey,
 		CanModify:    share.CanModify,
+		Expiration:   expiration,
 	}
 
 	var shareID string
@@ -73,12 +83,26 @@ func shareVaultItem(
 	}
 
 	return shared.ShareInfo{
-		ID:        shareID,
-		Recipient: userName,
-		CanModify: share.CanModify,
+		ID:         shareID,
+		Recipient:  userName,
+		CanModify:  share.CanModify,
+		Expiration: expiration,
 	}, shareErr
 }
 
+// getRecipient returns the user ID and display name of a user that content
+// is being shared with or transferred to, using either their email or
+// account ID
+func getRecipient(user string) (string, string, error) {
+	if strings.Contains(user, "@") {
+		recipientID, err := db.GetUserIDByEmail(user)
+		return recipientID, user, err
+	}
+
+	_, err := db.GetUserByID(user)
+	return user, shared.FormatIDTail(user), err
+}
+
 // DeleteVaultFolder recursively deletes the folder matching the specified
 // folder ID and all of its subfolders, returning the amount of freed space
 func DeleteVaultFolder(id, userID string, isShared, passVault bool) (int64, error) {
@@ -141,6 +165,7 @@ func DeleteVaultFolder(id, userID string, isShared, passVault bool) (int64, erro
 		return 0, err
 	}
 
+	_ = db.RemoveOwnershipTransferByItemID(id)
 	err = db.RemoveShareEntryByItemID(id)
 
 	return freed, err
@@ -178,27 +203,148 @@ func deleteVaultFile(id, userID string, isShared bool) (int64, error) {
 		return 0, errors.New("failed to delete")
 	}
 
+	// Storage is refunded to whoever was charged for the upload, which is
+	// the owner of the parent folder (a user or an organization)
 	totalUploadSize := metadata.Length - int64(constants.TotalOverhead*metadata.Chunks)
-	err = db.UpdateStorageUsed(userID, -totalUploadSize)
+	err = db.UpdateFolderOwnerStorage(metadata.FolderID, -totalUploadSize)
 	if err != nil {
 		log.Printf("Failed to update storage for user: %v\n", err)
 	}
 
 	_ = db.RemoveDownloadByFileID(id, userID)
+	_ = db.RemoveOwnershipTransferByItemID(id)
 	err = db.RemoveShareEntryByItemID(id)
 
 	return totalUploadSize, err
 }
 
+// initVaultUpload prepares storage for a new vault item's file chunks. If
+// storage can't be initialized, the vault item is removed.
+func initVaultUpload(itemID, userID string, upload shared.VaultUpload) error {
+	err := db.CreateNewUpload(itemID, upload.Name)
+	if err != nil {
+		_ = db.DeleteVaultFile(itemID, userID)
+		return err
+	}
+
+	if upload.Chunks == 1 {
+		err = storage.Interface.InitUpload(itemID)
+	} else {
+		err = storage.Interface.InitLargeUpload(upload.Name, itemID)
+	}
+
+	if err != nil {
+		_ = db.DeleteVaultFile(itemID, userID)
+		return err
+	}
+
+	return nil
+}
+
 func abortUpload(metadata db.FileMetadata, userID string, chunkLen int64, chunkNum int) {
 	storage.DeleteFileByMetadata(metadata)
-	totalSize := chunkLen
-	for chunkNum > 1 {
-		totalSize += int64(constants.ChunkSize)
-	}
+
+	// Chunks before the current one have already been added to the user's
+	// storage, but never more than the file's stated length
+	prevChunks := min(chunkNum, metadata.Chunks+1) - 1
+	totalSize := chunkLen + int64(max(prevChunks, 0))*int64(constants.ChunkSize)
+	totalSize = min(totalSize, metadata.Length)
 
 	err := db.UpdateStorageUsed(userID, -totalSize)
 	if err != nil {
 		log.Printf("Error adjusting user storage during abort: %v\n", err)
 	}
 }
+
+// ImportFile adds a file to the user's vault using data that is already
+// stored on the server (i.e. a send addressed to the user), without the
+// user needing to upload it again. Each chunk is read using readChunk and
+// is stored as-is, so the file key stays the same and only needs to be
+// wrapped with the key of the destination folder. Returns the ID of the new
+// vault file.
+func ImportFile(
+	userID string,
+	upload shared.VaultUpload,
+	readChunk func(chunk int) ([]byte, error),
+) (string, error) {
+	err := CanUserUpload(upload.Length, userID, upload.FolderID)
+	if err != nil {
+		return "", err
+	}
+
+	itemID, err := db.AddVaultItem(userID, upload)
+	if err != nil {
+		return "", err
+	}
+
+	err = initVaultUpload(itemID, userID, upload)
+	if err != nil {
+		return "", err
+	}
+
+	var stored int64
+	var metadata db.FileMetadata
+	for chunk := 1; chunk <= upload.Chunks; chunk++ {
+		metadata, err = db.RetrieveVaultMetadata(itemID, userID)
+		if err != nil {
+			break
+		}
+
+		var data []byte
+		data, err = readChunk(chunk)
+		if err != nil {
+			break
+		}
+
+		size := int64(len(data)) - int64(constants.TotalOverhead)
+		if metadata.OwnsParentFolder {
+			err = db.UpdateStorageUsed(userID, size)
+		} else {
+			err = db.UpdateFolderOwnerStorage(metadata.FolderID, size)
+		}
+
+		if err != nil {
+			break
+		}
+
+		stored += size
+		fileChunk, uploadValues, _ := transfer.PrepareUpload(metadata, chunk, data)
+		if upload.Chunks == 1 {
+			err = storage.Interface.UploadSingleChunk(fileChunk, uploadValues)
+		} else {
+			_, err = storage.Interface.UploadMultiChunk(fileChunk, uploadValues)
+		}
+
+		if err != nil {
+			break
+		}
+	}
+
+	if err != nil {
+		abortImport(itemID, userID, metadata, stored)
+		return "", err
+	}
+
+	return itemID, nil
+}
+
+// abortImport removes a partially imported file and refunds the storage that
+// was used by the chunks that were already stored
+func abortImport(itemID, userID string, metadata db.FileMetadata, stored int64) {
+	if len(metadata.ID) == 0 {
+		metadata = db.FileMetadata{ID: itemID}
+	}
+
+	storage.DeleteFileByMetadata(metadata)
+
+	var err error
+	if metadata.OwnsParentFolder || len(metadata.FolderID) == 0 {
+		err = db.UpdateStorageUsed(userID, -stored)
+	} else {
+		err = db.UpdateFolderOwnerStorage(metadata.FolderID, -stored)
+	}
+
+	if err != nil {
+		log.Printf("Error adjusting storage during import abort: %v\n", err)
+	}
+}
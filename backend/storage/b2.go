@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"context"
 	"errors"
 	"github.com/benbusby/b2"
 	"log"
@@ -209,6 +210,26 @@ func (b2Backend *B2) PartialDownloadById(remoteID, _ string, start, end int64) (
 	return b2Backend.client.PartialDownloadById(remoteID, start, end)
 }
 
+// Probe performs a lightweight request against the B2 bucket to verify that
+// the current authorization is still valid. For local storage, this only
+// checks that the storage directory is still accessible. The B2 library doesn't
+// accept a context, so callers are responsible for enforcing a timeout.
+func (b2Backend *B2) Probe(_ context.Context) error {
+	if b2Backend.local {
+		info, err := os.Stat(b2Backend.client.LocalPath)
+		if err != nil {
+			return err
+		} else if !info.IsDir() {
+			return errors.New("local storage path is not a directory")
+		}
+
+		return nil
+	}
+
+	_, err := b2Backend.client.ListNFiles(b2Backend.bucketID, 1)
+	return err
+}
+
 // =============================================================================
 
 // initLocalStorage configures the backblaze B2 Go library to store files locally
@@ -272,6 +272,16 @@ func (s3Backend *S3) PartialDownloadById(_, name string, start, end int64) ([]by
 	return buf.Bytes(), nil
 }
 
+// Probe verifies that the configured bucket is still reachable using the
+// current credentials.
+func (s3Backend *S3) Probe(ctx context.Context) error {
+	_, err := s3Backend.client.HeadBucket(ctx, &s3.HeadBucketInput{
+		Bucket: aws.String(s3Backend.bucketName),
+	})
+
+	return err
+}
+
 func initS3() storage {
 	var (
 		endpoint    = utils.GetEnvVar("YEETFILE_S3_ENDPOINT", "")
@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"context"
 	"errors"
 	"log"
 	"yeetfile/backend/cache"
@@ -24,6 +25,7 @@ type storage interface {
 	DeleteFile(remoteID, filename string) (bool, error)
 	FinishLargeUpload(remoteID, filename string, checksums []string) (string, int64, error)
 	PartialDownloadById(remoteID, filename string, start, end int64) ([]byte, error)
+	Probe(ctx context.Context) error
 }
 
 type FileChunk struct {
@@ -0,0 +1,389 @@
+package utils
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"log"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	envPrefix     = "YEETFILE_"
+	fileEnvSuffix = "_FILE"
+	redacted      = "********"
+
+	// ConfigFileEnvVar is the env var used for locating the (optional)
+	// server config file
+	ConfigFileEnvVar = envPrefix + "CONFIG"
+
+	// DebugServerSecret is the server secret used when one isn't provided.
+	// This is only acceptable to use in debug mode.
+	DebugServerSecret = "yeetfile-debug-secret-key-123456"
+)
+
+type ConfigVar struct {
+	Key    string
+	Secret bool
+}
+
+// ConfigVars is the full list of server configuration values that can be set
+// either in the environment or in the config file. Any key not in this list
+// is reported as unknown, since it's most likely a typo.
+var ConfigVars = []ConfigVar{
+	{Key: "YEETFILE_HOST"},
+	{Key: "YEETFILE_PORT"},
+	{Key: "YEETFILE_DEBUG"},
+	{Key: "YEETFILE_DOMAIN"},
+	{Key: "YEETFILE_STORAGE"},
+	{Key: "YEETFILE_LOCKDOWN"},
+	{Key: "YEETFILE_INSTANCE_ADMIN"},
+	{Key: "YEETFILE_ALLOW_INSECURE_LINKS"},
+	{Key: "YEETFILE_DEFAULT_MAX_PASSWORDS"},
+	{Key: "YEETFILE_DEFAULT_USER_STORAGE"},
+	{Key: "YEETFILE_DEFAULT_USER_SEND"},
+	{Key: "YEETFILE_MAX_TEXT_SEND_SIZE"},
+	{Key: "YEETFILE_MAX_NUM_USERS"},
+	{Key: "YEETFILE_LIMITER_SECONDS"},
+	{Key: "YEETFILE_LIMITER_ATTEMPTS"},
+	{Key: "YEETFILE_LOCKOUT_ATTEMPTS"},
+	{Key: "YEETFILE_LOCKOUT_SECONDS"},
+	{Key: "YEETFILE_LOCKOUT_MAX_SECONDS"},
+	{Key: "YEETFILE_UPGRADES_JSON"},
+	{Key: "YEETFILE_SERVER_PASSWORD", Secret: true},
+	{Key: "YEETFILE_SERVER_SECRET", Secret: true},
+	{Key: "YEETFILE_PREVIOUS_SERVER_SECRETS", Secret: true},
+	{Key: "YEETFILE_DISABLE_LEGACY_DECRYPTION"},
+	{Key: "YEETFILE_FALLBACK_WEB_SECRET", Secret: true},
+	{Key: "YEETFILE_SESSION_AUTH_KEY", Secret: true},
+	{Key: "YEETFILE_SESSION_ENC_KEY", Secret: true},
+	{Key: "YEETFILE_TLS_CERT"},
+	{Key: "YEETFILE_TLS_KEY", Secret: true},
+	{Key: "YEETFILE_TLS_CERT_PATH"},
+	{Key: "YEETFILE_TLS_KEY_PATH"},
+	{Key: "YEETFILE_TLS_CLIENT_CA"},
+	{Key: "YEETFILE_TLS_MIN_VERSION"},
+	{Key: "YEETFILE_TLS_CIPHERS"},
+	{Key: "YEETFILE_DB_HOST"},
+	{Key: "YEETFILE_DB_PORT"},
+	{Key: "YEETFILE_DB_USER"},
+	{Key: "YEETFILE_DB_PASS", Secret: true},
+	{Key: "YEETFILE_DB_NAME"},
+	{Key: "YEETFILE_DB_CERT"},
+	{Key: "YEETFILE_CACHE_DIR"},
+	{Key: "YEETFILE_CACHE_MAX_SIZE"},
+	{Key: "YEETFILE_CACHE_MAX_FILE_SIZE"},
+	{Key: "YEETFILE_LOCAL_STORAGE_PATH"},
+	{Key: "YEETFILE_LOCAL_STORAGE_LIMIT"},
+	{Key: "YEETFILE_B2_BUCKET_ID"},
+	{Key: "YEETFILE_B2_BUCKET_KEY_ID"},
+	{Key: "YEETFILE_B2_BUCKET_KEY", Secret: true},
+	{Key: "YEETFILE_S3_ENDPOINT"},
+	{Key: "YEETFILE_S3_BUCKET_NAME"},
+	{Key: "YEETFILE_S3_REGION_NAME"},
+	{Key: "YEETFILE_S3_ACCESS_KEY_ID"},
+	{Key: "YEETFILE_S3_SECRET_KEY", Secret: true},
+	{Key: "YEETFILE_EMAIL_ADDR"},
+	{Key: "YEETFILE_EMAIL_HOST"},
+	{Key: "YEETFILE_EMAIL_PORT"},
+	{Key: "YEETFILE_EMAIL_USER"},
+	{Key: "YEETFILE_EMAIL_PASSWORD", Secret: true},
+	{Key: "YEETFILE_EMAIL_NO_REPLY"},
+	{Key: "YEETFILE_STRIPE_KEY", Secret: true},
+	{Key: "YEETFILE_STRIPE_WEBHOOK_SECRET", Secret: true},
+	{Key: "YEETFILE_BTCPAY_WEBHOOK_SECRET", Secret: true},
+	{Key: "YEETFILE_OIDC_ISSUER"},
+	{Key: "YEETFILE_OIDC_CLIENT_ID"},
+	{Key: "YEETFILE_OIDC_CLIENT_SECRET", Secret: true},
+	{Key: "YEETFILE_OIDC_GROUPS_CLAIM"},
+	{Key: "YEETFILE_OIDC_ALLOWED_DOMAINS"},
+	{Key: "YEETFILE_OIDC_ALLOWED_GROUPS"},
+	{Key: "YEETFILE_OIDC_ADMIN_GROUPS"},
+	{Key: "YEETFILE_OIDC_ALLOW_MISSING_EMAIL_VERIFIED"},
+}
+
+// configSources maps each config key to where its value was loaded from
+// ("env", "file", or "secret file")
+var configSources = map[string]string{}
+
+// ParseConfigFile reads a YAML config file and returns a map of env var names
+// to values. Nested keys are joined with an underscore and prefixed with
+// "YEETFILE_", so that the following are equivalent:
+//
+//	db:
+//	  host: localhost
+//
+//	YEETFILE_DB_HOST: localhost
+func ParseConfigFile(path string) (map[string]string, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]any
+	if err = yaml.Unmarshal(contents, &raw); err != nil {
+		return nil, err
+	}
+
+	values := map[string]string{}
+	err = flattenConfig("", raw, values)
+	return values, err
+}
+
+// flattenConfig recursively converts nested config file values into env var
+// names and string values.
+func flattenConfig(prefix string, raw map[string]any, out map[string]string) error {
+	for key, value := range raw {
+		name := strings.ToUpper(key)
+		if len(prefix) > 0 {
+			name = prefix + "_" + name
+		} else if !strings.HasPrefix(name, envPrefix) {
+			name = envPrefix + name
+		}
+
+		switch v := value.(type) {
+		case map[string]any:
+			if err := flattenConfig(name, v, out); err != nil {
+				return err
+			}
+		case string:
+			out[name] = v
+		case int:
+			out[name] = strconv.Itoa(v)
+		case float64:
+			out[name] = strconv.FormatFloat(v, 'f', -1, 64)
+		case bool:
+			out[name] = strconv.FormatBool(v)
+		case nil:
+			out[name] = ""
+		default:
+			return fmt.Errorf("unsupported value for %s", name)
+		}
+	}
+
+	return nil
+}
+
+// IsKnownConfigKey returns true if the key (or the key without a "_FILE"
+// suffix) is a recognized server config value.
+func IsKnownConfigKey(key string) bool {
+	key = strings.TrimSuffix(key, fileEnvSuffix)
+	if key == ConfigFileEnvVar {
+		return true
+	}
+
+	return slices.ContainsFunc(ConfigVars, func(v ConfigVar) bool {
+		return v.Key == key
+	})
+}
+
+// applyConfigFile sets env vars from the config file values. Values that are
+// already set in the environment take precedence over the config file.
+func applyConfigFile(values map[string]string) {
+	for key, value := range values {
+		if _, exists := os.LookupEnv(key); exists {
+			continue
+		}
+
+		configSources[key] = "file"
+		_ = os.Setenv(key, value)
+	}
+}
+
+// applySecretFiles replaces any "YEETFILE_*_FILE" env var with the contents of
+// the file it points to (i.e. docker secrets). If both the "_FILE" variant and
+// the regular env var are set, the regular env var is used.
+func applySecretFiles() error {
+	for _, env := range os.Environ() {
+		key, path, _ := strings.Cut(env, "=")
+		if !strings.HasPrefix(key, envPrefix) || !strings.HasSuffix(key, fileEnvSuffix) {
+			continue
+		}
+
+		_ = os.Unsetenv(key)
+		base := strings.TrimSuffix(key, fileEnvSuffix)
+		if _, exists := os.LookupEnv(base); exists && configSources[base] != "file" {
+			continue
+		}
+
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("unable to read %s: %w", key, err)
+		}
+
+		configSources[base] = "secret file"
+		_ = os.Setenv(base, strings.TrimSpace(string(contents)))
+	}
+
+	return nil
+}
+
+// unknownConfigKeys returns all "YEETFILE_*" keys in the environment (which
+// includes values loaded from the config file) that aren't recognized.
+func unknownConfigKeys() []string {
+	var unknown []string
+	for _, env := range os.Environ() {
+		key, _, _ := strings.Cut(env, "=")
+		if strings.HasPrefix(key, envPrefix) && !IsKnownConfigKey(key) {
+			unknown = append(unknown, key)
+		}
+	}
+
+	sort.Strings(unknown)
+	return unknown
+}
+
+// validateConfig checks the current environment for values that are unsafe to
+// use outside of debug mode, or that are otherwise invalid.
+func validateConfig() []error {
+	var errs []error
+
+	debug := strings.ToLower(os.Getenv("YEETFILE_DEBUG"))
+	isDebug := debug == "1" || debug == "y" || debug == "true"
+
+	secret, exists := os.LookupEnv("YEETFILE_SERVER_SECRET")
+	decoded, err := base64.StdEncoding.DecodeString(secret)
+	if !exists || len(secret) == 0 {
+		if !isDebug {
+			errs = append(errs, errors.New(
+				"YEETFILE_SERVER_SECRET must be set when not in debug mode"))
+		}
+	} else if err != nil {
+		errs = append(errs, errors.New(
+			"YEETFILE_SERVER_SECRET must be a base64 encoded value"))
+	} else if string(decoded) == DebugServerSecret && !isDebug {
+		errs = append(errs, errors.New(
+			"YEETFILE_SERVER_SECRET is set to the debug secret"))
+	} else if len(decoded) != 32 {
+		errs = append(errs, fmt.Errorf(
+			"YEETFILE_SERVER_SECRET is %d bytes, but 32 bytes are required",
+			len(decoded)))
+	}
+
+	previous := os.Getenv("YEETFILE_PREVIOUS_SERVER_SECRETS")
+	for _, value := range strings.Split(previous, ",") {
+		value = strings.TrimSpace(value)
+		if len(value) == 0 {
+			continue
+		}
+
+		decoded, err = base64.StdEncoding.DecodeString(value)
+		if err != nil || len(decoded) != 32 {
+			errs = append(errs, errors.New(
+				"YEETFILE_PREVIOUS_SERVER_SECRETS must only contain "+
+					"32-byte, base64 encoded values"))
+			break
+		}
+	}
+
+	return errs
+}
+
+// printEffectiveConfig outputs every known config value along with where it
+// was loaded from. Secret values are redacted.
+func printEffectiveConfig() {
+	for _, configVar := range ConfigVars {
+		value, exists := os.LookupEnv(configVar.Key)
+		source := configSources[configVar.Key]
+		if !exists {
+			fmt.Printf("%s = (default)\n", configVar.Key)
+			continue
+		} else if len(source) == 0 {
+			source = "env"
+		}
+
+		if configVar.Secret && len(value) > 0 {
+			value = redacted
+		} else if strings.Contains(value, "\n") {
+			value = strings.Split(value, "\n")[0] + "..."
+		}
+
+		fmt.Printf("%s = %s (%s)\n", configVar.Key, value, source)
+	}
+}
+
+// runConfigCheck validates the config file and environment, and prints the
+// effective config. Returns the exit code for the "config check" command.
+func runConfigCheck(path string, fileErr error) int {
+	valid := true
+	if len(path) == 0 {
+		fmt.Println("No config file set, checking environment only")
+	} else if fileErr != nil {
+		fmt.Printf("ERROR: Unable to load %s: %v\n", path, fileErr)
+		return 1
+	} else {
+		fmt.Printf("Config file: %s\n", path)
+	}
+
+	for _, key := range unknownConfigKeys() {
+		fmt.Printf("ERROR: Unknown config key: %s\n", key)
+		valid = false
+	}
+
+	for _, err := range validateConfig() {
+		fmt.Printf("ERROR: %v\n", err)
+		valid = false
+	}
+
+	fmt.Println()
+	printEffectiveConfig()
+
+	if !valid {
+		return 1
+	}
+
+	fmt.Println("\nConfig OK")
+	return 0
+}
+
+// isConfigCheckCmd returns true if the server was started with the
+// "config check [path]" command, along with the optional path argument.
+func isConfigCheckCmd() (bool, string) {
+	if len(os.Args) < 3 || os.Args[1] != "config" || os.Args[2] != "check" {
+		return false, ""
+	} else if len(os.Args) > 3 {
+		return true, os.Args[3]
+	}
+
+	return true, ""
+}
+
+// init loads the config file (if any) before any other package reads values
+// from the environment. This also handles the "config check" command, since
+// it needs to run before packages like db and storage try to connect to
+// external services.
+func init() {
+	isCheck, checkPath := isConfigCheckCmd()
+	path := checkPath
+	if len(path) == 0 {
+		path = os.Getenv(ConfigFileEnvVar)
+	}
+
+	var fileErr error
+	if len(path) > 0 {
+		var values map[string]string
+		values, fileErr = ParseConfigFile(path)
+		if fileErr == nil {
+			applyConfigFile(values)
+		}
+	}
+
+	if fileErr == nil {
+		fileErr = applySecretFiles()
+	}
+
+	if isCheck {
+		os.Exit(runConfigCheck(path, fileErr))
+	} else if fileErr != nil {
+		log.Fatalf("Error loading server config: %v\n", fileErr)
+	}
+
+	for _, key := range unknownConfigKeys() {
+		log.Printf("WARNING: Unknown config key '%s' will be ignored\n", key)
+	}
+}
@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	contents := `
+storage: b2
+debug: true
+db:
+  host: localhost
+  port: 5432
+YEETFILE_DOMAIN: http://localhost:8090
+b2:
+  bucket_key_file: /run/secrets/b2
+`
+	err := os.WriteFile(path, []byte(contents), 0600)
+	assert.Nil(t, err)
+
+	values, err := ParseConfigFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "b2", values["YEETFILE_STORAGE"])
+	assert.Equal(t, "true", values["YEETFILE_DEBUG"])
+	assert.Equal(t, "localhost", values["YEETFILE_DB_HOST"])
+	assert.Equal(t, "5432", values["YEETFILE_DB_PORT"])
+	assert.Equal(t, "http://localhost:8090", values["YEETFILE_DOMAIN"])
+	assert.Equal(t, "/run/secrets/b2", values["YEETFILE_B2_BUCKET_KEY_FILE"])
+
+	for key := range values {
+		assert.True(t, IsKnownConfigKey(key), key)
+	}
+}
+
+func TestUnknownConfigKey(t *testing.T) {
+	assert.False(t, IsKnownConfigKey("YEETFILE_DB_HOTS"))
+	assert.False(t, IsKnownConfigKey("YEETFILE_STORAGE_FILE_FILE"))
+	assert.True(t, IsKnownConfigKey("YEETFILE_STORAGE_FILE"))
+}
+
+func TestSecretFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret")
+	err := os.WriteFile(path, []byte("hunter2\n"), 0600)
+	assert.Nil(t, err)
+
+	t.Setenv("YEETFILE_DB_PASS_FILE", path)
+	t.Setenv("YEETFILE_DB_PASS", "")
+	_ = os.Unsetenv("YEETFILE_DB_PASS")
+
+	err = applySecretFiles()
+	assert.Nil(t, err)
+	assert.Equal(t, "hunter2", os.Getenv("YEETFILE_DB_PASS"))
+
+	_, exists := os.LookupEnv("YEETFILE_DB_PASS_FILE")
+	assert.False(t, exists)
+}
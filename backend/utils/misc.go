@@ -54,6 +54,41 @@ func GetEnvVarBytesB64(key string, fallback []byte) []byte {
 	return decoded
 }
 
+// GetEnvVarBytesB64List retrieves a comma separated list of base64 strings from
+// the environment and returns each value as a []byte.
+func GetEnvVarBytesB64List(key string) [][]byte {
+	var values [][]byte
+	for _, value := range strings.Split(GetEnvVar(key, ""), ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			log.Fatalf("Error decoding %s (this should be a list of base64 values)", key)
+		}
+
+		values = append(values, decoded)
+	}
+
+	return values
+}
+
+// GetEnvVarList retrieves a comma separated list of strings from the
+// environment, ignoring empty values.
+func GetEnvVarList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(GetEnvVar(key, ""), ",") {
+		value = strings.TrimSpace(value)
+		if value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
+
 // GetEnvVarInt retrieves a string value from the environment and converts it
 // into an integer.
 func GetEnvVarInt(key string, fallback int) int {
@@ -251,6 +286,13 @@ func LimitedReader(w http.ResponseWriter, body io.ReadCloser) ([]byte, error) {
 	return limitedReader(w, body, 4096)
 }
 
+// LimitedSendMetadataReader reads the metadata for a new send, which can be
+// larger than other requests since it may include the encrypted manifest for
+// a multi-file send (base64 encoded in the request body).
+func LimitedSendMetadataReader(w http.ResponseWriter, body io.ReadCloser) ([]byte, error) {
+	return limitedReader(w, body, 4096+(constants.MaxSendManifestSize/3+1)*4)
+}
+
 func limitedReader(w http.ResponseWriter, body io.ReadCloser, limit int) ([]byte, error) {
 	limitedBody := http.MaxBytesReader(w, body, int64(limit))
 	return io.ReadAll(limitedBody)
@@ -260,6 +302,13 @@ func LimitedJSONReader(w http.ResponseWriter, body io.ReadCloser) *json.Decoder
 	return limitedJSONReader(w, body, 12288)
 }
 
+// LimitedOrgKeysJSONReader decodes requests containing keys that are wrapped
+// for each member, folder, or item in an organization. These grow with the
+// size of the organization, so the limit is higher than other JSON requests.
+func LimitedOrgKeysJSONReader(w http.ResponseWriter, body io.ReadCloser) *json.Decoder {
+	return limitedJSONReader(w, body, 4*1024*1024)
+}
+
 func limitedJSONReader(w http.ResponseWriter, body io.ReadCloser, limit int) *json.Decoder {
 	limitedBody := http.MaxBytesReader(w, body, int64(limit))
 	return json.NewDecoder(limitedBody)
@@ -54,6 +54,7 @@ func setupTestUser() TestUser {
 		ProtectedPrivateKey:     signupKeys.ProtectedPrivateKey,
 		PublicKey:               signupKeys.PublicKey,
 		ProtectedVaultFolderKey: signupKeys.ProtectedRootFolderKey,
+		KDF:                     signupKeys.KDF,
 	}
 
 	err = ctx.VerifyAccount(verifyAcct)
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 
 	"yeetfile/cli/requests"
 	"yeetfile/cli/utils"
@@ -14,6 +15,7 @@ import (
 
 var ServerPasswordError = errors.New("signup is password restricted on this server")
 var TwoFactorError = errors.New("two factor code missing or incorrect")
+var SSOError = errors.New("SSO login is missing, expired, or for a different email")
 
 // GetAccountInfo fetches the current user's account info
 func (ctx *Context) GetAccountInfo() (shared.AccountResponse, error) {
@@ -54,6 +56,27 @@ func (ctx *Context) GetAccountUsage() (shared.UsageResponse, error) {
 	return usageResponse, nil
 }
 
+// GetKDFParams fetches the key derivation params needed to derive a user's keys
+// before logging in. Params weaker than the legacy params are rejected, so
+// that the server can't weaken the user's keys.
+func (ctx *Context) GetKDFParams(identifier string) (shared.KDFParams, error) {
+	reqURL := endpoints.KDF.Format(ctx.Server) + "?identifier=" + url.QueryEscape(identifier)
+	resp, err := requests.GetRequest(ctx.Session, reqURL)
+	if err != nil {
+		return shared.KDFParams{}, err
+	} else if resp.StatusCode != http.StatusOK {
+		return shared.KDFParams{}, utils.ParseHTTPError(resp)
+	}
+
+	var params shared.KDFParams
+	err = json.NewDecoder(resp.Body).Decode(&params)
+	if err != nil {
+		return shared.KDFParams{}, err
+	}
+
+	return shared.ValidateKDFParams(params)
+}
+
 // Login logs a user into a YeetFile server, returning the server response,
 // the session cookie, and any errors.
 func (ctx *Context) Login(login shared.Login) (shared.LoginResponse, string, error) {
@@ -69,6 +92,8 @@ func (ctx *Context) Login(login shared.Login) (shared.LoginResponse, string, err
 	} else if resp.StatusCode != http.StatusOK {
 		if resp.StatusCode == http.StatusForbidden {
 			return shared.LoginResponse{}, "", TwoFactorError
+		} else if resp.StatusCode == http.StatusUnauthorized {
+			return shared.LoginResponse{}, "", SSOError
 		}
 		return shared.LoginResponse{}, "", utils.ParseHTTPError(resp)
 	}
@@ -130,6 +155,8 @@ func (ctx *Context) SubmitSignup(signup shared.Signup) (shared.SignupResponse, e
 	} else if response.StatusCode != http.StatusOK {
 		if response.StatusCode == http.StatusForbidden {
 			return shared.SignupResponse{}, ServerPasswordError
+		} else if response.StatusCode == http.StatusUnauthorized {
+			return shared.SignupResponse{}, SSOError
 		}
 		return shared.SignupResponse{}, utils.ParseHTTPError(response)
 	}
@@ -221,6 +248,132 @@ func (ctx *Context) GetUserProtectedKey() ([]byte, error) {
 	return protectedKey.ProtectedKey, err
 }
 
+// GetWrappedKeys returns every key that is encrypted with the user's public
+// key, along with the user's current key version
+func (ctx *Context) GetWrappedKeys() (shared.KeyRotationResponse, error) {
+	url := endpoints.KeyRotation.Format(ctx.Server)
+	resp, err := requests.GetRequest(ctx.Session, url)
+	if err != nil {
+		return shared.KeyRotationResponse{}, err
+	} else if resp.StatusCode != http.StatusOK {
+		return shared.KeyRotationResponse{}, utils.ParseHTTPError(resp)
+	}
+
+	var keys shared.KeyRotationResponse
+	err = json.NewDecoder(resp.Body).Decode(&keys)
+	return keys, err
+}
+
+// RotateKeys replaces the user's key pair and every key that was encrypted
+// with their previous public key. The server signs out the user's other
+// sessions afterwards, so the updated session is returned.
+func (ctx *Context) RotateKeys(rotation shared.KeyRotation) (string, error) {
+	reqData, err := json.Marshal(rotation)
+	if err != nil {
+		return "", err
+	}
+
+	url := endpoints.KeyRotation.Format(ctx.Server)
+	resp, err := requests.PutRequest(ctx.Session, url, reqData)
+	if err != nil {
+		return "", err
+	} else if resp.StatusCode != http.StatusOK {
+		return "", utils.ParseHTTPError(resp)
+	}
+
+	cookies := resp.Cookies()
+	if len(cookies) > 0 {
+		ctx.Session = cookies[0].Value
+	}
+
+	return ctx.Session, nil
+}
+
+// SetRecoveryKey sets or replaces the current user's recovery key
+func (ctx *Context) SetRecoveryKey(recoveryKey shared.SetRecoveryKey) error {
+	reqData, err := json.Marshal(recoveryKey)
+	if err != nil {
+		return err
+	}
+
+	url := endpoints.RecoveryKey.Format(ctx.Server)
+	resp, err := requests.PutRequest(ctx.Session, url, reqData)
+	if err != nil {
+		return err
+	} else if resp.StatusCode != http.StatusOK {
+		return utils.ParseHTTPError(resp)
+	}
+
+	return nil
+}
+
+// RemoveRecoveryKey removes the current user's recovery key
+func (ctx *Context) RemoveRecoveryKey(recoveryKey shared.RemoveRecoveryKey) error {
+	reqData, err := json.Marshal(recoveryKey)
+	if err != nil {
+		return err
+	}
+
+	url := endpoints.RecoveryKey.Format(ctx.Server)
+	resp, err := requests.DeleteRequest(ctx.Session, url, reqData)
+	if err != nil {
+		return err
+	} else if resp.StatusCode != http.StatusOK {
+		return utils.ParseHTTPError(resp)
+	}
+
+	return nil
+}
+
+// GetRecoveryProtectedKey returns the user's private key encrypted with their
+// recovery key, which is needed before the account can be recovered
+func (ctx *Context) GetRecoveryProtectedKey(
+	recoveryLogin shared.RecoveryKeyLogin,
+) ([]byte, error) {
+	reqData, err := json.Marshal(recoveryLogin)
+	if err != nil {
+		return nil, err
+	}
+
+	url := endpoints.Recover.Format(ctx.Server)
+	resp, err := requests.PostRequest(ctx.Session, url, reqData)
+	if err != nil {
+		return nil, err
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, utils.ParseHTTPError(resp)
+	}
+
+	var protectedKey shared.ProtectedKeyResponse
+	err = json.NewDecoder(resp.Body).Decode(&protectedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return protectedKey.ProtectedKey, nil
+}
+
+// RecoverAccount sets a new login for the user using their recovery key. If
+// the user has 2FA enabled and the code is missing or incorrect, TwoFactorError
+// is returned.
+func (ctx *Context) RecoverAccount(recoverAccount shared.RecoverAccount) error {
+	reqData, err := json.Marshal(recoverAccount)
+	if err != nil {
+		return err
+	}
+
+	url := endpoints.Recover.Format(ctx.Server)
+	resp, err := requests.PutRequest(ctx.Session, url, reqData)
+	if err != nil {
+		return err
+	} else if resp.StatusCode == http.StatusForbidden {
+		return TwoFactorError
+	} else if resp.StatusCode != http.StatusOK {
+		return utils.ParseHTTPError(resp)
+	}
+
+	return nil
+}
+
 // StartChangeEmail initiates the process for changing a user's email. If the
 // user doesn't have an email set, the response will contain the change ID
 // needed to confirm setting a new email. If they do have an email set, this
@@ -400,6 +553,117 @@ func (ctx *Context) Finalize2FA(totp shared.SetTOTP) (shared.SetTOTPResponse, er
 	return setTOTP, nil
 }
 
+// GetWebAuthnCredentials returns the security keys registered to the user's
+// account
+func (ctx *Context) GetWebAuthnCredentials() ([]shared.WebAuthnCredential, error) {
+	url := endpoints.WebAuthn.Format(ctx.Server)
+	resp, err := requests.GetRequest(ctx.Session, url)
+	if err != nil {
+		return nil, err
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, utils.ParseHTTPError(resp)
+	}
+
+	var credentials []shared.WebAuthnCredential
+	err = json.NewDecoder(resp.Body).Decode(&credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	return credentials, nil
+}
+
+// BeginWebAuthnRegistration requests the options for creating a new security
+// key credential with an authenticator
+func (ctx *Context) BeginWebAuthnRegistration() (shared.WebAuthnRegistrationOptions, error) {
+	url := endpoints.WebAuthnRegister.Format(ctx.Server)
+	resp, err := requests.GetRequest(ctx.Session, url)
+	if err != nil {
+		return shared.WebAuthnRegistrationOptions{}, err
+	} else if resp.StatusCode != http.StatusOK {
+		return shared.WebAuthnRegistrationOptions{}, utils.ParseHTTPError(resp)
+	}
+
+	var options shared.WebAuthnRegistrationOptions
+	err = json.NewDecoder(resp.Body).Decode(&options)
+	if err != nil {
+		return shared.WebAuthnRegistrationOptions{}, err
+	}
+
+	return options, nil
+}
+
+// FinishWebAuthnRegistration submits the authenticator's response to the
+// options from BeginWebAuthnRegistration. If the security key is the user's
+// first second factor, the response includes one-time recovery codes.
+func (ctx *Context) FinishWebAuthnRegistration(
+	registration shared.WebAuthnRegistration,
+) (shared.WebAuthnRegistrationResponse, error) {
+	url := endpoints.WebAuthnRegister.Format(ctx.Server)
+	reqData, err := json.Marshal(registration)
+	if err != nil {
+		return shared.WebAuthnRegistrationResponse{}, err
+	}
+
+	resp, err := requests.PostRequest(ctx.Session, url, reqData)
+	if err != nil {
+		return shared.WebAuthnRegistrationResponse{}, err
+	} else if resp.StatusCode != http.StatusOK {
+		return shared.WebAuthnRegistrationResponse{}, utils.ParseHTTPError(resp)
+	}
+
+	var response shared.WebAuthnRegistrationResponse
+	err = json.NewDecoder(resp.Body).Decode(&response)
+	if err != nil {
+		return shared.WebAuthnRegistrationResponse{}, err
+	}
+
+	return response, nil
+}
+
+// DeleteWebAuthnCredential removes one of the user's security keys, using the
+// user's TOTP or recovery code to confirm the removal
+func (ctx *Context) DeleteWebAuthnCredential(id, code string) error {
+	endpoint := endpoints.WebAuthnKey.Format(ctx.Server, id)
+	url := fmt.Sprintf("%s?code=%s", endpoint, code)
+	resp, err := requests.DeleteRequest(ctx.Session, url, nil)
+	if err != nil {
+		return err
+	} else if resp.StatusCode != http.StatusOK {
+		return utils.ParseHTTPError(resp)
+	}
+
+	return nil
+}
+
+// GetWebAuthnAssertionOptions requests a challenge for logging in with one of
+// the user's security keys. The resulting assertion is passed to Login in
+// place of a 2FA code.
+func (ctx *Context) GetWebAuthnAssertionOptions(
+	request shared.WebAuthnAssertionRequest,
+) (shared.WebAuthnAssertionOptions, error) {
+	url := endpoints.WebAuthnAssert.Format(ctx.Server)
+	reqData, err := json.Marshal(request)
+	if err != nil {
+		return shared.WebAuthnAssertionOptions{}, err
+	}
+
+	resp, err := requests.PostRequest(ctx.Session, url, reqData)
+	if err != nil {
+		return shared.WebAuthnAssertionOptions{}, err
+	} else if resp.StatusCode != http.StatusOK {
+		return shared.WebAuthnAssertionOptions{}, utils.ParseHTTPError(resp)
+	}
+
+	var options shared.WebAuthnAssertionOptions
+	err = json.NewDecoder(resp.Body).Decode(&options)
+	if err != nil {
+		return shared.WebAuthnAssertionOptions{}, err
+	}
+
+	return options, nil
+}
+
 // RecyclePaymentID frees the user's current payment ID and grants them a new one.
 func (ctx *Context) RecyclePaymentID() error {
 	url := endpoints.RecyclePaymentID.Format(ctx.Server)
@@ -412,3 +676,41 @@ func (ctx *Context) RecyclePaymentID() error {
 
 	return nil
 }
+
+// GetSecurityLog returns the user's recent security events, and whether they
+// receive an email when logging in from a new device or IP address
+func (ctx *Context) GetSecurityLog() (shared.SecurityLog, error) {
+	url := endpoints.SecurityLog.Format(ctx.Server)
+	resp, err := requests.GetRequest(ctx.Session, url)
+	if err != nil {
+		return shared.SecurityLog{}, err
+	} else if resp.StatusCode != http.StatusOK {
+		return shared.SecurityLog{}, utils.ParseHTTPError(resp)
+	}
+
+	var securityLog shared.SecurityLog
+	err = json.NewDecoder(resp.Body).Decode(&securityLog)
+	if err != nil {
+		return shared.SecurityLog{}, err
+	}
+
+	return securityLog, nil
+}
+
+// SetSecuritySettings updates the user's security settings
+func (ctx *Context) SetSecuritySettings(settings shared.SecuritySettings) error {
+	reqData, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+
+	url := endpoints.SecurityLog.Format(ctx.Server)
+	resp, err := requests.PutRequest(ctx.Session, url, reqData)
+	if err != nil {
+		return err
+	} else if resp.StatusCode != http.StatusOK {
+		return utils.ParseHTTPError(resp)
+	}
+
+	return nil
+}
@@ -3,7 +3,11 @@
 package api
 
 import (
+	"github.com/stretchr/testify/assert"
 	"testing"
+	"yeetfile/cli/crypto"
+	"yeetfile/shared"
+	"yeetfile/shared/constants"
 )
 
 func TestValidSessions(t *testing.T) {
@@ -20,4 +24,278 @@ func TestValidSessions(t *testing.T) {
 
 func TestChangeEmail(t *testing.T) {
 
-}
\ No newline at end of file
+}
+
+func TestRotateKeys(t *testing.T) {
+	user := setupTestUser()
+	defer cleanUpUserAccount(user)
+
+	fileID, err := uploadRandomFile(user, "", nil)
+	assert.Nil(t, err)
+
+	meta, _ := user.context.GetVaultItemMetadata(fileID)
+	fileKey, _ := crypto.DecryptWithPrivateKey(user.privKey, meta.ProtectedKey)
+
+	// Pending share invitations are encrypted with the recipient's public
+	// key, and need to be rotated as well
+	sharedID, _ := uploadRandomFile(UserA, "", nil)
+	sharedMeta, _ := UserA.context.GetVaultItemMetadata(sharedID)
+	sharedKey, _ := crypto.DecryptWithPrivateKey(UserA.privKey, sharedMeta.ProtectedKey)
+	request, err := prepSharedContent(UserA, sharedKey, false, user.id)
+	assert.Nil(t, err)
+
+	share, err := UserA.context.ShareFileWithUser(request, sharedID)
+	assert.Nil(t, err)
+
+	wrappedKeys, err := user.context.GetWrappedKeys()
+	assert.Nil(t, err)
+	assert.Len(t, wrappedKeys.Keys.Folders, 1)
+	assert.Len(t, wrappedKeys.Keys.Items, 1)
+	assert.Len(t, wrappedKeys.Keys.Shares, 1)
+
+	kdf := shared.DefaultKDFParams()
+	userKey, loginKeyHash := crypto.GenerateUserKeys(user.id, userPassword, kdf)
+	newPrivKey, newPubKey, err := crypto.GenerateKeyPair()
+	assert.Nil(t, err)
+
+	newProtectedKey, _ := crypto.EncryptChunk(userKey, newPrivKey)
+	rewrap := func(keys []shared.WrappedKey) []shared.WrappedKey {
+		rewrapped := []shared.WrappedKey{}
+		for _, key := range keys {
+			decrypted, err := crypto.DecryptWithPrivateKey(user.privKey, key.ProtectedKey)
+			assert.Nil(t, err)
+
+			encrypted, _ := crypto.EncryptWithPublicKey(newPubKey, decrypted)
+			rewrapped = append(rewrapped, shared.WrappedKey{
+				ID:           key.ID,
+				ProtectedKey: encrypted,
+			})
+		}
+
+		return rewrapped
+	}
+
+	rotation := shared.KeyRotation{
+		KeyVersion:   wrappedKeys.KeyVersion,
+		LoginKeyHash: loginKeyHash,
+		PublicKey:    newPubKey,
+		ProtectedKey: newProtectedKey,
+		Keys: shared.WrappedKeys{
+			Folders:   rewrap(wrappedKeys.Keys.Folders),
+			Items:     rewrap(wrappedKeys.Keys.Items),
+			Shares:    rewrap(wrappedKeys.Keys.Shares),
+			Transfers: rewrap(wrappedKeys.Keys.Transfers),
+			Sends:     rewrap(wrappedKeys.Keys.Sends),
+			Emergency: rewrap(wrappedKeys.Keys.Emergency),
+		},
+	}
+
+	// Rotations that leave out any keys should be rejected
+	incomplete := rotation
+	incomplete.Keys.Shares = []shared.WrappedKey{}
+	_, err = user.context.RotateKeys(incomplete)
+	assert.NotNil(t, err)
+
+	_, err = user.context.RotateKeys(rotation)
+	assert.Nil(t, err)
+
+	// The same rotation can't be applied again, since the key version
+	// has changed
+	_, err = user.context.RotateKeys(rotation)
+	assert.NotNil(t, err)
+
+	pubKey, err := UserA.context.FetchUserPubKey(user.id)
+	assert.Nil(t, err)
+	assert.Equal(t, newPubKey, pubKey.PublicKey)
+	assert.Equal(t, wrappedKeys.KeyVersion+1, pubKey.KeyVersion)
+
+	meta, _ = user.context.GetVaultItemMetadata(fileID)
+	decFileKey, err := crypto.DecryptWithPrivateKey(newPrivKey, meta.ProtectedKey)
+	assert.Nil(t, err)
+	assert.Equal(t, fileKey, decFileKey)
+
+	err = user.context.RespondToShareInvitation(share.ID, constants.InvitationAccept)
+	assert.Nil(t, err)
+
+	sharedMeta, err = user.context.GetVaultItemMetadata(sharedID)
+	assert.Nil(t, err)
+
+	decSharedKey, err := crypto.DecryptWithPrivateKey(newPrivKey, sharedMeta.ProtectedKey)
+	assert.Nil(t, err)
+	assert.Equal(t, sharedKey, decSharedKey)
+}
+
+func TestRecoveryKey(t *testing.T) {
+	user := setupTestUser()
+	defer cleanUpUserAccount(user)
+
+	phrase, err := crypto.GenerateRecoveryPhrase()
+	assert.Nil(t, err)
+
+	recoveryKey, recoveryKeyHash, err := crypto.GenerateRecoveryKeys(phrase)
+	assert.Nil(t, err)
+
+	recoveryProtectedKey, _ := crypto.EncryptChunk(recoveryKey, user.privKey)
+	kdf := shared.DefaultKDFParams()
+	_, loginKeyHash := crypto.GenerateUserKeys(user.id, userPassword, kdf)
+	_, wrongLoginKeyHash := crypto.GenerateUserKeys(user.id, "wrong", kdf)
+
+	// Setting a recovery key requires the user's password
+	err = user.context.SetRecoveryKey(shared.SetRecoveryKey{
+		LoginKeyHash:    wrongLoginKeyHash,
+		RecoveryKeyHash: recoveryKeyHash,
+		ProtectedKey:    recoveryProtectedKey,
+	})
+	assert.NotNil(t, err)
+
+	err = user.context.SetRecoveryKey(shared.SetRecoveryKey{
+		LoginKeyHash:    loginKeyHash,
+		RecoveryKeyHash: recoveryKeyHash,
+		ProtectedKey:    recoveryProtectedKey,
+	})
+	assert.Nil(t, err)
+
+	account, err := user.context.GetAccountInfo()
+	assert.Nil(t, err)
+	assert.True(t, account.HasRecoveryKey)
+
+	// Recovery doesn't require a session, only the recovery phrase
+	ctx := InitContext(server, "")
+	otherPhrase, _ := crypto.GenerateRecoveryPhrase()
+	_, otherHash, _ := crypto.GenerateRecoveryKeys(otherPhrase)
+	_, err = ctx.GetRecoveryProtectedKey(shared.RecoveryKeyLogin{
+		Identifier:      user.id,
+		RecoveryKeyHash: otherHash,
+	})
+	assert.NotNil(t, err)
+
+	protectedKey, err := ctx.GetRecoveryProtectedKey(shared.RecoveryKeyLogin{
+		Identifier:      user.id,
+		RecoveryKeyHash: recoveryKeyHash,
+	})
+	assert.Nil(t, err)
+
+	privKey, err := crypto.DecryptChunk(recoveryKey, protectedKey)
+	assert.Nil(t, err)
+	assert.Equal(t, user.privKey, privKey)
+
+	newPassword := "new password"
+	newUserKey, newLoginKeyHash := crypto.GenerateUserKeys(user.id, newPassword, kdf)
+	newProtectedKey, _ := crypto.EncryptChunk(newUserKey, privKey)
+	err = ctx.RecoverAccount(shared.RecoverAccount{
+		Identifier:      user.id,
+		RecoveryKeyHash: recoveryKeyHash,
+		LoginKeyHash:    newLoginKeyHash,
+		ProtectedKey:    newProtectedKey,
+		KDF:             kdf,
+	})
+	assert.Nil(t, err)
+
+	// The old password should no longer work, and the new password should
+	// return the same private key
+	_, _, err = user.context.Login(shared.Login{
+		Identifier:   user.id,
+		LoginKeyHash: loginKeyHash,
+	})
+	assert.NotNil(t, err)
+
+	login, _, err := user.context.Login(shared.Login{
+		Identifier:   user.id,
+		LoginKeyHash: newLoginKeyHash,
+	})
+	assert.Nil(t, err)
+
+	privKey, err = crypto.DecryptChunk(newUserKey, login.ProtectedKey)
+	assert.Nil(t, err)
+	assert.Equal(t, user.privKey, privKey)
+
+	// Removing the recovery key requires the user's password
+	err = user.context.RemoveRecoveryKey(shared.RemoveRecoveryKey{
+		LoginKeyHash: loginKeyHash,
+	})
+	assert.NotNil(t, err)
+
+	err = user.context.RemoveRecoveryKey(shared.RemoveRecoveryKey{
+		LoginKeyHash: newLoginKeyHash,
+	})
+	assert.Nil(t, err)
+
+	_, err = ctx.GetRecoveryProtectedKey(shared.RecoveryKeyLogin{
+		Identifier:      user.id,
+		RecoveryKeyHash: recoveryKeyHash,
+	})
+	assert.NotNil(t, err)
+}
+
+func TestKDFParams(t *testing.T) {
+	user := setupTestUser()
+	defer cleanUpUserAccount(user)
+
+	// Params are available before logging in
+	ctx := InitContext(server, "")
+	kdf, err := ctx.GetKDFParams(user.id)
+	assert.Nil(t, err)
+	assert.Equal(t, shared.DefaultKDFParams(), kdf)
+
+	// Unknown users receive the default params
+	unknown, err := ctx.GetKDFParams("unknown@example.com")
+	assert.Nil(t, err)
+	assert.Equal(t, shared.DefaultKDFParams(), unknown)
+
+	userKey, loginKeyHash := crypto.GenerateUserKeys(user.id, userPassword, kdf)
+
+	// Params weaker than the legacy params are rejected
+	legacy := shared.LegacyKDFParams()
+	legacyUserKey, legacyLoginKeyHash := crypto.GenerateUserKeys(
+		user.id,
+		userPassword,
+		legacy)
+	legacyProtectedKey, _ := crypto.EncryptChunk(legacyUserKey, user.privKey)
+	err = user.context.ChangePassword(shared.ChangePassword{
+		OldLoginKeyHash: loginKeyHash,
+		NewLoginKeyHash: legacyLoginKeyHash,
+		ProtectedKey:    legacyProtectedKey,
+		KDF: shared.KDFParams{
+			Algorithm:  legacy.Algorithm,
+			Iterations: 1,
+			Memory:     1,
+		},
+	})
+	assert.NotNil(t, err)
+
+	// Requests without params are treated as using the legacy params
+	err = user.context.ChangePassword(shared.ChangePassword{
+		OldLoginKeyHash: loginKeyHash,
+		NewLoginKeyHash: legacyLoginKeyHash,
+		ProtectedKey:    legacyProtectedKey,
+	})
+	assert.Nil(t, err)
+
+	kdf, err = ctx.GetKDFParams(user.id)
+	assert.Nil(t, err)
+	assert.Equal(t, legacy, kdf)
+
+	// Upgrading the params keeps the same private key
+	protectedKey, _ := crypto.EncryptChunk(userKey, user.privKey)
+	err = user.context.ChangePassword(shared.ChangePassword{
+		OldLoginKeyHash: legacyLoginKeyHash,
+		NewLoginKeyHash: loginKeyHash,
+		ProtectedKey:    protectedKey,
+		KDF:             shared.DefaultKDFParams(),
+	})
+	assert.Nil(t, err)
+
+	kdf, err = ctx.GetKDFParams(user.id)
+	assert.Nil(t, err)
+	assert.Equal(t, shared.DefaultKDFParams(), kdf)
+
+	login, _, err := ctx.Login(shared.Login{
+		Identifier:   user.id,
+		LoginKeyHash: loginKeyHash,
+	})
+	assert.Nil(t, err)
+
+	privKey, err := crypto.DecryptChunk(userKey, login.ProtectedKey)
+	assert.Nil(t, err)
+	assert.Equal(t, user.privKey, privKey)
+}
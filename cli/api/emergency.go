@@ -0,0 +1,208 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"yeetfile/cli/requests"
+	"yeetfile/cli/utils"
+	"yeetfile/shared"
+	"yeetfile/shared/endpoints"
+)
+
+// GetEmergencyAccess fetches the user's emergency contacts, as well as the
+// users who have designated the user as an emergency contact
+func (ctx *Context) GetEmergencyAccess() (shared.EmergencyAccessResponse, error) {
+	url := endpoints.EmergencyAccess.Format(ctx.Server)
+	resp, err := requests.GetRequest(ctx.Session, url)
+	if err != nil {
+		return shared.EmergencyAccessResponse{}, err
+	} else if resp.StatusCode != http.StatusOK {
+		return shared.EmergencyAccessResponse{}, utils.ParseHTTPError(resp)
+	}
+
+	var access shared.EmergencyAccessResponse
+	err = json.NewDecoder(resp.Body).Decode(&access)
+	if err != nil {
+		return shared.EmergencyAccessResponse{}, err
+	}
+
+	return access, nil
+}
+
+// AddEmergencyContact designates another user as an emergency contact. The
+// protected key should be the user's private key encrypted with the contact's
+// public key.
+func (ctx *Context) AddEmergencyContact(
+	contact shared.NewEmergencyContact,
+) (shared.EmergencyAccess, error) {
+	reqData, err := json.Marshal(contact)
+	if err != nil {
+		return shared.EmergencyAccess{}, err
+	}
+
+	url := endpoints.EmergencyAccess.Format(ctx.Server)
+	resp, err := requests.PostRequest(ctx.Session, url, reqData)
+	if err != nil {
+		return shared.EmergencyAccess{}, err
+	} else if resp.StatusCode != http.StatusOK {
+		return shared.EmergencyAccess{}, utils.ParseHTTPError(resp)
+	}
+
+	var access shared.EmergencyAccess
+	err = json.NewDecoder(resp.Body).Decode(&access)
+	if err != nil {
+		return shared.EmergencyAccess{}, err
+	}
+
+	return access, nil
+}
+
+// UpdateEmergencyContactKey replaces the private key shared with an emergency
+// contact, which is required after the user rotates their keys
+func (ctx *Context) UpdateEmergencyContactKey(id string, protectedKey []byte) error {
+	reqData, err := json.Marshal(shared.UpdateEmergencyAccess{
+		ProtectedKey: protectedKey,
+	})
+	if err != nil {
+		return err
+	}
+
+	url := endpoints.EmergencyContact.Format(ctx.Server, id)
+	resp, err := requests.PutRequest(ctx.Session, url, reqData)
+	if err != nil {
+		return err
+	} else if resp.StatusCode != http.StatusOK {
+		return utils.ParseHTTPError(resp)
+	}
+
+	return nil
+}
+
+// RemoveEmergencyContact removes an emergency contact. This can be used by
+// either the user who added the contact, or the contact.
+func (ctx *Context) RemoveEmergencyContact(id string) error {
+	url := endpoints.EmergencyContact.Format(ctx.Server, id)
+	return deleteItem(ctx.Session, url)
+}
+
+// RequestEmergencyAccess requests access to the vault of a user who designated
+// the current user as an emergency contact. Access is granted once the user's
+// waiting period ends, unless they deny the request first.
+func (ctx *Context) RequestEmergencyAccess(id string) error {
+	url := endpoints.EmergencyRequest.Format(ctx.Server, id)
+	resp, err := requests.PostRequest(ctx.Session, url, nil)
+	if err != nil {
+		return err
+	} else if resp.StatusCode != http.StatusOK {
+		return utils.ParseHTTPError(resp)
+	}
+
+	return nil
+}
+
+// DenyEmergencyAccess denies a request for emergency access, or cancels the
+// request if used by the contact
+func (ctx *Context) DenyEmergencyAccess(id string) error {
+	url := endpoints.EmergencyRequest.Format(ctx.Server, id)
+	return deleteItem(ctx.Session, url)
+}
+
+// GetEmergencyAccessKey fetches the keys of a user who granted the current user
+// emergency access. The protected key is the user's private key encrypted with
+// the current user's public key.
+func (ctx *Context) GetEmergencyAccessKey(id string) (shared.EmergencyAccessKeyResponse, error) {
+	url := endpoints.EmergencyKey.Format(ctx.Server, id)
+	resp, err := requests.GetRequest(ctx.Session, url)
+	if err != nil {
+		return shared.EmergencyAccessKeyResponse{}, err
+	} else if resp.StatusCode != http.StatusOK {
+		return shared.EmergencyAccessKeyResponse{}, utils.ParseHTTPError(resp)
+	}
+
+	var key shared.EmergencyAccessKeyResponse
+	err = json.NewDecoder(resp.Body).Decode(&key)
+	if err != nil {
+		return shared.EmergencyAccessKeyResponse{}, err
+	}
+
+	return key, nil
+}
+
+// EmergencyTakeover replaces the login of a user who granted the current user
+// takeover access
+func (ctx *Context) EmergencyTakeover(id string, takeover shared.EmergencyTakeover) error {
+	reqData, err := json.Marshal(takeover)
+	if err != nil {
+		return err
+	}
+
+	url := endpoints.EmergencyTakeover.Format(ctx.Server, id)
+	resp, err := requests.PutRequest(ctx.Session, url, reqData)
+	if err != nil {
+		return err
+	} else if resp.StatusCode != http.StatusOK {
+		return utils.ParseHTTPError(resp)
+	}
+
+	return nil
+}
+
+// FetchEmergencyFolderContents fetches the contents of a folder in the vault
+// of a user who granted the current user emergency access. The ID can be left
+// empty to fetch the user's home folder.
+func (ctx *Context) FetchEmergencyFolderContents(
+	grantID,
+	id string,
+	isPassVault bool,
+) (shared.VaultFolderResponse, error) {
+	endpoint := endpoints.VaultFolder
+	if isPassVault {
+		endpoint = endpoints.PassFolder
+	}
+
+	url := EmergencyURL(endpoint.Format(ctx.Server, id), grantID)
+	resp, err := requests.GetRequest(ctx.Session, url)
+	if err != nil {
+		return shared.VaultFolderResponse{}, err
+	} else if resp.StatusCode != http.StatusOK {
+		return shared.VaultFolderResponse{}, utils.ParseHTTPError(resp)
+	}
+
+	var folderResp shared.VaultFolderResponse
+	err = json.NewDecoder(resp.Body).Decode(&folderResp)
+	if err != nil {
+		return shared.VaultFolderResponse{}, err
+	}
+
+	return folderResp, nil
+}
+
+// GetEmergencyItemMetadata fetches the metadata needed to download a file from
+// the vault of a user who granted the current user emergency access
+func (ctx *Context) GetEmergencyItemMetadata(
+	grantID,
+	id string,
+) (shared.VaultDownloadResponse, error) {
+	url := EmergencyURL(endpoints.DownloadVaultFileMetadata.Format(ctx.Server, id), grantID)
+	resp, err := requests.GetRequest(ctx.Session, url)
+	if err != nil {
+		return shared.VaultDownloadResponse{}, err
+	} else if resp.StatusCode != http.StatusOK {
+		return shared.VaultDownloadResponse{}, utils.ParseHTTPError(resp)
+	}
+
+	var metadata shared.VaultDownloadResponse
+	err = json.NewDecoder(resp.Body).Decode(&metadata)
+	if err != nil {
+		return shared.VaultDownloadResponse{}, err
+	}
+
+	return metadata, nil
+}
+
+// EmergencyURL adds an emergency access grant ID to a vault URL, which allows
+// read-only access to the vault of the user who granted access
+func EmergencyURL(endpointURL, grantID string) string {
+	return endpointURL + "?emergency=" + url.QueryEscape(grantID)
+}
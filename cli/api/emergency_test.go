@@ -0,0 +1,228 @@
+//go:build server_test
+
+package api
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+	"yeetfile/backend/crypto/webauthntest"
+	"yeetfile/cli/crypto"
+	"yeetfile/shared"
+	"yeetfile/shared/constants"
+)
+
+func TestEmergencyAccess(t *testing.T) {
+	owner := setupTestUser()
+	defer cleanUpUserAccount(owner)
+
+	resp, err := owner.context.FetchUserPubKey(UserB.id)
+	assert.Nil(t, err)
+
+	protectedKey, err := crypto.EncryptWithPublicKey(resp.PublicKey, owner.privKey)
+	assert.Nil(t, err)
+
+	contact := shared.NewEmergencyContact{
+		User:         UserB.id,
+		AccessType:   constants.EmergencyAccessTakeover,
+		WaitDays:     constants.MaxEmergencyWaitDays + 1,
+		ProtectedKey: protectedKey,
+	}
+
+	// Waiting periods are limited
+	_, err = owner.context.AddEmergencyContact(contact)
+	assert.NotNil(t, err)
+
+	contact.WaitDays = 7
+	access, err := owner.context.AddEmergencyContact(contact)
+	assert.Nil(t, err)
+
+	_, err = owner.context.AddEmergencyContact(contact)
+	assert.NotNil(t, err) // Already a contact
+
+	ownerAccess, err := owner.context.GetEmergencyAccess()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(ownerAccess.Contacts))
+	assert.Equal(t, 0, len(ownerAccess.Grantors))
+	assert.Equal(t, access.ID, ownerAccess.Contacts[0].ID)
+	assert.Equal(t, UserB.pubKey, ownerAccess.Contacts[0].PublicKey)
+	assert.True(t, ownerAccess.Contacts[0].HasKey)
+
+	contactAccess, err := UserB.context.GetEmergencyAccess()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(contactAccess.Grantors))
+	assert.Equal(t, constants.EmergencyStatusIdle, contactAccess.Grantors[0].Status)
+
+	// Only the contact can request access
+	err = owner.context.RequestEmergencyAccess(access.ID)
+	assert.NotNil(t, err)
+
+	err = UserB.context.RequestEmergencyAccess(access.ID)
+	assert.Nil(t, err)
+
+	contactAccess, err = UserB.context.GetEmergencyAccess()
+	assert.Nil(t, err)
+	assert.Equal(t, constants.EmergencyStatusRequested, contactAccess.Grantors[0].Status)
+
+	// Access isn't granted until the waiting period ends
+	_, err = UserB.context.GetEmergencyAccessKey(access.ID)
+	assert.NotNil(t, err)
+
+	_, err = UserB.context.FetchEmergencyFolderContents(access.ID, "", false)
+	assert.NotNil(t, err)
+
+	err = UserB.context.EmergencyTakeover(access.ID, shared.EmergencyTakeover{
+		LoginKeyHash: []byte("login"),
+		ProtectedKey: []byte("key"),
+	})
+	assert.NotNil(t, err)
+
+	// Other users can't use the grant
+	_, err = UserA.context.FetchEmergencyFolderContents(access.ID, "", false)
+	assert.NotNil(t, err)
+
+	err = owner.context.DenyEmergencyAccess(access.ID)
+	assert.Nil(t, err)
+
+	contactAccess, err = UserB.context.GetEmergencyAccess()
+	assert.Nil(t, err)
+	assert.Equal(t, constants.EmergencyStatusIdle, contactAccess.Grantors[0].Status)
+
+	// Rotating the owner's keys removes the key shared with the contact
+	wrappedKeys, err := owner.context.GetWrappedKeys()
+	assert.Nil(t, err)
+
+	kdf := shared.DefaultKDFParams()
+	userKey, loginKeyHash := crypto.GenerateUserKeys(owner.id, userPassword, kdf)
+	newPrivKey, newPubKey, err := crypto.GenerateKeyPair()
+	assert.Nil(t, err)
+
+	newProtectedKey, _ := crypto.EncryptChunk(userKey, newPrivKey)
+	rewrap := func(keys []shared.WrappedKey) []shared.WrappedKey {
+		rewrapped := []shared.WrappedKey{}
+		for _, key := range keys {
+			decrypted, err := crypto.DecryptWithPrivateKey(owner.privKey, key.ProtectedKey)
+			assert.Nil(t, err)
+
+			encrypted, err := crypto.EncryptWithPublicKey(newPubKey, decrypted)
+			assert.Nil(t, err)
+
+			rewrapped = append(rewrapped, shared.WrappedKey{
+				ID:           key.ID,
+				ProtectedKey: encrypted,
+			})
+		}
+
+		return rewrapped
+	}
+
+	_, err = owner.context.RotateKeys(shared.KeyRotation{
+		KeyVersion:   wrappedKeys.KeyVersion,
+		LoginKeyHash: loginKeyHash,
+		PublicKey:    newPubKey,
+		ProtectedKey: newProtectedKey,
+		Keys: shared.WrappedKeys{
+			Folders:   rewrap(wrappedKeys.Keys.Folders),
+			Items:     rewrap(wrappedKeys.Keys.Items),
+			Shares:    rewrap(wrappedKeys.Keys.Shares),
+			Transfers: rewrap(wrappedKeys.Keys.Transfers),
+			Sends:     rewrap(wrappedKeys.Keys.Sends),
+			Emergency: rewrap(wrappedKeys.Keys.Emergency),
+		},
+	})
+	assert.Nil(t, err)
+
+	ownerAccess, err = owner.context.GetEmergencyAccess()
+	assert.Nil(t, err)
+	assert.False(t, ownerAccess.Contacts[0].HasKey)
+
+	protectedKey, err = crypto.EncryptWithPublicKey(resp.PublicKey, newPrivKey)
+	assert.Nil(t, err)
+
+	err = owner.context.UpdateEmergencyContactKey(access.ID, protectedKey)
+	assert.Nil(t, err)
+
+	ownerAccess, err = owner.context.GetEmergencyAccess()
+	assert.Nil(t, err)
+	assert.True(t, ownerAccess.Contacts[0].HasKey)
+
+	// Contacts can't replace the owner's key
+	err = UserB.context.UpdateEmergencyContactKey(access.ID, protectedKey)
+	assert.NotNil(t, err)
+
+	// Either user can remove the contact
+	err = UserB.context.RemoveEmergencyContact(access.ID)
+	assert.Nil(t, err)
+
+	ownerAccess, err = owner.context.GetEmergencyAccess()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(ownerAccess.Contacts))
+}
+
+func TestEmergencyTakeover(t *testing.T) {
+	owner := setupTestUser()
+	defer func() {
+		cleanUpUserAccount(owner)
+	}()
+
+	// Security keys can't be used by the contact, so they need to be removed
+	// along with the rest of the owner's 2FA
+	options, err := owner.context.BeginWebAuthnRegistration()
+	assert.Nil(t, err)
+
+	_, err = owner.context.FinishWebAuthnRegistration(createCredential(
+		webauthntest.NewES256Authenticator(),
+		"Security key",
+		options))
+	assert.Nil(t, err)
+
+	resp, err := owner.context.FetchUserPubKey(UserB.id)
+	assert.Nil(t, err)
+
+	protectedKey, err := crypto.EncryptWithPublicKey(resp.PublicKey, owner.privKey)
+	assert.Nil(t, err)
+
+	access, err := owner.context.AddEmergencyContact(shared.NewEmergencyContact{
+		User:         UserB.id,
+		AccessType:   constants.EmergencyAccessTakeover,
+		WaitDays:     1,
+		ProtectedKey: protectedKey,
+	})
+	assert.Nil(t, err)
+
+	err = UserB.context.RequestEmergencyAccess(access.ID)
+	assert.Nil(t, err)
+
+	// Waiting periods are counted in seconds in debug mode
+	time.Sleep(2 * time.Second)
+
+	kdf := shared.DefaultKDFParams()
+	newPassword := "emergency-password"
+	userKey, loginKeyHash := crypto.GenerateUserKeys(owner.id, newPassword, kdf)
+	newProtectedKey, err := crypto.EncryptChunk(userKey, owner.privKey)
+	assert.Nil(t, err)
+
+	err = UserB.context.EmergencyTakeover(access.ID, shared.EmergencyTakeover{
+		LoginKeyHash: loginKeyHash,
+		ProtectedKey: newProtectedKey,
+		KDF:          kdf,
+	})
+	assert.Nil(t, err)
+
+	// The owner's existing session is revoked, and the new password can be
+	// used without a security key
+	_, err = owner.context.GetWebAuthnCredentials()
+	assert.NotNil(t, err)
+
+	ctx := InitContext(server, "")
+	_, _, err = ctx.Login(shared.Login{
+		Identifier:   owner.id,
+		LoginKeyHash: loginKeyHash,
+	})
+	assert.Nil(t, err)
+	owner.context = ctx
+
+	credentials, err := owner.context.GetWebAuthnCredentials()
+	assert.Nil(t, err)
+	assert.Empty(t, credentials)
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"yeetfile/cli/requests"
+	"yeetfile/cli/utils"
+	"yeetfile/shared"
+	"yeetfile/shared/endpoints"
+)
+
+// GetFileRequests fetches the list of file requests created by the user
+func (ctx *Context) GetFileRequests() ([]shared.FileRequest, error) {
+	url := endpoints.FileRequests.Format(ctx.Server)
+	resp, err := requests.GetRequest(ctx.Session, url)
+	if err != nil {
+		return nil, err
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, utils.ParseHTTPError(resp)
+	}
+
+	var listResponse shared.FileRequestListResponse
+	err = json.NewDecoder(resp.Body).Decode(&listResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return listResponse.Requests, nil
+}
+
+// CreateFileRequest creates a new file request link for uploading files into
+// one of the user's vault folders. Returns the new request ID.
+func (ctx *Context) CreateFileRequest(request shared.NewFileRequest) (string, error) {
+	reqData, err := json.Marshal(request)
+	if err != nil {
+		return "", err
+	}
+
+	url := endpoints.FileRequests.Format(ctx.Server)
+	resp, err := requests.PostRequest(ctx.Session, url, reqData)
+	if err != nil {
+		return "", err
+	} else if resp.StatusCode != http.StatusOK {
+		return "", utils.ParseHTTPError(resp)
+	}
+
+	var createResponse shared.MetadataUploadResponse
+	err = json.NewDecoder(resp.Body).Decode(&createResponse)
+	if err != nil {
+		return "", err
+	}
+
+	return createResponse.ID, nil
+}
+
+// DeleteFileRequest removes one of the user's file requests
+func (ctx *Context) DeleteFileRequest(id string) error {
+	url := endpoints.FileRequest.Format(ctx.Server, id)
+	return deleteItem(ctx.Session, url)
+}
+
+// FetchFileRequestInfo fetches the public info for a file request, which
+// doesn't require the user to be logged in
+func (ctx *Context) FetchFileRequestInfo(server, id string) (shared.FileRequestInfo, error) {
+	url := endpoints.FileRequest.Format(server, id)
+	resp, err := requests.GetRequest(ctx.Session, url)
+	if err != nil {
+		return shared.FileRequestInfo{}, err
+	} else if resp.StatusCode != http.StatusOK {
+		return shared.FileRequestInfo{}, utils.ParseHTTPError(resp)
+	}
+
+	var info shared.FileRequestInfo
+	err = json.NewDecoder(resp.Body).Decode(&info)
+	if err != nil {
+		return shared.FileRequestInfo{}, err
+	}
+
+	return info, nil
+}
+
+// InitFileRequestUpload initializes a new file upload into the vault folder of
+// a file request's owner
+func (ctx *Context) InitFileRequestUpload(
+	server,
+	id string,
+	upload shared.VaultUpload,
+) (shared.MetadataUploadResponse, error) {
+	reqData, err := json.Marshal(upload)
+	if err != nil {
+		return shared.MetadataUploadResponse{}, err
+	}
+
+	url := endpoints.UploadFileRequest.Format(server, id)
+	resp, err := requests.PostRequest(ctx.Session, url, reqData)
+	if err != nil {
+		return shared.MetadataUploadResponse{}, err
+	} else if resp.StatusCode != http.StatusOK {
+		return shared.MetadataUploadResponse{}, utils.ParseHTTPError(resp)
+	}
+
+	var metaResponse shared.MetadataUploadResponse
+	err = json.NewDecoder(resp.Body).Decode(&metaResponse)
+	if err != nil {
+		return shared.MetadataUploadResponse{}, err
+	}
+
+	return metaResponse, nil
+}
@@ -0,0 +1,116 @@
+//go:build server_test
+
+package api
+
+import (
+	"encoding/hex"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"yeetfile/cli/crypto"
+	"yeetfile/shared"
+	"yeetfile/shared/endpoints"
+)
+
+func TestFileRequest(t *testing.T) {
+	folderKey, folderID, err := createRandomFolder(UserA, "", nil)
+	assert.Nil(t, err)
+
+	requestID, err := UserA.context.CreateFileRequest(shared.NewFileRequest{
+		FolderID:   folderID,
+		MaxUploads: 1,
+	})
+	assert.Nil(t, err)
+
+	// UserB shouldn't be able to create a request for UserA's folder
+	_, err = UserB.context.CreateFileRequest(shared.NewFileRequest{
+		FolderID: folderID,
+	})
+	assert.NotNil(t, err)
+
+	// Request info is available without an account
+	anonymous := InitContext(server, "")
+	info, err := anonymous.FetchFileRequestInfo(server, requestID)
+	assert.Nil(t, err)
+	assert.Equal(t, UserA.pubKey, info.PublicKey)
+	assert.Equal(t, 1, info.Remaining)
+
+	key, _ := crypto.GenerateRandomKey()
+	protectedKey, _ := crypto.EncryptWithPublicKey(info.PublicKey, key)
+	encName, _ := crypto.EncryptChunk(key, []byte("request.txt"))
+	upload := shared.VaultUpload{
+		Name:         hex.EncodeToString(encName),
+		Length:       int64(len(fileContent)),
+		Chunks:       1,
+		ProtectedKey: protectedKey,
+	}
+
+	meta, err := anonymous.InitFileRequestUpload(server, requestID, upload)
+	assert.Nil(t, err)
+
+	encData, _ := crypto.EncryptChunk(key, []byte(fileContent))
+	url := endpoints.UploadVaultFileData.Format(server, meta.ID, "1")
+
+	// Uploading without the request ID should fail
+	_, err = anonymous.UploadFileChunk(url, encData)
+	assert.NotNil(t, err)
+
+	_, err = anonymous.UploadFileChunk(url+"?request="+requestID, encData)
+	assert.Nil(t, err)
+
+	// Chunks can't be added once the upload has finished
+	_, err = anonymous.UploadFileChunk(url+"?request="+requestID, encData)
+	assert.NotNil(t, err)
+
+	extraURL := endpoints.UploadVaultFileData.Format(server, meta.ID, "2")
+	_, err = anonymous.UploadFileChunk(extraURL+"?request="+requestID, encData)
+	assert.NotNil(t, err)
+
+	// The request is limited to one upload
+	_, err = anonymous.InitFileRequestUpload(server, requestID, upload)
+	assert.NotNil(t, err)
+
+	// The uploaded file key is still wrapped with UserA's public key until
+	// it's re-wrapped with the folder key
+	folder, err := UserA.context.FetchFolderContents(folderID, false)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(folder.Items))
+	assert.True(t, folder.Items[0].PendingKey)
+
+	fileKey, err := crypto.DecryptWithPrivateKey(UserA.privKey, folder.Items[0].ProtectedKey)
+	assert.Nil(t, err)
+	assert.Equal(t, key, fileKey)
+
+	rewrapped, _ := crypto.EncryptChunk(folderKey, fileKey)
+	err = UserA.context.ModifyVaultFile(meta.ID, shared.ModifyVaultItem{
+		ProtectedKey: rewrapped,
+	})
+	assert.Nil(t, err)
+
+	// Keys can't be replaced once they've been re-wrapped
+	err = UserA.context.ModifyVaultFile(meta.ID, shared.ModifyVaultItem{
+		ProtectedKey: protectedKey,
+	})
+	assert.NotNil(t, err)
+
+	folder, err = UserA.context.FetchFolderContents(folderID, false)
+	assert.Nil(t, err)
+	assert.False(t, folder.Items[0].PendingKey)
+
+	fileKey, err = crypto.DecryptChunk(folderKey, folder.Items[0].ProtectedKey)
+	assert.Nil(t, err)
+	assert.Equal(t, key, fileKey)
+
+	requests, err := UserA.context.GetFileRequests()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(requests))
+	assert.Equal(t, 1, requests[0].Uploads)
+
+	err = UserB.context.DeleteFileRequest(requestID)
+	assert.NotNil(t, err)
+
+	err = UserA.context.DeleteFileRequest(requestID)
+	assert.Nil(t, err)
+
+	_, err = anonymous.FetchFileRequestInfo(server, requestID)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,50 @@
+//go:build server_test
+
+package api
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"yeetfile/cli/crypto"
+	"yeetfile/shared"
+)
+
+// lockoutAttempts matches the default YEETFILE_LOCKOUT_ATTEMPTS value
+const lockoutAttempts = 5
+
+func TestAccountLockout(t *testing.T) {
+	user := setupTestUser()
+	defer cleanUpUserAccount(user)
+
+	kdf := shared.DefaultKDFParams()
+	_, loginKeyHash := crypto.GenerateUserKeys(user.id, userPassword, kdf)
+	_, wrongLoginKeyHash := crypto.GenerateUserKeys(user.id, "wrong", kdf)
+
+	ctx := InitContext(server, "")
+	for i := 0; i < lockoutAttempts; i++ {
+		_, _, err := ctx.Login(shared.Login{
+			Identifier:   user.id,
+			LoginKeyHash: wrongLoginKeyHash,
+		})
+		assert.NotNil(t, err)
+	}
+
+	// The correct password is rejected while the account is locked, with
+	// the same error as an account that doesn't exist
+	_, _, err := ctx.Login(shared.Login{
+		Identifier:   user.id,
+		LoginKeyHash: loginKeyHash,
+	})
+	assert.NotNil(t, err)
+
+	_, _, unknownErr := ctx.Login(shared.Login{
+		Identifier:   "1234123412341234",
+		LoginKeyHash: loginKeyHash,
+	})
+	assert.NotNil(t, unknownErr)
+	assert.Equal(t, unknownErr.Error(), err.Error())
+
+	// Existing sessions aren't affected by the lockout
+	_, err = user.context.GetSecurityLog()
+	assert.Nil(t, err)
+}
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"yeetfile/cli/requests"
+	"yeetfile/cli/utils"
+	"yeetfile/shared"
+	"yeetfile/shared/endpoints"
+)
+
+var NoOrganizationError = errors.New("user is not a member of an organization")
+
+// GetOrganization fetches the organization that the user belongs to, including
+// its members and the keys for each org folder (encrypted with the user's
+// public key)
+func (ctx *Context) GetOrganization() (shared.Organization, error) {
+	url := endpoints.Org.Format(ctx.Server)
+	resp, err := requests.GetRequest(ctx.Session, url)
+	if err != nil {
+		return shared.Organization{}, err
+	} else if resp.StatusCode == http.StatusNotFound {
+		return shared.Organization{}, NoOrganizationError
+	} else if resp.StatusCode != http.StatusOK {
+		return shared.Organization{}, utils.ParseHTTPError(resp)
+	}
+
+	var org shared.Organization
+	err = json.NewDecoder(resp.Body).Decode(&org)
+	if err != nil {
+		return shared.Organization{}, err
+	}
+
+	return org, nil
+}
+
+// CreateOrganization creates a new organization with the user as its owner.
+// Returns the new organization ID.
+func (ctx *Context) CreateOrganization(name string) (string, error) {
+	url := endpoints.Org.Format(ctx.Server)
+	return ctx.postOrgRequest(url, shared.NewOrganization{Name: name})
+}
+
+// DeleteOrganization deletes the user's organization and all org folders
+func (ctx *Context) DeleteOrganization() error {
+	url := endpoints.Org.Format(ctx.Server)
+	return deleteItem(ctx.Session, url)
+}
+
+// AddOrgMember adds a user to the organization
+func (ctx *Context) AddOrgMember(member shared.NewOrgMember) (shared.OrgMember, error) {
+	reqData, err := json.Marshal(member)
+	if err != nil {
+		return shared.OrgMember{}, err
+	}
+
+	url := endpoints.OrgMembers.Format(ctx.Server)
+	resp, err := requests.PostRequest(ctx.Session, url, reqData)
+	if err != nil {
+		return shared.OrgMember{}, err
+	} else if resp.StatusCode != http.StatusOK {
+		return shared.OrgMember{}, utils.ParseHTTPError(resp)
+	}
+
+	var newMember shared.OrgMember
+	err = json.NewDecoder(resp.Body).Decode(&newMember)
+	if err != nil {
+		return shared.OrgMember{}, err
+	}
+
+	return newMember, nil
+}
+
+// SetOrgMemberRole changes the role of a member of the organization
+func (ctx *Context) SetOrgMemberRole(memberID, role string) error {
+	reqData, err := json.Marshal(shared.ModifyOrgMember{Role: role})
+	if err != nil {
+		return err
+	}
+
+	url := endpoints.OrgMember.Format(ctx.Server, memberID)
+	resp, err := requests.PutRequest(ctx.Session, url, reqData)
+	if err != nil {
+		return err
+	} else if resp.StatusCode != http.StatusOK {
+		return utils.ParseHTTPError(resp)
+	}
+
+	return nil
+}
+
+// RemoveOrgMember removes a member from the organization. The org folder keys
+// need to be rotated afterwards using RotateOrgKeys.
+func (ctx *Context) RemoveOrgMember(memberID string) error {
+	url := endpoints.OrgMember.Format(ctx.Server, memberID)
+	return deleteItem(ctx.Session, url)
+}
+
+// CreateOrgFolder creates a new folder owned by the organization. Returns the
+// new folder ID.
+func (ctx *Context) CreateOrgFolder(folder shared.NewOrgFolder) (string, error) {
+	url := endpoints.OrgFolders.Format(ctx.Server)
+	return ctx.postOrgRequest(url, folder)
+}
+
+// DeleteOrgFolder deletes an org folder and all of its contents
+func (ctx *Context) DeleteOrgFolder(folderID string) error {
+	url := endpoints.OrgFolder.Format(ctx.Server, folderID)
+	return deleteItem(ctx.Session, url)
+}
+
+// RotateOrgKeys replaces the keys for all of the organization's folders
+func (ctx *Context) RotateOrgKeys(rotation shared.OrgKeyRotation) error {
+	reqData, err := json.Marshal(rotation)
+	if err != nil {
+		return err
+	}
+
+	url := endpoints.OrgKeys.Format(ctx.Server)
+	resp, err := requests.PutRequest(ctx.Session, url, reqData)
+	if err != nil {
+		return err
+	} else if resp.StatusCode != http.StatusOK {
+		return utils.ParseHTTPError(resp)
+	}
+
+	return nil
+}
+
+func (ctx *Context) postOrgRequest(url string, data interface{}) (string, error) {
+	reqData, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := requests.PostRequest(ctx.Session, url, reqData)
+	if err != nil {
+		return "", err
+	} else if resp.StatusCode != http.StatusOK {
+		return "", utils.ParseHTTPError(resp)
+	}
+
+	var createResponse shared.MetadataUploadResponse
+	err = json.NewDecoder(resp.Body).Decode(&createResponse)
+	if err != nil {
+		return "", err
+	}
+
+	return createResponse.ID, nil
+}
@@ -0,0 +1,129 @@
+//go:build server_test
+
+package api
+
+import (
+	"encoding/hex"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"yeetfile/cli/crypto"
+	"yeetfile/shared"
+	"yeetfile/shared/constants"
+)
+
+func TestOrganization(t *testing.T) {
+	_, err := UserA.context.GetOrganization()
+	assert.Equal(t, NoOrganizationError, err)
+
+	_, err = UserA.context.CreateOrganization("Test Org")
+	assert.Nil(t, err)
+	defer UserA.context.DeleteOrganization()
+
+	_, err = UserA.context.CreateOrganization("Test Org")
+	assert.NotNil(t, err)
+
+	folderKey, _ := crypto.GenerateRandomKey()
+	encName, _ := crypto.EncryptChunk(folderKey, []byte("Org Folder"))
+	aKey, err := wrapKeyForUser(UserA, folderKey, UserA.id)
+	assert.Nil(t, err)
+
+	folder := shared.NewOrgFolder{
+		Name:       hex.EncodeToString(encName),
+		MemberKeys: []shared.OrgKey{{ID: UserA.id, ProtectedKey: aKey}},
+	}
+
+	folderID, err := UserA.context.CreateOrgFolder(folder)
+	assert.Nil(t, err)
+
+	// Adding a member without the folder keys should fail
+	_, err = UserA.context.AddOrgMember(shared.NewOrgMember{
+		User: UserB.id,
+		Role: constants.OrgRoleReadOnly,
+	})
+	assert.NotNil(t, err)
+
+	bKey, err := wrapKeyForUser(UserA, folderKey, UserB.id)
+	assert.Nil(t, err)
+
+	_, err = UserA.context.AddOrgMember(shared.NewOrgMember{
+		User:       UserB.id,
+		Role:       constants.OrgRoleReadOnly,
+		FolderKeys: []shared.OrgKey{{ID: folderID, ProtectedKey: bKey}},
+	})
+	assert.Nil(t, err)
+
+	org, err := UserB.context.GetOrganization()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(org.Members))
+	assert.Equal(t, 1, len(org.Folders))
+	assert.Equal(t, constants.OrgRoleReadOnly, org.Role)
+
+	decKey, err := crypto.DecryptWithPrivateKey(UserB.privKey, org.Folders[0].ProtectedKey)
+	assert.Nil(t, err)
+	assert.Equal(t, folderKey, decKey)
+
+	_, err = uploadRandomFile(UserB, folderID, folderKey)
+	assert.NotNil(t, err) // Read-only members can't upload
+
+	err = UserA.context.SetOrgMemberRole(UserB.id, constants.OrgRoleMember)
+	assert.Nil(t, err)
+
+	fileID, err := uploadRandomFile(UserB, folderID, folderKey)
+	assert.Nil(t, err)
+
+	err = UserA.context.RemoveOrgMember(UserB.id)
+	assert.Nil(t, err)
+
+	_, err = UserB.context.FetchFolderContents(folderID, false)
+	assert.NotNil(t, err)
+
+	org, err = UserA.context.GetOrganization()
+	assert.Nil(t, err)
+	assert.True(t, org.RotateKeys)
+
+	// New folders and members are blocked until keys have been rotated
+	_, err = UserA.context.CreateOrgFolder(folder)
+	assert.NotNil(t, err)
+
+	contents, err := UserA.context.FetchFolderContents(folderID, false)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(contents.Items))
+	assert.Equal(t, fileID, contents.Items[0].ID)
+
+	fileKey, err := crypto.DecryptChunk(folderKey, contents.Items[0].ProtectedKey)
+	assert.Nil(t, err)
+
+	newFolderKey, _ := crypto.GenerateRandomKey()
+	newEncName, _ := crypto.EncryptChunk(newFolderKey, []byte("Org Folder"))
+	newFileKey, _ := crypto.EncryptChunk(newFolderKey, fileKey)
+	newAKey, err := wrapKeyForUser(UserA, newFolderKey, UserA.id)
+	assert.Nil(t, err)
+
+	// Rotation should be rejected if any items are missing
+	rotation := shared.OrgFolderRotation{
+		FolderID:   folderID,
+		Name:       hex.EncodeToString(newEncName),
+		Items:      []shared.OrgKey{},
+		Subfolders: []shared.OrgKey{},
+		MemberKeys: []shared.OrgKey{{ID: UserA.id, ProtectedKey: newAKey}},
+	}
+
+	err = UserA.context.RotateOrgKeys(shared.OrgKeyRotation{
+		Folders: []shared.OrgFolderRotation{rotation},
+	})
+	assert.NotNil(t, err)
+
+	rotation.Items = []shared.OrgKey{{ID: fileID, ProtectedKey: newFileKey}}
+	err = UserA.context.RotateOrgKeys(shared.OrgKeyRotation{
+		Folders: []shared.OrgFolderRotation{rotation},
+	})
+	assert.Nil(t, err)
+
+	org, err = UserA.context.GetOrganization()
+	assert.Nil(t, err)
+	assert.False(t, org.RotateKeys)
+
+	decKey, err = crypto.DecryptWithPrivateKey(UserA.privKey, org.Folders[0].ProtectedKey)
+	assert.Nil(t, err)
+	assert.Equal(t, newFolderKey, decKey)
+}
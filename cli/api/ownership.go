@@ -0,0 +1,104 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"yeetfile/cli/requests"
+	"yeetfile/cli/utils"
+	"yeetfile/shared"
+	"yeetfile/shared/endpoints"
+)
+
+// TransferFileOwnership starts transferring ownership of a file to another
+// user. The request's protected key should be the file key encrypted with the
+// recipient's public key.
+func (ctx *Context) TransferFileOwnership(
+	request shared.OwnershipTransferRequest,
+	fileID string,
+) (shared.OwnershipTransfer, error) {
+	url := endpoints.TransferFileOwnership.Format(ctx.Server, fileID)
+	return transferOwnership(ctx.Session, url, request)
+}
+
+// TransferFolderOwnership starts transferring ownership of a folder, including
+// all of its contents, to another user. The request's protected key should be
+// the folder key encrypted with the recipient's public key.
+func (ctx *Context) TransferFolderOwnership(
+	request shared.OwnershipTransferRequest,
+	folderID string,
+) (shared.OwnershipTransfer, error) {
+	url := endpoints.TransferFolderOwnership.Format(ctx.Server, folderID)
+	return transferOwnership(ctx.Session, url, request)
+}
+
+// GetOwnershipTransfers fetches the pending ownership transfers that have been
+// offered to the user, as well as the transfers the user has started
+func (ctx *Context) GetOwnershipTransfers() (shared.OwnershipTransfersResponse, error) {
+	url := endpoints.OwnershipTransfers.Format(ctx.Server)
+	resp, err := requests.GetRequest(ctx.Session, url)
+	if err != nil {
+		return shared.OwnershipTransfersResponse{}, err
+	} else if resp.StatusCode != http.StatusOK {
+		return shared.OwnershipTransfersResponse{}, utils.ParseHTTPError(resp)
+	}
+
+	var transfers shared.OwnershipTransfersResponse
+	err = json.NewDecoder(resp.Body).Decode(&transfers)
+	if err != nil {
+		return shared.OwnershipTransfersResponse{}, err
+	}
+
+	return transfers, nil
+}
+
+// RespondToOwnershipTransfer accepts or declines an ownership transfer (see
+// constants.InvitationAccept, etc). Accepted files and folders are moved to
+// the user's home folder.
+func (ctx *Context) RespondToOwnershipTransfer(id, action string) error {
+	reqData, err := json.Marshal(shared.ShareInvitationAction{Action: action})
+	if err != nil {
+		return err
+	}
+
+	url := endpoints.OwnershipTransfer.Format(ctx.Server, id)
+	resp, err := requests.PutRequest(ctx.Session, url, reqData)
+	if err != nil {
+		return err
+	} else if resp.StatusCode != http.StatusOK {
+		return utils.ParseHTTPError(resp)
+	}
+
+	return nil
+}
+
+// CancelOwnershipTransfer cancels an ownership transfer started by the user
+func (ctx *Context) CancelOwnershipTransfer(id string) error {
+	url := endpoints.OwnershipTransfer.Format(ctx.Server, id)
+	return deleteItem(ctx.Session, url)
+}
+
+func transferOwnership(
+	session,
+	url string,
+	request shared.OwnershipTransferRequest,
+) (shared.OwnershipTransfer, error) {
+	reqData, err := json.Marshal(request)
+	if err != nil {
+		return shared.OwnershipTransfer{}, err
+	}
+
+	resp, err := requests.PostRequest(session, url, reqData)
+	if err != nil {
+		return shared.OwnershipTransfer{}, err
+	} else if resp.StatusCode != http.StatusOK {
+		return shared.OwnershipTransfer{}, utils.ParseHTTPError(resp)
+	}
+
+	var transfer shared.OwnershipTransfer
+	err = json.NewDecoder(resp.Body).Decode(&transfer)
+	if err != nil {
+		return shared.OwnershipTransfer{}, err
+	}
+
+	return transfer, nil
+}
@@ -0,0 +1,178 @@
+//go:build server_test
+
+package api
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"yeetfile/cli/crypto"
+	"yeetfile/shared"
+	"yeetfile/shared/constants"
+)
+
+func TestTransferFolderOwnership(t *testing.T) {
+	folderKey, folderID, err := createRandomFolder(UserA, "", nil)
+	assert.Nil(t, err)
+
+	subfolderKey, subfolderID, err := createRandomFolder(UserA, folderID, folderKey)
+	assert.Nil(t, err)
+
+	fileID, err := uploadRandomFile(UserA, subfolderID, subfolderKey)
+	assert.Nil(t, err)
+
+	shareRequest, err := prepSharedContent(UserA, folderKey, false, UserB.id)
+	assert.Nil(t, err)
+
+	shareInfo, err := UserA.context.ShareFolderWithUser(shareRequest, folderID)
+	assert.Nil(t, err)
+
+	err = UserB.context.RespondToShareInvitation(shareInfo.ID, constants.InvitationAccept)
+	assert.Nil(t, err)
+
+	// Users can't transfer items they don't own
+	protectedKey, err := wrapKeyForUser(UserB, folderKey, UserA.id)
+	assert.Nil(t, err)
+
+	request := shared.OwnershipTransferRequest{User: UserA.id, ProtectedKey: protectedKey}
+	_, err = UserB.context.TransferFolderOwnership(request, folderID)
+	assert.NotNil(t, err)
+
+	protectedKey, err = wrapKeyForUser(UserA, folderKey, UserB.id)
+	assert.Nil(t, err)
+
+	request = shared.OwnershipTransferRequest{User: UserB.id, ProtectedKey: protectedKey}
+	transfer, err := UserA.context.TransferFolderOwnership(request, folderID)
+	assert.Nil(t, err)
+
+	_, err = UserA.context.TransferFolderOwnership(request, folderID)
+	assert.NotNil(t, err) // Transfer already pending
+
+	transfers, err := UserA.context.GetOwnershipTransfers()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(transfers.Outgoing))
+	assert.Equal(t, transfer.ID, transfers.Outgoing[0].ID)
+
+	transfers, err = UserB.context.GetOwnershipTransfers()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(transfers.Incoming))
+
+	incoming := transfers.Incoming[0]
+	assert.Equal(t, transfer.ID, incoming.ID)
+	assert.True(t, incoming.IsFolder)
+	assert.Equal(t, UserA.pubKey, incoming.PublicKey)
+
+	decKey, err := crypto.DecryptWithPrivateKey(UserB.privKey, incoming.ProtectedKey)
+	assert.Nil(t, err)
+	assert.Equal(t, folderKey, decKey)
+
+	usageA, err := UserA.context.GetAccountUsage()
+	assert.Nil(t, err)
+	usageB, err := UserB.context.GetAccountUsage()
+	assert.Nil(t, err)
+
+	// Only the recipient can accept the transfer
+	err = UserA.context.RespondToOwnershipTransfer(transfer.ID, constants.InvitationAccept)
+	assert.NotNil(t, err)
+
+	err = UserB.context.RespondToOwnershipTransfer(transfer.ID, constants.InvitationAccept)
+	assert.Nil(t, err)
+
+	_, err = UserA.context.FetchFolderContents(folderID, false)
+	assert.NotNil(t, err)
+
+	contents, err := UserB.context.FetchFolderContents(folderID, false)
+	assert.Nil(t, err)
+	assert.True(t, contents.CurrentFolder.IsOwner)
+	assert.True(t, contents.CurrentFolder.CanModify)
+	assert.Equal(t, 1, len(contents.Folders))
+
+	subfolder, err := UserB.context.FetchFolderContents(subfolderID, false)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(subfolder.Items))
+	assert.Equal(t, fileID, subfolder.Items[0].ID)
+
+	// The folder replaces the recipient's link to the shared folder
+	root, err := UserB.context.FetchFolderContents("", false)
+	assert.Nil(t, err)
+
+	count := 0
+	for _, folder := range root.Folders {
+		if folder.RefID == folderID {
+			count += 1
+			assert.Equal(t, folderID, folder.ID)
+
+			rootKey, err := crypto.DecryptWithPrivateKey(UserB.privKey, folder.ProtectedKey)
+			assert.Nil(t, err)
+			assert.Equal(t, folderKey, rootKey)
+		}
+	}
+
+	assert.Equal(t, 1, count)
+
+	shares, err := UserB.context.GetSharedFolderInfo(folderID)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(shares))
+
+	newUsageA, err := UserA.context.GetAccountUsage()
+	assert.Nil(t, err)
+	newUsageB, err := UserB.context.GetAccountUsage()
+	assert.Nil(t, err)
+
+	assert.Equal(t, usageA.StorageUsed-incoming.Size, newUsageA.StorageUsed)
+	assert.Equal(t, usageB.StorageUsed+incoming.Size, newUsageB.StorageUsed)
+
+	err = UserB.context.DeleteVaultFolder(folderID, false)
+	assert.Nil(t, err)
+}
+
+func TestTransferFileOwnership(t *testing.T) {
+	fileID, err := uploadRandomFile(UserA, "", nil)
+	assert.Nil(t, err)
+
+	meta, err := UserA.context.GetVaultItemMetadata(fileID)
+	assert.Nil(t, err)
+
+	key, err := crypto.DecryptWithPrivateKey(UserA.privKey, meta.ProtectedKey)
+	assert.Nil(t, err)
+
+	protectedKey, err := wrapKeyForUser(UserA, key, UserB.id)
+	assert.Nil(t, err)
+
+	request := shared.OwnershipTransferRequest{User: UserB.id, ProtectedKey: protectedKey}
+	transfer, err := UserA.context.TransferFileOwnership(request, fileID)
+	assert.Nil(t, err)
+
+	err = UserB.context.RespondToOwnershipTransfer(transfer.ID, constants.InvitationDecline)
+	assert.Nil(t, err)
+
+	_, err = UserB.context.GetVaultItemMetadata(fileID)
+	assert.NotNil(t, err)
+
+	transfer, err = UserA.context.TransferFileOwnership(request, fileID)
+	assert.Nil(t, err)
+
+	err = UserA.context.CancelOwnershipTransfer(transfer.ID)
+	assert.Nil(t, err)
+
+	err = UserB.context.RespondToOwnershipTransfer(transfer.ID, constants.InvitationAccept)
+	assert.NotNil(t, err)
+
+	transfer, err = UserA.context.TransferFileOwnership(request, fileID)
+	assert.Nil(t, err)
+
+	err = UserB.context.RespondToOwnershipTransfer(transfer.ID, constants.InvitationAccept)
+	assert.Nil(t, err)
+
+	_, err = UserA.context.GetVaultItemMetadata(fileID)
+	assert.NotNil(t, err)
+
+	meta, err = UserB.context.GetVaultItemMetadata(fileID)
+	assert.Nil(t, err)
+
+	decKey, err := crypto.DecryptWithPrivateKey(UserB.privKey, meta.ProtectedKey)
+	assert.Nil(t, err)
+	assert.Equal(t, key, decKey)
+
+	err = UserB.context.DeleteVaultFile(fileID, false)
+	assert.Nil(t, err)
+}
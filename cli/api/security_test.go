@@ -0,0 +1,62 @@
+//go:build server_test
+
+package api
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"yeetfile/cli/crypto"
+	"yeetfile/shared"
+	"yeetfile/shared/constants"
+)
+
+func TestSecurityLog(t *testing.T) {
+	user := setupTestUser()
+	defer cleanUpUserAccount(user)
+
+	kdf := shared.DefaultKDFParams()
+	_, wrongLoginKeyHash := crypto.GenerateUserKeys(user.id, "wrong", kdf)
+
+	ctx := InitContext(server, "")
+	_, _, err := ctx.Login(shared.Login{
+		Identifier:   user.id,
+		LoginKeyHash: wrongLoginKeyHash,
+	})
+	assert.NotNil(t, err)
+
+	securityLog, err := user.context.GetSecurityLog()
+	assert.Nil(t, err)
+	assert.True(t, securityLog.LoginAlerts)
+	assert.NotEmpty(t, securityLog.Events)
+
+	// Events are returned newest first
+	failed := securityLog.Events[0]
+	assert.Equal(t, constants.EventLoginFailed, failed.Type)
+	assert.Equal(t, "incorrect password", failed.Details)
+	assert.NotEmpty(t, failed.IPAddress)
+
+	var hasLogin bool
+	for _, event := range securityLog.Events {
+		if event.Type == constants.EventLogin {
+			hasLogin = true
+		}
+	}
+
+	assert.True(t, hasLogin)
+
+	// Changing the password hint is recorded
+	err = user.context.ChangePasswordHint("hint")
+	assert.Nil(t, err)
+	securityLog, err = user.context.GetSecurityLog()
+	assert.Nil(t, err)
+	assert.Equal(t, constants.EventHintChanged, securityLog.Events[0].Type)
+
+	err = user.context.SetSecuritySettings(shared.SecuritySettings{
+		LoginAlerts: false,
+	})
+	assert.Nil(t, err)
+
+	securityLog, err = user.context.GetSecurityLog()
+	assert.Nil(t, err)
+	assert.False(t, securityLog.LoginAlerts)
+}
@@ -56,3 +56,91 @@ func (ctx *Context) FetchSendFileMetadata(server, id string) (shared.DownloadRes
 
 	return downloadResponse, nil
 }
+
+// GetSends fetches the list of active sends created by the current user
+func (ctx *Context) GetSends() ([]shared.SendItem, error) {
+	url := endpoints.SendRoot.Format(ctx.Server)
+	resp, err := requests.GetRequest(ctx.Session, url)
+	if err != nil {
+		return nil, err
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, utils.ParseHTTPError(resp)
+	}
+
+	var sendList shared.SendListResponse
+	err = json.NewDecoder(resp.Body).Decode(&sendList)
+	if err != nil {
+		return nil, err
+	}
+
+	return sendList.Sends, nil
+}
+
+// GetInbox fetches the list of active sends that have been addressed to the
+// current user
+func (ctx *Context) GetInbox() ([]shared.InboxItem, error) {
+	url := endpoints.SendInbox.Format(ctx.Server)
+	resp, err := requests.GetRequest(ctx.Session, url)
+	if err != nil {
+		return nil, err
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, utils.ParseHTTPError(resp)
+	}
+
+	var inbox shared.InboxResponse
+	err = json.NewDecoder(resp.Body).Decode(&inbox)
+	if err != nil {
+		return nil, err
+	}
+
+	return inbox.Sends, nil
+}
+
+// SaveInboxSend copies a send from the current user's inbox into their vault,
+// returning the ID of the new vault file
+func (ctx *Context) SaveInboxSend(id string, save shared.SaveInboxSend) (string, error) {
+	reqData, err := json.Marshal(save)
+	if err != nil {
+		return "", err
+	}
+
+	url := endpoints.SendInboxItem.Format(ctx.Server, id)
+	resp, err := requests.PostRequest(ctx.Session, url, reqData)
+	if err != nil {
+		return "", err
+	} else if resp.StatusCode != http.StatusOK {
+		return "", utils.ParseHTTPError(resp)
+	}
+
+	var saveResponse shared.MetadataUploadResponse
+	err = json.NewDecoder(resp.Body).Decode(&saveResponse)
+	if err != nil {
+		return "", err
+	}
+
+	return saveResponse.ID, nil
+}
+
+// ModifySend changes the expiration and/or remaining downloads of a send
+func (ctx *Context) ModifySend(id string, mod shared.ModifySend) error {
+	reqData, err := json.Marshal(mod)
+	if err != nil {
+		return err
+	}
+
+	url := endpoints.SendItem.Format(ctx.Server, id)
+	resp, err := requests.PatchRequest(ctx.Session, url, reqData)
+	if err != nil {
+		return err
+	} else if resp.StatusCode != http.StatusOK {
+		return utils.ParseHTTPError(resp)
+	}
+
+	return nil
+}
+
+// DeleteSend revokes a send, deleting it from the server
+func (ctx *Context) DeleteSend(id string) error {
+	url := endpoints.SendItem.Format(ctx.Server, id)
+	return deleteItem(ctx.Session, url)
+}
@@ -4,6 +4,7 @@ package api
 
 import (
 	"encoding/hex"
+	"encoding/json"
 	"github.com/stretchr/testify/assert"
 	"log"
 	"strings"
@@ -11,6 +12,7 @@ import (
 	"time"
 	"yeetfile/cli/crypto"
 	"yeetfile/shared"
+	"yeetfile/shared/constants"
 	"yeetfile/shared/endpoints"
 )
 
@@ -130,3 +132,351 @@ func TestSendFile(t *testing.T) {
 		t.Fatal("User was able to download sent file after expiration")
 	}
 }
+
+func TestModifyAndRevokeSend(t *testing.T) {
+	account, err := UserA.context.GetAccountInfo()
+	assert.Nil(t, err)
+	used := account.SendUsed
+
+	key, _, err := crypto.DeriveSendingKey([]byte("password"), nil)
+	assert.Nil(t, err)
+
+	encData, err := crypto.EncryptChunk(key, []byte("revoke me"))
+	assert.Nil(t, err)
+
+	encName, _ := crypto.EncryptChunk(key, []byte("revoke.txt"))
+	meta, err := UserA.context.InitSendFile(shared.UploadMetadata{
+		Name:       hex.EncodeToString(encName),
+		Chunks:     1,
+		Size:       int64(len(encData)),
+		Downloads:  2,
+		Expiration: "10m",
+	})
+	assert.Nil(t, err)
+
+	uploadURL := endpoints.UploadSendFileData.Format(server, meta.ID, "1")
+	_, err = UserA.context.UploadFileChunk(uploadURL, encData)
+	assert.Nil(t, err)
+
+	findSend := func(sends []shared.SendItem) *shared.SendItem {
+		for _, send := range sends {
+			if send.ID == meta.ID {
+				return &send
+			}
+		}
+
+		return nil
+	}
+
+	sends, err := UserA.context.GetSends()
+	assert.Nil(t, err)
+	send := findSend(sends)
+	assert.NotNil(t, send)
+	assert.Equal(t, 2, send.Downloads)
+	assert.Equal(t, 2, send.TotalDownloads)
+
+	// Other users shouldn't see or be able to modify the send
+	sends, err = UserB.context.GetSends()
+	assert.Nil(t, err)
+	assert.Nil(t, findSend(sends))
+	assert.NotNil(t, UserB.context.ModifySend(meta.ID, shared.ModifySend{Downloads: 5}))
+	assert.NotNil(t, UserB.context.DeleteSend(meta.ID))
+
+	// Extend the send
+	err = UserA.context.ModifySend(meta.ID, shared.ModifySend{
+		Downloads:  5,
+		Expiration: "2h",
+	})
+	assert.Nil(t, err)
+
+	err = UserA.context.ModifySend(meta.ID, shared.ModifySend{Downloads: 11})
+	assert.NotNil(t, err)
+
+	err = UserA.context.ModifySend(meta.ID, shared.ModifySend{Expiration: "31d"})
+	assert.NotNil(t, err)
+
+	download, err := UserB.context.FetchSendFileMetadata(server, meta.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, download.Downloads)
+	assert.True(t, download.Expiration.After(time.Now().Add(time.Hour)))
+
+	// Revoke the send, which should refund the (unused) send amount
+	err = UserA.context.DeleteSend(meta.ID)
+	assert.Nil(t, err)
+
+	_, err = UserA.context.FetchSendFileMetadata(server, meta.ID)
+	assert.NotNil(t, err)
+
+	account, err = UserA.context.GetAccountInfo()
+	assert.Nil(t, err)
+	assert.Equal(t, used, account.SendUsed)
+}
+
+func TestSendManifest(t *testing.T) {
+	key, _, err := crypto.DeriveSendingKey([]byte("password"), nil)
+	assert.Nil(t, err)
+
+	contents := []byte("file one contentsfile two contents")
+	encData, err := crypto.EncryptChunk(key, contents)
+	assert.Nil(t, err)
+
+	manifest := shared.SendManifest{Files: []shared.SendManifestFile{
+		{Path: "dir/one.txt", Offset: 0, Size: 17},
+		{Path: "dir/two.txt", Offset: 17, Size: 17},
+	}}
+	manifestJSON, err := json.Marshal(manifest)
+	assert.Nil(t, err)
+
+	encManifest, err := crypto.EncryptChunk(key, manifestJSON)
+	assert.Nil(t, err)
+
+	encName, _ := crypto.EncryptChunk(key, []byte("dir"))
+	uploadMetadata := shared.UploadMetadata{
+		Name:       hex.EncodeToString(encName),
+		Chunks:     1,
+		Size:       int64(len(contents)),
+		Downloads:  1,
+		Expiration: "10m",
+		Manifest:   make([]byte, constants.MaxSendManifestSize+1),
+	}
+
+	// Manifests can't exceed the max size
+	_, err = UserA.context.InitSendFile(uploadMetadata)
+	assert.NotNil(t, err)
+
+	uploadMetadata.Manifest = encManifest
+	meta, err := UserA.context.InitSendFile(uploadMetadata)
+	assert.Nil(t, err)
+
+	uploadURL := endpoints.UploadSendFileData.Format(server, meta.ID, "1")
+	_, err = UserA.context.UploadFileChunk(uploadURL, encData)
+	assert.Nil(t, err)
+
+	sends, err := UserA.context.GetSends()
+	assert.Nil(t, err)
+	for _, send := range sends {
+		if send.ID == meta.ID {
+			assert.True(t, send.Bundle)
+		}
+	}
+
+	download, err := UserB.context.FetchSendFileMetadata(server, meta.ID)
+	assert.Nil(t, err)
+
+	decManifest, err := crypto.DecryptChunk(key, download.Manifest)
+	assert.Nil(t, err)
+
+	var downloadedManifest shared.SendManifest
+	assert.Nil(t, json.Unmarshal(decManifest, &downloadedManifest))
+	assert.Equal(t, manifest, downloadedManifest)
+}
+
+func TestSendLargeText(t *testing.T) {
+	key, _, err := crypto.DeriveSendingKey([]byte("password"), nil)
+	assert.Nil(t, err)
+
+	text := []byte(strings.Repeat("# Large text\n", constants.MaxPlaintextLen))
+	encText, err := crypto.EncryptChunk(key, text)
+	assert.Nil(t, err)
+
+	encFormat, err := crypto.EncryptChunk(key, []byte(constants.TextFormatMarkdown))
+	assert.Nil(t, err)
+
+	encName, _ := crypto.EncryptChunk(key, []byte("text"))
+	uploadMetadata := shared.UploadMetadata{
+		Name:       hex.EncodeToString(encName),
+		Chunks:     1,
+		Size:       constants.MaxPlaintextLen,
+		Downloads:  1,
+		Expiration: "10m",
+		Text:       true,
+		Format:     encFormat,
+	}
+
+	// Text that fits in a single request shouldn't be uploaded in chunks
+	_, err = UserA.context.InitSendFile(uploadMetadata)
+	assert.NotNil(t, err)
+
+	uploadMetadata.Size = int64(len(text))
+	meta, err := UserA.context.InitSendFile(uploadMetadata)
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(meta.ID, constants.PlaintextIDPrefix))
+
+	uploadURL := endpoints.UploadSendFileData.Format(server, meta.ID, "1")
+	_, err = UserA.context.UploadFileChunk(uploadURL, encText)
+	assert.Nil(t, err)
+
+	download, err := UserB.context.FetchSendFileMetadata(server, meta.ID)
+	assert.Nil(t, err)
+
+	format, err := crypto.DecryptChunk(key, download.Format)
+	assert.Nil(t, err)
+	assert.Equal(t, constants.TextFormatMarkdown, string(format))
+
+	downloadURL := endpoints.DownloadSendFileData.Format(server, meta.ID, "1")
+	encDownloadedText, err := UserB.context.DownloadFileChunk(downloadURL)
+	assert.Nil(t, err)
+
+	downloadedText, err := crypto.DecryptChunk(key, encDownloadedText)
+	assert.Nil(t, err)
+	assert.Equal(t, text, downloadedText)
+}
+
+func TestSendToRecipients(t *testing.T) {
+	key, _, err := crypto.DeriveSendingKey(nil, nil)
+	assert.Nil(t, err)
+
+	contents := []byte("recipient contents")
+	encData, err := crypto.EncryptChunk(key, contents)
+	assert.Nil(t, err)
+
+	resp, err := UserA.context.FetchUserPubKey(UserB.id)
+	assert.Nil(t, err)
+
+	protectedKey, err := crypto.EncryptWithPublicKey(resp.PublicKey, key)
+	assert.Nil(t, err)
+
+	encName, _ := crypto.EncryptChunk(key, []byte("recipient.txt"))
+	uploadMetadata := shared.UploadMetadata{
+		Name:       hex.EncodeToString(encName),
+		Chunks:     1,
+		Size:       int64(len(contents)),
+		Downloads:  2,
+		Expiration: "10m",
+		Recipients: []shared.SendRecipient{{
+			User:         "missing-user-id",
+			ProtectedKey: protectedKey,
+		}},
+	}
+
+	// Recipients must be existing users
+	_, err = UserA.context.InitSendFile(uploadMetadata)
+	assert.NotNil(t, err)
+
+	uploadMetadata.Recipients[0].User = UserB.id
+	meta, err := UserA.context.InitSendFile(uploadMetadata)
+	assert.Nil(t, err)
+
+	uploadURL := endpoints.UploadSendFileData.Format(server, meta.ID, "1")
+	_, err = UserA.context.UploadFileChunk(uploadURL, encData)
+	assert.Nil(t, err)
+
+	// Only recipients (and the owner) can fetch the send
+	anonymous := InitContext(server, "")
+	_, err = anonymous.FetchSendFileMetadata(server, meta.ID)
+	assert.NotNil(t, err)
+
+	_, err = UserA.context.FetchSendFileMetadata(server, meta.ID)
+	assert.Nil(t, err)
+
+	inbox, err := UserB.context.GetInbox()
+	assert.Nil(t, err)
+
+	var item shared.InboxItem
+	for _, send := range inbox {
+		if send.ID == meta.ID {
+			item = send
+		}
+	}
+
+	assert.Equal(t, meta.ID, item.ID)
+	inboxKey, err := crypto.DecryptWithPrivateKey(UserB.privKey, item.ProtectedKey)
+	assert.Nil(t, err)
+	assert.Equal(t, key, inboxKey)
+
+	downloadURL := endpoints.DownloadSendFileData.Format(server, meta.ID, "1")
+	encDownloadedData, err := UserB.context.DownloadFileChunk(downloadURL)
+	assert.Nil(t, err)
+
+	downloadedData, err := crypto.DecryptChunk(inboxKey, encDownloadedData)
+	assert.Nil(t, err)
+	assert.Equal(t, contents, downloadedData)
+
+	// The sender's own inbox shouldn't include sends they created
+	inbox, err = UserA.context.GetInbox()
+	assert.Nil(t, err)
+	for _, send := range inbox {
+		assert.NotEqual(t, meta.ID, send.ID)
+	}
+}
+
+func TestSaveInboxSend(t *testing.T) {
+	key, _, err := crypto.DeriveSendingKey(nil, nil)
+	assert.Nil(t, err)
+
+	contents := []byte("saved to vault")
+	encData, err := crypto.EncryptChunk(key, contents)
+	assert.Nil(t, err)
+
+	sendKey, err := crypto.EncryptWithPublicKey(UserB.pubKey, key)
+	assert.Nil(t, err)
+
+	encName, _ := crypto.EncryptChunk(key, []byte("inbox.txt"))
+	meta, err := UserA.context.InitSendFile(shared.UploadMetadata{
+		Name:       hex.EncodeToString(encName),
+		Chunks:     1,
+		Size:       int64(len(contents)),
+		Downloads:  1,
+		Expiration: "10m",
+		Recipients: []shared.SendRecipient{{
+			User:         UserB.id,
+			ProtectedKey: sendKey,
+		}},
+	})
+	assert.Nil(t, err)
+
+	uploadURL := endpoints.UploadSendFileData.Format(server, meta.ID, "1")
+	_, err = UserA.context.UploadFileChunk(uploadURL, encData)
+	assert.Nil(t, err)
+
+	inbox, err := UserB.context.GetInbox()
+	assert.Nil(t, err)
+
+	found := false
+	for _, send := range inbox {
+		if send.ID == meta.ID {
+			found = true
+			assert.Equal(t, 1, send.Downloads)
+			assert.False(t, send.Bundle)
+			assert.NotEmpty(t, send.SenderName)
+		}
+	}
+
+	assert.True(t, found)
+
+	vaultName, _ := crypto.EncryptChunk(key, []byte("inbox.txt"))
+	vaultKey, err := crypto.EncryptWithPublicKey(UserB.pubKey, key)
+	assert.Nil(t, err)
+
+	save := shared.SaveInboxSend{
+		Name:         hex.EncodeToString(vaultName),
+		ProtectedKey: vaultKey,
+	}
+
+	// Only recipients can save a send to their vault
+	_, err = UserA.context.SaveInboxSend(meta.ID, save)
+	assert.NotNil(t, err)
+
+	itemID, err := UserB.context.SaveInboxSend(meta.ID, save)
+	assert.Nil(t, err)
+
+	vaultMeta, err := UserB.context.GetVaultItemMetadata(itemID)
+	assert.Nil(t, err)
+
+	vaultFileKey, err := crypto.DecryptWithPrivateKey(UserB.privKey, vaultMeta.ProtectedKey)
+	assert.Nil(t, err)
+
+	downloadURL := endpoints.DownloadVaultFileData.Format(server, itemID, "1")
+	encDownloadedData, err := UserB.context.DownloadFileChunk(downloadURL)
+	assert.Nil(t, err)
+
+	downloadedData, err := crypto.DecryptChunk(vaultFileKey, encDownloadedData)
+	assert.Nil(t, err)
+	assert.Equal(t, contents, downloadedData)
+
+	// Saving the send counts as a download, so the send should be removed
+	_, err = UserB.context.FetchSendFileMetadata(server, meta.ID)
+	assert.NotNil(t, err)
+
+	assert.Nil(t, UserB.context.DeleteVaultFile(itemID, false))
+}
@@ -35,8 +35,8 @@ func (ctx *Context) FetchUserPubKey(
 	return pubKeyResponse, nil
 }
 
-// ShareFileWithUser shares a new file with a user. This file will appear in the
-// recipient's home folder.
+// ShareFileWithUser invites a user to a file. The file will appear in the
+// recipient's home folder once they accept the invitation.
 func (ctx *Context) ShareFileWithUser(
 	request shared.ShareItemRequest,
 	fileID string,
@@ -45,8 +45,8 @@ func (ctx *Context) ShareFileWithUser(
 	return shareContentWithUser(ctx.Session, url, request)
 }
 
-// ShareFolderWithUser shares a new folder with a user. This folder will appear
-// in the recipient's home folder.
+// ShareFolderWithUser invites a user to a folder. The folder will appear in the
+// recipient's home folder once they accept the invitation.
 func (ctx *Context) ShareFolderWithUser(
 	request shared.ShareItemRequest,
 	folderID string,
@@ -109,6 +109,54 @@ func (ctx *Context) UpdateSharedFolderUsers(
 	return updateSharedUsers(ctx.Session, folderID, url, update)
 }
 
+// GetShareInvitations fetches the user's pending invitations to files and
+// folders that other users have shared with them, along with the list of users
+// they've blocked from sharing content with them
+func (ctx *Context) GetShareInvitations() (shared.ShareInvitationsResponse, error) {
+	url := endpoints.ShareInvitations.Format(ctx.Server)
+	resp, err := requests.GetRequest(ctx.Session, url)
+	if err != nil {
+		return shared.ShareInvitationsResponse{}, err
+	} else if resp.StatusCode != http.StatusOK {
+		return shared.ShareInvitationsResponse{}, utils.ParseHTTPError(resp)
+	}
+
+	var invitations shared.ShareInvitationsResponse
+	err = json.NewDecoder(resp.Body).Decode(&invitations)
+	if err != nil {
+		return shared.ShareInvitationsResponse{}, err
+	}
+
+	return invitations, nil
+}
+
+// RespondToShareInvitation accepts, declines, or blocks the sender of a share
+// invitation (see constants.InvitationAccept, etc). Accepted files and folders
+// are added to the user's home folder.
+func (ctx *Context) RespondToShareInvitation(id, action string) error {
+	reqData, err := json.Marshal(shared.ShareInvitationAction{Action: action})
+	if err != nil {
+		return err
+	}
+
+	url := endpoints.ShareInvitation.Format(ctx.Server, id)
+	resp, err := requests.PutRequest(ctx.Session, url, reqData)
+	if err != nil {
+		return err
+	} else if resp.StatusCode != http.StatusOK {
+		return utils.ParseHTTPError(resp)
+	}
+
+	return nil
+}
+
+// UnblockUser allows a previously blocked user to share content with the
+// current user again
+func (ctx *Context) UnblockUser(id string) error {
+	url := endpoints.ShareBlocked.Format(ctx.Server, id)
+	return deleteItem(ctx.Session, url)
+}
+
 func shareContentWithUser(
 	session,
 	url string,
@@ -118,6 +166,8 @@ func shareContentWithUser(
 	resp, err := requests.PostRequest(session, url, reqData)
 	if err != nil {
 		return shared.ShareInfo{}, err
+	} else if resp.StatusCode != http.StatusOK {
+		return shared.ShareInfo{}, utils.ParseHTTPError(resp)
 	}
 
 	var shareInfo shared.ShareInfo
@@ -6,22 +6,30 @@ import (
 	"encoding/hex"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 	"yeetfile/cli/crypto"
 	"yeetfile/shared"
+	"yeetfile/shared/constants"
 )
 
+// wrapKeyForUser encrypts a key with the recipient's public key, which is how
+// keys are sent to other users when sharing items, transferring ownership, etc
+func wrapKeyForUser(user TestUser, key []byte, recipient string) ([]byte, error) {
+	resp, err := user.context.FetchUserPubKey(recipient)
+	if err != nil {
+		return nil, err
+	}
+
+	return crypto.EncryptWithPublicKey(resp.PublicKey, key)
+}
+
 func prepSharedContent(
 	user TestUser,
 	key []byte,
 	canModify bool,
 	recipient string,
 ) (shared.ShareItemRequest, error) {
-	resp, err := user.context.FetchUserPubKey(recipient)
-	if err != nil {
-		return shared.ShareItemRequest{}, err
-	}
-
-	protectedKey, err := crypto.EncryptRSA(resp.PublicKey, key)
+	protectedKey, err := wrapKeyForUser(user, key, recipient)
 	if err != nil {
 		return shared.ShareItemRequest{}, err
 	}
@@ -39,13 +47,21 @@ func TestShareFile(t *testing.T) {
 	assert.NotNil(t, err)
 
 	meta, _ := UserA.context.GetVaultItemMetadata(id)
-	key, _ := crypto.DecryptRSA(UserA.privKey, meta.ProtectedKey)
+	key, _ := crypto.DecryptWithPrivateKey(UserA.privKey, meta.ProtectedKey)
 
 	request, err := prepSharedContent(UserA, key, false, UserB.id)
 	assert.Nil(t, err)
 
 	share, err := UserA.context.ShareFileWithUser(request, id)
 	assert.Nil(t, err)
+	assert.True(t, share.Pending)
+
+	// Shared content is inaccessible until the invitation is accepted
+	_, err = UserB.context.GetVaultItemMetadata(id)
+	assert.NotNil(t, err)
+
+	err = UserB.context.RespondToShareInvitation(share.ID, constants.InvitationAccept)
+	assert.Nil(t, err)
 
 	_, err = UserB.context.GetVaultItemMetadata(id)
 	assert.Nil(t, err)
@@ -86,6 +102,9 @@ func TestShareFolder(t *testing.T) {
 	shareInfo, err := UserA.context.ShareFolderWithUser(shareRequest, folderID)
 	assert.Nil(t, err)
 
+	err = UserB.context.RespondToShareInvitation(shareInfo.ID, constants.InvitationAccept)
+	assert.Nil(t, err)
+
 	folderContents, err := UserB.context.FetchFolderContents(folderID, false)
 	assert.Nil(t, err)
 	assert.Equal(t, 1, len(folderContents.Items))
@@ -145,3 +164,130 @@ func TestShareFolder(t *testing.T) {
 	_, err = UserB.context.FetchFolderContents(folderID, false)
 	assert.NotNil(t, err)
 }
+
+func TestShareExpiration(t *testing.T) {
+	folderKey, folderID, err := createRandomFolder(UserA, "", nil)
+	assert.Nil(t, err)
+
+	fileID, err := uploadRandomFile(UserA, "", nil)
+	assert.Nil(t, err)
+
+	meta, _ := UserA.context.GetVaultItemMetadata(fileID)
+	fileKey, _ := crypto.DecryptWithPrivateKey(UserA.privKey, meta.ProtectedKey)
+
+	fileShare, err := prepSharedContent(UserA, fileKey, false, UserB.id)
+	assert.Nil(t, err)
+	fileShare.Expiration = "3s"
+
+	shareInfo, err := UserA.context.ShareFileWithUser(fileShare, fileID)
+	assert.Nil(t, err)
+	assert.False(t, shareInfo.Expiration.IsZero())
+
+	err = UserB.context.RespondToShareInvitation(shareInfo.ID, constants.InvitationAccept)
+	assert.Nil(t, err)
+
+	folderShare, err := prepSharedContent(UserA, folderKey, false, UserB.id)
+	assert.Nil(t, err)
+	folderShare.Expiration = "3s"
+
+	shareInfo, err = UserA.context.ShareFolderWithUser(folderShare, folderID)
+	assert.Nil(t, err)
+
+	err = UserB.context.RespondToShareInvitation(shareInfo.ID, constants.InvitationAccept)
+	assert.Nil(t, err)
+
+	_, err = UserB.context.GetVaultItemMetadata(fileID)
+	assert.Nil(t, err)
+
+	_, err = UserB.context.FetchFolderContents(folderID, false)
+	assert.Nil(t, err)
+
+	time.Sleep(4 * time.Second)
+
+	// Expired shares should be inaccessible, even before they've been
+	// removed by the cron task
+	_, err = UserB.context.GetVaultItemMetadata(fileID)
+	assert.NotNil(t, err)
+
+	_, err = UserB.context.FetchFolderContents(folderID, false)
+	assert.NotNil(t, err)
+
+	root, err := UserB.context.FetchFolderContents("", false)
+	assert.Nil(t, err)
+	for _, item := range root.Items {
+		assert.NotEqual(t, fileID, item.RefID)
+	}
+
+	for _, folder := range root.Folders {
+		assert.NotEqual(t, folderID, folder.RefID)
+	}
+}
+
+func TestShareInvitations(t *testing.T) {
+	folderKey, folderID, err := createRandomFolder(UserA, "", nil)
+	assert.Nil(t, err)
+
+	shareRequest, err := prepSharedContent(UserA, folderKey, true, UserB.id)
+	assert.Nil(t, err)
+
+	shareInfo, err := UserA.context.ShareFolderWithUser(shareRequest, folderID)
+	assert.Nil(t, err)
+
+	invitations, err := UserB.context.GetShareInvitations()
+	assert.Nil(t, err)
+
+	var invitation shared.ShareInvitation
+	for _, pending := range invitations.Invitations {
+		if pending.ID == shareInfo.ID {
+			invitation = pending
+		}
+	}
+
+	assert.Equal(t, shareInfo.ID, invitation.ID)
+	assert.True(t, invitation.IsFolder)
+	assert.True(t, invitation.CanModify)
+	assert.Equal(t, UserA.pubKey, invitation.PublicKey)
+
+	// Pending invitations don't appear in the recipient's vault
+	root, err := UserB.context.FetchFolderContents("", false)
+	assert.Nil(t, err)
+	for _, folder := range root.Folders {
+		assert.NotEqual(t, folderID, folder.RefID)
+	}
+
+	err = UserB.context.RespondToShareInvitation(shareInfo.ID, constants.InvitationDecline)
+	assert.Nil(t, err)
+
+	_, err = UserB.context.FetchFolderContents(folderID, false)
+	assert.NotNil(t, err)
+
+	// Declined invitations can't be accepted
+	err = UserB.context.RespondToShareInvitation(shareInfo.ID, constants.InvitationAccept)
+	assert.NotNil(t, err)
+
+	shareInfo, err = UserA.context.ShareFolderWithUser(shareRequest, folderID)
+	assert.Nil(t, err)
+
+	err = UserB.context.RespondToShareInvitation(shareInfo.ID, constants.InvitationBlock)
+	assert.Nil(t, err)
+
+	_, err = UserA.context.ShareFolderWithUser(shareRequest, folderID)
+	assert.NotNil(t, err)
+
+	invitations, err = UserB.context.GetShareInvitations()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(invitations.Invitations))
+	assert.Equal(t, 1, len(invitations.Blocked))
+
+	err = UserB.context.UnblockUser(invitations.Blocked[0].ID)
+	assert.Nil(t, err)
+
+	shareInfo, err = UserA.context.ShareFolderWithUser(shareRequest, folderID)
+	assert.Nil(t, err)
+
+	err = UserB.context.RespondToShareInvitation(shareInfo.ID, constants.InvitationAccept)
+	assert.Nil(t, err)
+
+	_, err = UserB.context.FetchFolderContents(folderID, false)
+	assert.Nil(t, err)
+}
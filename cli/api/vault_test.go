@@ -32,7 +32,7 @@ func createRandomFolder(
 
 	var protectedKey []byte
 	if len(parentKey) == 0 {
-		protectedKey, _ = crypto.EncryptRSA(UserA.pubKey, folderKey)
+		protectedKey, _ = crypto.EncryptWithPublicKey(UserA.pubKey, folderKey)
 	} else {
 		protectedKey, _ = crypto.EncryptChunk(parentKey, folderKey)
 	}
@@ -60,7 +60,7 @@ func uploadRandomFile(user TestUser, folderID string, folderKey []byte) (string,
 
 	var key []byte
 	if len(folderKey) == 0 {
-		key, err = crypto.DecryptRSA(user.privKey, upload.ProtectedKey)
+		key, err = crypto.DecryptWithPrivateKey(user.privKey, upload.ProtectedKey)
 	} else {
 		key, err = crypto.DecryptChunk(folderKey, upload.ProtectedKey)
 	}
@@ -103,7 +103,7 @@ func generateRandomUpload(user TestUser, folderID string, folderKey []byte) (sha
 
 	var encKey []byte
 	if len(folderKey) == 0 {
-		encKey, err = crypto.EncryptRSA(user.pubKey, key)
+		encKey, err = crypto.EncryptWithPublicKey(user.pubKey, key)
 	} else {
 		encKey, err = crypto.EncryptChunk(folderKey, key)
 	}
@@ -138,7 +138,7 @@ func TestUploadVaultFile(t *testing.T) {
 	upload, _ := generateRandomUpload(UserA, "", nil)
 	meta, _ := UserA.context.InitVaultFile(upload)
 
-	key, _ := crypto.DecryptRSA(UserA.privKey, upload.ProtectedKey)
+	key, _ := crypto.DecryptWithPrivateKey(UserA.privKey, upload.ProtectedKey)
 	encData, _ := crypto.EncryptChunk(key, []byte(fileContent))
 
 	url := endpoints.UploadVaultFileData.Format(server, meta.ID, "1")
@@ -189,12 +189,12 @@ func TestDownloadFile(t *testing.T) {
 	}
 
 	// Attempt decrypting key with UserB's key
-	_, err = crypto.DecryptRSA(UserB.privKey, meta.ProtectedKey)
+	_, err = crypto.DecryptWithPrivateKey(UserB.privKey, meta.ProtectedKey)
 	if err == nil {
 		t.Fatal("UserB was able to decrypt the file key for a file UserA uploaded")
 	}
 
-	key, err := crypto.DecryptRSA(UserA.privKey, meta.ProtectedKey)
+	key, err := crypto.DecryptWithPrivateKey(UserA.privKey, meta.ProtectedKey)
 	if err != nil {
 		t.Fatalf("Error decrypting file key: %v\n", err)
 	}
@@ -214,7 +214,7 @@ func TestVaultFolders(t *testing.T) {
 	folderKey, _ := crypto.GenerateRandomKey()
 	encName, _ := crypto.EncryptChunk(folderKey, []byte("My Folder"))
 	hexName := hex.EncodeToString(encName)
-	protectedKey, _ := crypto.EncryptRSA(UserA.pubKey, folderKey)
+	protectedKey, _ := crypto.EncryptWithPublicKey(UserA.pubKey, folderKey)
 
 	resp, err := UserA.context.CreateVaultFolder(shared.NewVaultFolder{
 		Name:         hexName,
@@ -292,7 +292,7 @@ func TestUploadPastLimit(t *testing.T) {
 	assert.Nil(t, err)
 
 	hexEncName := hex.EncodeToString(encName)
-	encKey, err := crypto.EncryptRSA(UserA.pubKey, key)
+	encKey, err := crypto.EncryptWithPublicKey(UserA.pubKey, key)
 	assert.Nil(t, err)
 
 	upload := shared.VaultUpload{
@@ -387,7 +387,7 @@ func TestPassVaultItem(t *testing.T) {
 	encData, err := crypto.EncryptChunk(passKey, jsonData)
 	assert.Nil(t, err)
 
-	encKey, err := crypto.EncryptRSA(UserA.pubKey, passKey)
+	encKey, err := crypto.EncryptWithPublicKey(UserA.pubKey, passKey)
 	assert.Nil(t, err)
 
 	upload := shared.VaultUpload{
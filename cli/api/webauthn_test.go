@@ -0,0 +1,188 @@
+//go:build server_test
+
+package api
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"yeetfile/backend/crypto/webauthntest"
+	"yeetfile/cli/crypto"
+	"yeetfile/shared"
+)
+
+func webAuthnOrigin(rpID string) string {
+	if rpID == "localhost" {
+		return server
+	}
+
+	return "https://" + rpID
+}
+
+// createCredential registers the authenticator's credential in response to the
+// server's registration options
+func createCredential(
+	authenticator *webauthntest.Authenticator,
+	name string,
+	options shared.WebAuthnRegistrationOptions,
+) shared.WebAuthnRegistration {
+	clientData, attestation := authenticator.Create(
+		options.RPID,
+		webAuthnOrigin(options.RPID),
+		options.Challenge)
+	return shared.WebAuthnRegistration{
+		Name:              name,
+		ClientDataJSON:    clientData,
+		AttestationObject: attestation,
+	}
+}
+
+// getAssertion signs the server's challenge with the authenticator's
+// credential
+func getAssertion(
+	authenticator *webauthntest.Authenticator,
+	options shared.WebAuthnAssertionOptions,
+) *shared.WebAuthnAssertion {
+	clientData, authData, signature := authenticator.Get(
+		options.RPID,
+		webAuthnOrigin(options.RPID),
+		options.Challenge)
+	return &shared.WebAuthnAssertion{
+		CredentialID:      authenticator.CredentialID,
+		ClientDataJSON:    clientData,
+		AuthenticatorData: authData,
+		Signature:         signature,
+	}
+}
+
+func TestWebAuthn(t *testing.T) {
+	user := setupTestUser()
+	defer cleanUpUserAccount(user)
+
+	kdf := shared.DefaultKDFParams()
+	_, loginKeyHash := crypto.GenerateUserKeys(user.id, userPassword, kdf)
+	assertionReq := shared.WebAuthnAssertionRequest{
+		Identifier:   user.id,
+		LoginKeyHash: loginKeyHash,
+	}
+
+	ctx := InitContext(server, "")
+
+	// Assertions aren't available until a key is registered
+	_, err := ctx.GetWebAuthnAssertionOptions(assertionReq)
+	assert.NotNil(t, err)
+
+	// Register two named keys
+	authenticators := []*webauthntest.Authenticator{
+		webauthntest.NewES256Authenticator(),
+		webauthntest.NewES256Authenticator(),
+	}
+	names := []string{"Laptop", "Backup key"}
+	var recoveryCodes []string
+	for i, authenticator := range authenticators {
+		options, err := user.context.BeginWebAuthnRegistration()
+		assert.Nil(t, err)
+
+		response, err := user.context.FinishWebAuthnRegistration(
+			createCredential(authenticator, names[i], options))
+		assert.Nil(t, err)
+		assert.NotEmpty(t, response.ID)
+
+		// Recovery codes are only created along with the first key
+		if i == 0 {
+			recoveryCodes = response.RecoveryCodes
+			assert.Len(t, recoveryCodes, 6)
+		} else {
+			assert.Empty(t, response.RecoveryCodes)
+		}
+	}
+
+	credentials, err := user.context.GetWebAuthnCredentials()
+	assert.Nil(t, err)
+	assert.Len(t, credentials, 2)
+	assert.Equal(t, names[0], credentials[0].Name)
+	assert.Equal(t, names[1], credentials[1].Name)
+
+	// A registration challenge can't be reused
+	options, err := user.context.BeginWebAuthnRegistration()
+	assert.Nil(t, err)
+	registration := createCredential(webauthntest.NewES256Authenticator(), "Reused", options)
+	_, err = user.context.FinishWebAuthnRegistration(registration)
+	assert.Nil(t, err)
+	_, err = user.context.FinishWebAuthnRegistration(registration)
+	assert.NotNil(t, err)
+
+	// Removing a key requires a 2FA or recovery code
+	credentials, _ = user.context.GetWebAuthnCredentials()
+	err = user.context.DeleteWebAuthnCredential(credentials[2].ID, "")
+	assert.NotNil(t, err)
+	err = user.context.DeleteWebAuthnCredential(credentials[2].ID, "incorrect")
+	assert.NotNil(t, err)
+	err = user.context.DeleteWebAuthnCredential(credentials[2].ID, recoveryCodes[5])
+	assert.Nil(t, err)
+
+	// Logging in now requires a second factor
+	_, _, err = ctx.Login(shared.Login{
+		Identifier:   user.id,
+		LoginKeyHash: loginKeyHash,
+	})
+	assert.Equal(t, TwoFactorError, err)
+
+	// Assertion options require the user's password
+	_, wrongLoginKeyHash := crypto.GenerateUserKeys(user.id, "wrong", kdf)
+	_, err = ctx.GetWebAuthnAssertionOptions(shared.WebAuthnAssertionRequest{
+		Identifier:   user.id,
+		LoginKeyHash: wrongLoginKeyHash,
+	})
+	assert.NotNil(t, err)
+
+	assertionOptions, err := ctx.GetWebAuthnAssertionOptions(assertionReq)
+	assert.Nil(t, err)
+	assert.Len(t, assertionOptions.AllowCredentials, 2)
+
+	// An assertion signed by an unregistered key fails
+	_, _, err = ctx.Login(shared.Login{
+		Identifier:   user.id,
+		LoginKeyHash: loginKeyHash,
+		WebAuthn:     getAssertion(webauthntest.NewES256Authenticator(), assertionOptions),
+	})
+	assert.Equal(t, TwoFactorError, err)
+
+	// Each challenge can only be used once
+	assertionOptions, err = ctx.GetWebAuthnAssertionOptions(assertionReq)
+	assert.Nil(t, err)
+	assertion := getAssertion(authenticators[1], assertionOptions)
+	_, _, err = ctx.Login(shared.Login{
+		Identifier:   user.id,
+		LoginKeyHash: loginKeyHash,
+		WebAuthn:     assertion,
+	})
+	assert.Nil(t, err)
+
+	_, _, err = ctx.Login(shared.Login{
+		Identifier:   user.id,
+		LoginKeyHash: loginKeyHash,
+		WebAuthn:     assertion,
+	})
+	assert.Equal(t, TwoFactorError, err)
+
+	// Recovery codes can be used in place of a security key
+	_, _, err = ctx.Login(shared.Login{
+		Identifier:   user.id,
+		LoginKeyHash: loginKeyHash,
+		Code:         recoveryCodes[0],
+	})
+	assert.Nil(t, err)
+
+	// Removing every key disables 2FA
+	credentials, _ = user.context.GetWebAuthnCredentials()
+	for i, credential := range credentials {
+		err = user.context.DeleteWebAuthnCredential(credential.ID, recoveryCodes[i+1])
+		assert.Nil(t, err)
+	}
+
+	_, _, err = ctx.Login(shared.Login{
+		Identifier:   user.id,
+		LoginKeyHash: loginKeyHash,
+	})
+	assert.Nil(t, err)
+}
@@ -41,11 +41,15 @@ func getStorageString(used, available int64, isSend bool) string {
 }
 
 func changePassword(identifier, password, newPassword string) error {
-	userKey := crypto.GenerateUserKey([]byte(identifier), []byte(password))
-	oldLoginKeyHash := crypto.GenerateLoginKeyHash(userKey, []byte(password))
+	kdf, err := globals.API.GetKDFParams(identifier)
+	if err != nil {
+		return errors.New("error fetching key derivation params")
+	}
 
-	newUserKey := crypto.GenerateUserKey([]byte(identifier), []byte(newPassword))
-	newLoginKeyHash := crypto.GenerateLoginKeyHash(newUserKey, []byte(newPassword))
+	userKey, oldLoginKeyHash := crypto.GenerateUserKeys(identifier, password, kdf)
+
+	newKDF := shared.DefaultKDFParams()
+	newUserKey, newLoginKeyHash := crypto.GenerateUserKeys(identifier, newPassword, newKDF)
 
 	protectedKey, err := globals.API.GetUserProtectedKey()
 	if err != nil {
@@ -66,6 +70,7 @@ func changePassword(identifier, password, newPassword string) error {
 		OldLoginKeyHash: oldLoginKeyHash,
 		NewLoginKeyHash: newLoginKeyHash,
 		ProtectedKey:    newProtectedKey,
+		KDF:             newKDF,
 	})
 }
 
@@ -74,11 +79,15 @@ func changePasswordHint(passwordHint string) error {
 }
 
 func changeEmail(identifier, password, newEmail, changeID string) error {
-	userKey := crypto.GenerateUserKey([]byte(identifier), []byte(password))
-	oldLoginKeyHash := crypto.GenerateLoginKeyHash(userKey, []byte(password))
+	kdf, err := globals.API.GetKDFParams(identifier)
+	if err != nil {
+		return errors.New("error fetching key derivation params")
+	}
+
+	userKey, oldLoginKeyHash := crypto.GenerateUserKeys(identifier, password, kdf)
 
-	newUserKey := crypto.GenerateUserKey([]byte(newEmail), []byte(password))
-	newLoginKeyHash := crypto.GenerateLoginKeyHash(newUserKey, []byte(password))
+	newKDF := shared.DefaultKDFParams()
+	newUserKey, newLoginKeyHash := crypto.GenerateUserKeys(newEmail, password, newKDF)
 
 	protectedKey, err := globals.API.GetUserProtectedKey()
 	if err != nil {
@@ -100,6 +109,7 @@ func changeEmail(identifier, password, newEmail, changeID string) error {
 		OldLoginKeyHash: oldLoginKeyHash,
 		NewLoginKeyHash: newLoginKeyHash,
 		ProtectedKey:    newProtectedKey,
+		KDF:             newKDF,
 	}, changeID)
 }
 
@@ -129,6 +139,11 @@ func FetchAccountDetails() (shared.AccountResponse, string) {
 		twoFactorStr = "Enabled"
 	}
 
+	recoveryKeyStr := "Not Set"
+	if account.HasRecoveryKey {
+		recoveryKeyStr = "Enabled"
+	}
+
 	accountDetails := fmt.Sprintf(""+
 		"Email: %s\n"+
 		"Vault: %s\n"+
@@ -136,6 +151,7 @@ func FetchAccountDetails() (shared.AccountResponse, string) {
 		"Upgrades:      %s\n"+
 		"Password Hint: %s\n"+
 		"Two-Factor:    %s\n"+
+		"Recovery Key:  %s\n"+
 		"Payment ID:    %s",
 		shared.EscapeString(emailStr),
 		storageStr,
@@ -143,6 +159,7 @@ func FetchAccountDetails() (shared.AccountResponse, string) {
 		upgradeStr,
 		passwordHintStr,
 		twoFactorStr,
+		recoveryKeyStr,
 		shared.EscapeString(account.PaymentID))
 
 	return account, accountDetails
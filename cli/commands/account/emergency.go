@@ -0,0 +1,897 @@
+package account
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/huh/spinner"
+
+	"yeetfile/cli/commands/vault/items"
+	"yeetfile/cli/crypto"
+	"yeetfile/cli/globals"
+	"yeetfile/cli/styles"
+	"yeetfile/cli/transfer"
+	"yeetfile/cli/utils"
+	"yeetfile/shared"
+	"yeetfile/shared/constants"
+)
+
+const emergencyPassExportName = "YeetPass.json"
+
+// emergencyPassEntry is a decrypted YeetPass entry included in an emergency
+// vault export
+type emergencyPassEntry struct {
+	Folder string           `json:"folder"`
+	Name   string           `json:"name"`
+	Entry  shared.PassEntry `json:"entry"`
+}
+
+// emergencyExport walks the vault of a user who granted the current user
+// emergency access, and writes the decrypted contents to a local directory
+type emergencyExport struct {
+	grantID     string
+	keyPair     crypto.KeyPair
+	passEntries []emergencyPassEntry
+	numFiles    int
+}
+
+// addEmergencyContact designates another user as an emergency contact, which
+// shares the current user's private key with them (encrypted with the
+// contact's public key)
+func addEmergencyContact(user, accessType string, waitDays int, keyPair crypto.KeyPair) error {
+	publicKey, err := FetchTrustedPubKey(user)
+	if err != nil {
+		return err
+	}
+
+	protectedKey, err := crypto.EncryptWithPublicKey(publicKey, keyPair.PrivateKey)
+	if err != nil {
+		return errors.New("error encrypting private key")
+	}
+
+	_, err = globals.API.AddEmergencyContact(shared.NewEmergencyContact{
+		User:         user,
+		AccessType:   accessType,
+		WaitDays:     waitDays,
+		ProtectedKey: protectedKey,
+	})
+
+	return err
+}
+
+// confirmEmergencyContact shares the current user's private key with an
+// existing emergency contact again, which is needed after the user rotates
+// their keys. The identifier entered by the user needs to match the contact's
+// public key.
+func confirmEmergencyContact(
+	contact shared.EmergencyAccess,
+	user string,
+	keyPair crypto.KeyPair,
+) error {
+	publicKey, err := FetchTrustedPubKey(user)
+	if err != nil {
+		return err
+	} else if crypto.KeyHash(publicKey) != crypto.KeyHash(contact.PublicKey) {
+		return errors.New("identifier doesn't match this emergency contact")
+	}
+
+	protectedKey, err := crypto.EncryptWithPublicKey(publicKey, keyPair.PrivateKey)
+	if err != nil {
+		return errors.New("error encrypting private key")
+	}
+
+	return globals.API.UpdateEmergencyContactKey(contact.ID, protectedKey)
+}
+
+// unlockEmergencyKeys fetches and decrypts the key pair of a user who granted
+// the current user emergency access
+func unlockEmergencyKeys(grantID string) (crypto.KeyPair, shared.EmergencyAccessKeyResponse, error) {
+	keyPair, err := items.UnlockKeyPair()
+	if err != nil {
+		return crypto.KeyPair{}, shared.EmergencyAccessKeyResponse{}, err
+	}
+
+	response, err := globals.API.GetEmergencyAccessKey(grantID)
+	if err != nil {
+		return crypto.KeyPair{}, shared.EmergencyAccessKeyResponse{}, err
+	}
+
+	privateKey, err := crypto.DecryptWithPrivateKey(keyPair.PrivateKey, response.ProtectedKey)
+	if err != nil {
+		return crypto.KeyPair{}, shared.EmergencyAccessKeyResponse{},
+			errors.New("error decrypting emergency access key")
+	}
+
+	return crypto.IngestKeys(privateKey, response.PublicKey), response, nil
+}
+
+// exportEmergencyVault downloads and decrypts every file in the vault of a user
+// who granted the current user emergency access, as well as their YeetPass
+// entries, into the specified directory. Returns the number of exported files.
+func exportEmergencyVault(
+	grantID,
+	dir string,
+	keyPair crypto.KeyPair,
+) (int, error) {
+	export := emergencyExport{
+		grantID:     grantID,
+		keyPair:     keyPair,
+		passEntries: []emergencyPassEntry{},
+	}
+
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return 0, err
+	}
+
+	err = export.exportFolder("", dir, "", false)
+	if err != nil {
+		return export.numFiles, err
+	}
+
+	err = export.exportFolder("", dir, "", true)
+	if err != nil {
+		return export.numFiles, err
+	} else if len(export.passEntries) == 0 {
+		return export.numFiles, nil
+	}
+
+	passData, err := json.MarshalIndent(export.passEntries, "", "  ")
+	if err != nil {
+		return export.numFiles, err
+	}
+
+	passPath := uniqueExportPath(filepath.Join(dir, emergencyPassExportName))
+	return export.numFiles, os.WriteFile(passPath, passData, 0600)
+}
+
+// emergencyTakeover sets a new password for a user who granted the current
+// user takeover access. The user's private key is re-encrypted with the user
+// key derived from their identifier and the new password.
+func emergencyTakeover(grantID, password string) (string, error) {
+	keyPair, response, err := unlockEmergencyKeys(grantID)
+	if err != nil {
+		return "", err
+	} else if len(response.Identifier) == 0 {
+		return "", errors.New("emergency contact doesn't have takeover access")
+	}
+
+	kdf := shared.DefaultKDFParams()
+	userKey, loginKeyHash := crypto.GenerateUserKeys(response.Identifier, password, kdf)
+	protectedKey, err := crypto.EncryptChunk(userKey, keyPair.PrivateKey)
+	if err != nil {
+		return "", errors.New("error encrypting private key")
+	}
+
+	err = globals.API.EmergencyTakeover(grantID, shared.EmergencyTakeover{
+		LoginKeyHash: loginKeyHash,
+		ProtectedKey: protectedKey,
+		KDF:          kdf,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return response.Identifier, nil
+}
+
+func (export *emergencyExport) exportFolder(
+	folderID,
+	dir,
+	folderName string,
+	isPassVault bool,
+) error {
+	folderResp, err := globals.API.FetchEmergencyFolderContents(
+		export.grantID,
+		folderID,
+		isPassVault)
+	if err != nil {
+		return err
+	}
+
+	cryptCtx, err := export.keyPair.DeriveVaultCryptoContext(folderResp.KeySequence)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range folderResp.Items {
+		key, err := export.decryptItemKey(cryptCtx, item)
+		if err != nil {
+			return err
+		}
+
+		name := decryptExportName(key, item.Name, item.ID)
+		if isPassVault {
+			var entry shared.PassEntry
+			entryData, err := crypto.DecryptChunk(key, item.PasswordData)
+			if err != nil {
+				return err
+			} else if err = json.Unmarshal(entryData, &entry); err != nil {
+				return err
+			}
+
+			export.passEntries = append(export.passEntries, emergencyPassEntry{
+				Folder: folderName,
+				Name:   name,
+				Entry:  entry,
+			})
+			continue
+		}
+
+		// Items in the root folder are downloaded using their ref ID,
+		// like in the user's own vault
+		itemID := item.ID
+		if len(folderID) == 0 {
+			itemID = item.RefID
+		}
+
+		err = export.exportFile(itemID, filepath.Join(dir, name), key)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, folder := range folderResp.Folders {
+		key, err := cryptCtx.DecryptFunc(cryptCtx.DecryptionKey, folder.ProtectedKey)
+		if err != nil {
+			return err
+		}
+
+		name := decryptExportName(key, folder.Name, folder.ID)
+		subfolderDir := dir
+		if !isPassVault {
+			subfolderDir = filepath.Join(dir, name)
+			err = os.MkdirAll(subfolderDir, 0700)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = export.exportFolder(
+			folder.ID,
+			subfolderDir,
+			filepath.Join(folderName, name),
+			isPassVault)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (export *emergencyExport) exportFile(id, path string, key []byte) error {
+	path = uniqueExportPath(path)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	p, err := transfer.InitEmergencyVaultDownload(export.grantID, id, key, file)
+	if err != nil {
+		return err
+	}
+
+	err = p.DownloadData(func() {})
+	if err != nil {
+		return err
+	}
+
+	export.numFiles += 1
+	return nil
+}
+
+// decryptItemKey decrypts a vault item's key. Files uploaded to a file request
+// that haven't been opened by the owner yet are still encrypted with the
+// owner's public key, regardless of which folder they're in.
+func (export *emergencyExport) decryptItemKey(
+	cryptCtx crypto.CryptoCtx,
+	item shared.VaultItem,
+) ([]byte, error) {
+	if item.PendingKey {
+		return crypto.DecryptWithPrivateKey(export.keyPair.PrivateKey, item.ProtectedKey)
+	}
+
+	return cryptCtx.DecryptFunc(cryptCtx.DecryptionKey, item.ProtectedKey)
+}
+
+// decryptExportName decrypts the name of a vault item, and replaces anything
+// that can't be used as a file name. The item ID is used if the name can't be
+// decrypted.
+func decryptExportName(key []byte, encName, id string) string {
+	nameBytes, _ := hex.DecodeString(encName)
+	name, err := crypto.DecryptChunk(key, nameBytes)
+	if err != nil {
+		return id
+	}
+
+	safeName := strings.NewReplacer("/", "_", "\\", "_").Replace(string(name))
+	if safeName == "" || safeName == "." || safeName == ".." {
+		return id
+	}
+
+	return safeName
+}
+
+func uniqueExportPath(path string) string {
+	_, statErr := os.Stat(path)
+	for statErr == nil {
+		path = filepath.Join(
+			filepath.Dir(path),
+			shared.CreateNewSaveName(filepath.Base(path)))
+		_, statErr = os.Stat(path)
+	}
+
+	return path
+}
+
+type emergencyOptionKind int
+
+const (
+	exitEmergencyAccess emergencyOptionKind = iota
+	addEmergencyContactOption
+	showEmergencyContact
+	showEmergencyGrantor
+)
+
+type emergencyOption struct {
+	kind emergencyOptionKind
+	idx  int
+}
+
+const emergencyTimeFormat = "02 Jan 2006 15:04 MST"
+
+func showEmergencyAccessView() {
+	var access shared.EmergencyAccessResponse
+	var err error
+	_ = spinner.New().Title("Fetching emergency access...").Action(func() {
+		access, err = globals.API.GetEmergencyAccess()
+	}).Run()
+
+	if err != nil {
+		utils.ShowErrorForm(fmt.Sprintf("Error fetching emergency access: %v", err))
+		ShowAccountModel()
+		return
+	}
+
+	options := []huh.Option[emergencyOption]{}
+	for i, contact := range access.Contacts {
+		label := fmt.Sprintf("Contact: %s | %s | %s",
+			contact.UserName,
+			getEmergencyAccessString(contact.AccessType),
+			getEmergencyStatusString(contact))
+		if !contact.HasKey {
+			label += " | needs confirmation"
+		}
+
+		options = append(options, huh.NewOption(
+			label, emergencyOption{showEmergencyContact, i}))
+	}
+
+	for i, grantor := range access.Grantors {
+		label := fmt.Sprintf("Vault of %s | %s | %s",
+			grantor.UserName,
+			getEmergencyAccessString(grantor.AccessType),
+			getEmergencyStatusString(grantor))
+		options = append(options, huh.NewOption(
+			label, emergencyOption{showEmergencyGrantor, i}))
+	}
+
+	options = append(options,
+		huh.NewOption("Add Emergency Contact",
+			emergencyOption{kind: addEmergencyContactOption}),
+		huh.NewOption("Back", emergencyOption{kind: exitEmergencyAccess}))
+
+	desc := "Emergency contacts can request access to your vault if " +
+		"you become unavailable. You'll be notified by email when " +
+		"a contact requests access, and they'll only be given " +
+		"access if you don't deny the request before the waiting " +
+		"period ends. Users who added you as a contact are listed " +
+		"here too."
+
+	selected := emergencyOption{kind: exitEmergencyAccess}
+	err = huh.NewForm(huh.NewGroup(
+		utils.CreateHeader("Emergency Access", desc),
+		huh.NewSelect[emergencyOption]().
+			Options(options...).
+			Value(&selected),
+	)).WithTheme(styles.Theme).Run()
+	if err != nil {
+		return
+	}
+
+	switch selected.kind {
+	case addEmergencyContactOption:
+		showAddEmergencyContactView()
+	case showEmergencyContact:
+		showEmergencyContactView(access.Contacts[selected.idx])
+	case showEmergencyGrantor:
+		showEmergencyGrantorView(access.Grantors[selected.idx])
+	default:
+		ShowAccountModel()
+	}
+}
+
+func showAddEmergencyContactView() {
+	var user string
+	var accessType string
+	var waitDays string
+	var confirmed bool
+
+	account, _ := FetchAccountDetails()
+	desc := "Your emergency contact will be able to request access to " +
+		"your vault. If you don't deny the request within the waiting " +
+		"period, they'll be able to download your vault, or with " +
+		"takeover access, set a new password for your account."
+	if len(account.Email) == 0 || !globals.ServerInfo.EmailConfigured {
+		desc += "\n\nYou won't be notified by email when a contact " +
+			"requests access, so check this page regularly if " +
+			"you add a contact."
+	}
+
+	contactForm := func(prevErr error) (bool, error) {
+		var errMsg string
+		if prevErr != nil {
+			errMsg = styles.ErrStyle.Render(prevErr.Error())
+		}
+
+		err := huh.NewForm(huh.NewGroup(
+			utils.CreateHeader("Add Emergency Contact", desc),
+			huh.NewInput().
+				Title("Contact").
+				Placeholder("Email / Account ID").
+				Value(&user),
+			huh.NewSelect[string]().
+				Title("Access").
+				Options(
+					huh.NewOption(
+						getEmergencyAccessString(constants.EmergencyAccessView),
+						constants.EmergencyAccessView),
+					huh.NewOption(
+						getEmergencyAccessString(constants.EmergencyAccessTakeover),
+						constants.EmergencyAccessTakeover)).
+				Value(&accessType),
+			huh.NewInput().
+				Title("Waiting Period (Days)").
+				Placeholder(fmt.Sprintf("1-%d", constants.MaxEmergencyWaitDays)).
+				Validate(validateEmergencyWaitDays).
+				Value(&waitDays),
+			huh.NewConfirm().
+				Description(errMsg).
+				Affirmative("Add Contact").
+				Negative("Cancel").
+				Value(&confirmed),
+		)).WithTheme(styles.Theme).Run()
+		if err == huh.ErrUserAborted || !confirmed {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+
+		// Not run in a spinner, since the user may be prompted for
+		// their vault password in order to decrypt their private key
+		keyPair, err := items.UnlockKeyPair()
+		if err != nil {
+			return false, err
+		}
+
+		days, _ := strconv.Atoi(waitDays)
+		_ = spinner.New().Title("Adding emergency contact...").Action(func() {
+			err = addEmergencyContact(user, accessType, days, keyPair)
+		}).Run()
+
+		return err == nil, err
+	}
+
+	_, err := contactForm(nil)
+	for err != nil {
+		_, err = contactForm(err)
+	}
+
+	showEmergencyAccessView()
+}
+
+func showEmergencyContactView(contact shared.EmergencyAccess) {
+	const (
+		back = iota
+		deny
+		confirm
+		remove
+	)
+
+	details := fmt.Sprintf("Contact:        %s\n"+
+		"Fingerprint:    %s\n"+
+		"Access:         %s\n"+
+		"Waiting Period: %d day(s)\n"+
+		"Status:         %s",
+		contact.UserName,
+		crypto.KeyFingerprint(contact.PublicKey),
+		getEmergencyAccessString(contact.AccessType),
+		contact.WaitDays,
+		getEmergencyStatusString(contact))
+	if !contact.HasKey {
+		details += "\n\nYour keys have changed since this contact was " +
+			"added. Confirm the contact again to restore their " +
+			"emergency access."
+	}
+
+	options := []huh.Option[int]{}
+	if contact.Status != constants.EmergencyStatusIdle {
+		options = append(options, huh.NewOption("Deny Request", deny))
+	}
+
+	if !contact.HasKey {
+		options = append(options, huh.NewOption("Confirm Contact", confirm))
+	}
+
+	options = append(options,
+		huh.NewOption("Remove Contact", remove),
+		huh.NewOption("Back", back))
+
+	var action int
+	err := huh.NewForm(huh.NewGroup(
+		utils.CreateHeader("Emergency Contact", details),
+		huh.NewSelect[int]().
+			Options(options...).
+			Value(&action),
+	)).WithTheme(styles.Theme).Run()
+	if err != nil {
+		return
+	}
+
+	switch action {
+	case deny:
+		_ = spinner.New().Title("Denying request...").Action(func() {
+			err = globals.API.DenyEmergencyAccess(contact.ID)
+		}).Run()
+	case confirm:
+		showConfirmEmergencyContactView(contact)
+		return
+	case remove:
+		_ = spinner.New().Title("Removing contact...").Action(func() {
+			err = globals.API.RemoveEmergencyContact(contact.ID)
+		}).Run()
+	}
+
+	if err != nil {
+		utils.ShowErrorForm(fmt.Sprintf("Error updating emergency contact: %v", err))
+	}
+
+	showEmergencyAccessView()
+}
+
+func showConfirmEmergencyContactView(contact shared.EmergencyAccess) {
+	var user string
+	var confirmed bool
+	if strings.Contains(contact.UserName, "@") {
+		user = contact.UserName
+	}
+
+	desc := fmt.Sprintf("Enter the email or account ID of %s to share "+
+		"your new keys with them. Their public key needs to match the "+
+		"fingerprint below.\n\n%s",
+		contact.UserName,
+		FormatFingerprint(contact.PublicKey))
+
+	confirmForm := func(prevErr error) error {
+		var errMsg string
+		if prevErr != nil {
+			errMsg = styles.ErrStyle.Render(prevErr.Error())
+		}
+
+		err := huh.NewForm(huh.NewGroup(
+			utils.CreateHeader("Confirm Emergency Contact", desc),
+			huh.NewInput().
+				Title("Contact").
+				Placeholder("Email / Account ID").
+				Value(&user),
+			huh.NewConfirm().
+				Description(errMsg).
+				Affirmative("Confirm").
+				Negative("Cancel").
+				Value(&confirmed),
+		)).WithTheme(styles.Theme).Run()
+		if err == huh.ErrUserAborted || !confirmed {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		keyPair, err := items.UnlockKeyPair()
+		if err != nil {
+			return err
+		}
+
+		_ = spinner.New().Title("Confirming contact...").Action(func() {
+			err = confirmEmergencyContact(contact, user, keyPair)
+		}).Run()
+
+		return err
+	}
+
+	err := confirmForm(nil)
+	for err != nil {
+		err = confirmForm(err)
+	}
+
+	showEmergencyAccessView()
+}
+
+func showEmergencyGrantorView(grantor shared.EmergencyAccess) {
+	const (
+		back = iota
+		request
+		cancel
+		download
+		takeover
+		remove
+	)
+
+	details := fmt.Sprintf("From:           %s\n"+
+		"Access:         %s\n"+
+		"Waiting Period: %d day(s)\n"+
+		"Status:         %s",
+		grantor.UserName,
+		getEmergencyAccessString(grantor.AccessType),
+		grantor.WaitDays,
+		getEmergencyStatusString(grantor))
+
+	options := []huh.Option[int]{}
+	switch grantor.Status {
+	case constants.EmergencyStatusIdle:
+		options = append(options, huh.NewOption("Request Access", request))
+	case constants.EmergencyStatusRequested:
+		options = append(options, huh.NewOption("Cancel Request", cancel))
+	case constants.EmergencyStatusGranted:
+		options = append(options, huh.NewOption("Download Vault", download))
+		if grantor.AccessType == constants.EmergencyAccessTakeover {
+			options = append(options, huh.NewOption("Take Over Account", takeover))
+		}
+
+		options = append(options, huh.NewOption("End Access", cancel))
+	}
+
+	options = append(options,
+		huh.NewOption("Remove", remove),
+		huh.NewOption("Back", back))
+
+	var action int
+	err := huh.NewForm(huh.NewGroup(
+		utils.CreateHeader("Emergency Access", details),
+		huh.NewSelect[int]().
+			Options(options...).
+			Value(&action),
+	)).WithTheme(styles.Theme).Run()
+	if err != nil {
+		return
+	}
+
+	switch action {
+	case request:
+		var confirmed bool
+		_ = huh.NewForm(huh.NewGroup(
+			utils.CreateHeader("Request Access", fmt.Sprintf(
+				"%s will be notified of your request by email. "+
+					"If they don't deny it within %d day(s), "+
+					"you'll be given access to their vault.",
+				grantor.UserName, grantor.WaitDays)),
+			huh.NewConfirm().
+				Affirmative("Request Access").
+				Negative("Cancel").
+				Value(&confirmed),
+		)).WithTheme(styles.Theme).Run()
+		if !confirmed {
+			showEmergencyGrantorView(grantor)
+			return
+		}
+
+		_ = spinner.New().Title("Requesting access...").Action(func() {
+			err = globals.API.RequestEmergencyAccess(grantor.ID)
+		}).Run()
+	case cancel:
+		_ = spinner.New().Title("Updating request...").Action(func() {
+			err = globals.API.DenyEmergencyAccess(grantor.ID)
+		}).Run()
+	case download:
+		showEmergencyDownloadView(grantor)
+		return
+	case takeover:
+		showEmergencyTakeoverView(grantor)
+		return
+	case remove:
+		_ = spinner.New().Title("Removing emergency access...").Action(func() {
+			err = globals.API.RemoveEmergencyContact(grantor.ID)
+		}).Run()
+	}
+
+	if err != nil {
+		utils.ShowErrorForm(fmt.Sprintf("Error updating emergency access: %v", err))
+	}
+
+	showEmergencyAccessView()
+}
+
+func showEmergencyDownloadView(grantor shared.EmergencyAccess) {
+	var dir string
+	var confirmed bool
+	var numFiles int
+
+	desc := fmt.Sprintf("Every file in the vault of %s will be downloaded "+
+		"and decrypted into the directory below. Their YeetPass "+
+		"entries will be saved unencrypted to %s in the same "+
+		"directory, so keep it somewhere safe.",
+		grantor.UserName, emergencyPassExportName)
+
+	downloadForm := func(prevErr error) (bool, error) {
+		var errMsg string
+		if prevErr != nil {
+			errMsg = styles.ErrStyle.Render(prevErr.Error())
+		}
+
+		err := huh.NewForm(huh.NewGroup(
+			utils.CreateHeader("Download Vault", desc),
+			huh.NewInput().
+				Title("Directory").
+				Placeholder("path/to/directory").
+				Value(&dir),
+			huh.NewConfirm().
+				Description(errMsg).
+				Affirmative("Download").
+				Negative("Cancel").
+				Value(&confirmed),
+		)).WithTheme(styles.Theme).Run()
+		if err == huh.ErrUserAborted || !confirmed {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		} else if len(dir) == 0 {
+			return false, errors.New("missing directory")
+		}
+
+		_, err = items.UnlockKeyPair()
+		if err != nil {
+			return false, err
+		}
+
+		_ = spinner.New().Title("Downloading vault...").Action(func() {
+			var keyPair crypto.KeyPair
+			keyPair, _, err = unlockEmergencyKeys(grantor.ID)
+			if err != nil {
+				return
+			}
+
+			numFiles, err = exportEmergencyVault(grantor.ID, dir, keyPair)
+		}).Run()
+
+		return err == nil, err
+	}
+
+	downloaded, err := downloadForm(nil)
+	for err != nil {
+		downloaded, err = downloadForm(err)
+	}
+
+	if downloaded {
+		_ = huh.NewForm(huh.NewGroup(
+			utils.CreateHeader("Download Vault", fmt.Sprintf(
+				"Downloaded %d file(s) to %s", numFiles, dir)),
+			huh.NewConfirm().Affirmative("OK").Negative(""),
+		)).WithTheme(styles.Theme).Run()
+	}
+
+	showEmergencyAccessView()
+}
+
+func showEmergencyTakeoverView(grantor shared.EmergencyAccess) {
+	var password string
+	var confirmPassword string
+	var confirmed bool
+	var identifier string
+
+	desc := fmt.Sprintf("Set a new password for the account of %s. "+
+		"Their 2FA, password hint, and existing sessions will be "+
+		"removed, and you'll be able to log in to their account with "+
+		"the new password.", grantor.UserName)
+
+	takeoverForm := func(prevErr error) (bool, error) {
+		var errMsg string
+		if prevErr != nil {
+			errMsg = styles.ErrStyle.Render(prevErr.Error())
+		}
+
+		err := huh.NewForm(huh.NewGroup(
+			utils.CreateHeader("Take Over Account", desc),
+			huh.NewInput().
+				Title("New Password").
+				EchoMode(huh.EchoModePassword).
+				Value(&password),
+			huh.NewInput().
+				Title("Confirm New Password").
+				EchoMode(huh.EchoModePassword).
+				Value(&confirmPassword),
+			huh.NewConfirm().
+				Description(errMsg).
+				Affirmative("Take Over Account").
+				Negative("Cancel").
+				Value(&confirmed),
+		)).WithTheme(styles.Theme).Run()
+		if err == huh.ErrUserAborted || !confirmed {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		} else if len(password) == 0 {
+			return false, errors.New("missing new password")
+		} else if password != confirmPassword {
+			return false, errors.New("passwords don't match")
+		}
+
+		_, err = items.UnlockKeyPair()
+		if err != nil {
+			return false, err
+		}
+
+		_ = spinner.New().Title("Taking over account...").Action(func() {
+			identifier, err = emergencyTakeover(grantor.ID, password)
+		}).Run()
+
+		return err == nil, err
+	}
+
+	tookOver, err := takeoverForm(nil)
+	for err != nil {
+		tookOver, err = takeoverForm(err)
+	}
+
+	if tookOver {
+		_ = huh.NewForm(huh.NewGroup(
+			utils.CreateHeader("Take Over Account", fmt.Sprintf(
+				"You can now log in as %s using the new password.",
+				identifier)),
+			huh.NewConfirm().Affirmative("OK").Negative(""),
+		)).WithTheme(styles.Theme).Run()
+	}
+
+	showEmergencyAccessView()
+}
+
+func validateEmergencyWaitDays(waitDays string) error {
+	days, err := strconv.Atoi(waitDays)
+	if err != nil || days < 1 || days > constants.MaxEmergencyWaitDays {
+		return fmt.Errorf("must be between 1 and %d",
+			constants.MaxEmergencyWaitDays)
+	}
+
+	return nil
+}
+
+func getEmergencyAccessString(accessType string) string {
+	if accessType == constants.EmergencyAccessTakeover {
+		return "Takeover"
+	}
+
+	return "View Only"
+}
+
+func getEmergencyStatusString(access shared.EmergencyAccess) string {
+	switch access.Status {
+	case constants.EmergencyStatusRequested:
+		granted := access.Requested.Add(
+			time.Duration(access.WaitDays) * 24 * time.Hour)
+		return fmt.Sprintf("Requested (granted %s)",
+			utils.LocalTimeFromUTC(granted).Format(emergencyTimeFormat))
+	case constants.EmergencyStatusGranted:
+		return "Granted"
+	default:
+		return "Not Requested"
+	}
+}
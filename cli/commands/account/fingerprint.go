@@ -0,0 +1,190 @@
+package account
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/huh/spinner"
+
+	"yeetfile/cli/crypto"
+	"yeetfile/cli/globals"
+	"yeetfile/cli/styles"
+	"yeetfile/cli/utils"
+)
+
+// KeyChangedError is returned when the public key the server returns for a
+// user doesn't match the key that was previously pinned for them
+type KeyChangedError struct {
+	User      string
+	PublicKey []byte
+}
+
+func (e *KeyChangedError) Error() string {
+	return fmt.Sprintf("WARNING: The public key for %[1]s has changed since "+
+		"it was first pinned. This can happen if they changed "+
+		"their keys, but could also mean that the server is trying to read "+
+		"content you share with them. Compare fingerprints with %[1]s "+
+		"outside of YeetFile, and run 'yeetfile account fingerprint "+
+		"%[1]s' to trust the new key.\n\nNew fingerprint: %[2]s",
+		e.User, crypto.KeyFingerprintWords(e.PublicKey))
+}
+
+// FetchPubKey fetches a user's public key and checks it against the key pinned
+// for them in the config directory. Returns the key, whether it was already
+// pinned, and a KeyChangedError if the pinned key doesn't match.
+func FetchPubKey(user string) ([]byte, bool, error) {
+	pubKeyResponse, err := globals.API.FetchUserPubKey(user)
+	if err != nil {
+		return nil, false, err
+	}
+
+	pinnedHash, err := globals.Config.GetPinnedKey(user)
+	if err != nil {
+		return nil, false, err
+	} else if len(pinnedHash) == 0 {
+		return pubKeyResponse.PublicKey, false, nil
+	} else if pinnedHash != crypto.KeyHash(pubKeyResponse.PublicKey) {
+		return nil, true, &KeyChangedError{
+			User:      user,
+			PublicKey: pubKeyResponse.PublicKey,
+		}
+	}
+
+	return pubKeyResponse.PublicKey, true, nil
+}
+
+// FetchTrustedPubKey fetches a user's public key, pinning it if this is the
+// first time the key has been used (trust on first use). Returns an error if
+// the key doesn't match the one already pinned for the user.
+func FetchTrustedPubKey(user string) ([]byte, error) {
+	publicKey, pinned, err := FetchPubKey(user)
+	if err != nil {
+		return nil, err
+	} else if !pinned {
+		err = PinPubKey(user, publicKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return publicKey, nil
+}
+
+// PinPubKey saves the hash of a user's public key to the config directory, so
+// that the CLI can warn if the server returns a different key for them later
+func PinPubKey(user string, publicKey []byte) error {
+	return globals.Config.SetPinnedKey(user, crypto.KeyHash(publicKey))
+}
+
+// FormatFingerprint returns both the word and hex versions of a public key's
+// fingerprint, for displaying to the user
+func FormatFingerprint(publicKey []byte) string {
+	return fmt.Sprintf("%s\n%s",
+		crypto.KeyFingerprintWords(publicKey),
+		crypto.KeyFingerprint(publicKey))
+}
+
+// ShowFingerprintCommand handles 'yeetfile account fingerprint [user]'. Without
+// a user, it prints the fingerprint of the current user's public key. With a
+// user, it shows the fingerprint of that user's key and allows pinning it.
+func ShowFingerprintCommand(args []string) {
+	if len(args) == 0 {
+		_, publicKey, err := globals.Config.GetKeys()
+		if err != nil {
+			utils.HandleCLIError("Error reading public key", err)
+			return
+		}
+
+		fmt.Printf("Your public key fingerprint:\n\n%s\n\n"+
+			"Other users can compare this with the fingerprint shown "+
+			"by 'yeetfile account fingerprint <your email or ID>'.\n",
+			FormatFingerprint(publicKey))
+		return
+	}
+
+	showUserFingerprintView(args[0])
+}
+
+func showOwnFingerprintView() {
+	_, publicKey, err := globals.Config.GetKeys()
+	if err != nil {
+		utils.ShowErrorForm(fmt.Sprintf("Error reading public key: %v", err))
+		ShowAccountModel()
+		return
+	}
+
+	desc := fmt.Sprintf("%s\n\n", FormatFingerprint(publicKey)) +
+		utils.GenerateWrappedText("Users you share with can compare "+
+			"this with the fingerprint their CLI shows for you "+
+			"('yeetfile account fingerprint <your email or ID>') "+
+			"to verify the server hasn't replaced your key.")
+
+	_ = huh.NewForm(huh.NewGroup(
+		huh.NewNote().
+			Title(utils.GenerateTitle("Key Fingerprint")).
+			Description(desc),
+		huh.NewConfirm().Affirmative("OK").Negative(""),
+	)).WithTheme(styles.Theme).Run()
+
+	ShowAccountModel()
+}
+
+func showUserFingerprintView(user string) {
+	var publicKey []byte
+	var pinned bool
+	var err error
+	_ = spinner.New().Title("Fetching public key...").Action(func() {
+		publicKey, pinned, err = FetchPubKey(user)
+	}).Run()
+
+	var status string
+	var keyChangedErr *KeyChangedError
+	if errors.As(err, &keyChangedErr) {
+		publicKey = keyChangedErr.PublicKey
+		status = styles.ErrStyle.Render("Changed! This key doesn't " +
+			"match the one previously pinned for this user.")
+	} else if err != nil {
+		utils.HandleCLIError("Error fetching public key", err)
+		return
+	} else if pinned {
+		status = "Pinned"
+	} else {
+		status = "Not pinned"
+	}
+
+	desc := fmt.Sprintf("User:   %s\nStatus: %s\n\n%s\n\n",
+		user, status, FormatFingerprint(publicKey)) +
+		utils.GenerateWrappedText("Ask the user to run 'yeetfile "+
+			"account fingerprint' and compare the result outside of "+
+			"YeetFile before trusting this key.")
+
+	header := huh.NewNote().
+		Title(utils.GenerateTitle("Key Fingerprint")).
+		Description(desc)
+
+	if pinned && keyChangedErr == nil {
+		_ = huh.NewForm(huh.NewGroup(
+			header,
+			huh.NewConfirm().Affirmative("OK").Negative(""),
+		)).WithTheme(styles.Theme).Run()
+		return
+	}
+
+	var trust bool
+	err = huh.NewForm(huh.NewGroup(
+		header,
+		huh.NewConfirm().
+			Affirmative("Trust Key").
+			Negative("Cancel").
+			Value(&trust),
+	)).WithTheme(styles.Theme).Run()
+	if err != nil || !trust {
+		return
+	}
+
+	err = PinPubKey(user, publicKey)
+	if err != nil {
+		utils.ShowErrorForm(fmt.Sprintf("Error pinning key: %v", err))
+	}
+}
@@ -0,0 +1,199 @@
+package account
+
+import (
+	"errors"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/huh/spinner"
+
+	"yeetfile/cli/crypto"
+	"yeetfile/cli/globals"
+	"yeetfile/cli/styles"
+	"yeetfile/cli/utils"
+	"yeetfile/shared"
+)
+
+// setRecoveryKey generates a new recovery phrase and stores a copy of the
+// user's private key encrypted with the recovery key derived from it. Returns
+// the recovery phrase, which is never sent to the server.
+func setRecoveryKey(identifier, password string) (string, error) {
+	kdf, err := globals.API.GetKDFParams(identifier)
+	if err != nil {
+		return "", errors.New("error fetching key derivation params")
+	}
+
+	userKey, loginKeyHash := crypto.GenerateUserKeys(identifier, password, kdf)
+
+	protectedKey, err := globals.API.GetUserProtectedKey()
+	if err != nil {
+		return "", errors.New("error fetching protected key")
+	}
+
+	privateKey, err := crypto.DecryptChunk(userKey, protectedKey)
+	if err != nil {
+		return "", errors.New("incorrect identifier or password")
+	}
+
+	phrase, err := crypto.GenerateRecoveryPhrase()
+	if err != nil {
+		return "", err
+	}
+
+	recoveryKey, recoveryKeyHash, err := crypto.GenerateRecoveryKeys(phrase)
+	if err != nil {
+		return "", err
+	}
+
+	recoveryProtectedKey, err := crypto.EncryptChunk(recoveryKey, privateKey)
+	if err != nil {
+		return "", errors.New("error encrypting private key")
+	}
+
+	err = globals.API.SetRecoveryKey(shared.SetRecoveryKey{
+		LoginKeyHash:    loginKeyHash,
+		RecoveryKeyHash: recoveryKeyHash,
+		ProtectedKey:    recoveryProtectedKey,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return phrase, nil
+}
+
+func showSetRecoveryKeyView() {
+	var identifier string
+	var password string
+	var confirmed bool
+	var phrase string
+
+	desc := "A recovery key is a phrase that can be used to set a new " +
+		"password if you forget your current one, without losing " +
+		"access to your vault. Anyone with your recovery key (and 2FA " +
+		"code, if enabled) can take over your account, so store it " +
+		"somewhere safe and offline. Creating a new recovery key " +
+		"replaces your existing one."
+
+	recoveryKeyForm := func(prevErr error) (bool, error) {
+		var errMsg string
+		if prevErr != nil {
+			errMsg = styles.ErrStyle.Render(prevErr.Error())
+		}
+
+		err := huh.NewForm(huh.NewGroup(
+			utils.CreateHeader("Create Recovery Key", desc),
+			huh.NewInput().
+				Title("Identifier").
+				Placeholder("Email / Account ID").
+				Value(&identifier),
+			huh.NewInput().
+				Title("Password").
+				EchoMode(huh.EchoModePassword).
+				Value(&password),
+			huh.NewConfirm().
+				Description(errMsg).
+				Affirmative("Create Recovery Key").
+				Negative("Cancel").
+				Value(&confirmed),
+		)).WithTheme(styles.Theme).Run()
+		if err == huh.ErrUserAborted || !confirmed {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+
+		_ = spinner.New().Title("Creating recovery key...").Action(func() {
+			phrase, err = setRecoveryKey(identifier, password)
+		}).Run()
+
+		return err == nil, err
+	}
+
+	created, err := recoveryKeyForm(nil)
+	for err != nil {
+		created, err = recoveryKeyForm(err)
+	}
+
+	if created {
+		_ = huh.NewForm(huh.NewGroup(
+			utils.CreateHeader("Create Recovery Key",
+				"Your recovery key has been created. Write it "+
+					"down somewhere safe, it WILL NOT be shown "+
+					"again."),
+			huh.NewNote().Title("Recovery Key").Description(phrase),
+			huh.NewConfirm().Affirmative("OK").Negative(""),
+		)).WithTheme(styles.Theme).Run()
+	}
+
+	ShowAccountModel()
+}
+
+// removeRecoveryKey removes the user's recovery key after deriving their login
+// key hash from their password
+func removeRecoveryKey(identifier, password string) error {
+	kdf, err := globals.API.GetKDFParams(identifier)
+	if err != nil {
+		return errors.New("error fetching key derivation params")
+	}
+
+	_, loginKeyHash := crypto.GenerateUserKeys(identifier, password, kdf)
+	err = globals.API.RemoveRecoveryKey(shared.RemoveRecoveryKey{
+		LoginKeyHash: loginKeyHash,
+	})
+	if err != nil {
+		return errors.New("incorrect identifier or password")
+	}
+
+	return nil
+}
+
+func showRemoveRecoveryKeyView() {
+	var identifier string
+	var password string
+	var confirmed bool
+
+	desc := "Without a recovery key, your vault can't be recovered if " +
+		"you forget your password. Enter your password to continue."
+
+	removeForm := func(prevErr error) error {
+		var errMsg string
+		if prevErr != nil {
+			errMsg = styles.ErrStyle.Render(prevErr.Error())
+		}
+
+		err := huh.NewForm(huh.NewGroup(
+			utils.CreateHeader("Remove Recovery Key", desc),
+			huh.NewInput().
+				Title("Identifier").
+				Placeholder("Email / Account ID").
+				Value(&identifier),
+			huh.NewInput().
+				Title("Password").
+				EchoMode(huh.EchoModePassword).
+				Value(&password),
+			huh.NewConfirm().
+				Description(errMsg).
+				Affirmative("Remove Recovery Key").
+				Negative("Cancel").
+				Value(&confirmed),
+		)).WithTheme(styles.Theme).Run()
+		if err == huh.ErrUserAborted || !confirmed {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		_ = spinner.New().Title("Removing recovery key...").Action(func() {
+			err = removeRecoveryKey(identifier, password)
+		}).Run()
+
+		return err
+	}
+
+	err := removeForm(nil)
+	for err != nil {
+		err = removeForm(err)
+	}
+
+	ShowAccountModel()
+}
@@ -0,0 +1,232 @@
+package account
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/huh/spinner"
+
+	"yeetfile/cli/crypto"
+	"yeetfile/cli/globals"
+	"yeetfile/cli/styles"
+	"yeetfile/cli/utils"
+	"yeetfile/shared"
+)
+
+// rotateKeys generates a new key pair for the user and re-encrypts every key
+// that was encrypted with their previous public key. The vault key is used to
+// store the new private key in the config directory, replacing the old one.
+func rotateKeys(identifier, password string, vaultKey []byte) error {
+	kdf, err := globals.API.GetKDFParams(identifier)
+	if err != nil {
+		return errors.New("error fetching key derivation params")
+	}
+
+	userKey, loginKeyHash := crypto.GenerateUserKeys(identifier, password, kdf)
+
+	protectedKey, err := globals.API.GetUserProtectedKey()
+	if err != nil {
+		return errors.New("error fetching protected key")
+	}
+
+	privateKey, err := crypto.DecryptChunk(userKey, protectedKey)
+	if err != nil {
+		return errors.New("incorrect identifier or password")
+	}
+
+	wrappedKeys, err := globals.API.GetWrappedKeys()
+	if err != nil {
+		return err
+	}
+
+	newPrivateKey, newPublicKey, err := crypto.GenerateKeyPair()
+	if err != nil {
+		return errors.New("error generating new key pair")
+	}
+
+	newProtectedKey, err := crypto.EncryptChunk(userKey, newPrivateKey)
+	if err != nil {
+		return errors.New("error encrypting private key")
+	}
+
+	rewrap := func(keys []shared.WrappedKey) ([]shared.WrappedKey, error) {
+		rewrapped := []shared.WrappedKey{}
+		for _, key := range keys {
+			decrypted, err := crypto.DecryptWithPrivateKey(privateKey, key.ProtectedKey)
+			if err != nil {
+				return nil, err
+			}
+
+			encrypted, err := crypto.EncryptWithPublicKey(newPublicKey, decrypted)
+			if err != nil {
+				return nil, err
+			}
+
+			rewrapped = append(rewrapped, shared.WrappedKey{
+				ID:           key.ID,
+				ProtectedKey: encrypted,
+			})
+		}
+
+		return rewrapped, nil
+	}
+
+	rotation := shared.KeyRotation{
+		KeyVersion:   wrappedKeys.KeyVersion,
+		LoginKeyHash: loginKeyHash,
+		PublicKey:    newPublicKey,
+		ProtectedKey: newProtectedKey,
+	}
+
+	for _, group := range []struct {
+		keys      []shared.WrappedKey
+		rewrapped *[]shared.WrappedKey
+	}{
+		{wrappedKeys.Keys.Folders, &rotation.Keys.Folders},
+		{wrappedKeys.Keys.Items, &rotation.Keys.Items},
+		{wrappedKeys.Keys.Shares, &rotation.Keys.Shares},
+		{wrappedKeys.Keys.Transfers, &rotation.Keys.Transfers},
+		{wrappedKeys.Keys.Sends, &rotation.Keys.Sends},
+		{wrappedKeys.Keys.Emergency, &rotation.Keys.Emergency},
+	} {
+		*group.rewrapped, err = rewrap(group.keys)
+		if err != nil {
+			return fmt.Errorf("error re-encrypting keys: %v", err)
+		}
+	}
+
+	session, err := globals.API.RotateKeys(rotation)
+	if err != nil {
+		return err
+	}
+
+	encPrivateKey, err := crypto.EncryptChunk(vaultKey, newPrivateKey)
+	if err != nil {
+		return err
+	}
+
+	err = globals.Config.SetKeys(encPrivateKey, newPublicKey)
+	if err != nil {
+		return err
+	}
+
+	encSession, err := crypto.EncryptChunk(crypto.ReadCLIKey(), []byte(session))
+	if err != nil {
+		return err
+	}
+
+	return globals.Config.SetSession(string(encSession))
+}
+
+// getVaultKey returns the key used to encrypt the private key stored in the
+// config directory. This is the CLI key, unless the user set a separate vault
+// password when logging in, in which case the password is required.
+func getVaultKey(vaultPassword string) ([]byte, error) {
+	cliKey := crypto.ReadCLIKey()
+	encPrivateKey, _, err := globals.Config.GetKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	vaultKey := cliKey
+	if len(vaultPassword) > 0 {
+		vaultKey = crypto.DerivePBKDFKey([]byte(vaultPassword), cliKey)
+	}
+
+	_, err = crypto.DecryptChunk(vaultKey, encPrivateKey)
+	if err != nil {
+		return nil, errors.New("incorrect vault password")
+	}
+
+	return vaultKey, nil
+}
+
+func showRotateKeysView() {
+	var identifier string
+	var password string
+	var vaultPassword string
+	var confirmed bool
+
+	_, err := getVaultKey("")
+	needsVaultPassword := err != nil
+
+	desc := "Rotating your keys generates a new key pair and re-encrypts " +
+		"your root folder, items shared with you, and pending " +
+		"invitations, transfers, and sends with the new public key. " +
+		"Your other sessions will be logged out, and your recovery key " +
+		"will be removed. Your emergency contacts will need to be " +
+		"re-confirmed before they can access your vault. Users who " +
+		"pinned your key will be warned that it changed until they " +
+		"verify your new fingerprint."
+
+	rotateKeysForm := func(prevErr error) (bool, error) {
+		var errMsg string
+		if prevErr != nil {
+			errMsg = styles.ErrStyle.Render(prevErr.Error())
+		}
+
+		fields := []huh.Field{
+			utils.CreateHeader("Rotate Keys", desc),
+			huh.NewInput().
+				Title("Identifier").
+				Placeholder("Email / Account ID").
+				Value(&identifier),
+			huh.NewInput().
+				Title("Password").
+				EchoMode(huh.EchoModePassword).
+				Value(&password),
+		}
+
+		if needsVaultPassword {
+			fields = append(fields, huh.NewInput().
+				Title("Vault Password").
+				EchoMode(huh.EchoModePassword).
+				Value(&vaultPassword))
+		}
+
+		fields = append(fields, huh.NewConfirm().
+			Description(errMsg).
+			Affirmative("Rotate Keys").
+			Negative("Cancel").
+			Value(&confirmed))
+
+		err := huh.NewForm(huh.NewGroup(fields...)).
+			WithTheme(styles.Theme).Run()
+		if err == huh.ErrUserAborted || !confirmed {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+
+		vaultKey, err := getVaultKey(vaultPassword)
+		if err != nil {
+			return false, err
+		}
+
+		_ = spinner.New().Title("Rotating keys...").Action(func() {
+			err = rotateKeys(identifier, password, vaultKey)
+		}).Run()
+
+		return err == nil, err
+	}
+
+	rotated, err := rotateKeysForm(nil)
+	for err != nil {
+		rotated, err = rotateKeysForm(err)
+	}
+
+	if rotated {
+		_, publicKey, _ := globals.Config.GetKeys()
+		_ = huh.NewForm(huh.NewGroup(
+			huh.NewNote().
+				Title(utils.GenerateTitle("Rotate Keys")).
+				Description(fmt.Sprintf("Your keys have been rotated. "+
+					"Your new key fingerprint is:\n\n%s",
+					FormatFingerprint(publicKey))),
+			huh.NewConfirm().Affirmative("OK").Negative(""),
+		)).WithTheme(styles.Theme).Run()
+	}
+
+	ShowAccountModel()
+}
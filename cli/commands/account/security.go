@@ -0,0 +1,140 @@
+package account
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/huh/spinner"
+
+	"yeetfile/cli/globals"
+	"yeetfile/cli/styles"
+	"yeetfile/cli/utils"
+	"yeetfile/shared"
+	"yeetfile/shared/constants"
+)
+
+const securityTimeFormat = "02 Jan 2006 15:04 MST"
+
+type securityOption int
+
+const (
+	toggleLoginAlerts securityOption = iota
+	exitSecurityLog
+)
+
+// getSecurityEventString returns a readable label for a security event type
+func getSecurityEventString(eventType string) string {
+	switch eventType {
+	case constants.EventLogin:
+		return "Login"
+	case constants.EventLoginFailed:
+		return "Failed login"
+	case constants.Event2FAEnabled:
+		return "2FA enabled"
+	case constants.Event2FADisabled:
+		return "2FA disabled"
+	case constants.EventPasswordChanged:
+		return "Password changed"
+	case constants.EventKDFUpgraded:
+		return "Password key derivation upgraded"
+	case constants.EventEmailChanged:
+		return "Email changed"
+	case constants.EventHintChanged:
+		return "Password hint changed"
+	case constants.EventRecoveryChanged:
+		return "Recovery key changed"
+	case constants.EventShareGranted:
+		return "Vault item shared"
+	case constants.EventSessionsRevoked:
+		return "Sessions signed out"
+	default:
+		return eventType
+	}
+}
+
+// formatSecurityEvents returns one line per event, newest first
+func formatSecurityEvents(events []shared.SecurityEvent) string {
+	if len(events) == 0 {
+		return "No events recorded yet"
+	}
+
+	var lines []string
+	for _, event := range events {
+		line := fmt.Sprintf("%s | %s",
+			utils.LocalTimeFromUTC(event.Created).Format(securityTimeFormat),
+			getSecurityEventString(event.Type))
+		if len(event.Details) > 0 {
+			line += " (" + event.Details + ")"
+		}
+
+		if len(event.IPAddress) > 0 {
+			line += " | " + event.IPAddress
+		}
+
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func showSecurityLogView() {
+	var securityLog shared.SecurityLog
+	var err error
+	_ = spinner.New().Title("Fetching security log...").Action(func() {
+		securityLog, err = globals.API.GetSecurityLog()
+	}).Run()
+
+	if err != nil {
+		utils.ShowErrorForm(fmt.Sprintf("Error fetching security log: %v", err))
+		ShowAccountModel()
+		return
+	}
+
+	alertLabel := "Enable New Login Emails"
+	if securityLog.LoginAlerts {
+		alertLabel = "Disable New Login Emails"
+	}
+
+	options := []huh.Option[securityOption]{huh.NewOption("Back", exitSecurityLog)}
+	if globals.ServerInfo.EmailConfigured {
+		options = append([]huh.Option[securityOption]{
+			huh.NewOption(alertLabel, toggleLoginAlerts),
+		}, options...)
+	}
+
+	desc := "Recent logins, failed login attempts, and changes to your " +
+		"account security are listed below. If you don't recognize " +
+		"an event, change your password as soon as possible."
+
+	selected := exitSecurityLog
+	err = huh.NewForm(huh.NewGroup(
+		utils.CreateHeader("Security Log", desc),
+		huh.NewNote().
+			Title("Events").
+			Description(formatSecurityEvents(securityLog.Events)),
+		huh.NewSelect[securityOption]().
+			Options(options...).
+			Value(&selected),
+	)).WithTheme(styles.Theme).Run()
+	if err != nil {
+		return
+	}
+
+	if selected == toggleLoginAlerts {
+		_ = spinner.New().Title("Updating settings...").Action(func() {
+			err = globals.API.SetSecuritySettings(shared.SecuritySettings{
+				LoginAlerts: !securityLog.LoginAlerts,
+			})
+		}).Run()
+
+		if err != nil {
+			utils.ShowErrorForm(err.Error())
+		}
+
+		showSecurityLogView()
+		return
+	}
+
+	ShowAccountModel()
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/charmbracelet/huh/spinner"
 	"github.com/mdp/qrterminal/v3"
+	"os"
 	"strconv"
 	"strings"
 	"yeetfile/cli/globals"
@@ -25,15 +26,32 @@ const (
 	SetPasswordHint
 	SetTwoFactor
 	DeleteTwoFactor
+	SetRecoveryKey
+	RemoveRecoveryKey
+	EmergencyAccess
+	SecurityLog
 	PurchaseSendUpgrade
 	PurchaseVaultUpgrade
 	RecyclePaymentID
+	ViewFingerprint
+	RotateKeys
 	DeleteAccount
 	Exit
 )
 
 var actionMap map[Action]func()
 
+// ShowAccountCommand is the entrypoint for the 'account' command, which opens
+// the account view unless a subcommand (i.e. 'fingerprint') was provided
+func ShowAccountCommand() {
+	if len(os.Args) > 2 && os.Args[2] == "fingerprint" {
+		ShowFingerprintCommand(os.Args[3:])
+		return
+	}
+
+	ShowAccountModel()
+}
+
 func ShowAccountModel() {
 	account, accountDetails := FetchAccountDetails()
 	options := generateSelectOptions(account)
@@ -621,6 +639,20 @@ func generateSelectOptions(
 
 	options = append(options, twoFactorOption)
 
+	if account.HasRecoveryKey {
+		options = append(
+			options,
+			huh.NewOption("Replace Recovery Key", SetRecoveryKey),
+			huh.NewOption("Remove Recovery Key", RemoveRecoveryKey))
+	} else {
+		options = append(
+			options,
+			huh.NewOption("Create Recovery Key", SetRecoveryKey))
+	}
+
+	options = append(options, huh.NewOption("Emergency Access", EmergencyAccess))
+	options = append(options, huh.NewOption("Security Log", SecurityLog))
+
 	if globals.ServerInfo.BillingEnabled {
 		if len(globals.ServerInfo.Upgrades.SendUpgrades) > 0 {
 			options = append(
@@ -636,6 +668,8 @@ func generateSelectOptions(
 	}
 
 	options = append(options, huh.NewOption("Recycle Payment ID", RecyclePaymentID))
+	options = append(options, huh.NewOption("View Key Fingerprint", ViewFingerprint))
+	options = append(options, huh.NewOption("Rotate Keys", RotateKeys))
 	options = append(options, huh.NewOption("Delete Account", DeleteAccount))
 	options = append(options, huh.NewOption("Exit", Exit))
 	return options
@@ -865,7 +899,13 @@ func init() {
 		PurchaseSendUpgrade:  showSendUpgradeView,
 		PurchaseVaultUpgrade: showVaultUpgradeView,
 		DeleteTwoFactor:      showDeleteTwoFactorView,
+		SetRecoveryKey:       showSetRecoveryKeyView,
+		RemoveRecoveryKey:    showRemoveRecoveryKeyView,
+		EmergencyAccess:      showEmergencyAccessView,
+		SecurityLog:          showSecurityLogView,
 		RecyclePaymentID:     showRecyclePaymentIDView,
+		ViewFingerprint:      showOwnFingerprintView,
+		RotateKeys:           showRotateKeysView,
 		DeleteAccount:        showAccountDeletionView,
 		Exit:                 exitView,
 	}
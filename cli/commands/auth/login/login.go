@@ -1,6 +1,7 @@
 package login
 
 import (
+	"errors"
 	"strings"
 	"yeetfile/cli/crypto"
 	"yeetfile/cli/globals"
@@ -10,17 +11,24 @@ import (
 
 // LogIn logs into YeetFile by using the provided identifier and password to
 // generate the login key hash, and stores the user's key pair in their config
-// directory
-func LogIn(identifier, password, code string, sessionKey, vaultKey []byte) error {
+// directory. The SSO ticket is only required by servers that use single
+// sign-on.
+func LogIn(identifier, password, code, ssoTicket string, sessionKey, vaultKey []byte) error {
 	identifier = strings.TrimSpace(identifier)
 	password = strings.TrimSpace(password)
 
-	userKey, loginKeyHash := crypto.GenerateUserKeys(identifier, password)
+	kdf, err := globals.API.GetKDFParams(identifier)
+	if err != nil {
+		return err
+	}
+
+	userKey, loginKeyHash := crypto.GenerateUserKeys(identifier, password, kdf)
 
 	login := shared.Login{
 		Identifier:   identifier,
 		LoginKeyHash: loginKeyHash,
 		Code:         code,
+		SSOTicket:    ssoTicket,
 	}
 
 	loginResponse, session, err := globals.API.Login(login)
@@ -31,6 +39,11 @@ func LogIn(identifier, password, code string, sessionKey, vaultKey []byte) error
 	privateKey, err := crypto.DecryptChunk(userKey, loginResponse.ProtectedKey)
 	utils.HandleCLIError("failed to decrypt private key", err)
 
+	if kdf != shared.DefaultKDFParams() {
+		// The upgrade is attempted again on the next login if it fails
+		_ = upgradeKDFParams(identifier, password, loginKeyHash, privateKey)
+	}
+
 	encPrivateKey, _ := crypto.EncryptChunk(vaultKey, privateKey)
 	err = globals.Config.SetKeys(encPrivateKey, loginResponse.PublicKey)
 	if err != nil {
@@ -46,9 +59,72 @@ func LogIn(identifier, password, code string, sessionKey, vaultKey []byte) error
 	return nil
 }
 
+// upgradeKDFParams re-derives the user's keys using the default key derivation
+// params, and replaces their login key hash and protected key with the new
+// values. The user's password is unchanged.
+func upgradeKDFParams(identifier, password string, loginKeyHash, privateKey []byte) error {
+	kdf := shared.DefaultKDFParams()
+	userKey, newLoginKeyHash := crypto.GenerateUserKeys(identifier, password, kdf)
+	protectedKey, err := crypto.EncryptChunk(userKey, privateKey)
+	if err != nil {
+		return err
+	}
+
+	return globals.API.ChangePassword(shared.ChangePassword{
+		OldLoginKeyHash: loginKeyHash,
+		NewLoginKeyHash: newLoginKeyHash,
+		ProtectedKey:    protectedKey,
+		KDF:             kdf,
+		KDFUpgrade:      true,
+	})
+}
+
 // RequestPasswordHint sends a request for the password hint set for the account
 // matching the provided email.
 func RequestPasswordHint(email string) error {
 	err := globals.API.ForgotPassword(email)
 	return err
 }
+
+// RecoverAccount uses the user's recovery phrase to decrypt their private key,
+// and sets a new password by re-encrypting the private key with the user key
+// derived from the new password. The user needs to log in again afterward.
+func RecoverAccount(identifier, phrase, newPassword, code string) error {
+	identifier = strings.TrimSpace(identifier)
+	newPassword = strings.TrimSpace(newPassword)
+
+	recoveryKey, recoveryKeyHash, err := crypto.GenerateRecoveryKeys(phrase)
+	if err != nil {
+		return err
+	}
+
+	protectedKey, err := globals.API.GetRecoveryProtectedKey(
+		shared.RecoveryKeyLogin{
+			Identifier:      identifier,
+			RecoveryKeyHash: recoveryKeyHash,
+		})
+	if err != nil {
+		return err
+	}
+
+	privateKey, err := crypto.DecryptChunk(recoveryKey, protectedKey)
+	if err != nil {
+		return errors.New("failed to decrypt private key")
+	}
+
+	kdf := shared.DefaultKDFParams()
+	userKey, loginKeyHash := crypto.GenerateUserKeys(identifier, newPassword, kdf)
+	newProtectedKey, err := crypto.EncryptChunk(userKey, privateKey)
+	if err != nil {
+		return err
+	}
+
+	return globals.API.RecoverAccount(shared.RecoverAccount{
+		Identifier:      identifier,
+		RecoveryKeyHash: recoveryKeyHash,
+		Code:            code,
+		LoginKeyHash:    loginKeyHash,
+		ProtectedKey:    newProtectedKey,
+		KDF:             kdf,
+	})
+}
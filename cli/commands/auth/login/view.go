@@ -7,7 +7,9 @@ import (
 	"github.com/charmbracelet/huh"
 	"strings"
 	"yeetfile/cli/api"
+	"yeetfile/cli/commands/auth/sso"
 	"yeetfile/cli/crypto"
+	"yeetfile/cli/globals"
 	"yeetfile/cli/styles"
 	"yeetfile/cli/utils"
 	"yeetfile/shared"
@@ -93,11 +95,16 @@ func ShowLoginModel() {
 
 		if !loginSelected {
 			// User selected "forgot password"
-			email := ""
-			if strings.Contains(identifier, "@") {
-				email = identifier
+			if showUseRecoveryKeyPrompt() {
+				err = showRecoverAccountModel(identifier)
+			} else {
+				email := ""
+				if strings.Contains(identifier, "@") {
+					email = identifier
+				}
+				err = showForgotPasswordModel(email, "")
 			}
-			err = showForgotPasswordModel(email, "")
+
 			if err == nil {
 				return runFunc("")
 			}
@@ -120,13 +127,24 @@ func ShowLoginModel() {
 			)
 		}
 
-		err = LogIn(identifier, password, "", sessionKey, vaultKey)
-		if err != nil && err != api.TwoFactorError {
+		var ssoTicket string
+		if globals.ServerInfo.SSOEnabled {
+			ssoTicket, err = sso.Ticket("Login > SSO")
+			if err != nil {
+				return err
+			}
+		}
+
+		err = LogIn(identifier, password, "", ssoTicket, sessionKey, vaultKey)
+		if err == api.SSOError {
+			sso.Reset()
+			return runFunc(err.Error())
+		} else if err != nil && err != api.TwoFactorError {
 			return runFunc(err.Error())
 		} else if err == api.TwoFactorError {
 			for err == api.TwoFactorError {
 				code := showTwoFactorPrompt()
-				err = LogIn(identifier, password, code, sessionKey, vaultKey)
+				err = LogIn(identifier, password, code, ssoTicket, sessionKey, vaultKey)
 			}
 
 			if err != nil {
@@ -270,6 +288,94 @@ func showForgotPasswordModel(email string, errMsg string) error {
 	return nil
 }
 
+// showUseRecoveryKeyPrompt asks the user whether they want to reset their
+// password with their recovery key, or request their password hint
+func showUseRecoveryKeyPrompt() bool {
+	var useRecoveryKey bool
+	_ = huh.NewForm(huh.NewGroup(
+		utils.CreateHeader("Forgot Password", "If you created a "+
+			"recovery key, you can use it to set a new password "+
+			"without losing access to your vault. Otherwise, you can "+
+			"request your password hint."),
+		huh.NewConfirm().
+			Affirmative("Use Recovery Key").
+			Negative("Request Password Hint").
+			Value(&useRecoveryKey),
+	)).WithTheme(styles.Theme).Run()
+
+	return useRecoveryKey
+}
+
+func showRecoverAccountModel(identifier string) error {
+	var phrase string
+	var newPassword string
+	var submitted bool
+
+	recoverForm := func(prevErr error) (bool, error) {
+		var errMsg string
+		if prevErr != nil {
+			errMsg = styles.ErrStyle.Render(prevErr.Error())
+		}
+
+		err := huh.NewForm(huh.NewGroup(
+			utils.CreateHeader("Recover Account", "Enter your "+
+				"recovery key to set a new password. Your other "+
+				"sessions will be logged out."),
+			huh.NewInput().Title("Identifier").
+				Description("Email or Account ID").
+				Value(&identifier),
+			huh.NewText().Title("Recovery Key").
+				Value(&phrase),
+			huh.NewInput().Title("New Password").
+				EchoMode(huh.EchoModePassword).
+				Value(&newPassword),
+			huh.NewInput().Title("Confirm New Password").
+				EchoMode(huh.EchoModePassword).
+				Validate(func(s string) error {
+					if s != newPassword {
+						return errors.New("passwords do not match")
+					}
+
+					return nil
+				}),
+			huh.NewConfirm().
+				Affirmative("Submit").
+				Negative("Cancel").
+				Description(errMsg).
+				Value(&submitted),
+		)).WithTheme(styles.Theme).Run()
+		if err == huh.ErrUserAborted || !submitted {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+
+		err = RecoverAccount(identifier, phrase, newPassword, "")
+		for err == api.TwoFactorError {
+			code := showTwoFactorPrompt()
+			err = RecoverAccount(identifier, phrase, newPassword, code)
+		}
+
+		return err == nil, err
+	}
+
+	recovered, err := recoverForm(nil)
+	for err != nil {
+		recovered, err = recoverForm(err)
+	}
+
+	if recovered {
+		_ = huh.NewForm(huh.NewGroup(
+			utils.CreateHeader("Recover Account", "Your password "+
+				"has been changed. You may now log in with your "+
+				"new password."),
+			huh.NewConfirm().Affirmative("OK").Negative(""),
+		)).WithTheme(styles.Theme).Run()
+	}
+
+	return nil
+}
+
 func promptVaultPassword() string {
 	var password string
 	err := huh.NewForm(
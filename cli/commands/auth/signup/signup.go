@@ -9,8 +9,11 @@ import (
 // CreateSignupRequest generates all necessary keys, hashes, etc. for initial
 // signup. Note that for signup requests without email, an empty signup struct
 // is valid since the request has to be generated after the server provides
-// an account ID for the user.
-func CreateSignupRequest(identifier, password, hint, serverPw string) shared.Signup {
+// an account ID for the user. If a recovery phrase is provided, a copy of the
+// user's private key is encrypted with the recovery key derived from it.
+func CreateSignupRequest(
+	identifier, password, hint, serverPw, recoveryPhrase string,
+) shared.Signup {
 	if len(identifier) == 0 {
 		return shared.Signup{}
 	}
@@ -20,6 +23,20 @@ func CreateSignupRequest(identifier, password, hint, serverPw string) shared.Sig
 		utils.HandleCLIError("error generating signup keys", err)
 	}
 
+	var recoveryKeyHash []byte
+	var recoveryProtectedKey []byte
+	if len(recoveryPhrase) > 0 {
+		var recoveryKey []byte
+		recoveryKey, recoveryKeyHash, err = crypto.GenerateRecoveryKeys(
+			recoveryPhrase)
+		utils.HandleCLIError("error generating recovery keys", err)
+
+		recoveryProtectedKey, err = crypto.EncryptChunk(
+			recoveryKey,
+			signupKeys.PrivateKey)
+		utils.HandleCLIError("error encrypting private key", err)
+	}
+
 	return shared.Signup{
 		Identifier:              identifier,
 		LoginKeyHash:            signupKeys.LoginKeyHash,
@@ -28,11 +45,16 @@ func CreateSignupRequest(identifier, password, hint, serverPw string) shared.Sig
 		ProtectedVaultFolderKey: signupKeys.ProtectedRootFolderKey,
 		ServerPassword:          serverPw,
 		PasswordHint:            hint,
+		RecoveryKeyHash:         recoveryKeyHash,
+		RecoveryProtectedKey:    recoveryProtectedKey,
+		KDF:                     signupKeys.KDF,
 	}
 }
 
-func CreateVerificationRequest(identifier, password, code string) shared.VerifyAccount {
-	signup := CreateSignupRequest(identifier, password, "", "")
+func CreateVerificationRequest(
+	identifier, password, code, recoveryPhrase string,
+) shared.VerifyAccount {
+	signup := CreateSignupRequest(identifier, password, "", "", recoveryPhrase)
 	return shared.VerifyAccount{
 		ID:                      signup.Identifier,
 		Code:                    code,
@@ -40,5 +62,8 @@ func CreateVerificationRequest(identifier, password, code string) shared.VerifyA
 		PublicKey:               signup.PublicKey,
 		ProtectedPrivateKey:     signup.ProtectedPrivateKey,
 		ProtectedVaultFolderKey: signup.ProtectedVaultFolderKey,
+		RecoveryKeyHash:         signup.RecoveryKeyHash,
+		RecoveryProtectedKey:    signup.RecoveryProtectedKey,
+		KDF:                     signup.KDF,
 	}
 }
@@ -12,6 +12,8 @@ import (
 	"log"
 	"strings"
 	"yeetfile/cli/api"
+	"yeetfile/cli/commands/auth/sso"
+	"yeetfile/cli/crypto"
 	"yeetfile/cli/globals"
 	"yeetfile/cli/styles"
 	"yeetfile/cli/utils"
@@ -33,9 +35,13 @@ func ShowSignupModel() {
 	var password string
 	var passwordHint string
 	var signupType string
+	var createRecoveryKey bool
 
 	var options []huh.Option[string]
-	if globals.ServerInfo.EmailConfigured {
+	if globals.ServerInfo.SSOEnabled {
+		// Accounts use the email from the server's identity provider
+		options = huh.NewOptions(signupEmail)
+	} else if globals.ServerInfo.EmailConfigured {
 		options = huh.NewOptions(signupEmail, signupIDOnly)
 	} else {
 		options = huh.NewOptions(signupIDOnly)
@@ -78,6 +84,7 @@ func ShowSignupModel() {
 					"Setting a password hint is recommended.").
 				Lines(2).
 				Value(&passwordHint),
+			recoveryKeyConfirm(&createRecoveryKey),
 			huh.NewConfirm().Affirmative("Submit").Negative(""),
 		).WithHideFunc(func() bool {
 			return signupType != signupEmail
@@ -97,6 +104,7 @@ func ShowSignupModel() {
 
 					return errors.New("passwords do not match")
 				}),
+			recoveryKeyConfirm(&createRecoveryKey),
 			huh.NewConfirm().Affirmative("Submit").Negative(""),
 		).WithHideFunc(func() bool {
 			return signupType != signupIDOnly
@@ -104,32 +112,73 @@ func ShowSignupModel() {
 	).WithTheme(styles.Theme).WithShowHelp(true).Run()
 	utils.HandleCLIError("", err)
 
+	var recoveryPhrase string
+	if createRecoveryKey {
+		recoveryPhrase, err = crypto.GenerateRecoveryPhrase()
+		utils.HandleCLIError("error generating recovery key", err)
+	}
+
 	if signupType == signupIDOnly {
-		showIDOnlySignupModel(password, "")
+		showIDOnlySignupModel(password, "", recoveryPhrase)
 	} else if signupType == signupEmail {
-		showEmailSignupModel(email, password, passwordHint, "")
+		showEmailSignupModel(email, password, passwordHint, "", recoveryPhrase)
 	}
 }
 
+// recoveryKeyConfirm returns a field for opting into creating a recovery key
+// during signup
+func recoveryKeyConfirm(createRecoveryKey *bool) huh.Field {
+	return huh.NewConfirm().
+		Title("Recovery Key").
+		Description("Create a recovery key? It can be used to set a new\n" +
+			"password without losing access to your vault.").
+		Affirmative("Yes").
+		Negative("No").
+		Value(createRecoveryKey)
+}
+
 // showEmailSignupModel shows a spinner while the user's account is created
 // and finalized.
-func showEmailSignupModel(email, password, hint, serverPw string) {
+func showEmailSignupModel(email, password, hint, serverPw, recoveryPhrase string) {
+	var ssoTicket string
+	var err error
+	if globals.ServerInfo.SSOEnabled {
+		ssoTicket, err = sso.Ticket("Sign Up > SSO")
+		utils.HandleCLIError("", err)
+	}
+
+	var response shared.SignupResponse
 	var signupErr error
-	err := spinner.New().Title("Creating account...").Action(
+	err = spinner.New().Title("Creating account...").Action(
 		func() {
-			signup := CreateSignupRequest(email, password, hint, serverPw)
-			_, signupErr = globals.API.SubmitSignup(signup)
+			signup := CreateSignupRequest(
+				email,
+				password,
+				hint,
+				serverPw,
+				recoveryPhrase)
+			signup.SSOTicket = ssoTicket
+			response, signupErr = globals.API.SubmitSignup(signup)
 		}).Run()
 	utils.HandleCLIError("", err)
 
 	if signupErr == api.ServerPasswordError {
 		serverPassword := showServerPasswordPrompt()
-		showEmailSignupModel(email, password, hint, serverPassword)
+		showEmailSignupModel(email, password, hint, serverPassword, recoveryPhrase)
 		return
+	} else if signupErr == api.SSOError {
+		sso.Reset()
 	}
 
 	utils.HandleCLIError("error creating account", signupErr)
 
+	if len(ssoTicket) > 0 {
+		// Accounts created with SSO don't need to verify their email
+		sso.Replace(response.SSOTicket)
+		showSignupCompleteModel(recoveryPhrase)
+		return
+	}
+
 	var code string
 	desc := fmt.Sprintf(
 		"A verification code has been sent to %s, please enter it below.",
@@ -172,8 +221,17 @@ func showEmailSignupModel(email, password, hint, serverPw string) {
 	}
 
 	runFunc()
+	showSignupCompleteModel(recoveryPhrase)
+}
 
-	err = huh.NewForm(huh.NewGroup(
+// showSignupCompleteModel shows the user's recovery key, if they created one,
+// before they continue on to log in
+func showSignupCompleteModel(recoveryPhrase string) {
+	if len(recoveryPhrase) > 0 {
+		showRecoveryPhraseModel(recoveryPhrase)
+	}
+
+	err := huh.NewForm(huh.NewGroup(
 		huh.NewNote().Title(utils.GenerateTitle("Signup Complete")).
 			Description("You may now log in!"),
 		huh.NewConfirm().Affirmative("Log In").Negative(""))).
@@ -183,7 +241,7 @@ func showEmailSignupModel(email, password, hint, serverPw string) {
 
 // showIDOnlySignupModel shows a spinner while the user's ID-only account is
 // created and finalized.
-func showIDOnlySignupModel(password, serverPw string) {
+func showIDOnlySignupModel(password, serverPw, recoveryPhrase string) {
 	var response shared.SignupResponse
 	var signupErr error
 	err := spinner.New().Title("Creating account...").Action(
@@ -205,7 +263,7 @@ func showIDOnlySignupModel(password, serverPw string) {
 
 	if signupErr == api.ServerPasswordError {
 		serverPassword := showServerPasswordPrompt()
-		showIDOnlySignupModel(password, serverPassword)
+		showIDOnlySignupModel(password, serverPassword, recoveryPhrase)
 		return
 	}
 
@@ -223,7 +281,8 @@ func showIDOnlySignupModel(password, serverPw string) {
 					verify := CreateVerificationRequest(
 						response.Identifier,
 						password,
-						verificationCode)
+						verificationCode,
+						recoveryPhrase)
 					verifyErr = globals.API.VerifyAccount(verify)
 				}).Run()
 			utils.HandleCLIError("", err)
@@ -231,6 +290,10 @@ func showIDOnlySignupModel(password, serverPw string) {
 				runFunc(verifyErr.Error())
 			}
 
+			if len(recoveryPhrase) > 0 {
+				showRecoveryPhraseModel(recoveryPhrase)
+			}
+
 			showAccountConfirmationModel(response.Identifier)
 		}
 
@@ -300,6 +363,23 @@ func showAccountConfirmationModel(id string) {
 	utils.HandleCLIError("error showing confirmation", err)
 }
 
+// showRecoveryPhraseModel displays the recovery key created during signup
+func showRecoveryPhraseModel(phrase string) {
+	err := huh.NewForm(
+		huh.NewGroup(
+			huh.NewNote().Title(utils.GenerateTitle("Your Recovery Key")).
+				Description(phrase),
+			huh.NewConfirm().
+				Title("Warning").
+				Description("Write your recovery key down and store "+
+					"it somewhere safe. This will not be shown "+
+					"again.").
+				Affirmative("Continue").Negative(""),
+		),
+	).WithTheme(styles.Theme).WithShowHelp(true).Run()
+	utils.HandleCLIError("error showing recovery key", err)
+}
+
 func showServerPasswordPrompt() string {
 	var serverPw string
 	msg := fmt.Sprintf("This server (%s) is password protected.\nPlease enter"+
@@ -0,0 +1,66 @@
+package sso
+
+import (
+	"errors"
+	"fmt"
+	"github.com/charmbracelet/huh"
+	"strings"
+	"yeetfile/cli/globals"
+	"yeetfile/cli/styles"
+	"yeetfile/cli/utils"
+	"yeetfile/shared/endpoints"
+)
+
+const ssoMessage = `This server requires logging in with your organization's
+account. Open the link below in your browser, log in, and paste the
+code that is shown afterward.
+
+%s`
+
+// ticket is kept for the rest of the session, so that users who just signed
+// up can log in without going through their identity provider again (using
+// the new ticket returned by the server after signing up)
+var ticket string
+
+// Ticket returns the code that authorizes the user to log in or sign up,
+// prompting them to log in with the server's identity provider if they haven't
+// already done so
+func Ticket(title string) (string, error) {
+	if len(ticket) > 0 {
+		return ticket, nil
+	}
+
+	loginURL := endpoints.OIDCLogin.Format(globals.Config.Server) + "?cli=1"
+
+	var code string
+	err := huh.NewForm(huh.NewGroup(
+		huh.NewNote().Title(utils.GenerateTitle(title)).
+			Description(fmt.Sprintf(ssoMessage, styles.BoldStyle.Render(loginURL))),
+		huh.NewInput().Title("SSO Code").Value(&code).
+			Validate(func(s string) error {
+				if len(strings.TrimSpace(s)) == 0 {
+					return errors.New("code cannot be blank")
+				}
+
+				return nil
+			}),
+		huh.NewConfirm().Affirmative("Submit").Negative(""),
+	)).WithTheme(styles.Theme).WithShowHelp(true).Run()
+	if err != nil {
+		return "", err
+	}
+
+	ticket = strings.TrimSpace(code)
+	return ticket, nil
+}
+
+// Replace swaps the user's code for the one returned after signing up, since
+// the code used to sign up can't be used again
+func Replace(newTicket string) {
+	ticket = newTicket
+}
+
+// Reset removes the user's code, i.e. after it has expired
+func Reset() {
+	ticket = ""
+}
@@ -1,13 +1,19 @@
 package download
 
 import (
+	"archive/zip"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"strings"
 	"time"
 	"yeetfile/cli/crypto"
 	"yeetfile/cli/globals"
+	"yeetfile/cli/transfer"
 	"yeetfile/cli/utils"
 	"yeetfile/shared"
 	"yeetfile/shared/constants"
@@ -31,6 +37,8 @@ type PreparedDownload struct {
 	Expiration time.Time
 	Downloads  int
 	IsText     bool
+	Format     string
+	Files      []shared.SendManifestFile
 }
 
 func parseLink(link string) DownloadResource {
@@ -79,6 +87,22 @@ func prepDownload(link string) (PreparedDownload, error) {
 		IsText:     strings.HasPrefix(metadata.ID, constants.PlaintextIDPrefix),
 	}
 
+	if prep.IsText && len(metadata.Format) > 0 {
+		format, err := crypto.DecryptChunk(key, metadata.Format)
+		if err != nil {
+			return PreparedDownload{}, err
+		}
+
+		prep.Format = string(format)
+	}
+
+	if len(metadata.Manifest) > 0 {
+		prep.Files, err = decryptManifest(key, metadata.Manifest)
+		if err != nil {
+			return PreparedDownload{}, err
+		}
+	}
+
 	return prep, nil
 }
 
@@ -130,10 +154,114 @@ func (d DownloadResource) decryptResponse(
 	return string(decName), key, nil
 }
 
+// decryptManifest decrypts the manifest of a multi-file send and validates the
+// paths of each file in the manifest
+func decryptManifest(key, encManifest []byte) ([]shared.SendManifestFile, error) {
+	manifestJSON, err := crypto.DecryptChunk(key, encManifest)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest shared.SendManifest
+	err = json.Unmarshal(manifestJSON, &manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range manifest.Files {
+		if _, err = transfer.SanitizeBundlePath(file.Path); err != nil {
+			return nil, err
+		} else if file.Offset < 0 || file.Size < 0 {
+			return nil, transfer.InvalidBundlePathError
+		}
+	}
+
+	return manifest.Files, nil
+}
+
+// countBundleChunks returns the number of chunks that need to be downloaded
+// to retrieve the provided files, including the final chunk of the send
+func countBundleChunks(files []shared.SendManifestFile, numChunks int) int {
+	chunks := map[int64]bool{int64(numChunks - 1): true}
+	for _, file := range files {
+		if file.Size == 0 {
+			continue
+		}
+
+		first := file.Offset / constants.ChunkSize
+		last := (file.Offset + file.Size - 1) / constants.ChunkSize
+		for i := first; i <= last; i++ {
+			chunks[i] = true
+		}
+	}
+
+	return len(chunks)
+}
+
+// createBundleFile creates a file from a multi-file send within the
+// destination directory, creating any parent directories as needed
+func createBundleFile(destination string, file shared.SendManifestFile) (io.WriteCloser, error) {
+	relPath, err := transfer.SanitizeBundlePath(file.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	fullPath := filepath.Join(destination, relPath)
+	err = os.MkdirAll(filepath.Dir(fullPath), 0777)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.OpenFile(fullPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0666)
+}
+
+type zipEntryWriter struct {
+	io.Writer
+}
+
+func (zipEntryWriter) Close() error {
+	return nil
+}
+
+// downloadZipArchive downloads files from a multi-file send into a new zip
+// archive, which is written as the files are downloaded
+func downloadZipArchive(
+	p transfer.PendingDownload,
+	files []shared.SendManifestFile,
+	destination string,
+	progress func(),
+) error {
+	archive, err := os.OpenFile(destination, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0666)
+	if err != nil {
+		return err
+	}
+
+	defer archive.Close()
+
+	zipWriter := zip.NewWriter(archive)
+	err = p.DownloadBundleFiles(
+		files,
+		func(file shared.SendManifestFile) (io.WriteCloser, error) {
+			relPath, err := transfer.SanitizeBundlePath(file.Path)
+			if err != nil {
+				return nil, err
+			}
+
+			writer, err := zipWriter.Create(filepath.ToSlash(relPath))
+			return zipEntryWriter{writer}, err
+		},
+		progress)
+	if err != nil {
+		return err
+	}
+
+	return zipWriter.Close()
+}
+
 func generateDescription(download PreparedDownload) string {
 	name := download.Name
-	if strings.HasPrefix(download.ID, constants.PlaintextIDPrefix) {
-		name = "N/A (text-only)"
+	if download.IsText {
+		name = fmt.Sprintf("N/A (text-only, %s)", utils.TextFormatLabel(download.Format))
 	}
 
 	timeDiff := download.Expiration.Sub(time.Now())
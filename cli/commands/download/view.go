@@ -1,17 +1,36 @@
 package download
 
 import (
+	"errors"
 	"fmt"
 	"github.com/charmbracelet/huh"
 	"github.com/charmbracelet/huh/spinner"
+	"io"
 	"os"
 	"strings"
+	"yeetfile/cli/commands/vault/viewer"
 	"yeetfile/cli/globals"
 	"yeetfile/cli/styles"
 	"yeetfile/cli/transfer"
 	"yeetfile/cli/utils"
+	"yeetfile/shared"
+	"yeetfile/shared/constants"
 )
 
+type bundleMode int
+
+const (
+	saveAllFiles bundleMode = iota
+	saveZipArchive
+	saveSelectedFiles
+)
+
+// maxListedFiles is the max number of files from a multi-file send to list
+// in the download preview
+const maxListedFiles = 10
+
+var noFilesSelectedError = errors.New("no files selected")
+
 var downloadLink string
 var downloadErr error
 var saveErr error
@@ -52,10 +71,12 @@ func ShowDownloadModel() {
 		}
 	}
 
-	startDownload(downloadLink)
+	StartDownload(downloadLink)
 }
 
-func startDownload(link string) {
+// StartDownload fetches and decrypts the metadata for a send using its link,
+// and shows a preview of the send before downloading it
+func StartDownload(link string) {
 	preparedDownload, err := prepDownload(link)
 
 	if err != nil {
@@ -68,6 +89,11 @@ func startDownload(link string) {
 }
 
 func showPreviewModel(prep PreparedDownload) {
+	if len(prep.Files) > 0 {
+		showBundlePreviewModel(prep)
+		return
+	}
+
 	filename := prep.Name
 	description := generateDescription(prep)
 
@@ -118,6 +144,94 @@ func showPreviewModel(prep PreparedDownload) {
 	}
 }
 
+func showBundlePreviewModel(prep PreparedDownload) {
+	var mode bundleMode
+	var selected []int
+	destination, err := transfer.SanitizeBundlePath(prep.Name)
+	if err != nil || strings.ContainsRune(destination, os.PathSeparator) {
+		destination = prep.ID
+	}
+
+	var fileList []string
+	var fileOptions []huh.Option[int]
+	for i, file := range prep.Files {
+		label := fmt.Sprintf("%s (%s)", file.Path, shared.ReadableFileSize(file.Size))
+		if i < maxListedFiles {
+			fileList = append(fileList, "  "+label)
+		}
+
+		fileOptions = append(fileOptions, huh.NewOption(label, i))
+	}
+
+	if len(prep.Files) > maxListedFiles {
+		fileList = append(fileList, fmt.Sprintf("  ...and %d more",
+			len(prep.Files)-maxListedFiles))
+	}
+
+	description := generateDescription(prep) +
+		fmt.Sprintf("- Files (%d):\n%s", len(prep.Files), strings.Join(fileList, "\n"))
+
+	err = huh.NewForm(
+		huh.NewGroup(
+			huh.NewNote().
+				Title("Pending Download").
+				Description(description),
+			huh.NewSelect[bundleMode]().Title("Download").
+				Options(
+					huh.NewOption("All files", saveAllFiles),
+					huh.NewOption("All files (zip archive)", saveZipArchive),
+					huh.NewOption("Choose files...", saveSelectedFiles),
+				).Value(&mode),
+			huh.NewInput().
+				Title("Save to...").
+				Description("The folder to save files in, or the "+
+					"name of the zip archive").
+				Value(&destination),
+		),
+		huh.NewGroup(
+			huh.NewMultiSelect[int]().
+				Title("Files").
+				Options(fileOptions...).
+				Value(&selected),
+		).WithHideFunc(func() bool {
+			return mode != saveSelectedFiles
+		}),
+		huh.NewGroup(
+			huh.NewConfirm().
+				Title("Download").
+				Affirmative("Start Download").
+				Negative("").
+				DescriptionFunc(func() string {
+					if saveErr != nil {
+						return styles.ErrStyle.Render(saveErr.Error())
+					}
+
+					return ""
+				}, &destination).
+				Validate(func(bool) error {
+					if mode == saveSelectedFiles && len(selected) == 0 {
+						return noFilesSelectedError
+					}
+
+					return nil
+				}),
+		),
+	).WithTheme(styles.Theme).Run()
+	if err != nil {
+		return
+	}
+
+	files := prep.Files
+	if mode == saveSelectedFiles {
+		files = nil
+		for _, idx := range selected {
+			files = append(files, prep.Files[idx])
+		}
+	}
+
+	showDownloadBundleModel(prep, mode, destination, files)
+}
+
 func showPasswordPromptModel(err error) (string, error) {
 	desc := "This content is password protected"
 	if err != nil {
@@ -141,17 +255,28 @@ func showDownloadTextModel(prep PreparedDownload) {
 	var data []byte
 	var err error
 	_ = spinner.New().Title("Downloading text...").Action(func() {
-		data, err = transfer.DownloadText(prep.ID, prep.Server, prep.Key)
+		data, err = transfer.DownloadText(prep.ID, prep.Server, prep.Key, prep.Chunks)
 		if err != nil {
 			saveErr = err
 			return
 		}
 	}).Run()
 
+	// Formatted or long text is shown in a scrollable view, since it
+	// likely won't fit in the form
+	formatType, _ := utils.ParseTextFormat(prep.Format)
+	if formatType != constants.TextFormatPlain || len(data) > constants.MaxPlaintextLen {
+		viewer.ShowText(
+			"Downloaded Text",
+			utils.TextFormatLabel(prep.Format),
+			[]byte(utils.RenderText(string(data), prep.Format)))
+		return
+	}
+
 	_ = huh.NewForm(huh.NewGroup(
 		huh.NewNote().
 			Title("Downloaded Text").
-			Description(string(data)),
+			Description(shared.EscapeString(string(data))),
 		huh.NewConfirm().Affirmative("Exit").Negative(""),
 	)).WithTheme(styles.Theme).Run()
 }
@@ -189,3 +314,53 @@ func showDownloadFileModel(prep PreparedDownload, filename string) {
 
 	fmt.Printf("\n-- File downloaded to .%c%s\n\n", os.PathSeparator, filename)
 }
+
+func showDownloadBundleModel(
+	prep PreparedDownload,
+	mode bundleMode,
+	destination string,
+	files []shared.SendManifestFile,
+) {
+	if mode == saveZipArchive && !strings.HasSuffix(destination, ".zip") {
+		destination += ".zip"
+	}
+
+	downloadSpinner := spinner.New()
+	_ = downloadSpinner.Title("Downloading files...").Action(func() {
+		p := transfer.InitSendDownload(
+			prep.ID,
+			prep.Server,
+			prep.Key,
+			nil,
+			prep.Chunks,
+		)
+
+		chunk := 0
+		total := countBundleChunks(files, prep.Chunks)
+		progress := func() {
+			chunk++
+			percentage := int((float32(chunk) / float32(total)) * 100)
+			msg := fmt.Sprintf("Downloading files... (%d%%)", percentage)
+			downloadSpinner.Title(msg)
+		}
+
+		if mode == saveZipArchive {
+			saveErr = downloadZipArchive(p, files, destination, progress)
+			return
+		}
+
+		saveErr = p.DownloadBundleFiles(
+			files,
+			func(file shared.SendManifestFile) (io.WriteCloser, error) {
+				return createBundleFile(destination, file)
+			},
+			progress)
+	}).Run()
+
+	if saveErr != nil {
+		showPreviewModel(prep)
+		return
+	}
+
+	fmt.Printf("\n-- %d file(s) downloaded to %s\n\n", len(files), destination)
+}
@@ -0,0 +1,287 @@
+package inbox
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/huh/spinner"
+
+	"yeetfile/cli/commands/download"
+	"yeetfile/cli/commands/vault/items"
+	"yeetfile/cli/crypto"
+	"yeetfile/cli/globals"
+	"yeetfile/cli/styles"
+	"yeetfile/cli/utils"
+	"yeetfile/shared"
+	"yeetfile/shared/constants"
+)
+
+type inboxAction int
+
+const (
+	downloadSend inboxAction = iota
+	saveToVault
+	backToInbox
+)
+
+const (
+	inboxTimeFormat = "02 Jan 2006 15:04 MST"
+	noSendSelected  = -1
+	homeFolderName  = "Home"
+)
+
+type inboxSend struct {
+	shared.InboxItem
+	Name string
+	Key  []byte
+}
+
+// ShowInboxModel displays the list of sends that other users have addressed
+// to the current user. The key for each send is encrypted with the user's
+// public key, so sends can be downloaded without needing a link.
+func ShowInboxModel() {
+	var inbox []shared.InboxItem
+	var err error
+	_ = spinner.New().Title("Fetching inbox...").Action(func() {
+		inbox, err = globals.API.GetInbox()
+	}).Run()
+
+	if err != nil {
+		utils.ShowErrorForm(fmt.Sprintf("Error fetching inbox: %v", err))
+		return
+	}
+
+	// Not run in a spinner, since the user may be prompted for their vault
+	// password in order to decrypt their private key
+	keyPair, err := items.UnlockKeyPair()
+	if err != nil {
+		utils.ShowErrorForm(fmt.Sprintf("Error decrypting keys: %v", err))
+		return
+	}
+
+	sends := decryptInbox(inbox, keyPair.PrivateKey)
+
+	selected := noSendSelected
+	options := []huh.Option[int]{}
+	spacing := utils.GenerateListIdxSpacing(len(sends))
+	for i, send := range sends {
+		idxSpacing := utils.GetListIdxSpacing(spacing, i+1, len(sends))
+		label := fmt.Sprintf("%d.%s%s | from %s | %s | %s | expires %s",
+			i+1,
+			idxSpacing,
+			send.Name,
+			send.SenderName,
+			shared.ReadableFileSize(send.Size),
+			getDownloadsString(send),
+			utils.LocalTimeFromUTC(send.Expiration).Format(inboxTimeFormat))
+		options = append(options, huh.NewOption(label, i))
+	}
+
+	options = append(options, huh.NewOption("Exit", noSendSelected))
+
+	desc := "Sends that other users have addressed to you. Select a send " +
+		"to download it or save it to your vault."
+	if len(sends) == 0 {
+		desc = "You don't have any sends in your inbox."
+	}
+
+	err = huh.NewForm(huh.NewGroup(
+		utils.CreateHeader("Inbox", desc),
+		huh.NewSelect[int]().
+			Options(options...).
+			Value(&selected),
+	)).WithTheme(styles.Theme).WithShowHelp(true).Run()
+	if err != nil || selected == noSendSelected {
+		return
+	}
+
+	showInboxSendModel(sends[selected])
+}
+
+func showInboxSendModel(send inboxSend) {
+	details := fmt.Sprintf("From:      %s\n"+
+		"Type:      %s\n"+
+		"Size:      %s\n"+
+		"Downloads: %s\n"+
+		"Received:  %s\n"+
+		"Expires:   %s",
+		send.SenderName,
+		getSendTypeString(send),
+		shared.ReadableFileSize(send.Size),
+		getDownloadsString(send),
+		utils.LocalTimeFromUTC(send.Created).Format(inboxTimeFormat),
+		utils.LocalTimeFromUTC(send.Expiration).Format(inboxTimeFormat))
+
+	options := []huh.Option[inboxAction]{huh.NewOption("Download", downloadSend)}
+	if canSaveToVault(send) {
+		options = append(options, huh.NewOption("Save to Vault", saveToVault))
+	}
+
+	options = append(options, huh.NewOption("Back", backToInbox))
+
+	var action inboxAction
+	err := huh.NewForm(huh.NewGroup(
+		utils.CreateHeader(send.Name, details),
+		huh.NewSelect[inboxAction]().
+			Options(options...).
+			Value(&action),
+	)).WithTheme(styles.Theme).Run()
+	if err != nil {
+		return
+	}
+
+	switch action {
+	case downloadSend:
+		link := fmt.Sprintf("%s/%s#%s",
+			globals.Config.Server,
+			send.ID,
+			utils.B64Encode(send.Key))
+		download.StartDownload(link)
+	case saveToVault:
+		showSaveToVaultModel(send)
+	case backToInbox:
+		ShowInboxModel()
+	}
+}
+
+func showSaveToVaultModel(send inboxSend) {
+	// Not run in a spinner, since the user may be prompted for their vault
+	// password in order to decrypt the folder names
+	folders, err := items.FetchRootFolders()
+	if err != nil {
+		utils.ShowErrorForm(fmt.Sprintf("Error loading vault folders: %v", err))
+		return
+	}
+
+	folderNames := map[string]string{"": homeFolderName}
+	folderOptions := []huh.Option[string]{huh.NewOption(homeFolderName, "")}
+	for _, folder := range folders {
+		if folder.CanModify {
+			folderNames[folder.ID] = folder.Name
+			folderOptions = append(folderOptions, huh.NewOption(folder.Name, folder.ID))
+		}
+	}
+
+	var folderID string
+	var submitted bool
+	err = huh.NewForm(huh.NewGroup(
+		utils.CreateHeader("Save to Vault", fmt.Sprintf(
+			"Save %s to a folder in your vault. This counts as a "+
+				"download of the send.", send.Name)),
+		huh.NewSelect[string]().Title("Folder").
+			Options(folderOptions...).
+			Value(&folderID),
+		huh.NewConfirm().
+			Affirmative("Save").
+			Negative("Cancel").
+			Value(&submitted),
+	)).WithTheme(styles.Theme).Run()
+	if err != nil || !submitted {
+		showInboxSendModel(send)
+		return
+	}
+
+	_ = spinner.New().Title("Saving to vault...").Action(func() {
+		err = saveSendToVault(send, folderID)
+	}).Run()
+
+	if err != nil {
+		utils.ShowErrorForm(fmt.Sprintf("Error saving to vault: %v", err))
+		return
+	}
+
+	fmt.Printf("Saved %s to %s\n", send.Name, folderNames[folderID])
+}
+
+// saveSendToVault wraps the send key with the key of the destination folder,
+// and copies the send into the user's vault. The name is re-encrypted so that
+// the vault file is stored separately from the send.
+func saveSendToVault(send inboxSend, folderID string) error {
+	ctx, err := items.FetchVaultContext(folderID, false)
+	if err != nil {
+		return err
+	}
+
+	protectedKey, err := ctx.Crypto.EncryptFunc(ctx.Crypto.EncryptionKey, send.Key)
+	if err != nil {
+		return err
+	}
+
+	encName, err := crypto.EncryptChunk(send.Key, []byte(send.Name))
+	if err != nil {
+		return err
+	}
+
+	_, err = globals.API.SaveInboxSend(send.ID, shared.SaveInboxSend{
+		FolderID:     folderID,
+		Name:         hex.EncodeToString(encName),
+		ProtectedKey: protectedKey,
+	})
+
+	return err
+}
+
+// decryptInbox decrypts the key and name of each send in the inbox. Sends that
+// can't be decrypted are left out of the returned list.
+func decryptInbox(inbox []shared.InboxItem, privateKey []byte) []inboxSend {
+	var sends []inboxSend
+	for _, item := range inbox {
+		key, err := crypto.DecryptWithPrivateKey(privateKey, item.ProtectedKey)
+		if err != nil {
+			continue
+		}
+
+		encName, err := hex.DecodeString(item.Name)
+		if err != nil {
+			continue
+		}
+
+		name, err := crypto.DecryptChunk(key, encName)
+		if err != nil {
+			continue
+		}
+
+		// Text sends are uploaded with a random name
+		if isTextSend(item) {
+			name = []byte("Text")
+		}
+
+		sends = append(sends, inboxSend{
+			InboxItem: item,
+			Name:      string(name),
+			Key:       key,
+		})
+	}
+
+	return sends
+}
+
+// canSaveToVault checks if a send can be saved to the vault, which is only
+// supported for single file sends
+func canSaveToVault(send inboxSend) bool {
+	return !send.Bundle && !isTextSend(send.InboxItem)
+}
+
+func isTextSend(item shared.InboxItem) bool {
+	return strings.HasPrefix(item.ID, constants.PlaintextIDPrefix)
+}
+
+func getSendTypeString(send inboxSend) string {
+	if isTextSend(send.InboxItem) {
+		return "Text"
+	} else if send.Bundle {
+		return "Files"
+	}
+
+	return "File"
+}
+
+func getDownloadsString(send inboxSend) string {
+	if send.Downloads == 1 {
+		return "1 download left"
+	}
+
+	return fmt.Sprintf("%d downloads left", send.Downloads)
+}
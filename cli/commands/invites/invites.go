@@ -0,0 +1,265 @@
+package invites
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/huh/spinner"
+
+	"yeetfile/cli/commands/vault/items"
+	"yeetfile/cli/crypto"
+	"yeetfile/cli/globals"
+	"yeetfile/cli/styles"
+	"yeetfile/cli/utils"
+	"yeetfile/shared"
+	"yeetfile/shared/constants"
+)
+
+const (
+	inviteTimeFormat = "02 Jan 2006 15:04 MST"
+	backToInvites    = ""
+)
+
+type optionKind int
+
+const (
+	exitInvites optionKind = iota
+	showInvitation
+	showIncomingTransfer
+	showOutgoingTransfer
+	showBlocked
+)
+
+type inviteOption struct {
+	kind optionKind
+	idx  int
+}
+
+// ShowInvitesModel displays the list of pending invitations to files and
+// folders that other users have shared with the current user, as well as any
+// pending ownership transfers. Shared content isn't added to the user's vault
+// until they accept the invitation.
+func ShowInvitesModel() {
+	var response shared.ShareInvitationsResponse
+	var transfers shared.OwnershipTransfersResponse
+	var err error
+	_ = spinner.New().Title("Fetching invitations...").Action(func() {
+		response, err = globals.API.GetShareInvitations()
+		if err != nil {
+			return
+		}
+
+		transfers, err = globals.API.GetOwnershipTransfers()
+	}).Run()
+
+	if err != nil {
+		utils.ShowErrorForm(fmt.Sprintf("Error fetching invitations: %v", err))
+		return
+	}
+
+	var incoming []incomingTransfer
+	if len(transfers.Incoming) > 0 {
+		// Not run in a spinner, since the user may be prompted for their
+		// vault password in order to decrypt their private key
+		keyPair, err := items.UnlockKeyPair()
+		if err != nil {
+			utils.ShowErrorForm(fmt.Sprintf("Error decrypting keys: %v", err))
+			return
+		}
+
+		incoming = decryptTransfers(transfers.Incoming, keyPair.PrivateKey)
+	}
+
+	invitations := response.Invitations
+	total := len(invitations) + len(incoming) + len(transfers.Outgoing)
+
+	selected := inviteOption{kind: exitInvites}
+	options := []huh.Option[inviteOption]{}
+	spacing := utils.GenerateListIdxSpacing(total)
+	addOption := func(label string, kind optionKind, idx int) {
+		num := len(options) + 1
+		idxSpacing := utils.GetListIdxSpacing(spacing, num, total)
+		label = fmt.Sprintf("%d.%s%s", num, idxSpacing, label)
+		options = append(options, huh.NewOption(label, inviteOption{kind, idx}))
+	}
+
+	for i, invitation := range invitations {
+		addOption(fmt.Sprintf("%s | from %s | %s",
+			getItemTypeString(invitation.IsFolder),
+			invitation.SharerName,
+			getPermissionString(invitation)), showInvitation, i)
+	}
+
+	for i, transfer := range incoming {
+		addOption(fmt.Sprintf("Ownership of %s '%s' | from %s",
+			strings.ToLower(getItemTypeString(transfer.IsFolder)),
+			transfer.Name,
+			transfer.UserName), showIncomingTransfer, i)
+	}
+
+	for i, transfer := range transfers.Outgoing {
+		addOption(fmt.Sprintf("Ownership of %s | to %s | pending",
+			strings.ToLower(getItemTypeString(transfer.IsFolder)),
+			transfer.UserName), showOutgoingTransfer, i)
+	}
+
+	if len(response.Blocked) > 0 {
+		label := fmt.Sprintf("Blocked Users (%d)", len(response.Blocked))
+		options = append(options, huh.NewOption(label, inviteOption{kind: showBlocked}))
+	}
+
+	options = append(options, huh.NewOption("Exit", inviteOption{kind: exitInvites}))
+
+	desc := "Files and folders that other users have shared with you, and " +
+		"pending ownership transfers. Select an invitation to accept or " +
+		"decline it."
+	if total == 0 {
+		desc = "You don't have any pending invitations."
+	}
+
+	err = huh.NewForm(huh.NewGroup(
+		utils.CreateHeader("Invitations", desc),
+		huh.NewSelect[inviteOption]().
+			Options(options...).
+			Value(&selected),
+	)).WithTheme(styles.Theme).WithShowHelp(true).Run()
+	if err != nil {
+		return
+	}
+
+	switch selected.kind {
+	case showInvitation:
+		showInviteModel(invitations[selected.idx])
+	case showIncomingTransfer:
+		showIncomingTransferModel(incoming[selected.idx])
+	case showOutgoingTransfer:
+		showOutgoingTransferModel(transfers.Outgoing[selected.idx])
+	case showBlocked:
+		showBlockedModel(response.Blocked)
+	}
+}
+
+func showInviteModel(invitation shared.ShareInvitation) {
+	expiration := "Never"
+	if !invitation.Expiration.IsZero() {
+		expiration = utils.LocalTimeFromUTC(invitation.Expiration).
+			Format(inviteTimeFormat)
+	}
+
+	details := fmt.Sprintf("From:        %s\n"+
+		"Fingerprint: %s\n"+
+		"Type:        %s\n"+
+		"Access:      %s\n"+
+		"Received:    %s\n"+
+		"Expires:     %s\n\n"+
+		"Compare the fingerprint with the sender before accepting "+
+		"to make sure the invitation is from who you expect.",
+		invitation.SharerName,
+		crypto.KeyFingerprint(invitation.PublicKey),
+		getItemTypeString(invitation.IsFolder),
+		getPermissionString(invitation),
+		utils.LocalTimeFromUTC(invitation.Created).Format(inviteTimeFormat),
+		expiration)
+
+	var action string
+	err := huh.NewForm(huh.NewGroup(
+		utils.CreateHeader("Share Invitation", details),
+		huh.NewSelect[string]().
+			Options(
+				huh.NewOption("Accept", constants.InvitationAccept),
+				huh.NewOption("Decline", constants.InvitationDecline),
+				huh.NewOption("Block Sender", constants.InvitationBlock),
+				huh.NewOption("Back", backToInvites),
+			).
+			Value(&action),
+	)).WithTheme(styles.Theme).Run()
+	if err != nil {
+		return
+	} else if action == backToInvites {
+		ShowInvitesModel()
+		return
+	}
+
+	if action == constants.InvitationBlock {
+		var confirmed bool
+		_ = huh.NewForm(huh.NewGroup(
+			utils.CreateHeader("Block Sender", fmt.Sprintf(
+				"%s won't be able to share anything with you, and "+
+					"all of their pending invitations will be "+
+					"declined. You can unblock them later.",
+				invitation.SharerName)),
+			huh.NewConfirm().
+				Affirmative("Block").
+				Negative("Cancel").
+				Value(&confirmed),
+		)).WithTheme(styles.Theme).Run()
+		if !confirmed {
+			showInviteModel(invitation)
+			return
+		}
+	}
+
+	_ = spinner.New().Title("Updating invitation...").Action(func() {
+		err = globals.API.RespondToShareInvitation(invitation.ID, action)
+	}).Run()
+
+	if err != nil {
+		utils.ShowErrorForm(fmt.Sprintf("Error updating invitation: %v", err))
+		return
+	}
+
+	ShowInvitesModel()
+}
+
+func showBlockedModel(blocked []shared.BlockedUser) {
+	options := []huh.Option[string]{}
+	for _, user := range blocked {
+		options = append(options, huh.NewOption(user.Name, user.ID))
+	}
+
+	options = append(options, huh.NewOption("Back", backToInvites))
+
+	var selected string
+	err := huh.NewForm(huh.NewGroup(
+		utils.CreateHeader("Blocked Users", "Select a user to unblock "+
+			"them. Unblocked users can share files and folders with "+
+			"you again."),
+		huh.NewSelect[string]().
+			Options(options...).
+			Value(&selected),
+	)).WithTheme(styles.Theme).Run()
+	if err != nil {
+		return
+	} else if selected == backToInvites {
+		ShowInvitesModel()
+		return
+	}
+
+	_ = spinner.New().Title("Unblocking user...").Action(func() {
+		err = globals.API.UnblockUser(selected)
+	}).Run()
+
+	if err != nil {
+		utils.ShowErrorForm(fmt.Sprintf("Error unblocking user: %v", err))
+		return
+	}
+
+	ShowInvitesModel()
+}
+
+func getItemTypeString(isFolder bool) string {
+	if isFolder {
+		return "Folder"
+	}
+
+	return "File"
+}
+
+func getPermissionString(invitation shared.ShareInvitation) string {
+	if invitation.CanModify {
+		return "Read + Write"
+	}
+
+	return "Read Only"
+}
@@ -0,0 +1,142 @@
+package invites
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/huh/spinner"
+
+	"yeetfile/cli/crypto"
+	"yeetfile/cli/globals"
+	"yeetfile/cli/styles"
+	"yeetfile/cli/utils"
+	"yeetfile/shared"
+	"yeetfile/shared/constants"
+)
+
+type incomingTransfer struct {
+	shared.OwnershipTransfer
+	Name string
+}
+
+func showIncomingTransferModel(transfer incomingTransfer) {
+	itemType := getItemTypeString(transfer.IsFolder)
+	details := fmt.Sprintf("From:        %s\n"+
+		"Fingerprint: %s\n"+
+		"%-12s %s\n"+
+		"Size:        %s\n"+
+		"Received:    %s\n\n"+
+		"Accepting moves the %s to your home folder and makes you "+
+		"its owner. Its storage will count towards your account, and "+
+		"anyone it's shared with will keep their access.",
+		transfer.UserName,
+		crypto.KeyFingerprint(transfer.PublicKey),
+		itemType+":",
+		transfer.Name,
+		shared.ReadableFileSize(transfer.Size),
+		utils.LocalTimeFromUTC(transfer.Created).Format(inviteTimeFormat),
+		itemType)
+
+	var action string
+	err := huh.NewForm(huh.NewGroup(
+		utils.CreateHeader("Ownership Transfer", details),
+		huh.NewSelect[string]().
+			Options(
+				huh.NewOption("Accept", constants.InvitationAccept),
+				huh.NewOption("Decline", constants.InvitationDecline),
+				huh.NewOption("Back", backToInvites),
+			).
+			Value(&action),
+	)).WithTheme(styles.Theme).Run()
+	if err != nil {
+		return
+	} else if action == backToInvites {
+		ShowInvitesModel()
+		return
+	}
+
+	_ = spinner.New().Title("Updating transfer...").Action(func() {
+		err = globals.API.RespondToOwnershipTransfer(transfer.ID, action)
+	}).Run()
+
+	if err != nil {
+		utils.ShowErrorForm(fmt.Sprintf("Error updating transfer: %v", err))
+		return
+	}
+
+	ShowInvitesModel()
+}
+
+func showOutgoingTransferModel(transfer shared.OwnershipTransfer) {
+	details := fmt.Sprintf("To:      %s\n"+
+		"Size:    %s\n"+
+		"Started: %s\n\n"+
+		"You'll remain the owner of the %s until %s accepts the transfer.",
+		transfer.UserName,
+		shared.ReadableFileSize(transfer.Size),
+		utils.LocalTimeFromUTC(transfer.Created).Format(inviteTimeFormat),
+		getItemTypeString(transfer.IsFolder),
+		transfer.UserName)
+
+	var cancel bool
+	err := huh.NewForm(huh.NewGroup(
+		utils.CreateHeader("Ownership Transfer", details),
+		huh.NewSelect[bool]().
+			Options(
+				huh.NewOption("Cancel Transfer", true),
+				huh.NewOption("Back", false),
+			).
+			Value(&cancel),
+	)).WithTheme(styles.Theme).Run()
+	if err != nil {
+		return
+	} else if !cancel {
+		ShowInvitesModel()
+		return
+	}
+
+	_ = spinner.New().Title("Canceling transfer...").Action(func() {
+		err = globals.API.CancelOwnershipTransfer(transfer.ID)
+	}).Run()
+
+	if err != nil {
+		utils.ShowErrorForm(fmt.Sprintf("Error canceling transfer: %v", err))
+		return
+	}
+
+	ShowInvitesModel()
+}
+
+// decryptTransfers decrypts the names of the items being transferred to the
+// user. Item keys are encrypted with the user's public key, the same as items
+// in their home folder.
+func decryptTransfers(
+	transfers []shared.OwnershipTransfer,
+	privateKey []byte,
+) []incomingTransfer {
+	var result []incomingTransfer
+	for _, transfer := range transfers {
+		key, err := crypto.DecryptWithPrivateKey(privateKey, transfer.ProtectedKey)
+		if err != nil {
+			continue
+		}
+
+		encName, err := hex.DecodeString(transfer.Name)
+		if err != nil {
+			continue
+		}
+
+		name, err := crypto.DecryptChunk(key, encName)
+		if err != nil {
+			continue
+		}
+
+		result = append(result, incomingTransfer{
+			OwnershipTransfer: transfer,
+			Name:              string(name),
+		})
+	}
+
+	return result
+}
@@ -15,7 +15,12 @@ import (
 	"yeetfile/cli/commands/auth/logout"
 	"yeetfile/cli/commands/auth/signup"
 	"yeetfile/cli/commands/download"
+	"yeetfile/cli/commands/inbox"
+	"yeetfile/cli/commands/invites"
+	"yeetfile/cli/commands/org"
+	"yeetfile/cli/commands/requests"
 	"yeetfile/cli/commands/send"
+	"yeetfile/cli/commands/upload"
 	"yeetfile/cli/commands/vault"
 	"yeetfile/cli/crypto"
 	"yeetfile/cli/globals"
@@ -33,7 +38,13 @@ const (
 	Vault    Command = "vault"
 	Pass     Command = "pass"
 	Send     Command = "send"
+	Sends    Command = "sends"
+	Inbox    Command = "inbox"
+	Invites  Command = "invites"
 	Download Command = "download"
+	Requests Command = "requests"
+	Org      Command = "org"
+	Upload   Command = "upload"
 	Account  Command = "account"
 	Help     Command = "help"
 )
@@ -46,8 +57,14 @@ var CommandMap = map[Command][]func(){
 	Vault:    {vault.ShowFileVaultModel},
 	Pass:     {vault.ShowPassVaultModel},
 	Send:     {send.ShowSendModel},
+	Sends:    {send.ShowSendListModel},
+	Inbox:    {inbox.ShowInboxModel},
+	Invites:  {invites.ShowInvitesModel},
 	Download: {download.ShowDownloadModel},
-	Account:  {account.ShowAccountModel},
+	Requests: {requests.ShowFileRequestsModel},
+	Org:      {org.ShowOrgModel},
+	Upload:   {upload.ShowUploadModel},
+	Account:  {account.ShowAccountCommand},
 	Help:     {printHelp},
 }
 
@@ -58,7 +75,10 @@ var AuthHelp = []string{
 }
 
 var ActionHelp = []string{
-	fmt.Sprintf("%s  | Manage your YeetFile account", Account),
+	fmt.Sprintf("%s  | Manage your YeetFile account, or compare public key fingerprints\n"+
+		"             - Example: yeetfile account\n"+
+		"             - Example: yeetfile account fingerprint\n"+
+		"             - Example: yeetfile account fingerprint user@example.com", Account),
 	fmt.Sprintf("%s    | Manage files and folders in your YeetFile Vault\n"+
 		"             - Example: yeetfile vault", Vault),
 	fmt.Sprintf("%s     | Manage passwords in your YeetFile Password Vault\n"+
@@ -66,11 +86,24 @@ var ActionHelp = []string{
 	fmt.Sprintf("%s     | Create an end-to-end encrypted shareable link to a file or text\n"+
 		"             - Example: yeetfile send\n"+
 		"             - Example: yeetfile send path/to/file.png\n"+
+		"             - Example: yeetfile send file1.txt file2.txt path/to/dir\n"+
 		"             - Example: yeetfile send 'top secret text'", Send),
+	fmt.Sprintf("%s    | View, change, or revoke your active YeetFile Send links\n"+
+		"             - Example: yeetfile sends", Sends),
+	fmt.Sprintf("%s    | View and download sends that other users have addressed to you\n"+
+		"             - Example: yeetfile inbox", Inbox),
+	fmt.Sprintf("%s  | Accept or decline files, folders, and ownership transfers from other users\n"+
+		"             - Example: yeetfile invites", Invites),
 	fmt.Sprintf("%s | Download a file or text uploaded via YeetFile Send\n"+
 		"             - Example: yeetfile download\n"+
 		"             - Example: yeetfile download https://yeetfile.com/file_abc#top.secret.hash8\n"+
 		"             - Example: yeetfile download file_abc#top.secret.hash8", Download),
+	fmt.Sprintf("%s | Create or revoke links for others to upload files into your vault\n"+
+		"             - Example: yeetfile requests", Requests),
+	fmt.Sprintf("%s      | Manage your organization's members and shared vault folders\n"+
+		"             - Example: yeetfile org", Org),
+	fmt.Sprintf("%s   | Upload a file using a file request link (no account required)\n"+
+		"             - Example: yeetfile upload https://yeetfile.com/request/abc123 path/to/file.png", Upload),
 }
 
 var HelpMsg = `
@@ -145,7 +178,7 @@ func Entrypoint(args []string) {
 		if _, ok := authErr.(*net.OpError); ok {
 			utils.HandleCLIError("Unable to connect to the server", authErr)
 			return
-		} else if !isAuthCommand(command) && command != Download && authErr != nil {
+		} else if !isAuthCommand(command) && !isPublicCommand(command) && authErr != nil {
 			styles.PrintErrStr("You are not logged in. " +
 				"Use the 'login' or 'signup' commands to continue.")
 			return
@@ -208,6 +241,11 @@ session, or prefix commands with the variable (i.e. %[1]s=xxxx yeetfile vault)`,
 	return nil
 }
 
+// isPublicCommand checks if the provided command can be used without an account
+func isPublicCommand(cmd Command) bool {
+	return cmd == Download || cmd == Upload
+}
+
 // isAuthCommand checks if the provided command is related to authentication
 func isAuthCommand(cmd Command) bool {
 	return cmd == Login || cmd == Signup || cmd == Logout || cmd == Auth
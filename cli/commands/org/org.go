@@ -0,0 +1,220 @@
+package org
+
+import (
+	"encoding/hex"
+	"yeetfile/cli/commands/account"
+	"yeetfile/cli/crypto"
+	"yeetfile/cli/globals"
+	"yeetfile/shared"
+	"yeetfile/shared/constants"
+)
+
+type orgFolder struct {
+	shared.OrgFolder
+	Name string
+	Key  []byte
+}
+
+func canManage(org shared.Organization) bool {
+	return org.Role == constants.OrgRoleOwner || org.Role == constants.OrgRoleAdmin
+}
+
+// decryptOrgFolders decrypts the key and name of each org folder using the
+// user's private key
+func decryptOrgFolders(org shared.Organization, privateKey []byte) ([]orgFolder, error) {
+	var folders []orgFolder
+	for _, folder := range org.Folders {
+		key, err := crypto.DecryptWithPrivateKey(privateKey, folder.ProtectedKey)
+		if err != nil {
+			return nil, err
+		}
+
+		name, err := decryptName(key, folder.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		folders = append(folders, orgFolder{
+			OrgFolder: folder,
+			Name:      name,
+			Key:       key,
+		})
+	}
+
+	return folders, nil
+}
+
+// createOrgFolder creates a new org folder, with the folder key encrypted with
+// the public key of each member of the organization
+func createOrgFolder(org shared.Organization, name string) error {
+	key, err := crypto.GenerateRandomKey()
+	if err != nil {
+		return err
+	}
+
+	encName, err := crypto.EncryptChunk(key, []byte(name))
+	if err != nil {
+		return err
+	}
+
+	memberKeys, err := wrapForMembers(org.Members, key)
+	if err != nil {
+		return err
+	}
+
+	_, err = globals.API.CreateOrgFolder(shared.NewOrgFolder{
+		Name:       hex.EncodeToString(encName),
+		MemberKeys: memberKeys,
+	})
+
+	return err
+}
+
+// addOrgMember adds a user to the organization, encrypting the key of each org
+// folder with the new member's public key
+func addOrgMember(folders []orgFolder, user, role string) error {
+	publicKey, err := account.FetchTrustedPubKey(user)
+	if err != nil {
+		return err
+	}
+
+	folderKeys := []shared.OrgKey{}
+	for _, folder := range folders {
+		protectedKey, err := crypto.EncryptWithPublicKey(publicKey, folder.Key)
+		if err != nil {
+			return err
+		}
+
+		folderKeys = append(folderKeys, shared.OrgKey{
+			ID:           folder.ID,
+			ProtectedKey: protectedKey,
+		})
+	}
+
+	_, err = globals.API.AddOrgMember(shared.NewOrgMember{
+		User:       user,
+		Role:       role,
+		FolderKeys: folderKeys,
+	})
+
+	return err
+}
+
+// rotateOrgKeys generates a new key for each org folder. The folder name and
+// the keys of the folder's items and subfolders are re-encrypted with the new
+// key, which is then encrypted with the public key of each remaining member.
+// This is required after removing a member, so that they can't use folder keys
+// they may have kept to access new content. Subfolder keys are only re-wrapped,
+// not replaced, so files added to a subfolder can still be decrypted by a
+// removed member who kept that subfolder's key.
+func rotateOrgKeys(org shared.Organization, folders []orgFolder) error {
+	rotation := shared.OrgKeyRotation{Folders: []shared.OrgFolderRotation{}}
+	for _, folder := range folders {
+		newKey, err := crypto.GenerateRandomKey()
+		if err != nil {
+			return err
+		}
+
+		encName, err := crypto.EncryptChunk(newKey, []byte(folder.Name))
+		if err != nil {
+			return err
+		}
+
+		contents, err := globals.API.FetchFolderContents(folder.ID, false)
+		if err != nil {
+			return err
+		}
+
+		folderRotation := shared.OrgFolderRotation{
+			FolderID:   folder.ID,
+			Name:       hex.EncodeToString(encName),
+			Items:      []shared.OrgKey{},
+			Subfolders: []shared.OrgKey{},
+		}
+
+		for _, item := range contents.Items {
+			if item.PendingKey {
+				continue
+			}
+
+			key, err := rewrapKey(folder.Key, newKey, item.ProtectedKey)
+			if err != nil {
+				return err
+			}
+
+			folderRotation.Items = append(folderRotation.Items, shared.OrgKey{
+				ID:           item.ID,
+				ProtectedKey: key,
+			})
+		}
+
+		for _, subfolder := range contents.Folders {
+			key, err := rewrapKey(folder.Key, newKey, subfolder.ProtectedKey)
+			if err != nil {
+				return err
+			}
+
+			folderRotation.Subfolders = append(folderRotation.Subfolders, shared.OrgKey{
+				ID:           subfolder.ID,
+				ProtectedKey: key,
+			})
+		}
+
+		folderRotation.MemberKeys, err = wrapForMembers(org.Members, newKey)
+		if err != nil {
+			return err
+		}
+
+		rotation.Folders = append(rotation.Folders, folderRotation)
+	}
+
+	return globals.API.RotateOrgKeys(rotation)
+}
+
+// wrapForMembers encrypts a key with the public key of each org member
+func wrapForMembers(members []shared.OrgMember, key []byte) ([]shared.OrgKey, error) {
+	memberKeys := []shared.OrgKey{}
+	for _, member := range members {
+		publicKey, err := account.FetchTrustedPubKey(member.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		protectedKey, err := crypto.EncryptWithPublicKey(publicKey, key)
+		if err != nil {
+			return nil, err
+		}
+
+		memberKeys = append(memberKeys, shared.OrgKey{
+			ID:           member.ID,
+			ProtectedKey: protectedKey,
+		})
+	}
+
+	return memberKeys, nil
+}
+
+// rewrapKey decrypts a key with the old folder key and encrypts it with the
+// new folder key
+func rewrapKey(oldKey, newKey, protectedKey []byte) ([]byte, error) {
+	key, err := crypto.DecryptChunk(oldKey, protectedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return crypto.EncryptChunk(newKey, key)
+}
+
+func decryptName(key []byte, encName string) (string, error) {
+	nameBytes, err := hex.DecodeString(encName)
+	if err != nil {
+		return "", err
+	}
+
+	name, err := crypto.DecryptChunk(key, nameBytes)
+	if err != nil {
+		return "", err
+	}
+
+	return string(name), nil
+}
@@ -0,0 +1,492 @@
+package org
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/huh/spinner"
+
+	"yeetfile/cli/api"
+	"yeetfile/cli/commands/vault/items"
+	"yeetfile/cli/globals"
+	"yeetfile/cli/styles"
+	"yeetfile/cli/utils"
+	"yeetfile/shared"
+	"yeetfile/shared/constants"
+)
+
+type orgAction int
+
+const (
+	viewMembers orgAction = iota
+	addMember
+	viewFolders
+	createFolder
+	rotateKeys
+	leaveOrg
+	deleteOrg
+	exitOrg
+)
+
+type memberAction int
+
+const (
+	changeRole memberAction = iota
+	removeMember
+	backToMembers
+)
+
+const noneSelected = -1
+
+var roleLabels = map[string]string{
+	constants.OrgRoleOwner:    "Owner",
+	constants.OrgRoleAdmin:    "Admin",
+	constants.OrgRoleMember:   "Member",
+	constants.OrgRoleReadOnly: "Read Only",
+}
+
+// ShowOrgModel displays the organization that the user belongs to, or allows
+// creating a new organization if they aren't a member of one
+func ShowOrgModel() {
+	var org shared.Organization
+	var err error
+	_ = spinner.New().Title("Fetching organization...").Action(func() {
+		org, err = globals.API.GetOrganization()
+	}).Run()
+
+	if errors.Is(err, api.NoOrganizationError) {
+		showCreateOrgModel(nil)
+		return
+	} else if err != nil {
+		utils.ShowErrorForm(fmt.Sprintf("Error fetching organization: %v", err))
+		return
+	}
+
+	// Not run in a spinner, since the user may be prompted for their vault
+	// password in order to decrypt their private key
+	keyPair, err := items.UnlockKeyPair()
+	if err != nil {
+		utils.ShowErrorForm(fmt.Sprintf("Error decrypting keys: %v", err))
+		return
+	}
+
+	folders, err := decryptOrgFolders(org, keyPair.PrivateKey)
+	if err != nil {
+		utils.ShowErrorForm(fmt.Sprintf("Error decrypting org folders: %v", err))
+		return
+	}
+
+	showOrgActionsModel(org, folders)
+}
+
+func showOrgActionsModel(org shared.Organization, folders []orgFolder) {
+	details := fmt.Sprintf("Role:    %s\n"+
+		"Members: %d\n"+
+		"Folders: %d\n"+
+		"Storage: %s",
+		roleLabels[org.Role],
+		len(org.Members),
+		len(folders),
+		getStorageString(org))
+
+	var options []huh.Option[orgAction]
+	if org.RotateKeys && canManage(org) {
+		details += "\n\n" + styles.ErrStyle.Render("A member was removed from "+
+			"the organization. Org folder keys need to be rotated "+
+			"before making any other changes.")
+		options = append(options, huh.NewOption("Rotate Keys", rotateKeys))
+	}
+
+	options = append(options, huh.NewOption("Members", viewMembers))
+	if canManage(org) {
+		options = append(options, huh.NewOption("Add Member", addMember))
+	}
+
+	options = append(options, huh.NewOption("Folders", viewFolders))
+	if canManage(org) {
+		options = append(options, huh.NewOption("Create Folder", createFolder))
+	}
+
+	if org.Role == constants.OrgRoleOwner {
+		options = append(options, huh.NewOption("Delete Organization", deleteOrg))
+	} else {
+		options = append(options, huh.NewOption("Leave Organization", leaveOrg))
+	}
+
+	options = append(options, huh.NewOption("Exit", exitOrg))
+
+	var action orgAction
+	err := huh.NewForm(huh.NewGroup(
+		utils.CreateHeader(org.Name, details),
+		huh.NewSelect[orgAction]().
+			Options(options...).
+			Value(&action),
+	)).WithTheme(styles.Theme).WithShowHelp(true).Run()
+	if err != nil {
+		return
+	}
+
+	switch action {
+	case viewMembers:
+		showMembersModel(org, folders)
+	case addMember:
+		showAddMemberModel(org, folders, nil)
+	case viewFolders:
+		showFoldersModel(org, folders)
+	case createFolder:
+		showCreateFolderModel(org, nil)
+	case rotateKeys:
+		runKeyRotation(org, folders)
+	case leaveOrg:
+		confirmAction(
+			"Leave Organization",
+			fmt.Sprintf("Are you sure you want to leave %s? You will "+
+				"lose access to all org folders.", org.Name),
+			"Leaving organization...",
+			func() error {
+				return globals.API.RemoveOrgMember(org.UserID)
+			})
+	case deleteOrg:
+		confirmAction(
+			"Delete Organization",
+			fmt.Sprintf("Are you sure you want to delete %s? All org "+
+				"folders and their contents will be deleted.", org.Name),
+			"Deleting organization...",
+			globals.API.DeleteOrganization)
+	}
+}
+
+func showCreateOrgModel(prevErr error) {
+	var name string
+	var submitted bool
+	var errMsg string
+	if prevErr != nil {
+		errMsg = styles.ErrStyle.Render(prevErr.Error())
+	}
+
+	err := huh.NewForm(huh.NewGroup(
+		utils.CreateHeader("Organization", "You aren't a member of an "+
+			"organization. Create one to share vault folders with "+
+			"your team and pool your storage."),
+		huh.NewInput().Title("Organization Name").
+			CharLimit(constants.MaxOrgNameLen).
+			Validate(func(s string) error {
+				if len(strings.TrimSpace(s)) == 0 {
+					return errors.New("name cannot be empty")
+				}
+
+				return nil
+			}).
+			Value(&name),
+		huh.NewConfirm().
+			Affirmative("Create").
+			Negative("Cancel").
+			Description(errMsg).
+			Value(&submitted),
+	)).WithTheme(styles.Theme).Run()
+	if err != nil || !submitted {
+		return
+	}
+
+	_ = spinner.New().Title("Creating organization...").Action(func() {
+		_, err = globals.API.CreateOrganization(name)
+	}).Run()
+	if err != nil {
+		showCreateOrgModel(err)
+		return
+	}
+
+	ShowOrgModel()
+}
+
+func showMembersModel(org shared.Organization, folders []orgFolder) {
+	selected := noneSelected
+	var options []huh.Option[int]
+	spacing := utils.GenerateListIdxSpacing(len(org.Members))
+	for i, member := range org.Members {
+		idxSpacing := utils.GetListIdxSpacing(spacing, i+1, len(org.Members))
+		label := fmt.Sprintf("%d.%s%s | %s",
+			i+1,
+			idxSpacing,
+			member.Name,
+			roleLabels[member.Role])
+		options = append(options, huh.NewOption(label, i))
+	}
+
+	options = append(options, huh.NewOption("Back", noneSelected))
+
+	err := huh.NewForm(huh.NewGroup(
+		utils.CreateHeader("Members", org.Name),
+		huh.NewSelect[int]().
+			Options(options...).
+			Value(&selected),
+	)).WithTheme(styles.Theme).WithShowHelp(true).Run()
+	if err != nil {
+		return
+	} else if selected == noneSelected || !canManage(org) {
+		showOrgActionsModel(org, folders)
+		return
+	}
+
+	showMemberModel(org, folders, org.Members[selected])
+}
+
+func showMemberModel(org shared.Organization, folders []orgFolder, member shared.OrgMember) {
+	if member.Role == constants.OrgRoleOwner || member.ID == org.UserID {
+		showMembersModel(org, folders)
+		return
+	}
+
+	var action memberAction
+	err := huh.NewForm(huh.NewGroup(
+		utils.CreateHeader(member.Name, "Role: "+roleLabels[member.Role]),
+		huh.NewSelect[memberAction]().
+			Options(
+				huh.NewOption("Change Role", changeRole),
+				huh.NewOption("Remove From Organization", removeMember),
+				huh.NewOption("Back", backToMembers)).
+			Value(&action),
+	)).WithTheme(styles.Theme).Run()
+	if err != nil {
+		return
+	}
+
+	switch action {
+	case changeRole:
+		role := member.Role
+		err = huh.NewForm(huh.NewGroup(
+			utils.CreateHeader("Change Role", member.Name),
+			huh.NewSelect[string]().
+				Options(getRoleOptions(org)...).
+				Value(&role),
+		)).WithTheme(styles.Theme).Run()
+		if err != nil || role == member.Role {
+			showMemberModel(org, folders, member)
+			return
+		}
+
+		_ = spinner.New().Title("Updating role...").Action(func() {
+			err = globals.API.SetOrgMemberRole(member.ID, role)
+		}).Run()
+		if err != nil {
+			utils.ShowErrorForm(fmt.Sprintf("Error changing role: %v", err))
+		}
+
+		ShowOrgModel()
+	case removeMember:
+		removeOrgMember(org, folders, member)
+	case backToMembers:
+		showMembersModel(org, folders)
+	}
+}
+
+// removeOrgMember removes a member from the organization, and then rotates
+// the keys for each org folder
+func removeOrgMember(org shared.Organization, folders []orgFolder, member shared.OrgMember) {
+	var confirm bool
+	err := huh.NewForm(huh.NewGroup(
+		utils.CreateHeader("Remove Member", fmt.Sprintf(
+			"Remove %s from %s? The keys for all org folders will "+
+				"be rotated afterwards.", member.Name, org.Name)),
+		huh.NewConfirm().
+			Affirmative("Remove").
+			Negative("Cancel").
+			Value(&confirm),
+	)).WithTheme(styles.Theme).Run()
+	if err != nil || !confirm {
+		showMemberModel(org, folders, member)
+		return
+	}
+
+	_ = spinner.New().Title("Removing member...").Action(func() {
+		err = globals.API.RemoveOrgMember(member.ID)
+		if err != nil {
+			return
+		}
+
+		org, err = globals.API.GetOrganization()
+	}).Run()
+	if err != nil {
+		utils.ShowErrorForm(fmt.Sprintf("Error removing member: %v", err))
+		return
+	}
+
+	runKeyRotation(org, folders)
+}
+
+func runKeyRotation(org shared.Organization, folders []orgFolder) {
+	var err error
+	_ = spinner.New().Title("Rotating org folder keys...").Action(func() {
+		err = rotateOrgKeys(org, folders)
+	}).Run()
+	if err != nil {
+		utils.ShowErrorForm(fmt.Sprintf("Error rotating org folder keys: %v", err))
+		return
+	}
+
+	ShowOrgModel()
+}
+
+func showAddMemberModel(org shared.Organization, folders []orgFolder, prevErr error) {
+	var user string
+	var submitted bool
+	var errMsg string
+	if prevErr != nil {
+		errMsg = styles.ErrStyle.Render(prevErr.Error())
+	}
+
+	role := constants.OrgRoleMember
+	err := huh.NewForm(huh.NewGroup(
+		utils.CreateHeader("Add Member", "Add a YeetFile user to "+org.Name+
+			". They will have access to all org folders."),
+		huh.NewInput().Title("Email or Account ID").
+			Value(&user),
+		huh.NewSelect[string]().Title("Role").
+			Options(getRoleOptions(org)...).
+			Value(&role),
+		huh.NewConfirm().
+			Affirmative("Add").
+			Negative("Cancel").
+			Description(errMsg).
+			Value(&submitted),
+	)).WithTheme(styles.Theme).Run()
+	if err != nil || !submitted {
+		showOrgActionsModel(org, folders)
+		return
+	}
+
+	_ = spinner.New().Title("Adding member...").Action(func() {
+		err = addOrgMember(folders, strings.TrimSpace(user), role)
+	}).Run()
+	if err != nil {
+		showAddMemberModel(org, folders, err)
+		return
+	}
+
+	ShowOrgModel()
+}
+
+func showFoldersModel(org shared.Organization, folders []orgFolder) {
+	selected := noneSelected
+	var options []huh.Option[int]
+	for i, folder := range folders {
+		options = append(options, huh.NewOption(folder.Name, i))
+	}
+
+	options = append(options, huh.NewOption("Back", noneSelected))
+
+	desc := "Org folders are shown in the vault of every member."
+	if canManage(org) {
+		desc += " Select a folder to delete it."
+	}
+
+	err := huh.NewForm(huh.NewGroup(
+		utils.CreateHeader("Folders", desc),
+		huh.NewSelect[int]().
+			Options(options...).
+			Value(&selected),
+	)).WithTheme(styles.Theme).WithShowHelp(true).Run()
+	if err != nil {
+		return
+	} else if selected == noneSelected || !canManage(org) {
+		showOrgActionsModel(org, folders)
+		return
+	}
+
+	folder := folders[selected]
+	confirmAction(
+		"Delete Folder",
+		fmt.Sprintf("Are you sure you want to delete %s? The folder "+
+			"and its contents will be removed for all members.", folder.Name),
+		"Deleting folder...",
+		func() error {
+			return globals.API.DeleteOrgFolder(folder.ID)
+		})
+}
+
+func showCreateFolderModel(org shared.Organization, prevErr error) {
+	var name string
+	var submitted bool
+	var errMsg string
+	if prevErr != nil {
+		errMsg = styles.ErrStyle.Render(prevErr.Error())
+	}
+
+	err := huh.NewForm(huh.NewGroup(
+		utils.CreateHeader("Create Folder", "The folder will be shared "+
+			"with all members of "+org.Name+"."),
+		huh.NewInput().Title("Folder Name").
+			Value(&name),
+		huh.NewConfirm().
+			Affirmative("Create").
+			Negative("Cancel").
+			Description(errMsg).
+			Value(&submitted),
+	)).WithTheme(styles.Theme).Run()
+	if err != nil || !submitted {
+		ShowOrgModel()
+		return
+	}
+
+	_ = spinner.New().Title("Creating folder...").Action(func() {
+		err = createOrgFolder(org, name)
+	}).Run()
+	if err != nil {
+		showCreateFolderModel(org, err)
+		return
+	}
+
+	ShowOrgModel()
+}
+
+func confirmAction(title, desc, progress string, action func() error) {
+	var confirm bool
+	err := huh.NewForm(huh.NewGroup(
+		utils.CreateHeader(title, desc),
+		huh.NewConfirm().
+			Affirmative("Confirm").
+			Negative("Cancel").
+			Value(&confirm),
+	)).WithTheme(styles.Theme).Run()
+	if err != nil {
+		return
+	} else if !confirm {
+		ShowOrgModel()
+		return
+	}
+
+	_ = spinner.New().Title(progress).Action(func() {
+		err = action()
+	}).Run()
+	if err != nil {
+		utils.ShowErrorForm(fmt.Sprintf("Error: %v", err))
+	}
+}
+
+// getRoleOptions returns the roles that the user can assign to other members.
+// Only the owner can assign the admin role.
+func getRoleOptions(org shared.Organization) []huh.Option[string] {
+	var options []huh.Option[string]
+	roles := []string{constants.OrgRoleMember, constants.OrgRoleReadOnly}
+	if org.Role == constants.OrgRoleOwner {
+		roles = append([]string{constants.OrgRoleAdmin}, roles...)
+	}
+
+	for _, role := range roles {
+		options = append(options, huh.NewOption(roleLabels[role], role))
+	}
+
+	return options
+}
+
+func getStorageString(org shared.Organization) string {
+	if org.StorageAvailable <= 0 {
+		return fmt.Sprintf("%s used", shared.ReadableFileSize(org.StorageUsed))
+	}
+
+	return fmt.Sprintf("%s / %s (pooled)",
+		shared.ReadableFileSize(org.StorageUsed),
+		shared.ReadableFileSize(org.StorageAvailable))
+}
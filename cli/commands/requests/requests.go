@@ -0,0 +1,256 @@
+package requests
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/huh/spinner"
+
+	"yeetfile/cli/commands/vault/items"
+	"yeetfile/cli/globals"
+	"yeetfile/cli/models"
+	"yeetfile/cli/styles"
+	"yeetfile/cli/utils"
+	"yeetfile/shared"
+	"yeetfile/shared/endpoints"
+)
+
+const (
+	requestTimeFormat = "02 Jan 2006 15:04 MST"
+	newRequest        = -1
+	exitRequests      = -2
+	homeFolderName    = "Home"
+)
+
+var numericError = errors.New("input must only contain numeric characters")
+
+// ShowFileRequestsModel displays the user's file requests, and allows creating
+// new requests or revoking existing ones.
+func ShowFileRequestsModel() {
+	var requests []shared.FileRequest
+	var err error
+	_ = spinner.New().Title("Fetching file requests...").Action(func() {
+		requests, err = globals.API.GetFileRequests()
+	}).Run()
+
+	if err != nil {
+		utils.ShowErrorForm(fmt.Sprintf("Error fetching file requests: %v", err))
+		return
+	}
+
+	selected := exitRequests
+	options := []huh.Option[int]{huh.NewOption("Create New Request", newRequest)}
+	spacing := utils.GenerateListIdxSpacing(len(requests))
+	for i, request := range requests {
+		idxSpacing := utils.GetListIdxSpacing(spacing, i+1, len(requests))
+		label := fmt.Sprintf("%d.%s%s | %s | %s",
+			i+1,
+			idxSpacing,
+			request.ID,
+			getFolderString(request),
+			getUploadsString(request))
+		options = append(options, huh.NewOption(label, i))
+	}
+
+	options = append(options, huh.NewOption("Exit", exitRequests))
+
+	desc := "File requests let anyone with the link upload files into " +
+		"your vault, encrypted with your public key."
+	err = huh.NewForm(huh.NewGroup(
+		utils.CreateHeader("File Requests", desc),
+		huh.NewSelect[int]().
+			Options(options...).
+			Value(&selected),
+	)).WithTheme(styles.Theme).WithShowHelp(true).Run()
+	if err != nil || selected == exitRequests {
+		return
+	} else if selected == newRequest {
+		showCreateRequestModel(nil)
+		return
+	}
+
+	showRevokeRequestModel(requests[selected])
+}
+
+func showCreateRequestModel(prevErr error) {
+	// Not run in a spinner, since the user may be prompted for their vault
+	// password in order to decrypt the folder names
+	folders, err := fetchOwnedRootFolders()
+	if err != nil {
+		utils.ShowErrorForm(fmt.Sprintf("Error loading vault folders: %v", err))
+		return
+	}
+
+	folderOptions := []huh.Option[string]{huh.NewOption(homeFolderName, "")}
+	for _, folder := range folders {
+		folderOptions = append(folderOptions, huh.NewOption(folder.Name, folder.ID))
+	}
+
+	var (
+		folderID   string
+		maxUploads string
+		maxSizeMB  string
+		expDays    string
+		submitted  bool
+	)
+
+	var errMsg string
+	if prevErr != nil {
+		errMsg = styles.ErrStyle.Render(prevErr.Error())
+	}
+
+	err = huh.NewForm(huh.NewGroup(
+		utils.CreateHeader("New File Request", "Leave a field blank "+
+			"for no limit."),
+		huh.NewSelect[string]().Title("Folder").
+			Options(folderOptions...).
+			Value(&folderID),
+		huh.NewInput().Title("Max Uploads").
+			Validate(validateOptionalNumber).
+			Value(&maxUploads),
+		huh.NewInput().Title("Max File Size (MB)").
+			Validate(validateOptionalNumber).
+			Value(&maxSizeMB),
+		huh.NewInput().Title("Expiration (Days)").
+			Validate(validateOptionalNumber).
+			Value(&expDays),
+		huh.NewConfirm().
+			Affirmative("Create").
+			Negative("Cancel").
+			Description(errMsg).
+			Value(&submitted),
+	)).WithTheme(styles.Theme).Run()
+	if err != nil || !submitted {
+		ShowFileRequestsModel()
+		return
+	}
+
+	request := shared.NewFileRequest{FolderID: folderID}
+	request.MaxUploads, _ = strconv.Atoi(maxUploads)
+	if size, _ := strconv.ParseInt(maxSizeMB, 10, 64); size > 0 {
+		request.MaxSize = size * 1000 * 1000
+	}
+
+	if days, _ := strconv.Atoi(expDays); days > 0 {
+		request.Expiration = fmt.Sprintf("%dd", days)
+	}
+
+	var id string
+	_ = spinner.New().Title("Creating file request...").Action(func() {
+		id, err = globals.API.CreateFileRequest(request)
+	}).Run()
+	if err != nil {
+		showCreateRequestModel(err)
+		return
+	}
+
+	showRequestLinkModel(id)
+}
+
+func showRequestLinkModel(id string) {
+	link := endpoints.HTMLFileRequest.Format(globals.Config.Server, id)
+	_ = huh.NewForm(huh.NewGroup(
+		huh.NewNote().Title(utils.GenerateTitle("File Request Link")),
+		huh.NewNote().
+			Title("Link").
+			Description(link),
+		huh.NewNote().
+			Title("CLI").
+			Description(fmt.Sprintf("yeetfile upload %s <file>", link)),
+		huh.NewConfirm().Affirmative("OK").Negative(""),
+	)).WithTheme(styles.Theme).Run()
+
+	ShowFileRequestsModel()
+}
+
+func showRevokeRequestModel(request shared.FileRequest) {
+	expiration := "Never"
+	if !request.Expiration.IsZero() {
+		expiration = utils.LocalTimeFromUTC(request.Expiration).Format(requestTimeFormat)
+	}
+
+	maxSize := "No limit"
+	if request.MaxSize > 0 {
+		maxSize = shared.ReadableFileSize(request.MaxSize)
+	}
+
+	details := fmt.Sprintf("Link:     %s\n"+
+		"Folder:   %s\n"+
+		"Uploads:  %s\n"+
+		"Max Size: %s\n"+
+		"Expires:  %s",
+		endpoints.HTMLFileRequest.Format(globals.Config.Server, request.ID),
+		getFolderString(request),
+		getUploadsString(request),
+		maxSize,
+		expiration)
+
+	var revoke bool
+	err := huh.NewForm(huh.NewGroup(
+		utils.CreateHeader(request.ID, details),
+		huh.NewConfirm().
+			Affirmative("Revoke").
+			Negative("Back").
+			Value(&revoke),
+	)).WithTheme(styles.Theme).Run()
+	if err != nil || !revoke {
+		ShowFileRequestsModel()
+		return
+	}
+
+	err = globals.API.DeleteFileRequest(request.ID)
+	if err != nil {
+		utils.ShowErrorForm(fmt.Sprintf("Error revoking file request: %v", err))
+	}
+
+	ShowFileRequestsModel()
+}
+
+// fetchOwnedRootFolders returns the folders in the root of the user's vault
+// that can be used for a file request. The folder names are decrypted.
+func fetchOwnedRootFolders() ([]models.VaultItem, error) {
+	rootFolders, err := items.FetchRootFolders()
+	if err != nil {
+		return nil, err
+	}
+
+	var folders []models.VaultItem
+	for _, folder := range rootFolders {
+		if folder.IsOwner && len(folder.SharedBy) == 0 {
+			folders = append(folders, folder)
+		}
+	}
+
+	return folders, nil
+}
+
+func validateOptionalNumber(s string) error {
+	if len(s) == 0 {
+		return nil
+	}
+
+	_, err := strconv.Atoi(s)
+	if err != nil {
+		return numericError
+	}
+
+	return nil
+}
+
+func getFolderString(request shared.FileRequest) string {
+	if len(request.FolderID) == 0 {
+		return homeFolderName
+	}
+
+	return "Folder " + request.FolderID
+}
+
+func getUploadsString(request shared.FileRequest) string {
+	if request.MaxUploads == 0 {
+		return fmt.Sprintf("%d uploads", request.Uploads)
+	}
+
+	return fmt.Sprintf("%d/%d uploads", request.Uploads, request.MaxUploads)
+}
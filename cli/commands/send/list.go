@@ -0,0 +1,272 @@
+package send
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/huh/spinner"
+
+	"yeetfile/cli/globals"
+	"yeetfile/cli/styles"
+	"yeetfile/cli/utils"
+	"yeetfile/shared"
+	"yeetfile/shared/constants"
+)
+
+type sendAction int
+
+const (
+	changeSend sendAction = iota
+	revokeSend
+	backToSends
+)
+
+const (
+	sendTimeFormat = "02 Jan 2006 15:04 MST"
+	noSendSelected = -1
+)
+
+// ShowSendListModel displays the list of active sends created by the user,
+// which can be selected to change their expiration or revoke them.
+func ShowSendListModel() {
+	var sends []shared.SendItem
+	var err error
+	_ = spinner.New().Title("Fetching sends...").Action(func() {
+		sends, err = globals.API.GetSends()
+	}).Run()
+
+	if err != nil {
+		utils.ShowErrorForm(fmt.Sprintf("Error fetching sends: %v", err))
+		return
+	}
+
+	selected := noSendSelected
+	options := []huh.Option[int]{}
+	spacing := utils.GenerateListIdxSpacing(len(sends))
+	for i, send := range sends {
+		idxSpacing := utils.GetListIdxSpacing(spacing, i+1, len(sends))
+		label := fmt.Sprintf("%d.%s%s | %s | %s | expires %s",
+			i+1,
+			idxSpacing,
+			send.ID,
+			getSendSizeString(send),
+			getDownloadsString(send),
+			utils.LocalTimeFromUTC(send.Expiration).Format(sendTimeFormat))
+		options = append(options, huh.NewOption(label, i))
+	}
+
+	options = append(options, huh.NewOption("Exit", noSendSelected))
+
+	desc := "Select a send to change its expiration or revoke it.\n" +
+		"File and text names are encrypted, so sends are listed by ID."
+	if len(sends) == 0 {
+		desc = "You don't have any active sends."
+	}
+
+	err = huh.NewForm(huh.NewGroup(
+		utils.CreateHeader("My Sends", desc),
+		huh.NewSelect[int]().
+			Options(options...).
+			Value(&selected),
+	)).WithTheme(styles.Theme).WithShowHelp(true).Run()
+	if err != nil || selected == noSendSelected {
+		return
+	}
+
+	showSendActionsModel(sends[selected])
+}
+
+func showSendActionsModel(send shared.SendItem) {
+	var action sendAction
+	details := fmt.Sprintf("Type:      %s\n"+
+		"Size:      %s\n"+
+		"Downloads: %s\n"+
+		"Created:   %s\n"+
+		"Expires:   %s",
+		getSendTypeString(send),
+		getSendSizeString(send),
+		getDownloadsString(send),
+		utils.LocalTimeFromUTC(send.Created).Format(sendTimeFormat),
+		utils.LocalTimeFromUTC(send.Expiration).Format(sendTimeFormat))
+
+	err := huh.NewForm(huh.NewGroup(
+		utils.CreateHeader(send.ID, details),
+		huh.NewSelect[sendAction]().
+			Title("Actions").
+			Options(
+				huh.NewOption("Change Expiration / Downloads", changeSend),
+				huh.NewOption("Revoke", revokeSend),
+				huh.NewOption("Back", backToSends),
+			).Value(&action),
+	)).WithTheme(styles.Theme).Run()
+	if err != nil {
+		return
+	}
+
+	switch action {
+	case changeSend:
+		showChangeSendModel(send, nil)
+	case revokeSend:
+		showRevokeSendModel(send)
+	case backToSends:
+		ShowSendListModel()
+	}
+}
+
+func showChangeSendModel(send shared.SendItem, prevErr error) {
+	var (
+		newExpiration string
+		newUnits      = expMinutes
+		newDownloads  string
+		submitted     bool
+	)
+
+	var errMsg string
+	if prevErr != nil {
+		errMsg = styles.ErrStyle.Render(prevErr.Error())
+	}
+
+	err := huh.NewForm(huh.NewGroup(
+		utils.CreateHeader("Change Send", "Set a new expiration and/or "+
+			"number of remaining downloads. Leave a field blank to "+
+			"keep its current value."),
+		huh.NewInput().Title("Expiration").
+			Validate(func(s string) error {
+				if len(s) == 0 {
+					return nil
+				}
+
+				val, err := strconv.Atoi(s)
+				if err != nil {
+					return expValidationError
+				} else if val < 1 {
+					return inputTooLowError
+				} else if !isValidExp(int64(val), newUnits) {
+					return expExceedsMaxErr
+				}
+
+				return nil
+			}).Value(&newExpiration),
+		huh.NewSelect[string]().Title("Units").
+			Options([]huh.Option[string]{
+				huh.NewOption("Minutes", expMinutes),
+				huh.NewOption("Hours", expHours),
+				huh.NewOption("Days", expDays),
+			}...).Value(&newUnits),
+		huh.NewInput().Title("Remaining Downloads").
+			Placeholder(strconv.Itoa(send.Downloads)).
+			Validate(func(s string) error {
+				if len(s) == 0 {
+					return nil
+				}
+
+				val, err := strconv.Atoi(s)
+				if err != nil {
+					return expValidationError
+				} else if val > constants.MaxSendDownloads {
+					return exceedsMaxDownloads
+				} else if val < 1 {
+					return inputTooLowError
+				}
+
+				return nil
+			}).Value(&newDownloads),
+		huh.NewConfirm().
+			Affirmative("Submit").
+			Negative("Cancel").
+			Description(errMsg).
+			Value(&submitted),
+	)).WithTheme(styles.Theme).Run()
+	if err != nil || !submitted {
+		showSendActionsModel(send)
+		return
+	}
+
+	mod := shared.ModifySend{}
+	if len(newExpiration) > 0 {
+		expVal, _ := strconv.Atoi(newExpiration)
+		mod.Expiration = createExpString(expVal, newUnits)
+	}
+
+	if len(newDownloads) > 0 {
+		mod.Downloads, _ = strconv.Atoi(newDownloads)
+	}
+
+	if len(mod.Expiration) == 0 && mod.Downloads == 0 {
+		showSendActionsModel(send)
+		return
+	}
+
+	err = globals.API.ModifySend(send.ID, mod)
+	if err != nil {
+		showChangeSendModel(send, err)
+		return
+	}
+
+	if mod.Downloads > 0 {
+		send.Downloads = mod.Downloads
+	}
+
+	if len(mod.Expiration) > 0 {
+		expVal, _ := strconv.Atoi(newExpiration)
+		send.Expiration = time.Now().Add(getDuration(int64(expVal), newUnits))
+	}
+
+	showSendActionsModel(send)
+}
+
+func showRevokeSendModel(send shared.SendItem) {
+	var confirmed bool
+	desc := fmt.Sprintf("Are you sure you want to revoke %s? The link "+
+		"will stop working immediately and can't be restored.", send.ID)
+	err := huh.NewForm(huh.NewGroup(
+		utils.CreateHeader("Revoke Send", desc),
+		huh.NewConfirm().
+			Affirmative("Revoke").
+			Negative("Cancel").
+			Value(&confirmed),
+	)).WithTheme(styles.Theme).Run()
+	if err != nil || !confirmed {
+		showSendActionsModel(send)
+		return
+	}
+
+	err = globals.API.DeleteSend(send.ID)
+	if err != nil {
+		utils.ShowErrorForm(fmt.Sprintf("Error revoking send: %v", err))
+		showSendActionsModel(send)
+		return
+	}
+
+	ShowSendListModel()
+}
+
+func getSendTypeString(send shared.SendItem) string {
+	if send.TextOnly {
+		return textOption
+	} else if send.Bundle {
+		return filesOption
+	}
+
+	return fileOption
+}
+
+func getSendSizeString(send shared.SendItem) string {
+	if send.Size < 0 {
+		return "Uploading"
+	}
+
+	return shared.ReadableFileSize(send.Size)
+}
+
+func getDownloadsString(send shared.SendItem) string {
+	if send.TotalDownloads < 0 {
+		return fmt.Sprintf("%d downloads left", send.Downloads)
+	}
+
+	return fmt.Sprintf("%d/%d downloads left",
+		send.Downloads,
+		send.TotalDownloads)
+}
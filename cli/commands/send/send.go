@@ -1,12 +1,17 @@
 package send
 
 import (
+	"bytes"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"path/filepath"
 	"strings"
 	"time"
 	"yeetfile/cli/utils"
 
+	"yeetfile/cli/commands/account"
 	"yeetfile/cli/crypto"
 	"yeetfile/cli/globals"
 	"yeetfile/cli/transfer"
@@ -14,20 +19,27 @@ import (
 	"yeetfile/shared/constants"
 )
 
+var manifestTooLargeError = errors.New("too many files to send at once")
+
 type fileUpload struct {
 	FilePath     string
+	BundlePaths  []string
 	MaxDownloads int
 	ExpUnits     string
 	ExpValue     int
 	Password     string
+	Notify       bool
+	Recipients   []string
 }
 
 type textUpload struct {
 	Text         string
+	Format       string
 	MaxDownloads int
 	ExpUnits     string
 	ExpValue     int
 	Password     string
+	Recipients   []string
 }
 
 const (
@@ -61,32 +73,53 @@ func isValidExp(value int64, units string) bool {
 	return time.Now().Add(duration).Before(maxAge)
 }
 
-func createTextLink(upload textUpload) (string, string, error) {
+func createTextLink(upload textUpload, progress func(int, int)) (string, string, error) {
 	key, salt, err := crypto.DeriveSendingKey(
 		[]byte(upload.Password), nil)
 	if err != nil {
 		return "", "", err
 	}
 
-	encName, err := crypto.EncryptChunk(key, []byte(shared.GenRandomString(8)))
+	recipients, err := wrapSendKey(key, upload.Recipients)
 	if err != nil {
 		return "", "", err
 	}
-	hexEncName := hex.EncodeToString(encName)
-	encText, err := crypto.EncryptChunk(key, []byte(upload.Text))
+
+	encName, err := crypto.EncryptChunk(key, []byte(shared.GenRandomString(8)))
 	if err != nil {
 		return "", "", err
 	}
+	hexEncName := hex.EncodeToString(encName)
+
+	var encFormat []byte
+	if len(upload.Format) > 0 {
+		encFormat, err = crypto.EncryptChunk(key, []byte(upload.Format))
+		if err != nil {
+			return "", "", err
+		}
+	}
 
-	encTextUpload := shared.PlaintextUpload{
-		Name:       hexEncName,
-		Salt:       salt,
-		Downloads:  upload.MaxDownloads,
-		Expiration: createExpString(upload.ExpValue, upload.ExpUnits),
-		Text:       encText,
+	var id string
+	if len(upload.Text) > constants.MaxPlaintextLen {
+		id, err = uploadLargeText(upload, key, hexEncName, encFormat, recipients, progress)
+	} else {
+		var encText []byte
+		encText, err = crypto.EncryptChunk(key, []byte(upload.Text))
+		if err != nil {
+			return "", "", err
+		}
+
+		id, err = globals.API.UploadText(shared.PlaintextUpload{
+			Name:       hexEncName,
+			Salt:       salt,
+			Downloads:  upload.MaxDownloads,
+			Expiration: createExpString(upload.ExpValue, upload.ExpUnits),
+			Text:       encText,
+			Format:     encFormat,
+			Recipients: recipients,
+		})
 	}
 
-	id, err := globals.API.UploadText(encTextUpload)
 	if err != nil {
 		return "", "", err
 	}
@@ -98,6 +131,41 @@ func createTextLink(upload textUpload) (string, string, error) {
 	}
 }
 
+// uploadLargeText uploads text that exceeds constants.MaxPlaintextLen in
+// chunks, the same way that files are uploaded
+func uploadLargeText(
+	upload textUpload,
+	key []byte,
+	hexEncName string,
+	encFormat []byte,
+	recipients []shared.SendRecipient,
+	progress func(int, int),
+) (string, error) {
+	text := []byte(upload.Text)
+	size := int64(len(text))
+	metadata := shared.UploadMetadata{
+		Name:       hexEncName,
+		Chunks:     transfer.GetNumChunks(size),
+		Size:       size,
+		Downloads:  upload.MaxDownloads,
+		Expiration: createExpString(upload.ExpValue, upload.ExpUnits),
+		Text:       true,
+		Format:     encFormat,
+		Recipients: recipients,
+	}
+
+	pending, err := transfer.InitSendFile(bytes.NewReader(text), metadata, key)
+	if err != nil {
+		return "", err
+	}
+
+	chunk := 0
+	return pending.UploadData(func() {
+		chunk += 1
+		progress(chunk, pending.NumChunks)
+	})
+}
+
 func createFileLink(upload fileUpload, progress func(int, int)) (string, string, error) {
 	key, salt, err := crypto.DeriveSendingKey(
 		[]byte(upload.Password), nil)
@@ -105,6 +173,11 @@ func createFileLink(upload fileUpload, progress func(int, int)) (string, string,
 		return "", "", err
 	}
 
+	recipients, err := wrapSendKey(key, upload.Recipients)
+	if err != nil {
+		return "", "", err
+	}
+
 	file, stat, err := shared.GetFileInfo(upload.FilePath)
 
 	encName, err := crypto.EncryptChunk(key, []byte(stat.Name()))
@@ -118,6 +191,8 @@ func createFileLink(upload fileUpload, progress func(int, int)) (string, string,
 		Size:       size,
 		Downloads:  upload.MaxDownloads,
 		Expiration: createExpString(upload.ExpValue, upload.ExpUnits),
+		Notify:     upload.Notify,
+		Recipients: recipients,
 	}
 
 	pending, err := transfer.InitSendFile(file, metadata, key)
@@ -141,6 +216,112 @@ func createFileLink(upload fileUpload, progress func(int, int)) (string, string,
 	}
 }
 
+// createBundleLink uploads multiple files (or the contents of directories) as a
+// single send. The file contents are uploaded as one combined stream, and the
+// location of each file within the stream is stored in an encrypted manifest.
+func createBundleLink(upload fileUpload, progress func(int, int)) (string, string, error) {
+	key, salt, err := crypto.DeriveSendingKey(
+		[]byte(upload.Password), nil)
+	if err != nil {
+		return "", "", err
+	}
+
+	recipients, err := wrapSendKey(key, upload.Recipients)
+	if err != nil {
+		return "", "", err
+	}
+
+	reader, manifest, err := transfer.NewBundleReader(upload.BundlePaths)
+	if err != nil {
+		return "", "", err
+	}
+
+	defer reader.Close()
+
+	manifestJSON, err := json.Marshal(manifest)
+	if err != nil {
+		return "", "", err
+	}
+
+	encManifest, err := crypto.EncryptChunk(key, manifestJSON)
+	if err != nil {
+		return "", "", err
+	} else if len(encManifest) > constants.MaxSendManifestSize {
+		return "", "", manifestTooLargeError
+	}
+
+	encName, err := crypto.EncryptChunk(key, []byte(getBundleName(upload.BundlePaths, manifest)))
+	if err != nil {
+		return "", "", err
+	}
+
+	metadata := shared.UploadMetadata{
+		Name:       hex.EncodeToString(encName),
+		Chunks:     transfer.GetNumChunks(reader.Size()),
+		Size:       reader.Size(),
+		Downloads:  upload.MaxDownloads,
+		Expiration: createExpString(upload.ExpValue, upload.ExpUnits),
+		Notify:     upload.Notify,
+		Manifest:   encManifest,
+		Recipients: recipients,
+	}
+
+	pending, err := transfer.InitSendFile(reader, metadata, key)
+	if err != nil {
+		return "", "", err
+	}
+
+	chunk := 0
+	result, err := pending.UploadData(func() {
+		chunk += 1
+		progress(chunk, pending.NumChunks)
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	if len(upload.Password) > 0 {
+		return result, utils.B64Encode(salt), nil
+	} else {
+		return result, utils.B64Encode(key), nil
+	}
+}
+
+// wrapSendKey encrypts the send key with the public key of each recipient, so
+// that only those users are able to decrypt the send from their inbox.
+// Recipients can be either an email address or an account ID.
+func wrapSendKey(key []byte, users []string) ([]shared.SendRecipient, error) {
+	var recipients []shared.SendRecipient
+	for _, user := range users {
+		publicKey, err := account.FetchTrustedPubKey(user)
+		if err != nil {
+			return nil, fmt.Errorf("unable to verify recipient %s: %w", user, err)
+		}
+
+		protectedKey, err := crypto.EncryptWithPublicKey(publicKey, key)
+		if err != nil {
+			return nil, err
+		}
+
+		recipients = append(recipients, shared.SendRecipient{
+			User:         user,
+			ProtectedKey: protectedKey,
+		})
+	}
+
+	return recipients, nil
+}
+
+// getBundleName returns the name to use for a multi-file send, which is the
+// name of the directory if only one was provided
+func getBundleName(paths []string, manifest shared.SendManifest) string {
+	if len(paths) == 1 {
+		return filepath.Base(filepath.Clean(paths[0]))
+	}
+
+	return fmt.Sprintf("%d files", len(manifest.Files))
+}
+
 func createExpString(expValue int, expUnits string) string {
 	return fmt.Sprintf("%d%s", expValue, strings.ToLower(string(expUnits[0])))
 }
@@ -20,8 +20,10 @@ import (
 )
 
 var (
-	fileOption = "File"
-	textOption = "Text"
+	fileOption  = "File"
+	filesOption = "Files"
+	textOption  = "Text"
+	sendsOption = "My Sends"
 )
 
 var (
@@ -30,6 +32,10 @@ var (
 	expirationUnits string
 	password        string
 	setPassword     bool
+	notify          bool
+	textFormat      string
+	codeLanguage    string
+	recipientList   string
 )
 
 var serverError error
@@ -39,9 +45,8 @@ var (
 	expValidationError  = errors.New("input must only contain numeric characters")
 	inputTooLowError    = errors.New("input must be greater >= 1")
 	exceedsMaxDownloads = errors.New("max downloads must be <= 10")
-	exceedsMaxTextLen   = errors.New(fmt.Sprintf(
-		"text exceeds max length (%d)",
-		constants.MaxPlaintextLen))
+	tooManyRecipients   = fmt.Errorf("sends can't have more than %d recipients",
+		constants.MaxSendRecipients)
 )
 
 var expExceedsMaxErr = errors.New(fmt.Sprintf(
@@ -100,6 +105,30 @@ func getSendFields() []huh.Field {
 	}
 }
 
+func getNotifyField() huh.Field {
+	return huh.NewSelect[bool]().Title("Email Notifications (Optional)").
+		Description("If set to 'Yes', you will be emailed when the file is\n" +
+			"downloaded or expires (requires an email on your account).").
+		Options([]huh.Option[bool]{
+			huh.NewOption("No", false),
+			huh.NewOption("Yes", true),
+		}...).Value(&notify)
+}
+
+func getRecipientsField() huh.Field {
+	return huh.NewInput().Title("Recipients (Optional)").
+		Description("Comma-separated emails or account IDs of YeetFile\n" +
+			"users. Only these users will be able to open the send,\n" +
+			"which will appear in their inbox instead of using a link.").
+		Validate(func(s string) error {
+			if len(getRecipients(s)) > constants.MaxSendRecipients {
+				return tooManyRecipients
+			}
+
+			return nil
+		}).Value(&recipientList)
+}
+
 func getPasswordGroup() *huh.Group {
 	return huh.NewGroup(
 		huh.NewInput().Title("Password").
@@ -115,7 +144,7 @@ func getPasswordGroup() *huh.Group {
 				return nil
 			}),
 	).WithHideFunc(func() bool {
-		return !setPassword
+		return !setPassword || len(getRecipients(recipientList)) > 0
 	})
 }
 
@@ -178,7 +207,7 @@ func showSendFileModel(filepath string) {
 	confirm := getConfirmationField(&filepath)
 	fields := getSendFields()
 	fields = append([]huh.Field{title, filepicker}, fields...)
-	fields = append(fields, confirm)
+	fields = append(fields, getNotifyField(), getRecipientsField(), confirm)
 
 	err := huh.NewForm(huh.NewGroup(fields...), getPasswordGroup()).
 		WithTheme(styles.Theme).
@@ -197,8 +226,10 @@ func showSendFileModel(filepath string) {
 			FilePath:     filepath,
 			ExpUnits:     expirationUnits,
 			ExpValue:     expVal,
-			Password:     password,
+			Password:     getSendPassword(),
 			MaxDownloads: maxDownloads,
+			Notify:       notify,
+			Recipients:   getRecipients(recipientList),
 		}, func(chunk int, total int) {
 			percentage := int((float32(chunk) / float32(total)) * 100)
 			msg := fmt.Sprintf("Uploading... (%d%%)", percentage)
@@ -212,34 +243,95 @@ func showSendFileModel(filepath string) {
 		return
 	}
 
-	showLinkModel("File Link", result, secret)
+	showResultModel("File Link", result, secret)
+}
+
+func showSendBundleModel(paths []string) {
+	title := huh.NewNote().Title(utils.GenerateTitle("Send Files"))
+	fileList := huh.NewNote().
+		Title("Files").
+		Description(strings.Join(paths, "\n"))
+	pathsStr := strings.Join(paths, " ")
+	confirm := getConfirmationField(&pathsStr)
+	fields := getSendFields()
+	fields = append([]huh.Field{title, fileList}, fields...)
+	fields = append(fields, getNotifyField(), getRecipientsField(), confirm)
+
+	err := huh.NewForm(huh.NewGroup(fields...), getPasswordGroup()).
+		WithTheme(styles.Theme).
+		WithShowHelp(true).Run()
+	if err != nil {
+		return
+	}
+
+	var result string
+	var secret string
+	progress := spinner.New()
+	_ = progress.Title("Preparing files...").Action(func() {
+		expVal, _ := strconv.Atoi(expiration)
+		maxDownloads, _ := strconv.Atoi(downloads)
+		result, secret, err = createBundleLink(fileUpload{
+			BundlePaths:  paths,
+			ExpUnits:     expirationUnits,
+			ExpValue:     expVal,
+			Password:     getSendPassword(),
+			MaxDownloads: maxDownloads,
+			Notify:       notify,
+			Recipients:   getRecipients(recipientList),
+		}, func(chunk int, total int) {
+			percentage := int((float32(chunk) / float32(total)) * 100)
+			msg := fmt.Sprintf("Uploading... (%d%%)", percentage)
+			progress.Title(msg)
+		})
+	}).Run()
+
+	if err != nil {
+		serverError = err
+		showSendBundleModel(paths)
+		return
+	}
+
+	showResultModel("Files Link", result, secret)
 }
 
 func showSendTextModel(text string) {
+	maxLen := getMaxTextLen()
 	title := huh.NewNote().Title(utils.GenerateTitle("Send Text"))
 	input := huh.NewText().Title("Text").
-		CharLimit(constants.MaxPlaintextLen).
-		Description(fmt.Sprintf("(%d / %d)",
-			len(text), constants.MaxPlaintextLen)).
+		CharLimit(maxLen).
+		Description(fmt.Sprintf("(%d / %d)", len(text), maxLen)).
 		DescriptionFunc(
 			func() string {
-				msg := fmt.Sprintf("(%d / %d)",
-					len(text), constants.MaxPlaintextLen)
+				msg := fmt.Sprintf("(%d / %d)", len(text), maxLen)
 				return msg
 			}, &text).
 		Validate(func(s string) error {
-			if len(s) > constants.MaxPlaintextLen {
-				return exceedsMaxTextLen
+			if len(s) > maxLen {
+				return fmt.Errorf("text exceeds max length (%d)", maxLen)
 			}
 
 			return nil
 		}).Value(&text)
+	format := huh.NewSelect[string]().Title("Format").
+		Options(
+			huh.NewOption("Plain Text", constants.TextFormatPlain),
+			huh.NewOption("Markdown", constants.TextFormatMarkdown),
+			huh.NewOption("Code", constants.TextFormatCode),
+		).Value(&textFormat)
 	confirm := getConfirmationField(&text)
 	fields := getSendFields()
-	fields = append([]huh.Field{title, input}, fields...)
-	fields = append(fields, confirm)
+	fields = append([]huh.Field{title, input, format}, fields...)
+	fields = append(fields, getRecipientsField(), confirm)
 
-	err := huh.NewForm(huh.NewGroup(fields...), getPasswordGroup()).
+	languageGroup := huh.NewGroup(
+		huh.NewInput().Title("Language (Optional)").
+			Placeholder("go, python, js, ...").
+			Value(&codeLanguage),
+	).WithHideFunc(func() bool {
+		return textFormat != constants.TextFormatCode
+	})
+
+	err := huh.NewForm(huh.NewGroup(fields...), languageGroup, getPasswordGroup()).
 		WithTheme(styles.Theme).
 		WithShowHelp(true).Run()
 	if err != nil {
@@ -254,10 +346,16 @@ func showSendTextModel(text string) {
 		maxDownloads, _ := strconv.Atoi(downloads)
 		result, secret, err = createTextLink(textUpload{
 			Text:         text,
+			Format:       getTextFormat(),
 			ExpUnits:     expirationUnits,
 			ExpValue:     expVal,
-			Password:     password,
+			Password:     getSendPassword(),
 			MaxDownloads: maxDownloads,
+			Recipients:   getRecipients(recipientList),
+		}, func(chunk int, total int) {
+			percentage := int((float32(chunk) / float32(total)) * 100)
+			msg := fmt.Sprintf("Uploading... (%d%%)", percentage)
+			progress.Title(msg)
 		})
 	}).Run()
 
@@ -267,7 +365,29 @@ func showSendTextModel(text string) {
 		return
 	}
 
-	showLinkModel("Text Link", result, secret)
+	showResultModel("Text Link", result, secret)
+}
+
+// showResultModel shows the link for a new send, or the list of users that the
+// send was addressed to if recipients were provided
+func showResultModel(title, id, secret string) {
+	recipients := getRecipients(recipientList)
+	if len(recipients) == 0 {
+		showLinkModel(title, id, secret)
+		return
+	}
+
+	_ = huh.NewForm(huh.NewGroup(
+		huh.NewNote().Title(utils.GenerateTitle("Sent")),
+		huh.NewNote().
+			Title("Recipients").
+			Description(strings.Join(recipients, "\n")),
+		huh.NewNote().
+			Title("Note").
+			Description("Recipients can open this send from their "+
+				"inbox using 'yeetfile inbox'."),
+		huh.NewConfirm().Affirmative("OK").Negative(""),
+	)).WithTheme(styles.Theme).Run()
 }
 
 func showLinkModel(title, id, secret string) {
@@ -298,7 +418,10 @@ func ShowSendModel() {
 	var filepath string
 	var text string
 	if len(os.Args) > 2 {
-		if _, err := os.Stat(os.Args[2]); err != nil {
+		if isBundle(os.Args[2:]) {
+			showSendBundleModel(os.Args[2:])
+			return
+		} else if _, err := os.Stat(os.Args[2]); err != nil {
 			text = strings.Join(os.Args[2:], " ")
 		} else {
 			filepath = os.Args[2]
@@ -321,6 +444,7 @@ func ShowSendModel() {
 				[]huh.Option[int]{
 					huh.NewOption(fileOption, 0),
 					huh.NewOption(textOption, 1),
+					huh.NewOption(sendsOption, 2),
 				}...).
 				Value(&option),
 		),
@@ -329,9 +453,78 @@ func ShowSendModel() {
 		return
 	}
 
-	if option == 0 {
+	switch option {
+	case 0:
 		showSendFileModel("")
-	} else {
+	case 1:
 		showSendTextModel("")
+	case 2:
+		ShowSendListModel()
+	}
+}
+
+// isBundle checks if the provided arguments should be sent as a multi-file
+// send, which is the case if every argument is an existing path and there are
+// either multiple paths or the only path is a directory.
+func isBundle(args []string) bool {
+	for _, arg := range args {
+		if _, err := os.Stat(arg); err != nil {
+			return false
+		}
+	}
+
+	if len(args) > 1 {
+		return true
+	}
+
+	stat, err := os.Stat(args[0])
+	return err == nil && stat.IsDir()
+}
+
+// getMaxTextLen returns the max length of a text send for the current server.
+// Servers that don't report a max text size only support single request text
+// uploads.
+func getMaxTextLen() int {
+	if globals.ServerInfo.MaxTextSize > 0 {
+		return int(globals.ServerInfo.MaxTextSize)
+	}
+
+	return constants.MaxPlaintextLen
+}
+
+// getTextFormat returns the format hint for the text being sent, or an empty
+// string for plain text
+func getTextFormat() string {
+	switch textFormat {
+	case constants.TextFormatMarkdown:
+		return textFormat
+	case constants.TextFormatCode:
+		return textFormat + strings.ToLower(strings.TrimSpace(codeLanguage))
+	default:
+		return ""
+	}
+}
+
+// getRecipients splits a comma-separated list of recipients, ignoring any
+// empty entries
+func getRecipients(list string) []string {
+	var recipients []string
+	for _, recipient := range strings.Split(list, ",") {
+		recipient = strings.TrimSpace(recipient)
+		if len(recipient) > 0 {
+			recipients = append(recipients, recipient)
+		}
 	}
+
+	return recipients
+}
+
+// getSendPassword returns the password for the send. Sends addressed to
+// recipients are protected by the recipients' keys instead of a password.
+func getSendPassword() string {
+	if len(getRecipients(recipientList)) > 0 {
+		return ""
+	}
+
+	return password
 }
@@ -0,0 +1,123 @@
+package upload
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/huh/spinner"
+
+	"yeetfile/cli/globals"
+	"yeetfile/cli/styles"
+	"yeetfile/cli/transfer"
+	"yeetfile/cli/utils"
+	"yeetfile/shared"
+)
+
+var uploadErr error
+
+// ShowUploadModel uploads a file using a file request link. The link and file
+// can be provided as arguments, otherwise the user is prompted for them. An
+// account isn't required to upload a file using a request.
+func ShowUploadModel() {
+	var link, path string
+	if len(os.Args) > 3 && uploadErr == nil {
+		link, path = os.Args[2], os.Args[3]
+	} else {
+		if len(os.Args) > 2 {
+			link = os.Args[2]
+		}
+
+		var msg string
+		if uploadErr != nil {
+			msg = styles.ErrStyle.Render(uploadErr.Error())
+		}
+
+		err := huh.NewForm(huh.NewGroup(
+			huh.NewNote().Title(utils.GenerateTitle("Upload")),
+			huh.NewInput().
+				Title("File Request Link").
+				Placeholder("https://yeetfile.com/request/...").
+				Value(&link),
+			huh.NewFilePicker().Title("File").Value(&path),
+			huh.NewConfirm().
+				Description(msg).
+				Affirmative("Upload").
+				Negative(""),
+		)).WithTheme(styles.Theme).Run()
+		if err != nil {
+			return
+		}
+	}
+
+	server, id := parseRequestLink(link)
+
+	var info shared.FileRequestInfo
+	var err error
+	progress := spinner.New()
+	_ = progress.Title("Preparing file...").Action(func() {
+		info, err = globals.API.FetchFileRequestInfo(server, id)
+		if err != nil {
+			return
+		}
+
+		err = uploadFile(server, path, info, func(chunk, total int) {
+			percentage := int((float32(chunk) / float32(total)) * 100)
+			progress.Title(fmt.Sprintf("Uploading... (%d%%)", percentage))
+		})
+	}).Run()
+
+	if err != nil {
+		uploadErr = err
+		ShowUploadModel()
+		return
+	}
+
+	fmt.Printf("Finished uploading %s\n", path)
+}
+
+func uploadFile(
+	server,
+	path string,
+	info shared.FileRequestInfo,
+	progress func(int, int),
+) error {
+	file, stat, err := shared.GetFileInfo(path)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+	if info.MaxSize > 0 && stat.Size() > info.MaxSize {
+		return fmt.Errorf("file exceeds the max size for this request (%s)",
+			shared.ReadableFileSize(info.MaxSize))
+	}
+
+	pending, err := transfer.InitFileRequestFile(file, stat, server, info)
+	if err != nil {
+		return err
+	}
+
+	chunk := 0
+	_, err = pending.UploadData(func() {
+		chunk += 1
+		progress(chunk, pending.NumChunks)
+	})
+
+	return err
+}
+
+// parseRequestLink splits a file request link into the server and request ID.
+// If only the request ID is provided, the configured server is used.
+func parseRequestLink(link string) (string, string) {
+	link = strings.TrimSuffix(strings.TrimSpace(link), "/")
+	if !strings.Contains(link, "/") {
+		return globals.Config.Server, link
+	}
+
+	link = strings.Replace(link, "/request/", "/", 1)
+	segments := strings.Split(link, "/")
+	server := strings.Join(segments[0:len(segments)-1], "/")
+	return server, segments[len(segments)-1]
+}
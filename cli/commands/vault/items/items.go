@@ -3,6 +3,7 @@ package items
 import (
 	"encoding/hex"
 	"encoding/json"
+	"log"
 	"os"
 	"time"
 	"yeetfile/cli/crypto"
@@ -43,6 +44,8 @@ func FetchVaultContext(folderID string, isPassVault bool) (*VaultContext, error)
 		return &VaultContext{}, err
 	}
 
+	folderResp.Items = rewrapPendingKeys(folderResp.Items, cryptCtx)
+
 	ctx := VaultContext{
 		FolderID: folderID,
 		Crypto:   cryptCtx,
@@ -56,6 +59,79 @@ func FetchVaultContext(folderID string, isPassVault bool) (*VaultContext, error)
 	return &ctx, nil
 }
 
+// FetchRootFolders returns the decrypted folders in the root of the user's file
+// vault, unlocking the user's vault keys first if needed.
+func FetchRootFolders() ([]models.VaultItem, error) {
+	if _, err := UnlockKeyPair(); err != nil {
+		return nil, err
+	}
+
+	ctx, err := FetchVaultContext("", false)
+	if err != nil {
+		return nil, err
+	}
+
+	return ctx.parseFolders()
+}
+
+// UnlockKeyPair returns the user's key pair, prompting for their vault password
+// if the private key can't be decrypted using the CLI key
+func UnlockKeyPair() (crypto.KeyPair, error) {
+	if keyPair.PublicKey == nil || keyPair.PrivateKey == nil {
+		var err error
+		keyPair, err = unlockVaultKeys()
+		if err != nil {
+			return crypto.KeyPair{}, err
+		}
+	}
+
+	return keyPair, nil
+}
+
+// rewrapPendingKeys re-encrypts the keys of files uploaded to a subfolder via
+// a file request. The uploader only has the user's public key, so these need
+// to be wrapped with the folder key before they can be decrypted like the rest
+// of the folder's contents. Files that fail to be re-wrapped are left out of
+// the returned list and will be retried the next time the folder is loaded.
+func rewrapPendingKeys(
+	files []shared.VaultItem,
+	cryptCtx crypto.CryptoCtx,
+) []shared.VaultItem {
+	result := []shared.VaultItem{}
+	for _, file := range files {
+		if !file.PendingKey {
+			result = append(result, file)
+			continue
+		}
+
+		key, err := crypto.DecryptWithPrivateKey(keyPair.PrivateKey, file.ProtectedKey)
+		if err != nil {
+			log.Printf("Error decrypting pending item key: %v\n", err)
+			continue
+		}
+
+		protectedKey, err := cryptCtx.EncryptFunc(cryptCtx.EncryptionKey, key)
+		if err != nil {
+			log.Printf("Error encrypting pending item key: %v\n", err)
+			continue
+		}
+
+		err = globals.API.ModifyVaultFile(file.ID, shared.ModifyVaultItem{
+			ProtectedKey: protectedKey,
+		})
+		if err != nil {
+			log.Printf("Error updating pending item key: %v\n", err)
+			continue
+		}
+
+		file.ProtectedKey = protectedKey
+		file.PendingKey = false
+		result = append(result, file)
+	}
+
+	return result
+}
+
 // UploadFile uploads the file contained at the specified path to the user's
 // vault in the current folder. Provides a progress callback to indicate how
 // many chunks from the total have been uploaded. Returns the uploaded file
@@ -1,6 +1,7 @@
 package share
 
 import (
+	"yeetfile/cli/commands/account"
 	"yeetfile/cli/crypto"
 	"yeetfile/cli/globals"
 	"yeetfile/cli/models"
@@ -10,6 +11,8 @@ import (
 const ReadPerm = "Read Only"
 const WritePerm = "Read + Write"
 
+const shareTimeFormat = "02 Jan 2006 15:04 MST"
+
 type Action int
 
 const (
@@ -17,6 +20,7 @@ const (
 	Edit
 	Remove
 	Add
+	Transfer
 )
 
 type Perm int
@@ -62,6 +66,7 @@ func shareItem(
 	decryptKey []byte,
 	recipient string,
 	perm Perm,
+	expiration string,
 ) (shared.ShareInfo, error) {
 	itemKey, err := decryptFunc(decryptKey, item.ProtectedKey)
 	if err != nil {
@@ -77,6 +82,7 @@ func shareItem(
 		User:         recipient,
 		CanModify:    perm == Write,
 		ProtectedKey: userKey,
+		Expiration:   expiration,
 	}
 
 	if item.IsFolder {
@@ -90,15 +96,46 @@ func generateUserProtectedKey(
 	recipient string,
 	key []byte,
 ) ([]byte, error) {
-	pubKeyResponse, err := globals.API.FetchUserPubKey(recipient)
+	publicKey, err := account.FetchTrustedPubKey(recipient)
 	if err != nil {
 		return nil, err
 	}
 
-	userItemKey, err := crypto.EncryptRSA(pubKeyResponse.PublicKey, key)
+	userItemKey, err := crypto.EncryptWithPublicKey(publicKey, key)
 	if err != nil {
 		return nil, err
 	}
 
 	return userItemKey, nil
 }
+
+// transferOwnership starts transferring ownership of the item to another user.
+// The item key is wrapped with the recipient's public key, which is how the
+// keys of items in their home folder are stored.
+func transferOwnership(
+	item models.VaultItem,
+	decryptFunc crypto.CryptFunc,
+	decryptKey []byte,
+	recipient string,
+) (shared.OwnershipTransfer, error) {
+	itemKey, err := decryptFunc(decryptKey, item.ProtectedKey)
+	if err != nil {
+		return shared.OwnershipTransfer{}, err
+	}
+
+	userKey, err := generateUserProtectedKey(recipient, itemKey)
+	if err != nil {
+		return shared.OwnershipTransfer{}, err
+	}
+
+	request := shared.OwnershipTransferRequest{
+		User:         recipient,
+		ProtectedKey: userKey,
+	}
+
+	if item.IsFolder {
+		return globals.API.TransferFolderOwnership(request, item.ID)
+	} else {
+		return globals.API.TransferFileOwnership(request, item.ID)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/charmbracelet/huh"
 	"github.com/charmbracelet/huh/spinner"
 	"strings"
+	"yeetfile/cli/commands/account"
 	"yeetfile/cli/commands/vault/internal"
 	"yeetfile/cli/crypto"
 	"yeetfile/cli/models"
@@ -28,6 +29,7 @@ func (m model) add() (internal.Event, error) {
 	recipient := m.input
 	var confirmed bool
 	var perm Perm
+	var expiration string
 	fields := []huh.Field{
 		huh.NewInput().
 			Title("Share With User").
@@ -41,6 +43,15 @@ func (m model) add() (internal.Event, error) {
 				huh.NewOption(WritePerm, Write),
 			).
 			Title("Permissions"),
+		huh.NewSelect[string]().
+			Value(&expiration).
+			Options(
+				huh.NewOption("Never", ""),
+				huh.NewOption("1 Day", "1d"),
+				huh.NewOption("1 Week", "7d"),
+				huh.NewOption("30 Days", "30d"),
+			).
+			Title("Access Expires"),
 		huh.NewConfirm().
 			Affirmative("Share").
 			Negative("Cancel").
@@ -56,12 +67,22 @@ func (m model) add() (internal.Event, error) {
 
 	m.input = recipient
 	if confirmed {
+		trusted, err := confirmRecipientKey(recipient)
+		if err != nil {
+			m.errMsg = err.Error()
+			return m.add()
+		} else if !trusted {
+			m.errMsg = ""
+			return RunModel(m.item, m.users, m.decryptFunc, m.decryptKey)
+		}
+
 		addedUser, err := shareItem(
 			m.item,
 			m.decryptFunc,
 			m.decryptKey,
 			recipient,
-			perm)
+			perm,
+			expiration)
 		if err != nil {
 			m.errMsg = err.Error()
 			return m.add()
@@ -173,6 +194,79 @@ func (m model) remove() (internal.Event, error) {
 	return RunModel(m.item, m.users, m.decryptFunc, m.decryptKey)
 }
 
+func (m model) transfer() (internal.Event, error) {
+	recipient := m.input
+	var confirmed bool
+	desc := fmt.Sprintf("The recipient will become the owner of '%s' once "+
+		"they accept the transfer, and you'll lose access to it. ",
+		m.item.Name)
+	if m.item.IsFolder {
+		desc += "All subfolders and files are included, and the " +
+			"folder's storage will count towards their account."
+	} else {
+		desc += "The file's storage will count towards their account."
+	}
+
+	fields := []huh.Field{
+		utils.CreateHeader("Transfer Ownership", desc),
+		huh.NewInput().
+			Title("Transfer To User").
+			Description("Enter user's email or account ID below").
+			Placeholder("user@example.com | 1234123412341234").
+			Value(&recipient),
+		huh.NewConfirm().
+			Affirmative("Transfer").
+			Negative("Cancel").
+			Value(&confirmed),
+	}
+
+	if len(m.errMsg) > 0 {
+		fields = append(fields, huh.NewNote().
+			Title(styles.ErrStyle.Render("Error:")).
+			Description(styles.ErrStyle.Render(m.errMsg)))
+	}
+	_ = huh.NewForm(huh.NewGroup(fields...)).WithTheme(styles.Theme).Run()
+
+	m.input = recipient
+	if !confirmed {
+		m.errMsg = ""
+		return RunModel(m.item, m.users, m.decryptFunc, m.decryptKey)
+	}
+
+	trusted, err := confirmRecipientKey(recipient)
+	if err != nil {
+		m.errMsg = err.Error()
+		return m.transfer()
+	} else if !trusted {
+		m.errMsg = ""
+		return RunModel(m.item, m.users, m.decryptFunc, m.decryptKey)
+	}
+
+	var transfer shared.OwnershipTransfer
+	_ = spinner.New().Title("Starting transfer...").
+		Action(func() {
+			transfer, err = transferOwnership(
+				m.item,
+				m.decryptFunc,
+				m.decryptKey,
+				recipient)
+		}).Run()
+	if err != nil {
+		m.errMsg = err.Error()
+		return m.transfer()
+	}
+
+	_ = huh.NewForm(huh.NewGroup(
+		utils.CreateHeader("Transfer Ownership", fmt.Sprintf(
+			"Ownership transfer sent to %s. You can cancel the "+
+				"transfer with 'yeetfile invites' until they "+
+				"accept it.", transfer.UserName)),
+		huh.NewConfirm().Affirmative("OK").Negative(""),
+	)).WithTheme(styles.Theme).Run()
+
+	return m.cancel()
+}
+
 func (m model) cancel() (internal.Event, error) {
 	m.item.SharedWith = len(m.users)
 	return internal.Event{
@@ -182,6 +276,41 @@ func (m model) cancel() (internal.Event, error) {
 	}, nil
 }
 
+// confirmRecipientKey checks the recipient's public key against the key pinned
+// for them. The first time the user shares with a recipient, the fingerprint
+// of their key is shown and has to be confirmed before the key is pinned.
+func confirmRecipientKey(recipient string) (bool, error) {
+	publicKey, pinned, err := account.FetchPubKey(recipient)
+	if err != nil {
+		return false, err
+	} else if pinned {
+		return true, nil
+	}
+
+	desc := fmt.Sprintf("This is the first time you're sharing with "+
+		"%s. Their public key fingerprint is:\n\n%s\n\n",
+		recipient, account.FormatFingerprint(publicKey)) +
+		utils.GenerateWrappedText("To make sure the server hasn't "+
+			"replaced their key, ask them to run 'yeetfile account "+
+			"fingerprint' and check that the result matches.")
+
+	var confirmed bool
+	err = huh.NewForm(huh.NewGroup(
+		huh.NewNote().
+			Title(utils.GenerateTitle("Verify Recipient")).
+			Description(desc),
+		huh.NewConfirm().
+			Affirmative("Trust Key").
+			Negative("Cancel").
+			Value(&confirmed),
+	)).WithTheme(styles.Theme).Run()
+	if err != nil || !confirmed {
+		return false, nil
+	}
+
+	return true, account.PinPubKey(recipient, publicKey)
+}
+
 func generateFormFields(
 	label string,
 	shares []shared.ShareInfo,
@@ -197,6 +326,7 @@ func generateFormFields(
 			Value(action).
 			Options(
 				huh.NewOption("Add User", Add),
+				huh.NewOption("Transfer Ownership", Transfer),
 				huh.NewOption("Return to Vault", Cancel),
 			).
 			Title("Select an action to perform"))
@@ -214,6 +344,15 @@ func generateFormFields(
 				opt = ReadPerm
 			}
 
+			if share.Pending {
+				opt += " | invitation pending"
+			}
+
+			if !share.Expiration.IsZero() {
+				expiration := utils.LocalTimeFromUTC(share.Expiration)
+				opt += " | expires " + expiration.Format(shareTimeFormat)
+			}
+
 			idx := fmt.Sprintf("%d. ", i+1)
 			title := fmt.Sprintf("%s%s", idx, share.Recipient)
 			desc := strings.Repeat(" ", len(idx)) + opt
@@ -228,6 +367,7 @@ func generateFormFields(
 				huh.NewOption("Add User", Add),
 				huh.NewOption("Edit Permissions", Edit),
 				huh.NewOption("Remove Access", Remove),
+				huh.NewOption("Transfer Ownership", Transfer),
 				huh.NewOption("Return to vault", Cancel),
 			).
 			Title("Select an action to perform"))
@@ -282,9 +422,10 @@ func RunModel(
 
 func init() {
 	actionMap = map[Action]func(model) (internal.Event, error){
-		Add:    model.add,
-		Remove: model.remove,
-		Edit:   model.edit,
-		Cancel: model.cancel,
+		Add:      model.add,
+		Remove:   model.remove,
+		Edit:     model.edit,
+		Cancel:   model.cancel,
+		Transfer: model.transfer,
 	}
 }
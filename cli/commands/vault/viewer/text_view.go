@@ -98,11 +98,13 @@ func (m model) footerView() string {
 	return lipgloss.JoinHorizontal(lipgloss.Center, line, info)
 }
 
-func showText(name, modified string, content []byte) {
+// ShowText displays text content in a scrollable view, with the name and
+// info (i.e. the modified date) shown in the header.
+func ShowText(name, info string, content []byte) {
 	p := tea.NewProgram(
 		model{
 			name:     name,
-			modified: modified,
+			modified: info,
 			content:  string(content),
 		},
 		tea.WithAltScreen(),
@@ -114,7 +114,7 @@ func RunViewerModel(
 func showFilePreview(name string, modified time.Time, fileBytes []byte) {
 	var noteContent string
 	if fileBytes != nil && utf8.Valid(fileBytes) {
-		showText(name, modified.Format(time.DateTime), fileBytes)
+		ShowText(name, modified.Format(time.DateTime), fileBytes)
 		return
 	} else if fileBytes != nil && isLikelyImage(name) {
 		cmd, args, err := getImageViewerCommand()
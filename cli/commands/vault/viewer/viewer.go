@@ -126,6 +126,7 @@ func downloadFile(id string, key []byte) ([]byte, error) {
 	}
 
 	var result []byte
+	stream := crypto.OpenChunkStream(key)
 	chunk := 1
 	for chunk <= metadata.Chunks {
 		url := endpoints.DownloadVaultFileData.Format(
@@ -137,7 +138,10 @@ func downloadFile(id string, key []byte) ([]byte, error) {
 			return nil, err
 		}
 
-		decData, err := crypto.DecryptChunk(key, chunkData)
+		decData, err := stream.DecryptChunk(
+			chunkData,
+			chunk-1,
+			chunk == metadata.Chunks)
 		if err != nil {
 			return nil, err
 		}
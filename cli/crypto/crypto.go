@@ -16,6 +16,7 @@ import (
 	"io"
 	"log"
 	"math/big"
+	"strings"
 	"yeetfile/shared"
 	"yeetfile/shared/constants"
 )
@@ -25,9 +26,11 @@ type CryptFunc func([]byte, []byte) ([]byte, error)
 type SignupKeys struct {
 	UserKey                []byte
 	LoginKeyHash           []byte
+	PrivateKey             []byte
 	ProtectedPrivateKey    []byte
 	PublicKey              []byte
 	ProtectedRootFolderKey []byte
+	KDF                    shared.KDFParams
 }
 
 // DeriveSendingKey uses PBKDF2 to derive a key for sending a file. The salt
@@ -100,14 +103,15 @@ func DerivePBKDFKey(password []byte, salt []byte) []byte {
 	return key
 }
 
-// DeriveArgon2Key uses Argon2 to derive a key from a known password and salt.
-// Used for the User Key, and subsequently the Login Key.
-func DeriveArgon2Key(password, salt []byte) []byte {
+// DeriveArgon2Key uses Argon2 to derive a key from a known password and salt,
+// using the provided key derivation params. Used for the User Key, and
+// subsequently the Login Key.
+func DeriveArgon2Key(password, salt []byte, params shared.KDFParams) []byte {
 	key := argon2.IDKey(
 		password,
 		salt,
-		constants.Argon2Iter,
-		constants.Argon2Mem*1024,
+		params.Iterations,
+		params.Memory*1024,
 		1,
 		uint32(constants.KeySize))
 	return key
@@ -116,17 +120,25 @@ func DeriveArgon2Key(password, salt []byte) []byte {
 // GenerateUserKey generates the key used for encrypting and decrypting
 // files that are stored in YeetFile, using their identifier (email or acct ID)
 // and their password.
-func GenerateUserKey(identifier []byte, password []byte) []byte {
+func GenerateUserKey(
+	identifier []byte,
+	password []byte,
+	params shared.KDFParams,
+) []byte {
 	identifierHash := blake2b.Sum256(identifier)
-	return DeriveArgon2Key(password, identifierHash[:16])
+	return DeriveArgon2Key(password, identifierHash[:16], params)
 }
 
 // GenerateLoginKeyHash generates a login key using the user's user key and
 // their password, and returns a hex encoded hash of the resulting key.
-func GenerateLoginKeyHash(userKey []byte, password []byte) []byte {
+func GenerateLoginKeyHash(
+	userKey []byte,
+	password []byte,
+	params shared.KDFParams,
+) []byte {
 	hexUserKey := hex.EncodeToString(userKey)
 	pwHash := blake2b.Sum256(password)
-	loginKey := DeriveArgon2Key([]byte(hexUserKey), pwHash[:16])
+	loginKey := DeriveArgon2Key([]byte(hexUserKey), pwHash[:16], params)
 
 	h := sha256.New()
 	h.Write(loginKey)
@@ -161,6 +173,44 @@ func GenerateRSAKeyPair() ([]byte, []byte, error) {
 	return privateKeyBytes, publicKeyBytes, nil
 }
 
+// KeyFingerprint returns a short, human-readable fingerprint of a user's
+// public key, which can be compared out of band to verify the key belongs to
+// the expected user. The fingerprint is the first 16 bytes of the key's SHA-256
+// hash, formatted as groups of 4 hex characters.
+func KeyFingerprint(publicKey []byte) string {
+	hash := sha256.Sum256(publicKey)
+	hexHash := strings.ToUpper(hex.EncodeToString(hash[:16]))
+
+	var groups []string
+	for i := 0; i < len(hexHash); i += 4 {
+		groups = append(groups, hexHash[i:i+4])
+	}
+
+	return strings.Join(groups, " ")
+}
+
+// KeyFingerprintWords returns the fingerprint of a user's public key as a list
+// of words, which is easier to read aloud and compare than hex. Each word
+// represents one of the first 8 bytes of the key's SHA-256 hash, so the words
+// match the start of the KeyFingerprint value.
+func KeyFingerprintWords(publicKey []byte) string {
+	hash := sha256.Sum256(publicKey)
+
+	var words []string
+	for _, b := range hash[:8] {
+		words = append(words, fingerprintWords[b])
+	}
+
+	return strings.Join(words, " ")
+}
+
+// KeyHash returns the hex encoded SHA-256 hash of a public key, which is used
+// for pinning keys in the config directory
+func KeyHash(publicKey []byte) string {
+	hash := sha256.Sum256(publicKey)
+	return hex.EncodeToString(hash[:])
+}
+
 // EncryptRSA uses a user's public key to encrypt a chunk of data.
 func EncryptRSA(key []byte, data []byte) ([]byte, error) {
 	hash := sha256.New()
@@ -276,18 +326,24 @@ func DecryptString(key []byte, byteStr []byte) (string, error) {
 
 // GenerateUserKeys generates the main user key as well as the login key hash,
 // which is generated from the user key. Returns the user key and login key hash.
-func GenerateUserKeys(identifier, password string) ([]byte, []byte) {
-	userKey := GenerateUserKey([]byte(identifier), []byte(password))
-	loginKeyHash := GenerateLoginKeyHash(userKey, []byte(password))
+func GenerateUserKeys(
+	identifier,
+	password string,
+	params shared.KDFParams,
+) ([]byte, []byte) {
+	userKey := GenerateUserKey([]byte(identifier), []byte(password), params)
+	loginKeyHash := GenerateLoginKeyHash(userKey, []byte(password), params)
 
 	return userKey, loginKeyHash
 }
 
 // GenerateSignupKeys generates the main user key, the login key hash, the
-// private/public key pair, and the encrypted root folder key
+// private/public key pair, and the encrypted root folder key. The user keys are
+// derived using the default key derivation params.
 func GenerateSignupKeys(identifier, password string) (SignupKeys, error) {
-	userKey, loginKeyHash := GenerateUserKeys(identifier, password)
-	privateKey, publicKey, err := GenerateRSAKeyPair()
+	kdf := shared.DefaultKDFParams()
+	userKey, loginKeyHash := GenerateUserKeys(identifier, password, kdf)
+	privateKey, publicKey, err := GenerateKeyPair()
 	if err != nil {
 		return SignupKeys{}, err
 	}
@@ -302,7 +358,7 @@ func GenerateSignupKeys(identifier, password string) (SignupKeys, error) {
 		return SignupKeys{}, err
 	}
 
-	protectedRootFolderKey, err := EncryptRSA(publicKey, rootFolderKey)
+	protectedRootFolderKey, err := EncryptWithPublicKey(publicKey, rootFolderKey)
 	if err != nil {
 		return SignupKeys{}, err
 	}
@@ -310,8 +366,10 @@ func GenerateSignupKeys(identifier, password string) (SignupKeys, error) {
 	return SignupKeys{
 		UserKey:                userKey,
 		LoginKeyHash:           loginKeyHash,
+		PrivateKey:             privateKey,
 		ProtectedPrivateKey:    protectedKey,
 		PublicKey:              publicKey,
 		ProtectedRootFolderKey: protectedRootFolderKey,
+		KDF:                    kdf,
 	}, nil
 }
@@ -2,7 +2,9 @@ package crypto
 
 import (
 	"bytes"
+	"strings"
 	"testing"
+	"yeetfile/shared"
 	"yeetfile/shared/constants"
 )
 
@@ -85,12 +87,13 @@ func TestLoginKey(t *testing.T) {
 	myPassword := []byte("my-password")
 	myEmail := []byte("myemail@domain.com")
 
-	storageKey := GenerateUserKey(myEmail, myPassword)
-	loginKey := GenerateLoginKeyHash(storageKey, myPassword)
+	kdf := shared.DefaultKDFParams()
+	storageKey := GenerateUserKey(myEmail, myPassword, kdf)
+	loginKey := GenerateLoginKeyHash(storageKey, myPassword, kdf)
 
 	// Simulates login at a later time
-	newStorageKey := GenerateUserKey(myEmail, myPassword)
-	newLoginKey := GenerateLoginKeyHash(newStorageKey, myPassword)
+	newStorageKey := GenerateUserKey(myEmail, myPassword, kdf)
+	newLoginKey := GenerateLoginKeyHash(newStorageKey, myPassword, kdf)
 
 	if len(loginKey) != len(newLoginKey) {
 		t.Fatalf("Login key hash lengths do not match")
@@ -101,4 +104,40 @@ func TestLoginKey(t *testing.T) {
 			}
 		}
 	}
+
+	// Keys derived with different params shouldn't match
+	legacyStorageKey := GenerateUserKey(myEmail, myPassword, shared.LegacyKDFParams())
+	if bytes.Equal(storageKey, legacyStorageKey) {
+		t.Fatalf("User keys derived with different params match")
+	}
+}
+
+func TestKeyFingerprint(t *testing.T) {
+	_, publicKey, err := GenerateRSAKeyPair()
+	if err != nil {
+		t.Fatalf("Error generating key pair: %v\n", err)
+	}
+
+	fingerprint := KeyFingerprint(publicKey)
+	if len(fingerprint) != 39 {
+		t.Fatalf("Unexpected fingerprint length: %s\n", fingerprint)
+	}
+
+	if fingerprint != KeyFingerprint(publicKey) {
+		t.Fatalf("Fingerprint should be consistent for the same key")
+	}
+
+	_, otherKey, _ := GenerateRSAKeyPair()
+	if fingerprint == KeyFingerprint(otherKey) {
+		t.Fatalf("Different keys should have different fingerprints")
+	}
+
+	words := strings.Split(KeyFingerprintWords(publicKey), " ")
+	if len(words) != 8 {
+		t.Fatalf("Unexpected number of fingerprint words: %v\n", words)
+	}
+
+	if KeyFingerprintWords(publicKey) == KeyFingerprintWords(otherKey) {
+		t.Fatalf("Different keys should have different word fingerprints")
+	}
 }
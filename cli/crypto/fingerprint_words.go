@@ -0,0 +1,40 @@
+package crypto
+
+// fingerprintWords is a fixed list of 256 short, distinct words used to render
+// key fingerprints and recovery phrases as words (one word per byte). The list
+// is compiled into the CLI rather than fetched from the server, so that a
+// server can't change how a fingerprint reads.
+var fingerprintWords = [256]string{
+	"acorn", "affix", "agent", "ajar", "alike", "alone", "angel", "april",
+	"argue", "aroma", "ashes", "avert", "axis", "baked", "barn", "bath",
+	"blaze", "blink", "blunt", "body", "bonus", "boots", "breed", "bring",
+	"broke", "bud", "bunch", "bush", "cage", "candy", "cargo", "cash",
+	"chaos", "chef", "chili", "chow", "chute", "civil", "clash", "clean",
+	"click", "clock", "coach", "coil", "come", "copy", "couch", "cramp",
+	"crazy", "crisp", "crowd", "cub", "curry", "cut", "dairy", "dart",
+	"deck", "debug", "decor", "dense", "dial", "dime", "dish", "dock",
+	"donut", "dove", "draw", "drive", "drum", "duck", "duty", "earth",
+	"echo", "eel", "elk", "email", "entry", "equal", "event", "fable",
+	"fancy", "fern", "fetch", "film", "five", "flask", "flip", "floss",
+	"foil", "found", "fray", "frost", "froze", "game", "gecko", "gig",
+	"glide", "goal", "goose", "grain", "grass", "greet", "grip", "grub",
+	"gummy", "habit", "harp", "haven", "heave", "hill", "human", "hunt",
+	"icing", "ion", "ivory", "jazz", "job", "joy", "jumbo", "keep",
+	"king", "knot", "lair", "large", "late", "lend", "lid", "limb",
+	"list", "lunar", "lyric", "mango", "maple", "math", "mom", "motor",
+	"mouth", "mule", "music", "mute", "navy", "neon", "niece", "oat",
+	"onion", "opera", "outer", "pace", "panda", "party", "peach", "perky",
+	"petty", "plead", "plus", "pogo", "polka", "poppy", "pout", "prior",
+	"props", "pull", "punk", "push", "quiet", "quote", "radio", "rake",
+	"rank", "react", "relay", "reply", "rich", "ripen", "rival", "rock",
+	"royal", "ruby", "sage", "salsa", "satin", "scale", "scarf", "scoot",
+	"scowl", "seed", "serve", "shady", "share", "shelf", "shirt", "shove",
+	"shrub", "silly", "size", "skies", "slab", "slash", "sleep", "slope",
+	"slot", "small", "smog", "sneak", "snout", "speed", "spill", "spool",
+	"spree", "stack", "stamp", "stash", "stem", "stir", "stood", "stout",
+	"stuck", "stunt", "sushi", "swear", "swim", "sworn", "take", "taper",
+	"tasty", "theme", "thorn", "thump", "tiger", "trace", "trap", "trek",
+	"trio", "trunk", "turf", "tweak", "twirl", "unify", "upper", "utter",
+	"vest", "viral", "vocal", "vowel", "wagon", "watch", "whole", "width",
+	"wind", "wiry", "wolf", "work", "wreck", "yard", "yoyo", "zebra",
+}
@@ -0,0 +1,152 @@
+package crypto
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"errors"
+	"io"
+	"yeetfile/shared/constants"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+var UnsupportedKeyError = errors.New("unsupported public key type")
+
+var x25519Info = []byte("yeetfile x25519")
+
+// GenerateKeyPair generates the key pair used for new accounts and key
+// rotation. Returns the PKCS8 encoded private key and the PKIX encoded public
+// key, the same encodings used by GenerateRSAKeyPair.
+func GenerateKeyPair() ([]byte, []byte, error) {
+	return GenerateX25519KeyPair()
+}
+
+// GenerateX25519KeyPair generates a new X25519 key pair
+func GenerateX25519KeyPair() ([]byte, []byte, error) {
+	privateKey, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	privateKeyBytes, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	publicKeyBytes, err := x509.MarshalPKIXPublicKey(privateKey.PublicKey())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return privateKeyBytes, publicKeyBytes, nil
+}
+
+// EncryptWithPublicKey encrypts data (typically a folder or file key) with a
+// user's public key, using the scheme that matches the type of key the user
+// has (X25519 or the older RSA-OAEP keys).
+func EncryptWithPublicKey(key []byte, data []byte) ([]byte, error) {
+	publicKey, err := x509.ParsePKIXPublicKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	switch publicKey := publicKey.(type) {
+	case *ecdh.PublicKey:
+		return encryptX25519(publicKey, data)
+	case *rsa.PublicKey:
+		return EncryptRSA(key, data)
+	default:
+		return nil, UnsupportedKeyError
+	}
+}
+
+// DecryptWithPrivateKey decrypts data that was encrypted with the public key
+// matching the provided private key
+func DecryptWithPrivateKey(key []byte, data []byte) ([]byte, error) {
+	privateKey, err := x509.ParsePKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	switch privateKey := privateKey.(type) {
+	case *ecdh.PrivateKey:
+		return decryptX25519(privateKey, data)
+	case *rsa.PrivateKey:
+		return DecryptRSA(key, data)
+	default:
+		return nil, UnsupportedKeyError
+	}
+}
+
+// encryptX25519 encrypts data using an ephemeral X25519 key and the recipient's
+// public key. The result is the scheme version, the ephemeral public key, and
+// the data encrypted with AES-GCM using a key derived from the shared secret.
+func encryptX25519(publicKey *ecdh.PublicKey, data []byte) ([]byte, error) {
+	if publicKey.Curve() != ecdh.X25519() {
+		return nil, UnsupportedKeyError
+	}
+
+	ephemeralKey, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	sharedSecret, err := ephemeralKey.ECDH(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	ephemeralPublicKey := ephemeralKey.PublicKey().Bytes()
+	key, err := deriveX25519Key(sharedSecret, ephemeralPublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted, err := EncryptChunk(key, data)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []byte{constants.KeySchemeX25519}
+	result = append(result, ephemeralPublicKey...)
+	return append(result, encrypted...), nil
+}
+
+func decryptX25519(privateKey *ecdh.PrivateKey, data []byte) ([]byte, error) {
+	headerSize := 1 + constants.X25519KeySize
+	if len(data) <= headerSize || data[0] != constants.KeySchemeX25519 {
+		return nil, errors.New("invalid X25519 encrypted data")
+	}
+
+	ephemeralPublicKey, err := ecdh.X25519().NewPublicKey(data[1:headerSize])
+	if err != nil {
+		return nil, err
+	}
+
+	sharedSecret, err := privateKey.ECDH(ephemeralPublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := deriveX25519Key(sharedSecret, ephemeralPublicKey.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	return DecryptChunk(key, data[headerSize:])
+}
+
+// deriveX25519Key derives an AES key from an X25519 shared secret, using the
+// ephemeral public key as the HKDF salt
+func deriveX25519Key(sharedSecret, ephemeralPublicKey []byte) ([]byte, error) {
+	key := make([]byte, constants.KeySize)
+	reader := hkdf.New(sha256.New, sharedSecret, ephemeralPublicKey, x25519Info)
+	if _, err := io.ReadFull(reader, key); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
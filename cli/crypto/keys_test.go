@@ -0,0 +1,55 @@
+package crypto
+
+import (
+	"bytes"
+	"testing"
+	"yeetfile/shared/constants"
+)
+
+func TestPublicKeyEncryption(t *testing.T) {
+	x25519Private, x25519Public, err := GenerateX25519KeyPair()
+	if err != nil {
+		t.Fatalf("Error generating X25519 key pair: %v\n", err)
+	}
+
+	rsaPrivate, rsaPublic, err := GenerateRSAKeyPair()
+	if err != nil {
+		t.Fatalf("Error generating RSA key pair: %v\n", err)
+	}
+
+	keyPairs := []KeyPair{
+		IngestKeys(x25519Private, x25519Public),
+		IngestKeys(rsaPrivate, rsaPublic),
+	}
+
+	for _, kp := range keyPairs {
+		encrypted, err := EncryptWithPublicKey(kp.PublicKey, data)
+		if err != nil {
+			t.Fatalf("Error encrypting with public key: %v\n", err)
+		}
+
+		decrypted, err := DecryptWithPrivateKey(kp.PrivateKey, encrypted)
+		if err != nil {
+			t.Fatalf("Error decrypting with private key: %v\n", err)
+		} else if !bytes.Equal(decrypted, data) {
+			t.Fatalf("Decrypted data doesn't match source data")
+		}
+	}
+
+	encrypted, _ := EncryptWithPublicKey(x25519Public, data)
+	if encrypted[0] != constants.KeySchemeX25519 {
+		t.Fatalf("X25519 encrypted data is missing the scheme version")
+	}
+
+	// Data should only decrypt with the matching private key
+	otherPrivate, _, _ := GenerateX25519KeyPair()
+	_, err = DecryptWithPrivateKey(otherPrivate, encrypted)
+	if err == nil {
+		t.Fatalf("Data shouldn't decrypt with another user's private key")
+	}
+
+	_, err = DecryptWithPrivateKey(rsaPrivate, encrypted)
+	if err == nil {
+		t.Fatalf("X25519 data shouldn't decrypt with an RSA private key")
+	}
+}
@@ -0,0 +1,96 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"strings"
+	"yeetfile/shared/constants"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// RecoveryPhraseLength is the number of words in a recovery phrase. Each word
+// encodes one byte, so a phrase contains 128 bits of entropy.
+const RecoveryPhraseLength = 16
+
+var InvalidRecoveryPhraseError = errors.New("invalid recovery phrase")
+
+var (
+	recoveryKeyInfo  = []byte("yeetfile recovery key")
+	recoveryHashInfo = []byte("yeetfile recovery hash")
+)
+
+// GenerateRecoveryPhrase generates a random recovery phrase that can be used
+// to recover an account if the user forgets their password
+func GenerateRecoveryPhrase() (string, error) {
+	entropy := make([]byte, RecoveryPhraseLength)
+	if _, err := rand.Read(entropy); err != nil {
+		return "", err
+	}
+
+	var words []string
+	for _, b := range entropy {
+		words = append(words, fingerprintWords[b])
+	}
+
+	return strings.Join(words, " "), nil
+}
+
+// GenerateRecoveryKeys derives the recovery key and recovery key hash from a
+// recovery phrase. The recovery key encrypts a copy of the user's private key,
+// and the hash is sent to the server to authorize an account recovery. Since
+// the phrase is random, the keys are derived with HKDF instead of Argon2.
+func GenerateRecoveryKeys(phrase string) ([]byte, []byte, error) {
+	entropy, err := parseRecoveryPhrase(phrase)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	recoveryKey, err := deriveRecoveryKey(entropy, recoveryKeyInfo)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	recoveryKeyHash, err := deriveRecoveryKey(entropy, recoveryHashInfo)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return recoveryKey, recoveryKeyHash, nil
+}
+
+func parseRecoveryPhrase(phrase string) ([]byte, error) {
+	words := strings.Fields(strings.ToLower(phrase))
+	if len(words) != RecoveryPhraseLength {
+		return nil, InvalidRecoveryPhraseError
+	}
+
+	indexes := make(map[string]byte, len(fingerprintWords))
+	for i, word := range fingerprintWords {
+		indexes[word] = byte(i)
+	}
+
+	var entropy []byte
+	for _, word := range words {
+		b, ok := indexes[word]
+		if !ok {
+			return nil, InvalidRecoveryPhraseError
+		}
+
+		entropy = append(entropy, b)
+	}
+
+	return entropy, nil
+}
+
+func deriveRecoveryKey(entropy, info []byte) ([]byte, error) {
+	key := make([]byte, constants.KeySize)
+	reader := hkdf.New(sha256.New, entropy, nil, info)
+	if _, err := io.ReadFull(reader, key); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
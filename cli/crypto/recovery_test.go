@@ -0,0 +1,50 @@
+package crypto
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRecoveryKeys(t *testing.T) {
+	phrase, err := GenerateRecoveryPhrase()
+	if err != nil {
+		t.Fatalf("Error generating recovery phrase: %v\n", err)
+	} else if len(strings.Fields(phrase)) != RecoveryPhraseLength {
+		t.Fatalf("Recovery phrase has the wrong number of words: %s", phrase)
+	}
+
+	recoveryKey, recoveryKeyHash, err := GenerateRecoveryKeys(phrase)
+	if err != nil {
+		t.Fatalf("Error generating recovery keys: %v\n", err)
+	} else if bytes.Equal(recoveryKey, recoveryKeyHash) {
+		t.Fatalf("Recovery key and recovery key hash should be different")
+	}
+
+	// Phrases should be parsed regardless of case and spacing
+	messyPhrase := "  " + strings.ToUpper(strings.ReplaceAll(phrase, " ", "\n  "))
+	key, hash, err := GenerateRecoveryKeys(messyPhrase)
+	if err != nil {
+		t.Fatalf("Error generating recovery keys: %v\n", err)
+	} else if !bytes.Equal(key, recoveryKey) || !bytes.Equal(hash, recoveryKeyHash) {
+		t.Fatalf("Recovery keys should match for the same phrase")
+	}
+
+	encrypted, _ := EncryptChunk(recoveryKey, data)
+	decrypted, err := DecryptChunk(key, encrypted)
+	if err != nil || !bytes.Equal(decrypted, data) {
+		t.Fatalf("Data should decrypt with the recovery key")
+	}
+
+	words := strings.Fields(phrase)
+	for _, invalid := range []string{
+		strings.Join(words[1:], " "),
+		strings.Join(append(words[1:], "notaword"), " "),
+		"",
+	} {
+		_, _, err = GenerateRecoveryKeys(invalid)
+		if err != InvalidRecoveryPhraseError {
+			t.Fatalf("Expected invalid phrase error for %q", invalid)
+		}
+	}
+}
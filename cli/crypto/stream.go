@@ -0,0 +1,144 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"sync"
+	"yeetfile/shared/constants"
+)
+
+var MixedChunkStreamError = errors.New("chunk doesn't belong to this file")
+
+// ChunkStream encrypts and decrypts the chunks of a single file's contents.
+//
+// Version 2 chunks use a nonce made up of the format version, a random stream
+// ID shared by every chunk of the file, and the chunk's index. The nonce and a
+// flag indicating if the chunk is the last one in the file are authenticated as
+// additional data, so a chunk that has been reordered, duplicated, moved from
+// another file, or that has had the chunks after it dropped will fail to
+// decrypt. Version 1 chunks (a random IV and no additional data) can still be
+// decrypted, but every chunk in a file must use the same version.
+type ChunkStream struct {
+	key     []byte
+	id      []byte
+	version int
+	mu      *sync.Mutex
+}
+
+// NewChunkStream creates a ChunkStream with a new random stream ID, for
+// encrypting a file's contents
+func NewChunkStream(key []byte) (*ChunkStream, error) {
+	id := make([]byte, constants.StreamIDSize)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	return &ChunkStream{
+		key:     key,
+		id:      id,
+		version: constants.ChunkFormatV2,
+		mu:      &sync.Mutex{},
+	}, nil
+}
+
+// OpenChunkStream creates a ChunkStream for decrypting a file's contents. The
+// stream ID and format version are set by the first chunk that is decrypted.
+func OpenChunkStream(key []byte) *ChunkStream {
+	return &ChunkStream{key: key, mu: &sync.Mutex{}}
+}
+
+// EncryptChunk encrypts a chunk of file data in the v2 format. The index is the
+// 0-based position of the chunk in the file.
+func (s *ChunkStream) EncryptChunk(data []byte, index int, final bool) ([]byte, error) {
+	nonce := make([]byte, constants.IVSize)
+	nonce[0] = constants.ChunkFormatV2
+	copy(nonce[1:], s.id)
+	binary.BigEndian.PutUint32(nonce[1+constants.StreamIDSize:], uint32(index))
+
+	aesgcm, err := newGCM(s.key)
+	if err != nil {
+		return nil, err
+	}
+
+	result := aesgcm.Seal(nil, nonce, data, chunkAAD(nonce, final))
+	return append(nonce, result...), nil
+}
+
+// DecryptChunk decrypts a chunk of file data, which is expected to be at the
+// provided index in the file. Chunks that weren't encrypted in the v2 format
+// are decrypted using DecryptChunk.
+func (s *ChunkStream) DecryptChunk(chunk []byte, index int, final bool) ([]byte, error) {
+	plaintext, err := decryptChunkV2(s.key, chunk, index, final)
+	version := constants.ChunkFormatV2
+	if err != nil {
+		// Legacy chunks have a random IV, so the first byte may match
+		// the v2 format version by chance
+		plaintext, err = DecryptChunk(s.key, chunk)
+		if err != nil {
+			return nil, err
+		}
+
+		version = 1
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var id []byte
+	if version == constants.ChunkFormatV2 {
+		id = bytes.Clone(chunk[1 : 1+constants.StreamIDSize])
+	}
+
+	if s.version == 0 {
+		s.version = version
+		s.id = id
+	} else if s.version != version || !bytes.Equal(s.id, id) {
+		return nil, MixedChunkStreamError
+	}
+
+	return plaintext, nil
+}
+
+func decryptChunkV2(key, chunk []byte, index int, final bool) ([]byte, error) {
+	if len(chunk) <= constants.IVSize || chunk[0] != constants.ChunkFormatV2 {
+		return nil, errors.New("not a v2 chunk")
+	}
+
+	nonce := chunk[:constants.IVSize]
+	chunkIndex := binary.BigEndian.Uint32(nonce[1+constants.StreamIDSize:])
+	if chunkIndex != uint32(index) {
+		return nil, errors.New("unexpected chunk index")
+	}
+
+	aesgcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return aesgcm.Open(nil, nonce, chunk[constants.IVSize:], chunkAAD(nonce, final))
+}
+
+// chunkAAD returns the additional data for a v2 chunk, which is the chunk's
+// nonce followed by the final chunk flag
+func chunkAAD(nonce []byte, final bool) []byte {
+	aad := make([]byte, len(nonce)+1)
+	copy(aad, nonce)
+	if final {
+		aad[len(nonce)] = 1
+	}
+
+	return aad
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,77 @@
+package crypto
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestChunkStream(t *testing.T) {
+	key, _ := GenerateRandomKey()
+	stream, err := NewChunkStream(key)
+	if err != nil {
+		t.Fatalf("Error creating chunk stream: %v\n", err)
+	}
+
+	chunks := [][]byte{[]byte("first"), []byte("second"), []byte("third")}
+	var encrypted [][]byte
+	for i, chunk := range chunks {
+		encChunk, err := stream.EncryptChunk(chunk, i, i == len(chunks)-1)
+		if err != nil {
+			t.Fatalf("Error encrypting chunk: %v\n", err)
+		}
+
+		encrypted = append(encrypted, encChunk)
+	}
+
+	decStream := OpenChunkStream(key)
+	for i, encChunk := range encrypted {
+		decrypted, err := decStream.DecryptChunk(encChunk, i, i == len(chunks)-1)
+		if err != nil {
+			t.Fatalf("Error decrypting chunk %d: %v\n", i, err)
+		} else if !bytes.Equal(decrypted, chunks[i]) {
+			t.Fatalf("Decrypted chunk doesn't match original chunk")
+		}
+	}
+
+	// Reordered chunks should fail to decrypt
+	_, err = OpenChunkStream(key).DecryptChunk(encrypted[1], 0, false)
+	if err == nil {
+		t.Fatalf("Chunk should fail to decrypt at the wrong index")
+	}
+
+	// Truncated files should fail to decrypt, since the new final chunk
+	// wasn't encrypted as the final chunk
+	_, err = OpenChunkStream(key).DecryptChunk(encrypted[1], 1, true)
+	if err == nil {
+		t.Fatalf("Non-final chunk should fail to decrypt as the final chunk")
+	}
+
+	// Chunks from another file encrypted with the same key should fail
+	otherStream, _ := NewChunkStream(key)
+	otherChunk, _ := otherStream.EncryptChunk(chunks[1], 1, false)
+	_, err = decStream.DecryptChunk(otherChunk, 1, false)
+	if err != MixedChunkStreamError {
+		t.Fatalf("Chunk from another file should fail to decrypt")
+	}
+}
+
+func TestChunkStreamLegacy(t *testing.T) {
+	key, _ := GenerateRandomKey()
+	legacyChunk, _ := EncryptChunk(key, data)
+
+	stream := OpenChunkStream(key)
+	decrypted, err := stream.DecryptChunk(legacyChunk, 0, true)
+	if err != nil {
+		t.Fatalf("Error decrypting legacy chunk: %v\n", err)
+	} else if !bytes.Equal(decrypted, data) {
+		t.Fatalf("Decrypted chunk doesn't match original chunk")
+	}
+
+	// v1 and v2 chunks can't be mixed in the same file
+	v2Stream, _ := NewChunkStream(key)
+	v2Chunk, _ := v2Stream.EncryptChunk(data, 1, true)
+	_, err = stream.DecryptChunk(v2Chunk, 1, true)
+	if err != MixedChunkStreamError {
+		t.Fatalf("Mixed chunk versions should fail to decrypt")
+	}
+}
@@ -60,8 +60,8 @@ func (kp KeyPair) DeriveVaultCryptoContext(keySequence [][]byte) (CryptoCtx, err
 	} else {
 		decryptedFolderKey = kp.PrivateKey
 		encryptKey = kp.PublicKey
-		decryptFunc = DecryptRSA
-		encryptFunc = EncryptRSA
+		decryptFunc = DecryptWithPrivateKey
+		encryptFunc = EncryptWithPublicKey
 	}
 
 	return CryptoCtx{
@@ -80,7 +80,7 @@ func (kp KeyPair) UnwindKeySequence(keySequence [][]byte) ([]byte, error) {
 	var err error
 	for _, key := range keySequence {
 		if parentKey == nil {
-			parentKey, err = DecryptRSA(kp.PrivateKey, key)
+			parentKey, err = DecryptWithPrivateKey(kp.PrivateKey, key)
 			if err != nil {
 				log.Println("Error decrypting root folder key")
 				return nil, err
@@ -18,6 +18,10 @@ func PutRequest(session, url string, data []byte) (*http.Response, error) {
 	return sendRequest(session, http.MethodPut, url, data)
 }
 
+func PatchRequest(session, url string, data []byte) (*http.Response, error) {
+	return sendRequest(session, http.MethodPatch, url, data)
+}
+
 func DeleteRequest(session, url string, data []byte) (*http.Response, error) {
 	return sendRequest(session, http.MethodDelete, url, data)
 }
@@ -0,0 +1,231 @@
+package transfer
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"yeetfile/cli/crypto"
+	"yeetfile/cli/globals"
+	"yeetfile/shared"
+	"yeetfile/shared/constants"
+)
+
+var EmptyBundleError = errors.New("no file content to send")
+var InvalidBundlePathError = errors.New("invalid file path in send manifest")
+
+type bundleFile struct {
+	file   *os.File
+	offset int64
+	size   int64
+}
+
+// BundleReader reads the contents of multiple files as a single stream, which
+// is used for uploading multi-file sends. Files are read in the same order as
+// they're listed in the send manifest.
+type BundleReader struct {
+	files []bundleFile
+	size  int64
+}
+
+// NewBundleReader opens each of the provided files and directories (including
+// all files within directories) and returns a reader for their combined
+// contents, along with the manifest describing where each file is located
+// within the stream. Paths in the manifest are relative to the parent of each
+// provided path.
+func NewBundleReader(paths []string) (*BundleReader, shared.SendManifest, error) {
+	reader := &BundleReader{}
+	manifest := shared.SendManifest{}
+
+	addFile := func(diskPath, manifestPath string) error {
+		file, stat, err := shared.GetFileInfo(diskPath)
+		if err != nil {
+			return err
+		}
+
+		reader.files = append(reader.files, bundleFile{
+			file:   file,
+			offset: reader.size,
+			size:   stat.Size(),
+		})
+
+		manifest.Files = append(manifest.Files, shared.SendManifestFile{
+			Path:   manifestPath,
+			Offset: reader.size,
+			Size:   stat.Size(),
+		})
+
+		reader.size += stat.Size()
+		return nil
+	}
+
+	for _, p := range paths {
+		p = filepath.Clean(p)
+		root := filepath.Dir(p)
+		err := filepath.WalkDir(p, func(walkPath string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			} else if !d.Type().IsRegular() {
+				return nil
+			}
+
+			relPath, err := filepath.Rel(root, walkPath)
+			if err != nil {
+				return err
+			}
+
+			return addFile(walkPath, filepath.ToSlash(relPath))
+		})
+
+		if err != nil {
+			reader.Close()
+			return nil, shared.SendManifest{}, err
+		}
+	}
+
+	if reader.size == 0 {
+		reader.Close()
+		return nil, shared.SendManifest{}, EmptyBundleError
+	}
+
+	return reader, manifest, nil
+}
+
+// Size returns the combined size of all files in the bundle
+func (b *BundleReader) Size() int64 {
+	return b.size
+}
+
+// ReadAt reads len(p) bytes from the combined file stream starting at the
+// provided offset, reading across file boundaries as needed.
+func (b *BundleReader) ReadAt(p []byte, off int64) (int, error) {
+	n := 0
+	for _, f := range b.files {
+		if n == len(p) {
+			break
+		} else if off+int64(n) >= f.offset+f.size {
+			continue
+		}
+
+		fileOffset := off + int64(n) - f.offset
+		toRead := min(int64(len(p)-n), f.size-fileOffset)
+		read, err := f.file.ReadAt(p[n:n+int(toRead)], fileOffset)
+		n += read
+		if err != nil && !(errors.Is(err, io.EOF) && int64(read) == toRead) {
+			return n, err
+		}
+	}
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+
+	return n, nil
+}
+
+// Close closes all files in the bundle
+func (b *BundleReader) Close() {
+	for _, f := range b.files {
+		_ = f.file.Close()
+	}
+}
+
+// SanitizeBundlePath validates a path from a decrypted send manifest before
+// it's used for writing a file, and returns the path in the local format.
+// Absolute paths and paths that would be written outside the destination are
+// rejected.
+func SanitizeBundlePath(manifestPath string) (string, error) {
+	cleaned := path.Clean(strings.ReplaceAll(manifestPath, "\\", "/"))
+	if cleaned == "." ||
+		path.IsAbs(cleaned) ||
+		cleaned == ".." ||
+		strings.HasPrefix(cleaned, "../") ||
+		filepath.VolumeName(cleaned) != "" {
+		return "", InvalidBundlePathError
+	}
+
+	return filepath.FromSlash(cleaned), nil
+}
+
+// DownloadBundleFiles downloads the chunks containing the selected files from
+// a multi-file send, and writes each file's contents to the writer returned by
+// open. Files are written in the order they appear in the combined stream.
+func (p PendingDownload) DownloadBundleFiles(
+	files []shared.SendManifestFile,
+	open func(file shared.SendManifestFile) (io.WriteCloser, error),
+	progress func(),
+) error {
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Offset < files[j].Offset
+	})
+
+	currentChunk := -1
+	var chunkData []byte
+	stream := crypto.OpenChunkStream(p.Key)
+	fetchStreamChunk := func(chunk int) error {
+		if chunk == currentChunk {
+			return nil
+		}
+
+		url := p.UnformattedEndpoint.Format(p.Server, p.ID, strconv.Itoa(chunk+1))
+		body, err := globals.API.DownloadFileChunk(url)
+		if err != nil {
+			return err
+		}
+
+		chunkData, err = stream.DecryptChunk(body, chunk, chunk == p.NumChunks-1)
+		if err != nil {
+			return err
+		}
+
+		currentChunk = chunk
+		progress()
+		return nil
+	}
+
+	for _, file := range files {
+		writer, err := open(file)
+		if err != nil {
+			return err
+		}
+
+		pos := file.Offset
+		end := file.Offset + file.Size
+		for pos < end {
+			chunk := int(pos / constants.ChunkSize)
+			if err = fetchStreamChunk(chunk); err != nil {
+				_ = writer.Close()
+				return err
+			}
+
+			chunkStart := int64(chunk) * constants.ChunkSize
+			start := pos - chunkStart
+			stop := min(end-chunkStart, int64(len(chunkData)))
+			if start >= stop {
+				_ = writer.Close()
+				return io.ErrUnexpectedEOF
+			}
+
+			if _, err = writer.Write(chunkData[start:stop]); err != nil {
+				_ = writer.Close()
+				return err
+			}
+
+			pos = chunkStart + stop
+		}
+
+		if err = writer.Close(); err != nil {
+			return err
+		}
+	}
+
+	// The download counter for a send is only decremented once the final
+	// chunk is downloaded, so it's always fetched (even when it doesn't
+	// contain any of the selected files) to count this as a download.
+	return fetchStreamChunk(p.NumChunks - 1)
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"strconv"
 	"sync"
+	"yeetfile/cli/api"
 	"yeetfile/cli/crypto"
 	"yeetfile/cli/globals"
 	"yeetfile/shared/constants"
@@ -19,12 +20,14 @@ type PendingDownload struct {
 	NumChunks           int
 	UnformattedEndpoint endpoints.Endpoint
 	Server              string
+	GrantID             string
 }
 
 type DownloadChunk struct {
 	File     *os.File
 	ChunkNum int
-	Key      []byte
+	Final    bool
+	Stream   *crypto.ChunkStream
 	Endpoint string
 }
 
@@ -62,7 +65,7 @@ func fetchChunk(chunk DownloadChunk) ([]byte, error) {
 		return nil, err
 	}
 
-	decryptedData, err := crypto.DecryptChunk(chunk.Key, body)
+	decryptedData, err := chunk.Stream.DecryptChunk(body, chunk.ChunkNum, chunk.Final)
 	if err != nil {
 		return nil, err
 	}
@@ -123,12 +126,32 @@ func InitVaultDownload(
 	return p, nil
 }
 
+// InitEmergencyVaultDownload is like InitVaultDownload, but downloads a file
+// from the vault of a user who granted the current user emergency access
+func InitEmergencyVaultDownload(
+	grantID,
+	id string,
+	key []byte,
+	file *os.File,
+) (PendingDownload, error) {
+	metadata, err := globals.API.GetEmergencyItemMetadata(grantID, id)
+	if err != nil {
+		return PendingDownload{}, err
+	}
+
+	p := initDownload(metadata.ID, globals.Config.Server, key, file, metadata.Chunks)
+	p.UnformattedEndpoint = endpoints.DownloadVaultFileData
+	p.GrantID = grantID
+	return p, nil
+}
+
 func (p PendingDownload) DownloadData(progress func()) error {
 	var wg sync.WaitGroup
 	ctx, cancel := context.WithCancel(context.Background())
 	wCtx := WorkerCtx{ctx: ctx, cancel: cancel}
 	defer cancel()
 
+	stream := crypto.OpenChunkStream(p.Key)
 	jobs := make(chan DownloadChunk, constants.MaxTransferThreads)
 	for i := 1; i <= constants.MaxTransferThreads; i++ {
 		wg.Add(1)
@@ -138,12 +161,11 @@ func (p PendingDownload) DownloadData(progress func()) error {
 	// Download all but the final file chunk using the workers
 	for chunk := 0; chunk < p.NumChunks-1; chunk++ {
 		chunkNum := strconv.Itoa(chunk + 1)
-		url := p.UnformattedEndpoint.Format(p.Server, p.ID, chunkNum)
 		fileChunk := DownloadChunk{
 			File:     p.File,
 			ChunkNum: chunk,
-			Key:      p.Key,
-			Endpoint: url,
+			Stream:   stream,
+			Endpoint: p.chunkURL(chunkNum),
 		}
 		jobs <- fileChunk
 	}
@@ -159,8 +181,9 @@ func (p PendingDownload) DownloadData(progress func()) error {
 	finalChunk := DownloadChunk{
 		File:     p.File,
 		ChunkNum: p.NumChunks - 1,
-		Key:      p.Key,
-		Endpoint: p.UnformattedEndpoint.Format(p.Server, p.ID, strconv.Itoa(p.NumChunks)),
+		Final:    true,
+		Stream:   stream,
+		Endpoint: p.chunkURL(strconv.Itoa(p.NumChunks)),
 	}
 	data, err := fetchChunk(finalChunk)
 	if err != nil {
@@ -175,13 +198,34 @@ func (p PendingDownload) DownloadData(progress func()) error {
 	return nil
 }
 
-func DownloadText(id, server string, key []byte) ([]byte, error) {
-	url := endpoints.DownloadSendFileData.Format(server, id, "1")
-	body, err := globals.API.DownloadFileChunk(url)
-	decryptedData, err := crypto.DecryptChunk(key, body)
-	if err != nil {
-		return nil, err
+func (p PendingDownload) chunkURL(chunkNum string) string {
+	url := p.UnformattedEndpoint.Format(p.Server, p.ID, chunkNum)
+	if len(p.GrantID) > 0 {
+		return api.EmergencyURL(url, p.GrantID)
 	}
 
-	return decryptedData, nil
+	return url
+}
+
+// DownloadText downloads and decrypts a text send. Larger text sends are
+// uploaded in multiple chunks, which are downloaded in order.
+func DownloadText(id, server string, key []byte, chunks int) ([]byte, error) {
+	var text []byte
+	stream := crypto.OpenChunkStream(key)
+	for chunk := 1; chunk <= max(chunks, 1); chunk++ {
+		url := endpoints.DownloadSendFileData.Format(server, id, strconv.Itoa(chunk))
+		body, err := globals.API.DownloadFileChunk(url)
+		if err != nil {
+			return nil, err
+		}
+
+		decryptedData, err := stream.DecryptChunk(body, chunk-1, chunk >= chunks)
+		if err != nil {
+			return nil, err
+		}
+
+		text = append(text, decryptedData...)
+	}
+
+	return text, nil
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/hex"
 	"errors"
+	"io"
 	"log"
 	"os"
 	"strconv"
@@ -18,9 +19,12 @@ import (
 type PendingUpload struct {
 	ID                  string
 	Key                 []byte
-	File                *os.File
+	File                io.ReaderAt
+	Size                int64
 	NumChunks           int
 	UnformattedEndpoint endpoints.Endpoint
+	Server              string
+	Query               string
 }
 
 type FileChunk struct {
@@ -114,14 +118,65 @@ func InitVaultFile(
 		ID:                  metaResponse.ID,
 		Key:                 key,
 		File:                file,
+		Size:                size,
 		NumChunks:           numChunks,
 		UnformattedEndpoint: endpoints.UploadVaultFileData,
 	}, nil
 }
 
-// InitSendFile initializes a file's metadata for sending.
-func InitSendFile(
+// InitFileRequestFile initializes a file upload using a file request, which
+// uploads the file into the request owner's vault. The file key is encrypted
+// with the owner's public key.
+func InitFileRequestFile(
 	file *os.File,
+	stat os.FileInfo,
+	server string,
+	info shared.FileRequestInfo,
+) (PendingUpload, error) {
+	key, err := crypto.GenerateRandomKey()
+	if err != nil {
+		return PendingUpload{}, err
+	}
+
+	protectedKey, err := crypto.EncryptWithPublicKey(info.PublicKey, key)
+	if err != nil {
+		return PendingUpload{}, err
+	}
+
+	encName, err := crypto.EncryptChunk(key, []byte(stat.Name()))
+	if err != nil {
+		return PendingUpload{}, err
+	}
+
+	numChunks := GetNumChunks(stat.Size())
+	upload := shared.VaultUpload{
+		Name:         hex.EncodeToString(encName),
+		Length:       stat.Size(),
+		Chunks:       numChunks,
+		ProtectedKey: protectedKey,
+	}
+
+	metaResponse, err := globals.API.InitFileRequestUpload(server, info.ID, upload)
+	if err != nil {
+		return PendingUpload{}, err
+	}
+
+	return PendingUpload{
+		ID:                  metaResponse.ID,
+		Key:                 key,
+		File:                file,
+		Size:                stat.Size(),
+		NumChunks:           numChunks,
+		UnformattedEndpoint: endpoints.UploadVaultFileData,
+		Server:              server,
+		Query:               "?request=" + info.ID,
+	}, nil
+}
+
+// InitSendFile initializes a file's metadata for sending. For multi-file sends,
+// the file is a BundleReader containing the contents of all files.
+func InitSendFile(
+	file io.ReaderAt,
 	meta shared.UploadMetadata,
 	key []byte,
 ) (PendingUpload, error) {
@@ -134,6 +189,7 @@ func InitSendFile(
 		ID:                  metaResponse.ID,
 		Key:                 key,
 		File:                file,
+		Size:                meta.Size,
 		NumChunks:           meta.Chunks,
 		UnformattedEndpoint: endpoints.UploadSendFileData,
 	}, nil
@@ -146,7 +202,11 @@ func (p PendingUpload) UploadData(progress func()) (string, error) {
 	var fileChunk FileChunk
 	var prepErr error
 
-	stat, _ := p.File.Stat()
+	stream, err := crypto.NewChunkStream(p.Key)
+	if err != nil {
+		return "", err
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	wCtx := WorkerCtx{ctx: ctx, cancel: cancel}
 	defer cancel()
@@ -160,7 +220,7 @@ func (p PendingUpload) UploadData(progress func()) (string, error) {
 	// Send all but the final file chunk to the workers. The final chunk
 	// will indicate if Backblaze has accepted all file contents.
 	for chunk := 0; chunk < p.NumChunks-1; chunk++ {
-		fileChunk, prepErr = p.prepareChunk(chunk, stat.Size())
+		fileChunk, prepErr = p.prepareChunk(stream, chunk)
 		if prepErr != nil {
 			cancel()
 			break
@@ -177,7 +237,7 @@ func (p PendingUpload) UploadData(progress func()) (string, error) {
 	}
 
 	// Prepare final chunk
-	fileChunk, prepErr = p.prepareChunk(p.NumChunks-1, stat.Size())
+	fileChunk, prepErr = p.prepareChunk(stream, p.NumChunks-1)
 	if prepErr != nil {
 		return "", prepErr
 	}
@@ -194,20 +254,32 @@ func (p PendingUpload) UploadData(progress func()) (string, error) {
 // prepareChunk reads a chunk of a file and encrypts it, returning a FileChunk
 // struct containing the encrypted data, the chunk number, and the endpoint
 // to send the chunk to.
-func (p PendingUpload) prepareChunk(chunk int, size int64) (FileChunk, error) {
+func (p PendingUpload) prepareChunk(
+	stream *crypto.ChunkStream,
+	chunk int,
+) (FileChunk, error) {
+	server := p.Server
+	if len(server) == 0 {
+		server = globals.Config.Server
+	}
+
 	endpoint := p.UnformattedEndpoint.Format(
-		globals.Config.Server,
+		server,
 		p.ID,
-		strconv.Itoa(chunk+1))
+		strconv.Itoa(chunk+1)) + p.Query
 
-	start, end := GetReadBounds(chunk, size)
+	start, end := GetReadBounds(chunk, p.Size)
 	contents := make([]byte, end-start)
 	_, err := p.File.ReadAt(contents, start)
 	if err != nil {
 		return FileChunk{}, err
 	}
 
-	encData, _ := crypto.EncryptChunk(p.Key, contents)
+	encData, err := stream.EncryptChunk(contents, chunk, chunk == p.NumChunks-1)
+	if err != nil {
+		return FileChunk{}, err
+	}
+
 	return FileChunk{
 		Chunk:         chunk,
 		Endpoint:      endpoint,
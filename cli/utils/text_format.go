@@ -0,0 +1,167 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"yeetfile/cli/styles"
+	"yeetfile/shared/constants"
+)
+
+var (
+	headingStyle    = lipgloss.NewStyle().Bold(true).Foreground(styles.AccentLight)
+	boldTextStyle   = lipgloss.NewStyle().Bold(true)
+	italicTextStyle = lipgloss.NewStyle().Italic(true)
+	inlineCodeStyle = lipgloss.NewStyle().Foreground(styles.Magenta)
+	codeBlockStyle  = lipgloss.NewStyle().Foreground(styles.White)
+	quoteStyle      = lipgloss.NewStyle().Foreground(styles.Gray).Italic(true)
+	linkStyle       = lipgloss.NewStyle().Foreground(styles.AccentLight).Underline(true)
+	gutterStyle     = lipgloss.NewStyle().Foreground(styles.Gray)
+)
+
+var (
+	headingPattern    = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	listPattern       = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	rulePattern       = regexp.MustCompile(`^\s*([-*_])(\s*[-*_]){2,}\s*$`)
+	inlineCodePattern = regexp.MustCompile("`[^`]+`")
+	boldPattern       = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+	italicPattern     = regexp.MustCompile(`\*([^*\s][^*]*)\*|(?:^|\s)_([^_\s][^_]*)_`)
+	linkPattern       = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+)
+
+const codeTabWidth = 4
+
+// ParseTextFormat splits a text send format hint into the format type and the
+// language (for code snippets). Unknown formats are treated as plain text.
+func ParseTextFormat(format string) (string, string) {
+	if strings.HasPrefix(format, constants.TextFormatCode) {
+		return constants.TextFormatCode, strings.TrimPrefix(format, constants.TextFormatCode)
+	} else if format == constants.TextFormatMarkdown {
+		return constants.TextFormatMarkdown, ""
+	}
+
+	return constants.TextFormatPlain, ""
+}
+
+// TextFormatLabel returns a readable label for a text send format hint
+func TextFormatLabel(format string) string {
+	formatType, lang := ParseTextFormat(format)
+	switch formatType {
+	case constants.TextFormatMarkdown:
+		return "Markdown"
+	case constants.TextFormatCode:
+		if len(lang) > 0 {
+			return fmt.Sprintf("Code (%s)", lang)
+		}
+
+		return "Code"
+	default:
+		return "Plain Text"
+	}
+}
+
+// RenderText formats text for displaying in the terminal according to the
+// format hint of a text send
+func RenderText(text, format string) string {
+	formatType, _ := ParseTextFormat(format)
+	switch formatType {
+	case constants.TextFormatMarkdown:
+		return renderMarkdown(text)
+	case constants.TextFormatCode:
+		return renderCode(text)
+	default:
+		return text
+	}
+}
+
+// renderCode adds line numbers to a code snippet
+func renderCode(text string) string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	width := len(fmt.Sprint(len(lines)))
+	tab := strings.Repeat(" ", codeTabWidth)
+
+	var result strings.Builder
+	for i, line := range lines {
+		gutter := gutterStyle.Render(fmt.Sprintf("%*d │", width, i+1))
+		result.WriteString(fmt.Sprintf("%s %s\n",
+			gutter,
+			strings.ReplaceAll(line, "\t", tab)))
+	}
+
+	return result.String()
+}
+
+// renderMarkdown renders a subset of markdown (headings, lists, quotes, code
+// blocks, links, and bold/italic/code text)
+func renderMarkdown(text string) string {
+	var result strings.Builder
+	inCodeBlock := false
+	tab := strings.Repeat(" ", codeTabWidth)
+
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCodeBlock = !inCodeBlock
+			continue
+		} else if inCodeBlock {
+			line = strings.ReplaceAll(line, "\t", tab)
+			result.WriteString(gutterStyle.Render("│ ") + codeBlockStyle.Render(line) + "\n")
+			continue
+		}
+
+		if match := headingPattern.FindStringSubmatch(line); match != nil {
+			heading := headingStyle.Render(renderInline(match[2]))
+			if len(match[1]) == 1 {
+				heading = headingStyle.Underline(true).Render(renderInline(match[2]))
+			}
+
+			result.WriteString(heading + "\n")
+		} else if rulePattern.MatchString(line) {
+			result.WriteString(gutterStyle.Render(strings.Repeat("─", 40)) + "\n")
+		} else if match = listPattern.FindStringSubmatch(line); match != nil {
+			result.WriteString(fmt.Sprintf("%s  • %s\n", match[1], renderInline(match[2])))
+		} else if strings.HasPrefix(line, ">") {
+			quote := strings.TrimSpace(strings.TrimPrefix(line, ">"))
+			result.WriteString(gutterStyle.Render("┃ ") + quoteStyle.Render(quote) + "\n")
+		} else {
+			result.WriteString(renderInline(line) + "\n")
+		}
+	}
+
+	return strings.TrimRight(result.String(), "\n")
+}
+
+// renderInline renders inline markdown styles within a single line. Text
+// within inline code is left as-is.
+func renderInline(line string) string {
+	var result strings.Builder
+	last := 0
+	for _, loc := range inlineCodePattern.FindAllStringIndex(line, -1) {
+		result.WriteString(renderInlineStyles(line[last:loc[0]]))
+		result.WriteString(inlineCodeStyle.Render(line[loc[0]+1 : loc[1]-1]))
+		last = loc[1]
+	}
+
+	result.WriteString(renderInlineStyles(line[last:]))
+	return result.String()
+}
+
+func renderInlineStyles(text string) string {
+	text = linkPattern.ReplaceAllStringFunc(text, func(s string) string {
+		match := linkPattern.FindStringSubmatch(s)
+		return fmt.Sprintf("%s (%s)", linkStyle.Render(match[1]), match[2])
+	})
+
+	text = boldPattern.ReplaceAllStringFunc(text, func(s string) string {
+		match := boldPattern.FindStringSubmatch(s)
+		return boldTextStyle.Render(match[1] + match[2])
+	})
+
+	return italicPattern.ReplaceAllStringFunc(text, func(s string) string {
+		match := italicPattern.FindStringSubmatch(s)
+		prefix := s[:len(s)-len(strings.TrimLeft(s, " \t"))]
+		return prefix + italicTextStyle.Render(match[1]+match[2])
+	})
+}
@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"yeetfile/shared/constants"
+)
+
+func TestParseTextFormat(t *testing.T) {
+	formatType, lang := ParseTextFormat(constants.TextFormatCode + "go")
+	if formatType != constants.TextFormatCode || lang != "go" {
+		t.Fatalf("Incorrect code format parsing (%s, %s)", formatType, lang)
+	}
+
+	formatType, _ = ParseTextFormat("unknown")
+	if formatType != constants.TextFormatPlain {
+		t.Fatalf("Unknown formats should be treated as plain text")
+	}
+}
+
+func TestRenderText(t *testing.T) {
+	text := "# Title\n- one\n* two\n```\n**not bold**\n```\n`**code**` and **bold**"
+	if RenderText(text, constants.TextFormatPlain) != text {
+		t.Fatalf("Plain text should not be modified")
+	}
+
+	rendered := RenderText(text, constants.TextFormatMarkdown)
+	if strings.Contains(rendered, "# Title") || strings.Contains(rendered, "```") {
+		t.Fatalf("Markdown headings and code fences should be rendered")
+	} else if !strings.Contains(rendered, "• one") || !strings.Contains(rendered, "• two") {
+		t.Fatalf("Markdown list items should be rendered")
+	} else if !strings.Contains(rendered, "**not bold**") || !strings.Contains(rendered, "**code**") {
+		t.Fatalf("Text within code should not be styled")
+	} else if strings.Contains(rendered, "**bold**") {
+		t.Fatalf("Bold text should be rendered")
+	}
+
+	code := RenderText("a\nb\n", constants.TextFormatCode+"go")
+	if !strings.Contains(code, "1 │ a") || !strings.Contains(code, "2 │ b") {
+		t.Fatalf("Code should be rendered with line numbers")
+	}
+}
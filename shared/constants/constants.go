@@ -21,7 +21,13 @@ const (
 	KeySize                         = 32
 	ChunkSize                       = 10000000 // 10 mb
 	TotalOverhead                   = 28       // encryption overhead (16) + iv size (12)
-	MaxPlaintextLen                 = 2000
+	ChunkFormatV2                   = 2        // first byte of a v2 chunk nonce
+	StreamIDSize                    = 7        // random per-file ID in v2 chunk nonces
+	KeySchemeX25519                 = 2        // first byte of data wrapped with an X25519 public key
+	X25519KeySize                   = 32       // raw X25519 public key size
+	MaxPlaintextLen                 = 2000     // max length of text stored in a single request
+	DefaultMaxTextSendSize          = 1000000  // 1 mb
+	MaxTextFormatLen                = 128      // encrypted text format hint (bytes)
 	MaxHintLen                      = 200
 	PlaintextIDPrefix               = "text"
 	FileIDPrefix                    = "file"
@@ -29,6 +35,86 @@ const (
 	ChangeIDLength                  = 9
 	MaxTransferThreads              = 3
 	MaxSendAgeDays                  = 30 //days
+	MaxSendDownloads                = 10
+	MaxSendManifestSize             = 512 * 1024 // encrypted manifest size (bytes)
+	MaxSendRecipients               = 10
 	MaxPassNoteLen                  = 500
+	MaxOrgNameLen                   = 64
 	RecoveryCodeLen                 = 8
+	MaxEmergencyWaitDays            = 90
+	MaxWebAuthnNameLen              = 64
+	MaxWebAuthnCredentials          = 10
+	WebAuthnTimeout                 = 120 // seconds
+	MaxSecurityEvents               = 100
+	SecurityLogRetentionDays        = 180
+	SSOTicketTimeout                = 600 // seconds
+)
+
+// Key derivation params for user keys. Accounts created before the params were
+// stored per user use Argon2Mem and Argon2Iter, and are upgraded to the default
+// params on their next login.
+const (
+	KDFArgon2id              = "argon2id"
+	DefaultArgon2Mem  uint32 = 128 // MB
+	DefaultArgon2Iter uint32 = 3
+	MaxArgon2Mem      uint32 = 1024 // MB
+	MaxArgon2Iter     uint32 = 10
+)
+
+// Format hints for text sends, which are encrypted alongside the text and used
+// to decide how the text is displayed. Code snippets use TextFormatCode
+// followed by an optional language name (i.e. "code:go").
+const (
+	TextFormatPlain    = "plain"
+	TextFormatMarkdown = "markdown"
+	TextFormatCode     = "code:"
+)
+
+// Roles for members of an organization. Owners and admins can manage members
+// and org folders, members can modify the contents of org folders, and
+// read-only members can only view them.
+const (
+	OrgRoleOwner    = "owner"
+	OrgRoleAdmin    = "admin"
+	OrgRoleMember   = "member"
+	OrgRoleReadOnly = "read-only"
+)
+
+// Actions a recipient can take on a pending share invitation. Blocking the
+// sender also declines all of their other pending invitations.
+const (
+	InvitationAccept  = "accept"
+	InvitationDecline = "decline"
+	InvitationBlock   = "block"
+)
+
+// Types of emergency access that a user can give a trusted contact. Contacts
+// with view access can only download the user's vault, and contacts with
+// takeover access can also set a new password for the user's account.
+const (
+	EmergencyAccessView     = "view"
+	EmergencyAccessTakeover = "takeover"
+)
+
+// Status of an emergency access grant. A requested grant becomes granted once
+// the owner's waiting period ends without the request being denied.
+const (
+	EmergencyStatusIdle      = "idle"
+	EmergencyStatusRequested = "requested"
+	EmergencyStatusGranted   = "granted"
+)
+
+// Types of events recorded in a user's security log
+const (
+	EventLogin           = "login"
+	EventLoginFailed     = "login_failed"
+	Event2FAEnabled      = "2fa_enabled"
+	Event2FADisabled     = "2fa_disabled"
+	EventPasswordChanged = "password_changed"
+	EventKDFUpgraded     = "kdf_upgraded"
+	EventEmailChanged    = "email_changed"
+	EventHintChanged     = "hint_changed"
+	EventRecoveryChanged = "recovery_key_changed"
+	EventShareGranted    = "share_granted"
+	EventSessionsRevoked = "sessions_revoked"
 )
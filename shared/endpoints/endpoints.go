@@ -23,6 +23,7 @@ type HTMLEndpoints struct {
 	Info           string
 	Upgrade        string
 	Admin          string
+	OIDCLogin      string
 }
 
 type BillingEndpoints struct {
@@ -36,13 +37,23 @@ var BillingPageEndpoints BillingEndpoints
 var (
 	Signup           = Endpoint("/api/signup")
 	Login            = Endpoint("/api/login")
+	KDF              = Endpoint("/api/kdf")
 	Logout           = Endpoint("/api/logout")
 	Account          = Endpoint("/api/account")
 	AccountUsage     = Endpoint("/api/account/usage")
 	RecyclePaymentID = Endpoint("/api/account/recycle/payment_id")
+	SecurityLog      = Endpoint("/api/account/security")
 	Forgot           = Endpoint("/api/forgot")
+	Recover          = Endpoint("/api/recover")
+	RecoveryKey      = Endpoint("/api/account/recovery")
 	Session          = Endpoint("/api/session")
 	TwoFactor        = Endpoint("/api/2fa")
+	WebAuthn         = Endpoint("/api/2fa/webauthn")
+	WebAuthnRegister = Endpoint("/api/2fa/webauthn/register")
+	WebAuthnKey      = Endpoint("/api/2fa/webauthn/key/*")
+	WebAuthnAssert   = Endpoint("/api/2fa/webauthn/assertion")
+	OIDCLogin        = Endpoint("/api/oidc/login")
+	OIDCCallback     = Endpoint("/api/oidc/callback")
 	VerifyAccount    = Endpoint("/api/verify/account")
 	VerifyEmail      = Endpoint("/api/verify/email")
 	ChangeEmail      = Endpoint("/api/change/email/*")
@@ -52,8 +63,11 @@ var (
 
 	AdminUserActions = Endpoint("/api/admin/user/*")
 	AdminFileActions = Endpoint("/api/admin/files/*")
+	AdminUserUnlock  = Endpoint("/api/admin/unlock/*")
 
-	Up = Endpoint("/up")
+	Up          = Endpoint("/up")
+	HealthLive  = Endpoint("/health/live")
+	HealthReady = Endpoint("/health/ready")
 
 	PassRoot     = Endpoint("/api/pass")
 	PassFolder   = Endpoint("/api/pass/folder/*")
@@ -74,11 +88,41 @@ var (
 	UploadSendText           = Endpoint("/api/send/plaintext")
 	DownloadSendFileMetadata = Endpoint("/api/send/d/*")
 	DownloadSendFileData     = Endpoint("/api/send/d/*/*")
+	SendRoot                 = Endpoint("/api/send")
+	SendItem                 = Endpoint("/api/send/*")
+	SendInbox                = Endpoint("/api/inbox")
+	SendInboxItem            = Endpoint("/api/inbox/*")
 
-	ShareFile    = Endpoint("/api/share/file/*")
-	ShareFolder  = Endpoint("/api/share/folder/*")
-	PubKey       = Endpoint("/api/pubkey")
-	ProtectedKey = Endpoint("/api/protectedkey")
+	FileRequests      = Endpoint("/api/request")
+	FileRequest       = Endpoint("/api/request/*")
+	UploadFileRequest = Endpoint("/api/request/*/u")
+
+	Org        = Endpoint("/api/org")
+	OrgMembers = Endpoint("/api/org/members")
+	OrgMember  = Endpoint("/api/org/members/*")
+	OrgFolders = Endpoint("/api/org/folders")
+	OrgFolder  = Endpoint("/api/org/folders/*")
+	OrgKeys    = Endpoint("/api/org/keys")
+
+	ShareFile        = Endpoint("/api/share/file/*")
+	ShareFolder      = Endpoint("/api/share/folder/*")
+	ShareInvitations = Endpoint("/api/share/invitations")
+	ShareInvitation  = Endpoint("/api/share/invitations/*")
+	ShareBlocked     = Endpoint("/api/share/blocked/*")
+	PubKey           = Endpoint("/api/pubkey")
+	ProtectedKey     = Endpoint("/api/protectedkey")
+	KeyRotation      = Endpoint("/api/protectedkey/rotate")
+
+	OwnershipTransfers      = Endpoint("/api/ownership")
+	OwnershipTransfer       = Endpoint("/api/ownership/*")
+	TransferFileOwnership   = Endpoint("/api/ownership/file/*")
+	TransferFolderOwnership = Endpoint("/api/ownership/folder/*")
+
+	EmergencyAccess   = Endpoint("/api/emergency")
+	EmergencyContact  = Endpoint("/api/emergency/*")
+	EmergencyRequest  = Endpoint("/api/emergency/request/*")
+	EmergencyKey      = Endpoint("/api/emergency/key/*")
+	EmergencyTakeover = Endpoint("/api/emergency/takeover/*")
 
 	StripeWebhook  = Endpoint("/stripe/webhook")
 	StripeCheckout = Endpoint("/stripe/checkout")
@@ -91,6 +135,7 @@ var (
 	HTMLHome             = Endpoint("/")
 	HTMLSend             = Endpoint("/send")
 	HTMLSendDownload     = Endpoint("/send/*")
+	HTMLFileRequest      = Endpoint("/request/*")
 	HTMLPass             = Endpoint("/pass")
 	HTMLPassFolder       = Endpoint("/pass/*")
 	HTMLPassEntry        = Endpoint("/pass/*/entry/*")
@@ -115,13 +160,23 @@ var (
 var JSVarNameMap = map[Endpoint]string{
 	Signup:           "Signup",
 	Login:            "Login",
+	KDF:              "KDF",
 	Logout:           "Logout",
 	Forgot:           "Forgot",
+	Recover:          "Recover",
+	RecoveryKey:      "RecoveryKey",
 	Session:          "Session",
 	Account:          "Account",
 	AccountUsage:     "AccountUsage",
 	RecyclePaymentID: "RecyclePaymentID",
+	SecurityLog:      "SecurityLog",
 	TwoFactor:        "TwoFactor",
+	WebAuthn:         "WebAuthn",
+	WebAuthnRegister: "WebAuthnRegister",
+	WebAuthnKey:      "WebAuthnKey",
+	WebAuthnAssert:   "WebAuthnAssert",
+	OIDCLogin:        "OIDCLogin",
+	OIDCCallback:     "OIDCCallback",
 	VerifyAccount:    "VerifyAccount",
 	VerifyEmail:      "VerifyEmail",
 	ChangeEmail:      "ChangeEmail",
@@ -131,6 +186,7 @@ var JSVarNameMap = map[Endpoint]string{
 
 	AdminUserActions: "AdminUserActions",
 	AdminFileActions: "AdminFileActions",
+	AdminUserUnlock:  "AdminUserUnlock",
 
 	PassRoot:     "PassRoot",
 	PassFolder:   "PassFolder",
@@ -151,11 +207,41 @@ var JSVarNameMap = map[Endpoint]string{
 	UploadSendText:           "UploadSendText",
 	DownloadSendFileMetadata: "DownloadSendFileMetadata",
 	DownloadSendFileData:     "DownloadSendFileData",
+	SendRoot:                 "SendRoot",
+	SendItem:                 "SendItem",
+	SendInbox:                "SendInbox",
+	SendInboxItem:            "SendInboxItem",
+
+	FileRequests:      "FileRequests",
+	FileRequest:       "FileRequest",
+	UploadFileRequest: "UploadFileRequest",
+
+	Org:        "Org",
+	OrgMembers: "OrgMembers",
+	OrgMember:  "OrgMember",
+	OrgFolders: "OrgFolders",
+	OrgFolder:  "OrgFolder",
+	OrgKeys:    "OrgKeys",
+
+	ShareFile:        "ShareFile",
+	ShareFolder:      "ShareFolder",
+	ShareInvitations: "ShareInvitations",
+	ShareInvitation:  "ShareInvitation",
+	ShareBlocked:     "ShareBlocked",
+	PubKey:           "PubKey",
+	ProtectedKey:     "ProtectedKey",
+	KeyRotation:      "KeyRotation",
+
+	OwnershipTransfers:      "OwnershipTransfers",
+	OwnershipTransfer:       "OwnershipTransfer",
+	TransferFileOwnership:   "TransferFileOwnership",
+	TransferFolderOwnership: "TransferFolderOwnership",
 
-	ShareFile:    "ShareFile",
-	ShareFolder:  "ShareFolder",
-	PubKey:       "PubKey",
-	ProtectedKey: "ProtectedKey",
+	EmergencyAccess:   "EmergencyAccess",
+	EmergencyContact:  "EmergencyContact",
+	EmergencyRequest:  "EmergencyRequest",
+	EmergencyKey:      "EmergencyKey",
+	EmergencyTakeover: "EmergencyTakeover",
 
 	StaticFile: "StaticFile",
 
@@ -165,6 +251,7 @@ var JSVarNameMap = map[Endpoint]string{
 	HTMLAccount:          "HTMLAccount",
 	HTMLSend:             "HTMLSend",
 	HTMLSendDownload:     "HTMLSendDownload",
+	HTMLFileRequest:      "HTMLFileRequest",
 	HTMLPass:             "HTMLPass",
 	HTMLPassFolder:       "HTMLPassFolder",
 	HTMLPassIndex:        "HTMLPassIndex",
@@ -215,6 +302,7 @@ func init() {
 		Info:           string(HTMLServerInfo),
 		Upgrade:        string(HTMLUpgrade),
 		Admin:          string(HTMLAdmin),
+		OIDCLogin:      string(OIDCLogin),
 	}
 
 	BillingPageEndpoints = BillingEndpoints{
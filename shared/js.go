@@ -16,14 +16,26 @@ export const IVSize = %d;
 export const KeySize = %d;
 export const ChunkSize = %d;
 export const TotalOverhead = %d;
+export const ChunkFormatV2 = %d;
+export const StreamIDSize = %d;
+export const KeySchemeX25519 = %d;
+export const X25519KeySize = %d;
 export const MaxPlaintextLen = %d;
+export const TextFormatPlain = "%s";
+export const TextFormatMarkdown = "%s";
+export const TextFormatCode = "%s";
 export const PlaintextIDPrefix = "%s";
 export const FileIDPrefix = "%s";
 export const VerificationCodeLength = %d;
 export const MaxHintLen = %d;
 export const MaxPassNoteLen = %d;
 export const Argon2Iter = %d;
-export const Argon2Mem = %d;`
+export const Argon2Mem = %d;
+export const KDFArgon2id = "%s";
+export const DefaultArgon2Iter = %d;
+export const DefaultArgon2Mem = %d;
+export const MaxArgon2Iter = %d;
+export const MaxArgon2Mem = %d;`
 
 const endpointsHeadJS = `
 // Auto-generated from shared/js.go. Don't edit this manually.
@@ -57,14 +69,26 @@ func GenerateSharedJS() (string, string) {
 		constants.KeySize,
 		constants.ChunkSize,
 		constants.TotalOverhead,
+		constants.ChunkFormatV2,
+		constants.StreamIDSize,
+		constants.KeySchemeX25519,
+		constants.X25519KeySize,
 		constants.MaxPlaintextLen,
+		constants.TextFormatPlain,
+		constants.TextFormatMarkdown,
+		constants.TextFormatCode,
 		constants.PlaintextIDPrefix,
 		constants.FileIDPrefix,
 		constants.VerificationCodeLength,
 		constants.MaxHintLen,
 		constants.MaxPassNoteLen,
 		constants.Argon2Iter,
-		constants.Argon2Mem)
+		constants.Argon2Mem,
+		constants.KDFArgon2id,
+		constants.DefaultArgon2Iter,
+		constants.DefaultArgon2Mem,
+		constants.MaxArgon2Iter,
+		constants.MaxArgon2Mem)
 
 	jsEndpoints := endpointsHeadJS
 	for apiEndpoint, varName := range endpoints.JSVarNameMap {
@@ -10,6 +10,7 @@ type AccountResponse struct {
 	PaymentID        string    `json:"paymentID"`
 	HasPasswordHint  bool      `json:"hasPasswordHint"`
 	Has2FA           bool      `json:"has2FA"`
+	HasRecoveryKey   bool      `json:"hasRecoveryKey"`
 	StorageAvailable int64     `json:"storageAvailable"`
 	StorageUsed      int64     `json:"storageUsed"`
 	SendAvailable    int64     `json:"sendAvailable"`
@@ -25,11 +26,16 @@ type UsageResponse struct {
 }
 
 type UploadMetadata struct {
-	Name       string `json:"name"`
-	Chunks     int    `json:"chunks"`
-	Size       int64  `json:"size"`
-	Downloads  int    `json:"downloads"`
-	Expiration string `json:"expiration"`
+	Name       string          `json:"name"`
+	Chunks     int             `json:"chunks"`
+	Size       int64           `json:"size"`
+	Downloads  int             `json:"downloads"`
+	Expiration string          `json:"expiration"`
+	Notify     bool            `json:"notify"`
+	Manifest   []byte          `json:"manifest"`
+	Text       bool            `json:"text"`
+	Format     []byte          `json:"format"`
+	Recipients []SendRecipient `json:"recipients"`
 }
 
 type VaultUpload struct {
@@ -44,6 +50,7 @@ type VaultUpload struct {
 type ModifyVaultItem struct {
 	Name         string `json:"name"`
 	PasswordData []byte `json:"passwordData" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	ProtectedKey []byte `json:"protectedKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
 }
 
 type MetadataUploadResponse struct {
@@ -67,6 +74,7 @@ type VaultItem struct {
 	IsOwner      bool      `json:"isOwner"`
 	RefID        string    `json:"refID"`
 	PasswordData []byte    `json:"passwordData" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	PendingKey   bool      `json:"pendingKey"`
 }
 
 type VaultItemInfo struct {
@@ -125,11 +133,21 @@ type VaultDownloadResponse struct {
 }
 
 type PlaintextUpload struct {
-	Name       string `json:"name"`
-	Salt       []byte `json:"salt" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
-	Downloads  int    `json:"downloads"`
-	Expiration string `json:"expiration"`
-	Text       []byte `json:"text" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	Name       string          `json:"name"`
+	Salt       []byte          `json:"salt" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	Downloads  int             `json:"downloads"`
+	Expiration string          `json:"expiration"`
+	Text       []byte          `json:"text" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	Format     []byte          `json:"format" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	Recipients []SendRecipient `json:"recipients"`
+}
+
+// SendRecipient is a YeetFile user that a send is addressed to, identified by
+// either their email or account ID. The send key is encrypted with the user's
+// public key, so only that user is able to decrypt the send.
+type SendRecipient struct {
+	User         string `json:"user"`
+	ProtectedKey []byte `json:"protectedKey"`
 }
 
 type DownloadResponse struct {
@@ -139,37 +157,217 @@ type DownloadResponse struct {
 	Chunks     int       `json:"chunks"`
 	Downloads  int       `json:"downloads"`
 	Expiration time.Time `json:"expiration" ts_type:"Date" ts_transform:"new Date(__VALUE__)"`
+	Manifest   []byte    `json:"manifest" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	Format     []byte    `json:"format" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+}
+
+// SendManifest lists the files contained in a multi-file send. The manifest is
+// encrypted with the send key before upload. The contents of each file are
+// concatenated into a single stream (in manifest order) before being chunked,
+// so each file is located using its offset and size within that stream.
+type SendManifest struct {
+	Files []SendManifestFile `json:"files"`
+}
+
+type SendManifestFile struct {
+	Path   string `json:"path"`
+	Offset int64  `json:"offset"`
+	Size   int64  `json:"size"`
+}
+
+type SendItem struct {
+	ID             string    `json:"id"`
+	Size           int64     `json:"size"`
+	TextOnly       bool      `json:"textOnly"`
+	Bundle         bool      `json:"bundle"`
+	Notify         bool      `json:"notify"`
+	Downloads      int       `json:"downloads"`
+	TotalDownloads int       `json:"totalDownloads"`
+	Expiration     time.Time `json:"expiration" ts_type:"Date" ts_transform:"new Date(__VALUE__)"`
+	Created        time.Time `json:"created" ts_type:"Date" ts_transform:"new Date(__VALUE__)"`
+}
+
+type SendListResponse struct {
+	Sends []SendItem `json:"sends"`
+}
+
+type InboxItem struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	SenderName   string    `json:"senderName"`
+	Size         int64     `json:"size"`
+	Bundle       bool      `json:"bundle"`
+	Downloads    int       `json:"downloads"`
+	ProtectedKey []byte    `json:"protectedKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	Expiration   time.Time `json:"expiration" ts_type:"Date" ts_transform:"new Date(__VALUE__)"`
+	Created      time.Time `json:"created" ts_type:"Date" ts_transform:"new Date(__VALUE__)"`
+}
+
+type InboxResponse struct {
+	Sends []InboxItem `json:"sends"`
+}
+
+// SaveInboxSend is used to copy a send from the user's inbox into their vault.
+// The name is re-encrypted with the send key, and the send key is encrypted
+// with the key of the destination folder.
+type SaveInboxSend struct {
+	FolderID     string `json:"folderID"`
+	Name         string `json:"name"`
+	ProtectedKey []byte `json:"protectedKey"`
+}
+
+type ModifySend struct {
+	Downloads  int    `json:"downloads"`
+	Expiration string `json:"expiration"`
+}
+
+type NewFileRequest struct {
+	FolderID   string `json:"folderID"`
+	MaxUploads int    `json:"maxUploads"`
+	MaxSize    int64  `json:"maxSize"`
+	Expiration string `json:"expiration"`
+}
+
+type FileRequest struct {
+	ID         string    `json:"id"`
+	FolderID   string    `json:"folderID"`
+	MaxUploads int       `json:"maxUploads"`
+	Uploads    int       `json:"uploads"`
+	MaxSize    int64     `json:"maxSize"`
+	Expiration time.Time `json:"expiration" ts_type:"Date" ts_transform:"new Date(__VALUE__)"`
+	Created    time.Time `json:"created" ts_type:"Date" ts_transform:"new Date(__VALUE__)"`
+}
+
+type FileRequestListResponse struct {
+	Requests []FileRequest `json:"requests"`
+}
+
+type FileRequestInfo struct {
+	ID         string    `json:"id"`
+	PublicKey  []byte    `json:"publicKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	MaxSize    int64     `json:"maxSize"`
+	Remaining  int       `json:"remaining"`
+	Expiration time.Time `json:"expiration" ts_type:"Date" ts_transform:"new Date(__VALUE__)"`
+}
+
+type NewOrganization struct {
+	Name string `json:"name"`
+}
+
+type OrgMember struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
+
+// OrgFolder is a top level folder owned by an organization. The protected key
+// is the folder key encrypted with the current member's public key.
+type OrgFolder struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	ProtectedKey []byte `json:"protectedKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+}
+
+type Organization struct {
+	ID               string      `json:"id"`
+	Name             string      `json:"name"`
+	UserID           string      `json:"userID"`
+	Role             string      `json:"role"`
+	Members          []OrgMember `json:"members"`
+	Folders          []OrgFolder `json:"folders"`
+	StorageUsed      int64       `json:"storageUsed"`
+	StorageAvailable int64       `json:"storageAvailable"`
+	RotateKeys       bool        `json:"rotateKeys"`
+}
+
+// OrgKey is a key wrapped for a specific organization member, folder, or
+// vault item, depending on the context it's used in.
+type OrgKey struct {
+	ID           string `json:"id"`
+	ProtectedKey []byte `json:"protectedKey"`
+}
+
+// NewOrgMember adds a user to the organization. The key of every org folder
+// needs to be encrypted with the new member's public key.
+type NewOrgMember struct {
+	User       string   `json:"user"`
+	Role       string   `json:"role"`
+	FolderKeys []OrgKey `json:"folderKeys"`
+}
+
+type ModifyOrgMember struct {
+	Role string `json:"role"`
+}
+
+// NewOrgFolder creates a folder owned by the organization. The folder key
+// needs to be encrypted with the public key of every member.
+type NewOrgFolder struct {
+	Name       string   `json:"name"`
+	MemberKeys []OrgKey `json:"memberKeys"`
+}
+
+// OrgFolderRotation replaces the key of an org folder. The name and the keys
+// of the folder's items and subfolders are encrypted with the new folder key,
+// and the new folder key is encrypted with the public key of every member.
+type OrgFolderRotation struct {
+	FolderID   string   `json:"folderID"`
+	Name       string   `json:"name"`
+	Items      []OrgKey `json:"items"`
+	Subfolders []OrgKey `json:"subfolders"`
+	MemberKeys []OrgKey `json:"memberKeys"`
+}
+
+type OrgKeyRotation struct {
+	Folders []OrgFolderRotation `json:"folders"`
+}
+
+// KDFParams are the params used to derive a user's keys from their password.
+// Memory is in MB.
+type KDFParams struct {
+	Algorithm  string `json:"algorithm"`
+	Iterations uint32 `json:"iterations"`
+	Memory     uint32 `json:"memory"`
 }
 
 type Signup struct {
-	Identifier              string `json:"identifier"`
-	LoginKeyHash            []byte `json:"loginKeyHash" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
-	PublicKey               []byte `json:"publicKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
-	ProtectedPrivateKey     []byte `json:"protectedPrivateKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
-	ProtectedVaultFolderKey []byte `json:"protectedVaultFolderKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
-	PasswordHint            string `json:"passwordHint"`
-	ServerPassword          string `json:"serverPassword"`
+	Identifier              string    `json:"identifier"`
+	LoginKeyHash            []byte    `json:"loginKeyHash" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	PublicKey               []byte    `json:"publicKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	ProtectedPrivateKey     []byte    `json:"protectedPrivateKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	ProtectedVaultFolderKey []byte    `json:"protectedVaultFolderKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	PasswordHint            string    `json:"passwordHint"`
+	ServerPassword          string    `json:"serverPassword"`
+	RecoveryKeyHash         []byte    `json:"recoveryKeyHash" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	RecoveryProtectedKey    []byte    `json:"recoveryProtectedKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	KDF                     KDFParams `json:"kdf"`
+	SSOTicket               string    `json:"ssoTicket,omitempty"`
 }
 
 type SignupResponse struct {
 	Identifier string `json:"identifier"`
 	Captcha    string `json:"captcha"`
 	Error      string `json:"error"`
+	SSOTicket  string `json:"ssoTicket,omitempty"`
 }
 
 type VerifyAccount struct {
-	ID                      string `json:"id"`
-	Code                    string `json:"code"`
-	LoginKeyHash            []byte `json:"loginKeyHash" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
-	PublicKey               []byte `json:"publicKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
-	ProtectedPrivateKey     []byte `json:"protectedPrivateKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
-	ProtectedVaultFolderKey []byte `json:"protectedVaultFolderKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	ID                      string    `json:"id"`
+	Code                    string    `json:"code"`
+	LoginKeyHash            []byte    `json:"loginKeyHash" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	PublicKey               []byte    `json:"publicKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	ProtectedPrivateKey     []byte    `json:"protectedPrivateKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	ProtectedVaultFolderKey []byte    `json:"protectedVaultFolderKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	RecoveryKeyHash         []byte    `json:"recoveryKeyHash" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	RecoveryProtectedKey    []byte    `json:"recoveryProtectedKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	KDF                     KDFParams `json:"kdf"`
 }
 
 type Login struct {
-	Identifier   string `json:"identifier"`
-	LoginKeyHash []byte `json:"loginKeyHash" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
-	Code         string `json:"code"`
+	Identifier   string             `json:"identifier"`
+	LoginKeyHash []byte             `json:"loginKeyHash" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	Code         string             `json:"code"`
+	WebAuthn     *WebAuthnAssertion `json:"webauthn,omitempty"`
+	SSOTicket    string             `json:"ssoTicket,omitempty"`
 }
 
 type LoginResponse struct {
@@ -202,17 +400,87 @@ type ChangePasswordHint struct {
 }
 
 type PubKeyResponse struct {
-	PublicKey []byte `json:"publicKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	PublicKey  []byte `json:"publicKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	KeyVersion int    `json:"keyVersion"`
+}
+
+// WrappedKey is a folder, file, share, transfer, or send key that has been
+// encrypted with a user's public key
+type WrappedKey struct {
+	ID           string `json:"id"`
+	ProtectedKey []byte `json:"protectedKey"`
+}
+
+// WrappedKeys contains every key that is encrypted with a user's public key,
+// grouped by where the key is stored
+type WrappedKeys struct {
+	Folders   []WrappedKey `json:"folders"`
+	Items     []WrappedKey `json:"items"`
+	Shares    []WrappedKey `json:"shares"`
+	Transfers []WrappedKey `json:"transfers"`
+	Sends     []WrappedKey `json:"sends"`
+	Emergency []WrappedKey `json:"emergency"`
+}
+
+type KeyRotationResponse struct {
+	KeyVersion int         `json:"keyVersion"`
+	Keys       WrappedKeys `json:"keys"`
+}
+
+// KeyRotation replaces a user's key pair. Every key returned in the
+// KeyRotationResponse needs to be re-encrypted with the new public key, and
+// KeyVersion needs to match the user's current key version.
+type KeyRotation struct {
+	KeyVersion   int         `json:"keyVersion"`
+	LoginKeyHash []byte      `json:"loginKeyHash"`
+	PublicKey    []byte      `json:"publicKey"`
+	ProtectedKey []byte      `json:"protectedKey"`
+	Keys         WrappedKeys `json:"keys"`
 }
 
 type ProtectedKeyResponse struct {
 	ProtectedKey []byte `json:"protectedKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
 }
 
+// SetRecoveryKey sets the user's recovery key. ProtectedKey is the user's
+// private key encrypted with their recovery key, and RecoveryKeyHash is used to
+// authorize an account recovery.
+type SetRecoveryKey struct {
+	LoginKeyHash    []byte `json:"loginKeyHash"`
+	RecoveryKeyHash []byte `json:"recoveryKeyHash"`
+	ProtectedKey    []byte `json:"protectedKey"`
+}
+
+// RemoveRecoveryKey removes the user's recovery key, which requires their
+// login key hash.
+type RemoveRecoveryKey struct {
+	LoginKeyHash []byte `json:"loginKeyHash"`
+}
+
+// RecoveryKeyLogin is used to fetch the user's private key encrypted with
+// their recovery key, before recovering their account.
+type RecoveryKeyLogin struct {
+	Identifier      string `json:"identifier"`
+	RecoveryKeyHash []byte `json:"recoveryKeyHash"`
+}
+
+// RecoverAccount sets a new password for the user using their recovery key.
+// ProtectedKey is the user's private key encrypted with the user key derived
+// from their new password.
+type RecoverAccount struct {
+	Identifier      string    `json:"identifier"`
+	RecoveryKeyHash []byte    `json:"recoveryKeyHash"`
+	Code            string    `json:"code"`
+	LoginKeyHash    []byte    `json:"loginKeyHash"`
+	ProtectedKey    []byte    `json:"protectedKey"`
+	KDF             KDFParams `json:"kdf"`
+}
+
 type ShareItemRequest struct {
 	User         string `json:"user"`
 	CanModify    bool   `json:"canModify"`
 	ProtectedKey []byte `json:"protectedKey"`
+	Expiration   string `json:"expiration"`
 }
 
 type NewSharedItem struct {
@@ -222,6 +490,7 @@ type NewSharedItem struct {
 	RecipientID  string
 	ProtectedKey []byte
 	CanModify    bool
+	Expiration   time.Time
 }
 
 type FileOwnershipInfo struct {
@@ -236,9 +505,128 @@ type FolderOwnershipInfo struct {
 }
 
 type ShareInfo struct {
-	ID        string `json:"id"`
-	Recipient string `json:"recipientName"`
-	CanModify bool   `json:"canModify"`
+	ID         string    `json:"id"`
+	Recipient  string    `json:"recipientName"`
+	CanModify  bool      `json:"canModify"`
+	Expiration time.Time `json:"expiration" ts_type:"Date" ts_transform:"new Date(__VALUE__)"`
+	Pending    bool      `json:"pending"`
+}
+
+type ShareInvitation struct {
+	ID         string    `json:"id"`
+	SharerName string    `json:"sharerName"`
+	PublicKey  []byte    `json:"publicKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	IsFolder   bool      `json:"isFolder"`
+	CanModify  bool      `json:"canModify"`
+	Expiration time.Time `json:"expiration" ts_type:"Date" ts_transform:"new Date(__VALUE__)"`
+	Created    time.Time `json:"created" ts_type:"Date" ts_transform:"new Date(__VALUE__)"`
+}
+
+type BlockedUser struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type ShareInvitationsResponse struct {
+	Invitations []ShareInvitation `json:"invitations"`
+	Blocked     []BlockedUser     `json:"blocked"`
+}
+
+type ShareInvitationAction struct {
+	Action string `json:"action"`
+}
+
+type OwnershipTransferRequest struct {
+	User         string `json:"user"`
+	ProtectedKey []byte `json:"protectedKey"`
+}
+
+type OwnershipTransfer struct {
+	ID           string    `json:"id"`
+	UserName     string    `json:"userName"`
+	PublicKey    []byte    `json:"publicKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	Name         string    `json:"name"`
+	ProtectedKey []byte    `json:"protectedKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	IsFolder     bool      `json:"isFolder"`
+	Size         int64     `json:"size"`
+	Created      time.Time `json:"created" ts_type:"Date" ts_transform:"new Date(__VALUE__)"`
+}
+
+type OwnershipTransfersResponse struct {
+	Incoming []OwnershipTransfer `json:"incoming"`
+	Outgoing []OwnershipTransfer `json:"outgoing"`
+}
+
+// NewEmergencyContact designates another user as an emergency contact.
+// ProtectedKey is the owner's private key encrypted with the contact's public
+// key, and WaitDays is how long a request for access can be denied before
+// access is granted.
+type NewEmergencyContact struct {
+	User         string `json:"user"`
+	AccessType   string `json:"accessType"`
+	WaitDays     int    `json:"waitDays"`
+	ProtectedKey []byte `json:"protectedKey"`
+}
+
+type UpdateEmergencyAccess struct {
+	ProtectedKey []byte `json:"protectedKey"`
+}
+
+type EmergencyAccess struct {
+	ID         string    `json:"id"`
+	UserName   string    `json:"userName"`
+	AccessType string    `json:"accessType"`
+	WaitDays   int       `json:"waitDays"`
+	Status     string    `json:"status"`
+	Requested  time.Time `json:"requested" ts_type:"Date" ts_transform:"new Date(__VALUE__)"`
+	HasKey     bool      `json:"hasKey"`
+	PublicKey  []byte    `json:"publicKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+}
+
+// EmergencyAccessResponse contains the contacts the user has designated
+// (Contacts), and the users who designated the user as a contact (Grantors)
+type EmergencyAccessResponse struct {
+	Contacts []EmergencyAccess `json:"contacts"`
+	Grantors []EmergencyAccess `json:"grantors"`
+}
+
+type SecurityEvent struct {
+	Type      string    `json:"type"`
+	IPAddress string    `json:"ipAddress"`
+	UserAgent string    `json:"userAgent"`
+	Details   string    `json:"details"`
+	Created   time.Time `json:"created" ts_type:"Date" ts_transform:"new Date(__VALUE__)"`
+}
+
+// SecurityLog contains the user's most recent security events, and whether
+// they're notified by email when logging in from a new device or IP
+type SecurityLog struct {
+	Events      []SecurityEvent `json:"events"`
+	LoginAlerts bool            `json:"loginAlerts"`
+}
+
+type SecuritySettings struct {
+	LoginAlerts bool `json:"loginAlerts"`
+}
+
+// EmergencyAccessKeyResponse contains the owner's keys for a contact whose
+// access has been granted. Identifier (the owner's email or account ID) is
+// only included for takeover access, since it's needed to derive the owner's
+// new user key.
+type EmergencyAccessKeyResponse struct {
+	Identifier   string `json:"identifier"`
+	AccessType   string `json:"accessType"`
+	PublicKey    []byte `json:"publicKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	ProtectedKey []byte `json:"protectedKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+}
+
+// EmergencyTakeover replaces the owner's login with one chosen by a contact
+// with takeover access. ProtectedKey is the owner's private key encrypted with
+// the user key derived from the new password.
+type EmergencyTakeover struct {
+	LoginKeyHash []byte    `json:"loginKeyHash"`
+	ProtectedKey []byte    `json:"protectedKey"`
+	KDF          KDFParams `json:"kdf"`
 }
 
 type ShareEdit struct {
@@ -260,16 +648,22 @@ type StartEmailChangeResponse struct {
 }
 
 type ChangeEmail struct {
-	NewEmail        string `json:"newEmail"`
-	OldLoginKeyHash []byte `json:"oldLoginKeyHash" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
-	NewLoginKeyHash []byte `json:"newLoginKeyHash" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
-	ProtectedKey    []byte `json:"protectedKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	NewEmail        string    `json:"newEmail"`
+	OldLoginKeyHash []byte    `json:"oldLoginKeyHash" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	NewLoginKeyHash []byte    `json:"newLoginKeyHash" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	ProtectedKey    []byte    `json:"protectedKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	KDF             KDFParams `json:"kdf"`
 }
 
 type ChangePassword struct {
-	OldLoginKeyHash []byte `json:"oldLoginKeyHash" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
-	NewLoginKeyHash []byte `json:"newLoginKeyHash" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
-	ProtectedKey    []byte `json:"protectedKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	OldLoginKeyHash []byte    `json:"oldLoginKeyHash" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	NewLoginKeyHash []byte    `json:"newLoginKeyHash" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	ProtectedKey    []byte    `json:"protectedKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	KDF             KDFParams `json:"kdf"`
+
+	// KDFUpgrade is set when the password is unchanged, and only the key
+	// derivation params are being upgraded
+	KDFUpgrade bool `json:"kdfUpgrade"`
 }
 
 type NewTOTP struct {
@@ -287,6 +681,54 @@ type SetTOTPResponse struct {
 	RecoveryCodes [6]string `json:"recoveryCodes"`
 }
 
+type WebAuthnCredential struct {
+	ID       string    `json:"id"`
+	Name     string    `json:"name"`
+	Created  time.Time `json:"created" ts_type:"Date" ts_transform:"new Date(__VALUE__)"`
+	LastUsed time.Time `json:"lastUsed" ts_type:"Date" ts_transform:"new Date(__VALUE__)"`
+}
+
+type WebAuthnRegistrationOptions struct {
+	Challenge          []byte   `json:"challenge" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	RPID               string   `json:"rpId"`
+	RPName             string   `json:"rpName"`
+	UserID             []byte   `json:"userId" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	UserName           string   `json:"userName"`
+	Algorithms         []int    `json:"algorithms"`
+	ExcludeCredentials [][]byte `json:"excludeCredentials" ts_type:"Uint8Array[]" ts_transform:"__VALUE__ ? __VALUE__.map(base64ToArray) : []"`
+	Timeout            int      `json:"timeout"`
+}
+
+type WebAuthnRegistration struct {
+	Name              string `json:"name"`
+	ClientDataJSON    []byte `json:"clientDataJSON" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	AttestationObject []byte `json:"attestationObject" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+}
+
+type WebAuthnRegistrationResponse struct {
+	ID            string   `json:"id"`
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+type WebAuthnAssertionRequest struct {
+	Identifier   string `json:"identifier"`
+	LoginKeyHash []byte `json:"loginKeyHash" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+}
+
+type WebAuthnAssertionOptions struct {
+	Challenge        []byte   `json:"challenge" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	RPID             string   `json:"rpId"`
+	AllowCredentials [][]byte `json:"allowCredentials" ts_type:"Uint8Array[]" ts_transform:"__VALUE__ ? __VALUE__.map(base64ToArray) : []"`
+	Timeout          int      `json:"timeout"`
+}
+
+type WebAuthnAssertion struct {
+	CredentialID      []byte `json:"credentialId" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	ClientDataJSON    []byte `json:"clientDataJSON" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	AuthenticatorData []byte `json:"authenticatorData" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	Signature         []byte `json:"signature" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+}
+
 type ServerInfo struct {
 	StorageBackend     string `json:"storageBackend"`
 	PasswordRestricted bool   `json:"passwordRestricted"`
@@ -297,12 +739,23 @@ type ServerInfo struct {
 	BTCPayEnabled      bool   `json:"btcPayEnabled"`
 	DefaultStorage     int64  `json:"defaultStorage"`
 	DefaultSend        int64  `json:"defaultSend"`
+	MaxTextSize        int64  `json:"maxTextSize"`
+	SSOEnabled         bool   `json:"ssoEnabled"`
 
 	Upgrades      Upgrades   `json:"upgrades"`
 	MonthUpgrades []*Upgrade `json:"monthUpgrades"`
 	YearUpgrades  []*Upgrade `json:"yearUpgrades"`
 }
 
+type HealthCheck struct {
+	OK bool `json:"ok"`
+}
+
+type HealthResponse struct {
+	Status string                 `json:"status"`
+	Checks map[string]HealthCheck `json:"checks,omitempty"`
+}
+
 type PassEntry struct {
 	Username        string   `json:"username"`
 	Password        string   `json:"password"`
@@ -359,6 +812,7 @@ type AdminUserInfoResponse struct {
 	Email       string `json:"email"`
 	StorageUsed string `json:"storageUsed"`
 	SendUsed    string `json:"sendUsed"`
+	Locked      bool   `json:"locked"`
 
 	Files []AdminFileInfoResponse `json:"files"`
 }
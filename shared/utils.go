@@ -236,3 +236,40 @@ func TrimEmptyLines(s string) string {
 
 	return strings.Join(lines[start:end], "\n")
 }
+
+// DefaultKDFParams returns the key derivation params used for new passwords
+func DefaultKDFParams() KDFParams {
+	return KDFParams{
+		Algorithm:  constants.KDFArgon2id,
+		Iterations: constants.DefaultArgon2Iter,
+		Memory:     constants.DefaultArgon2Mem,
+	}
+}
+
+// LegacyKDFParams returns the key derivation params used by accounts created
+// before the params were stored per user
+func LegacyKDFParams() KDFParams {
+	return KDFParams{
+		Algorithm:  constants.KDFArgon2id,
+		Iterations: constants.Argon2Iter,
+		Memory:     constants.Argon2Mem,
+	}
+}
+
+// ValidateKDFParams returns the params if they're supported, or the legacy
+// params if they're empty (from clients that don't send params). Params that
+// are weaker than the legacy params are rejected.
+func ValidateKDFParams(params KDFParams) (KDFParams, error) {
+	if params == (KDFParams{}) {
+		return LegacyKDFParams(), nil
+	} else if params.Algorithm != constants.KDFArgon2id {
+		return KDFParams{}, errors.New("unsupported key derivation algorithm")
+	} else if params.Iterations < constants.Argon2Iter ||
+		params.Iterations > constants.MaxArgon2Iter ||
+		params.Memory < constants.Argon2Mem ||
+		params.Memory > constants.MaxArgon2Mem {
+		return KDFParams{}, errors.New("invalid key derivation params")
+	}
+
+	return params, nil
+}
@@ -50,8 +50,14 @@ func main() {
 
 	converter := typescriptify.New().
 		Add(shared.UploadMetadata{}).
+		Add(shared.SendManifest{}).
+		Add(shared.SendManifestFile{}).
 		Add(shared.VaultUpload{}).
 		Add(shared.ModifyVaultItem{}).
+		Add(shared.NewFileRequest{}).
+		Add(shared.FileRequest{}).
+		Add(shared.FileRequestListResponse{}).
+		Add(shared.FileRequestInfo{}).
 		Add(shared.MetadataUploadResponse{}).
 		Add(shared.NewFolderResponse{}).
 		Add(shared.VaultItem{}).
@@ -63,6 +69,7 @@ func main() {
 		Add(shared.VaultDownloadResponse{}).
 		Add(shared.PlaintextUpload{}).
 		Add(shared.DownloadResponse{}).
+		Add(shared.KDFParams{}).
 		Add(shared.Signup{}).
 		Add(shared.SignupResponse{}).
 		Add(shared.VerifyAccount{}).
@@ -78,6 +85,10 @@ func main() {
 		Add(shared.FolderOwnershipInfo{}).
 		Add(shared.ShareInfo{}).
 		Add(shared.ShareEdit{}).
+		Add(shared.ShareInvitation{}).
+		Add(shared.BlockedUser{}).
+		Add(shared.ShareInvitationsResponse{}).
+		Add(shared.ShareInvitationAction{}).
 		Add(shared.DeleteResponse{}).
 		Add(shared.DeleteAccount{}).
 		Add(shared.ChangePassword{}).
@@ -89,6 +100,16 @@ func main() {
 		Add(shared.NewTOTP{}).
 		Add(shared.SetTOTP{}).
 		Add(shared.SetTOTPResponse{}).
+		Add(shared.WebAuthnCredential{}).
+		Add(shared.WebAuthnRegistrationOptions{}).
+		Add(shared.WebAuthnRegistration{}).
+		Add(shared.WebAuthnRegistrationResponse{}).
+		Add(shared.WebAuthnAssertionRequest{}).
+		Add(shared.WebAuthnAssertionOptions{}).
+		Add(shared.WebAuthnAssertion{}).
+		Add(shared.SecurityEvent{}).
+		Add(shared.SecurityLog{}).
+		Add(shared.SecuritySettings{}).
 		Add(shared.ItemIndex{}).
 		Add(shared.AdminUserInfoResponse{}).
 		Add(shared.AdminFileInfoResponse{})
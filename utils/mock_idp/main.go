@@ -0,0 +1,39 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"strings"
+	"yeetfile/backend/oidc/oidctest"
+)
+
+// Runs a mock OpenID Connect identity provider for testing single sign-on
+// locally. Every login is approved as the provided email, e.g.:
+//
+//	go run ./utils/mock_idp -email user@example.com -groups admins
+//
+// and the server is started with:
+//
+//	YEETFILE_OIDC_ISSUER=http://localhost:8091
+//	YEETFILE_OIDC_CLIENT_ID=yeetfile
+//	YEETFILE_OIDC_CLIENT_SECRET=secret
+func main() {
+	addr := flag.String("addr", "localhost:8091", "address to listen on")
+	clientID := flag.String("client-id", "yeetfile", "client ID")
+	clientSecret := flag.String("client-secret", "secret", "client secret")
+	email := flag.String("email", "user@example.com", "email to log in as")
+	groups := flag.String("groups", "", "comma separated groups for the user")
+	flag.Parse()
+
+	var groupList []string
+	if len(*groups) > 0 {
+		groupList = strings.Split(*groups, ",")
+	}
+
+	provider := oidctest.NewProvider(*clientID, *clientSecret, *email, groupList)
+	provider.Issuer = "http://" + *addr
+
+	log.Printf("Mock identity provider running at %s\n", provider.Issuer)
+	log.Fatal(http.ListenAndServe(*addr, provider))
+}
@@ -1,6 +1,7 @@
 import {Endpoints} from "./endpoints.js";
 import {YeetFileDB} from "./db.js";
 import * as interfaces from "./interfaces.js";
+import * as webauthn from "./webauthn.js";
 
 const init = () => {
     let logoutBtn = document.getElementById("logout-btn");
@@ -27,6 +28,17 @@ const init = () => {
         disable2FALink.addEventListener("click", disable2FA);
     }
 
+    let addSecurityKeyLink = document.getElementById("add-security-key");
+    addSecurityKeyLink.addEventListener("click", addSecurityKey);
+
+    let removeSecurityKeyLinks = document.getElementsByClassName("remove-security-key");
+    for (let i = 0; i < removeSecurityKeyLinks.length; i++) {
+        let link = removeSecurityKeyLinks[i] as HTMLAnchorElement;
+        link.addEventListener("click", () => {
+            removeSecurityKey(link.dataset.id, link.dataset.name);
+        });
+    }
+
     let recyclePaymentIDBtn = document.getElementById("recycle-payment-id");
     recyclePaymentIDBtn.addEventListener("click", recyclePaymentID);
 
@@ -179,6 +191,69 @@ const disable2FA = () => {
     dialog.showModal();
 }
 
+const addSecurityKey = async (event: Event) => {
+    event.preventDefault();
+    if (!webauthn.isSecurityKeySupported()) {
+        alert("Security keys aren't supported in this browser");
+        return;
+    }
+
+    let name = prompt("Enter a name for the new security key or passkey:");
+    if (!name || name.trim().length === 0) {
+        return;
+    }
+
+    try {
+        let response = await fetch(Endpoints.WebAuthnRegister.path);
+        if (!response.ok) {
+            throw new Error(await response.text());
+        }
+
+        let options = new interfaces.WebAuthnRegistrationOptions(await response.json());
+        let registration = await webauthn.createSecurityKey(name.trim(), options);
+
+        response = await fetch(Endpoints.WebAuthnRegister.path, {
+            method: "POST",
+            body: JSON.stringify(registration, jsonReplacer),
+        });
+
+        if (!response.ok) {
+            throw new Error(await response.text());
+        }
+
+        let registrationResponse = new interfaces.WebAuthnRegistrationResponse(
+            await response.json());
+        if (registrationResponse.recoveryCodes && registrationResponse.recoveryCodes.length > 0) {
+            alert("Security key added! Save the following recovery codes in a " +
+                "safe place, they can be used to log in if you lose access to " +
+                "your security keys:\n\n" + registrationResponse.recoveryCodes.join("\n"));
+        }
+
+        window.location.reload();
+    } catch (error) {
+        alert(`Failed to add security key: ${error.message}`);
+    }
+}
+
+const removeSecurityKey = (id: string, name: string) => {
+    let code = prompt(`To remove security key "${name}", enter your 2FA ` +
+        "code or a recovery code:");
+    if (!code || code.trim().length === 0) {
+        return;
+    }
+
+    let url = Endpoints.format(Endpoints.WebAuthnKey, id);
+    fetch(`${url}?code=${encodeURIComponent(code.trim())}`, {
+        method: "DELETE",
+    }).then(response => {
+        if (response.ok) {
+            window.location.reload();
+        } else {
+            alert("Failed to remove security key -- double check your code and try again.");
+        }
+    });
+}
+
 const recyclePaymentID = () => {
     let confirmMsg = "Are you sure you want to recycle your payment ID? " +
         "This will remove all records of past payments you've made.";
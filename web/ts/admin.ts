@@ -44,11 +44,36 @@ const generateUserActionsHTML = (userInfo: AdminUserInfoResponse): HTMLDivElemen
     userInfoElement.innerText = `ID: ${userInfo.id}
 Email: ${userInfo.email}
 Storage Used: ${userInfo.storageUsed}
-Send Used: ${userInfo.sendUsed}`;
+Send Used: ${userInfo.sendUsed}
+Locked: ${userInfo.locked ? "Yes" : "No"}`;
 
     userResponseDiv.appendChild(userInfoElement);
     userResponseDiv.appendChild(document.createElement("br"));
 
+    if (userInfo.locked) {
+        let unlockButton = document.createElement("button");
+        unlockButton.id = `unlock-user-${userInfo.id}`;
+        unlockButton.innerText = "Unlock Account";
+
+        userResponseDiv.appendChild(unlockButton);
+
+        unlockButton.addEventListener("click", () => {
+            fetch(Endpoints.format(Endpoints.AdminUserUnlock, userInfo.id), {
+                method: "POST"
+            }).then(async response => {
+                if (!response.ok) {
+                    alert("Failed to unlock user! " + await response.text());
+                } else {
+                    alert("The user's account has been unlocked!");
+                    unlockButton.remove();
+                }
+            }).catch(error => {
+                alert("Failed to unlock user");
+                console.error(error);
+            });
+        });
+    }
+
     let deleteBtnID = `delete-user-${userInfo.id}`;
     let deleteButton = document.createElement("button");
     deleteButton.id = deleteBtnID;
@@ -17,6 +17,10 @@ export class VaultFolderCache {
         this.cache[folderID] = response
     }
 
+    remove = (folderID: string) => {
+        delete this.cache[folderID];
+    }
+
     addFolder = (folderID: string, folder: interfaces.VaultFolder) => {
         this.cache[folderID].folders.unshift(folder);
     }
@@ -43,11 +43,21 @@ const submitChangeEmail = async () => {
     let password = passwordInput.value;
     let newEmail = newEmailInput.value;
 
-    let oldUserKey = await crypto.generateUserKey(identifier, password);
-    let oldLoginKeyHash = await crypto.generateLoginKeyHash(oldUserKey, password);
+    let kdf;
+    try {
+        kdf = await crypto.fetchKDFParams(identifier);
+    } catch (e) {
+        showMessage(e.message, true);
+        disableInputs(false);
+        return;
+    }
+
+    let oldUserKey = await crypto.generateUserKey(identifier, password, kdf);
+    let oldLoginKeyHash = await crypto.generateLoginKeyHash(oldUserKey, password, kdf);
 
-    let newUserKey = await crypto.generateUserKey(newEmail, password);
-    let newLoginKeyHash = await crypto.generateLoginKeyHash(newUserKey, password);
+    let newKDF = crypto.defaultKDFParams();
+    let newUserKey = await crypto.generateUserKey(newEmail, password, newKDF);
+    let newLoginKeyHash = await crypto.generateLoginKeyHash(newUserKey, password, newKDF);
 
     let protectedKeyResponse = await fetch(Endpoints.ProtectedKey.path);
     let protectedKey = new ProtectedKeyResponse(
@@ -69,6 +79,7 @@ const submitChangeEmail = async () => {
     changeEmail.newLoginKeyHash = newLoginKeyHash;
     changeEmail.protectedKey = newProtectedKey;
     changeEmail.newEmail = newEmail;
+    changeEmail.kdf = newKDF;
 
     let changeID = window.location.href.split("/").pop()
     fetch(Endpoints.format(Endpoints.ChangeEmail, changeID), {
@@ -47,14 +47,16 @@ const submitPasswordChange = async () => {
     }
 
     let oldLoginKeyHash, newLoginKeyHash, newProtectedKey;
+    let newKDF = crypto.defaultKDFParams();
     try {
-        let oldUserKey = await crypto.generateUserKey(id.value, oldPw.value);
-        oldLoginKeyHash = await crypto.generateLoginKeyHash(oldUserKey, oldPw.value);
+        let kdf = await crypto.fetchKDFParams(id.value);
+        let oldUserKey = await crypto.generateUserKey(id.value, oldPw.value, kdf);
+        oldLoginKeyHash = await crypto.generateLoginKeyHash(oldUserKey, oldPw.value, kdf);
 
         let privateKey = await crypto.decryptChunk(oldUserKey, protectedKey);
 
-        let newUserKey = await crypto.generateUserKey(id.value, newPw.value);
-        newLoginKeyHash = await crypto.generateLoginKeyHash(newUserKey, newPw.value);
+        let newUserKey = await crypto.generateUserKey(id.value, newPw.value, newKDF);
+        newLoginKeyHash = await crypto.generateLoginKeyHash(newUserKey, newPw.value, newKDF);
 
         newProtectedKey = await crypto.encryptChunk(newUserKey, privateKey);
     } catch (error) {
@@ -67,6 +69,7 @@ const submitPasswordChange = async () => {
     changePassword.oldLoginKeyHash = oldLoginKeyHash;
     changePassword.newLoginKeyHash = newLoginKeyHash;
     changePassword.protectedKey = newProtectedKey;
+    changePassword.kdf = newKDF;
 
     fetch(Endpoints.ChangePassword.path, {
         method: "PUT",
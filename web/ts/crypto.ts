@@ -1,4 +1,6 @@
 import * as constants from "./constants.js";
+import { Endpoints } from "./endpoints.js";
+import { KDFParams } from "./interfaces.js";
 
 // @ts-ignore;
 export let webcrypto;
@@ -155,6 +157,99 @@ export const decryptRSA = async (
     return new Uint8Array(decrypted);
 }
 
+/**
+ * encryptWithPublicKey encrypts a Uint8Array (typically a folder or file key)
+ * using a user's public key, with the scheme that matches the key's type. Data
+ * encrypted with an X25519 key is prefixed with the scheme version and the
+ * ephemeral public key used to derive the encryption key.
+ * @param key {CryptoKey} - the X25519 or RSA-OAEP public key
+ * @param data {Uint8Array} - the data to encrypt
+ * @returns {Promise<Uint8Array>}
+ */
+export const encryptWithPublicKey = async (
+    key: CryptoKey,
+    data: Uint8Array,
+): Promise<Uint8Array> => {
+    if (key.algorithm.name !== "X25519") {
+        return await encryptRSA(key, data);
+    }
+
+    let ephemeralKey = await webcrypto.subtle.generateKey(
+        { name: "X25519" }, true, ["deriveBits"]);
+    let ephemeralPublicKey = await exportKey(ephemeralKey.publicKey, "raw");
+    let aesKey = await deriveX25519Key(
+        ephemeralKey.privateKey, key, ephemeralPublicKey);
+    let encrypted = await encryptChunk(aesKey, data);
+
+    let merged = new Uint8Array(1 + ephemeralPublicKey.length + encrypted.length);
+    merged[0] = constants.KeySchemeX25519;
+    merged.set(ephemeralPublicKey, 1);
+    merged.set(encrypted, 1 + ephemeralPublicKey.length);
+
+    return merged;
+}
+
+/**
+ * decryptWithPrivateKey decrypts data that was encrypted using
+ * encryptWithPublicKey with the matching public key
+ * @param key {CryptoKey} - the X25519 or RSA-OAEP private key
+ * @param data {Uint8Array} - the data to decrypt
+ * @returns {Promise<Uint8Array>}
+ */
+export const decryptWithPrivateKey = async (
+    key: CryptoKey,
+    data: Uint8Array,
+): Promise<Uint8Array> => {
+    if (key.algorithm.name !== "X25519") {
+        return await decryptRSA(key, data);
+    }
+
+    let headerSize = 1 + constants.X25519KeySize;
+    if (data.length <= headerSize || data[0] !== constants.KeySchemeX25519) {
+        throw new Error("Invalid X25519 encrypted data");
+    }
+
+    let ephemeralPublicKey = data.slice(1, headerSize);
+    let ephemeralKey = await webcrypto.subtle.importKey(
+        "raw", ephemeralPublicKey, { name: "X25519" }, true, []);
+    let aesKey = await deriveX25519Key(key, ephemeralKey, ephemeralPublicKey);
+    let decrypted = await decryptChunk(aesKey, data.slice(headerSize));
+
+    return new Uint8Array(decrypted);
+}
+
+/**
+ * deriveX25519Key derives an AES-GCM key from the X25519 shared secret of a
+ * private and public key, using the ephemeral public key as the HKDF salt
+ * @param privateKey {CryptoKey}
+ * @param publicKey {CryptoKey}
+ * @param ephemeralPublicKey {Uint8Array} - the raw ephemeral public key
+ * @returns {Promise<CryptoKey>}
+ */
+const deriveX25519Key = async (
+    privateKey: CryptoKey,
+    publicKey: CryptoKey,
+    ephemeralPublicKey: Uint8Array,
+): Promise<CryptoKey> => {
+    let sharedSecret = await webcrypto.subtle.deriveBits(
+        { name: "X25519", public: publicKey }, privateKey, 256);
+    let hkdfKey = await webcrypto.subtle.importKey(
+        "raw", sharedSecret, "HKDF", false, ["deriveKey"]);
+
+    return await webcrypto.subtle.deriveKey(
+        {
+            name: "HKDF",
+            hash: "SHA-256",
+            salt: ephemeralPublicKey,
+            info: utf8Encode.encode("yeetfile x25519"),
+        },
+        hkdfKey,
+        { name: "AES-GCM", length: 256 },
+        false,
+        ["encrypt", "decrypt"],
+    );
+}
+
 /**
  * decryptString decrypts an encrypted string using the provided key
  * @param key {CryptoKey} - the PBKDF2 key to use for decryption
@@ -186,14 +281,212 @@ export const decryptChunk = async (
     return await webcrypto.subtle.decrypt({ name: "AES-GCM", iv }, key, fileData);
 }
 
+/**
+ * ChunkStream encrypts and decrypts the chunks of a single file's contents.
+ * Version 2 chunks use a nonce containing the format version, a random stream
+ * ID shared by every chunk in the file, and the chunk's index. The nonce and a
+ * final chunk flag are authenticated as additional data, so chunks can't be
+ * reordered, moved between files, or dropped from the end of a file. Version 1
+ * chunks (see decryptChunk) can still be decrypted.
+ */
+export class ChunkStream {
+    key: CryptoKey;
+    id: Uint8Array;
+    version: number;
+
+    constructor(key: CryptoKey, id?: Uint8Array) {
+        this.key = key;
+        this.id = id;
+        this.version = id ? constants.ChunkFormatV2 : 0;
+    }
+
+    /**
+     * Creates a ChunkStream with a new random stream ID, for encrypting a
+     * file's contents
+     * @param key {CryptoKey} - the file key
+     * @returns {ChunkStream}
+     */
+    static create = (key: CryptoKey): ChunkStream => {
+        let id = webcrypto.getRandomValues(new Uint8Array(constants.StreamIDSize));
+        return new ChunkStream(key, id);
+    }
+
+    /**
+     * Encrypts a chunk of file data using the v2 chunk format
+     * @param data {Uint8Array} - the data to encrypt
+     * @param index {number} - the 0-based index of the chunk in the file
+     * @param final {boolean} - whether this is the last chunk in the file
+     * @returns {Promise<Uint8Array>}
+     */
+    encrypt = async (
+        data: Uint8Array,
+        index: number,
+        final: boolean,
+    ): Promise<Uint8Array> => {
+        let iv = chunkNonce(this.id, index);
+        let additionalData = chunkAAD(iv, final);
+        let encrypted = await webcrypto.subtle.encrypt(
+            { name: "AES-GCM", iv, additionalData }, this.key, data);
+
+        let merged = new Uint8Array(iv.length + encrypted.byteLength);
+        merged.set(iv);
+        merged.set(new Uint8Array(encrypted), iv.length);
+
+        return merged;
+    }
+
+    /**
+     * Decrypts a chunk of file data, which is expected to be at the provided
+     * index in the file. Chunks in the v1 format are decrypted using
+     * decryptChunk, but every chunk in a file must use the same format.
+     * @param data {Uint8Array} - the encrypted chunk
+     * @param index {number} - the 0-based index of the chunk in the file
+     * @param final {boolean} - whether this is the last chunk in the file
+     * @returns {Promise<Uint8Array>}
+     */
+    decrypt = async (
+        data: Uint8Array,
+        index: number,
+        final: boolean,
+    ): Promise<Uint8Array> => {
+        let version = constants.ChunkFormatV2;
+        let id = data.slice(1, 1 + constants.StreamIDSize);
+        let decrypted: ArrayBuffer | Uint8Array;
+        try {
+            let iv = data.slice(0, IVSize);
+            if (data[0] !== constants.ChunkFormatV2 ||
+                !chunkNonce(id, index).every((b, i) => b === iv[i])) {
+                throw new Error("not a v2 chunk");
+            }
+
+            let additionalData = chunkAAD(iv, final);
+            decrypted = await webcrypto.subtle.decrypt(
+                { name: "AES-GCM", iv, additionalData },
+                this.key,
+                data.slice(IVSize));
+        } catch (_) {
+            // Legacy chunks have a random IV, so the first byte may
+            // match the v2 format version by chance
+            decrypted = await decryptChunk(this.key, data);
+            version = 1;
+            id = undefined;
+        }
+
+        if (this.version === 0) {
+            this.version = version;
+            this.id = id;
+        } else if (this.version !== version ||
+            (id && !id.every((b, i) => b === this.id[i]))) {
+            throw new Error("Chunk doesn't belong to this file");
+        }
+
+        return new Uint8Array(decrypted);
+    }
+}
+
+/**
+ * Returns the nonce for a v2 chunk: the format version, stream ID, and the
+ * chunk index as a big-endian uint32
+ * @param id {Uint8Array} - the stream ID
+ * @param index {number} - the 0-based index of the chunk in the file
+ * @returns {Uint8Array}
+ */
+const chunkNonce = (id: Uint8Array, index: number): Uint8Array => {
+    let nonce = new Uint8Array(IVSize);
+    nonce[0] = constants.ChunkFormatV2;
+    nonce.set(id, 1);
+    new DataView(nonce.buffer).setUint32(1 + constants.StreamIDSize, index);
+    return nonce;
+}
+
+/**
+ * Returns the additional data for a v2 chunk, which is the chunk's nonce
+ * followed by the final chunk flag
+ * @param nonce {Uint8Array} - the chunk nonce
+ * @param final {boolean} - whether this is the last chunk in the file
+ * @returns {Uint8Array}
+ */
+const chunkAAD = (nonce: Uint8Array, final: boolean): Uint8Array => {
+    let aad = new Uint8Array(nonce.length + 1);
+    aad.set(nonce);
+    aad[nonce.length] = final ? 1 : 0;
+    return aad;
+}
+
+/**
+ * defaultKDFParams returns the key derivation params used for new passwords
+ * @returns {KDFParams}
+ */
+export const defaultKDFParams = (): KDFParams => {
+    return new KDFParams({
+        algorithm: constants.KDFArgon2id,
+        iterations: constants.DefaultArgon2Iter,
+        memory: constants.DefaultArgon2Mem,
+    });
+}
+
+/**
+ * legacyKDFParams returns the key derivation params used by accounts created
+ * before the params were stored per user
+ * @returns {KDFParams}
+ */
+export const legacyKDFParams = (): KDFParams => {
+    return new KDFParams({
+        algorithm: constants.KDFArgon2id,
+        iterations: constants.Argon2Iter,
+        memory: constants.Argon2Mem,
+    });
+}
+
+/**
+ * isDefaultKDFParams checks if the params match the default params, otherwise
+ * the user's keys should be upgraded to the default params
+ * @param params {KDFParams}
+ * @returns {boolean}
+ */
+export const isDefaultKDFParams = (params: KDFParams): boolean => {
+    let defaults = defaultKDFParams();
+    return params.algorithm === defaults.algorithm &&
+        params.iterations === defaults.iterations &&
+        params.memory === defaults.memory;
+}
+
+/**
+ * fetchKDFParams fetches the key derivation params needed to derive a user's
+ * keys before logging in. Params weaker than the legacy params are rejected,
+ * so that the server can't weaken the user's keys.
+ * @param identifier {string} - the user's email or account ID
+ * @returns {Promise<KDFParams>}
+ */
+export const fetchKDFParams = async (identifier: string): Promise<KDFParams> => {
+    let url = `${Endpoints.KDF.path}?identifier=${encodeURIComponent(identifier)}`;
+    let response = await fetch(url);
+    if (!response.ok) {
+        throw new Error(`Error fetching key derivation params: ${await response.text()}`);
+    }
+
+    let params = new KDFParams(await response.json());
+    if (params.algorithm !== constants.KDFArgon2id ||
+        params.iterations < constants.Argon2Iter ||
+        params.iterations > constants.MaxArgon2Iter ||
+        params.memory < constants.Argon2Mem ||
+        params.memory > constants.MaxArgon2Mem) {
+        throw new Error("Invalid key derivation params");
+    }
+
+    return params;
+}
+
 /**
  * Generate an argon2 hash from a provided payload/password and salt.
  * @param payload
  * @param salt
+ * @param params {KDFParams} - the key derivation params (legacy params if omitted)
  */
 export const generateArgon2Key = async (
     payload: string,
     salt: Uint8Array,
+    params: KDFParams = legacyKDFParams(),
 ): Promise<CryptoKey> => {
     await sodium.ready;
 
@@ -201,8 +494,8 @@ export const generateArgon2Key = async (
         constants.KeySize,
         sodium.from_string(payload),
         salt,
-        constants.Argon2Iter,
-        constants.Argon2Mem * 1024 * 1024,
+        params.iterations,
+        params.memory * 1024 * 1024,
         sodium.crypto_pwhash_ALG_ARGON2ID13
     );
 
@@ -214,14 +507,16 @@ export const generateArgon2Key = async (
  * their identifier (email or account ID) as the salt.
  * @param identifier {string} - the user's email or account ID
  * @param password {string} - the user's password
+ * @param params {KDFParams} - the user's key derivation params
  * @returns {Promise<CryptoKey>}
  */
 export const generateUserKey = async (
     identifier: string,
     password: string,
+    params: KDFParams,
 ): Promise<CryptoKey> => {
     let emailHash = hashBlake2b(16, identifier);
-    return await generateArgon2Key(password, emailHash);
+    return await generateArgon2Key(password, emailHash, params);
 }
 
 /**
@@ -230,17 +525,19 @@ export const generateUserKey = async (
  * of that login key.
  * @param userKey {CryptoKey} - the user's user key from generateUserKey
  * @param password {string} - the user's password
+ * @param params {KDFParams} - the user's key derivation params
  * @returns {Promise<Uint8Array>}
  */
 export const generateLoginKeyHash = async (
     userKey: CryptoKey,
     password: string,
+    params: KDFParams,
 ): Promise<Uint8Array> => {
     let userKeyExported = await exportKey(userKey, "raw");
     let userKeyHex = toHexString(userKeyExported);
     let pwHash = hashBlake2b(16, password);
 
-    let loginKey = await generateArgon2Key(userKeyHex, new Uint8Array(pwHash));
+    let loginKey = await generateArgon2Key(userKeyHex, new Uint8Array(pwHash), params);
     let loginKeyBytes = await exportKey(loginKey, "raw");
     let loginKeyHash = await webcrypto.subtle.digest("SHA-256", loginKeyBytes);
 
@@ -391,6 +688,7 @@ export const generatePassphrase = (
 /**
  * ingestPublicKey takes the raw base64 of the user's public key and
  * converts them into a CryptoKey object that can be used for encryption.
+ * Older accounts use RSA-OAEP keys, newer accounts use X25519 keys.
  * @param publicKey {Uint8Array}
  * @param callback {function(CryptoKey)}
  */
@@ -407,7 +705,10 @@ export const ingestPublicKey = (
         },
         false,
         ["encrypt"]
-    ).catch((error: Error) => {
+    ).catch(() => {
+        return webcrypto.subtle.importKey(
+            "spki", publicKey, { name: "X25519" }, false, []);
+    }).catch((error: Error) => {
         console.error("Error re-importing vault key:", error);
     }).then((key: CryptoKey) => {
         callback(key);
@@ -434,6 +735,10 @@ export const ingestProtectedKey = (
         },
         false,
         ["decrypt"])
+        .catch(() => {
+            return webcrypto.subtle.importKey(
+                "pkcs8", protectedKey, { name: "X25519" }, false, ["deriveBits"]);
+        })
         .catch((error: Error) => {
             console.error("Error re-importing vault key:", error);
         })
@@ -443,10 +748,10 @@ export const ingestProtectedKey = (
 }
 
 /**
- * generateKeyPair generates RSA-OAEP public + private keys. The private key
- * is used for encrypting/decrypting the user's root folder, as well as folder
- * keys that are shared with the user. The public key is used by other users to
- * share folders.
+ * generateKeyPair generates X25519 public + private keys, or RSA-OAEP keys if
+ * the browser doesn't support X25519. The private key is used for
+ * encrypting/decrypting the user's root folder, as well as folder keys that are
+ * shared with the user. The public key is used by other users to share folders.
  *
  * Note that the generated key pair is marked as "extractable", since the private
  * key must be further encrypted by the user key before being sent to the server.
@@ -454,14 +759,19 @@ export const ingestProtectedKey = (
  * @returns {Promise<CryptoKeyPair>}
  */
 export const generateKeyPair = async (): Promise<CryptoKeyPair> => {
-    return await webcrypto.subtle.generateKey(
-        {
-            name: "RSA-OAEP",
-            modulusLength: 2048,
-            publicExponent: new Uint8Array([0x01, 0x00, 0x01]), // 65537
-            hash: { name: "SHA-256" }
-        }, true, ["encrypt", "decrypt"]
-    );
+    try {
+        return await webcrypto.subtle.generateKey(
+            { name: "X25519" }, true, ["deriveBits"]);
+    } catch (_) {
+        return await webcrypto.subtle.generateKey(
+            {
+                name: "RSA-OAEP",
+                modulusLength: 2048,
+                publicExponent: new Uint8Array([0x01, 0x00, 0x01]), // 65537
+                hash: { name: "SHA-256" }
+            }, true, ["encrypt", "decrypt"]
+        );
+    }
 }
 
 /**
@@ -476,7 +786,7 @@ export const unwindKeys = async (privateKey: CryptoKey, keySequence: Uint8Array[
     for (let i = 0; i < keySequence.length; i++) {
         if (!parentKey) {
             let protectedKey = keySequence[i];
-            parentKey = await decryptRSA(privateKey, protectedKey);
+            parentKey = await decryptWithPrivateKey(privateKey, protectedKey);
             continue;
         }
 
@@ -0,0 +1,202 @@
+import {Endpoints} from "../endpoints.js";
+import * as interfaces from "../interfaces.js";
+import {closeDialog} from "./dialogs.js";
+
+const acceptAction = "accept";
+const declineAction = "decline";
+const blockAction = "block";
+
+export class ShareInvitationsDialog {
+    dialog: HTMLDialogElement;
+    loading: HTMLElement;
+    empty: HTMLElement;
+    table: HTMLTableElement;
+    tableBody: HTMLTableElement;
+    blockedTable: HTMLTableElement;
+    blockedTableBody: HTMLTableElement;
+    close: HTMLButtonElement;
+
+    constructor() {
+        this.init();
+    }
+
+    init = () => {
+        this.dialog = document.getElementById("invitations-dialog") as HTMLDialogElement;
+        this.loading = document.getElementById("invitations-loading");
+        this.empty = document.getElementById("invitations-empty");
+        this.table = document.getElementById("invitations-table") as HTMLTableElement;
+        this.tableBody = document.getElementById("invitations-table-body") as HTMLTableElement;
+        this.blockedTable = document.getElementById("blocked-table") as HTMLTableElement;
+        this.blockedTableBody = document.getElementById("blocked-table-body") as HTMLTableElement;
+        this.close = document.getElementById("close-invitations") as HTMLButtonElement;
+    }
+
+    /**
+     * Display the dialog for accepting or declining invitations to files and
+     * folders that other users have shared with the current user
+     * @param callback {function()} - Callback fired when an invitation is accepted
+     */
+    show = (callback: () => void) => {
+        this.init();
+        this.loading.style.display = "inherit";
+        this.empty.style.display = "none";
+        this.table.style.display = "none";
+        this.blockedTable.style.display = "none";
+        this.tableBody.innerHTML = "";
+        this.blockedTableBody.innerHTML = "";
+
+        fetch(Endpoints.ShareInvitations.path).then(async response => {
+            this.loading.style.display = "none";
+            if (!response.ok) {
+                alert("Error fetching invitations: " + await response.text());
+                return;
+            }
+
+            let invitations = new interfaces.ShareInvitationsResponse(await response.json());
+            if (invitations.invitations.length === 0) {
+                this.empty.style.display = "inherit";
+            } else {
+                this.table.style.display = "table";
+            }
+
+            for (let invitation of invitations.invitations) {
+                await this.generateInvitationRow(invitation, callback);
+            }
+
+            if (invitations.blocked.length > 0) {
+                this.blockedTable.style.display = "table";
+            }
+
+            for (let blocked of invitations.blocked) {
+                this.generateBlockedRow(blocked);
+            }
+        });
+
+        this.close.addEventListener("click", event => {
+            event.stopPropagation();
+            closeDialog(this.dialog);
+        });
+
+        this.dialog.showModal();
+    }
+
+    generateInvitationRow = async (
+        invitation: interfaces.ShareInvitation,
+        callback: () => void,
+    ) => {
+        let row = document.createElement("tr");
+        row.id = `invitation-${invitation.id}`;
+
+        let sharer = document.createElement("td");
+        sharer.innerText = invitation.sharerName;
+
+        let fingerprint = document.createElement("td");
+        fingerprint.innerText = await keyFingerprint(invitation.publicKey);
+
+        let details = document.createElement("td");
+        details.innerText = `${invitation.isFolder ? "Folder" : "File"} ` +
+            `(${invitation.canModify ? "Read + Write" : "Read Only"})`;
+
+        let actions = document.createElement("td");
+        actions.appendChild(this.actionButton("Accept", "accent-btn", () => {
+            this.respond(invitation.id, acceptAction, row, callback);
+        }));
+        actions.appendChild(this.actionButton("Decline", "", () => {
+            this.respond(invitation.id, declineAction, row, callback);
+        }));
+        actions.appendChild(this.actionButton("Block", "destructive-btn", () => {
+            if (confirm(`Block ${invitation.sharerName}? All of their ` +
+                `pending invitations will be declined.`)) {
+                this.respond(invitation.id, blockAction, row, callback);
+            }
+        }));
+
+        row.append(sharer, fingerprint, details, actions);
+        this.tableBody.appendChild(row);
+    }
+
+    generateBlockedRow = (blocked: interfaces.BlockedUser) => {
+        let row = document.createElement("tr");
+
+        let name = document.createElement("td");
+        name.innerText = blocked.name;
+
+        let actions = document.createElement("td");
+        actions.appendChild(this.actionButton("Unblock", "", () => {
+            let endpoint = Endpoints.format(Endpoints.ShareBlocked, blocked.id);
+            fetch(endpoint, {method: "DELETE"}).then(async response => {
+                if (!response.ok) {
+                    alert("Error unblocking user: " + await response.text());
+                    return;
+                }
+
+                row.remove();
+            });
+        }));
+
+        row.append(name, actions);
+        this.blockedTableBody.appendChild(row);
+    }
+
+    actionButton = (label: string, className: string, onClick: () => void) => {
+        let button = document.createElement("button");
+        button.innerText = label;
+        button.className = className;
+        button.addEventListener("click", event => {
+            event.stopPropagation();
+            onClick();
+        });
+
+        return button;
+    }
+
+    respond = (
+        id: string,
+        action: string,
+        row: HTMLTableRowElement,
+        callback: () => void,
+    ) => {
+        let endpoint = Endpoints.format(Endpoints.ShareInvitation, id);
+        fetch(endpoint, {
+            method: "PUT",
+            headers: {
+                "Content-Type": "application/json",
+            },
+            body: JSON.stringify({action: action}),
+        }).then(async response => {
+            if (!response.ok) {
+                alert("Error updating invitation: " + await response.text());
+                return;
+            }
+
+            if (action === blockAction) {
+                // All other invitations from the sender were declined too,
+                // so the full list needs to be reloaded
+                closeDialog(this.dialog);
+                this.show(callback);
+                return;
+            }
+
+            row.remove();
+            if (action === acceptAction) {
+                callback();
+            }
+        });
+    }
+}
+
+/**
+ * Generates a fingerprint for a user's public key, matching the format used by
+ * the CLI: the first 16 bytes of the key's SHA-256 hash, as groups of 4 hex
+ * characters.
+ * @param publicKey {Uint8Array} - The public key to generate a fingerprint for
+ */
+export const keyFingerprint = async (publicKey: Uint8Array): Promise<string> => {
+    let hash = new Uint8Array(await window.crypto.subtle.digest("SHA-256", publicKey));
+    let hex = Array.from(hash.slice(0, 16))
+        .map(b => b.toString(16).padStart(2, "0"))
+        .join("")
+        .toUpperCase();
+
+    return hex.match(/.{4}/g).join(" ");
+}
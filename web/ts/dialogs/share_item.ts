@@ -5,6 +5,7 @@ export class ShareContentDialog {
     dialog: HTMLDialogElement;
     target: HTMLInputElement;
     modify: HTMLInputElement;
+    expiration: HTMLSelectElement;
 
     submit: HTMLButtonElement;
     cancel: HTMLButtonElement;
@@ -21,6 +22,7 @@ export class ShareContentDialog {
         this.dialog = document.getElementById("share-dialog") as HTMLDialogElement;
         this.target = document.getElementById("share-target") as HTMLInputElement;
         this.modify = document.getElementById("share-modify") as HTMLInputElement;
+        this.expiration = document.getElementById("share-expiration") as HTMLSelectElement;
 
         this.submit = document.getElementById("submit-share") as HTMLButtonElement;
         this.cancel = document.getElementById("cancel-share") as HTMLButtonElement;
@@ -73,7 +75,8 @@ export class ShareContentDialog {
 
             let target = this.target.value;
             let canModify = this.modify.checked;
-            transfer.shareItem(target, rawKey, id, canModify, isFolder).then(response => {
+            let expiration = this.expiration.value;
+            transfer.shareItem(target, rawKey, id, canModify, expiration, isFolder).then(response => {
                 let name = target;
                 if (!target.includes("@")) {
                     name = "*" + name.substring(name.length - 4, name.length);
@@ -81,7 +84,7 @@ export class ShareContentDialog {
                 generateShareRow(
                     id,
                     this.tableBody,
-                    {id: response.id, recipientName: name},
+                    {id: response.id, recipientName: name, canModify: canModify, expiration: response.expiration},
                     isFolder,
                     callback);
                 updateButton(this.submit, false, "Share");
@@ -105,6 +108,7 @@ const generateShareRow = (id, tableBody, recipient, isFolder, callback) => {
     let row = `<tr id="share-${recipient.id}">
 <td>${recipient.recipientName}</td>
 <td><input id="can-modify-${recipient.id}" type="checkbox" ${recipient.canModify ? "checked" : ""}></td>
+<td>${formatShareExpiration(recipient.expiration)}</td>
 <td><img id="remove-share-${recipient.id}" class="small-icon red-icon" src="/static/icons/remove.svg"></td>
 </tr>`;
 
@@ -130,4 +134,14 @@ const generateShareRow = (id, tableBody, recipient, isFolder, callback) => {
 
         }
     });
-}
\ No newline at end of file
+}
+
+const formatShareExpiration = (expiration: string | Date | undefined): string => {
+    let date = expiration ? new Date(expiration) : undefined;
+    if (!date || date.getFullYear() <= 1) {
+        // Go's zero time is used for shares without an expiration
+        return "Never";
+    }
+
+    return date.toLocaleString();
+}
@@ -237,7 +237,7 @@ export class VaultPassDialog {
      * @param folderID {string} - the vault folder ID
      * @param folderKey {CryptoKey} - the vault folder key
      * @param encFn {(CryptoKey, Uint8Array) => Uint8Array} - the function for
-     * encrypting the item's key (AES-GCM or RSA).
+     * encrypting the item's key (AES-GCM or the user's public key).
      * @param callback {(PackagedPassEntry)} - The callback for successful submissions
      */
     create = (
@@ -2,6 +2,8 @@ import * as crypto from "./crypto.js";
 import * as transfer from "./transfer.js";
 import * as interfaces from "./interfaces.js";
 import {Endpoints} from "./endpoints.js";
+import {renderMarkdown} from "./markdown.js";
+import {TextFormatCode, TextFormatMarkdown} from "./constants.js";
 
 let timeoutInterval;
 
@@ -48,7 +50,7 @@ const getSecret = (): string => {
     return secret;
 }
 
-const showDownload = (name, download, key) => {
+const showDownload = async (name, download, key) => {
     let downloadBtn = document.getElementById("download-nopass") as HTMLButtonElement;
 
     let loading = document.getElementById("loading");
@@ -78,6 +80,12 @@ const showDownload = (name, download, key) => {
     let size = document.getElementById("size");
     size.textContent = calcFileSize(download.size);
 
+    let manifest: interfaces.SendManifest;
+    if (download.manifest && download.manifest.length > 0) {
+        manifest = await decryptManifest(key, download.manifest);
+        showFiles(manifest);
+    }
+
     let downloadDiv = document.getElementById("download-prompt-div");
     downloadDiv.style.display = "inherit";
 
@@ -103,12 +111,34 @@ const showDownload = (name, download, key) => {
 
         if (download.id.startsWith("text_")) {
             downloadText(download, key, downloadCallback);
+        } else if (manifest) {
+            transfer.downloadSentBundle(manifest.files, download, key, downloadCallback, () => {});
         } else {
             transfer.downloadSentFile(name, download, key, downloadCallback, () => {});
         }
     })
 }
 
+const decryptManifest = async (
+    key: CryptoKey,
+    encManifest: Uint8Array,
+): Promise<interfaces.SendManifest> => {
+    let manifestJSON = await crypto.decryptString(key, encManifest);
+    return new interfaces.SendManifest(JSON.parse(manifestJSON));
+}
+
+const showFiles = (manifest: interfaces.SendManifest) => {
+    let filesDiv = document.getElementById("files-div");
+    filesDiv.style.display = "inherit";
+
+    let fileList = document.getElementById("files");
+    for (let file of manifest.files) {
+        let item = document.createElement("li");
+        item.textContent = `${file.path} (${calcFileSize(file.size)})`;
+        fileList.appendChild(item);
+    }
+}
+
 const decryptName = async (key, name) => {
     let nameBytes = hexToBytes(name);
     return await crypto.decryptString(key, nameBytes);
@@ -195,19 +225,35 @@ const promptPassword = (download) => {
     });
 }
 
-const downloadText = (download, key, callback) => {
-    const fetch = () => {
+const downloadText = async (download, key, callback) => {
+    let format = "";
+    if (download.format && download.format.length > 0) {
+        format = await crypto.decryptString(key, download.format);
+    }
+
+    // Larger text sends are uploaded in chunks, which are fetched in order
+    let chunks: Uint8Array[] = [];
+    let stream = new crypto.ChunkStream(key);
+    const fetch = (chunkNum: number) => {
         let xhr = new XMLHttpRequest();
-        let url = Endpoints.format(Endpoints.DownloadSendFileData, download.id);
+        let url = Endpoints.format(Endpoints.DownloadSendFileData, download.id, String(chunkNum));
         xhr.open("GET", url, true);
         xhr.responseType = "blob";
 
         xhr.onreadystatechange = async () => {
             if (xhr.readyState === 4 && xhr.status === 200) {
                 let data = new Uint8Array(await xhr.response.arrayBuffer());
-                let decryptedChunk = await crypto.decryptChunk(key, data);
-                let decryptedText = new TextDecoder().decode(decryptedChunk);
-                displayText(decryptedText);
+                let final = chunkNum >= download.chunks;
+                chunks.push(await stream.decrypt(data, chunkNum - 1, final));
+                if (!final) {
+                    fetch(chunkNum + 1);
+                    return;
+                }
+
+                let decoder = new TextDecoder();
+                let decryptedText = chunks.map(chunk => decoder.decode(chunk, {stream: true})).join("") +
+                    decoder.decode();
+                displayText(decryptedText, format);
                 callback(true);
             } else if (xhr.readyState === 4 && xhr.status !== 200) {
                 alert(`Error ${xhr.status}: ${xhr.responseText}`);
@@ -218,15 +264,30 @@ const downloadText = (download, key, callback) => {
         xhr.send();
     }
 
-    fetch();
+    fetch(1);
 }
 
-const displayText = (text) => {
+const displayText = (text: string, format: string) => {
     let plaintextDiv = document.getElementById("plaintext-div");
     let plaintextContent = document.getElementById("plaintext-content");
 
     plaintextDiv.style.display = "initial";
-    plaintextContent.innerText = text;
+    if (format === TextFormatMarkdown) {
+        plaintextContent.appendChild(renderMarkdown(text));
+    } else if (format.startsWith(TextFormatCode)) {
+        let pre = document.createElement("pre");
+        let code = document.createElement("code");
+        let language = format.slice(TextFormatCode.length);
+        if (language) {
+            code.className = `language-${language}`;
+        }
+
+        code.textContent = text;
+        pre.appendChild(code);
+        plaintextContent.appendChild(pre);
+    } else {
+        plaintextContent.innerText = text;
+    }
 }
 
 if (document.readyState !== "loading") {
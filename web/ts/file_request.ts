@@ -0,0 +1,148 @@
+import * as crypto from "./crypto.js";
+import * as transfer from "./transfer.js";
+import * as interfaces from "./interfaces.js";
+import {Endpoints} from "./endpoints.js";
+
+const init = () => {
+    let id = window.location.pathname.split("/").slice(-1)[0];
+    let url = Endpoints.format(Endpoints.FileRequest, id);
+
+    fetch(url).then(async response => {
+        if (!response.ok) {
+            alert(`Error ${response.status}: ${await response.text()}`);
+            window.location.assign("/");
+            return;
+        }
+
+        let info = new interfaces.FileRequestInfo(await response.json());
+        crypto.ingestPublicKey(info.publicKey, publicKey => {
+            showRequest(info, publicKey);
+        });
+    }).catch(error => {
+        console.error(error);
+        alert("Error fetching file request");
+    });
+};
+
+const showRequest = (info: interfaces.FileRequestInfo, publicKey: CryptoKey) => {
+    let loading = document.getElementById("loading");
+    loading.style.display = "none";
+
+    let prompt = document.getElementById("download-prompt-div");
+    prompt.style.display = "inherit";
+
+    let progressBar = document.getElementById("item-bar");
+    progressBar.style.display = "none";
+
+    let maxSize = document.getElementById("max-size");
+    maxSize.textContent = info.maxSize > 0 ? calcFileSize(info.maxSize) : "No limit";
+
+    let remaining = document.getElementById("remaining");
+    remaining.textContent = info.remaining >= 0 ? String(info.remaining) : "No limit";
+
+    let expiration = document.getElementById("expiration");
+    expiration.textContent = info.expiration.getFullYear() > 1 ?
+        info.expiration.toLocaleString() :
+        "Never";
+
+    let fileInput = document.getElementById("request-files") as HTMLInputElement;
+    let uploadBtn = document.getElementById("request-upload") as HTMLButtonElement;
+
+    fileInput.addEventListener("change", () => {
+        uploadBtn.disabled = fileInput.files.length === 0;
+    });
+
+    uploadBtn.addEventListener("click", async () => {
+        let files = Array.from(fileInput.files);
+        if (info.remaining >= 0 && files.length > info.remaining) {
+            alert(`Only ${info.remaining} more file(s) can be uploaded`);
+            return;
+        }
+
+        for (let file of files) {
+            if (info.maxSize > 0 && file.size > info.maxSize) {
+                alert(`${file.name} exceeds the max file size`);
+                return;
+            }
+        }
+
+        uploadBtn.disabled = true;
+        fileInput.disabled = true;
+        progressBar.style.display = "inherit";
+
+        for (let i = 0; i < files.length; i++) {
+            setStatus(`Uploading ${files[i].name}... (${i + 1} / ${files.length})`);
+            let success = await uploadFile(info.id, files[i], publicKey);
+            if (!success) {
+                setStatus(`Error uploading ${files[i].name}`);
+                progressBar.style.display = "none";
+                return;
+            }
+
+            if (info.remaining > 0) {
+                info.remaining -= 1;
+                remaining.textContent = String(info.remaining);
+            }
+        }
+
+        progressBar.style.display = "none";
+        setStatus("Finished uploading!");
+        fileInput.value = "";
+        fileInput.disabled = info.remaining === 0;
+    });
+}
+
+/**
+ * Encrypts and uploads a single file using the file request. The file key is
+ * encrypted with the request owner's public key.
+ * @param requestID {string} - The file request ID
+ * @param file {File} - The file to upload
+ * @param publicKey {CryptoKey} - The public key of the request owner
+ * @returns {Promise<boolean>} - True if the file was uploaded successfully
+ */
+const uploadFile = async (
+    requestID: string,
+    file: File,
+    publicKey: CryptoKey,
+): Promise<boolean> => {
+    let key = crypto.generateRandomKey();
+    let protectedKey = await crypto.encryptWithPublicKey(publicKey, key);
+    let importedKey = await crypto.importKey(key);
+
+    let encryptedName = await crypto.encryptString(importedKey, file.name);
+    let metadata = new interfaces.VaultUpload({
+        name: toHexString(encryptedName),
+        length: file.size,
+        chunks: getNumChunks(file.size),
+        folderID: "",
+        protectedKey: Array.from(protectedKey),
+    });
+
+    return new Promise(resolve => {
+        transfer.uploadFileRequestMetadata(requestID, metadata, id => {
+            transfer.uploadFileRequestChunks(requestID, id, file, importedKey, finished => {
+                if (finished) {
+                    resolve(true);
+                }
+            }, errorMessage => {
+                alert(errorMessage);
+                resolve(false);
+            });
+        }, () => {
+            resolve(false);
+        });
+    });
+}
+
+const setStatus = (msg: string) => {
+    let status = document.getElementById("request-status");
+    status.textContent = msg;
+}
+
+if (document.readyState !== "loading") {
+    init();
+} else {
+    document.addEventListener("DOMContentLoaded", () => {
+        init();
+    });
+}
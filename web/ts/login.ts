@@ -1,6 +1,15 @@
 import * as crypto from "./crypto.js";
+import * as webauthn from "./webauthn.js";
 import { Endpoints } from "./endpoints.js";
-import { Login, LoginResponse } from "./interfaces.js";
+import {
+    ChangePassword,
+    KDFParams,
+    Login,
+    LoginResponse,
+    WebAuthnAssertion,
+    WebAuthnAssertionOptions,
+    WebAuthnAssertionRequest,
+} from "./interfaces.js";
 
 const useVaultPasswordKey = "UseVaultPassword";
 const useVaultPasswordValue = "true";
@@ -10,8 +19,11 @@ let twoFactorDialog;
 let vaultPasswordCB;
 let loginBtn;
 let buttonLabel;
+let ssoTicket = "";
 
 const init = () => {
+    setupSSO();
+
     vaultPasswordCB = document.getElementById("vault-pass-cb");
     vaultPasswordDialog = document.getElementById("vault-pass-dialog");
     twoFactorDialog = document.getElementById("two-factor-dialog");
@@ -42,6 +54,27 @@ const init = () => {
     });
 }
 
+/**
+ * setupSSO shows the login form once the user has been redirected back from
+ * the identity provider, with their email filled in from their SSO login
+ */
+const setupSSO = () => {
+    let params = new URLSearchParams(window.location.search);
+    ssoTicket = params.get("sso") || "";
+
+    let ssoDiv = document.getElementById("sso-div");
+    if (!ssoDiv || !ssoTicket) {
+        return;
+    }
+
+    ssoDiv.style.display = "none";
+    document.getElementById("login-div").style.display = "inherit";
+
+    let identifier = document.getElementById("identifier") as HTMLInputElement;
+    identifier.value = params.get("email") || "";
+    identifier.readOnly = true;
+}
+
 const resetLoginButton = () => {
     let btn = document.getElementById("login-btn") as HTMLButtonElement;
     btn.disabled = false;
@@ -66,7 +99,7 @@ const disableInputs = (disabled: boolean) => {
     forgotPw.style.display = disabled ? "none" : "inline";
 }
 
-const login = async (twoFactorCode: string) => {
+const login = async (twoFactorCode: string, useSecurityKey: boolean = false) => {
     disableInputs(true);
 
     let identifier = document.getElementById("identifier") as HTMLInputElement;
@@ -76,8 +109,17 @@ const login = async (twoFactorCode: string) => {
         return;
     }
 
-    let userKey = await crypto.generateUserKey(identifier.value, password.value);
-    let loginKeyHash = await crypto.generateLoginKeyHash(userKey, password.value);
+    let kdf: KDFParams;
+    try {
+        kdf = await crypto.fetchKDFParams(identifier.value);
+    } catch (error) {
+        showMessage(error.message, true);
+        disableInputs(false);
+        return;
+    }
+
+    let userKey = await crypto.generateUserKey(identifier.value, password.value, kdf);
+    let loginKeyHash = await crypto.generateLoginKeyHash(userKey, password.value, kdf);
 
     let url = new URL(window.location.href);
     let params = new URLSearchParams(url.search);
@@ -90,6 +132,19 @@ const login = async (twoFactorCode: string) => {
     loginBody.loginKeyHash = loginKeyHash;
     loginBody.identifier = identifier.value;
     loginBody.code = twoFactorCode;
+    loginBody.ssoTicket = ssoTicket;
+
+    if (useSecurityKey) {
+        try {
+            loginBody.webauthn = await getSecurityKeyAssertion(
+                identifier.value,
+                loginKeyHash);
+        } catch (error) {
+            showMessage(`Security key error: ${error.message}`, true);
+            disableInputs(false);
+            return;
+        }
+    }
 
     fetch(Endpoints.Login.path, {
         method: "POST",
@@ -109,6 +164,18 @@ const login = async (twoFactorCode: string) => {
                 userKey, loginResponse.protectedKey));
             let pubKey = loginResponse.publicKey;
 
+            if (!crypto.isDefaultKDFParams(kdf)) {
+                // The upgrade is attempted again on the next login if it fails
+                await upgradeKDFParams(
+                    identifier.value,
+                    password.value,
+                    loginKeyHash,
+                    privKey,
+                ).catch(error => {
+                    console.warn("error upgrading key derivation params", error);
+                });
+            }
+
             if (vaultPasswordCB.checked) {
                 showVaultPassDialog(privKey, pubKey);
             } else {
@@ -128,6 +195,69 @@ const login = async (twoFactorCode: string) => {
     });
 }
 
+/**
+ * getSecurityKeyAssertion requests a challenge from the server and signs it
+ * with one of the user's security keys
+ * @param identifier {string} - the user's email or account ID
+ * @param loginKeyHash {Uint8Array} - the user's login key hash
+ * @returns {Promise<WebAuthnAssertion>}
+ */
+const getSecurityKeyAssertion = async (
+    identifier: string,
+    loginKeyHash: Uint8Array,
+): Promise<WebAuthnAssertion> => {
+    let assertionRequest = new WebAuthnAssertionRequest();
+    assertionRequest.identifier = identifier;
+    assertionRequest.loginKeyHash = loginKeyHash;
+
+    let response = await fetch(Endpoints.WebAuthnAssert.path, {
+        method: "POST",
+        body: JSON.stringify(assertionRequest, jsonReplacer)
+    });
+
+    if (!response.ok) {
+        throw new Error(await response.text());
+    }
+
+    let options = new WebAuthnAssertionOptions(await response.json());
+    return webauthn.getSecurityKeyAssertion(options);
+}
+
+/**
+ * upgradeKDFParams re-derives the user's keys using the default key derivation
+ * params, and replaces their login key hash and protected key with the new
+ * values. The user's password is unchanged.
+ * @param identifier {string} - the user's email or account ID
+ * @param password {string} - the user's password
+ * @param loginKeyHash {Uint8Array} - the login key hash derived with the previous params
+ * @param privKey {Uint8Array} - the user's decrypted private key
+ */
+const upgradeKDFParams = async (
+    identifier: string,
+    password: string,
+    loginKeyHash: Uint8Array,
+    privKey: Uint8Array,
+) => {
+    let kdf = crypto.defaultKDFParams();
+    let userKey = await crypto.generateUserKey(identifier, password, kdf);
+
+    let changePassword = new ChangePassword();
+    changePassword.oldLoginKeyHash = loginKeyHash;
+    changePassword.newLoginKeyHash = await crypto.generateLoginKeyHash(userKey, password, kdf);
+    changePassword.protectedKey = await crypto.encryptChunk(userKey, privKey);
+    changePassword.kdf = kdf;
+    changePassword.kdfUpgrade = true;
+
+    let response = await fetch(Endpoints.ChangePassword.path, {
+        method: "PUT",
+        body: JSON.stringify(changePassword, jsonReplacer)
+    });
+
+    if (!response.ok) {
+        throw new Error(await response.text());
+    }
+}
+
 const isValidIdentifier = (identifier) => {
     if (identifier.includes("@")) {
         return true;
@@ -147,6 +277,15 @@ const showTwoFactorDialog = () => {
     let codeInput = document.getElementById("two-factor-code") as HTMLInputElement;
     let submit = document.getElementById("submit-2fa") as HTMLButtonElement;
     let cancel = document.getElementById("cancel-2fa") as HTMLButtonElement;
+    let securityKey = document.getElementById("security-key-2fa") as HTMLButtonElement;
+
+    if (webauthn.isSecurityKeySupported()) {
+        securityKey.className = "";
+        securityKey.onclick = () => {
+            dialog.close();
+            login("", true);
+        };
+    }
 
     codeInput.addEventListener("keydown", (event: KeyboardEvent) => {
         if (event.key === "Enter") {
@@ -0,0 +1,124 @@
+const headingPattern = /^(#{1,6})\s+(.*)$/;
+const listPattern = /^\s*[-*+]\s+(.*)$/;
+const rulePattern = /^\s*([-*_])(\s*[-*_]){2,}\s*$/;
+const inlinePattern = /(`[^`]+`)|(\*\*[^*]+\*\*|__[^_]+__)|(\*[^*\s][^*]*\*|_[^_\s][^_]*_)|(\[[^\]]+\]\([^)\s]+\))/;
+
+/**
+ * Renders a subset of markdown (headings, lists, quotes, code blocks, links,
+ * and bold/italic/code text) as DOM elements. Text is only ever added using
+ * text nodes, so the rendered content can't include any HTML from the source.
+ * @param text {string} - The markdown text to render
+ * @returns {DocumentFragment} - The rendered markdown
+ */
+export const renderMarkdown = (text: string): DocumentFragment => {
+    let fragment = document.createDocumentFragment();
+    let codeBlock: HTMLElement = null;
+    let list: HTMLElement = null;
+    let paragraph: HTMLElement = null;
+
+    for (let line of text.split("\n")) {
+        if (line.trim().startsWith("```")) {
+            if (codeBlock) {
+                codeBlock = null;
+            } else {
+                let pre = document.createElement("pre");
+                codeBlock = document.createElement("code");
+                pre.appendChild(codeBlock);
+                fragment.appendChild(pre);
+            }
+
+            list = paragraph = null;
+            continue;
+        } else if (codeBlock) {
+            codeBlock.appendChild(document.createTextNode(line + "\n"));
+            continue;
+        }
+
+        let heading = line.match(headingPattern);
+        let listItem = line.match(listPattern);
+        if (heading) {
+            let element = document.createElement(`h${heading[1].length}`);
+            appendInline(element, heading[2]);
+            fragment.appendChild(element);
+            list = paragraph = null;
+        } else if (rulePattern.test(line)) {
+            fragment.appendChild(document.createElement("hr"));
+            list = paragraph = null;
+        } else if (listItem) {
+            if (!list) {
+                list = document.createElement("ul");
+                fragment.appendChild(list);
+            }
+
+            let item = document.createElement("li");
+            appendInline(item, listItem[1]);
+            list.appendChild(item);
+            paragraph = null;
+        } else if (line.startsWith(">")) {
+            let quote = document.createElement("blockquote");
+            appendInline(quote, line.slice(1).trim());
+            fragment.appendChild(quote);
+            list = paragraph = null;
+        } else if (line.trim().length === 0) {
+            list = paragraph = null;
+        } else {
+            if (!paragraph) {
+                paragraph = document.createElement("p");
+                fragment.appendChild(paragraph);
+            } else {
+                paragraph.appendChild(document.createElement("br"));
+            }
+
+            appendInline(paragraph, line);
+            list = null;
+        }
+    }
+
+    return fragment;
+}
+
+/**
+ * Appends text to an element, rendering inline code, bold, italic, and links
+ * @param parent {HTMLElement} - The element to append the text to
+ * @param text {string} - The text to render
+ */
+const appendInline = (parent: HTMLElement, text: string) => {
+    while (text.length > 0) {
+        let match = text.match(inlinePattern);
+        if (!match) {
+            parent.appendChild(document.createTextNode(text));
+            return;
+        }
+
+        parent.appendChild(document.createTextNode(text.slice(0, match.index)));
+
+        let token = match[0];
+        if (match[1]) {
+            let code = document.createElement("code");
+            code.textContent = token.slice(1, -1);
+            parent.appendChild(code);
+        } else if (match[2]) {
+            let bold = document.createElement("strong");
+            appendInline(bold, token.slice(2, -2));
+            parent.appendChild(bold);
+        } else if (match[3]) {
+            let italic = document.createElement("em");
+            appendInline(italic, token.slice(1, -1));
+            parent.appendChild(italic);
+        } else {
+            let [label, href] = token.slice(1, -1).split("](");
+            if (href.startsWith("https://") || href.startsWith("http://")) {
+                let link = document.createElement("a");
+                link.href = href;
+                link.rel = "noopener noreferrer";
+                link.target = "_blank";
+                link.textContent = label;
+                parent.appendChild(link);
+            } else {
+                parent.appendChild(document.createTextNode(token));
+            }
+        }
+
+        text = text.slice(match.index + token.length);
+    }
+}
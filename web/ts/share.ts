@@ -2,6 +2,7 @@ import * as crypto from "./crypto.js";
 import * as interfaces from "./interfaces.js";
 import * as transfer from "./transfer.js";
 import {Endpoints} from "./endpoints.js";
+import {MaxPlaintextLen, TextFormatCode, TextFormatPlain} from "./constants.js";
 
 type SendForm = {
     files: FileList,
@@ -11,6 +12,8 @@ type SendForm = {
     expiration: number,
     expUnits: ExpUnits,
     text: string,
+    format: string,
+    notify: boolean,
 }
 
 const init = () => {
@@ -54,13 +57,21 @@ const init = () => {
 
     let uploadTextContent = document.getElementById("upload-text-content") as HTMLInputElement;
     let uploadTextLabel = document.getElementById("upload-text-label");
-    uploadTextLabel.innerText=`Text (${uploadTextContent.value.length}/2000):`;
+    let maxTextLen = (uploadTextContent as unknown as HTMLTextAreaElement).maxLength;
+    uploadTextLabel.innerText=`Text (${uploadTextContent.value.length}/${maxTextLen}):`;
     uploadTextContent.addEventListener("input", () => {
         if (uploadTextLabel) {
-            uploadTextLabel.innerText=`Text (${uploadTextContent.value.length}/2000):`;
+            uploadTextLabel.innerText=`Text (${uploadTextContent.value.length}/${maxTextLen}):`;
         }
     });
 
+    let textFormat = document.getElementById("text-format") as HTMLSelectElement;
+    let codeLanguage = document.getElementById("code-language") as HTMLInputElement;
+    codeLanguage.style.display = "none";
+    textFormat.addEventListener("change", () => {
+        codeLanguage.style.display = textFormat.value === TextFormatCode ? "inline" : "none";
+    });
+
     let form = document.getElementById("upload-form") as HTMLFormElement;
     let nameDiv = document.getElementById("name-div") as HTMLDivElement;
     let filePicker = document.getElementById("upload") as HTMLInputElement;
@@ -150,6 +161,14 @@ const getFormValues = (): SendForm => {
     let exp = (document.getElementById("expiration") as HTMLInputElement).value;
     let unit = indexToExpUnit((document.getElementById("duration-unit") as HTMLSelectElement).selectedIndex);
     let text = (document.getElementById("upload-text-content") as HTMLTextAreaElement).value;
+    let notifyCB = document.getElementById("notify") as HTMLInputElement;
+    let format = (document.getElementById("text-format") as HTMLSelectElement).value;
+    if (format === TextFormatCode) {
+        let language = (document.getElementById("code-language") as HTMLInputElement).value;
+        format += language.trim().toLowerCase();
+    } else if (format === TextFormatPlain) {
+        format = "";
+    }
 
     // If the password checkbox isn't checked, unset password
     let usePassword = (document.getElementById("use-password") as HTMLInputElement).checked;
@@ -165,6 +184,8 @@ const getFormValues = (): SendForm => {
         expiration: exp ? parseInt(exp) : 0,
         expUnits: unit,
         text: text,
+        format: format,
+        notify: notifyCB ? notifyCB.checked : false,
     };
 }
 
@@ -269,7 +290,8 @@ const submitFormMulti = async (
         salt: Array.from(salt),
         downloads: form.downloads,
         size: size,
-        expiration: expString
+        expiration: expString,
+        notify: form.notify
     }), (id) => {
         uploadZip(id, key, zip, chunks).then(() => {
             callback();
@@ -305,7 +327,8 @@ const submitFormSingle = async (
         salt: [],
         downloads: form.downloads,
         size: file.size,
-        expiration: expString
+        expiration: expString,
+        notify: form.notify
     }), (id) => {
         let chunk = 1;
         let percent = (chunk / chunks) * 100;
@@ -341,14 +364,30 @@ const submitFormText = async (
     secret: string,
     callback: () => void,
 ) => {
-    let encryptedText = await crypto.encryptString(key, form.text);
     let encryptedName = await crypto.encryptString(key, genRandomString(10));
+    let encryptedFormat = form.format ?
+        await crypto.encryptString(key, form.format) :
+        new Uint8Array();
 
     let hexName = toHexString(encryptedName);
     let expString = getExpString(form.expiration, form.expUnits);
     let downloads = form.downloads;
 
-    uploadTextOnly(hexName, encryptedText, new Uint8Array(), downloads, expString, (tag) => {
+    let textBytes = new TextEncoder().encode(form.text);
+    if (textBytes.length > MaxPlaintextLen) {
+        uploadLargeText(hexName, textBytes, encryptedFormat, downloads, expString, key, (tag) => {
+            if (tag) {
+                showFileTag(tag, secret);
+                callback();
+            } else {
+                resetForm();
+            }
+        });
+        return;
+    }
+
+    let encryptedText = await crypto.encryptChunk(key, textBytes);
+    uploadTextOnly(hexName, encryptedText, encryptedFormat, downloads, expString, (tag) => {
         if (tag) {
             showFileTag(tag, secret);
             callback();
@@ -358,15 +397,59 @@ const submitFormText = async (
     });
 }
 
+/**
+ * Uploads text that exceeds MaxPlaintextLen in chunks, the same way that files
+ * are uploaded. This requires an account.
+ * @param name {string} - The pseudo-name for the text (not shown to recipient)
+ * @param text {Uint8Array} - The (unencrypted) text content
+ * @param format {Uint8Array} - The encrypted text format hint
+ * @param downloads {number} - The number of possible downloads
+ * @param exp {string} - The expiration string
+ * @param key {CryptoKey} - The key used for encrypting the text
+ * @param callback {function(string)} - The function indicating upload completion
+ */
+const uploadLargeText = (
+    name: string,
+    text: Uint8Array,
+    format: Uint8Array,
+    downloads: number,
+    exp: string,
+    key: CryptoKey,
+    callback: (string) => void,
+) => {
+    transfer.uploadSendMetadata(new interfaces.UploadMetadata({
+        name: name,
+        chunks: getNumChunks(text.length),
+        size: text.length,
+        downloads: downloads,
+        expiration: exp,
+        text: true,
+        format: Array.from(format),
+    }), (id) => {
+        let file = new File([text], "text");
+        transfer.uploadSendChunks(id, file, key, (done: boolean) => {
+            if (done) {
+                callback(id);
+            }
+        }, err => {
+            console.error(err);
+            callback("");
+        });
+    }, () => {
+        callback("");
+    });
+}
+
 const uploadZip = async (id, key, zip, chunks) => {
     let i = 0;
     let zipData = new Uint8Array(0);
+    let stream = crypto.ChunkStream.create(key);
 
     zip.generateInternalStream({type:"uint8array"}).on("data", async (data: Uint8Array) => {
         zipData = concatTypedArrays(zipData, data);
         if (zipData.length >= chunkSize) {
             let slice = zipData.subarray(0, chunkSize);
-            let blob = await crypto.encryptChunk(key, slice);
+            let blob = await stream.encrypt(slice, i, i === chunks - 1);
 
             updateProgress(`Uploading file... ${i + 1}/${chunks}`)
             transfer.sendChunk(
@@ -385,7 +468,7 @@ const uploadZip = async (id, key, zip, chunks) => {
         }
     }).on("end", async () => {
         if (zipData.length > 0) {
-            let blob = await crypto.encryptChunk(key, zipData);
+            let blob = await stream.encrypt(zipData, i, true);
             updateProgress(`Uploading file... ${i + 1}/${chunks}`);
             transfer.sendChunk(Endpoints.UploadSendFileData, blob, id, i + 1, (tag) => {
                 showFileTag(tag, "");
@@ -400,7 +483,7 @@ const uploadZip = async (id, key, zip, chunks) => {
  * Uploads text (not a file) to YeetFile Send
  * @param name {string} - The pseudo-name for the text (not shown to recipient)
  * @param text {Uint8Array} - The encrypted text content
- * @param salt {Uint8Array} - The salt used when encrypting the text
+ * @param format {Uint8Array} - The encrypted text format hint
  * @param downloads {number} - The number of possible downloads
  * @param exp {string} - The expiration string
  * @param callback {function(string)} - The function indicating upload completion
@@ -408,7 +491,7 @@ const uploadZip = async (id, key, zip, chunks) => {
 const uploadTextOnly = (
     name: string,
     text: Uint8Array,
-    salt: Uint8Array,
+    format: Uint8Array,
     downloads: number,
     exp: string,
     callback: (string) => void) => {
@@ -428,10 +511,11 @@ const uploadTextOnly = (
 
     xhr.send(JSON.stringify({
         name: name,
-        salt: Array.from(salt),
+        salt: [],
         downloads: downloads,
         expiration: exp,
         text: Array.from(text),
+        format: Array.from(format),
         size: text.length,
     }));
 }
@@ -528,14 +612,24 @@ const setupTypeToggles = () => {
     let uploadFileBtn = document.getElementById("upload-file-btn");
     let uploadFileRow = document.getElementById("upload-file-row");
 
+    // Notifications are only available for file uploads, since text uploads
+    // aren't associated with an account
+    let notifyDiv = document.getElementById("notify-div");
+
     uploadTextBtn.addEventListener("click", () => {
         uploadTextRow.style.display = "contents";
         uploadFileRow.style.display = "none";
+        if (notifyDiv) {
+            notifyDiv.style.display = "none";
+        }
     });
 
     uploadFileBtn.addEventListener("click", () => {
         uploadTextRow.style.display = "none";
         uploadFileRow.style.display = "contents";
+        if (notifyDiv) {
+            notifyDiv.style.display = "inherit";
+        }
     });
 }
 
@@ -6,11 +6,13 @@ import * as interfaces from "./interfaces.js";
 let emailToggle;
 let idToggle;
 let serverPassword;
+let ssoTicket = "";
 
 const verifyButtonID = "verify-account";
 
 const init = () => {
     setupToggles();
+    setupSSO();
 
     serverPassword = document.getElementById("server-password") as HTMLInputElement;
 
@@ -63,6 +65,27 @@ const setupToggles = () => {
     });
 }
 
+/**
+ * setupSSO shows the signup form once the user has been redirected back from
+ * the identity provider, using the email from their SSO login
+ */
+const setupSSO = () => {
+    let params = new URLSearchParams(window.location.search);
+    ssoTicket = params.get("sso") || "";
+
+    let ssoDiv = document.getElementById("sso-div");
+    if (!ssoDiv || !ssoTicket) {
+        return;
+    }
+
+    ssoDiv.style.display = "none";
+    document.getElementById("signup-fieldset").style.display = "inherit";
+
+    let emailInput = document.getElementById("email") as HTMLInputElement;
+    emailInput.value = params.get("email") || "";
+    emailInput.readOnly = true;
+}
+
 /**
  * generateKeys generates the necessary keys for using YeetFile
  * @param identifier {string} - either email or account ID
@@ -79,21 +102,23 @@ const generateKeys = async (
         pubKey: Uint8Array,
     ) => void,
 ) => {
-    let userKey = await crypto.generateUserKey(identifier, password);
-    let loginKeyHash = await crypto.generateLoginKeyHash(userKey, password);
+    let kdf = crypto.defaultKDFParams();
+    let userKey = await crypto.generateUserKey(identifier, password, kdf);
+    let loginKeyHash = await crypto.generateLoginKeyHash(userKey, password, kdf);
     let keyPair = await crypto.generateKeyPair();
     let publicKey = await crypto.exportKey(keyPair.publicKey, "spki");
     let privateKey = await crypto.exportKey(keyPair.privateKey, "pkcs8");
     let protectedPrivateKey = await crypto.encryptChunk(userKey, privateKey);
 
     let vaultFolderKey = await crypto.generateRandomKey();
-    let protectedVaultFolderKey = await crypto.encryptRSA(keyPair.publicKey, vaultFolderKey);
+    let protectedVaultFolderKey = await crypto.encryptWithPublicKey(keyPair.publicKey, vaultFolderKey);
 
     let signup = new interfaces.Signup();
     signup.loginKeyHash = loginKeyHash;
     signup.publicKey = publicKey;
     signup.protectedPrivateKey = protectedPrivateKey;
     signup.protectedVaultFolderKey = protectedVaultFolderKey;
+    signup.kdf = kdf;
 
     keyCallback(signup, privateKey, publicKey);
 }
@@ -170,7 +195,13 @@ const submitSignupForm = (
 
     xhr.onreadystatechange = () => {
         if (xhr.readyState === 4 && xhr.status === 200) {
-            if (email && email.length > 0) {
+            if (ssoTicket) {
+                // SSO accounts don't need to verify their email, and the
+                // signup ticket is replaced with one for logging in
+                let response = JSON.parse(xhr.responseText);
+                ssoTicket = response.ssoTicket;
+                ssoLogin(email, userKeys.loginKeyHash);
+            } else if (email && email.length > 0) {
                 window.location.assign(Endpoints.HTMLVerifyEmail.path + "?email=" + email);
             } else {
                 let response = JSON.parse(xhr.responseText);
@@ -193,10 +224,33 @@ const submitSignupForm = (
     sendData.identifier = email;
     sendData.serverPassword = serverPassword.value;
     sendData.passwordHint = hintInput.value;
+    sendData.ssoTicket = ssoTicket;
 
     xhr.send(JSON.stringify(sendData, jsonReplacer));
 }
 
+/**
+ * Logs the user in after creating an account with their SSO login
+ * @param email {string}
+ * @param loginKeyHash {Uint8Array}
+ */
+const ssoLogin = (email: string, loginKeyHash: Uint8Array) => {
+    let login = new interfaces.Login();
+    login.identifier = email;
+    login.loginKeyHash = loginKeyHash;
+    login.ssoTicket = ssoTicket;
+
+    fetch(Endpoints.Login.path, {
+        method: "POST", body: JSON.stringify(login, jsonReplacer)
+    }).then(async response => {
+        if (response.ok) {
+            window.location.assign(Endpoints.HTMLAccount.path);
+        } else {
+            showMessage("Error " + await response.text(), true);
+        }
+    });
+}
+
 /**
  * Generates the "captcha" for verifying account ID-only signups
  * @param id {string} - the user's new account ID
@@ -259,6 +313,7 @@ const verifyAccountID = async (id: string) => {
             body.publicKey = userKeys.publicKey;
             body.protectedPrivateKey = userKeys.protectedPrivateKey;
             body.protectedVaultFolderKey = userKeys.protectedVaultFolderKey;
+            body.kdf = userKeys.kdf;
 
             fetch(Endpoints.VerifyAccount.path, {
                 method: "POST", body: JSON.stringify(body, jsonReplacer)
@@ -83,6 +83,7 @@ const uploadChunks = async (
     const activeUploads: Set<Promise<any>> = new Set();
 
     let chunks = getNumChunks(file.size);
+    let stream = crypto.ChunkStream.create(key);
     let progressAmount = 0;
     let progressBar = document.getElementById("item-bar") as HTMLProgressElement;
     if (progressBar && chunks > 1) {
@@ -108,7 +109,7 @@ const uploadChunks = async (
             }
 
             let data = await readChunk(file, start, end);
-            let blob = await crypto.encryptChunk(key, new Uint8Array(data));
+            let blob = await stream.encrypt(new Uint8Array(data), chunk, chunk === chunks - 1);
 
             sendChunk(endpoint, blob, id, chunk + 1, (response) => {
                 resolve("");
@@ -209,6 +210,7 @@ const downloadFile = (
     errorCallback: () => void,
 ) => {
     let writer = getFileWriter(name, download.size);
+    let stream = new crypto.ChunkStream(key);
 
     const fetch = (chunkNum) => {
         let xhr = new XMLHttpRequest();
@@ -219,9 +221,10 @@ const downloadFile = (
         xhr.onreadystatechange = async () => {
             if (xhr.readyState === 4 && xhr.status === 200) {
                 let data = new Uint8Array(await xhr.response.arrayBuffer());
-                crypto.decryptChunk(key, data).then(decryptedChunk => {
-                    writer.write(new Uint8Array(decryptedChunk)).then(() => {
-                        if (chunkNum === download.chunks) {
+                let final = chunkNum === download.chunks;
+                stream.decrypt(data, chunkNum - 1, final).then(decryptedChunk => {
+                    writer.write(decryptedChunk).then(() => {
+                        if (final) {
                             writer.close().then(r => console.log(r));
                             callback(true);
                         } else {
@@ -246,16 +249,105 @@ const downloadFile = (
     fetch(1);
 }
 
+/**
+ * Downloads a multi-file send, splitting the combined stream of file contents
+ * into a separate download for each file listed in the send manifest. Chunks
+ * are fetched in order, so the send is only counted as downloaded once.
+ * @param files {interfaces.SendManifestFile[]} - The files in the send
+ * @param download {PendingDownload} - The send metadata
+ * @param key {CryptoKey} - The key to use for decrypting the file contents
+ * @param callback {function(boolean)} - A function that returns true when all
+ * files are finished downloading
+ * @param errorCallback {function()} - A callback for any download errors
+ */
+export const downloadSentBundle = (
+    files: interfaces.SendManifestFile[],
+    download: PendingDownload,
+    key: CryptoKey,
+    callback: (success: boolean) => void,
+    errorCallback: () => void,
+) => {
+    let sortedFiles = [...files].sort((a, b) => a.offset - b.offset);
+    let fileIdx = 0;
+    let writer = null;
+    let stream = new crypto.ChunkStream(key);
+
+    // Writes the decrypted chunk to each file that it contains, opening a new
+    // writer as each file starts and closing it once the file is complete
+    const writeFiles = async (chunkStart: number, data: Uint8Array, final: boolean) => {
+        let pos = chunkStart;
+        let chunkEnd = chunkStart + data.length;
+        while (fileIdx < sortedFiles.length && (pos < chunkEnd || final)) {
+            let file = sortedFiles[fileIdx];
+            if (!writer) {
+                writer = getFileWriter(file.path.split("/").pop(), file.size);
+            }
+
+            let fileEnd = file.offset + file.size;
+            let stop = Math.min(fileEnd, chunkEnd);
+            if (stop > pos) {
+                await writer.write(data.slice(pos - chunkStart, stop - chunkStart));
+                pos = stop;
+            }
+
+            if (pos < fileEnd) {
+                break;
+            }
+
+            await writer.close();
+            writer = null;
+            fileIdx++;
+        }
+    }
+
+    const fetch = (chunkNum) => {
+        let xhr = new XMLHttpRequest();
+        let url = Endpoints.format(Endpoints.DownloadSendFileData, download.id, chunkNum);
+        xhr.open("GET", url, true);
+        xhr.responseType = "blob";
+
+        xhr.onreadystatechange = async () => {
+            if (xhr.readyState === 4 && xhr.status === 200) {
+                let data = new Uint8Array(await xhr.response.arrayBuffer());
+                let final = chunkNum === download.chunks;
+                stream.decrypt(data, chunkNum - 1, final).then(async decryptedChunk => {
+                    let chunkStart = (chunkNum - 1) * chunkSize;
+                    await writeFiles(chunkStart, decryptedChunk, final);
+                    if (final) {
+                        callback(true);
+                    } else {
+                        fetch(chunkNum + 1);
+                    }
+                }).catch(err => {
+                    console.error(err);
+                    errorCallback();
+                });
+            } else if (xhr.readyState === 4 && xhr.status !== 200) {
+                alert(`Error ${xhr.status}: ${xhr.responseText}`);
+                errorCallback();
+            }
+        };
+
+        xhr.send();
+    }
+
+    fetch(1);
+}
+
 /**
  * Fetches a single file chunk from the given URL
  * @param url
- * @param key
+ * @param stream - the ChunkStream for the file the chunk belongs to
+ * @param index - the 0-based index of the chunk in the file
+ * @param final - whether this is the last chunk in the file
  * @param successCallback
  * @param errorCallback
  */
 export const fetchSingleChunk = (
     url: string,
-    key: CryptoKey,
+    stream: crypto.ChunkStream,
+    index: number,
+    final: boolean,
     successCallback: (Uint8Array) => void,
     errorCallback: () => void,
 ) => {
@@ -267,7 +359,7 @@ export const fetchSingleChunk = (
 
         response.arrayBuffer().then(buf => {
             let data = new Uint8Array(buf);
-            crypto.decryptChunk(key, data).then(decryptedChunk => {
+            stream.decrypt(data, index, final).then(decryptedChunk => {
                 successCallback(decryptedChunk);
             }).catch(err => {
                 console.error(err);
@@ -335,7 +427,7 @@ export const removeUserFromShared = (id, shareID, isFolder) => {
  * @param canModify {boolean} - Whether the recipient can modify/delete the file/folder
  * @param isFolder {boolean} - An indicator of what type of content is being shared
  */
-export const shareItem = (recipient, rawKey, itemID, canModify, isFolder): Promise<interfaces.ShareInfo> => {
+export const shareItem = (recipient, rawKey, itemID, canModify, expiration, isFolder): Promise<interfaces.ShareInfo> => {
     let endpoint = isFolder ?
         Endpoints.format(Endpoints.ShareFolder, itemID) :
         Endpoints.format(Endpoints.ShareFile, itemID);
@@ -357,7 +449,7 @@ export const shareItem = (recipient, rawKey, itemID, canModify, isFolder): Promi
                     return;
                 }
 
-                let userEncItemKey = await crypto.encryptRSA(userPubKey, new Uint8Array(rawKey));
+                let userEncItemKey = await crypto.encryptWithPublicKey(userPubKey, new Uint8Array(rawKey));
                 fetch(endpoint, {
                     method: "POST",
                     headers: {
@@ -367,13 +459,14 @@ export const shareItem = (recipient, rawKey, itemID, canModify, isFolder): Promi
                         user: recipient,
                         protectedKey: Array.from(userEncItemKey),
                         canModify: canModify,
+                        expiration: expiration,
                     })
-                }).then(response => {
+                }).then(async response => {
                     if (!response.ok) {
                         alert("Error sharing content with user");
                         reject();
                     } else {
-                        resolve(new interfaces.ShareInfo(response));
+                        resolve(new interfaces.ShareInfo(await response.json()));
                     }
                 });
             });
@@ -435,6 +528,16 @@ export const uploadVaultMetadata = (
     uploadMetadata(metadata, Endpoints.UploadVaultFileMetadata, callback, errorCallback);
 }
 
+export const uploadFileRequestMetadata = (
+    requestID: string,
+    metadata: interfaces.VaultUpload,
+    callback: (id: string) => void,
+    errorCallback: () => void,
+) => {
+    let endpoint = {path: Endpoints.format(Endpoints.UploadFileRequest, requestID)};
+    uploadMetadata(metadata, endpoint, callback, errorCallback);
+}
+
 export const uploadSendChunks = async (id, file, key, callback, errorCallback) => {
     await uploadChunks(Endpoints.UploadSendFileData, id, file, key, callback, errorCallback);
 }
@@ -443,6 +546,13 @@ export const uploadVaultChunks = async (id, file, key, callback, errorCallback)
     await uploadChunks(Endpoints.UploadVaultFileData, id, file, key, callback, errorCallback);
 }
 
+export const uploadFileRequestChunks = async (requestID, id, file, key, callback, errorCallback) => {
+    // File request uploads don't have a session, so the request ID is used to
+    // authorize uploading chunks for the new vault item
+    let endpoint = {path: `${Endpoints.UploadVaultFileData.path}?request=${requestID}`};
+    await uploadChunks(endpoint, id, file, key, callback, errorCallback);
+}
+
 export const downloadVaultFile = (
     name: string,
     download: interfaces.VaultDownloadResponse,
@@ -5,6 +5,7 @@ import {PasswordGeneratorDialog} from "./dialogs/password_generator.js";
 import {VaultPassDialog} from "./dialogs/vault_pass.js";
 import {ProtectedVaultDialog} from "./dialogs/protected_vault.js";
 import {ShareContentDialog} from "./dialogs/share_item.js";
+import {ShareInvitationsDialog} from "./dialogs/share_invitations.js";
 import * as transfer from "./transfer.js";
 import * as constants from "./constants.js";
 import {Endpoint, Endpoints} from "./endpoints.js";
@@ -169,6 +170,67 @@ export class VaultView {
         vaultFileInput.addEventListener("click touchstart", () => {
             vaultFileInput.value = "";
         });
+
+        this.setupRequestDialog();
+    }
+
+    /**
+     * Sets up the dialog for creating file request links for the current folder
+     */
+    setupRequestDialog = () => {
+        let requestBtn = document.getElementById("new-file-request") as HTMLButtonElement;
+        let dialog = document.getElementById("request-dialog") as HTMLDialogElement;
+        let maxUploads = document.getElementById("request-max-uploads") as HTMLInputElement;
+        let maxSize = document.getElementById("request-max-size") as HTMLInputElement;
+        let expiration = document.getElementById("request-expiration") as HTMLInputElement;
+        let link = document.getElementById("request-link") as HTMLAnchorElement;
+        let createBtn = document.getElementById("create-request") as HTMLButtonElement;
+
+        requestBtn.addEventListener("click", () => {
+            maxUploads.value = "";
+            maxSize.value = "";
+            expiration.value = "";
+            link.href = "";
+            link.textContent = "";
+            createBtn.disabled = false;
+            dialog.showModal();
+        });
+
+        createBtn.addEventListener("click", () => {
+            let request = new interfaces.NewFileRequest();
+            request.folderID = this.folderID;
+            request.maxUploads = parseInt(maxUploads.value) || 0;
+            request.maxSize = (parseInt(maxSize.value) || 0) * 1000 * 1000;
+            request.expiration = expiration.value ? `${parseInt(expiration.value)}d` : "";
+
+            createBtn.disabled = true;
+            fetch(Endpoints.FileRequests.path, {
+                method: "POST",
+                headers: {
+                    "Content-Type": "application/json",
+                },
+                body: JSON.stringify(request)
+            }).then(async response => {
+                if (!response.ok) {
+                    alert(`Error creating file request: ${await response.text()}`);
+                    createBtn.disabled = false;
+                    return;
+                }
+
+                let created = new interfaces.MetadataUploadResponse(await response.json());
+                let url = window.location.origin + Endpoints.format(Endpoints.HTMLFileRequest, created.id);
+                link.href = url;
+                link.textContent = url;
+            }).catch(error => {
+                console.error(error);
+                createBtn.disabled = false;
+            });
+        });
+
+        let closeBtn = document.getElementById("close-request") as HTMLButtonElement;
+        closeBtn.addEventListener("click", () => {
+            dialog.close();
+        });
     }
 
     /**
@@ -181,7 +243,7 @@ export class VaultView {
             this.passwordDialog.create(
                 this.folderID,
                 this.folderKey || this.publicKey,
-                this.folderKey ? crypto.encryptChunk : crypto.encryptRSA,
+                this.folderKey ? crypto.encryptChunk : crypto.encryptWithPublicKey,
                 this.uploadPassword);
         });
 
@@ -198,6 +260,18 @@ export class VaultView {
     setupVaultDialogs = () => {
         this.shareDialog = new ShareContentDialog();
         this.actionsDialog = new ActionsDialog(this.#actionsCallback);
+
+        let invitationsDialog = new ShareInvitationsDialog();
+        let invitationsBtn = document.getElementById("share-invitations") as HTMLButtonElement;
+        invitationsBtn.addEventListener("click", () => {
+            invitationsDialog.show(() => {
+                // Accepted items are added to the user's root folder
+                this.cache.remove("");
+                if (this.folderID === "") {
+                    this.loadFolder("");
+                }
+            });
+        });
     }
 
     /**
@@ -606,32 +680,64 @@ export class VaultView {
     }
 
     /**
-     * Decrypt encrypted file/folder data using either RSA (root folder) or AES
-     * (any subfolder)
+     * Decrypt encrypted file/folder data using either the user's private key
+     * (root folder) or AES (any subfolder)
      * @param data {Uint8Array} - The data to decrypt
      * @returns {Promise<Uint8Array>} - The decrypted chunk of data
      */
     decryptData = async (data: Uint8Array): Promise<Uint8Array> => {
         if (!this.folderKey) {
-            return await crypto.decryptRSA(this.privateKey, data);
+            return await crypto.decryptWithPrivateKey(this.privateKey, data);
         } else {
             return await crypto.decryptChunk(this.folderKey, data);
         }
     }
 
     /**
-     * Encrypt file/folder data using either RSA (root folder only) or AES (any
-     * subfolder)
+     * Encrypt file/folder data using either the user's public key (root folder
+     * only) or AES (any subfolder)
      * @param data {Uint8Array} - The data to encrypt
      */
     encryptData = async (data: Uint8Array): Promise<Uint8Array> => {
         if (!this.folderKey) {
-            return await crypto.encryptRSA(this.publicKey, data);
+            return await crypto.encryptWithPublicKey(this.publicKey, data);
         } else {
             return await crypto.encryptChunk(this.folderKey, data);
         }
     }
 
+    /**
+     * Re-wraps the key of a file that was uploaded to this folder via a file
+     * request. These keys are encrypted with the user's public key (since the
+     * uploader doesn't have access to the folder key), so they're decrypted
+     * and re-encrypted with the folder key before being updated on the server.
+     * @param item {interfaces.VaultItem} - The item with a pending key
+     * @returns {Promise<Uint8Array>} - The decrypted item key
+     */
+    rewrapPendingKey = async (item: interfaces.VaultItem): Promise<Uint8Array> => {
+        let itemKey = await crypto.decryptWithPrivateKey(this.privateKey, item.protectedKey);
+        let protectedKey = await this.encryptData(itemKey);
+
+        let modify = new ModifyVaultItem();
+        modify.protectedKey = protectedKey;
+        let response = await fetch(Endpoints.format(Endpoints.VaultFile, item.id), {
+            method: "PUT",
+            headers: {
+                "Content-Type": "application/json",
+            },
+            body: JSON.stringify(modify, jsonReplacer)
+        });
+
+        if (response.ok) {
+            item.protectedKey = protectedKey;
+            item.pendingKey = false;
+        } else {
+            console.error("Error updating file request item key");
+        }
+
+        return itemKey;
+    }
+
     /**
      * Download a vault file by file ID
      * @param id {string} - The ID of the file to download
@@ -822,7 +928,13 @@ export class VaultView {
 
         for (let i = 0; i < items.length; i++) {
             let item = items[i];
-            let itemKey = await this.decryptData(item.protectedKey);
+            let itemKey: Uint8Array;
+            if (item.pendingKey) {
+                itemKey = await this.rewrapPendingKey(item);
+            } else {
+                itemKey = await this.decryptData(item.protectedKey);
+            }
+
             let tmpKey = await crypto.importKey(itemKey);
             let decName = await crypto.decryptString(tmpKey, hexToBytes(item.name));
 
@@ -1039,10 +1151,12 @@ export class VaultView {
                 }
 
                 let bytes;
+                let stream = new crypto.ChunkStream(file.key);
                 const fetchChunk = (chunkNum: number) => {
                     let endpoint = Endpoints.DownloadVaultFileData;
                     let chunkURL = Endpoints.format(endpoint, metadata.id, `${chunkNum}`);
-                    transfer.fetchSingleChunk(chunkURL, file.key, chunk => {
+                    let final = chunkNum === metadata.chunks;
+                    transfer.fetchSingleChunk(chunkURL, stream, chunkNum - 1, final, chunk => {
                         if (!bytes) {
                             bytes = chunk;
                         } else {
@@ -1054,7 +1168,7 @@ export class VaultView {
                             bytes = combinedArray;
                         }
 
-                        if (chunkNum === metadata.chunks) {
+                        if (final) {
                             if (render.isNonTextFileType(file.decName)) {
                                 render.renderFileHTML(file.decName, bytes, (tag, url) => {
                                     html(tag);
@@ -0,0 +1,82 @@
+import {
+    WebAuthnAssertion,
+    WebAuthnAssertionOptions,
+    WebAuthnRegistration,
+    WebAuthnRegistrationOptions,
+} from "./interfaces.js";
+
+/**
+ * isSecurityKeySupported returns true if the browser supports WebAuthn
+ * @returns {boolean}
+ */
+export const isSecurityKeySupported = (): boolean => {
+    return window.PublicKeyCredential !== undefined && navigator.credentials !== undefined;
+}
+
+/**
+ * createSecurityKey prompts the user to register a new security key or passkey
+ * using the options returned by the server
+ * @param name {string} - the name of the new key
+ * @param options {WebAuthnRegistrationOptions} - the server's registration options
+ * @returns {Promise<WebAuthnRegistration>} the registration to send to the server
+ */
+export const createSecurityKey = async (
+    name: string,
+    options: WebAuthnRegistrationOptions,
+): Promise<WebAuthnRegistration> => {
+    let credential = await navigator.credentials.create({
+        publicKey: {
+            challenge: options.challenge,
+            rp: {id: options.rpId, name: options.rpName},
+            user: {
+                id: options.userId,
+                name: options.userName,
+                displayName: options.userName,
+            },
+            pubKeyCredParams: options.algorithms.map(alg => {
+                return {type: "public-key" as PublicKeyCredentialType, alg: alg};
+            }),
+            excludeCredentials: options.excludeCredentials.map(id => {
+                return {type: "public-key" as PublicKeyCredentialType, id: id};
+            }),
+            attestation: "none",
+            timeout: options.timeout,
+        },
+    }) as PublicKeyCredential;
+
+    let response = credential.response as AuthenticatorAttestationResponse;
+    let registration = new WebAuthnRegistration();
+    registration.name = name;
+    registration.clientDataJSON = new Uint8Array(response.clientDataJSON);
+    registration.attestationObject = new Uint8Array(response.attestationObject);
+    return registration;
+}
+
+/**
+ * getSecurityKeyAssertion prompts the user to sign the server's challenge with
+ * one of their registered security keys
+ * @param options {WebAuthnAssertionOptions} - the server's assertion options
+ * @returns {Promise<WebAuthnAssertion>} the assertion to include in the login request
+ */
+export const getSecurityKeyAssertion = async (
+    options: WebAuthnAssertionOptions,
+): Promise<WebAuthnAssertion> => {
+    let credential = await navigator.credentials.get({
+        publicKey: {
+            challenge: options.challenge,
+            rpId: options.rpId,
+            allowCredentials: options.allowCredentials.map(id => {
+                return {type: "public-key" as PublicKeyCredentialType, id: id};
+            }),
+            timeout: options.timeout,
+        },
+    }) as PublicKeyCredential;
+
+    let response = credential.response as AuthenticatorAssertionResponse;
+    let assertion = new WebAuthnAssertion();
+    assertion.credentialId = new Uint8Array(credential.rawId);
+    assertion.clientDataJSON = new Uint8Array(response.clientDataJSON);
+    assertion.authenticatorData = new Uint8Array(response.authenticatorData);
+    assertion.signature = new Uint8Array(response.signature);
+    return assertion;
+}